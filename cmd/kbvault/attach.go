@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// attachmentsDir is the storage prefix attachments are stored under, in a
+// directory per note
+const attachmentsDir = "attachments/"
+
+// attachmentsHeading is the section the attach command adds references
+// under
+const attachmentsHeading = "## Attachments"
+
+func newAttachCmd() *cobra.Command {
+	var linkOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "attach <note-id> <file>",
+		Short: "Attach a file to a note",
+		Long: `Store a file such as an image or PDF under attachments/<note-id>/ in the
+vault and add a reference to it to the "Attachments" section of the note,
+creating the section at the end of the note if it doesn't exist. Images are
+embedded; other files are linked. The file is streamed to storage and must
+not exceed the vault's max_file_size.
+
+With --link-only the file is not copied: the note references a URL as
+given, or a local file by its absolute path.
+
+Examples:
+  # Attach a diagram
+  kbvault attach 01HQ2X3Y4Z diagram.png
+
+  # Reference a paper without copying it into the vault
+  kbvault attach 01HQ2X3Y4Z https://example.com/paper.pdf --link-only`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeNoteIDs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			notes, err := listAllNotes(ctx, storageBackend)
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
+			}
+			n, err := newVaultNoteResolver(notes).ResolveByID(args[0])
+			if err != nil {
+				return err
+			}
+
+			file := args[1]
+			var name, target string
+			if linkOnly {
+				if name, target, err = externalAttachment(file); err != nil {
+					return err
+				}
+			} else {
+				name = filepath.Base(file)
+				key, err := storeAttachment(ctx, storageBackend, n.ID, file, cfg.Vault.MaxFileSize)
+				if err != nil {
+					return err
+				}
+				target = relativeLink(n.FilePath, key)
+			}
+
+			added, err := addNoteAttachment(ctx, cmd.ErrOrStderr(), storageBackend, n, name, target, time.Now())
+			if err != nil {
+				return err
+			}
+			if !added {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s already references %s\n", n.ID, target)
+			} else if linkOnly {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Linked %s from %s\n", target, n.ID)
+			} else {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Attached %s to %s\n", name, n.ID)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&linkOnly, "link-only", false, "Reference the file where it is instead of copying it into the vault")
+
+	return cmd
+}
+
+// storeAttachment streams file to attachments/<noteID>/ in storage and
+// returns its storage path. Files larger than maxSize, when it is set, and
+// names already used by another attachment of the note are rejected.
+func storeAttachment(ctx context.Context, storage types.StorageBackend, noteID, file string, maxSize int64) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", file)
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return "", fmt.Errorf("%s is %d bytes, which exceeds the vault's max_file_size of %d bytes", file, info.Size(), maxSize)
+	}
+
+	key := attachmentsDir + noteID + "/" + filepath.Base(file)
+	exists, err := storage.Exists(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %w", key, err)
+	}
+	if exists {
+		return "", fmt.Errorf("note %s already has an attachment named %s", noteID, filepath.Base(file))
+	}
+
+	if err := storage.WriteStream(ctx, key, f); err != nil {
+		return "", fmt.Errorf("failed to store attachment: %w", err)
+	}
+	return key, nil
+}
+
+// externalAttachment returns the name and link target of a file referenced
+// with --link-only: a URL as given, or a local file's absolute path
+func externalAttachment(file string) (string, string, error) {
+	if u, err := url.Parse(file); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		name := path.Base(u.Path)
+		if name == "/" || name == "." {
+			name = u.Host
+		}
+		return name, file, nil
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", file, err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return "", "", fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	return filepath.Base(abs), escapeLinkPath(filepath.ToSlash(abs)), nil
+}
+
+// relativeLink returns the link from the note at notePath to the storage
+// path target
+func relativeLink(notePath, target string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(notePath)), filepath.FromSlash(target))
+	if err != nil {
+		return escapeLinkPath("/" + target)
+	}
+	return escapeLinkPath(filepath.ToSlash(rel))
+}
+
+// escapeLinkPath escapes spaces and other characters that would end a
+// markdown link destination
+func escapeLinkPath(p string) string {
+	return (&url.URL{Path: p}).String()
+}
+
+// attachmentReference returns the markdown list item referencing an
+// attachment: an embedded image for image files, a link otherwise
+func attachmentReference(name, target string) string {
+	text := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(name)
+	reference := "[" + text + "](" + target + ")"
+	if strings.HasPrefix(types.ContentTypeByExtension(name), "image/") {
+		reference = "!" + reference
+	}
+	return "- " + reference
+}
+
+// addNoteAttachment adds a reference to target to the Attachments section
+// of n unless n already references it. It reports whether the note was
+// changed.
+func addNoteAttachment(ctx context.Context, warnings io.Writer, storage types.StorageBackend, n *types.Note, name, target string, now time.Time) (bool, error) {
+	original, err := storage.Read(ctx, n.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read note %s: %w", n.ID, err)
+	}
+
+	body := editableContent(original, false)
+	if strings.Contains(body, "]("+target+")") {
+		return false, nil
+	}
+
+	updated, err := applyNoteEdit(original, addSectionEntry(body, attachmentsHeading, attachmentReference(name, target)), false, now)
+	if err != nil {
+		return false, err
+	}
+
+	if err := storage.Write(ctx, n.FilePath, updated); err != nil {
+		return false, fmt.Errorf("failed to save note %s: %w", n.ID, err)
+	}
+	syncSearchIndex(ctx, warnings, storage, n.FilePath, false)
+	return true, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func runAttachCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newAttachCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// writeAttachmentFile writes a file to attach outside the vault
+func writeAttachmentFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, data, 0644))
+	return file
+}
+
+func TestAttachCmd(t *testing.T) {
+	dir := setupLinkTestVault(t)
+	png := []byte("\x89PNG\r\n\x1a\nimage data")
+
+	out, err := runAttachCmd(t, "a", writeAttachmentFile(t, "diagram.png", png))
+	require.NoError(t, err)
+	assert.Equal(t, "Attached diagram.png to a\n", out)
+
+	stored, err := os.ReadFile(filepath.Join(dir, "attachments", "a", "diagram.png"))
+	require.NoError(t, err)
+	assert.Equal(t, png, stored)
+
+	out, err = runAttachCmd(t, "a", writeAttachmentFile(t, "my paper.pdf", []byte("%PDF-1.7")))
+	require.NoError(t, err)
+	assert.Equal(t, "Attached my paper.pdf to a\n", out)
+	assert.FileExists(t, filepath.Join(dir, "attachments", "a", "my paper.pdf"))
+
+	fm, body := readLinkTestNote(t, dir, "a")
+	assert.Equal(t, "# Alpha\n\nBuilds on [[Gamma]].\n\n## Attachments\n\n"+
+		"- ![diagram.png](../attachments/a/diagram.png)\n"+
+		"- [my paper.pdf](../attachments/a/my%20paper.pdf)\n", body)
+	assert.Equal(t, []string{"go"}, fm.Tags)
+	assert.NotEqual(t, "2024-01-01T00:00:00Z", fm.Updated)
+
+	// The note isn't listed twice and the attachment isn't a note
+	notes := listNoteFiles(context.Background(), mustStorage(t))
+	assert.Len(t, notes, 3)
+
+	// Attachment names are unique per note
+	_, err = runAttachCmd(t, "a", writeAttachmentFile(t, "diagram.png", []byte("other")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already has an attachment named diagram.png")
+	stored, err = os.ReadFile(filepath.Join(dir, "attachments", "a", "diagram.png"))
+	require.NoError(t, err)
+	assert.Equal(t, png, stored)
+}
+
+func TestAttachCmd_LinkOnly(t *testing.T) {
+	dir := setupLinkTestVault(t)
+	file := writeAttachmentFile(t, "photo.jpg", []byte("jpeg"))
+
+	out, err := runAttachCmd(t, "b", file, "--link-only")
+	require.NoError(t, err)
+	assert.Equal(t, "Linked "+filepath.ToSlash(file)+" from b\n", out)
+
+	out, err = runAttachCmd(t, "b", "https://example.com/papers/paper.pdf", "--link-only")
+	require.NoError(t, err)
+	assert.Equal(t, "Linked https://example.com/papers/paper.pdf from b\n", out)
+
+	_, body := readLinkTestNote(t, dir, "b")
+	assert.Contains(t, body, "## Attachments\n\n- ![photo.jpg]("+filepath.ToSlash(file)+")\n"+
+		"- [paper.pdf](https://example.com/papers/paper.pdf)\n")
+
+	// Nothing was copied
+	_, err = os.Stat(filepath.Join(dir, "attachments"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Referencing the same file again changes nothing
+	out, err = runAttachCmd(t, "b", "https://example.com/papers/paper.pdf", "--link-only")
+	require.NoError(t, err)
+	assert.Equal(t, "b already references https://example.com/papers/paper.pdf\n", out)
+	_, after := readLinkTestNote(t, dir, "b")
+	assert.Equal(t, body, after)
+
+	_, err = runAttachCmd(t, "b", filepath.Join(t.TempDir(), "missing.pdf"), "--link-only")
+	assert.Error(t, err)
+}
+
+func TestAttachCmd_Errors(t *testing.T) {
+	dir := setupLinkTestVault(t)
+	currentConfig.Vault.MaxFileSize = 10
+
+	_, err := runAttachCmd(t, "a", writeAttachmentFile(t, "big.pdf", []byte(strings.Repeat("x", 11))))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the vault's max_file_size of 10 bytes")
+	_, err = os.Stat(filepath.Join(dir, "attachments"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = runAttachCmd(t, "missing", writeAttachmentFile(t, "small.pdf", []byte("x")))
+	require.Error(t, err)
+	assert.True(t, types.IsNotFoundError(err))
+
+	_, err = runAttachCmd(t, "a", t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is a directory")
+}
+
+// streamOnlyStorage rejects whole-file writes, so attachments must be
+// streamed
+type streamOnlyStorage struct {
+	types.StorageBackend
+	streamed []string
+}
+
+func (s *streamOnlyStorage) Write(ctx context.Context, path string, data []byte) error {
+	return errors.New("attachments must be streamed")
+}
+
+func (s *streamOnlyStorage) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	s.streamed = append(s.streamed, path)
+	return s.StorageBackend.WriteStream(ctx, path, reader)
+}
+
+func TestStoreAttachment_Streams(t *testing.T) {
+	setupLinkTestVault(t)
+	backend := &streamOnlyStorage{StorageBackend: mustStorage(t)}
+
+	key, err := storeAttachment(context.Background(), backend, "a", writeAttachmentFile(t, "data.bin", []byte("binary")), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "attachments/a/data.bin", key)
+	assert.Equal(t, []string{"attachments/a/data.bin"}, backend.streamed)
+
+	data, err := backend.Read(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "binary", string(data))
+}
+
+func TestRelativeLink(t *testing.T) {
+	assert.Equal(t, "../attachments/a/x.png", relativeLink("notes/a.md", "attachments/a/x.png"))
+	assert.Equal(t, "attachments/a/x.png", relativeLink("a.md", "attachments/a/x.png"))
+	assert.Equal(t, "../../attachments/a/my%20x.png", relativeLink("notes/daily/a.md", "attachments/a/my x.png"))
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/cache"
+)
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the storage cache",
+		Long: `Manage the disk cache that remote storage backends read through.
+
+Notes fetched from S3 or Azure are kept under storage.cache.disk.path and
+served from disk until they are older than ttl_hours. Changes made through
+kbvault invalidate the cached copy; clear the cache after editing the
+vault with other tools.`,
+	}
+
+	cmd.AddCommand(newCacheStatsCmd())
+	cmd.AddCommand(newCacheClearCmd())
+	cmd.AddCommand(newCacheWarmCmd())
+
+	return cmd
+}
+
+func newCacheStatsCmd() *cobra.Command {
+	var outputJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show cache hit rate and size",
+		Long: `Show how many reads were served from the cache, how many went to the
+storage backend, and how much disk space the cache uses for the current
+vault. Counts accumulate across runs until the cache is cleared.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withCache(cmd, func(c *cache.DiskCache) error {
+				stats := c.Stats()
+				if outputJSON {
+					encoder := json.NewEncoder(cmd.OutOrStdout())
+					encoder.SetIndent("", "  ")
+					return encoder.Encode(stats)
+				}
+				return outputCacheStats(cmd.OutOrStdout(), stats)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output stats as JSON")
+
+	return cmd
+}
+
+func newCacheClearCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove cached notes for the current vault",
+		Long: `Remove every cached note for the current vault and reset the cache
+statistics. Other vaults sharing the same cache path are not affected.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Entries left behind while caching was enabled are cleared even
+			// if it has since been turned off
+			if !storage.CacheEnabled(cfg.Storage) {
+				if err := cache.ClearNamespace(cfg.Storage.Cache.Disk, storage.CacheNamespace(cfg.Storage)); err != nil {
+					return err
+				}
+			} else if err := withCache(cmd, (*cache.DiskCache).Clear); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Cleared cache in %s\n", cfg.Storage.Cache.Disk.Path)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newCacheWarmCmd() *cobra.Command {
+	var concurrency int
+
+	cmd := &cobra.Command{
+		Use:   "warm",
+		Short: "Fetch every note into the cache",
+		Long: `Read every note from the storage backend and cache it, so the vault can
+be used offline or on a slow connection. Notes that are already cached and
+within the TTL are skipped.
+
+Examples:
+  kbvault cache warm
+  kbvault cache warm --concurrency 16`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			return withCache(cmd, func(c *cache.DiskCache) error {
+				paths := listNoteFiles(ctx, c)
+
+				result, err := c.Warm(ctx, paths, concurrency)
+				if err != nil {
+					return fmt.Errorf("failed to warm cache: %w", err)
+				}
+
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Cached %d notes (%d fetched, %d already cached)\n",
+					result.Fetched+result.Cached, result.Fetched, result.Cached)
+
+				if len(result.Failed) == 0 {
+					return nil
+				}
+
+				failed := make([]string, 0, len(result.Failed))
+				for path := range result.Failed {
+					failed = append(failed, path)
+				}
+				sort.Strings(failed)
+				for _, path := range failed {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to cache %s: %v\n", path, result.Failed[path])
+				}
+
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d note(s) could not be cached", len(result.Failed))
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&concurrency, "concurrency", cache.DefaultWarmConcurrency, "Number of notes to fetch in parallel")
+
+	return cmd
+}
+
+// withCache opens the storage backend for the current vault and runs fn on
+// its disk cache. It fails if caching is not enabled for the vault.
+func withCache(cmd *cobra.Command, fn func(*cache.DiskCache) error) error {
+	// Get profile-aware configuration
+	cfg := getConfig()
+	if cfg == nil {
+		return fmt.Errorf("configuration not initialized")
+	}
+
+	// Initialize storage backend
+	storageBackend, err := storage.CreateStorage(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() {
+		if closeErr := storageBackend.Close(); closeErr != nil {
+			// Log error but don't fail the command (ignore write errors)
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+		}
+	}()
+
+	c, ok := storageBackend.(*cache.DiskCache)
+	if !ok {
+		return fmt.Errorf("caching is not enabled for %s storage (see storage.cache in the configuration)", storageBackend.Type())
+	}
+
+	return fn(c)
+}
+
+func outputCacheStats(w io.Writer, stats cache.Stats) error {
+	var b strings.Builder
+
+	hitRate := 0.0
+	if total := stats.Hits + stats.Misses; total > 0 {
+		hitRate = float64(stats.Hits) / float64(total) * 100
+	}
+
+	fmt.Fprintf(&b, "Entries:    %d\n", stats.Entries)
+	fmt.Fprintf(&b, "Size:       %s\n", formatBytes(stats.SizeBytes))
+	fmt.Fprintf(&b, "Hits:       %d\n", stats.Hits)
+	fmt.Fprintf(&b, "Misses:     %d\n", stats.Misses)
+	fmt.Fprintf(&b, "Hit rate:   %.1f%%\n", hitRate)
+	fmt.Fprintf(&b, "Evictions:  %d\n", stats.Evictions)
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
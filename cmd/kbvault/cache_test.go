@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/cache"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// setupCacheTestConfig points the current config at a vault with caching
+// enabled and the given notes
+func setupCacheTestConfig(t *testing.T, notes map[string]string) {
+	t.Helper()
+
+	cacheDir := t.TempDir()
+	setupTestVault(t, notes, func(c *types.Config) {
+		c.Storage.Cache.Enabled = true
+		c.Storage.Cache.Disk.Path = cacheDir
+		c.Storage.Cache.Disk.CleanupIntervalHours = 0
+	})
+}
+
+func runCacheCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newCacheCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func cacheStatsJSON(t *testing.T) cache.Stats {
+	t.Helper()
+
+	out, err := runCacheCmd(t, "stats", "--json")
+	require.NoError(t, err)
+
+	var stats cache.Stats
+	require.NoError(t, json.Unmarshal([]byte(out), &stats))
+	return stats
+}
+
+func TestCacheWarmAndStats(t *testing.T) {
+	setupCacheTestConfig(t, map[string]string{
+		"notes/a.md":         "# A\n",
+		"notes/b.md":         "# B\n",
+		"notes/dailies/c.md": "# C\n",
+	})
+
+	out, err := runCacheCmd(t, "warm", "--concurrency", "2")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Cached 3 notes (3 fetched, 0 already cached)")
+
+	stats := cacheStatsJSON(t)
+	assert.Equal(t, 3, stats.Entries)
+	assert.Equal(t, int64(12), stats.SizeBytes)
+
+	// A second warm finds everything cached
+	out, err = runCacheCmd(t, "warm")
+	require.NoError(t, err)
+	assert.Contains(t, out, "(0 fetched, 3 already cached)")
+
+	out, err = runCacheCmd(t, "stats")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Entries:    3")
+	assert.Contains(t, out, "Hit rate:")
+}
+
+func TestCacheClear(t *testing.T) {
+	setupCacheTestConfig(t, map[string]string{"notes/a.md": "# A\n"})
+
+	_, err := runCacheCmd(t, "warm")
+	require.NoError(t, err)
+	require.Equal(t, 1, cacheStatsJSON(t).Entries)
+
+	out, err := runCacheCmd(t, "clear")
+	require.NoError(t, err)
+	assert.Contains(t, out, currentConfig.Storage.Cache.Disk.Path)
+	assert.Equal(t, cache.Stats{}, cacheStatsJSON(t))
+
+	// Clearing still works once caching has been turned off
+	_, err = runCacheCmd(t, "warm")
+	require.NoError(t, err)
+	currentConfig.Storage.Cache.Enabled = false
+	_, err = runCacheCmd(t, "clear")
+	require.NoError(t, err)
+	currentConfig.Storage.Cache.Enabled = true
+	assert.Equal(t, 0, cacheStatsJSON(t).Entries)
+}
+
+func TestCacheCmd_NotEnabled(t *testing.T) {
+	setupCacheTestConfig(t, nil)
+	currentConfig.Storage.Cache.Enabled = false
+
+	_, err := runCacheCmd(t, "stats")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "caching is not enabled")
+}
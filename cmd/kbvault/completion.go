@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
 func newCompletionCmd() *cobra.Command {
@@ -61,3 +69,69 @@ PowerShell:
 
 	return cmd
 }
+
+// completeNoteIDs returns a ValidArgsFunction completing the first count
+// arguments of a command as note IDs; later arguments get the shell's
+// default completion. Titles are shown as descriptions where the shell
+// supports them.
+func completeNoteIDs(count int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) >= count {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+
+		// The configuration is loaded by the root command's pre-run hook,
+		// which completion requests run too
+		config := getConfig()
+		if config == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		backend, err := storage.CreateStorage(config.Storage)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		defer func() { _ = backend.Close() }()
+
+		return noteIDCompletions(cmd.Context(), backend, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// noteIDCompletions returns the IDs of notes starting with prefix, sorted.
+// To stay fast on remote storage, no notes are read: IDs and titles come
+// from the saved search index, as of when it was last saved, or, without
+// one, IDs are taken from the note file names alone.
+func noteIDCompletions(ctx context.Context, backend types.StorageBackend, prefix string) []cobra.Completion {
+	titles := make(map[string]string)
+	engine := search.New(backend, searchOptions())
+	if loaded, err := engine.LoadIndex(ctx); err == nil && loaded {
+		for _, doc := range engine.Documents() {
+			if !engine.Ignored(doc.FilePath) {
+				titles[doc.ID] = doc.Title
+			}
+		}
+	} else {
+		_ = walkNoteFiles(ctx, backend, func(file string, _ *types.FileInfo) error {
+			titles[strings.TrimSuffix(path.Base(file), ".md")] = ""
+			return nil
+		})
+	}
+
+	ids := make([]string, 0, len(titles))
+	for id := range titles {
+		if strings.HasPrefix(id, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	completions := make([]cobra.Completion, len(ids))
+	for i, id := range ids {
+		completions[i] = id
+		if title := titles[id]; title != "" {
+			completions[i] = cobra.CompletionWithDesc(id, title)
+		}
+	}
+	return completions
+}
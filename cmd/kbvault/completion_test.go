@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// newCompletionVault configures a local vault holding a few notes and
+// returns its backend
+func newCompletionVault(t *testing.T) types.StorageBackend {
+	t.Helper()
+
+	setupTestVault(t, map[string]string{
+		"notes/01JA.md":               "---\nid: 01JA\ntitle: Alpha\n---\n\nFirst.\n",
+		"notes/01JB.md":               "---\nid: 01JB\ntitle: Beta\n---\n\nSecond.\n",
+		"notes/dailies/2024-01-15.md": "---\nid: 2024-01-15\ntitle: Monday\n---\n\nDaily.\n",
+		"notes/image.png":             "binary",
+	})
+	return mustStorage(t)
+}
+
+func TestNoteIDCompletions(t *testing.T) {
+	backend := newCompletionVault(t)
+	ctx := context.Background()
+
+	// Without a saved index, IDs come from the file names
+	recorder := &readRecorder{StorageBackend: backend}
+	assert.Equal(t, []cobra.Completion{"01JA", "01JB", "2024-01-15"}, noteIDCompletions(ctx, recorder, ""))
+	assert.Equal(t, []cobra.Completion{"01JA", "01JB"}, noteIDCompletions(ctx, recorder, "01"))
+	assert.Empty(t, noteIDCompletions(ctx, recorder, "zz"))
+	assert.Empty(t, recorder.read, "completion should not read notes")
+
+	// With one, titles are included
+	engine := search.New(backend, searchOptions())
+	require.NoError(t, engine.BuildIndex(ctx))
+	require.NoError(t, engine.SaveIndex(ctx))
+
+	assert.Equal(t, []cobra.Completion{"01JA\tAlpha", "01JB\tBeta"}, noteIDCompletions(ctx, backend, "01"))
+	assert.Equal(t, []cobra.Completion{"2024-01-15\tMonday"}, noteIDCompletions(ctx, backend, "2024"))
+}
+
+func TestNoteIDCompletions_Commands(t *testing.T) {
+	newCompletionVault(t)
+
+	complete := func(args ...string) []string {
+		t.Helper()
+
+		// Keep the test configuration rather than loading the user's
+		cmd := newRootCmd()
+		cmd.PersistentPreRunE = nil
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs(append([]string{cobra.ShellCompNoDescRequestCmd}, args...))
+		require.NoError(t, cmd.Execute())
+		return strings.Split(strings.TrimSpace(out.String()), "\n")
+	}
+
+	for _, name := range []string{"show", "edit", "delete", "history", "open", "attach"} {
+		assert.Equal(t, []string{"01JA", "01JB", ":4"}, complete(name, "01"), name)
+	}
+
+	// Both arguments of diff and link are note IDs
+	assert.Equal(t, []string{"01JB", ":4"}, complete("diff", "01JA", "01JB"))
+	assert.Equal(t, []string{"2024-01-15", ":4"}, complete("link", "01JA", "2"))
+
+	// The second argument of attach is a file
+	assert.Equal(t, []string{":0"}, complete("attach", "01JA", ""))
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -24,6 +32,8 @@ Allows viewing, setting, and validating configuration options.`,
 	cmd.AddCommand(newConfigSetCmd())
 	cmd.AddCommand(newConfigValidateCmd())
 	cmd.AddCommand(newConfigPathCmd())
+	cmd.AddCommand(newConfigStackCmd())
+	cmd.AddCommand(newConfigDiffCmd())
 
 	return cmd
 }
@@ -103,8 +113,16 @@ Supports nested keys using dot notation (e.g., server.http.port).`,
 				return nil
 			}
 
-			// Profile mode: save to profile manager
-			if err := profileManager.UpdateProfile(currentProfile, cfg); err != nil {
+			// Profile mode: apply the change to the unexpanded profile so that
+			// values resolved from ${VAR} references are not written to disk
+			rawCfg, err := profileManager.GetRawConfig(currentProfile)
+			if err != nil {
+				return fmt.Errorf("failed to load profile '%s': %w", currentProfile, err)
+			}
+			if err := setConfigValue(rawCfg, key, value); err != nil {
+				return fmt.Errorf("failed to set config value: %w", err)
+			}
+			if err := profileManager.UpdateProfile(currentProfile, rawCfg); err != nil {
 				return fmt.Errorf("failed to save configuration to profile '%s': %w", currentProfile, err)
 			}
 
@@ -117,31 +135,319 @@ Supports nested keys using dot notation (e.g., server.http.port).`,
 }
 
 func newConfigValidateCmd() *cobra.Command {
+	var (
+		all               bool
+		checkConnectivity bool
+		timeout           time.Duration
+	)
+
 	cmd := &cobra.Command{
-		Use:   "validate",
+		Use:   "validate [profile]",
 		Short: "Validate configuration",
-		Long:  `Validate the current configuration for correctness and completeness.`,
+		Long: `Validate the current configuration, a named profile, or every profile.
+
+Besides the configuration rules, validation checks that a local storage
+path is writable. With --check-connectivity, remote backends are also
+contacted with a health check. The command exits non-zero if any profile
+fails, which makes it suitable for CI.
+
+Examples:
+  kbvault config validate
+  kbvault config validate work --check-connectivity
+  kbvault config validate --all`,
+		Args: cobra.MaximumNArgs(1),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// A broken active profile is reported as a validation failure
+			// rather than aborting before the command runs
+			_ = initializeConfig()
+			return applyTimeout(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Get profile-aware configuration
-			cfg := getConfig()
-			if cfg == nil {
-				return fmt.Errorf("configuration not initialized")
+			if all && len(args) > 0 {
+				return fmt.Errorf("cannot combine a profile name with --all")
+			}
+
+			opts := validateOptions{
+				checkConnectivity: checkConnectivity,
+				timeout:           timeout,
 			}
 
-			// Validate configuration
-			if err := cfg.Validate(); err != nil {
-				fmt.Printf("❌ Configuration validation failed:\n%v\n", err)
+			var results []profileValidation
+			switch {
+			case all || len(args) > 0:
+				pm, err := config.NewProfileManager()
+				if err != nil {
+					return fmt.Errorf("failed to initialize profile manager: %w", err)
+				}
+				pm.SetStrictEnv(globalFlags.StrictEnv)
+
+				names, err := pm.ListProfileNames()
+				if err != nil {
+					return fmt.Errorf("failed to list profiles: %w", err)
+				}
+				if !all {
+					if !slices.Contains(names, args[0]) {
+						return fmt.Errorf("profile '%s' does not exist", args[0])
+					}
+					names = args
+				}
+
+				for _, name := range names {
+					cfg, err := pm.GetConfig(name)
+					if err != nil {
+						results = append(results, profileValidation{Profile: name, Err: err})
+						continue
+					}
+					results = append(results, profileValidation{
+						Profile: name,
+						Err:     validateConfigDeep(cmd.Context(), cfg, opts),
+					})
+				}
+
+			default:
+				result := profileValidation{Profile: getProfile()}
+				if cfg := getConfig(); cfg != nil {
+					result.Err = validateConfigDeep(cmd.Context(), cfg, opts)
+				} else if err := initializeConfig(); err != nil {
+					result.Err = err
+				}
+				if result.Profile == "" {
+					result.Profile = "default"
+				}
+				results = append(results, result)
+			}
+
+			if err := printValidationResults(cmd.OutOrStdout(), results); err != nil {
 				return err
 			}
 
-			fmt.Println("✅ Configuration is valid")
+			failed := 0
+			for _, r := range results {
+				if r.Err != nil {
+					failed++
+				}
+			}
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d profile(s) failed validation", failed, len(results))
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&all, "all", false, "Validate every profile")
+	cmd.Flags().BoolVar(&checkConnectivity, "check-connectivity", false,
+		"Run a health check against remote storage backends")
+	cmd.Flags().DurationVar(&timeout, "check-timeout", 10*time.Second, "Timeout for each connectivity check")
+
 	return cmd
 }
 
+// validateOptions controls the checks performed beyond Config.Validate
+type validateOptions struct {
+	checkConnectivity bool
+	timeout           time.Duration
+}
+
+// profileValidation is the validation outcome for one profile
+type profileValidation struct {
+	Profile string
+	Err     error
+}
+
+// validateConfigDeep runs Config.Validate followed by backend-specific checks
+func validateConfigDeep(ctx context.Context, cfg *types.Config, opts validateOptions) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	switch cfg.Storage.Type {
+	case types.StorageTypeLocal:
+		return checkLocalPathWritable(cfg.Storage.Local.Path, cfg.Storage.Local.CreateDirs)
+	default:
+		if !opts.checkConnectivity {
+			return nil
+		}
+		return checkStorageConnectivity(ctx, cfg.Storage, opts.timeout)
+	}
+}
+
+// checkLocalPathWritable verifies that files can be created in the vault path.
+// A missing path is accepted when create_dirs is set and its nearest existing
+// parent is writable.
+func checkLocalPathWritable(path string, createDirs bool) error {
+	dir := path
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("storage path %s is not a directory", dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("cannot access storage path %s: %w", dir, err)
+		}
+		if !createDirs {
+			return fmt.Errorf("storage path %s does not exist", path)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("storage path %s does not exist", path)
+		}
+		dir = parent
+	}
+
+	probe, err := os.CreateTemp(dir, ".kbvault-validate-*")
+	if err != nil {
+		return fmt.Errorf("storage path %s is not writable: %w", dir, err)
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+
+	return nil
+}
+
+// newStorageBackend creates storage backends for validation. Tests replace
+// it to simulate unreachable storage.
+var newStorageBackend = storage.CreateStorage
+
+// checkStorageConnectivity opens the storage backend and runs its health check
+func checkStorageConnectivity(ctx context.Context, cfg types.StorageConfig, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	backend, err := newStorageBackend(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	if err := backend.Health(ctx); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	return nil
+}
+
+// printValidationResults writes a per-profile pass/fail table
+func printValidationResults(out io.Writer, results []profileValidation) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "PROFILE\tSTATUS\tDETAILS")
+	for _, r := range results {
+		status, details := "PASS", ""
+		if r.Err != nil {
+			status, details = "FAIL", strings.ReplaceAll(r.Err.Error(), "\n", "; ")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", r.Profile, status, details)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+func newConfigDiffCmd() *cobra.Command {
+	var (
+		allKeys        bool
+		outputJSON     bool
+		includeSecrets bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <profile-a> <profile-b>",
+		Short: "Compare the configuration of two profiles",
+		Long: `Show the configuration keys whose values differ between two profiles,
+with each profile's value. Lists and tables are shown as JSON. Credentials
+such as storage.s3.secret_access_key are left out unless --include-secrets
+is given.
+
+Examples:
+  kbvault config diff work personal
+  kbvault config diff work personal --all-keys
+  kbvault config diff work personal --json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nameA, nameB := args[0], args[1]
+
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize profile manager: %w", err)
+			}
+			pm.SetStrictEnv(globalFlags.StrictEnv)
+
+			names, err := pm.ListProfileNames()
+			if err != nil {
+				return fmt.Errorf("failed to list profiles: %w", err)
+			}
+			configs := make([]*types.Config, 2)
+			for i, name := range args {
+				if !slices.Contains(names, name) {
+					return fmt.Errorf("profile '%s' does not exist", name)
+				}
+				if configs[i], err = pm.GetConfig(name); err != nil {
+					return fmt.Errorf("failed to load profile %s: %w", name, err)
+				}
+			}
+
+			differences := config.DiffConfigs(configs[0], configs[1], allKeys, includeSecrets)
+			out := cmd.OutOrStdout()
+			if outputJSON {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(struct {
+					A           string                    `json:"a"`
+					B           string                    `json:"b"`
+					Differences []config.ConfigDifference `json:"differences"`
+				}{nameA, nameB, differences})
+			}
+			return printConfigDiff(out, nameA, nameB, differences)
+		},
+	}
+
+	cmd.Flags().BoolVar(&allKeys, "all-keys", false, "Include keys with the same value in both profiles")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&includeSecrets, "include-secrets", false, "Compare credentials too")
+
+	return cmd
+}
+
+// printConfigDiff prints config diff results as a table, marking keys
+// that differ with *
+func printConfigDiff(out io.Writer, nameA, nameB string, differences []config.ConfigDifference) error {
+	if len(differences) == 0 {
+		_, _ = fmt.Fprintf(out, "Profiles %s and %s have the same configuration\n", nameA, nameB)
+		return nil
+	}
+
+	display := func(value string) string {
+		if value == "" {
+			return `""`
+		}
+		return value
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "  KEY\t%s\t%s\n", strings.ToUpper(nameA), strings.ToUpper(nameB))
+	for _, diff := range differences {
+		marker := "*"
+		if diff.Equal {
+			marker = " "
+		}
+		_, _ = fmt.Fprintf(w, "%s %s\t%s\t%s\n", marker, diff.Key, display(diff.A), display(diff.B))
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
 func newConfigPathCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "path",
@@ -175,6 +481,32 @@ func newConfigPathCmd() *cobra.Command {
 	return cmd
 }
 
+func newConfigStackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stack",
+		Short: "Show the storage layers built from the configuration",
+		Long: `Show the layers storage is built from for the current profile, outermost
+first and ending with the backend. Use it to check whether reads go through
+the disk cache and how many attempts the backend client makes.
+
+Example output:
+  cache(disk) -> s3(retry x3)`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(storage.DescribeStack(cfg.Storage), " -> "))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
 func showAllConfig(config *types.Config, format string) error {
 	switch format {
 	case "json":
@@ -271,6 +603,10 @@ func showConfigKey(config *types.Config, key string) error {
 			fmt.Println(config.Vault.NotesDir)
 		} else if parts[1] == "max_file_size" {
 			fmt.Println(config.Vault.MaxFileSize)
+		} else if parts[1] == "title_source" {
+			fmt.Println(config.Vault.TitleSource)
+		} else if parts[1] == "id_scheme" {
+			fmt.Println(config.Vault.IDScheme)
 		} else {
 			return fmt.Errorf("unknown vault key: %s", parts[1])
 		}
@@ -285,6 +621,8 @@ func showConfigKey(config *types.Config, key string) error {
 				fmt.Println(config.Storage.Local.Path)
 			case "lock_timeout":
 				fmt.Println(config.Storage.Local.LockTimeout)
+			case "version_retention":
+				fmt.Println(config.Storage.Local.VersionRetention)
 			default:
 				return fmt.Errorf("unknown storage.local key: %s", parts[2])
 			}
@@ -311,6 +649,8 @@ func showConfigKey(config *types.Config, key string) error {
 				fmt.Println(config.Server.HTTP.Port)
 			case "read_timeout":
 				fmt.Println(config.Server.HTTP.ReadTimeout)
+			case "enable_metrics":
+				fmt.Println(config.Server.HTTP.EnableMetrics)
 			default:
 				return fmt.Errorf("unknown server.http key: %s", parts[2])
 			}
@@ -334,6 +674,17 @@ func setConfigValue(config *types.Config, key, value string) error {
 			config.Vault.Name = value
 		} else if len(parts) == 2 && parts[1] == "notes_dir" {
 			config.Vault.NotesDir = value
+		} else if len(parts) == 2 && parts[1] == "title_source" {
+			if _, err := types.ParseTitleSources(value); err != nil {
+				return err
+			}
+			config.Vault.TitleSource = value
+		} else if len(parts) == 2 && parts[1] == "id_scheme" {
+			scheme, err := types.ParseIDScheme(value)
+			if err != nil {
+				return err
+			}
+			config.Vault.IDScheme = string(scheme)
 		} else {
 			return fmt.Errorf("unknown vault key: %s", strings.Join(parts[1:], "."))
 		}
@@ -348,6 +699,12 @@ func setConfigValue(config *types.Config, key, value string) error {
 			switch parts[2] {
 			case "host":
 				config.Server.HTTP.Host = value
+			case "enable_metrics":
+				enabled, err := strconv.ParseBool(value)
+				if err != nil {
+					return fmt.Errorf("invalid value for %s: %q is not a boolean", key, value)
+				}
+				config.Server.HTTP.EnableMetrics = enabled
 			default:
 				return fmt.Errorf("setting %s not supported yet", key)
 			}
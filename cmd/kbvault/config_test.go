@@ -1,8 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -179,6 +186,36 @@ func TestSetConfigValue(t *testing.T) {
 				return cfg.Vault.Name == "new-vault-name"
 			},
 		},
+		{
+			name:    "set_vault_title_source",
+			key:     "vault.title_source",
+			value:   "heading,frontmatter",
+			wantErr: false,
+			check: func(cfg *types.Config) bool {
+				return cfg.Vault.TitleSource == "heading,frontmatter"
+			},
+		},
+		{
+			name:    "set_invalid_title_source",
+			key:     "vault.title_source",
+			value:   "heading,body",
+			wantErr: true,
+		},
+		{
+			name:    "set_vault_id_scheme",
+			key:     "vault.id_scheme",
+			value:   "Slug-Timestamp",
+			wantErr: false,
+			check: func(cfg *types.Config) bool {
+				return cfg.Vault.IDScheme == "slug-timestamp"
+			},
+		},
+		{
+			name:    "set_invalid_id_scheme",
+			key:     "vault.id_scheme",
+			value:   "uuid",
+			wantErr: true,
+		},
 		{
 			name:    "set_vault_notes_dir",
 			key:     "vault.notes_dir",
@@ -206,6 +243,22 @@ func TestSetConfigValue(t *testing.T) {
 				return cfg.Server.HTTP.Host == "0.0.0.0"
 			},
 		},
+		{
+			name:    "set_server_http_enable_metrics",
+			key:     "server.http.enable_metrics",
+			value:   "true",
+			wantErr: false,
+			check: func(cfg *types.Config) bool {
+				return cfg.Server.HTTP.EnableMetrics
+			},
+		},
+		{
+			name:    "set_invalid_enable_metrics",
+			key:     "server.http.enable_metrics",
+			value:   "sometimes",
+			wantErr: true,
+			check:   nil,
+		},
 		{
 			name:    "invalid_vault_key",
 			key:     "vault.invalid",
@@ -258,3 +311,231 @@ func TestSetConfigValue(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckLocalPathWritable(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(filePath, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		createDirs bool
+		wantErr    string
+	}{
+		{"existing directory", tmpDir, false, ""},
+		{"missing with create_dirs", filepath.Join(tmpDir, "a", "b"), true, ""},
+		{"missing without create_dirs", filepath.Join(tmpDir, "missing"), false, "does not exist"},
+		{"path is a file", filePath, false, "not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLocalPathWritable(tt.path, tt.createDirs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkLocalPathWritable() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkLocalPathWritable() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The probe file must not be left behind
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the fixture file in %s, found %d entries", tmpDir, len(entries))
+	}
+}
+
+func TestValidateConfigDeep(t *testing.T) {
+	cfg := types.DefaultConfig()
+	cfg.Storage.Local.Path = t.TempDir()
+	if err := validateConfigDeep(context.Background(), cfg, validateOptions{}); err != nil {
+		t.Errorf("validateConfigDeep() error = %v", err)
+	}
+
+	cfg.Vault.Name = ""
+	if err := validateConfigDeep(context.Background(), cfg, validateOptions{}); err == nil {
+		t.Error("validateConfigDeep() expected error for empty vault name")
+	}
+
+	// Remote backends are only contacted when connectivity checks are requested
+	s3Cfg := types.DefaultConfig()
+	s3Cfg.Storage.Type = types.StorageTypeS3
+	s3Cfg.Storage.S3.Bucket = "bucket"
+	s3Cfg.Storage.S3.Region = "us-east-1"
+	if err := validateConfigDeep(context.Background(), s3Cfg, validateOptions{}); err != nil {
+		t.Errorf("validateConfigDeep() error = %v", err)
+	}
+}
+
+func TestConfigValidateCmd_All(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	pm, err := config.NewProfileManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	good := types.DefaultConfig()
+	good.Storage.Local.Path = filepath.Join(tmpDir, "good")
+	if err := pm.UpdateProfile("good", good); err != nil {
+		t.Fatal(err)
+	}
+
+	bad := types.DefaultConfig()
+	bad.Storage.Local.Path = filepath.Join(tmpDir, "missing")
+	bad.Storage.Local.CreateDirs = false
+	if err := pm.UpdateProfile("bad", bad); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newConfigValidateCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	if err := cmd.Flags().Set("all", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.RunE(cmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 profile(s) failed validation") {
+		t.Errorf("expected one failing profile, got error %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"PROFILE", "good", "PASS", "bad", "FAIL", "does not exist"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestConfigValidateCmd_UnknownProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cmd := newConfigValidateCmd()
+	err := cmd.RunE(cmd, []string{"nope"})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected missing profile error, got %v", err)
+	}
+}
+
+func TestConfigStackCmd(t *testing.T) {
+	useTestConfig(t, func(c *types.Config) {
+		c.Storage.Type = types.StorageTypeS3
+		c.Storage.S3.RetryAttempts = 3
+		c.Storage.Cache.AutoEnable = true
+		c.Storage.Cache.Disk.Enabled = true
+	})
+
+	cmd := newConfigStackCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("stack failed: %v", err)
+	}
+	if got, want := out.String(), "cache(disk) -> s3(retry x3)\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestConfigDiffCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	pm, err := config.NewProfileManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	work := types.DefaultConfig()
+	work.Vault.Name = "work-kb"
+	work.Storage.Type = types.StorageTypeS3
+	work.Storage.S3.Bucket = "work-kb"
+	work.Storage.S3.Region = "us-east-1"
+	work.Storage.S3.SecretAccessKey = "work-secret"
+	if err := pm.UpdateProfile("work", work); err != nil {
+		t.Fatal(err)
+	}
+
+	personal := types.DefaultConfig()
+	personal.Vault.Name = "personal-kb"
+	personal.Storage.Local.Path = filepath.Join(tmpDir, "personal")
+	if err := pm.UpdateProfile("personal", personal); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := newConfigDiffCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("diff %v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	output := run("work", "personal")
+	for _, want := range []string{"KEY", "WORK", "PERSONAL", "storage.type", "s3", "local", "vault.name", "work-kb", "personal-kb", "storage.s3.bucket"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"vault.notes_dir", "secret_access_key", "work-secret"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("output contains %q:\n%s", unwanted, output)
+		}
+	}
+
+	if output := run("work", "personal", "--all-keys"); !strings.Contains(output, "vault.notes_dir") {
+		t.Errorf("--all-keys output missing equal keys:\n%s", output)
+	}
+
+	var result struct {
+		A           string                    `json:"a"`
+		B           string                    `json:"b"`
+		Differences []config.ConfigDifference `json:"differences"`
+	}
+	if err := json.Unmarshal([]byte(run("work", "personal", "--json")), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.A != "work" || result.B != "personal" {
+		t.Errorf("profiles = %q, %q", result.A, result.B)
+	}
+	found := false
+	for _, diff := range result.Differences {
+		if diff.Key == "storage.type" {
+			found = true
+			if diff.A != "s3" || diff.B != "local" {
+				t.Errorf("storage.type = %q, %q, want s3, local", diff.A, diff.B)
+			}
+		}
+		if diff.Equal {
+			t.Errorf("unexpected equal key %s", diff.Key)
+		}
+	}
+	if !found {
+		t.Errorf("storage.type missing from %+v", result.Differences)
+	}
+
+	if output := run("work", "work"); output != "Profiles work and work have the same configuration\n" {
+		t.Errorf("output = %q", output)
+	}
+
+	cmd := newConfigDiffCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"work", "nope"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "profile 'nope' does not exist") {
+		t.Errorf("expected missing profile error, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -13,7 +14,11 @@ import (
 )
 
 func newConfigureCmd() *cobra.Command {
-	var profileName string
+	var (
+		profileName string
+		validate    bool
+		force       bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "configure",
@@ -27,23 +32,26 @@ configuration options.
 Examples:
   kbvault configure                    # Configure default profile
   kbvault configure --profile work     # Configure work profile
-  kbvault configure --profile personal # Configure personal profile`,
+  kbvault configure --profile personal # Configure personal profile
+  kbvault configure --validate         # Check storage access before saving`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Skip initialization for configure command to avoid circular dependency
-			return nil
+			return applyTimeout(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runConfigure(profileName)
+			return runConfigure(cmd.Context(), profileName, validate, force)
 		},
 	}
 
 	cmd.Flags().StringVar(&profileName, "profile", "",
 		"Profile name to configure (creates new if doesn't exist, default: active profile)")
+	cmd.Flags().BoolVar(&validate, "validate", false, "Check that the storage backend is reachable before saving")
+	cmd.Flags().BoolVar(&force, "force", false, "Save the profile even if --validate fails")
 
 	return cmd
 }
 
-func runConfigure(profileName string) error {
+func runConfigure(ctx context.Context, profileName string, validate, force bool) error {
 	pm, err := config.NewProfileManager()
 	if err != nil {
 		return fmt.Errorf("failed to initialize profile manager: %w", err)
@@ -108,6 +116,13 @@ func runConfigure(profileName string) error {
 		}
 	}
 
+	if validate {
+		fmt.Println()
+		if err := checkProfileStorage(ctx, os.Stdout, currentConfig, force); err != nil {
+			return err
+		}
+	}
+
 	// Save configuration
 	if profileExists(pm, profileName) {
 		err = pm.UpdateProfile(profileName, currentConfig)
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
@@ -39,8 +40,11 @@ Examples:
 
   # Interactive mode for safer deletion
   kbvault delete note-123 --interactive`,
-		Args: cobra.ExactArgs(1),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNoteIDs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
 			// Get profile-aware configuration
 			cfg := getConfig()
 			if cfg == nil {
@@ -62,7 +66,7 @@ Examples:
 			query := args[0]
 
 			// Find notes to delete
-			notes, err := findNotesToDelete(storageBackend, query)
+			notes, err := findNotesToDelete(ctx, storageBackend, query)
 			if err != nil {
 				return err
 			}
@@ -88,7 +92,7 @@ Examples:
 			}
 
 			// Perform deletion
-			return deleteNotes(storageBackend, notes)
+			return deleteNotes(ctx, storageBackend, notes)
 		},
 	}
 
@@ -100,17 +104,17 @@ Examples:
 }
 
 // findNotesToDelete finds notes matching the query pattern
-func findNotesToDelete(storage types.StorageBackend, query string) ([]*types.Note, error) {
+func findNotesToDelete(ctx context.Context, storage types.StorageBackend, query string) ([]*types.Note, error) {
 	// Check if query has wildcards
 	if strings.Contains(query, "*") {
-		return findNotesByPattern(storage, query)
+		return findNotesByPattern(ctx, storage, query)
 	}
 
 	// Find single note by ID or title
-	note, err := findNoteByQuery(storage, query)
+	note, err := findNoteByQuery(ctx, storage, query)
 	if err != nil {
 		// Try partial matches
-		matches, err := findNotesByTitle(storage, query)
+		matches, err := findNotesByTitle(ctx, storage, query)
 		if err != nil {
 			return nil, err
 		}
@@ -136,8 +140,8 @@ func findNotesToDelete(storage types.StorageBackend, query string) ([]*types.Not
 }
 
 // findNotesByPattern finds notes matching a wildcard pattern
-func findNotesByPattern(storage types.StorageBackend, pattern string) ([]*types.Note, error) {
-	allNotes, err := listAllNotesGeneric(storage)
+func findNotesByPattern(ctx context.Context, storage types.StorageBackend, pattern string) ([]*types.Note, error) {
+	allNotes, err := listAllNotesGeneric(ctx, storage)
 	if err != nil {
 		return nil, err
 	}
@@ -150,7 +154,7 @@ func findNotesByPattern(storage types.StorageBackend, pattern string) ([]*types.
 		suffix := strings.TrimPrefix(pattern, "*")
 		for _, metadata := range allNotes {
 			if strings.HasSuffix(metadata.Title, suffix) || strings.HasSuffix(metadata.ID, suffix) {
-				note, err := loadNoteByID(storage, metadata.ID)
+				note, err := loadNoteByID(ctx, storage, metadata.ID)
 				if err == nil {
 					matches = append(matches, note)
 				}
@@ -160,7 +164,7 @@ func findNotesByPattern(storage types.StorageBackend, pattern string) ([]*types.
 		prefix := strings.TrimSuffix(pattern, "*")
 		for _, metadata := range allNotes {
 			if strings.HasPrefix(metadata.Title, prefix) || strings.HasPrefix(metadata.ID, prefix) {
-				note, err := loadNoteByID(storage, metadata.ID)
+				note, err := loadNoteByID(ctx, storage, metadata.ID)
 				if err == nil {
 					matches = append(matches, note)
 				}
@@ -171,7 +175,7 @@ func findNotesByPattern(storage types.StorageBackend, pattern string) ([]*types.
 		searchTerm := strings.ReplaceAll(pattern, "*", "")
 		for _, metadata := range allNotes {
 			if strings.Contains(metadata.Title, searchTerm) || strings.Contains(metadata.ID, searchTerm) {
-				note, err := loadNoteByID(storage, metadata.ID)
+				note, err := loadNoteByID(ctx, storage, metadata.ID)
 				if err == nil {
 					matches = append(matches, note)
 				}
@@ -287,20 +291,28 @@ func confirmInteractive(notes []*types.Note) bool {
 	return response == "y" || response == "yes"
 }
 
-// deleteNotes performs the actual deletion
-func deleteNotes(storage types.StorageBackend, notes []*types.Note) error {
+// deleteNotes performs the actual deletion, removing the notes in one batch
+// where storage supports it
+func deleteNotes(ctx context.Context, storage types.StorageBackend, notes []*types.Note) error {
 	var errors []string
 	deletedCount := 0
 
+	paths := make([]string, len(notes))
+	for i, note := range notes {
+		paths[i] = note.FilePath
+	}
+	failed := types.PathErrors(types.BatchDelete(ctx, storage, paths), paths)
+
 	for _, note := range notes {
 		fmt.Printf("Deleting '%s'...", note.Title)
 
-		if err := storage.Delete(context.TODO(), note.FilePath); err != nil {
+		if err := failed[note.FilePath]; err != nil {
 			fmt.Printf(" FAILED: %v\n", err)
 			errors = append(errors, fmt.Sprintf("%s: %v", note.Title, err))
 		} else {
 			fmt.Printf(" OK\n")
 			deletedCount++
+			syncSearchIndex(ctx, os.Stderr, storage, note.FilePath, true)
 		}
 	}
 
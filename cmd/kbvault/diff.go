@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+)
+
+// diffContextLines is how many unchanged lines surround each change
+const diffContextLines = 3
+
+// Diff output formats
+const (
+	diffFormatUnified = "unified"
+	diffFormatJSON    = "json"
+)
+
+// Diff line operations
+const (
+	diffOpContext = " "
+	diffOpAdd     = "+"
+	diffOpRemove  = "-"
+)
+
+// diffSide is one of the two texts being compared
+type diffSide struct {
+	// Name labels the text in the diff header
+	Name string
+	Text string
+}
+
+// diffLine is one line of a hunk
+type diffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// diffHunk is a run of changes with the unchanged lines around them. Starts
+// are 1-based line numbers.
+type diffHunk struct {
+	FromStart int        `json:"from_start"`
+	FromLines int        `json:"from_lines"`
+	ToStart   int        `json:"to_start"`
+	ToLines   int        `json:"to_lines"`
+	Lines     []diffLine `json:"lines"`
+}
+
+// noteDiff is the difference between two texts
+type noteDiff struct {
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	Identical bool       `json:"identical"`
+	Hunks     []diffHunk `json:"hunks"`
+}
+
+func newDiffCmd() *cobra.Command {
+	var (
+		format             string
+		includeFrontmatter bool
+		file               string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <note-id> [other-note-id]",
+		Short: "Show the differences between two notes",
+		Long: `Show a unified diff of two notes' bodies.
+
+With one note, the stored note is compared with its working copy: the file
+given by --file or, by default, the temporary file kbvault edit keeps when
+an edit can't be saved. This shows what the aborted edit changed.
+
+Frontmatter is left out of the comparison unless --include-frontmatter is
+given. With --format json the changes are printed as hunks of lines.
+
+Examples:
+  # Compare two notes
+  kbvault diff 01HQ2X3Y4Z 01HQ2X5B6C
+
+  # See the changes kept from a failed edit
+  kbvault diff 01HQ2X3Y4Z
+
+  # Compare a note with a draft, frontmatter included
+  kbvault diff 01HQ2X3Y4Z --file draft.md --include-frontmatter`,
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeNoteIDs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+			if format != diffFormatUnified && format != diffFormatJSON {
+				return fmt.Errorf("invalid --format %q: must be %s or %s", format, diffFormatUnified, diffFormatJSON)
+			}
+			if file != "" && len(args) == 2 {
+				return fmt.Errorf("--file compares a single note with a file; give one note ID")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			fromPath, fromData, err := findNoteData(ctx, storageBackend, args[0])
+			if err != nil {
+				return err
+			}
+			from := diffSide{Name: fromPath, Text: string(fromData)}
+
+			var to diffSide
+			if len(args) == 2 {
+				toPath, toData, err := findNoteData(ctx, storageBackend, args[1])
+				if err != nil {
+					return err
+				}
+				to = diffSide{Name: toPath, Text: string(toData)}
+			} else {
+				if file == "" {
+					file = editTempFile(parseNoteFromData(fromPath, fromData).ID)
+				}
+				data, err := os.ReadFile(file)
+				if errors.Is(err, fs.ErrNotExist) && !cmd.Flags().Changed("file") {
+					return fmt.Errorf("no working copy of %s found at %s; give another note ID or --file", fromPath, file)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to read working copy: %w", err)
+				}
+				to = diffSide{Name: file, Text: string(data)}
+			}
+
+			if !includeFrontmatter {
+				from.Text = note.StripFrontmatter(from.Text)
+				to.Text = note.StripFrontmatter(to.Text)
+			}
+
+			d := diffNotes(from, to)
+			out := cmd.OutOrStdout()
+			if format == diffFormatJSON {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(d)
+			}
+			return writeUnifiedDiff(out, d, newOutputStyle(out))
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", diffFormatUnified, "Output format (unified, json)")
+	cmd.Flags().BoolVar(&includeFrontmatter, "include-frontmatter", false, "Compare frontmatter as well as the body")
+	cmd.Flags().StringVar(&file, "file", "", "Compare the note with this file instead of its edit working copy")
+
+	return cmd
+}
+
+// diffNotes compares two texts line by line
+func diffNotes(from, to diffSide) *noteDiff {
+	a, b := diffLines(from.Text), diffLines(to.Text)
+	d := &noteDiff{From: from.Name, To: to.Name, Identical: from.Text == to.Text, Hunks: []diffHunk{}}
+	if d.Identical {
+		return d
+	}
+
+	matcher := difflib.NewMatcher(a, b)
+	for _, group := range matcher.GetGroupedOpCodes(diffContextLines) {
+		first, last := group[0], group[len(group)-1]
+		hunk := diffHunk{
+			FromStart: first.I1 + 1,
+			FromLines: last.I2 - first.I1,
+			ToStart:   first.J1 + 1,
+			ToLines:   last.J2 - first.J1,
+		}
+		for _, op := range group {
+			if op.Tag == 'e' {
+				for _, line := range a[op.I1:op.I2] {
+					hunk.Lines = append(hunk.Lines, diffLine{Op: diffOpContext, Text: line})
+				}
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				for _, line := range a[op.I1:op.I2] {
+					hunk.Lines = append(hunk.Lines, diffLine{Op: diffOpRemove, Text: line})
+				}
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				for _, line := range b[op.J1:op.J2] {
+					hunk.Lines = append(hunk.Lines, diffLine{Op: diffOpAdd, Text: line})
+				}
+			}
+		}
+		d.Hunks = append(d.Hunks, hunk)
+	}
+	return d
+}
+
+// diffLines splits text into lines without their line endings
+func diffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// writeUnifiedDiff prints d in unified diff format, with removed lines in
+// red, added lines in green and hunk headers in cyan in color output.
+// Identical texts print nothing.
+func writeUnifiedDiff(w io.Writer, d *noteDiff, style outputStyle) error {
+	if d.Identical {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString(style.bold("--- "+d.From) + "\n")
+	b.WriteString(style.bold("+++ "+d.To) + "\n")
+	for _, hunk := range d.Hunks {
+		header := fmt.Sprintf("@@ -%s +%s @@", diffRange(hunk.FromStart, hunk.FromLines), diffRange(hunk.ToStart, hunk.ToLines))
+		b.WriteString(style.cyan(header) + "\n")
+		for _, line := range hunk.Lines {
+			text := line.Op + line.Text
+			switch line.Op {
+			case diffOpAdd:
+				text = style.green(text)
+			case diffOpRemove:
+				text = style.red(text)
+			}
+			b.WriteString(text + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// diffRange formats a hunk's line range as unified diffs do: an empty range
+// starts at the line before it, and a length of one is left out
+func diffRange(start, lines int) string {
+	switch lines {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
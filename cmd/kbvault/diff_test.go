@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unifiedDiff renders the plain unified diff of two texts
+func unifiedDiff(t *testing.T, from, to string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	d := diffNotes(diffSide{Name: "a.md", Text: from}, diffSide{Name: "b.md", Text: to})
+	require.NoError(t, writeUnifiedDiff(&out, d, outputStyle{}))
+	return out.String()
+}
+
+func TestDiffNotes(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "identical",
+			from: "one\ntwo\n",
+			to:   "one\ntwo\n",
+			want: "",
+		},
+		{
+			name: "added lines",
+			from: "one\ntwo\n",
+			to:   "one\ntwo\nthree\nfour\n",
+			want: "--- a.md\n+++ b.md\n@@ -1,2 +1,4 @@\n one\n two\n+three\n+four\n",
+		},
+		{
+			name: "removed lines",
+			from: "one\ntwo\nthree\n",
+			to:   "one\nthree\n",
+			want: "--- a.md\n+++ b.md\n@@ -1,3 +1,2 @@\n one\n-two\n three\n",
+		},
+		{
+			name: "changed line",
+			from: "one\n",
+			to:   "uno\n",
+			want: "--- a.md\n+++ b.md\n@@ -1 +1 @@\n-one\n+uno\n",
+		},
+		{
+			name: "into empty",
+			from: "",
+			to:   "new\n",
+			want: "--- a.md\n+++ b.md\n@@ -0,0 +1 @@\n+new\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, unifiedDiff(t, tt.from, tt.to))
+		})
+	}
+}
+
+func TestDiffNotesColor(t *testing.T) {
+	var out bytes.Buffer
+	d := diffNotes(diffSide{Name: "a.md", Text: "one\n"}, diffSide{Name: "b.md", Text: "uno\n"})
+	require.NoError(t, writeUnifiedDiff(&out, d, outputStyle{color: true}))
+
+	assert.Contains(t, out.String(), ansiRed+"-one"+ansiReset)
+	assert.Contains(t, out.String(), ansiGreen+"+uno"+ansiReset)
+	assert.Contains(t, out.String(), ansiCyan+"@@ -1 +1 @@"+ansiReset)
+}
+
+// runDiffCmd runs the diff command against a local vault holding files
+func runDiffCmd(t *testing.T, files map[string]string, args ...string) (string, error) {
+	t.Helper()
+
+	setupTestVault(t, files)
+
+	var out bytes.Buffer
+	cmd := newDiffCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestDiffCmd(t *testing.T) {
+	files := map[string]string{
+		"notes/first.md":  "---\nid: first\ntitle: First\n---\n\nShared line.\nOnly in first.\n",
+		"notes/second.md": "---\nid: second\ntitle: Second\n---\n\nShared line.\nOnly in second.\n",
+	}
+
+	t.Run("two notes", func(t *testing.T) {
+		out, err := runDiffCmd(t, files, "first", "second")
+		require.NoError(t, err)
+		assert.Equal(t, "--- notes/first.md\n+++ notes/second.md\n@@ -1,2 +1,2 @@\n Shared line.\n-Only in first.\n+Only in second.\n", out)
+	})
+
+	t.Run("include frontmatter", func(t *testing.T) {
+		out, err := runDiffCmd(t, files, "first", "second", "--include-frontmatter")
+		require.NoError(t, err)
+		assert.Contains(t, out, "-id: first\n-title: First\n+id: second\n+title: Second\n")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := runDiffCmd(t, files, "first", "first", "--format", "json")
+		require.NoError(t, err)
+
+		var d noteDiff
+		require.NoError(t, json.Unmarshal([]byte(out), &d))
+		assert.True(t, d.Identical)
+		assert.Empty(t, d.Hunks)
+	})
+
+	t.Run("working copy", func(t *testing.T) {
+		draft := filepath.Join(t.TempDir(), "draft.md")
+		require.NoError(t, os.WriteFile(draft, []byte("Shared line.\nOnly in first.\nAdded.\n"), 0644))
+
+		out, err := runDiffCmd(t, files, "first", "--file", draft)
+		require.NoError(t, err)
+		assert.Contains(t, out, "+++ "+draft+"\n")
+		assert.Contains(t, out, " Only in first.\n+Added.\n")
+	})
+
+	t.Run("no working copy", func(t *testing.T) {
+		_, err := runDiffCmd(t, map[string]string{"notes/lonely-diff-note.md": "# Lonely\n"}, "lonely-diff-note")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no working copy")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := runDiffCmd(t, files, "first", "second", "--format", "side-by-side")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid --format")
+	})
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/links"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Kinds of problem reported by the doctor command
+const (
+	issueDuplicateID        = "duplicate_id"
+	issueIDMismatch         = "id_mismatch"
+	issueBrokenLink         = "broken_link"
+	issueFileTooLarge       = "file_too_large"
+	issueInvalidFrontmatter = "invalid_frontmatter"
+	issueSchemaViolation    = "schema_violation"
+)
+
+// doctorIssue is a single problem found in the vault
+type doctorIssue struct {
+	Kind    string `json:"kind"`
+	NoteID  string `json:"note_id"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+	Fixed   bool   `json:"fixed,omitempty"`
+}
+
+// doctorReport is the result of checking a vault
+type doctorReport struct {
+	NotesChecked int           `json:"notes_checked"`
+	Issues       []doctorIssue `json:"issues"`
+}
+
+// doctorOptions controls which checks run and whether fixes are applied
+type doctorOptions struct {
+	MaxFileSize int64
+	Schema      note.Schema
+	Fix         bool
+}
+
+// unresolved returns the number of issues that were not fixed
+func (r *doctorReport) unresolved() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if !issue.Fixed {
+			count++
+		}
+	}
+	return count
+}
+
+func newDoctorCmd() *cobra.Command {
+	var (
+		outputJSON bool
+		fix        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the vault for problems",
+		Long: `Scan the vault and report problems:
+
+  duplicate_id         Two files share a name and therefore a note ID
+  id_mismatch          The frontmatter id differs from the file name
+  broken_link          A link points to a note that doesn't exist
+  file_too_large       A note exceeds the vault's max_file_size
+  invalid_frontmatter  The frontmatter block is not valid YAML
+  schema_violation     The frontmatter lacks a field vault.frontmatter_schema
+                       requires, or has a value it doesn't allow
+
+--fix repairs the safe subset: frontmatter ids are rewritten to match the
+file name. Other problems are reported for manual attention.
+
+The command exits with a non-zero status if any problem remains, which
+makes it suitable for CI checks.
+
+Examples:
+  # Report problems
+  kbvault doctor
+
+  # Repair what can be fixed automatically
+  kbvault doctor --fix
+
+  # Machine-readable report
+  kbvault doctor --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			report, err := runDoctor(cmd.Context(), storageBackend, doctorOptions{
+				MaxFileSize: cfg.Vault.MaxFileSize,
+				Schema:      cfg.Vault.FrontmatterSchema,
+				Fix:         fix,
+			})
+			if err != nil {
+				return err
+			}
+
+			if outputJSON {
+				err = outputDoctorJSON(cmd.OutOrStdout(), report)
+			} else {
+				err = outputDoctorReport(cmd.OutOrStdout(), report)
+			}
+			if err != nil {
+				return err
+			}
+
+			if remaining := report.unresolved(); remaining > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d problem(s) found", remaining)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output the report as JSON")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Fix problems that can be repaired safely")
+
+	return cmd
+}
+
+// doctorFile is a note file with the raw data needed for checks and fixes
+type doctorFile struct {
+	note *types.Note
+	data []byte
+}
+
+// runDoctor checks every note file in the vault
+func runDoctor(ctx context.Context, storage types.StorageBackend, opts doctorOptions) (*doctorReport, error) {
+	var files []doctorFile
+	for _, path := range listNoteFiles(ctx, storage) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, err := storage.Read(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		files = append(files, doctorFile{note: note.ParseWithOptions(path, data, noteParseOptions()), data: data})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].note.FilePath < files[j].note.FilePath
+	})
+
+	report := &doctorReport{
+		NotesChecked: len(files),
+		Issues:       []doctorIssue{},
+	}
+
+	notes := make([]*types.Note, len(files))
+	paths := make(map[string][]string)
+	for i, file := range files {
+		notes[i] = file.note
+		paths[file.note.ID] = append(paths[file.note.ID], file.note.FilePath)
+	}
+
+	parser := links.New(newVaultNoteResolver(notes))
+
+	for _, file := range files {
+		n := file.note
+		issue := func(kind, message string) doctorIssue {
+			return doctorIssue{Kind: kind, NoteID: n.ID, Path: n.FilePath, Message: message}
+		}
+
+		if others := paths[n.ID]; len(others) > 1 {
+			report.Issues = append(report.Issues, issue(issueDuplicateID,
+				fmt.Sprintf("ID %s is shared by %s", n.ID, strings.Join(others, ", "))))
+		}
+
+		if opts.MaxFileSize > 0 && int64(len(file.data)) > opts.MaxFileSize {
+			report.Issues = append(report.Issues, issue(issueFileTooLarge,
+				fmt.Sprintf("file is %s, the limit is %s", formatBytes(int64(len(file.data))), formatBytes(opts.MaxFileSize))))
+		}
+
+		front, body, hasFrontmatter := note.SplitFrontmatter(string(file.data))
+		fm, fmErr := note.ParseFrontmatter(front)
+		if hasFrontmatter && fmErr != nil {
+			report.Issues = append(report.Issues, issue(issueInvalidFrontmatter, fmErr.Error()))
+		}
+
+		// Frontmatter that doesn't parse is reported above
+		if fmErr == nil {
+			var schemaErr *note.SchemaError
+			if errors.As(note.ValidateFrontmatter(fm, opts.Schema), &schemaErr) {
+				for _, violation := range schemaErr.Violations {
+					report.Issues = append(report.Issues, issue(issueSchemaViolation, violation.Message))
+				}
+			}
+		}
+
+		if id := n.Frontmatter.ID; id != n.ID {
+			mismatch := issue(issueIDMismatch, fmt.Sprintf("frontmatter id %q does not match file name", id))
+			// Only rewrite frontmatter that parses cleanly, so nothing is lost
+			mismatch.Fixable = hasFrontmatter && fmErr == nil
+			if opts.Fix && mismatch.Fixable {
+				fm.ID = n.ID
+				if err := storage.Write(ctx, n.FilePath, note.SerializeNote(fm, body)); err != nil {
+					return nil, fmt.Errorf("failed to fix %s: %w", n.FilePath, err)
+				}
+				mismatch.Fixed = true
+			}
+			report.Issues = append(report.Issues, mismatch)
+		}
+
+		broken, err := parser.FindBrokenLinks(n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse links for note %s: %w", n.ID, err)
+		}
+		for _, link := range broken {
+			report.Issues = append(report.Issues, issue(issueBrokenLink,
+				fmt.Sprintf("link %q does not resolve to a note", link.LinkText)))
+		}
+	}
+
+	return report, nil
+}
+
+func outputDoctorJSON(w io.Writer, report *doctorReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func outputDoctorReport(w io.Writer, report *doctorReport) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Checked %d notes\n", report.NotesChecked)
+	if len(report.Issues) == 0 {
+		b.WriteString("No problems found.\n")
+	} else {
+		b.WriteString("\n")
+		for _, issue := range report.Issues {
+			status := ""
+			switch {
+			case issue.Fixed:
+				status = " (fixed)"
+			case issue.Fixable:
+				status = " (fixable with --fix)"
+			}
+			fmt.Fprintf(&b, "%-20s %s: %s%s\n", issue.Kind, issue.Path, issue.Message, status)
+		}
+
+		fixed := len(report.Issues) - report.unresolved()
+		fmt.Fprintf(&b, "\n%d problem(s) found", len(report.Issues))
+		if fixed > 0 {
+			fmt.Fprintf(&b, ", %d fixed", fixed)
+		}
+		b.WriteString("\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// newDoctorTestVault writes files relative to a temporary vault directory
+// and returns local storage over it
+func newDoctorTestVault(t *testing.T, files map[string]string) (types.StorageBackend, string) {
+	t.Helper()
+
+	dir := setupTestVault(t, files)
+
+	backend, err := storage.CreateStorage(types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: dir, CreateDirs: true},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	return backend, dir
+}
+
+// issueKinds returns "kind path" for each issue in the report
+func issueKinds(report *doctorReport) []string {
+	var kinds []string
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind+" "+issue.Path)
+	}
+	return kinds
+}
+
+func TestRunDoctor(t *testing.T) {
+	backend, _ := newDoctorTestVault(t, map[string]string{
+		"notes/good.md":        "---\nid: good\ntitle: Good\n---\n\nLinks to [[Other]].\n",
+		"notes/other.md":       "# Other\n",
+		"notes/dup.md":         "# Dup in notes\n",
+		"notes/dailies/dup.md": "# Dup in daily\n",
+		"notes/wrong.md":       "---\nid: something-else\ntitle: Wrong\n---\n\nBody\n",
+		"notes/broken.md":      "---\ntitle: [unclosed\n---\n\nSee [[Nowhere]].\n",
+		"notes/big.md":         "# Big\n\n" + strings.Repeat("x", 200) + "\n",
+	})
+
+	report, err := runDoctor(context.Background(), backend, doctorOptions{MaxFileSize: 100})
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, report.NotesChecked)
+	assert.ElementsMatch(t, []string{
+		"duplicate_id notes/dailies/dup.md",
+		"duplicate_id notes/dup.md",
+		"file_too_large notes/big.md",
+		"invalid_frontmatter notes/broken.md",
+		"broken_link notes/broken.md",
+		"id_mismatch notes/wrong.md",
+	}, issueKinds(report))
+	assert.Equal(t, 6, report.unresolved())
+
+	for _, issue := range report.Issues {
+		assert.Equal(t, issue.Kind == issueIDMismatch, issue.Fixable, issue.Kind)
+	}
+}
+
+func TestRunDoctorFix(t *testing.T) {
+	backend, dir := newDoctorTestVault(t, map[string]string{
+		"notes/wrong.md": "---\nid: something-else\ntitle: Wrong\ntags:\n  - keep\n---\n\nBody\n",
+	})
+
+	report, err := runDoctor(context.Background(), backend, doctorOptions{Fix: true})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.True(t, report.Issues[0].Fixed)
+	assert.Equal(t, 0, report.unresolved())
+
+	data, err := os.ReadFile(filepath.Join(dir, "notes", "wrong.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "id: wrong\n")
+	assert.Contains(t, string(data), "  - keep\n")
+	assert.True(t, strings.HasSuffix(string(data), "---\n\nBody\n"))
+
+	// A second run finds nothing left to fix
+	report, err = runDoctor(context.Background(), backend, doctorOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+}
+
+func TestRunDoctorHealthyVault(t *testing.T) {
+	backend, _ := newDoctorTestVault(t, map[string]string{
+		"notes/a.md": "---\nid: a\ntitle: Alpha\n---\n\nSee [[Beta]] and [b](notes/b.md).\n",
+		"notes/b.md": "# Beta\n\nBack to [[a]].\n",
+	})
+
+	report, err := runDoctor(context.Background(), backend, doctorOptions{MaxFileSize: 1024})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.NotesChecked)
+	assert.Empty(t, report.Issues)
+}
+
+func TestRunDoctorSchema(t *testing.T) {
+	backend, _ := newDoctorTestVault(t, map[string]string{
+		"notes/ok.md":      "---\nid: ok\ntitle: OK\nauthor: alice\nstatus: draft\n---\n\nBody\n",
+		"notes/missing.md": "---\nid: missing\ntitle: Missing\n---\n\nBody\n",
+		"notes/status.md":  "---\nid: status\ntitle: Status\nauthor: bob\nstatus: wip\n---\n\nBody\n",
+	})
+
+	report, err := runDoctor(context.Background(), backend, doctorOptions{
+		Schema: note.Schema{
+			Required: []string{"author"},
+			Allowed:  map[string][]string{"status": {"draft", "published"}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"schema_violation notes/missing.md",
+		"schema_violation notes/status.md",
+	}, issueKinds(report))
+	for _, issue := range report.Issues {
+		assert.False(t, issue.Fixable)
+	}
+}
+
+func TestDoctorOutput(t *testing.T) {
+	report := &doctorReport{
+		NotesChecked: 3,
+		Issues: []doctorIssue{
+			{Kind: issueIDMismatch, NoteID: "a", Path: "notes/a.md", Message: "mismatch", Fixable: true, Fixed: true},
+			{Kind: issueBrokenLink, NoteID: "b", Path: "notes/b.md", Message: `link "x" does not resolve to a note`},
+		},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, outputDoctorReport(&text, report))
+	assert.Contains(t, text.String(), "Checked 3 notes")
+	assert.Contains(t, text.String(), "notes/a.md: mismatch (fixed)")
+	assert.Contains(t, text.String(), "2 problem(s) found, 1 fixed")
+
+	var out bytes.Buffer
+	require.NoError(t, outputDoctorJSON(&out, report))
+	var decoded doctorReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, report.Issues, decoded.Issues)
+
+	var empty bytes.Buffer
+	require.NoError(t, outputDoctorReport(&empty, &doctorReport{NotesChecked: 1}))
+	assert.Contains(t, empty.String(), "No problems found.")
+}
+
+func TestDoctorCmdExitStatus(t *testing.T) {
+	setupTestVault(t, map[string]string{
+		"notes/a.md": "# A\n\n[[Missing]]\n",
+	})
+
+	cmd := newDoctorCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 problem(s) found")
+	assert.Contains(t, out.String(), "broken_link")
+}
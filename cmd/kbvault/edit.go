@@ -3,11 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 	"github.com/spf13/cobra"
@@ -15,8 +19,14 @@ import (
 
 func newEditCmd() *cobra.Command {
 	var (
-		editor    string
-		createNew bool
+		editor          string
+		editorArgs      string
+		createNew       bool
+		editFrontmatter bool
+		contentFile     string
+		fromStdin       bool
+		lockSession     bool
+		force           bool
 	)
 
 	cmd := &cobra.Command{
@@ -27,6 +37,24 @@ func newEditCmd() *cobra.Command {
 You can specify the note by its ID or title. If multiple notes match
 the title, you'll be prompted to choose.
 
+Only the note body is opened for editing; its frontmatter is kept and the
+updated timestamp is refreshed on save. Use --edit-frontmatter to edit the
+whole file, metadata included.
+
+Use --stdin or --content-file to replace the note body without launching
+an editor, e.g. from scripts or CI. Combined with --edit-frontmatter the
+input replaces the whole file.
+
+The editor command is split like a shell command line, so EDITOR may
+include arguments (e.g. "code --wait"). --editor-args passes additional
+arguments before the file name.
+
+With --lock, or when storage.local.enable_locking is set, the note is locked
+for the whole edit session so a second edit of the same note fails instead
+of silently overwriting the first. Locks left by sessions that are no longer
+running, or older than a day, are removed automatically; --force takes over
+a lock held by another session.
+
 Examples:
   # Edit by note ID
   kbvault edit note-123
@@ -37,10 +65,26 @@ Examples:
   # Use specific editor
   kbvault edit note-123 --editor vim
 
+  # Edit tags and other metadata along with the body
+  kbvault edit note-123 --edit-frontmatter
+
+  # Open vim at line 10
+  kbvault edit note-123 --editor vim --editor-args "+10"
+
+  # Replace the body without opening an editor
+  generate-report | kbvault edit note-123 --stdin
+  kbvault edit note-123 --content-file body.md
+
+  # Lock the note while editing, taking over any existing lock
+  kbvault edit note-123 --lock --force
+
   # Create new note if not found
   kbvault edit "new topic" --create`,
-		Args: cobra.ExactArgs(1),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNoteIDs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
 			// Get profile-aware configuration
 			cfg := getConfig()
 			if cfg == nil {
@@ -62,34 +106,78 @@ Examples:
 			query := args[0]
 
 			// Find the note
-			note, err := findNoteByQuery(storageBackend, query)
+			note, err := findNoteByQuery(ctx, storageBackend, query)
 			if err != nil {
 				if createNew {
-					return createAndEditNote(storageBackend, query, editor)
+					return createAndEditNote(ctx, storageBackend, query, cfg.Vault.IDScheme, editor, editorArgs)
 				}
 				return fmt.Errorf("note not found: %w", err)
 			}
 
+			// Hold the note for the whole session, not just the final write
+			if lockSession || (cfg.Storage.Type == types.StorageTypeLocal && cfg.Storage.Local.EnableLocking) {
+				release, err := acquireEditLock(ctx, storageBackend, note.FilePath, force, cmd.ErrOrStderr())
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+
+			// Replace the content without an editor when it is supplied
+			if fromStdin || contentFile != "" {
+				content, err := readReplacementContent(cmd.InOrStdin(), contentFile)
+				if err != nil {
+					return err
+				}
+				return replaceNoteContent(ctx, cmd.OutOrStdout(), storageBackend, note, content, editFrontmatter)
+			}
+
 			// Edit the note
-			return editNote(storageBackend, note, editor)
+			return editNote(ctx, storageBackend, note, editor, editorArgs, editFrontmatter)
 		},
 	}
 
 	cmd.Flags().StringVar(&editor, "editor", "", "Editor to use (overrides EDITOR env var)")
 	cmd.Flags().BoolVar(&createNew, "create", false, "Create new note if not found")
+	cmd.Flags().BoolVar(&editFrontmatter, "edit-frontmatter", false, "Edit the raw file including frontmatter")
+	cmd.Flags().StringVar(&editorArgs, "editor-args", "", "Extra arguments passed to the editor (e.g. \"+10\")")
+	cmd.Flags().StringVar(&contentFile, "content-file", "", "Replace the note body with the contents of a file")
+	cmd.Flags().BoolVar(&fromStdin, "stdin", false, "Replace the note body with content read from stdin")
+	cmd.Flags().BoolVar(&lockSession, "lock", false, "Lock the note for the duration of the edit session")
+	cmd.Flags().BoolVar(&force, "force", false, "Take over an edit lock held by another session")
+	cmd.MarkFlagsMutuallyExclusive("stdin", "content-file")
+	cmd.MarkFlagsMutuallyExclusive("create", "stdin")
+	cmd.MarkFlagsMutuallyExclusive("create", "content-file")
 
 	return cmd
 }
 
 // findNoteByQuery searches for a note by ID or title
-func findNoteByQuery(storage types.StorageBackend, query string) (*types.Note, error) {
+func findNoteByQuery(ctx context.Context, storage types.StorageBackend, query string) (*types.Note, error) {
+	notes, err := findNoteCandidates(ctx, storage, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(notes) == 1 {
+		return notes[0], nil
+	}
+
+	// Multiple matches - let user choose
+	return selectFromMultipleNotes(notes, query)
+}
+
+// findNoteCandidates returns the notes matching query: the note with that
+// exact ID, otherwise the notes whose title matches. It is an error for
+// nothing to match.
+func findNoteCandidates(ctx context.Context, storage types.StorageBackend, query string) ([]*types.Note, error) {
 	// First try as exact ID
-	if note, err := loadNoteByID(storage, query); err == nil {
-		return note, nil
+	if note, err := loadNoteByID(ctx, storage, query); err == nil {
+		return []*types.Note{note}, nil
 	}
 
 	// Then search by title
-	notes, err := findNotesByTitle(storage, query)
+	notes, err := findNotesByTitle(ctx, storage, query)
 	if err != nil {
 		return nil, err
 	}
@@ -97,18 +185,12 @@ func findNoteByQuery(storage types.StorageBackend, query string) (*types.Note, e
 	if len(notes) == 0 {
 		return nil, fmt.Errorf("no notes found matching '%s'", query)
 	}
-
-	if len(notes) == 1 {
-		return notes[0], nil
-	}
-
-	// Multiple matches - let user choose
-	return selectFromMultipleNotes(notes, query)
+	return notes, nil
 }
 
 // findNotesByTitle searches for notes with matching titles
-func findNotesByTitle(storage types.StorageBackend, titleQuery string) ([]*types.Note, error) {
-	allNotes, err := listAllNotesGeneric(storage)
+func findNotesByTitle(ctx context.Context, storage types.StorageBackend, titleQuery string) ([]*types.Note, error) {
+	allNotes, err := listAllNotesGeneric(ctx, storage)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +201,7 @@ func findNotesByTitle(storage types.StorageBackend, titleQuery string) ([]*types
 	for _, metadata := range allNotes {
 		// Check for exact match first
 		if strings.ToLower(metadata.Title) == titleQuery {
-			note, err := loadNoteByID(storage, metadata.ID)
+			note, err := loadNoteByID(ctx, storage, metadata.ID)
 			if err == nil {
 				return []*types.Note{note}, nil
 			}
@@ -127,7 +209,7 @@ func findNotesByTitle(storage types.StorageBackend, titleQuery string) ([]*types
 
 		// Check for partial match
 		if strings.Contains(strings.ToLower(metadata.Title), titleQuery) {
-			note, err := loadNoteByID(storage, metadata.ID)
+			note, err := loadNoteByID(ctx, storage, metadata.ID)
 			if err == nil {
 				matches = append(matches, note)
 			}
@@ -165,132 +247,53 @@ func selectFromMultipleNotes(notes []*types.Note, query string) (*types.Note, er
 }
 
 // loadNoteByID loads a complete note by its ID
-func loadNoteByID(storage types.StorageBackend, noteID string) (*types.Note, error) {
-	// Try common file extensions and patterns
-	possiblePaths := []string{
-		noteID + ".md",
-		noteID,
-		"notes/" + noteID + ".md",
-		"daily/" + noteID + ".md",
-	}
-
-	for _, path := range possiblePaths {
-		if note, err := readNote(storage, path); err == nil {
+func loadNoteByID(ctx context.Context, storage types.StorageBackend, noteID string) (*types.Note, error) {
+	for _, path := range notePathCandidates(noteID) {
+		if note, err := readNote(ctx, storage, path); err == nil {
 			return note, nil
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	return nil, fmt.Errorf("note not found: %s", noteID)
 }
 
 // readNote reads and parses a note from storage
-func readNote(storage types.StorageBackend, path string) (*types.Note, error) {
-	data, err := storage.Read(context.TODO(), path)
+func readNote(ctx context.Context, storage types.StorageBackend, path string) (*types.Note, error) {
+	data, err := storage.Read(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 
-	content := string(data)
-	note := &types.Note{
-		FilePath: path,
-	}
-
-	// Extract ID from filename
-	base := filepath.Base(path)
-	if strings.HasSuffix(base, ".md") {
-		note.ID = strings.TrimSuffix(base, ".md")
-	} else {
-		note.ID = base
-	}
-
-	// Parse frontmatter and content
-	title, noteContent := parseFrontmatterAndContent(content)
-	note.Title = title
-	note.Content = noteContent
-
-	// Fallback to ID if title is empty
-	if note.Title == "" {
-		note.Title = note.ID
-	}
-
-	return note, nil
+	return note.ParseWithOptions(path, data, noteParseOptions()), nil
 }
 
-// parseFrontmatterAndContent extracts title from YAML frontmatter and returns content
-func parseFrontmatterAndContent(content string) (string, string) {
-	// Check if content starts with YAML frontmatter marker
-	if !strings.HasPrefix(content, "---") {
-		// No frontmatter, extract title from first heading
-		return extractTitleFromMarkdown(content), content
-	}
-
-	// Split by frontmatter markers
-	parts := strings.SplitN(content, "---", 3)
-	if len(parts) < 3 {
-		// Invalid frontmatter, treat as plain content
-		return extractTitleFromMarkdown(content), content
-	}
-
-	// parts[0] is empty (before first ---)
-	// parts[1] is the frontmatter
-	// parts[2] is the content
-	frontmatter := parts[1]
-	bodyContent := strings.TrimSpace(parts[2])
-
-	// Extract title from frontmatter
-	title := extractTitleFromFrontmatter(frontmatter)
-
-	// If no title in frontmatter, try markdown heading
-	if title == "" {
-		title = extractTitleFromMarkdown(bodyContent)
+// editNote opens a note in the configured editor
+func editNote(ctx context.Context, storage types.StorageBackend, n *types.Note, editorOverride, editorArgs string, editFrontmatter bool) error {
+	// Load the full file so frontmatter can be written back
+	original, err := storage.Read(ctx, n.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read note: %w", err)
 	}
 
-	return title, bodyContent
-}
-
-// extractTitleFromFrontmatter extracts title from YAML frontmatter
-func extractTitleFromFrontmatter(frontmatter string) string {
-	lines := strings.Split(frontmatter, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "title:") {
-			// Extract value after "title:"
-			title := strings.TrimSpace(strings.TrimPrefix(line, "title:"))
-			// Remove quotes if present
-			title = strings.Trim(title, "\"'")
-			if title != "" {
-				return title
-			}
-		}
-	}
-	return ""
-}
+	editContent := editableContent(original, editFrontmatter)
 
-// extractTitleFromMarkdown extracts title from first markdown heading
-func extractTitleFromMarkdown(content string) string {
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "# ") {
-			return strings.TrimPrefix(line, "# ")
-		}
-	}
-	return ""
-}
-
-// editNote opens a note in the configured editor
-func editNote(storage types.StorageBackend, note *types.Note, editorOverride string) error {
 	// Create temporary file for editing
-	tempDir := os.TempDir()
-	tempFile := filepath.Join(tempDir, "kbvault-edit-"+note.ID+".md")
+	tempFile := editTempFile(n.ID)
 
 	// Write current content to temp file
-	if err := os.WriteFile(tempFile, []byte(note.Content), 0644); err != nil {
+	if err := os.WriteFile(tempFile, []byte(editContent), 0644); err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 
-	// Clean up temp file when done
+	// Clean up temp file when done, unless it holds edits that couldn't be saved
+	keepTempFile := false
 	defer func() {
+		if keepTempFile {
+			return
+		}
 		if err := os.Remove(tempFile); err != nil {
 			fmt.Printf("Warning: failed to clean up temp file: %v\n", err)
 		}
@@ -304,7 +307,7 @@ func editNote(storage types.StorageBackend, note *types.Note, editorOverride str
 	originalModTime := stat.ModTime()
 
 	// Open in editor
-	if err := openInEditorWithOverride(tempFile, editorOverride); err != nil {
+	if err := openInEditorWithOverride(tempFile, editorOverride, editorArgs); err != nil {
 		return fmt.Errorf("failed to open editor: %w", err)
 	}
 
@@ -314,32 +317,153 @@ func editNote(storage types.StorageBackend, note *types.Note, editorOverride str
 		return fmt.Errorf("failed to stat temp file after editing: %w", err)
 	}
 
-	if stat.ModTime().Equal(originalModTime) {
+	// Read modified content
+	modifiedContent, err := os.ReadFile(tempFile)
+	if err != nil {
+		return fmt.Errorf("failed to read modified content: %w", err)
+	}
+
+	if stat.ModTime().Equal(originalModTime) || string(modifiedContent) == editContent {
 		fmt.Println("No changes made.")
 		return nil
 	}
 
-	// Read modified content
-	modifiedContent, err := os.ReadFile(tempFile)
+	updated, err := applyNoteEdit(original, string(modifiedContent), editFrontmatter, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to read modified content: %w", err)
+		keepTempFile = true
+		return fmt.Errorf("%w (your edits were kept in %s)", err, tempFile)
 	}
 
 	// Write back to storage
-	if err := storage.Write(context.TODO(), note.FilePath, modifiedContent); err != nil {
+	if err := storage.Write(ctx, n.FilePath, updated); err != nil {
+		return fmt.Errorf("failed to save changes: %w", err)
+	}
+	syncSearchIndex(ctx, os.Stderr, storage, n.FilePath, false)
+
+	fmt.Printf("Note '%s' updated successfully.\n", n.Title)
+	return nil
+}
+
+// editTempFile returns the temporary file a note is edited in, which is
+// kept when the edit can't be saved
+func editTempFile(noteID string) string {
+	return filepath.Join(os.TempDir(), "kbvault-edit-"+noteID+".md")
+}
+
+// editableContent returns the part of a note file opened in the editor:
+// the body alone, or the whole file when editing frontmatter
+func editableContent(original []byte, editFrontmatter bool) string {
+	if editFrontmatter {
+		return string(original)
+	}
+	_, body, _ := note.SplitFrontmatter(string(original))
+	return body
+}
+
+// applyNoteEdit combines edited content with the note's metadata and
+// refreshes the updated timestamp and body checksum. Notes without
+// frontmatter are saved as edited.
+func applyNoteEdit(original []byte, edited string, editFrontmatter bool, now time.Time) ([]byte, error) {
+	source := string(original)
+	body := edited
+	if editFrontmatter {
+		source = edited
+	}
+
+	front, originalBody, ok := note.SplitFrontmatter(source)
+	if !ok {
+		return []byte(edited), nil
+	}
+	if editFrontmatter {
+		body = originalBody
+	}
+
+	fm, err := note.ParseFrontmatter(front)
+	if err != nil {
+		if editFrontmatter {
+			return nil, err
+		}
+		// Keep metadata we can't parse exactly as it was
+		return []byte("---\n" + front + "\n---\n\n" + body), nil
+	}
+
+	hint := "fix the frontmatter with --edit-frontmatter"
+	if editFrontmatter {
+		hint = "fix the fields listed"
+	}
+	if err := checkFrontmatterSchema(fm, hint); err != nil {
+		return nil, err
+	}
+
+	fm.Updated = note.FormatTimestamp(now)
+	note.UpdateChecksum(&fm, body, checksumsTracked())
+	return note.SerializeNote(fm, body), nil
+}
+
+// readReplacementContent reads replacement note content from a file, or
+// from stdin when no file is given
+func readReplacementContent(stdin io.Reader, contentFile string) (string, error) {
+	if contentFile != "" {
+		data, err := os.ReadFile(contentFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read content file: %w", err)
+		}
+		return string(data), nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return string(data), nil
+}
+
+// replaceNoteContent saves new content for a note without opening an
+// editor, keeping its frontmatter unless editFrontmatter is set
+func replaceNoteContent(ctx context.Context, out io.Writer, storage types.StorageBackend, n *types.Note, content string, editFrontmatter bool) error {
+	original, err := storage.Read(ctx, n.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read note: %w", err)
+	}
+
+	if content == editableContent(original, editFrontmatter) {
+		_, _ = fmt.Fprintln(out, "No changes made.")
+		return nil
+	}
+
+	updated, err := applyNoteEdit(original, content, editFrontmatter, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := storage.Write(ctx, n.FilePath, updated); err != nil {
 		return fmt.Errorf("failed to save changes: %w", err)
 	}
+	syncSearchIndex(ctx, out, storage, n.FilePath, false)
 
-	fmt.Printf("Note '%s' updated successfully.\n", note.Title)
+	_, _ = fmt.Fprintf(out, "Note '%s' updated successfully.\n", n.Title)
 	return nil
 }
 
-// createAndEditNote creates a new note and opens it for editing
-func createAndEditNote(storage types.StorageBackend, title, editorOverride string) error {
-	// Generate note ID from title
-	noteID := generateNoteID(title)
+// createAndEditNote creates a new note, with an ID generated by scheme, and
+// opens its body for editing
+func createAndEditNote(ctx context.Context, storage types.StorageBackend, title, scheme, editorOverride, editorArgs string) error {
+	noteID := note.GenerateID(title, scheme)
 	filePath := noteID + ".md"
 
+	exists, err := storage.Exists(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to check note: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("note %s already exists", noteID)
+	}
+
+	// Check the frontmatter before any editing is done
+	if err := checkFrontmatterSchema(newNoteFrontmatter(noteID, title, time.Now()), "create the note with kbvault new and --field key=value"); err != nil {
+		return err
+	}
+
 	// Create initial content
 	content := fmt.Sprintf("# %s\n\n", title)
 
@@ -360,7 +484,7 @@ func createAndEditNote(storage types.StorageBackend, title, editorOverride strin
 	}()
 
 	// Open in editor
-	if err := openInEditorWithOverride(tempFile, editorOverride); err != nil {
+	if err := openInEditorWithOverride(tempFile, editorOverride, editorArgs); err != nil {
 		return fmt.Errorf("failed to open editor: %w", err)
 	}
 
@@ -370,17 +494,20 @@ func createAndEditNote(storage types.StorageBackend, title, editorOverride strin
 		return fmt.Errorf("failed to read content: %w", err)
 	}
 
-	// Write to storage
-	if err := storage.Write(context.TODO(), filePath, finalContent); err != nil {
+	// Write to storage with frontmatter, which holds the title for schemes
+	// that don't derive the ID from it
+	data := newNoteData(noteID, title, string(finalContent), time.Now(), checksumsTracked())
+	if err := storage.Write(ctx, filePath, data); err != nil {
 		return fmt.Errorf("failed to save new note: %w", err)
 	}
+	syncSearchIndex(ctx, os.Stderr, storage, filePath, false)
 
 	fmt.Printf("New note '%s' created successfully at %s\n", title, filePath)
 	return nil
 }
 
 // openInEditorWithOverride opens a file in the specified editor
-func openInEditorWithOverride(filePath, editorOverride string) error {
+func openInEditorWithOverride(filePath, editorOverride, editorArgs string) error {
 	editor := editorOverride
 	if editor == "" {
 		editor = os.Getenv("EDITOR")
@@ -389,7 +516,10 @@ func openInEditorWithOverride(filePath, editorOverride string) error {
 		editor = "nano" // Default fallback
 	}
 
-	cmd := exec.Command(editor, filePath)
+	cmd, err := editorCommand(editor, editorArgs, filePath)
+	if err != nil {
+		return err
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -397,47 +527,115 @@ func openInEditorWithOverride(filePath, editorOverride string) error {
 	return cmd.Run()
 }
 
-// generateNoteID creates a URL-safe ID from a title
-func generateNoteID(title string) string {
-	// Convert to lowercase and replace spaces/special chars with hyphens
-	id := strings.ToLower(title)
-	id = strings.ReplaceAll(id, " ", "-")
-	id = strings.ReplaceAll(id, "_", "-")
-
-	// Remove non-alphanumeric characters except hyphens
-	var result strings.Builder
-	for _, r := range id {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
-			result.WriteRune(r)
-		}
+// editorCommand builds the command that opens filePath. The editor and
+// its extra arguments are split like a shell command line, so "code --wait"
+// or a quoted path containing spaces both work.
+func editorCommand(editor, editorArgs, filePath string) (*exec.Cmd, error) {
+	args, err := splitCommandLine(editor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid editor command: %w", err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("editor command is empty")
+	}
+
+	extra, err := splitCommandLine(editorArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid editor arguments: %w", err)
 	}
 
-	// Clean up multiple consecutive hyphens
-	cleaned := strings.ReplaceAll(result.String(), "--", "-")
-	cleaned = strings.Trim(cleaned, "-")
+	args = append(args, extra...)
+	args = append(args, filePath)
+	return exec.Command(args[0], args[1:]...), nil
+}
 
-	// Ensure it's not empty
-	if cleaned == "" {
-		cleaned = "note"
+// splitCommandLine splits s into words, honoring single quotes, double
+// quotes and backslash escapes the way a POSIX shell does
+func splitCommandLine(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			// Inside double quotes a backslash only escapes a few characters
+			if quote == '"' && !strings.ContainsRune(`"\$`+"`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", s)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
 	}
 
-	return cleaned
+	return words, nil
 }
 
-// listAllNotesGeneric lists all notes using the generic storage interface
-func listAllNotesGeneric(storage types.StorageBackend) ([]*types.NoteMetadata, error) {
-	files, err := storage.List(context.TODO(), "")
-	if err != nil {
-		return nil, err
+// newNoteData renders a note created by edit --create
+func newNoteData(id, title, body string, now time.Time, trackChecksums bool) []byte {
+	fm := newNoteFrontmatter(id, title, now)
+	note.UpdateChecksum(&fm, body, trackChecksums)
+	return note.SerializeNote(fm, body)
+}
+
+// newNoteFrontmatter returns the frontmatter of a note created by edit
+// --create
+func newNoteFrontmatter(id, title string, now time.Time) types.Frontmatter {
+	return types.Frontmatter{
+		ID:      id,
+		Title:   title,
+		Type:    "note",
+		Created: note.FormatTimestamp(now),
+		Updated: note.FormatTimestamp(now),
 	}
+}
 
+// listAllNotesGeneric lists all notes using the generic storage interface
+func listAllNotesGeneric(ctx context.Context, storage types.StorageBackend) ([]*types.NoteMetadata, error) {
 	var notes []*types.NoteMetadata
-	for _, file := range files {
-		if !strings.HasSuffix(file, ".md") {
-			continue
-		}
-
-		note, err := readNote(storage, file)
+	for _, file := range listNoteFiles(ctx, storage) {
+		note, err := readNote(ctx, storage, file)
 		if err != nil {
 			continue // Skip files that can't be read
 		}
@@ -445,6 +643,9 @@ func listAllNotesGeneric(storage types.StorageBackend) ([]*types.NoteMetadata, e
 		metadata := note.ToMetadata()
 		notes = append(notes, &metadata)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	return notes, nil
 }
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+const editTestNote = `---
+id: 01ABC
+title: Edited Note
+tags:
+  - go
+  - testing
+type: default
+created: 2024-01-01T00:00:00Z
+updated: 2024-01-01T00:00:00Z
+---
+
+# Edited Note
+
+Original body.
+`
+
+func TestEditableContent(t *testing.T) {
+	body := editableContent([]byte(editTestNote), false)
+	if strings.Contains(body, "tags:") || !strings.HasPrefix(body, "# Edited Note") {
+		t.Errorf("body edit should exclude frontmatter, got %q", body)
+	}
+
+	if got := editableContent([]byte(editTestNote), true); got != editTestNote {
+		t.Errorf("frontmatter edit should include the whole file, got %q", got)
+	}
+}
+
+func TestApplyNoteEdit(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("body edit keeps tags", func(t *testing.T) {
+		updated, err := applyNoteEdit([]byte(editTestNote), "# Edited Note\n\nNew body.\n", false, now)
+		if err != nil {
+			t.Fatalf("applyNoteEdit: %v", err)
+		}
+
+		front, body, ok := note.SplitFrontmatter(string(updated))
+		if !ok {
+			t.Fatalf("frontmatter lost:\n%s", updated)
+		}
+		fm, err := note.ParseFrontmatter(front)
+		if err != nil {
+			t.Fatalf("ParseFrontmatter: %v", err)
+		}
+		if strings.Join(fm.Tags, ",") != "go,testing" {
+			t.Errorf("tags = %v", fm.Tags)
+		}
+		if fm.Created != "2024-01-01T00:00:00Z" {
+			t.Errorf("created = %q", fm.Created)
+		}
+		if fm.Updated != "2024-06-01T12:00:00Z" {
+			t.Errorf("updated = %q", fm.Updated)
+		}
+		if body != "# Edited Note\n\nNew body.\n" {
+			t.Errorf("body = %q", body)
+		}
+	})
+
+	t.Run("frontmatter edit", func(t *testing.T) {
+		edited := strings.Replace(editTestNote, "  - testing\n", "  - testing\n  - added\n", 1)
+		updated, err := applyNoteEdit([]byte(editTestNote), edited, true, now)
+		if err != nil {
+			t.Fatalf("applyNoteEdit: %v", err)
+		}
+		if !strings.Contains(string(updated), "  - added\n") {
+			t.Errorf("added tag missing:\n%s", updated)
+		}
+		if !strings.Contains(string(updated), "updated: 2024-06-01T12:00:00Z\n") {
+			t.Errorf("updated timestamp not bumped:\n%s", updated)
+		}
+	})
+
+	t.Run("invalid frontmatter edit", func(t *testing.T) {
+		edited := strings.Replace(editTestNote, "title: Edited Note", "title: [broken", 1)
+		if _, err := applyNoteEdit([]byte(editTestNote), edited, true, now); err == nil {
+			t.Error("expected error for invalid frontmatter")
+		}
+	})
+
+	t.Run("frontmatter schema", func(t *testing.T) {
+		useTestConfig(t, func(c *types.Config) {
+			c.Vault.FrontmatterSchema = types.FrontmatterSchema{Required: []string{"author"}}
+		})
+
+		_, err := applyNoteEdit([]byte(editTestNote), "# Edited Note\n", false, now)
+		if err == nil || !strings.Contains(err.Error(), `missing required field "author"`) {
+			t.Fatalf("expected schema error, got %v", err)
+		}
+
+		edited := strings.Replace(editTestNote, "type: default\n", "type: default\nauthor: alice\n", 1)
+		if _, err := applyNoteEdit([]byte(editTestNote), edited, true, now); err != nil {
+			t.Errorf("conforming frontmatter edit: %v", err)
+		}
+	})
+
+	t.Run("note without frontmatter", func(t *testing.T) {
+		updated, err := applyNoteEdit([]byte("# Plain\n"), "# Plain\n\nMore.\n", false, now)
+		if err != nil {
+			t.Fatalf("applyNoteEdit: %v", err)
+		}
+		if string(updated) != "# Plain\n\nMore.\n" {
+			t.Errorf("content = %q", updated)
+		}
+	})
+}
+
+// newEditTestStorage returns local storage in dir holding editTestNote
+func newEditTestStorage(t *testing.T, dir string) types.StorageBackend {
+	t.Helper()
+
+	backend, err := storage.CreateStorage(types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: dir, CreateDirs: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateStorage: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+
+	if err := backend.Write(context.Background(), "01ABC.md", []byte(editTestNote)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return backend
+}
+
+func TestEditNotePreservesFrontmatter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the editor")
+	}
+
+	dir := t.TempDir()
+	backend := newEditTestStorage(t, dir)
+	ctx := context.Background()
+
+	// The editor appends a line to the file it is given
+	editor := filepath.Join(dir, "editor.sh")
+	script := "#!/bin/sh\nsleep 0.01\necho 'Appended line.' >> \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write editor script: %v", err)
+	}
+
+	n := &types.Note{ID: "01ABC", Title: "Edited Note", FilePath: "01ABC.md"}
+	if err := editNote(context.Background(), backend, n, editor, "", false); err != nil {
+		t.Fatalf("editNote: %v", err)
+	}
+
+	data, err := backend.Read(ctx, "01ABC.md")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "tags:\n  - go\n  - testing\n") {
+		t.Errorf("tags not preserved:\n%s", content)
+	}
+	if !strings.HasSuffix(content, "Original body.\nAppended line.\n") {
+		t.Errorf("edit not saved:\n%s", content)
+	}
+	if strings.Contains(content, "updated: 2024-01-01T00:00:00Z") {
+		t.Errorf("updated timestamp not bumped:\n%s", content)
+	}
+}
+
+func TestReplaceNoteContent(t *testing.T) {
+	ctx := context.Background()
+	n := &types.Note{ID: "01ABC", Title: "Edited Note", FilePath: "01ABC.md"}
+
+	t.Run("stdin replaces body", func(t *testing.T) {
+		backend := newEditTestStorage(t, t.TempDir())
+
+		content, err := readReplacementContent(strings.NewReader("# Replaced\n"), "")
+		if err != nil {
+			t.Fatalf("readReplacementContent: %v", err)
+		}
+
+		var out bytes.Buffer
+		if err := replaceNoteContent(context.Background(), &out, backend, n, content, false); err != nil {
+			t.Fatalf("replaceNoteContent: %v", err)
+		}
+		if !strings.Contains(out.String(), "updated successfully") {
+			t.Errorf("output = %q", out.String())
+		}
+
+		data, _ := backend.Read(ctx, "01ABC.md")
+		if !strings.Contains(string(data), "  - testing\n") || !strings.HasSuffix(string(data), "---\n\n# Replaced\n") {
+			t.Errorf("unexpected note content:\n%s", data)
+		}
+	})
+
+	t.Run("content file replaces whole file", func(t *testing.T) {
+		backend := newEditTestStorage(t, t.TempDir())
+
+		file := filepath.Join(t.TempDir(), "note.md")
+		edited := strings.Replace(editTestNote, "  - go\n", "", 1)
+		if err := os.WriteFile(file, []byte(edited), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		content, err := readReplacementContent(strings.NewReader("ignored"), file)
+		if err != nil {
+			t.Fatalf("readReplacementContent: %v", err)
+		}
+		if err := replaceNoteContent(context.Background(), &bytes.Buffer{}, backend, n, content, true); err != nil {
+			t.Fatalf("replaceNoteContent: %v", err)
+		}
+
+		data, _ := backend.Read(ctx, "01ABC.md")
+		if strings.Contains(string(data), "  - go\n") || !strings.Contains(string(data), "  - testing\n") {
+			t.Errorf("tags not updated:\n%s", data)
+		}
+	})
+
+	t.Run("unchanged content", func(t *testing.T) {
+		backend := newEditTestStorage(t, t.TempDir())
+
+		var out bytes.Buffer
+		body := editableContent([]byte(editTestNote), false)
+		if err := replaceNoteContent(context.Background(), &out, backend, n, body, false); err != nil {
+			t.Fatalf("replaceNoteContent: %v", err)
+		}
+		if !strings.Contains(out.String(), "No changes made.") {
+			t.Errorf("output = %q", out.String())
+		}
+
+		data, _ := backend.Read(ctx, "01ABC.md")
+		if string(data) != editTestNote {
+			t.Errorf("note should not be rewritten:\n%s", data)
+		}
+	})
+
+	t.Run("missing content file", func(t *testing.T) {
+		if _, err := readReplacementContent(nil, filepath.Join(t.TempDir(), "missing.md")); err == nil {
+			t.Error("expected error for missing content file")
+		}
+	})
+}
+
+func TestEditCmdStdin(t *testing.T) {
+	dir := setupTestVault(t, nil)
+	backend := newEditTestStorage(t, dir)
+
+	cmd := newEditCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader("Body from stdin.\n"))
+	cmd.SetArgs([]string{"01ABC", "--stdin"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("edit --stdin: %v", err)
+	}
+
+	data, err := backend.Read(context.Background(), "01ABC.md")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !strings.HasSuffix(string(data), "---\n\nBody from stdin.\n") || !strings.Contains(string(data), "tags:\n") {
+		t.Errorf("unexpected note content:\n%s", data)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "vim", want: []string{"vim"}},
+		{input: "  code  --wait ", want: []string{"code", "--wait"}},
+		{input: `"/opt/My Editor/bin/edit" -n`, want: []string{"/opt/My Editor/bin/edit", "-n"}},
+		{input: `emacs '+10:5' -nw`, want: []string{"emacs", "+10:5", "-nw"}},
+		{input: `a\ b "c\"d" "e\f" ''`, want: []string{"a b", `c"d`, `e\f`, ""}},
+		{input: `vim "unterminated`, wantErr: true},
+		{input: `vim \`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("splitCommandLine(%q) expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitCommandLine(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	cmd, err := editorCommand(`vim -u "my vimrc"`, "+10", "/tmp/note.md")
+	if err != nil {
+		t.Fatalf("editorCommand: %v", err)
+	}
+	want := []string{"vim", "-u", "my vimrc", "+10", "/tmp/note.md"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("args = %q, want %q", cmd.Args, want)
+	}
+
+	if _, err := editorCommand("   ", "", "/tmp/note.md"); err == nil {
+		t.Error("expected error for empty editor")
+	}
+}
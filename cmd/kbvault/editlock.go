@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/server"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// editLockDir is where edit session locks are kept in vault storage. Locks
+// live outside the note directories so they never show up as notes, and
+// apart from the local backend's per-write lock files in its storage
+// subdirectory, which are only held for the duration of a single write.
+const editLockDir = ".kbvault/locks/"
+
+// editLockMaxAge is how long an edit session lock is honoured before it is
+// considered stale, whatever its owner's state
+const editLockMaxAge = 24 * time.Hour
+
+// editLock records the edit session holding a note
+type editLock struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Token   string    `json:"token"`
+	Created time.Time `json:"created"`
+}
+
+// noteLockedError is returned when another edit session holds a note
+type noteLockedError struct {
+	Path string
+	Lock editLock
+}
+
+func (e *noteLockedError) Error() string {
+	return fmt.Sprintf("note %s is being edited by process %d on %s since %s; use --force to edit anyway",
+		e.Path, e.Lock.PID, e.Lock.Host, e.Lock.Created.Local().Format("2006-01-02 15:04:05"))
+}
+
+// editLockPath returns the storage path of the edit session lock for the
+// note at notePath
+func editLockPath(notePath string) string {
+	return editLockDir + notePath + ".lock"
+}
+
+// stale reports whether the session that took the lock is gone: the lock
+// is older than editLockMaxAge, or it was taken on this host by a process
+// that is no longer running
+func (l editLock) stale(host string, now time.Time) bool {
+	if now.Sub(l.Created) > editLockMaxAge {
+		return true
+	}
+	return l.Host == host && !server.ProcessRunning(l.PID)
+}
+
+// acquireEditLock takes the edit session lock for the note at notePath. A
+// lock held by another live session is an error unless force is set, in
+// which case it is taken over with a warning; stale locks are replaced. The
+// returned function releases the lock, leaving it alone if another session
+// has since taken it over.
+func acquireEditLock(ctx context.Context, storage types.StorageBackend, notePath string, force bool, out io.Writer) (func(), error) {
+	host, _ := os.Hostname()
+	lockPath := editLockPath(notePath)
+
+	existing, err := readEditLock(ctx, storage, lockPath)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		switch {
+		case existing.stale(host, time.Now()):
+			_, _ = fmt.Fprintf(out, "Removing stale edit lock on %s left by process %d on %s\n", notePath, existing.PID, existing.Host)
+		case force:
+			_, _ = fmt.Fprintf(out, "Warning: taking over edit lock on %s held by process %d on %s\n", notePath, existing.PID, existing.Host)
+		default:
+			return nil, &noteLockedError{Path: notePath, Lock: *existing}
+		}
+	}
+
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+	lock := editLock{PID: os.Getpid(), Host: host, Token: token, Created: time.Now().UTC()}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode edit lock: %w", err)
+	}
+	if err := writeEditLock(ctx, storage, lockPath, data, existing == nil); err != nil {
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+	}
+
+	// Another session may have written its lock between our check and
+	// write. Whichever lock is stored now wins.
+	current, err := readEditLock(ctx, storage, lockPath)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.Token != token {
+		if current == nil {
+			current = &editLock{}
+		}
+		return nil, &noteLockedError{Path: notePath, Lock: *current}
+	}
+
+	release := func() {
+		// Release even when ctx has ended, keeping its values such as the
+		// request ID
+		ctx := context.WithoutCancel(ctx)
+		current, err := readEditLock(ctx, storage, lockPath)
+		if err != nil || current == nil || current.Token != token {
+			return
+		}
+		if err := storage.Delete(ctx, lockPath); err != nil {
+			_, _ = fmt.Fprintf(out, "Warning: failed to remove edit lock: %v\n", err)
+		}
+	}
+	return release, nil
+}
+
+// writeEditLock stores an encoded lock at lockPath. A new lock is created
+// atomically on storage that supports it, failing with an error matching
+// fs.ErrExist when another session's lock got there first; otherwise the
+// lock is overwritten.
+func writeEditLock(ctx context.Context, storage types.StorageBackend, lockPath string, data []byte, create bool) error {
+	if creator, ok := storage.(types.CreateBackend); ok && create {
+		if err := creator.Create(ctx, lockPath, data); err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return err
+			}
+			return fmt.Errorf("failed to write edit lock: %w", err)
+		}
+		return nil
+	}
+
+	if err := storage.Write(ctx, lockPath, data); err != nil {
+		return fmt.Errorf("failed to write edit lock: %w", err)
+	}
+	return nil
+}
+
+// readEditLock reads the lock at lockPath, returning nil when there is none.
+// An unreadable lock is treated as stale rather than blocking edits forever.
+func readEditLock(ctx context.Context, storage types.StorageBackend, lockPath string) (*editLock, error) {
+	exists, err := storage.Exists(ctx, lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check edit lock: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := storage.Read(ctx, lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edit lock: %w", err)
+	}
+	var lock editLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return &editLock{}, nil
+	}
+	return &lock, nil
+}
+
+// newLockToken returns a random token identifying one edit session
+func newLockToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
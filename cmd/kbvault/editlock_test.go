@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// storeEditLock stores lock as the edit session lock for notePath
+func storeEditLock(t *testing.T, backend types.StorageBackend, notePath string, lock editLock) {
+	t.Helper()
+
+	data, err := json.Marshal(lock)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if err := backend.Write(context.Background(), editLockPath(notePath), data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+func lockExists(t *testing.T, backend types.StorageBackend, notePath string) bool {
+	t.Helper()
+
+	exists, err := backend.Exists(context.Background(), editLockPath(notePath))
+	if err != nil {
+		t.Fatalf("Exists: %v", err)
+	}
+	return exists
+}
+
+func TestAcquireEditLock(t *testing.T) {
+	backend := newEditTestStorage(t, t.TempDir())
+	ctx := context.Background()
+	var out bytes.Buffer
+
+	release, err := acquireEditLock(ctx, backend, "01ABC.md", false, &out)
+	if err != nil {
+		t.Fatalf("acquireEditLock: %v", err)
+	}
+	if !lockExists(t, backend, "01ABC.md") {
+		t.Fatal("lock not written")
+	}
+
+	lock, err := readEditLock(ctx, backend, editLockPath("01ABC.md"))
+	if err != nil {
+		t.Fatalf("readEditLock: %v", err)
+	}
+	if lock.PID != os.Getpid() {
+		t.Errorf("PID = %d, want %d", lock.PID, os.Getpid())
+	}
+
+	// The note itself is still listed on its own
+	files, err := backend.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f, ".lock") {
+			t.Errorf("List returned lock file %s", f)
+		}
+	}
+
+	release()
+	if lockExists(t, backend, "01ABC.md") {
+		t.Error("lock not removed on release")
+	}
+	if out.Len() != 0 {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestAcquireEditLock_HeldByAnotherSession(t *testing.T) {
+	backend := newEditTestStorage(t, t.TempDir())
+	ctx := context.Background()
+	host, _ := os.Hostname()
+
+	// A live session: this test process, under another token
+	held := editLock{PID: os.Getpid(), Host: host, Token: "other", Created: time.Now()}
+	storeEditLock(t, backend, "01ABC.md", held)
+
+	var out bytes.Buffer
+	_, err := acquireEditLock(ctx, backend, "01ABC.md", false, &out)
+	var locked *noteLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("err = %v, want noteLockedError", err)
+	}
+	if locked.Lock.Token != "other" {
+		t.Errorf("reported lock token = %q", locked.Lock.Token)
+	}
+	if !strings.Contains(err.Error(), "--force") {
+		t.Errorf("error %q does not mention --force", err)
+	}
+
+	// --force takes the lock over with a warning
+	release, err := acquireEditLock(ctx, backend, "01ABC.md", true, &out)
+	if err != nil {
+		t.Fatalf("acquireEditLock with force: %v", err)
+	}
+	if !strings.Contains(out.String(), "Warning: taking over edit lock") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	// Releasing leaves a lock taken over by yet another session in place
+	storeEditLock(t, backend, "01ABC.md", held)
+	release()
+	if !lockExists(t, backend, "01ABC.md") {
+		t.Error("release removed another session's lock")
+	}
+}
+
+func TestAcquireEditLock_Concurrent(t *testing.T) {
+	backend := newEditTestStorage(t, t.TempDir())
+	ctx := context.Background()
+
+	const sessions = 8
+	errs := make(chan error, sessions)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := acquireEditLock(ctx, backend, "01ABC.md", false, io.Discard)
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	acquired := 0
+	for err := range errs {
+		var locked *noteLockedError
+		switch {
+		case err == nil:
+			acquired++
+		case !errors.As(err, &locked):
+			t.Errorf("err = %v, want noteLockedError", err)
+		}
+	}
+	if acquired != 1 {
+		t.Errorf("%d sessions acquired the lock, want 1", acquired)
+	}
+}
+
+// racingStorage is storage without atomic creates where another session
+// writes its own lock right after each write
+type racingStorage struct {
+	types.StorageBackend
+	other editLock
+}
+
+func (s *racingStorage) Write(ctx context.Context, path string, data []byte) error {
+	if err := s.StorageBackend.Write(ctx, path, data); err != nil {
+		return err
+	}
+	other, err := json.Marshal(s.other)
+	if err != nil {
+		return err
+	}
+	return s.StorageBackend.Write(ctx, path, other)
+}
+
+func TestAcquireEditLock_LostRace(t *testing.T) {
+	host, _ := os.Hostname()
+	other := editLock{PID: os.Getpid(), Host: host, Token: "other", Created: time.Now()}
+	backend := &racingStorage{StorageBackend: newEditTestStorage(t, t.TempDir()), other: other}
+
+	_, err := acquireEditLock(context.Background(), backend, "01ABC.md", false, io.Discard)
+	var locked *noteLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("err = %v, want noteLockedError", err)
+	}
+	if locked.Lock.Token != "other" {
+		t.Errorf("reported lock token = %q", locked.Lock.Token)
+	}
+}
+
+func TestAcquireEditLock_Stale(t *testing.T) {
+	host, _ := os.Hostname()
+
+	marshal := func(lock editLock) []byte {
+		data, err := json.Marshal(lock)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"expired", marshal(editLock{PID: os.Getpid(), Host: "elsewhere", Token: "old", Created: time.Now().Add(-2 * editLockMaxAge)})},
+		{"unreadable", []byte("not json")},
+		{"dead process", marshal(editLock{PID: 1 << 30, Host: host, Token: "dead", Created: time.Now()})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newEditTestStorage(t, t.TempDir())
+			ctx := context.Background()
+			if err := backend.Write(ctx, editLockPath("01ABC.md"), tt.data); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			var out bytes.Buffer
+			release, err := acquireEditLock(ctx, backend, "01ABC.md", false, &out)
+			if err != nil {
+				t.Fatalf("acquireEditLock: %v", err)
+			}
+			defer release()
+
+			if !strings.Contains(out.String(), "Removing stale edit lock") {
+				t.Errorf("output = %q", out.String())
+			}
+			lock, err := readEditLock(ctx, backend, editLockPath("01ABC.md"))
+			if err != nil {
+				t.Fatalf("readEditLock: %v", err)
+			}
+			if lock.PID != os.Getpid() {
+				t.Errorf("lock not taken over: %+v", lock)
+			}
+		})
+	}
+}
+
+func TestEditLockStale(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		lock editLock
+		want bool
+	}{
+		{"live process", editLock{PID: os.Getpid(), Host: "here", Created: now}, false},
+		{"other host", editLock{PID: 1 << 30, Host: "there", Created: now}, false},
+		{"dead process", editLock{PID: 1 << 30, Host: "here", Created: now}, true},
+		{"expired", editLock{PID: os.Getpid(), Host: "here", Created: now.Add(-editLockMaxAge - time.Minute)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lock.stale("here", now); got != tt.want {
+				t.Errorf("stale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/links"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// noteGraph is the link graph emitted by the graph command
+type noteGraph struct {
+	Nodes []graphNode `json:"nodes"`
+	Edges []graphEdge `json:"edges"`
+}
+
+// graphNode is a note in the graph
+type graphNode struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+}
+
+// graphEdge is a link from one note to another
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// graphOptions selects which part of the vault graph to emit
+type graphOptions struct {
+	From          string
+	Depth         int
+	ConnectedOnly bool
+}
+
+func newGraphCmd() *cobra.Command {
+	var (
+		format string
+		opts   graphOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Output the note link graph",
+		Long: `Output the directed graph of links between notes. Edges are
+wikilinks and markdown links that resolve to another note in the vault.
+
+DOT output can be piped to Graphviz; JSON output lists nodes (id, title,
+tags) and edges (from, to). Use --from with --depth to limit the graph to
+notes within N links of a starting note, following links in either
+direction.
+
+Examples:
+  # Render the whole vault with Graphviz
+  kbvault graph | dot -Tsvg > vault.svg
+
+  # Notes within two links of a note, as JSON
+  kbvault graph --format json --from 01HQ2X3Y4Z --depth 2
+
+  # Leave out notes without any links
+  kbvault graph --connected-only`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if format != "dot" && format != "json" {
+				return fmt.Errorf("invalid format %q: must be dot or json", format)
+			}
+			if opts.Depth < 0 {
+				return fmt.Errorf("depth cannot be negative")
+			}
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			notes, err := listAllNotes(ctx, storageBackend)
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
+			}
+
+			graph, err := buildNoteGraph(ctx, notes, opts)
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				return outputGraphJSON(cmd.OutOrStdout(), graph)
+			}
+			return outputGraphDOT(cmd.OutOrStdout(), graph)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "dot", "Output format (dot, json)")
+	cmd.Flags().StringVar(&opts.From, "from", "", "Only include notes near this note ID")
+	cmd.Flags().IntVar(&opts.Depth, "depth", 1, "Maximum number of links from --from")
+	cmd.Flags().BoolVar(&opts.ConnectedOnly, "connected-only", false, "Exclude notes without links")
+
+	return cmd
+}
+
+// buildNoteGraph resolves links between notes and selects the nodes and
+// edges to emit
+func buildNoteGraph(ctx context.Context, notes []*types.Note, opts graphOptions) (*noteGraph, error) {
+	linkGraph, err := buildLinkGraph(ctx, notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build link graph: %w", err)
+	}
+
+	// Restrict to the neighborhood of the starting note if requested
+	var include map[string]bool
+	if opts.From != "" {
+		resolver := newVaultNoteResolver(notes)
+		start, err := resolver.ResolveByID(opts.From)
+		if err != nil {
+			return nil, err
+		}
+
+		include = make(map[string]bool)
+		for _, id := range linkGraph.Neighborhood(start.ID, opts.Depth) {
+			include[id] = true
+		}
+	}
+
+	graph := &noteGraph{
+		Nodes: []graphNode{},
+		Edges: []graphEdge{},
+	}
+
+	sorted := make([]*types.Note, len(notes))
+	copy(sorted, notes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	for _, note := range sorted {
+		if include != nil && !include[note.ID] {
+			continue
+		}
+
+		outgoing := graphEdgesFrom(linkGraph, note.ID, include)
+		if opts.ConnectedOnly && len(outgoing) == 0 && !hasIncomingEdge(linkGraph, note.ID, include) {
+			continue
+		}
+
+		tags := note.Frontmatter.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		graph.Nodes = append(graph.Nodes, graphNode{
+			ID:    note.ID,
+			Title: note.Title,
+			Tags:  tags,
+		})
+		graph.Edges = append(graph.Edges, outgoing...)
+	}
+
+	return graph, nil
+}
+
+// graphEdgesFrom returns the edges leaving a note whose targets are included
+func graphEdgesFrom(graph *links.Graph, noteID string, include map[string]bool) []graphEdge {
+	var edges []graphEdge
+	for _, link := range graph.GetOutgoingLinks(noteID) {
+		if include != nil && !include[link.TargetID] {
+			continue
+		}
+		edges = append(edges, graphEdge{From: noteID, To: link.TargetID})
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// hasIncomingEdge reports whether an included note links to noteID
+func hasIncomingEdge(graph *links.Graph, noteID string, include map[string]bool) bool {
+	for _, link := range graph.GetIncomingLinks(noteID) {
+		if include == nil || include[link.SourceID] {
+			return true
+		}
+	}
+	return false
+}
+
+func outputGraphJSON(w io.Writer, graph *noteGraph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
+
+func outputGraphDOT(w io.Writer, graph *noteGraph) error {
+	var b strings.Builder
+
+	b.WriteString("digraph kbvault {\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s];\n", dotQuote(node.ID), dotQuote(node.Title))
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+	b.WriteString("}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// dotQuote quotes a string as a DOT identifier
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
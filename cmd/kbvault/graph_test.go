@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func newGraphTestNotes() []*types.Note {
+	return []*types.Note{
+		{ID: "a", Title: "Alpha", FilePath: "notes/a.md", Content: "See [[Beta]].",
+			Frontmatter: types.Frontmatter{Tags: []string{"go"}}},
+		{ID: "b", Title: "Beta", FilePath: "notes/b.md", Content: "See [Gamma](notes/c.md)."},
+		{ID: "c", Title: "Gamma", FilePath: "notes/c.md", Content: "See [[Delta]] and [[Missing]]."},
+		{ID: "d", Title: "Delta", FilePath: "notes/d.md", Content: "End of the chain."},
+		{ID: "e", Title: "Epsilon", FilePath: "notes/e.md", Content: "No links."},
+	}
+}
+
+func TestBuildNoteGraph(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("whole vault", func(t *testing.T) {
+		graph, err := buildNoteGraph(ctx, newGraphTestNotes(), graphOptions{})
+		require.NoError(t, err)
+
+		assert.Len(t, graph.Nodes, 5)
+		assert.Equal(t, graphNode{ID: "a", Title: "Alpha", Tags: []string{"go"}}, graph.Nodes[0])
+		assert.Equal(t, []graphEdge{{"a", "b"}, {"b", "c"}, {"c", "d"}}, graph.Edges)
+	})
+
+	t.Run("connected only", func(t *testing.T) {
+		graph, err := buildNoteGraph(ctx, newGraphTestNotes(), graphOptions{ConnectedOnly: true})
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(graph.Nodes))
+		for _, node := range graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+	})
+
+	t.Run("subgraph", func(t *testing.T) {
+		graph, err := buildNoteGraph(ctx, newGraphTestNotes(), graphOptions{From: "b", Depth: 1})
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(graph.Nodes))
+		for _, node := range graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, ids)
+		assert.Equal(t, []graphEdge{{"a", "b"}, {"b", "c"}}, graph.Edges)
+	})
+
+	t.Run("unknown start note", func(t *testing.T) {
+		_, err := buildNoteGraph(ctx, newGraphTestNotes(), graphOptions{From: "zzz", Depth: 1})
+		assert.Error(t, err)
+	})
+}
+
+func TestOutputGraph(t *testing.T) {
+	graph := &noteGraph{
+		Nodes: []graphNode{
+			{ID: "a", Title: `Say "hi"`, Tags: []string{}},
+			{ID: "b", Title: "Beta", Tags: []string{"x"}},
+		},
+		Edges: []graphEdge{{From: "a", To: "b"}},
+	}
+
+	var dot bytes.Buffer
+	require.NoError(t, outputGraphDOT(&dot, graph))
+	assert.Equal(t, "digraph kbvault {\n"+
+		"  \"a\" [label=\"Say \\\"hi\\\"\"];\n"+
+		"  \"b\" [label=\"Beta\"];\n"+
+		"  \"a\" -> \"b\";\n"+
+		"}\n", dot.String())
+
+	var out bytes.Buffer
+	require.NoError(t, outputGraphJSON(&out, graph))
+
+	var decoded noteGraph
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, *graph, decoded)
+}
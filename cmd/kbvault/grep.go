@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// maxGrepLineSize is the longest line grep reads; longer lines fail the
+// file they are in
+const maxGrepLineSize = 1024 * 1024
+
+func newGrepCmd() *cobra.Command {
+	var (
+		after      int
+		before     int
+		around     int
+		ignoreCase bool
+		fixed      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "grep <pattern>",
+		Short: "Search note contents line by line without an index",
+		Long: `Stream every note and print the lines matching a regular expression
+as note-id:line:text. Context lines requested with -A, -B or -C are
+printed as note-id-line-text, and groups of lines that aren't adjacent
+are separated by --.
+
+Unlike search, grep needs no index and always sees the current content.
+With storage.s3.select_enabled set, S3 Select checks each object for the
+pattern's literal text first so that objects without it aren't
+downloaded; storage that can't do this is scanned in full.
+
+Examples:
+  kbvault grep "TODO"
+  kbvault grep -i -C 2 "deploy checklist"
+  kbvault grep -F "a.b[0]"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if after < 0 || before < 0 || around < 0 {
+				return fmt.Errorf("context line counts cannot be negative")
+			}
+			// -A and -B take precedence over -C
+			if around > 0 {
+				if !cmd.Flags().Changed("after-context") {
+					after = around
+				}
+				if !cmd.Flags().Changed("before-context") {
+					before = around
+				}
+			}
+
+			re, err := compileGrepPattern(args[0], fixed, ignoreCase)
+			if err != nil {
+				return err
+			}
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			ctx := cmd.Context()
+
+			files := listNoteFiles(ctx, storageBackend)
+			sort.Strings(files)
+			files, err = grepCandidates(ctx, storageBackend, files, grepLiteral(args[0], fixed), ignoreCase)
+			if err != nil {
+				return err
+			}
+
+			g := &grepper{w: cmd.OutOrStdout(), re: re, before: before, after: after}
+			for _, file := range files {
+				if err := grepFile(ctx, g, storageBackend, file); err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&after, "after-context", "A", 0, "Print lines of context after each match")
+	cmd.Flags().IntVarP(&before, "before-context", "B", 0, "Print lines of context before each match")
+	cmd.Flags().IntVarP(&around, "context", "C", 0, "Print lines of context before and after each match")
+	cmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	cmd.Flags().BoolVarP(&fixed, "fixed-strings", "F", false, "Treat the pattern as literal text")
+
+	return cmd
+}
+
+// compileGrepPattern compiles a grep pattern, quoting it first when it is
+// literal text
+func compileGrepPattern(pattern string, fixed, ignoreCase bool) (*regexp.Regexp, error) {
+	if fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// grepLiteral returns text every match of pattern contains, or "" when
+// there is none: the whole pattern when it is literal, otherwise the
+// literal prefix of the regular expression
+func grepLiteral(pattern string, fixed bool) string {
+	if fixed {
+		return pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ""
+	}
+	prefix, _ := re.LiteralPrefix()
+	return prefix
+}
+
+// grepCandidates narrows files to those the backend finds literal in on
+// the server, when it can. Otherwise every file is a candidate.
+func grepCandidates(ctx context.Context, backend types.StorageBackend, files []string, literal string, ignoreCase bool) ([]string, error) {
+	selector, ok := backend.(types.SelectBackend)
+	if !ok || literal == "" || strings.Contains(literal, "\n") {
+		return files, nil
+	}
+
+	candidates, err := selector.FilesContaining(ctx, files, literal, ignoreCase)
+	if errors.Is(err, types.ErrSelectNotSupported) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search storage: %w", err)
+	}
+	return candidates, nil
+}
+
+// grepFile streams one note through g
+func grepFile(ctx context.Context, g *grepper, backend types.StorageBackend, file string) error {
+	reader, err := backend.ReadStream(ctx, file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	id := strings.TrimSuffix(path.Base(file), ".md")
+	if err := g.scan(id, reader); err != nil {
+		return fmt.Errorf("failed to search %s: %w", file, err)
+	}
+	return nil
+}
+
+// grepper prints the lines of notes that match re with before and after
+// lines of context
+type grepper struct {
+	w             io.Writer
+	re            *regexp.Regexp
+	before, after int
+
+	// printed is set once any line is printed, so that later groups are
+	// separated from it
+	printed bool
+}
+
+// grepLine is a line held back as possible context for a later match
+type grepLine struct {
+	number int
+	text   string
+}
+
+// scan prints the matching lines of the note id read from r with their
+// context
+func (g *grepper) scan(id string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxGrepLineSize)
+
+	var (
+		held      []grepLine // up to before lines preceding the current one
+		last      int        // number of the last line printed from this note
+		afterLeft int        // context lines still to print after a match
+	)
+	for number := 1; scanner.Scan(); number++ {
+		text := scanner.Text()
+
+		switch {
+		case g.re.MatchString(text):
+			for _, line := range held {
+				g.print(id, line.number, '-', line.text, last)
+				last = line.number
+			}
+			held = held[:0]
+			g.print(id, number, ':', text, last)
+			last = number
+			afterLeft = g.after
+
+		case afterLeft > 0:
+			g.print(id, number, '-', text, last)
+			last = number
+			afterLeft--
+
+		case g.before > 0:
+			if len(held) == g.before {
+				held = append(held[:0], held[1:]...)
+			}
+			held = append(held, grepLine{number: number, text: text})
+		}
+	}
+	return scanner.Err()
+}
+
+// print writes one line of output, after a -- separator when context is
+// shown and the line doesn't directly follow the last one printed from the
+// note, last being 0 when none has been
+func (g *grepper) print(id string, number int, sep byte, text string, last int) {
+	if g.printed && (g.before > 0 || g.after > 0) && (last == 0 || number != last+1) {
+		_, _ = fmt.Fprintln(g.w, "--")
+	}
+	_, _ = fmt.Fprintf(g.w, "%s%c%d%c%s\n", id, sep, number, sep, text)
+	g.printed = true
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+const grepNote = `one
+two match
+three
+four
+five
+six match
+seven
+eight
+nine
+ten match
+`
+
+func TestGrepper_Context(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after int
+		want          string
+	}{
+		{
+			name: "matches only",
+			want: "n:2:two match\nn:6:six match\nn:10:ten match\n",
+		},
+		{
+			name:  "after context",
+			after: 1,
+			want:  "n:2:two match\nn-3-three\n--\nn:6:six match\nn-7-seven\n--\nn:10:ten match\n",
+		},
+		{
+			name:   "before context",
+			before: 2,
+			want:   "n-1-one\nn:2:two match\n--\nn-4-four\nn-5-five\nn:6:six match\n--\nn-8-eight\nn-9-nine\nn:10:ten match\n",
+		},
+		{
+			name:   "overlapping context is not repeated",
+			before: 3,
+			after:  3,
+			want: "n-1-one\nn:2:two match\nn-3-three\nn-4-four\nn-5-five\nn:6:six match\n" +
+				"n-7-seven\nn-8-eight\nn-9-nine\nn:10:ten match\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			g := &grepper{w: &buf, re: regexp.MustCompile("match"), before: tt.before, after: tt.after}
+			require.NoError(t, g.scan("n", strings.NewReader(grepNote)))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestGrepper_SeparatesNotes(t *testing.T) {
+	var buf bytes.Buffer
+	g := &grepper{w: &buf, re: regexp.MustCompile("match"), after: 1}
+	require.NoError(t, g.scan("a", strings.NewReader("match\nnext\n")))
+	require.NoError(t, g.scan("b", strings.NewReader("match\n")))
+	assert.Equal(t, "a:1:match\na-2-next\n--\nb:1:match\n", buf.String())
+
+	// Without context, notes follow each other directly
+	buf.Reset()
+	g = &grepper{w: &buf, re: regexp.MustCompile("match")}
+	require.NoError(t, g.scan("a", strings.NewReader("match\n")))
+	require.NoError(t, g.scan("b", strings.NewReader("match\n")))
+	assert.Equal(t, "a:1:match\nb:1:match\n", buf.String())
+}
+
+func TestGrepCmd(t *testing.T) {
+	useTestConfig(t)
+
+	backend, err := storage.CreateStorage(currentConfig.Storage)
+	require.NoError(t, err)
+	defer func() { _ = backend.Close() }()
+
+	ctx := context.Background()
+	require.NoError(t, backend.Write(ctx, "notes/01ALPHA.md", []byte("# Alpha\n\nRun the Deploy checklist.\nThen relax.\n")))
+	require.NoError(t, backend.Write(ctx, "notes/01BETA.md", []byte("# Beta\n\nA [deploy] note, 1.5x faster.\n")))
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := newGrepCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		require.NoError(t, cmd.Execute())
+		return out.String()
+	}
+
+	assert.Equal(t, "01BETA:3:A [deploy] note, 1.5x faster.\n", run("deploy"))
+	assert.Equal(t, "01ALPHA:3:Run the Deploy checklist.\n01BETA:3:A [deploy] note, 1.5x faster.\n", run("-i", "deploy"))
+	assert.Equal(t, "01ALPHA-2-\n01ALPHA:3:Run the Deploy checklist.\n01ALPHA-4-Then relax.\n", run("-C", "1", "Deploy"))
+	assert.Equal(t, "01ALPHA:3:Run the Deploy checklist.\n", run("-C", "1", "-B", "0", "-A", "0", "Deploy"))
+
+	// Fixed strings are not regular expressions
+	assert.Equal(t, "01BETA:3:A [deploy] note, 1.5x faster.\n", run("-F", "[deploy]"))
+	assert.Equal(t, "", run("-F", "1.5x.faster"))
+	assert.NotEmpty(t, run("1.5x.faster"))
+
+	cmd := newGrepCmd()
+	cmd.SetArgs([]string{"("})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	assert.ErrorContains(t, cmd.Execute(), "invalid pattern")
+}
+
+func TestGrepLiteral(t *testing.T) {
+	assert.Equal(t, "a.b", grepLiteral("a.b", true))
+	assert.Equal(t, "deploy", grepLiteral("deploy", false))
+	assert.Equal(t, "deploy ", grepLiteral("deploy (now|later)", false))
+	assert.Equal(t, "", grepLiteral("(?i)deploy", false))
+	assert.Equal(t, "", grepLiteral("[dD]eploy", false))
+}
+
+// selectStorage is a storage backend that searches on the server
+type selectStorage struct {
+	types.StorageBackend
+	matches []string
+	err     error
+	literal string
+}
+
+func (s *selectStorage) FilesContaining(ctx context.Context, paths []string, literal string, ignoreCase bool) ([]string, error) {
+	s.literal = literal
+	return s.matches, s.err
+}
+
+func TestGrepCandidates(t *testing.T) {
+	ctx := context.Background()
+	files := []string{"a.md", "b.md", "c.md"}
+
+	backend := &selectStorage{matches: []string{"b.md"}}
+	got, err := grepCandidates(ctx, backend, files, "deploy", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.md"}, got)
+	assert.Equal(t, "deploy", backend.literal)
+
+	// Without a literal to look for, every file is searched
+	got, err = grepCandidates(ctx, backend, files, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, files, got)
+
+	// Storage that turns out not to support it falls back to every file
+	got, err = grepCandidates(ctx, &selectStorage{err: types.ErrSelectNotSupported}, files, "deploy", false)
+	require.NoError(t, err)
+	assert.Equal(t, files, got)
+
+	_, err = grepCandidates(ctx, &selectStorage{err: errors.New("boom")}, files, "deploy", false)
+	assert.ErrorContains(t, err, "boom")
+}
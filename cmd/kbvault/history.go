@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var (
+		versionID string
+		show      bool
+		restore   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "history <note-id>",
+		Short: "List and restore past versions of a note",
+		Long: `List the past versions storage keeps of a note, newest first, with when
+each was written and its size. Deleted notes keep their versions, so they
+can be recovered too.
+
+S3 storage keeps versions when versioning is enabled on the vault's bucket.
+Local storage keeps a snapshot of a note under .kbvault/versions each time
+it is overwritten or deleted when storage.local.version_retention is set,
+up to that many per note.
+
+With --version and --show a past version is printed. With --restore a past
+version becomes the current content of the note; the versions in between
+are kept.
+
+Examples:
+  # List the versions of a note
+  kbvault history 01HQ2X3Y4Z
+
+  # Print a past version
+  kbvault history 01HQ2X3Y4Z --version 3HL4kqtJlcpXroDTDmJ --show
+
+  # Make a past version current again
+  kbvault history 01HQ2X3Y4Z --restore 3HL4kqtJlcpXroDTDmJ`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNoteIDs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+			if show != (versionID != "") {
+				return fmt.Errorf("--version and --show must be used together")
+			}
+			if versionID != "" && restore != "" {
+				return fmt.Errorf("--restore cannot be combined with --version")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			versioned, ok := storageBackend.(types.VersionedBackend)
+			if !ok {
+				return fmt.Errorf("history is not available for %s storage: %w", cfg.Storage.Type, types.ErrVersioningNotSupported)
+			}
+
+			history, err := findNoteHistory(ctx, versioned, args[0])
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch {
+			case show:
+				if _, err := history.version(versionID); err != nil {
+					return err
+				}
+				content, err := versioned.ReadVersion(ctx, history.path, versionID)
+				if err != nil {
+					return fmt.Errorf("failed to read version %s of %s: %w", versionID, history.path, err)
+				}
+				_, _ = out.Write(content)
+				return nil
+
+			case restore != "":
+				if _, err := history.version(restore); err != nil {
+					return err
+				}
+				if err := versioned.RestoreVersion(ctx, history.path, restore); err != nil {
+					return fmt.Errorf("failed to restore %s: %w", history.path, err)
+				}
+				syncSearchIndex(ctx, cmd.ErrOrStderr(), storageBackend, history.path, false)
+				_, _ = fmt.Fprintf(out, "Restored %s to version %s\n", history.path, restore)
+				return nil
+			}
+
+			printNoteHistory(out, history.versions)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&versionID, "version", "", "Version to print with --show")
+	cmd.Flags().BoolVar(&show, "show", false, "Print the version given by --version")
+	cmd.Flags().StringVar(&restore, "restore", "", "Make this version the current content of the note")
+
+	return cmd
+}
+
+// noteHistory is the versions of a note
+type noteHistory struct {
+	path     string
+	versions []types.FileVersion
+}
+
+// findNoteHistory finds the versions of the note with the given ID or path,
+// trying the paths the note may be stored at in order. Deleted notes are
+// found by the versions they leave behind.
+func findNoteHistory(ctx context.Context, versioned types.VersionedBackend, noteID string) (*noteHistory, error) {
+	for _, path := range notePathCandidates(noteID) {
+		versions, err := versioned.ListVersions(ctx, path)
+		if err != nil {
+			if errors.Is(err, types.ErrVersioningNotSupported) {
+				return nil, fmt.Errorf("history is not available: %w", err)
+			}
+			return nil, fmt.Errorf("failed to list versions of %s: %w", path, err)
+		}
+		if len(versions) > 0 {
+			return &noteHistory{path: path, versions: versions}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no versions of note %s found", noteID)
+}
+
+// version returns the version with the given ID, which must have content
+func (h *noteHistory) version(versionID string) (types.FileVersion, error) {
+	for _, v := range h.versions {
+		if v.ID != versionID {
+			continue
+		}
+		if v.Deleted {
+			return v, fmt.Errorf("version %s of %s is a delete marker and has no content", versionID, h.path)
+		}
+		return v, nil
+	}
+	return types.FileVersion{}, fmt.Errorf("%s has no version %s", h.path, versionID)
+}
+
+// printNoteHistory writes versions as a table, marking the current version
+// and deletions
+func printNoteHistory(out io.Writer, versions []types.FileVersion) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer func() { _ = w.Flush() }()
+
+	_, _ = fmt.Fprintln(w, "VERSION\tMODIFIED\tSIZE\tSTATUS")
+	for _, v := range versions {
+		size := formatBytes(v.Size)
+		var status []string
+		if v.Current {
+			status = append(status, "current")
+		}
+		if v.Deleted {
+			size = "-"
+			status = append(status, "deleted")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.ID, v.ModTime.Format(time.RFC3339), size, strings.Join(status, ", "))
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3/s3test"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// fakeObjectVersion is a version held by the fake versioned bucket
+type fakeObjectVersion struct {
+	key, id, modified, body string
+	latest, deleteMarker    bool
+}
+
+// setupHistoryTest points the current config at a fake versioned S3 bucket
+// holding versions and returns the objects written to it, keyed by key
+func setupHistoryTest(t *testing.T, versions []fakeObjectVersion) func() map[string]string {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		written = map[string]string{}
+	)
+	s3Config := s3test.NewFakeS3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		key := strings.TrimPrefix(r.URL.Path, "/"+s3test.Bucket+"/")
+		switch {
+		case r.Method == http.MethodGet && query.Has("versions"):
+			var b strings.Builder
+			b.WriteString("<ListVersionsResult><IsTruncated>false</IsTruncated>")
+			for _, v := range versions {
+				if !strings.HasPrefix(v.key, query.Get("prefix")) {
+					continue
+				}
+				if v.deleteMarker {
+					fmt.Fprintf(&b, "<DeleteMarker><Key>%s</Key><VersionId>%s</VersionId><IsLatest>%t</IsLatest><LastModified>%s</LastModified></DeleteMarker>",
+						v.key, v.id, v.latest, v.modified)
+					continue
+				}
+				fmt.Fprintf(&b, "<Version><Key>%s</Key><VersionId>%s</VersionId><IsLatest>%t</IsLatest><LastModified>%s</LastModified><Size>%d</Size></Version>",
+					v.key, v.id, v.latest, v.modified, len(v.body))
+			}
+			b.WriteString("</ListVersionsResult>")
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(b.String()))
+		case r.Method == http.MethodGet && query.Has("versionId"):
+			for _, v := range versions {
+				if v.key == key && v.id == query.Get("versionId") && !v.deleteMarker {
+					_, _ = w.Write([]byte(v.body))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+			// A copy of a version of the same key, as made by a restore
+			source, _ := url.Parse("/" + r.Header.Get("X-Amz-Copy-Source"))
+			for _, v := range versions {
+				if "/"+s3test.Bucket+"/"+v.key == source.Path && v.id == source.Query().Get("versionId") {
+					mu.Lock()
+					written[key] = v.body
+					mu.Unlock()
+				}
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte("<CopyObjectResult><ETag>\"copied\"</ETag></CopyObjectResult>"))
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			written[key] = string(body)
+			mu.Unlock()
+			sum := md5.Sum(body)
+			w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		default:
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+		}
+	}))
+
+	useTestConfig(t, func(c *types.Config) {
+		c.Storage.Type = types.StorageTypeS3
+		c.Storage.Cache.Disk.Enabled = false
+		c.Storage.S3 = s3Config
+	})
+
+	return func() map[string]string {
+		mu.Lock()
+		defer mu.Unlock()
+		copied := make(map[string]string, len(written))
+		for k, v := range written {
+			copied[k] = v
+		}
+		return copied
+	}
+}
+
+func runHistoryCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newHistoryCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+var historyTestVersions = []fakeObjectVersion{
+	{key: "notes/a.md", id: "v1", modified: "2024-01-01T00:00:00.000Z", body: "first"},
+	{key: "notes/a.md", id: "v2", modified: "2024-01-02T00:00:00.000Z", body: "second", latest: true},
+	{key: "notes/a.md.bak", id: "x1", modified: "2024-01-03T00:00:00.000Z", body: "backup", latest: true},
+	{key: "notes/b.md", id: "b1", modified: "2024-01-01T00:00:00.000Z", body: "deleted note"},
+	{key: "notes/b.md", id: "b2", modified: "2024-01-02T00:00:00.000Z", latest: true, deleteMarker: true},
+}
+
+func TestHistoryCmd_List(t *testing.T) {
+	setupHistoryTest(t, historyTestVersions)
+
+	out, err := runHistoryCmd(t, "a")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3, out)
+	assert.Contains(t, lines[0], "VERSION")
+	assert.Contains(t, lines[1], "v2")
+	assert.Contains(t, lines[1], "2024-01-02T00:00:00Z")
+	assert.Contains(t, lines[1], "6 B")
+	assert.Contains(t, lines[1], "current")
+	assert.Contains(t, lines[2], "v1")
+	assert.NotContains(t, out, "x1", "versions of other keys sharing the prefix are left out")
+
+	out, err = runHistoryCmd(t, "b")
+	require.NoError(t, err)
+	assert.Contains(t, out, "current, deleted")
+	assert.Contains(t, out, "b1")
+}
+
+func TestHistoryCmd_ShowAndRestore(t *testing.T) {
+	written := setupHistoryTest(t, historyTestVersions)
+
+	out, err := runHistoryCmd(t, "a", "--version", "v1", "--show")
+	require.NoError(t, err)
+	assert.Equal(t, "first", out)
+
+	out, err = runHistoryCmd(t, "a", "--restore", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "Restored notes/a.md to version v1\n", out)
+	assert.Equal(t, map[string]string{"notes/a.md": "first"}, written())
+
+	// A deleted note is recovered from the version before its delete marker
+	_, err = runHistoryCmd(t, "b", "--restore", "b1")
+	require.NoError(t, err)
+	assert.Equal(t, "deleted note", written()["notes/b.md"])
+
+	_, err = runHistoryCmd(t, "b", "--restore", "b2")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is a delete marker")
+
+	_, err = runHistoryCmd(t, "a", "--version", "nope", "--show")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notes/a.md has no version nope")
+}
+
+func TestHistoryCmd_Compressed(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write([]byte("compressed content"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	written := setupHistoryTest(t, []fakeObjectVersion{
+		{key: "notes/c.md.gz", id: "c1", modified: "2024-01-01T00:00:00.000Z", body: gz.String()},
+		{key: "notes/c.md.gz", id: "c2", modified: "2024-01-02T00:00:00.000Z", body: "newer", latest: true},
+	})
+	currentConfig.Storage.Compression = types.CompressionGzip
+
+	out, err := runHistoryCmd(t, "c", "--version", "c1", "--show")
+	require.NoError(t, err)
+	assert.Equal(t, "compressed content", out)
+
+	_, err = runHistoryCmd(t, "c", "--restore", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, gz.String(), written()["notes/c.md.gz"], "the version is restored in its compressed form")
+}
+
+func TestHistoryCmd_Errors(t *testing.T) {
+	setupHistoryTest(t, historyTestVersions)
+
+	_, err := runHistoryCmd(t, "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no versions of note missing found")
+
+	_, err = runHistoryCmd(t, "a", "--show")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--version and --show must be used together")
+
+	currentConfig.Storage = types.DefaultConfig().Storage
+	currentConfig.Storage.Local.Path = t.TempDir()
+	_, err = runHistoryCmd(t, "a")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, types.ErrVersioningNotSupported)
+	assert.Contains(t, err.Error(), "storage.local.version_retention")
+}
+
+func TestHistoryCmd_Local(t *testing.T) {
+	root := useTestConfig(t, func(c *types.Config) {
+		c.Storage.Local.VersionRetention = 5
+	}).Storage.Local.Path
+	require.NoError(t, os.Mkdir(filepath.Join(root, "notes"), 0o755))
+
+	storageBackend := mustStorage(t)
+	ctx := context.Background()
+	for _, content := range []string{"first", "second"} {
+		require.NoError(t, storageBackend.Write(ctx, "notes/a.md", []byte(content)))
+	}
+
+	out, err := runHistoryCmd(t, "a")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3, out)
+	assert.Contains(t, lines[1], "current")
+	oldest := strings.Fields(lines[2])[0]
+
+	out, err = runHistoryCmd(t, "a", "--version", oldest, "--show")
+	require.NoError(t, err)
+	assert.Equal(t, "first", out)
+
+	_, err = runHistoryCmd(t, "a", "--restore", oldest)
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(root, "notes", "a.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	// The content the restore replaced is kept as a version
+	out, err = runHistoryCmd(t, "a")
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(out), "\n"), 4, out)
+}
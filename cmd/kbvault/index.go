@@ -0,0 +1,652 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector"
+)
+
+// vectorIndexPath is where the state of the vector index is saved in vault
+// storage
+const vectorIndexPath = ".kbvault/vector-index.json"
+
+// vectorCheckpointPath is where kbvault index build records the notes it
+// has indexed so far, so that an interrupted build resumes where it stopped
+const vectorCheckpointPath = ".kbvault/vector-index-checkpoint.json"
+
+// defaultVectorBatchSize is the number of chunks embedded per request when
+// vector_search.indexing.batch_size is not set
+const defaultVectorBatchSize = 100
+
+// vectorIndexState records what the vault has sent to the vector index, so
+// that chunks of changed or deleted notes can be removed
+type vectorIndexState struct {
+	Backend    types.VectorSearchType `json:"backend"`
+	Dimensions int                    `json:"dimensions"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+
+	// Notes maps each indexed note ID to its number of chunks
+	Notes map[string]int `json:"notes"`
+}
+
+// vectorCheckpoint records the progress of a build that hasn't finished
+type vectorCheckpoint struct {
+	Backend   types.VectorSearchType `json:"backend"`
+	UpdatedAt time.Time              `json:"updated_at"`
+
+	// Notes maps each note indexed so far to the fingerprint of its
+	// chunks, so that notes changed since are indexed again
+	Notes map[string]string `json:"notes"`
+}
+
+// vectorBuildResult reports what kbvault index build did
+type vectorBuildResult struct {
+	// Notes and Chunks count the vault's notes and their chunks
+	Notes  int
+	Chunks int
+
+	// Embedded and Upserted count the chunks embedded and stored by this
+	// run
+	Embedded int
+	Upserted int
+
+	// Skipped counts the notes an interrupted build had already indexed
+	Skipped int
+}
+
+// vectorIndexStatus is the output of kbvault index status
+type vectorIndexStatus struct {
+	Backend    types.VectorSearchType `json:"backend"`
+	Notes      int                    `json:"notes"`
+	Chunks     int                    `json:"chunks"`
+	Dimensions int                    `json:"dimensions"`
+	UpdatedAt  *time.Time             `json:"updated_at,omitempty"`
+}
+
+// vectorIndexer embeds notes from the search engine into a vector index
+type vectorIndexer struct {
+	storage  types.StorageBackend
+	engine   *search.Engine
+	backend  types.VectorSearchBackend
+	indexing types.IndexingConfig
+	out      io.Writer
+}
+
+func newIndexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Manage the vector search index",
+		Long: `Manage the vector index used for semantic search, separately from the
+full-text search index.
+
+Notes are split into chunks of vector_search.indexing.chunk_size characters,
+embedded with the configured embedding provider in batches of
+vector_search.indexing.batch_size, and stored in the vector_search backend.
+Vector search must be enabled in the configuration.`,
+	}
+
+	cmd.AddCommand(newIndexBuildCmd())
+	cmd.AddCommand(newIndexUpdateCmd())
+	cmd.AddCommand(newIndexDeleteCmd())
+	cmd.AddCommand(newIndexStatusCmd())
+
+	return cmd
+}
+
+func newIndexBuildCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Embed and index every note",
+		Long: `Embed every note in the vault and store it in the vector index. Chunks
+left over from notes that were deleted or shortened since the last build
+are removed. Progress is reported after each batch.
+
+Progress is checkpointed after each batch. If a build fails or is
+interrupted, running it again resumes where it stopped: notes the earlier
+run indexed, and that haven't changed since, are skipped rather than
+embedded again. Use --force to discard the checkpoint and index every
+note.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withVectorIndexer(cmd, func(v *vectorIndexer) error {
+				result, err := v.build(cmd.Context(), force)
+				out := cmd.OutOrStdout()
+				if err != nil {
+					if result != nil && result.Upserted > 0 {
+						_, _ = fmt.Fprintf(out, "Embedded %d chunks, upserted %d before failing\n", result.Embedded, result.Upserted)
+					}
+					return err
+				}
+				_, _ = fmt.Fprintf(out, "Indexed %d notes (%d chunks)\n", result.Notes, result.Chunks)
+				_, _ = fmt.Fprintf(out, "Embedded %d chunks, upserted %d, skipped %d notes already indexed\n", result.Embedded, result.Upserted, result.Skipped)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Ignore the checkpoint of an interrupted build and index every note")
+
+	return cmd
+}
+
+func newIndexUpdateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "update <note-id>",
+		Short: "Re-embed and index one note",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withVectorIndexer(cmd, func(v *vectorIndexer) error {
+				chunks, err := v.update(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Indexed %s (%d chunks)\n", args[0], chunks)
+				return nil
+			})
+		},
+	}
+}
+
+func newIndexDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <note-id>",
+		Short: "Remove one note from the vector index",
+		Long: `Remove a note and all of its chunks from the vector index. The note
+itself is not changed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withVectorIndexer(cmd, func(v *vectorIndexer) error {
+				if err := v.delete(cmd.Context(), args[0]); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed %s from the vector index\n", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func newIndexStatusCmd() *cobra.Command {
+	var outputJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show what the vector index contains",
+		Long: `Show the vector backend, the number of indexed notes and chunks, the
+embedding dimensions and when the index was last updated by kbvault index.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			state, err := loadVectorIndexState(cmd.Context(), storageBackend)
+			if err != nil {
+				return err
+			}
+
+			status := state.status()
+			if status.Backend == "" {
+				status.Backend = cfg.VectorSearch.Type
+			}
+			if outputJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(status)
+			}
+			return outputVectorIndexStatus(cmd.OutOrStdout(), status)
+		},
+	}
+
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output status as JSON")
+
+	return cmd
+}
+
+// withVectorIndexer opens storage, the search engine and the configured
+// vector backend for the current vault and runs fn with an indexer over
+// them. It fails if vector search is not enabled.
+func withVectorIndexer(cmd *cobra.Command, fn func(*vectorIndexer) error) error {
+	// Get profile-aware configuration
+	cfg := getConfig()
+	if cfg == nil {
+		return fmt.Errorf("configuration not initialized")
+	}
+	if !cfg.VectorSearch.Enabled {
+		return fmt.Errorf("vector search is not enabled (set vector_search.enabled in the configuration)")
+	}
+
+	// Initialize storage backend
+	storageBackend, err := storage.CreateStorage(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() {
+		if closeErr := storageBackend.Close(); closeErr != nil {
+			// Log error but don't fail the command (ignore write errors)
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+		}
+	}()
+
+	vectorConfig := vaultVectorConfig(cfg)
+	backend, err := vector.NewBackend(&vectorConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize vector search: %w", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	engine, err := openSearchEngine(cmd.Context(), storageBackend, searchOptions())
+	if err != nil {
+		return err
+	}
+
+	return fn(&vectorIndexer{
+		storage:  storageBackend,
+		engine:   engine,
+		backend:  backend,
+		indexing: cfg.VectorSearch.Indexing,
+		out:      cmd.OutOrStdout(),
+	})
+}
+
+// vaultVectorConfig returns the vector search configuration of cfg with a
+// relative local database path resolved against a local vault's root
+func vaultVectorConfig(cfg *types.Config) types.VectorSearchConfig {
+	vc := cfg.VectorSearch
+	dbPath := vc.Local.DatabasePath
+	if cfg.Storage.Type == types.StorageTypeLocal && dbPath != "" && !filepath.IsAbs(dbPath) {
+		vc.Local.DatabasePath = filepath.Join(cfg.Storage.Local.Path, dbPath)
+	}
+	return vc
+}
+
+// build indexes every note and removes chunks the vault no longer has.
+// Notes recorded in the checkpoint of an interrupted build are skipped
+// unless force is set. On failure the result counts the work done before
+// it.
+func (v *vectorIndexer) build(ctx context.Context, force bool) (*vectorBuildResult, error) {
+	state, err := loadVectorIndexState(ctx, v.storage)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := &vectorCheckpoint{Notes: make(map[string]string)}
+	if !force {
+		saved, err := loadVectorCheckpoint(ctx, v.storage)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil && saved.Backend == v.backend.Type() {
+			checkpoint = saved
+		}
+	}
+
+	docs := v.engine.Documents()
+	result := &vectorBuildResult{Notes: len(docs)}
+	counts := make(map[string]int, len(docs))
+	var chunks []*types.Document
+
+	// pending lists the notes to index with the index in chunks just past
+	// their last chunk, in order
+	type pendingNote struct {
+		id, fingerprint string
+		end             int
+	}
+	var pending []pendingNote
+	for _, doc := range docs {
+		noteChunks := vector.ChunkDocument(vectorDocument(doc), v.indexing)
+		counts[doc.ID] = len(noteChunks)
+		result.Chunks += len(noteChunks)
+
+		fingerprint := chunksFingerprint(noteChunks)
+		if checkpoint.Notes[doc.ID] == fingerprint {
+			result.Skipped++
+			continue
+		}
+		chunks = append(chunks, noteChunks...)
+		pending = append(pending, pendingNote{id: doc.ID, fingerprint: fingerprint, end: len(chunks)})
+	}
+
+	// Record each note in the checkpoint once all of its chunks are stored
+	next := 0
+	progress := func(done int) error {
+		marked := false
+		for ; next < len(pending) && pending[next].end <= done; next++ {
+			checkpoint.Notes[pending[next].id] = pending[next].fingerprint
+			marked = true
+		}
+		if !marked {
+			return nil
+		}
+		return v.saveCheckpoint(ctx, checkpoint)
+	}
+
+	stats, err := v.index(ctx, chunks, progress)
+	result.Embedded, result.Upserted = stats.embedded, stats.upserted
+	if err != nil {
+		return result, fmt.Errorf("index build stopped after %d of %d notes, run it again to resume: %w",
+			len(checkpoint.Notes), len(docs), err)
+	}
+
+	for id, count := range state.Notes {
+		v.removeStale(ctx, id, count, counts[id])
+	}
+
+	state.Notes = counts
+	if stats.dimensions > 0 {
+		state.Dimensions = stats.dimensions
+	}
+	if err := v.save(ctx, state); err != nil {
+		return result, err
+	}
+	if err := v.storage.Delete(ctx, vectorCheckpointPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		_, _ = fmt.Fprintf(v.out, "Warning: failed to remove the index build checkpoint: %v\n", err)
+	}
+	return result, nil
+}
+
+// update indexes one note and returns its number of chunks
+func (v *vectorIndexer) update(ctx context.Context, id string) (int, error) {
+	doc, ok := v.engine.DocumentByID(id)
+	if !ok {
+		return 0, fmt.Errorf("note not found: %s", id)
+	}
+
+	state, err := loadVectorIndexState(ctx, v.storage)
+	if err != nil {
+		return 0, err
+	}
+
+	chunks := vector.ChunkDocument(vectorDocument(doc), v.indexing)
+	stats, err := v.index(ctx, chunks, nil)
+	if err != nil {
+		return 0, err
+	}
+	if previous, ok := state.Notes[id]; ok {
+		v.removeStale(ctx, id, previous, len(chunks))
+	}
+
+	state.Notes[id] = len(chunks)
+	if stats.dimensions > 0 {
+		state.Dimensions = stats.dimensions
+	}
+	if err := v.save(ctx, state); err != nil {
+		return 0, err
+	}
+	return len(chunks), nil
+}
+
+// delete removes a note's chunks from the vector index. A note kbvault
+// index has no record of, such as one indexed by kbvault watch, is removed
+// by its ID.
+func (v *vectorIndexer) delete(ctx context.Context, id string) error {
+	state, err := loadVectorIndexState(ctx, v.storage)
+	if err != nil {
+		return err
+	}
+
+	count, ok := state.Notes[id]
+	if !ok {
+		count = 1
+	}
+	for _, chunkID := range chunkIDs(id, count) {
+		if err := v.backend.DeleteDocument(ctx, chunkID); err != nil {
+			return fmt.Errorf("failed to remove %s from the vector index: %w", id, err)
+		}
+	}
+
+	delete(state.Notes, id)
+	return v.save(ctx, state)
+}
+
+// indexStats counts the work done by index
+type indexStats struct {
+	// dimensions of the embeddings, or 0 when there was nothing to index
+	dimensions int
+
+	// embedded and upserted count the chunks embedded and stored
+	embedded int
+	upserted int
+}
+
+// index embeds chunks in batches of the configured batch size and stores
+// them, reporting progress when there is more than one batch. After each
+// batch is stored, progress, if set, is called with the number of chunks
+// stored so far. On failure the stats count the work done before it.
+func (v *vectorIndexer) index(ctx context.Context, chunks []*types.Document, progress func(done int) error) (indexStats, error) {
+	batchSize := v.indexing.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultVectorBatchSize
+	}
+
+	var stats indexStats
+	for start := 0; start < len(chunks); start += batchSize {
+		batch := chunks[start:min(start+batchSize, len(chunks))]
+
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			texts[i] = chunk.Content
+		}
+		embeddings, err := v.backend.GetEmbeddings(ctx, texts)
+		if err != nil {
+			return stats, fmt.Errorf("failed to embed notes: %w", err)
+		}
+		if len(embeddings) != len(batch) {
+			return stats, fmt.Errorf("failed to embed notes: expected %d embeddings, got %d", len(batch), len(embeddings))
+		}
+		for i, chunk := range batch {
+			chunk.Embedding = embeddings[i]
+		}
+		stats.dimensions = len(embeddings[0])
+		stats.embedded += len(batch)
+
+		if err := v.backend.IndexDocuments(ctx, batch); err != nil {
+			return stats, fmt.Errorf("failed to index notes: %w", err)
+		}
+		stats.upserted += len(batch)
+
+		if len(chunks) > batchSize {
+			_, _ = fmt.Fprintf(v.out, "Indexed %d/%d chunks\n", start+len(batch), len(chunks))
+		}
+		if progress != nil {
+			if err := progress(start + len(batch)); err != nil {
+				return stats, err
+			}
+		}
+	}
+	return stats, nil
+}
+
+// removeStale deletes the chunks a note had beyond those it has now. A
+// failure is reported as a warning; the chunks are only orphaned.
+func (v *vectorIndexer) removeStale(ctx context.Context, id string, previous, current int) {
+	keep := make(map[string]bool)
+	for _, chunkID := range chunkIDs(id, current) {
+		keep[chunkID] = true
+	}
+
+	for _, chunkID := range chunkIDs(id, previous) {
+		if keep[chunkID] {
+			continue
+		}
+		if err := v.backend.DeleteDocument(ctx, chunkID); err != nil {
+			_, _ = fmt.Fprintf(v.out, "Warning: failed to remove %s from the vector index: %v\n", chunkID, err)
+		}
+	}
+}
+
+// save records state as updated now by the current backend
+func (v *vectorIndexer) save(ctx context.Context, state *vectorIndexState) error {
+	state.Backend = v.backend.Type()
+	state.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode vector index state: %w", err)
+	}
+	if err := v.storage.Write(ctx, vectorIndexPath, data); err != nil {
+		return fmt.Errorf("failed to save vector index state: %w", err)
+	}
+	return nil
+}
+
+// saveCheckpoint records the progress of a build
+func (v *vectorIndexer) saveCheckpoint(ctx context.Context, checkpoint *vectorCheckpoint) error {
+	checkpoint.Backend = v.backend.Type()
+	checkpoint.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode index build checkpoint: %w", err)
+	}
+	if err := v.storage.Write(ctx, vectorCheckpointPath, data); err != nil {
+		return fmt.Errorf("failed to save index build checkpoint: %w", err)
+	}
+	return nil
+}
+
+// loadVectorCheckpoint reads the checkpoint of an interrupted build, or
+// returns nil when there is none
+func loadVectorCheckpoint(ctx context.Context, storageBackend types.StorageBackend) (*vectorCheckpoint, error) {
+	exists, err := storageBackend.Exists(ctx, vectorCheckpointPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check index build checkpoint: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := storageBackend.Read(ctx, vectorCheckpointPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index build checkpoint: %w", err)
+	}
+	checkpoint := &vectorCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode index build checkpoint: %w", err)
+	}
+	if checkpoint.Notes == nil {
+		checkpoint.Notes = make(map[string]string)
+	}
+	return checkpoint, nil
+}
+
+// chunksFingerprint identifies the content of a note's chunks
+func chunksFingerprint(chunks []*types.Document) string {
+	h := sha256.New()
+	for _, chunk := range chunks {
+		_, _ = io.WriteString(h, chunk.Content)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// chunkIDs returns the vector index IDs of a note split into count chunks.
+// A note that fits in one chunk is indexed under its own ID.
+func chunkIDs(noteID string, count int) []string {
+	if count == 0 {
+		return nil
+	}
+	if count == 1 {
+		return []string{noteID}
+	}
+
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = vector.ChunkID(noteID, i)
+	}
+	return ids
+}
+
+// loadVectorIndexState reads the saved vector index state, or returns an
+// empty state when the vault has none
+func loadVectorIndexState(ctx context.Context, storageBackend types.StorageBackend) (*vectorIndexState, error) {
+	state := &vectorIndexState{Notes: make(map[string]int)}
+
+	exists, err := storageBackend.Exists(ctx, vectorIndexPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check vector index state: %w", err)
+	}
+	if !exists {
+		return state, nil
+	}
+
+	data, err := storageBackend.Read(ctx, vectorIndexPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vector index state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to decode vector index state: %w", err)
+	}
+	if state.Notes == nil {
+		state.Notes = make(map[string]int)
+	}
+	return state, nil
+}
+
+// status summarizes the state
+func (s *vectorIndexState) status() vectorIndexStatus {
+	status := vectorIndexStatus{
+		Backend:    s.Backend,
+		Notes:      len(s.Notes),
+		Dimensions: s.Dimensions,
+	}
+	for _, count := range s.Notes {
+		status.Chunks += count
+	}
+	if !s.UpdatedAt.IsZero() {
+		updated := s.UpdatedAt
+		status.UpdatedAt = &updated
+	}
+	return status
+}
+
+func outputVectorIndexStatus(w io.Writer, status vectorIndexStatus) error {
+	var b strings.Builder
+
+	updated := "never"
+	if status.UpdatedAt != nil {
+		updated = status.UpdatedAt.Local().Format("2006-01-02 15:04:05")
+	}
+
+	fmt.Fprintf(&b, "Backend:       %s\n", status.Backend)
+	fmt.Fprintf(&b, "Notes:         %d\n", status.Notes)
+	fmt.Fprintf(&b, "Chunks:        %d\n", status.Chunks)
+	fmt.Fprintf(&b, "Dimensions:    %d\n", status.Dimensions)
+	fmt.Fprintf(&b, "Last updated:  %s\n", updated)
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/local"
+)
+
+// wordEmbedder embeds text by counting a few known words, so that notes
+// about the same thing are close together
+type wordEmbedder struct {
+	batches []int
+
+	// failAfter, if set, fails every batch after the first failAfter
+	failAfter int
+}
+
+var embedderWords = []string{"cat", "dog", "rocket", "garden"}
+
+func (w *wordEmbedder) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := w.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (w *wordEmbedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if w.failAfter > 0 && len(w.batches) >= w.failAfter {
+		return nil, errors.New("embedding service unavailable")
+	}
+	w.batches = append(w.batches, len(texts))
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		vec := make([]float64, len(embedderWords)+1)
+		for j, word := range embedderWords {
+			vec[j] = float64(strings.Count(strings.ToLower(text), word))
+		}
+		// Keep every vector non-zero
+		vec[len(embedderWords)] = 0.01
+		embeddings[i] = vec
+	}
+	return embeddings, nil
+}
+
+func (w *wordEmbedder) Dimensions() int {
+	return len(embedderWords) + 1
+}
+
+// vectorIndexFixture is a local vault indexed into a local vector backend
+// with a fake embedder
+type vectorIndexFixture struct {
+	root     string
+	indexer  *vectorIndexer
+	backend  *local.Backend
+	embedder *wordEmbedder
+	out      *bytes.Buffer
+}
+
+// setupVectorIndexer returns a fixture for a vault with the given notes,
+// keyed by note ID
+func setupVectorIndexer(t *testing.T, indexing types.IndexingConfig, notes map[string]string) *vectorIndexFixture {
+	t.Helper()
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "notes"), 0o755))
+	for id, body := range notes {
+		writeWatchNote(t, root, "notes/"+id+".md", id, strings.ToUpper(id[:1])+id[1:], body)
+	}
+
+	config := types.DefaultConfig().Storage
+	config.Local.Path = root
+	storageBackend, err := storage.CreateStorage(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storageBackend.Close() })
+
+	engine, err := openSearchEngine(context.Background(), storageBackend, searchOptions())
+	require.NoError(t, err)
+
+	embedder := &wordEmbedder{}
+	backend, err := local.New(types.VectorSearchConfig{
+		Local: types.LocalVectorConfig{DatabasePath: filepath.Join(root, "vector.db")},
+	}, embedder)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	return &vectorIndexFixture{
+		root: root,
+		indexer: &vectorIndexer{
+			storage:  storageBackend,
+			engine:   engine,
+			backend:  backend,
+			indexing: indexing,
+			out:      out,
+		},
+		backend:  backend,
+		embedder: embedder,
+		out:      out,
+	}
+}
+
+// vectorSearch returns the IDs of the documents nearest to query
+func vectorSearch(t *testing.T, backend types.VectorSearchBackend, query string) []string {
+	t.Helper()
+
+	results, err := backend.Search(context.Background(), &types.VectorQuery{Query: query, Limit: 10, MinScore: 0.5})
+	require.NoError(t, err)
+
+	ids := make([]string, len(results.Results))
+	for i, r := range results.Results {
+		ids[i] = r.Document.ID
+	}
+	return ids
+}
+
+func TestVectorIndexer_Build(t *testing.T) {
+	ctx := context.Background()
+	long := strings.Repeat("rocket engines burn fuel. ", 20) // 520 characters
+	f := setupVectorIndexer(t, types.IndexingConfig{ChunkSize: 200, BatchSize: 2}, map[string]string{
+		"cats":    "my cat sleeps all day",
+		"dogs":    "the dog wants a walk",
+		"rockets": long,
+	})
+	indexer, backend := f.indexer, f.backend
+
+	result, err := indexer.build(ctx, false)
+	require.NoError(t, err)
+	notes, chunks := result.Notes, result.Chunks
+	assert.Equal(t, 3, notes)
+	assert.Greater(t, chunks, 3)
+	assert.Equal(t, chunks, result.Embedded)
+	assert.Equal(t, chunks, result.Upserted)
+	assert.Zero(t, result.Skipped)
+	assert.Equal(t, chunks, backend.Count())
+
+	// Chunks are embedded in batches of BatchSize, with progress reported
+	assert.Len(t, f.embedder.batches, (chunks+1)/2)
+	for _, size := range f.embedder.batches {
+		assert.LessOrEqual(t, size, 2)
+	}
+	assert.Contains(t, f.out.String(), "Indexed 2/")
+	assert.Contains(t, f.out.String(), fmt.Sprintf("Indexed %d/%d chunks\n", chunks, chunks))
+
+	assert.Equal(t, []string{"cats"}, vectorSearch(t, backend, "cat"))
+	rocketIDs := vectorSearch(t, backend, "rocket")
+	require.NotEmpty(t, rocketIDs)
+	for _, id := range rocketIDs {
+		assert.True(t, strings.HasPrefix(id, "rockets#"), id)
+	}
+
+	state, err := loadVectorIndexState(ctx, indexer.storage)
+	require.NoError(t, err)
+	assert.Equal(t, types.VectorSearchTypeLocal, state.Backend)
+	assert.Equal(t, len(embedderWords)+1, state.Dimensions)
+	assert.Equal(t, 1, state.Notes["cats"])
+	assert.Equal(t, chunks-2, state.Notes["rockets"])
+	assert.False(t, state.UpdatedAt.IsZero())
+}
+
+func TestVectorIndexer_BuildResume(t *testing.T) {
+	ctx := context.Background()
+	f := setupVectorIndexer(t, types.IndexingConfig{BatchSize: 2}, map[string]string{
+		"cats":    "my cat sleeps all day",
+		"dogs":    "the dog wants a walk",
+		"gardens": "a garden full of roses",
+		"rockets": "rocket engines burn fuel",
+		"zebras":  "zebras have stripes",
+	})
+	indexer, backend := f.indexer, f.backend
+
+	// The embedder fails after two batches, four of the five notes
+	f.embedder.failAfter = 2
+	result, err := indexer.build(ctx, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index build stopped after 4 of 5 notes, run it again to resume")
+	assert.Contains(t, err.Error(), "embedding service unavailable")
+	assert.Equal(t, 4, result.Embedded)
+	assert.Equal(t, 4, result.Upserted)
+	assert.Equal(t, 4, backend.Count())
+
+	checkpoint, err := loadVectorCheckpoint(ctx, indexer.storage)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Len(t, checkpoint.Notes, 4)
+	state, err := loadVectorIndexState(ctx, indexer.storage)
+	require.NoError(t, err)
+	assert.Empty(t, state.Notes, "the index state is only saved by a complete build")
+
+	// A note changed since the interrupted run is indexed again
+	var changed string
+	for id := range checkpoint.Notes {
+		changed = id
+		break
+	}
+	writeWatchNote(t, f.root, "notes/"+changed+".md", changed, "Changed", "a dog in the garden")
+	require.NoError(t, indexer.engine.IndexFile(ctx, "notes/"+changed+".md"))
+
+	// Running again embeds only the rest
+	f.embedder.failAfter = 0
+	f.embedder.batches = nil
+	result, err = indexer.build(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Notes)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Equal(t, 2, result.Embedded)
+	assert.Equal(t, 2, result.Upserted)
+	assert.Equal(t, []int{2}, f.embedder.batches)
+	assert.Equal(t, 5, backend.Count())
+	assert.Contains(t, vectorSearch(t, backend, "zebras"), "zebras")
+
+	state, err = loadVectorIndexState(ctx, indexer.storage)
+	require.NoError(t, err)
+	assert.Len(t, state.Notes, 5)
+
+	// A complete build leaves no checkpoint, so the next one starts over
+	checkpoint, err = loadVectorCheckpoint(ctx, indexer.storage)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	f.embedder.batches = nil
+	result, err = indexer.build(ctx, false)
+	require.NoError(t, err)
+	assert.Zero(t, result.Skipped)
+	assert.Equal(t, 5, result.Embedded)
+}
+
+func TestVectorIndexer_BuildForce(t *testing.T) {
+	ctx := context.Background()
+	f := setupVectorIndexer(t, types.IndexingConfig{BatchSize: 1}, map[string]string{
+		"cats": "my cat sleeps all day",
+		"dogs": "the dog wants a walk",
+	})
+
+	f.embedder.failAfter = 1
+	_, err := f.indexer.build(ctx, false)
+	require.Error(t, err)
+
+	// --force ignores the checkpoint
+	f.embedder.failAfter = 0
+	result, err := f.indexer.build(ctx, true)
+	require.NoError(t, err)
+	assert.Zero(t, result.Skipped)
+	assert.Equal(t, 2, result.Embedded)
+}
+
+func TestVectorIndexer_UpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	f := setupVectorIndexer(t, types.IndexingConfig{ChunkSize: 200}, map[string]string{
+		"cats":    "my cat sleeps all day",
+		"rockets": strings.Repeat("rocket engines burn fuel. ", 20),
+	})
+	indexer, backend := f.indexer, f.backend
+
+	_, err := indexer.build(ctx, false)
+	require.NoError(t, err)
+	require.Greater(t, backend.Count(), 2)
+
+	// Shortening a note removes its old chunks
+	writeWatchNote(t, f.root, "notes/rockets.md", "rockets", "Rockets", "a garden rocket")
+	require.NoError(t, indexer.engine.IndexFile(ctx, "notes/rockets.md"))
+
+	chunks, err := indexer.update(ctx, "rockets")
+	require.NoError(t, err)
+	assert.Equal(t, 1, chunks)
+	assert.Equal(t, 2, backend.Count())
+	assert.Equal(t, []string{"rockets"}, vectorSearch(t, backend, "garden"))
+
+	_, err = indexer.update(ctx, "missing")
+	assert.ErrorContains(t, err, "note not found: missing")
+
+	require.NoError(t, indexer.delete(ctx, "cats"))
+	assert.Equal(t, 1, backend.Count())
+	assert.Empty(t, vectorSearch(t, backend, "cat"))
+
+	state, err := loadVectorIndexState(ctx, indexer.storage)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"rockets": 1}, state.Notes)
+}
+
+func TestIndexStatusCmd(t *testing.T) {
+	ctx := context.Background()
+	f := setupVectorIndexer(t, types.IndexingConfig{}, map[string]string{
+		"cats": "my cat sleeps all day",
+		"dogs": "the dog wants a walk",
+	})
+	_, err := f.indexer.build(ctx, false)
+	require.NoError(t, err)
+
+	useTestConfig(t, func(c *types.Config) { c.Storage.Local.Path = f.root })
+
+	cmd := newIndexCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"status"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "Backend:       local\n")
+	assert.Contains(t, out.String(), "Notes:         2\n")
+	assert.Contains(t, out.String(), "Chunks:        2\n")
+	assert.Contains(t, out.String(), "Dimensions:    5\n")
+	assert.NotContains(t, out.String(), "never")
+
+	cmd = newIndexCmd()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"status", "--json"})
+	require.NoError(t, cmd.Execute())
+	var status vectorIndexStatus
+	require.NoError(t, json.Unmarshal(out.Bytes(), &status))
+	assert.Equal(t, 2, status.Notes)
+	assert.NotNil(t, status.UpdatedAt)
+}
+
+func TestIndexCmdRequiresVectorSearch(t *testing.T) {
+	useTestConfig(t)
+
+	for _, args := range [][]string{{"build"}, {"update", "id"}, {"delete", "id"}} {
+		cmd := newIndexCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		require.Error(t, err, args)
+		assert.Contains(t, err.Error(), "vector search is not enabled")
+	}
+
+	// Status works without an index
+	cmd := newIndexCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"status"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "Notes:         0\n")
+	assert.Contains(t, out.String(), "Last updated:  never\n")
+}
+
+func TestVaultVectorConfig(t *testing.T) {
+	cfg := types.DefaultConfig()
+	cfg.Storage.Local.Path = "/vault"
+
+	assert.Equal(t, filepath.Join("/vault", "vector.db"), vaultVectorConfig(cfg).Local.DatabasePath)
+
+	cfg.VectorSearch.Local.DatabasePath = "/data/vectors.db"
+	assert.Equal(t, "/data/vectors.db", vaultVectorConfig(cfg).Local.DatabasePath)
+
+	cfg.VectorSearch.Local.DatabasePath = "vector.db"
+	cfg.Storage.Type = types.StorageTypeS3
+	assert.Equal(t, "vector.db", vaultVectorConfig(cfg).Local.DatabasePath)
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/templates"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -22,13 +29,56 @@ func newInitCmd() *cobra.Command {
 		Use:   "init [path]",
 		Short: "Initialize a new kbVault",
 		Long: `Initialize a new kbVault in the specified directory.
-Creates the necessary directory structure and configuration files.`,
+Creates the necessary directory structure and configuration files.
+
+Without --profile, the vault is self-contained: its configuration is written
+to .kbvault/config.toml and notes are stored in the directory itself.
+
+With --profile, init sets up that profile's storage, whatever its backend.
+A profile that doesn't exist yet is created with local storage in the
+given directory; for an existing profile the location comes from its
+storage settings.
+
+Either way init creates the notes, daily and templates directories named
+in the configuration, a default template and a welcome note. Object stores
+such as S3 have no directories, so for them init checks that the bucket is
+reachable instead. Existing notes are never touched; --force rewrites the
+configuration and starter files of a vault that is already initialized.
+
+Examples:
+  # Initialize a vault in the current directory
+  kbvault init
+
+  # Create a profile for a new vault and set it up
+  kbvault --profile research init ~/research
+
+  # Set up the storage of an existing S3 profile
+  kbvault --profile work init`,
 		Args: cobra.MaximumNArgs(1),
+		// A local vault needs no existing configuration, and a profile
+		// named with --profile is created if it doesn't exist
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if globalFlags.Profile != "" {
+				if err := initializeInitProfile(globalFlags.Profile); err != nil {
+					return err
+				}
+			}
+			return applyTimeout(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Determine vault path
+			ctx := cmd.Context()
+
 			if len(args) > 0 {
 				vaultPath = args[0]
-			} else if vaultPath == "" {
+			}
+			out := cmd.OutOrStdout()
+
+			if globalFlags.Profile != "" {
+				return initProfileVault(ctx, out, globalFlags.Profile, vaultPath, vaultName, force)
+			}
+
+			// Determine vault path
+			if vaultPath == "" {
 				var err error
 				vaultPath, err = os.Getwd()
 				if err != nil {
@@ -59,9 +109,16 @@ Creates the necessary directory structure and configuration files.`,
 				return fmt.Errorf("failed to create configuration: %w", err)
 			}
 
-			fmt.Printf("✅ Initialized kbVault at: %s\n", vaultPath)
-			fmt.Printf("📁 Configuration: %s\n", configPath)
-			fmt.Printf("📝 Notes directory: %s\n", filepath.Join(vaultPath, "notes"))
+			// Storage paths in the saved config are relative to the vault
+			cfg := localVaultConfig(vaultPath, vaultName)
+			cfg.Storage.Local.Path = vaultPath
+			if err := scaffoldVault(ctx, out, cfg, force); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(out, "✅ Initialized kbVault at: %s\n", vaultPath)
+			_, _ = fmt.Fprintf(out, "📁 Configuration: %s\n", configPath)
+			_, _ = fmt.Fprintf(out, "📝 Notes directory: %s\n", filepath.Join(vaultPath, cfg.Vault.NotesDir))
 
 			return nil
 		},
@@ -74,6 +131,85 @@ Creates the necessary directory structure and configuration files.`,
 	return cmd
 }
 
+// initializeInitProfile prepares the configuration for init --profile.
+// Unlike other commands, init accepts a profile that doesn't exist yet.
+func initializeInitProfile(profile string) error {
+	pm, err := config.NewProfileManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize profile manager: %w", err)
+	}
+	pm.SetStrictEnv(globalFlags.StrictEnv)
+
+	names, err := pm.ListProfileNames()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	for _, name := range names {
+		if name == profile {
+			return initializeConfig()
+		}
+	}
+
+	profileManager = pm
+	currentProfile = profile
+	currentConfig = nil
+	return nil
+}
+
+// initProfileVault sets up the storage of a profile, creating the profile
+// with local storage at vaultPath if it doesn't exist
+func initProfileVault(ctx context.Context, out io.Writer, profile, vaultPath, vaultName string, force bool) error {
+	pm := getProfileManager()
+	cfg := getConfig()
+
+	if cfg == nil {
+		if vaultPath == "" {
+			var err error
+			vaultPath, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+		}
+		absPath, err := filepath.Abs(vaultPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+		if vaultName == "" {
+			vaultName = filepath.Base(absPath)
+		}
+
+		if err := pm.CreateProfile(profile, &config.CreateProfileOptions{
+			StorageType: types.StorageTypeLocal,
+			LocalPath:   absPath,
+			VaultName:   vaultName,
+		}); err != nil {
+			return err
+		}
+		if cfg, err = pm.GetConfig(profile); err != nil {
+			return fmt.Errorf("failed to load configuration for profile '%s': %w", profile, err)
+		}
+		_, _ = fmt.Fprintf(out, "Profile '%s' created. Use 'kbvault profile switch %s' to make it active.\n", profile, profile)
+	} else {
+		if vaultPath != "" {
+			return fmt.Errorf("profile '%s' already exists; its vault location comes from its storage settings, so don't pass a path", profile)
+		}
+		initialized, err := vaultInitialized(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		if initialized && !force {
+			return fmt.Errorf("profile '%s' is already initialized (use --force to reinitialize)", profile)
+		}
+	}
+
+	if err := scaffoldVault(ctx, out, cfg, force); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "✅ Initialized profile '%s' (%s storage)\n", profile, cfg.Storage.Type)
+	return nil
+}
+
 func createVaultStructure(vaultPath string) error {
 	// Create main directories
 	dirs := []string{
@@ -105,19 +241,143 @@ func createVaultStructure(vaultPath string) error {
 }
 
 func createDefaultConfig(vaultPath, vaultName string) error {
+	cfg := localVaultConfig(vaultPath, vaultName)
+
+	// Save configuration
+	manager := config.NewManager()
+	configPath := filepath.Join(vaultPath, ".kbvault", "config.toml")
+
+	return manager.SaveToFile(cfg, configPath)
+}
+
+// localVaultConfig returns the starter configuration for a self-contained
+// vault, which stores notes in the vault directory itself
+func localVaultConfig(vaultPath, vaultName string) *types.Config {
 	// Use directory name if no vault name provided
 	if vaultName == "" {
 		vaultName = filepath.Base(vaultPath)
 	}
 
-	// Create default configuration
 	cfg := types.DefaultConfig()
 	cfg.Vault.Name = vaultName
-	// Note: VaultConfig doesn't have a Path field
+	cfg.Storage.Local.Path = "."
+	return cfg
+}
 
-	// Save configuration
-	manager := config.NewManager()
-	configPath := filepath.Join(vaultPath, ".kbvault", "config.toml")
+// scaffoldVault creates the directories named in cfg, a default template
+// and a welcome note in the vault's storage. Starter files that already
+// exist are only replaced with force.
+func scaffoldVault(ctx context.Context, out io.Writer, cfg *types.Config, force bool) error {
+	backend, err := storage.CreateStorage(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() {
+		if closeErr := backend.Close(); closeErr != nil {
+			_, _ = fmt.Fprintf(out, "Warning: failed to close storage: %v\n", closeErr)
+		}
+	}()
 
-	return manager.SaveToFile(cfg, configPath)
+	if cfg.Storage.Type == types.StorageTypeLocal {
+		for _, dir := range []string{cfg.Vault.NotesDir, cfg.Vault.DailyDir, cfg.Vault.TemplatesDir} {
+			if dir == "" {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Join(cfg.Storage.Local.Path, dir), 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", dir, err)
+			}
+		}
+	} else if err := backend.Health(ctx); err != nil {
+		// Object stores have no directories to create; make sure the
+		// bucket is reachable with the configured credentials
+		return fmt.Errorf("failed to reach %s storage: %w", cfg.Storage.Type, err)
+	}
+
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{starterTemplatePath(cfg), []byte(starterTemplate(cfg))},
+		{welcomeNotePath(cfg), welcomeNote(cfg, time.Now())},
+	}
+	for _, file := range files {
+		exists, err := backend.Exists(ctx, file.path)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", file.path, err)
+		}
+		if exists && !force {
+			continue
+		}
+		if err := backend.Write(ctx, file.path, file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+		_, _ = fmt.Fprintf(out, "Created %s\n", file.path)
+	}
+
+	return nil
+}
+
+// vaultInitialized reports whether init has already set up the vault's
+// storage, judging by the welcome note
+func vaultInitialized(ctx context.Context, cfg *types.Config) (bool, error) {
+	backend, err := storage.CreateStorage(cfg.Storage)
+	if err != nil {
+		return false, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	exists, err := backend.Exists(ctx, welcomeNotePath(cfg))
+	if err != nil {
+		return false, fmt.Errorf("failed to check vault: %w", err)
+	}
+	return exists, nil
+}
+
+// starterTemplatePath is where init writes the vault's default template
+func starterTemplatePath(cfg *types.Config) string {
+	name := cfg.Vault.DefaultTemplate
+	if name == "" {
+		name = "default"
+	}
+	return path.Join(cfg.Vault.TemplatesDir, name+".md")
+}
+
+// starterTemplate returns the content of the vault's default template,
+// the built-in template of the same name if there is one
+func starterTemplate(cfg *types.Config) string {
+	if content, ok := templates.Builtin(cfg.Vault.DefaultTemplate); ok {
+		return content
+	}
+	content, _ := templates.Builtin("default")
+	return content
+}
+
+// welcomeNotePath is where init writes the welcome note
+func welcomeNotePath(cfg *types.Config) string {
+	return path.Join(cfg.Vault.NotesDir, "welcome.md")
+}
+
+// welcomeNote renders the note init creates to introduce a new vault
+func welcomeNote(cfg *types.Config, now time.Time) []byte {
+	fm := types.Frontmatter{
+		ID:      "welcome",
+		Title:   "Welcome to " + cfg.Vault.Name,
+		Tags:    []string{"kbvault"},
+		Type:    "note",
+		Created: note.FormatTimestamp(now),
+		Updated: note.FormatTimestamp(now),
+	}
+	body := fmt.Sprintf(`# Welcome to %s
+
+This vault was created by kbvault init. A few things to try:
+
+- kbvault new "My First Note" --tags ideas
+- kbvault list
+- kbvault search "welcome"
+
+Templates live in %s/ and new notes in %s/. Delete this note whenever you like.
+`, cfg.Vault.Name, cfg.Vault.TemplatesDir, cfg.Vault.NotesDir)
+
+	note.UpdateChecksum(&fm, body, cfg.Vault.TrackChecksums)
+	return note.SerializeNote(fm, body)
 }
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -296,3 +299,127 @@ func TestDefaultConfigStructure(t *testing.T) {
 		t.Error("Default config should have a logging level")
 	}
 }
+
+// runInitCmd runs kbvault init through the root command so that global
+// flags such as --profile apply
+func runInitCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	oldConfig, oldProfile, oldManager, oldFlags := currentConfig, currentProfile, profileManager, *globalFlags
+	t.Cleanup(func() {
+		currentConfig, currentProfile, profileManager, *globalFlags = oldConfig, oldProfile, oldManager, oldFlags
+	})
+
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// checkStarterLayout verifies the directories and files init creates in a
+// local vault stored at root
+func checkStarterLayout(t *testing.T, root string) {
+	t.Helper()
+
+	for _, dir := range []string{"notes", "notes/dailies", "templates"} {
+		if info, err := os.Stat(filepath.Join(root, dir)); err != nil || !info.IsDir() {
+			t.Errorf("directory %s was not created: %v", dir, err)
+		}
+	}
+
+	template, err := os.ReadFile(filepath.Join(root, "templates", "default.md"))
+	if err != nil {
+		t.Fatalf("default template was not created: %v", err)
+	}
+	if !strings.Contains(string(template), "{{.Title}}") {
+		t.Errorf("unexpected default template:\n%s", template)
+	}
+
+	welcome, err := os.ReadFile(filepath.Join(root, "notes", "welcome.md"))
+	if err != nil {
+		t.Fatalf("welcome note was not created: %v", err)
+	}
+	n := note.Parse("notes/welcome.md", welcome)
+	if n.ID != "welcome" || !strings.HasPrefix(n.Title, "Welcome to ") {
+		t.Errorf("unexpected welcome note %q: %q", n.ID, n.Title)
+	}
+}
+
+func TestInitCmdLocalVault(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "kb")
+
+	out, err := runInitCmd(t, "init", dir, "--name", "Research")
+	if err != nil {
+		t.Fatalf("init: %v\n%s", err, out)
+	}
+	checkStarterLayout(t, dir)
+
+	// Notes are stored in the vault directory itself
+	cfg, err := config.NewManager().LoadFromFile(filepath.Join(dir, ".kbvault", "config.toml"))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.Vault.Name != "Research" || cfg.Storage.Local.Path != "." {
+		t.Errorf("unexpected config: name %q, path %q", cfg.Vault.Name, cfg.Storage.Local.Path)
+	}
+
+	// Reinitializing needs --force, which rewrites the starter files
+	welcomePath := filepath.Join(dir, "notes", "welcome.md")
+	if err := os.WriteFile(welcomePath, []byte("# Mine\n"), 0644); err != nil {
+		t.Fatalf("failed to edit welcome note: %v", err)
+	}
+	if _, err := runInitCmd(t, "init", dir); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+	if _, err := runInitCmd(t, "init", dir, "--force"); err != nil {
+		t.Fatalf("init --force: %v", err)
+	}
+	if data, _ := os.ReadFile(welcomePath); strings.Contains(string(data), "# Mine") {
+		t.Error("init --force should rewrite the welcome note")
+	}
+}
+
+func TestInitCmdProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "research")
+
+	out, err := runInitCmd(t, "--profile", "research", "init", dir)
+	if err != nil {
+		t.Fatalf("init --profile: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Profile 'research' created") {
+		t.Errorf("expected profile creation message:\n%s", out)
+	}
+	checkStarterLayout(t, dir)
+
+	// The profile's storage points at the vault; no local config is written
+	pm, err := config.NewProfileManager()
+	if err != nil {
+		t.Fatalf("failed to create profile manager: %v", err)
+	}
+	cfg, err := pm.GetConfig("research")
+	if err != nil {
+		t.Fatalf("failed to load profile: %v", err)
+	}
+	if cfg.Storage.Type != types.StorageTypeLocal || cfg.Storage.Local.Path != dir || cfg.Vault.Name != "research" {
+		t.Errorf("unexpected profile config: %s %q %q", cfg.Storage.Type, cfg.Storage.Local.Path, cfg.Vault.Name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".kbvault", "config.toml")); !os.IsNotExist(err) {
+		t.Errorf("init --profile should not write a local config: %v", err)
+	}
+
+	// An existing profile takes its location from the profile
+	if _, err := runInitCmd(t, "--profile", "research", "init"); err == nil || !strings.Contains(err.Error(), "already initialized") {
+		t.Errorf("expected already initialized error, got %v", err)
+	}
+	if _, err := runInitCmd(t, "--profile", "research", "init", dir); err == nil || !strings.Contains(err.Error(), "don't pass a path") {
+		t.Errorf("expected path error, got %v", err)
+	}
+	if _, err := runInitCmd(t, "--profile", "research", "init", "--force"); err != nil {
+		t.Errorf("init --force on existing profile: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// formatJSONLines is the --format value that writes one JSON object per
+// line (newline-delimited JSON), so consumers can process results without
+// buffering them all
+const formatJSONLines = "jsonl"
+
+// sortNone is the list --sort value that leaves notes in the order they
+// are read, so --format jsonl can write them as they are found
+const sortNone = search.SortNone
+
+// errListLimitReached stops a note walk once --limit notes are written
+var errListLimitReached = errors.New("list limit reached")
+
+// noteJSONLine is a note as list --format jsonl writes it
+type noteJSONLine struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	FilePath string    `json:"file_path"`
+	Tags     []string  `json:"tags"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+
+	// Stats are only filled in with --stats
+	*note.NoteStats
+}
+
+func newNoteJSONLine(n *types.Note, showStats bool) noteJSONLine {
+	tags := noteTags(n)
+	if tags == nil {
+		tags = []string{}
+	}
+	line := noteJSONLine{
+		ID:       n.ID,
+		Title:    n.Title,
+		FilePath: n.FilePath,
+		Tags:     tags,
+		Created:  n.CreatedAt,
+		Updated:  n.UpdatedAt,
+	}
+	if showStats {
+		stats := noteStats(n)
+		line.NoteStats = &stats
+	}
+	return line
+}
+
+// displayNotesJSONLines writes notes one JSON object per line
+func displayNotesJSONLines(w io.Writer, notes []*types.Note, showStats bool) error {
+	encoder := json.NewEncoder(w)
+	for _, n := range notes {
+		if err := encoder.Encode(newNoteJSONLine(n, showStats)); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return nil
+}
+
+// streamNotesJSONLines writes notes one JSON object per line as they are
+// read, in storage order, keeping notes with any of tags when tags are
+// given and stopping after limit notes when limit is positive
+func streamNotesJSONLines(ctx context.Context, w io.Writer, storage types.StorageBackend, tags []string, limit int, showStats bool) error {
+	encoder := json.NewEncoder(w)
+	written := 0
+
+	err := walkNoteFiles(ctx, storage, func(file string, info *types.FileInfo) error {
+		n, err := parseNoteFile(ctx, storage, file, info)
+		if err != nil {
+			// Skip files that can't be parsed, as list does
+			return ctx.Err()
+		}
+		if len(tags) > 0 && !hasAnyTag(noteTags(n), tags) {
+			return nil
+		}
+
+		if err := encoder.Encode(newNoteJSONLine(n, showStats)); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		written++
+		if limit > 0 && written >= limit {
+			return errListLimitReached
+		}
+		return nil
+	})
+	if errors.Is(err, errListLimitReached) {
+		return nil
+	}
+	return err
+}
+
+// streamSearchJSONLines runs query and writes each result as one JSON
+// object per line as the engine sends it. With --sort none results are
+// written as soon as they are scored; other sorts need every result
+// first.
+func streamSearchJSONLines(ctx context.Context, w io.Writer, engine *search.Engine, query search.SearchQuery) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results, errc := engine.SearchStream(ctx, query)
+	encoder := json.NewEncoder(w)
+	for result := range results {
+		if err := encoder.Encode(result); err != nil {
+			// Stop the search before giving up on its results
+			cancel()
+			for range results {
+			}
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	if err := <-errc; err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	return nil
+}
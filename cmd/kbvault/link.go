@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/links"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// relatedHeading is the section the link command adds links under
+const relatedHeading = "## Related"
+
+func newLinkCmd() *cobra.Command {
+	var bidirectional bool
+
+	cmd := &cobra.Command{
+		Use:   "link <from-id> <to-id>",
+		Short: "Link one note to another",
+		Long: `Add a [[to-id]] wikilink to the "Related" section of a note, creating the
+section at the end of the note if it doesn't exist. The target note must
+exist. Nothing is added if the note already links to the target, whether
+by ID or by title.
+
+The note's frontmatter is kept and its updated timestamp is refreshed.
+
+Examples:
+  # Link a note to another
+  kbvault link 01HQ2X3Y4Z 01HQ2X5A6B
+
+  # Link both notes to each other
+  kbvault link 01HQ2X3Y4Z 01HQ2X5A6B --bidirectional`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeNoteIDs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			notes, err := listAllNotes(ctx, storageBackend)
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
+			}
+			resolver := newVaultNoteResolver(notes)
+
+			from, err := resolver.ResolveByID(args[0])
+			if err != nil {
+				return err
+			}
+			to, err := resolver.ResolveByID(args[1])
+			if err != nil {
+				return err
+			}
+			if from.ID == to.ID {
+				return fmt.Errorf("cannot link note %s to itself", from.ID)
+			}
+
+			pairs := [][2]*types.Note{{from, to}}
+			if bidirectional {
+				pairs = append(pairs, [2]*types.Note{to, from})
+			}
+
+			now := time.Now()
+			for _, pair := range pairs {
+				source, target := pair[0], pair[1]
+				added, err := addNoteLink(ctx, cmd.ErrOrStderr(), storageBackend, resolver, source, target, now)
+				if err != nil {
+					return err
+				}
+				if added {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Linked %s -> %s\n", source.ID, target.ID)
+				} else {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s already links to %s\n", source.ID, target.ID)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&bidirectional, "bidirectional", false, "Also link the target note back to the source")
+
+	return cmd
+}
+
+// addNoteLink adds a [[to]] link to the Related section of from unless
+// from already links to to. It reports whether the note was changed.
+func addNoteLink(ctx context.Context, warnings io.Writer, storage types.StorageBackend, resolver *vaultNoteResolver, from, to *types.Note, now time.Time) (bool, error) {
+	existing, err := links.New(resolver).ParseLinks(from)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse links for note %s: %w", from.ID, err)
+	}
+	for _, link := range existing {
+		if link.IsValid && link.TargetID == to.ID {
+			return false, nil
+		}
+	}
+
+	original, err := storage.Read(ctx, from.FilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read note %s: %w", from.ID, err)
+	}
+
+	body := addRelatedLink(editableContent(original, false), to.ID)
+	updated, err := applyNoteEdit(original, body, false, now)
+	if err != nil {
+		return false, err
+	}
+
+	if err := storage.Write(ctx, from.FilePath, updated); err != nil {
+		return false, fmt.Errorf("failed to save note %s: %w", from.ID, err)
+	}
+	syncSearchIndex(ctx, warnings, storage, from.FilePath, false)
+	return true, nil
+}
+
+// addRelatedLink adds a "- [[id]]" item to the end of the Related section
+// of body, appending the section when body has none
+func addRelatedLink(body, id string) string {
+	return addSectionEntry(body, relatedHeading, "- [["+id+"]]")
+}
+
+// addSectionEntry adds entry as the last line of the section of body under
+// heading, appending the section when body has none
+func addSectionEntry(body, heading, entry string) string {
+	text := strings.TrimRight(body, "\n")
+	lines := strings.Split(text, "\n")
+
+	start := -1
+	for i, line := range lines {
+		if strings.EqualFold(strings.TrimSpace(line), heading) {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		if text != "" {
+			text += "\n\n"
+		}
+		return text + heading + "\n\n" + entry + "\n"
+	}
+
+	// The section runs until the next heading of the same or a higher level
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "# ") || strings.HasPrefix(trimmed, "## ") {
+			end = i
+			break
+		}
+	}
+
+	// Add after the section's last non-blank line
+	insert := start + 1
+	for i := end - 1; i > start; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			insert = i + 1
+			break
+		}
+	}
+
+	added := []string{entry}
+	if insert == start+1 {
+		added = append([]string{""}, added...)
+	}
+	if insert < len(lines) && strings.TrimSpace(lines[insert]) != "" {
+		added = append(added, "")
+	}
+
+	result := append(append(append([]string{}, lines[:insert]...), added...), lines[insert:]...)
+	return strings.Join(result, "\n") + "\n"
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestAddRelatedLink(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "creates section",
+			body: "# Alpha\n\nSome text.\n",
+			want: "# Alpha\n\nSome text.\n\n## Related\n\n- [[b]]\n",
+		},
+		{
+			name: "empty body",
+			body: "",
+			want: "## Related\n\n- [[b]]\n",
+		},
+		{
+			name: "appends to existing section",
+			body: "# Alpha\n\n## Related\n\n- [[c]]\n",
+			want: "# Alpha\n\n## Related\n\n- [[c]]\n- [[b]]\n",
+		},
+		{
+			name: "section followed by another section",
+			body: "# Alpha\n\n## Related\n\n- [[c]]\n\n## Notes\n\nMore.\n",
+			want: "# Alpha\n\n## Related\n\n- [[c]]\n- [[b]]\n\n## Notes\n\nMore.\n",
+		},
+		{
+			name: "empty section",
+			body: "# Alpha\n\n## related\n## Notes\n",
+			want: "# Alpha\n\n## related\n\n- [[b]]\n\n## Notes\n",
+		},
+		{
+			name: "subheadings stay in the section",
+			body: "## Related\n\n### Papers\n\n- [[c]]\n",
+			want: "## Related\n\n### Papers\n\n- [[c]]\n- [[b]]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, addRelatedLink(tt.body, "b"))
+		})
+	}
+}
+
+const linkTestNote = `---
+id: %s
+title: %s
+tags:
+  - go
+created: 2024-01-01T00:00:00Z
+updated: 2024-01-01T00:00:00Z
+---
+
+# %s
+
+%s
+`
+
+// setupLinkTestVault writes notes a, b and c to a temporary vault; a
+// already mentions Gamma by title
+func setupLinkTestVault(t *testing.T) string {
+	t.Helper()
+
+	files := make(map[string]string)
+	for _, n := range []struct{ id, title, body string }{
+		{"a", "Alpha", "Builds on [[Gamma]]."},
+		{"b", "Beta", "Standalone."},
+		{"c", "Gamma", "Background."},
+	} {
+		files["notes/"+n.id+".md"] = fmt.Sprintf(linkTestNote, n.id, n.title, n.title, n.body)
+	}
+	return setupTestVault(t, files)
+}
+
+func readLinkTestNote(t *testing.T, dir, id string) (types.Frontmatter, string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, "notes", id+".md"))
+	require.NoError(t, err)
+
+	front, body, ok := note.SplitFrontmatter(string(data))
+	require.True(t, ok, "frontmatter lost:\n%s", data)
+	fm, err := note.ParseFrontmatter(front)
+	require.NoError(t, err)
+	return fm, body
+}
+
+func runLinkCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newLinkCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestLinkCmd(t *testing.T) {
+	dir := setupLinkTestVault(t)
+
+	out, err := runLinkCmd(t, "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, "Linked a -> b\n", out)
+
+	fm, body := readLinkTestNote(t, dir, "a")
+	assert.Equal(t, "# Alpha\n\nBuilds on [[Gamma]].\n\n## Related\n\n- [[b]]\n", body)
+	assert.Equal(t, "Alpha", fm.Title)
+	assert.Equal(t, []string{"go"}, fm.Tags)
+	assert.Equal(t, "2024-01-01T00:00:00Z", fm.Created)
+	assert.NotEqual(t, "2024-01-01T00:00:00Z", fm.Updated)
+
+	// The target note is untouched
+	fm, _ = readLinkTestNote(t, dir, "b")
+	assert.Equal(t, "2024-01-01T00:00:00Z", fm.Updated)
+}
+
+func TestLinkCmd_Dedup(t *testing.T) {
+	dir := setupLinkTestVault(t)
+
+	_, err := runLinkCmd(t, "a", "b")
+	require.NoError(t, err)
+	_, before := readLinkTestNote(t, dir, "a")
+
+	out, err := runLinkCmd(t, "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, "a already links to b\n", out)
+
+	// A link by title counts as well
+	out, err = runLinkCmd(t, "a", "c")
+	require.NoError(t, err)
+	assert.Equal(t, "a already links to c\n", out)
+
+	_, after := readLinkTestNote(t, dir, "a")
+	assert.Equal(t, before, after)
+	assert.Equal(t, 1, strings.Count(after, "[[b]]"))
+}
+
+func TestLinkCmd_Bidirectional(t *testing.T) {
+	dir := setupLinkTestVault(t)
+
+	out, err := runLinkCmd(t, "b", "c", "--bidirectional")
+	require.NoError(t, err)
+	assert.Equal(t, "Linked b -> c\nLinked c -> b\n", out)
+
+	_, body := readLinkTestNote(t, dir, "b")
+	assert.Contains(t, body, "## Related\n\n- [[c]]\n")
+	_, body = readLinkTestNote(t, dir, "c")
+	assert.Contains(t, body, "## Related\n\n- [[b]]\n")
+
+	// a already links to c, so only the reverse link is added
+	out, err = runLinkCmd(t, "a", "c", "--bidirectional")
+	require.NoError(t, err)
+	assert.Equal(t, "a already links to c\nLinked c -> a\n", out)
+	_, body = readLinkTestNote(t, dir, "c")
+	assert.Contains(t, body, "- [[b]]\n- [[a]]\n")
+}
+
+func TestLinkCmd_Errors(t *testing.T) {
+	setupLinkTestVault(t)
+
+	_, err := runLinkCmd(t, "a", "missing")
+	require.Error(t, err)
+	assert.True(t, types.IsNotFoundError(err))
+
+	_, err = runLinkCmd(t, "a", "a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "to itself")
+}
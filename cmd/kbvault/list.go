@@ -3,13 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
-	"path/filepath"
+	"io"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
@@ -17,18 +19,43 @@ import (
 func newListCmd() *cobra.Command {
 	var (
 		format    string
+		tmplText  string
+		asJSON    bool
+		countOnly bool
 		sortBy    string
 		reverse   bool
 		limit     int
 		tags      []string
 		showPaths bool
+		showStats bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all notes with metadata",
 		Long: `List all notes in the vault with their metadata.
-Supports filtering by tags and various sorting options.`,
+Supports filtering by tags and various sorting options.
+
+With --count-only only the number of notes matching the tag filter is
+printed, ignoring --limit, or {"count": N} with --json.
+
+With --format jsonl each note is written as one JSON object per line.
+With --sort none as well, notes are written as they are read, in storage
+order, without waiting for the whole vault:
+
+  kbvault list --format jsonl --sort none | jq -r .file_path
+
+With --stats each note's word count, character count and estimated
+reading time are shown as well. Counting scans the whole body of every
+listed note, which takes noticeably longer in large vaults, so it is off
+by default.
+
+With --format template each note is rendered through the Go template
+given with --template, which can use the fields ID, Title, Tags, Type,
+FilePath, StorageBackend, CreatedAt, UpdatedAt and Size, and the join,
+lower and upper functions:
+
+  kbvault list --format template --template '{{.ID}} {{.Title}} {{range .Tags}}#{{.}} {{end}}'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Get profile-aware configuration
 			config := getConfig()
@@ -36,6 +63,15 @@ Supports filtering by tags and various sorting options.`,
 				return fmt.Errorf("configuration not initialized")
 			}
 
+			var tmpl *template.Template
+			if format == formatTemplate && !asJSON {
+				parsed, err := parseOutputTemplate(tmplText)
+				if err != nil {
+					return err
+				}
+				tmpl = parsed
+			}
+
 			// Initialize storage backend
 			storage, err := storage.CreateStorage(config.Storage)
 			if err != nil {
@@ -44,12 +80,17 @@ Supports filtering by tags and various sorting options.`,
 			defer func() {
 				if err := storage.Close(); err != nil {
 					// Log error but don't fail the command
-					fmt.Printf("Warning: failed to close storage: %v\n", err)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", err)
 				}
 			}()
 
+			// Unsorted JSON lines are written as the notes are read
+			if format == formatJSONLines && sortBy == sortNone && !asJSON && !countOnly {
+				return streamNotesJSONLines(cmd.Context(), cmd.OutOrStdout(), storage, tags, limit, showStats)
+			}
+
 			// List all notes
-			notes, err := listAllNotes(storage)
+			notes, err := listAllNotes(cmd.Context(), storage)
 			if err != nil {
 				return fmt.Errorf("failed to list notes: %w", err)
 			}
@@ -59,6 +100,13 @@ Supports filtering by tags and various sorting options.`,
 				notes = filterNotesByTags(notes, tags)
 			}
 
+			if asJSON {
+				format = "json"
+			}
+			if countOnly {
+				return outputCount(cmd.OutOrStdout(), len(notes), format)
+			}
+
 			// Sort notes
 			sortNotes(notes, sortBy, reverse)
 
@@ -68,315 +116,202 @@ Supports filtering by tags and various sorting options.`,
 			}
 
 			// Display results
+			out := cmd.OutOrStdout()
 			switch format {
 			case "json":
-				return displayNotesJSON(notes)
+				return displayNotesJSON(out, notes, showStats)
+			case formatJSONLines:
+				return displayNotesJSONLines(out, notes, showStats)
 			case "compact":
-				return displayNotesCompact(notes, showPaths)
+				return displayNotesCompact(out, notes, showPaths, showStats)
+			case formatTemplate:
+				return displayNotesTemplate(out, notes, tmpl)
 			default:
-				return displayNotesDefault(notes, showPaths)
+				return displayNotesDefault(out, notes, showPaths, showStats, newOutputStyle(out))
 			}
 		},
 	}
 
-	cmd.Flags().StringVarP(&format, "format", "f", "default", "Output format (default, compact, json)")
-	cmd.Flags().StringVarP(&sortBy, "sort", "s", "updated", "Sort by field (title, created, updated)")
+	cmd.Flags().StringVarP(&format, "format", "f", "default", "Output format (default, compact, json, jsonl, template)")
+	cmd.Flags().StringVar(&tmplText, "template", "", "Go template rendered for each note with --format template")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON (same as --format json)")
+	cmd.Flags().BoolVar(&countOnly, "count-only", false, "Print only the number of notes")
+	cmd.Flags().StringVarP(&sortBy, "sort", "s", "updated", "Sort by field (title, created, updated, none)")
 	cmd.Flags().BoolVarP(&reverse, "reverse", "r", false, "Reverse sort order")
 	cmd.Flags().IntVarP(&limit, "limit", "l", 0, "Limit number of results (0 = no limit)")
 	cmd.Flags().StringSliceVarP(&tags, "tags", "t", []string{}, "Filter by tags (comma-separated)")
 	cmd.Flags().BoolVarP(&showPaths, "paths", "p", false, "Show file paths")
+	cmd.Flags().BoolVar(&showStats, "stats", false, "Show word count, character count and reading time (reads every note body)")
 
 	return cmd
 }
 
-func listAllNotes(storage types.StorageBackend) ([]*types.Note, error) {
-	ctx := context.Background()
-
-	// Try to list files from common directories
-	dirs := []string{"", "notes/", "daily/"}
-	var allFiles []string
-
-	for _, dir := range dirs {
-		files, err := storage.List(ctx, dir)
-		if err != nil {
-			// Continue if directory doesn't exist
-			continue
-		}
-		allFiles = append(allFiles, files...)
-	}
-
-	// Remove duplicates (in case files appear in multiple directories)
-	fileSet := make(map[string]bool)
-	var uniqueFiles []string
-	for _, f := range allFiles {
-		if !fileSet[f] {
-			fileSet[f] = true
-			uniqueFiles = append(uniqueFiles, f)
-		}
-	}
-
+func listAllNotes(ctx context.Context, storage types.StorageBackend) ([]*types.Note, error) {
 	var notes []*types.Note
 
-	for _, file := range uniqueFiles {
-		// Filter for markdown files only
-		if !strings.HasSuffix(file, ".md") {
-			continue
-		}
-
+	err := walkNoteFiles(ctx, storage, func(file string, info *types.FileInfo) error {
 		// Read and parse the note
-		note, err := readAndParseNote(storage, file)
+		note, err := parseNoteFile(ctx, storage, file, info)
 		if err != nil {
 			// Skip files that can't be parsed
 			// but don't fail the entire list command
-			continue
+			return ctx.Err()
 		}
 
 		notes = append(notes, note)
-	}
+		return nil
+	})
 
-	return notes, nil
+	return notes, err
 }
 
-// readAndParseNote reads a note file and extracts its metadata
-func readAndParseNote(storage types.StorageBackend, filePath string) (*types.Note, error) {
-	ctx := context.Background()
-
-	// Read file content
-	data, err := storage.Read(ctx, filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
-	}
-
-	content := string(data)
-	note := &types.Note{
-		FilePath: filePath,
-	}
-
-	// Extract ID from filename (remove .md extension)
-	base := filepath.Base(filePath)
-	if strings.HasSuffix(base, ".md") {
-		note.ID = strings.TrimSuffix(base, ".md")
-	} else {
-		note.ID = base
-	}
-
-	// Parse frontmatter and content to extract title and metadata
-	parsedContent := parseNoteMetadata(content, note)
-
-	// Fallback to ID if title is still empty
-	if note.Title == "" {
-		note.Title = note.ID
-	}
+// listNoteFiles returns the markdown files in the directories notes are
+// kept in
+func listNoteFiles(ctx context.Context, storage types.StorageBackend) []string {
+	var files []string
+	_ = walkNoteFiles(ctx, storage, func(file string, _ *types.FileInfo) error {
+		files = append(files, file)
+		return nil
+	})
+	return files
+}
 
-	// Get file metadata for size and timestamps
-	fileInfo, err := storage.Stat(ctx, filePath)
-	if err == nil {
-		note.Size = fileInfo.Size
-		// Use ModTime for both created and updated if available
-		if fileInfo.ModTime > 0 {
-			modTime := time.Unix(0, fileInfo.ModTime*int64(time.Millisecond))
-			note.UpdatedAt = modTime
-			// If CreatedAt is zero, use UpdatedAt as default
-			if note.CreatedAt.IsZero() {
-				note.CreatedAt = modTime
+// walkNoteFiles calls fn for each markdown file in the directories notes
+// are kept in, once even when it is found in several of them. Files
+// matching vault.ignore are skipped. Directories that can't be walked, e.g.
+// because they don't exist, are skipped; an error from fn, or ctx being
+// done, stops the walk and is returned.
+func walkNoteFiles(ctx context.Context, storage types.StorageBackend, fn types.WalkFunc) error {
+	ignore := ignorePatterns()
+	seen := make(map[string]bool)
+	for _, dir := range noteDirs() {
+		var fnErr error
+		_ = types.Walk(ctx, storage, dir, func(file string, info *types.FileInfo) error {
+			if seen[file] || !strings.HasSuffix(file, ".md") || ignore.Match(file) {
+				return nil
 			}
+			seen[file] = true
+			fnErr = fn(file, info)
+			return fnErr
+		})
+		if fnErr != nil {
+			return fnErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// Set storage backend type
-	note.StorageBackend = storage.Type()
-
-	// Set content for the note
-	note.Content = parsedContent
-
-	return note, nil
+// noteDirs returns the directories notes are kept in for the active
+// configuration: the vault root, the notes and daily directories and
+// vault.extra_dirs
+func noteDirs() []string {
+	if cfg := getConfig(); cfg != nil {
+		return cfg.Vault.NoteDirs()
+	}
+	return types.DefaultConfig().Vault.NoteDirs()
 }
 
-// parseNoteMetadata extracts metadata from frontmatter and content
-func parseNoteMetadata(content string, note *types.Note) string {
-	// Check if content starts with YAML frontmatter marker
-	if !strings.HasPrefix(content, "---") {
-		// No frontmatter, extract title from markdown and use defaults
-		note.Title = extractTitleFromMarkdown(content)
-		note.Frontmatter = types.Frontmatter{
-			ID:   note.ID,
-			Type: "note",
-			Tags: []string{},
-		}
-		return content
+// ignorePatterns returns the vault.ignore patterns of the active
+// configuration, or nil when there are none
+func ignorePatterns() *types.IgnorePatterns {
+	cfg := getConfig()
+	if cfg == nil {
+		return nil
 	}
+	// Invalid patterns are rejected when the configuration is validated;
+	// ignore nothing if one slips through
+	ignore, _ := types.ParseIgnorePatterns(cfg.Vault.Ignore)
+	return ignore
+}
 
-	// Split by frontmatter markers
-	parts := strings.SplitN(content, "---", 3)
-	if len(parts) < 3 {
-		// Invalid frontmatter, treat as plain content
-		note.Title = extractTitleFromMarkdown(content)
-		note.Frontmatter = types.Frontmatter{
-			ID:   note.ID,
-			Type: "note",
-			Tags: []string{},
+// notePathCandidates returns the paths a note with the given ID may be
+// stored at, in the order they are tried
+func notePathCandidates(noteID string) []string {
+	paths := []string{noteID + ".md", noteID}
+	for _, dir := range noteDirs() {
+		if dir != "" {
+			paths = append(paths, dir+noteID+".md")
 		}
-		return content
 	}
+	return paths
+}
 
-	// parts[0] is empty (before first ---)
-	// parts[1] is the frontmatter
-	// parts[2] is the content
-	frontmatter := parts[1]
-	bodyContent := strings.TrimSpace(parts[2])
-
-	// Parse frontmatter fields
-	parseFrontmatterFields(frontmatter, note)
-
-	// Extract title from frontmatter
-	if note.Title == "" {
-		note.Title = extractTitleFromFrontmatter(frontmatter)
+// noteParseOptions returns note parsing options from the active
+// configuration
+func noteParseOptions() note.ParseOptions {
+	var opts note.ParseOptions
+	if cfg := getConfig(); cfg != nil {
+		// Invalid values are rejected when the configuration is validated;
+		// fall back to the default order if one slips through
+		opts.TitleSources, _ = types.ParseTitleSources(cfg.Vault.TitleSource)
 	}
+	return opts
+}
 
-	// If no title in frontmatter, try markdown heading
-	if note.Title == "" {
-		note.Title = extractTitleFromMarkdown(bodyContent)
+// noteTags returns a note's tags, including #hashtags in its body when the
+// active configuration enables inline tags
+func noteTags(n *types.Note) []string {
+	inline := types.DefaultConfig().Vault.InlineTags
+	if cfg := getConfig(); cfg != nil {
+		inline = cfg.Vault.InlineTags
 	}
-
-	return bodyContent
+	return note.Tags(n, inline)
 }
 
-// parseFrontmatterFields extracts structured fields from YAML frontmatter
-func parseFrontmatterFields(frontmatter string, note *types.Note) {
-	lines := strings.Split(frontmatter, "\n")
-	fm := types.Frontmatter{
-		ID:   note.ID,
-		Type: "note",
-		Tags: []string{},
+// readAndParseNote reads a note file and extracts its metadata
+func readAndParseNote(ctx context.Context, storage types.StorageBackend, filePath string) (*types.Note, error) {
+	// Get file metadata for size and timestamps
+	fileInfo, err := storage.Stat(ctx, filePath)
+	if err != nil {
+		fileInfo = nil
 	}
+	return parseNoteFile(ctx, storage, filePath, fileInfo)
+}
 
-	// Track if we're in a tags array
-	inTagsArray := false
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
-			continue
-		}
-
-		// Check if this line starts a tags array
-		if strings.HasPrefix(trimmedLine, "tags:") {
-			value := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "tags:"))
-			// If value is empty, tags are on following lines
-			if value == "" {
-				inTagsArray = true
-				continue
-			}
-			// Handle inline tags: tags: [tag1, tag2]
-			value = strings.TrimPrefix(value, "[")
-			value = strings.TrimSuffix(value, "]")
-			if value != "" {
-				tagParts := strings.Split(value, ",")
-				for _, tag := range tagParts {
-					tag = strings.TrimSpace(tag)
-					tag = strings.Trim(tag, "\"'")
-					if tag != "" {
-						fm.Tags = append(fm.Tags, tag)
-					}
-				}
-			}
-			continue
-		}
+// parseNoteFile reads and parses the note at filePath, taking its size and
+// timestamps from fileInfo when it is not nil
+func parseNoteFile(ctx context.Context, storage types.StorageBackend, filePath string, fileInfo *types.FileInfo) (*types.Note, error) {
+	// Read file content
+	data, err := storage.Read(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
 
-		// Parse YAML list items (tags array)
-		if inTagsArray {
-			if strings.HasPrefix(trimmedLine, "- ") {
-				tag := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "-"))
-				tag = strings.Trim(tag, "\"'")
-				if tag != "" {
-					fm.Tags = append(fm.Tags, tag)
-				}
-			} else if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
-				// End of tags array (next non-indented line)
-				inTagsArray = false
-				// Continue to normal processing below
-			} else {
-				// Might be a continuation, check if it's still a list item
-				if !strings.HasPrefix(trimmedLine, "- ") {
-					inTagsArray = false
-				}
-			}
+	// Parse frontmatter and content to extract title and metadata
+	n := note.ParseWithOptions(filePath, data, noteParseOptions())
 
-			// Don't process this line further if we're still in tags array
-			if inTagsArray && strings.HasPrefix(trimmedLine, "- ") {
-				continue
+	if fileInfo != nil {
+		n.Size = fileInfo.Size
+		// Use ModTime for both created and updated if available
+		if fileInfo.ModTime > 0 {
+			modTime := time.Unix(fileInfo.ModTime, 0)
+			n.UpdatedAt = modTime
+			// If CreatedAt is zero, use UpdatedAt as default
+			if n.CreatedAt.IsZero() {
+				n.CreatedAt = modTime
 			}
 		}
+	}
 
-		// Parse key: value pairs
-		if !strings.Contains(trimmedLine, ":") {
-			continue
-		}
-
-		parts := strings.SplitN(trimmedLine, ":", 2)
-		if len(parts) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		switch key {
-		case "id":
-			value = strings.Trim(value, "\"'")
-			if value != "" {
-				fm.ID = value
-			}
-
-		case "title":
-			value = strings.Trim(value, "\"'")
-			note.Title = value
-			fm.Title = value
-
-		case "type":
-			value = strings.Trim(value, "\"'")
-			if value != "" {
-				fm.Type = value
-			}
-
-		case "created":
-			value = strings.Trim(value, "\"'")
-			fm.Created = value
-			// Try to parse as ISO timestamp
-			if t, err := time.Parse(time.RFC3339, value); err == nil {
-				note.CreatedAt = t
-			}
-
-		case "updated":
-			value = strings.Trim(value, "\"'")
-			fm.Updated = value
-			// Try to parse as ISO timestamp
-			if t, err := time.Parse(time.RFC3339, value); err == nil {
-				note.UpdatedAt = t
-			}
-
-		case "storage":
-			value = strings.Trim(value, "\"'")
-			if value != "" {
-				fm.Storage = value
-			}
+	// Set storage backend type
+	n.StorageBackend = storage.Type()
 
-		case "template":
-			value = strings.Trim(value, "\"'")
-			if value != "" {
-				fm.Template = value
-			}
-		}
-	}
+	return n, nil
+}
 
-	note.Frontmatter = fm
+// noteStats returns the length statistics of a note's body
+func noteStats(n *types.Note) note.NoteStats {
+	return note.Stats(n.Content)
 }
 
 func filterNotesByTags(notes []*types.Note, filterTags []string) []*types.Note {
 	var filtered []*types.Note
 
 	for _, note := range notes {
-		if hasAnyTag(note.Frontmatter.Tags, filterTags) {
+		if hasAnyTag(noteTags(note), filterTags) {
 			filtered = append(filtered, note)
 		}
 	}
@@ -400,6 +335,9 @@ func hasAnyTag(noteTags, filterTags []string) bool {
 }
 
 func sortNotes(notes []*types.Note, sortBy string, reverse bool) {
+	if sortBy == sortNone {
+		return
+	}
 	sort.Slice(notes, func(i, j int) bool {
 		var less bool
 
@@ -422,87 +360,107 @@ func sortNotes(notes []*types.Note, sortBy string, reverse bool) {
 	})
 }
 
-func displayNotesDefault(notes []*types.Note, showPaths bool) error {
+func displayNotesDefault(w io.Writer, notes []*types.Note, showPaths, showStats bool, style outputStyle) error {
 	if len(notes) == 0 {
-		fmt.Println("No notes found.")
+		_, _ = fmt.Fprintln(w, "No notes found.")
 		return nil
 	}
 
-	fmt.Printf("Found %d note(s):\n\n", len(notes))
+	_, _ = fmt.Fprintf(w, "Found %d note(s):\n\n", len(notes))
 
 	for i, note := range notes {
-		fmt.Printf("%d. 📝 %s\n", i+1, note.Title)
-		fmt.Printf("   🆔 %s\n", note.ID)
+		_, _ = fmt.Fprintf(w, "%d. %s%s\n", i+1, style.icon("📝 ", ""), style.bold(note.Title))
+		_, _ = fmt.Fprintf(w, "   %s %s\n", style.icon("🆔", "ID:"), note.ID)
 
-		if len(note.Frontmatter.Tags) > 0 {
-			fmt.Printf("   🏷️  %s\n", strings.Join(note.Frontmatter.Tags, ", "))
+		if tags := noteTags(note); len(tags) > 0 {
+			_, _ = fmt.Fprintf(w, "   %s %s\n", style.icon("🏷️ ", "Tags:"), strings.Join(tags, ", "))
 		}
 
-		fmt.Printf("   📅 Updated: %s\n", formatRelativeTime(note.UpdatedAt))
+		_, _ = fmt.Fprintf(w, "   %sUpdated: %s\n", style.icon("📅 ", ""), formatRelativeTime(note.UpdatedAt))
 
 		if showPaths {
-			fmt.Printf("   📁 %s\n", note.FilePath)
+			_, _ = fmt.Fprintf(w, "   %s %s\n", style.icon("📁", "Path:"), note.FilePath)
+		}
+
+		if showStats {
+			stats := noteStats(note)
+			_, _ = fmt.Fprintf(w, "   %sLength: %d words, %d characters, %s read\n",
+				style.icon("📊 ", ""), stats.Words, stats.Characters, formatReadingTime(stats.ReadingMinutes))
 		}
 
 		if i < len(notes)-1 {
-			fmt.Println()
+			_, _ = fmt.Fprintln(w)
 		}
 	}
 
 	return nil
 }
 
-func displayNotesCompact(notes []*types.Note, showPaths bool) error {
+func displayNotesCompact(w io.Writer, notes []*types.Note, showPaths, showStats bool) error {
 	if len(notes) == 0 {
-		fmt.Println("No notes found.")
+		_, _ = fmt.Fprintln(w, "No notes found.")
 		return nil
 	}
 
 	for _, note := range notes {
 		line := fmt.Sprintf("%s | %s", note.ID[:8], note.Title)
 
-		if len(note.Frontmatter.Tags) > 0 {
-			line += fmt.Sprintf(" | %s", strings.Join(note.Frontmatter.Tags, ","))
+		if tags := noteTags(note); len(tags) > 0 {
+			line += fmt.Sprintf(" | %s", strings.Join(tags, ","))
 		}
 
 		if showPaths {
 			line += fmt.Sprintf(" | %s", note.FilePath)
 		}
 
-		fmt.Println(line)
+		if showStats {
+			line += fmt.Sprintf(" | %d words", noteStats(note).Words)
+		}
+
+		_, _ = fmt.Fprintln(w, line)
 	}
 
 	return nil
 }
 
-func displayNotesJSON(notes []*types.Note) error {
-	fmt.Println("[")
+func displayNotesJSON(w io.Writer, notes []*types.Note, showStats bool) error {
+	_, _ = fmt.Fprintln(w, "[")
 
 	for i, note := range notes {
-		fmt.Printf(`  {
+		statsFields := ""
+		if showStats {
+			stats := noteStats(note)
+			statsFields = fmt.Sprintf(`,
+    "words": %d,
+    "characters": %d,
+    "reading_minutes": %d`, stats.Words, stats.Characters, stats.ReadingMinutes)
+		}
+
+		_, _ = fmt.Fprintf(w, `  {
     "id": "%s",
     "title": "%s",
     "file_path": "%s",
     "tags": [%s],
     "created": "%s",
-    "updated": "%s"
+    "updated": "%s"%s
   }`,
 			note.ID,
 			note.Title,
 			note.FilePath,
-			formatTagsJSON(note.Frontmatter.Tags),
+			formatTagsJSON(noteTags(note)),
 			note.CreatedAt.Format("2006-01-02T15:04:05Z"),
 			note.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			statsFields,
 		)
 
 		if i < len(notes)-1 {
-			fmt.Println(",")
+			_, _ = fmt.Fprintln(w, ",")
 		} else {
-			fmt.Println()
+			_, _ = fmt.Fprintln(w)
 		}
 	}
 
-	fmt.Println("]")
+	_, _ = fmt.Fprintln(w, "]")
 	return nil
 }
 
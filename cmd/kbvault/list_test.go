@@ -1,9 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -347,3 +354,357 @@ func TestFormatTagsJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestNoteParseOptions(t *testing.T) {
+	useTestConfig(t, func(c *types.Config) { c.Vault.TitleSource = "heading,frontmatter" })
+
+	opts := noteParseOptions()
+	want := []types.TitleSource{types.TitleSourceHeading, types.TitleSourceFrontmatter}
+	if len(opts.TitleSources) != len(want) {
+		t.Fatalf("TitleSources = %v, want %v", opts.TitleSources, want)
+	}
+	for i := range want {
+		if opts.TitleSources[i] != want[i] {
+			t.Errorf("TitleSources = %v, want %v", opts.TitleSources, want)
+		}
+	}
+
+	currentConfig = nil
+	if opts := noteParseOptions(); opts.TitleSources != nil {
+		t.Errorf("TitleSources = %v, want default order", opts.TitleSources)
+	}
+}
+
+// readRecorder records the paths read from a storage backend
+type readRecorder struct {
+	types.StorageBackend
+	read []string
+}
+
+func (r *readRecorder) Read(ctx context.Context, path string) ([]byte, error) {
+	r.read = append(r.read, path)
+	return r.StorageBackend.Read(ctx, path)
+}
+
+func TestListNoteFiles_ConfiguredDirs(t *testing.T) {
+	useTestConfig(t, func(c *types.Config) {
+		c.Vault.NotesDir = "kb"
+		c.Vault.DailyDir = "journal"
+		c.Vault.ExtraDirs = []string{"archive"}
+		c.Vault.Ignore = []string{"*.draft.md"}
+	})
+
+	backend, err := storage.CreateStorage(currentConfig.Storage)
+	if err != nil {
+		t.Fatalf("CreateStorage: %v", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	ctx := context.Background()
+	for _, path := range []string{"kb/01ABC.md", "journal/2024-01-15.md", "archive/old.md", "notes/elsewhere.md", "kb/idea.draft.md"} {
+		if err := backend.Write(ctx, path, []byte("# "+path+"\n")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	recorder := &readRecorder{StorageBackend: backend}
+	files := listNoteFiles(context.Background(), recorder)
+	sort.Strings(files)
+	want := []string{"archive/old.md", "journal/2024-01-15.md", "kb/01ABC.md"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("listNoteFiles() = %v, want %v", files, want)
+	}
+	if len(recorder.read) != 0 {
+		t.Errorf("listing read %v", recorder.read)
+	}
+
+	n, err := loadNoteByID(context.Background(), recorder, "01ABC")
+	if err != nil {
+		t.Fatalf("loadNoteByID: %v", err)
+	}
+	if n.FilePath != "kb/01ABC.md" {
+		t.Errorf("FilePath = %q, want kb/01ABC.md", n.FilePath)
+	}
+	for _, path := range recorder.read {
+		if strings.HasPrefix(path, "notes/") || strings.HasPrefix(path, "daily/") {
+			t.Errorf("probed %s outside the configured directories", path)
+		}
+	}
+}
+
+func TestFilterNotesByTags_InlineTags(t *testing.T) {
+	useTestConfig(t)
+
+	notes := []*types.Note{
+		{ID: "front", Frontmatter: types.Frontmatter{Tags: []string{"go"}}, Content: "Plain body.\n"},
+		{ID: "inline", Content: "Notes on #go and #日本語.\n"},
+		{ID: "mixed", Frontmatter: types.Frontmatter{Tags: []string{"rust"}}, Content: "Compared with #Go.\n"},
+	}
+
+	ids := func(notes []*types.Note) string {
+		var ids []string
+		for _, n := range notes {
+			ids = append(ids, n.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	if got := ids(filterNotesByTags(notes, []string{"go"})); got != "front,inline,mixed" {
+		t.Errorf("with inline tags, filter go = %s", got)
+	}
+	if got := ids(filterNotesByTags(notes, []string{"日本語"})); got != "inline" {
+		t.Errorf("with inline tags, filter 日本語 = %s", got)
+	}
+	if got := strings.Join(noteTags(notes[2]), ","); got != "rust,Go" {
+		t.Errorf("noteTags(mixed) = %s, want rust,Go", got)
+	}
+
+	currentConfig.Vault.InlineTags = false
+	if got := ids(filterNotesByTags(notes, []string{"go"})); got != "front" {
+		t.Errorf("without inline tags, filter go = %s", got)
+	}
+}
+
+// setupListVault points currentConfig at a new local vault holding count
+// notes, tagged even or odd by index
+func setupListVault(t *testing.T, count int) types.StorageBackend {
+	t.Helper()
+
+	useTestConfig(t)
+
+	backend, err := storage.CreateStorage(currentConfig.Storage)
+	if err != nil {
+		t.Fatalf("CreateStorage: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+
+	ctx := context.Background()
+	for i := 0; i < count; i++ {
+		tag := "odd"
+		if i%2 == 0 {
+			tag = "even"
+		}
+		content := fmt.Sprintf("---\nid: note%04d\ntitle: Note %d\ntags: [%s]\n---\n\nBody.\n", i, i, tag)
+		if err := backend.Write(ctx, fmt.Sprintf("notes/note%04d.md", i), []byte(content)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	return backend
+}
+
+// runListCmd runs list with args and returns what it wrote to stdout
+func runListCmd(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := newListCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("list %v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestListCmd_CountOnly(t *testing.T) {
+	backend := setupListVault(t, 5)
+	run := func(args ...string) string {
+		t.Helper()
+		return runListCmd(t, args...)
+	}
+
+	// The count is the number of notes a normal listing shows
+	notes, err := listAllNotes(context.Background(), backend)
+	if err != nil {
+		t.Fatalf("listAllNotes: %v", err)
+	}
+	if got, want := run("--count-only"), fmt.Sprintf("%d\n", len(notes)); got != want {
+		t.Errorf("list --count-only = %q, want %q", got, want)
+	}
+	even := filterNotesByTags(notes, []string{"even"})
+	if got, want := run("--count-only", "--tags", "even", "--limit", "1"), fmt.Sprintf("%d\n", len(even)); got != want {
+		t.Errorf("list --count-only --tags even = %q, want %q", got, want)
+	}
+	if len(even) != 3 {
+		t.Errorf("filtered %d notes tagged even, want 3", len(even))
+	}
+
+	for _, args := range [][]string{{"--count-only", "--json"}, {"--count-only", "-f", "json"}} {
+		var result map[string]int
+		if err := json.Unmarshal([]byte(run(args...)), &result); err != nil {
+			t.Fatalf("list %v output is not JSON: %v", args, err)
+		}
+		if result["count"] != 5 || len(result) != 1 {
+			t.Errorf("list %v = %v, want {count: 5}", args, result)
+		}
+	}
+}
+
+func TestListCmd_Output(t *testing.T) {
+	setupListVault(t, 3)
+
+	out := runListCmd(t, "--sort", "title", "--paths")
+	for _, want := range []string{"Found 3 note(s):", "1. Note 0\n", "   ID: note0002\n", "   Tags: odd\n", "   Updated: ", "   Path: notes/note0001.md\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("default output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = runListCmd(t, "-f", "compact", "--sort", "title", "--tags", "even")
+	if want := "note0000 | Note 0 | even\nnote0002 | Note 2 | even\n"; out != want {
+		t.Errorf("compact output = %q, want %q", out, want)
+	}
+
+	var listed []map[string]interface{}
+	if err := json.Unmarshal([]byte(runListCmd(t, "--json", "--limit", "2")), &listed); err != nil {
+		t.Fatalf("JSON output does not parse: %v", err)
+	}
+	if len(listed) != 2 || listed[0]["file_path"] == "" {
+		t.Errorf("JSON output = %v, want 2 notes", listed)
+	}
+
+	setupListVault(t, 0)
+	for _, format := range []string{"default", "compact"} {
+		if out := runListCmd(t, "-f", format); out != "No notes found.\n" {
+			t.Errorf("%s output for an empty vault = %q", format, out)
+		}
+	}
+}
+
+func TestListCmd_Template(t *testing.T) {
+	setupListVault(t, 3)
+
+	out := runListCmd(t, "--sort", "title", "--format", "template",
+		"--template", `{{.ID}} {{.Title}} {{range .Tags}}#{{.}} {{end}}{{.FilePath}}`)
+	want := "note0000 Note 0 #even notes/note0000.md\n" +
+		"note0001 Note 1 #odd notes/note0001.md\n" +
+		"note0002 Note 2 #even notes/note0002.md\n"
+	if out != want {
+		t.Errorf("template output = %q, want %q", out, want)
+	}
+
+	if out := runListCmd(t, "-f", "template", "--template", `{{upper .Title}}`, "--tags", "odd"); out != "NOTE 1\n" {
+		t.Errorf("template output with functions = %q", out)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-f", "template"}, "--format template requires --template"},
+		{[]string{"-f", "template", "--template", "{{range .Tags}"}, "invalid --template"},
+		{[]string{"-f", "template", "--template", "{{.Body}}"}, "failed to render --template"},
+	}
+	for _, tt := range tests {
+		cmd := newListCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(tt.args)
+		err := cmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("list %v error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestListCmd_JSONLines(t *testing.T) {
+	setupListVault(t, 5)
+
+	// parseLines checks that every line is a JSON object on its own
+	parseLines := func(out string) []noteJSONLine {
+		t.Helper()
+		var notes []noteJSONLine
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			var note noteJSONLine
+			if err := json.Unmarshal([]byte(line), &note); err != nil {
+				t.Fatalf("line %q is not JSON: %v", line, err)
+			}
+			notes = append(notes, note)
+		}
+		return notes
+	}
+
+	notes := parseLines(runListCmd(t, "-f", "jsonl", "--sort", "title"))
+	if len(notes) != 5 {
+		t.Fatalf("got %d lines, want 5", len(notes))
+	}
+	for i, note := range notes {
+		if want := fmt.Sprintf("note%04d", i); note.ID != want || note.FilePath != "notes/"+want+".md" {
+			t.Errorf("line %d = %+v, want note %s", i, note, want)
+		}
+		if len(note.Tags) != 1 || note.Updated.IsZero() {
+			t.Errorf("line %d = %+v, want a tag and an update time", i, note)
+		}
+	}
+
+	// Streamed in storage order, with the tag filter and limit applied
+	streamed := parseLines(runListCmd(t, "-f", "jsonl", "--sort", "none", "--tags", "even"))
+	if len(streamed) != 3 {
+		t.Errorf("streamed %d even notes, want 3", len(streamed))
+	}
+	for _, note := range streamed {
+		if note.Tags[0] != "even" {
+			t.Errorf("streamed note %s tagged %v", note.ID, note.Tags)
+		}
+	}
+	if limited := parseLines(runListCmd(t, "-f", "jsonl", "--sort", "none", "--limit", "2")); len(limited) != 2 {
+		t.Errorf("streamed %d notes with --limit 2, want 2", len(limited))
+	}
+
+	if got := runListCmd(t, "-f", "jsonl", "--count-only"); got != "{\"count\":5}\n" {
+		t.Errorf("jsonl count = %q", got)
+	}
+
+	setupListVault(t, 0)
+	for _, sortBy := range []string{"title", "none"} {
+		if out := runListCmd(t, "-f", "jsonl", "--sort", sortBy); out != "" {
+			t.Errorf("jsonl output for an empty vault sorted by %s = %q", sortBy, out)
+		}
+	}
+}
+
+func TestListCmd_Stats(t *testing.T) {
+	backend := setupListVault(t, 2)
+	long := "---\nid: longnote\ntitle: Long\n---\n\n" + strings.Repeat("word ", 450) + "\n"
+	if err := backend.Write(context.Background(), "notes/longnote.md", []byte(long)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runListCmd(t, "--sort", "title", "--stats")
+	if !strings.Contains(out, "Length: 450 words, 1800 characters, 3 min read") {
+		t.Errorf("default output is missing the long note's stats:\n%s", out)
+	}
+	if !strings.Contains(out, "Length: 1 words, 5 characters, 1 min read") {
+		t.Errorf("default output is missing a short note's stats:\n%s", out)
+	}
+	if out := runListCmd(t, "--sort", "title"); strings.Contains(out, "Length:") {
+		t.Errorf("stats shown without --stats:\n%s", out)
+	}
+
+	if out := runListCmd(t, "-f", "compact", "--sort", "title", "--stats", "--limit", "1"); out != "longnote | Long | 450 words\n" {
+		t.Errorf("compact output = %q", out)
+	}
+
+	var listed []map[string]interface{}
+	if err := json.Unmarshal([]byte(runListCmd(t, "--json", "--sort", "title", "--stats")), &listed); err != nil {
+		t.Fatalf("JSON output does not parse: %v", err)
+	}
+	if len(listed) != 3 || listed[0]["words"] != 450.0 || listed[0]["reading_minutes"] != 3.0 {
+		t.Errorf("JSON output = %v, want stats for 3 notes", listed)
+	}
+
+	for _, sortBy := range []string{"title", "none"} {
+		for _, line := range strings.Split(strings.TrimSpace(runListCmd(t, "-f", "jsonl", "--sort", sortBy, "--stats")), "\n") {
+			var note map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &note); err != nil {
+				t.Fatalf("line %q is not JSON: %v", line, err)
+			}
+			if _, ok := note["characters"]; !ok {
+				t.Errorf("jsonl line sorted by %s has no stats: %s", sortBy, line)
+			}
+		}
+	}
+	if out := runListCmd(t, "-f", "jsonl", "--limit", "1"); strings.Contains(out, "words") {
+		t.Errorf("jsonl output has stats without --stats: %s", out)
+	}
+}
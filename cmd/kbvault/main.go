@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -20,18 +24,31 @@ var (
 	profileManager *config.ProfileManager
 	currentConfig  *types.Config
 	currentProfile string
+
+	// stopTimeout releases the timer of the --timeout deadline
+	stopTimeout context.CancelFunc = func() {}
 )
 
+// timeoutEnv sets the --timeout default
+const timeoutEnv = "KBVAULT_TIMEOUT"
+
 // GlobalFlags contains flags that are available to all commands
 type GlobalFlags struct {
-	Profile string
+	Profile   string
+	StrictEnv bool
+	NoColor   bool
+	Timeout   time.Duration
 }
 
 var globalFlags = &GlobalFlags{}
 
 func main() {
-	if err := newRootCmd().Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	// Every run carries a request ID for the operations it traces
+	ctx := trace.WithRequestID(context.Background(), trace.NewRequestID())
+	err := newRootCmd().ExecuteContext(ctx)
+	stopTimeout()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", describeTimeout(err))
 		os.Exit(1)
 	}
 }
@@ -56,7 +73,10 @@ Examples:
   kbvault profile create work --storage-type s3 --s3-bucket my-work-kb`,
 		Version: fmt.Sprintf("%s (commit: %s, built: %s)", version, commitHash, buildTime),
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			return initializeConfig()
+			if err := initializeConfig(); err != nil {
+				return err
+			}
+			return applyTimeout(cmd)
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			// Show help if no subcommand is provided
@@ -69,6 +89,12 @@ Examples:
 	// Add global flags
 	cmd.PersistentFlags().StringVar(&globalFlags.Profile, "profile", "",
 		"Configuration profile to use (default: active profile)")
+	cmd.PersistentFlags().BoolVar(&globalFlags.StrictEnv, "strict-env", false,
+		"Fail when config values reference unset ${VAR} environment variables")
+	cmd.PersistentFlags().BoolVar(&globalFlags.NoColor, "no-color", false,
+		"Disable emoji and colors in output (also set by NO_COLOR)")
+	cmd.PersistentFlags().DurationVar(&globalFlags.Timeout, "timeout", 0,
+		"Abort storage and search operations that take longer, e.g. 30s or 2m (default: no timeout, or "+timeoutEnv+")")
 
 	// Add subcommands
 	cmd.AddCommand(newInitCmd())
@@ -77,8 +103,25 @@ Examples:
 	cmd.AddCommand(newListCmd())
 	cmd.AddCommand(newConfigCmd())
 	cmd.AddCommand(newSearchCmd())
+	cmd.AddCommand(newGrepCmd())
 	cmd.AddCommand(newEditCmd())
+	cmd.AddCommand(newOpenCmd())
 	cmd.AddCommand(newDeleteCmd())
+	cmd.AddCommand(newStatsCmd())
+	cmd.AddCommand(newGraphCmd())
+	cmd.AddCommand(newLinkCmd())
+	cmd.AddCommand(newAttachCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newVerifyCmd())
+	cmd.AddCommand(newWatchCmd())
+	cmd.AddCommand(newIndexCmd())
+	cmd.AddCommand(newCacheCmd())
+	cmd.AddCommand(newS3Cmd())
+	cmd.AddCommand(newRekeyCmd())
+	cmd.AddCommand(newServerCmd())
+	cmd.AddCommand(newTemplateCmd())
 	cmd.AddCommand(newProfileCmd())
 	cmd.AddCommand(newConfigureCmd())
 	cmd.AddCommand(newCompletionCmd())
@@ -95,6 +138,7 @@ func initializeConfig() error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize profile manager: %w", err)
 	}
+	profileManager.SetStrictEnv(globalFlags.StrictEnv)
 
 	// Determine which profile to use
 	profile := globalFlags.Profile
@@ -141,6 +185,40 @@ func initializeConfig() error {
 	return nil
 }
 
+// applyTimeout bounds the context of cmd by --timeout, or by
+// KBVAULT_TIMEOUT when the flag isn't given. Zero means no timeout.
+func applyTimeout(cmd *cobra.Command) error {
+	if !cmd.Flags().Changed("timeout") {
+		if value := os.Getenv(timeoutEnv); value != "" {
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %w", timeoutEnv, value, err)
+			}
+			globalFlags.Timeout = timeout
+		}
+	}
+
+	if globalFlags.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %s: must not be negative", globalFlags.Timeout)
+	}
+	if globalFlags.Timeout == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), globalFlags.Timeout)
+	stopTimeout = cancel
+	cmd.SetContext(ctx)
+	return nil
+}
+
+// describeTimeout explains an error caused by the --timeout deadline
+func describeTimeout(err error) error {
+	if globalFlags.Timeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("operation timed out after %s (raise --timeout or %s): %w", globalFlags.Timeout, timeoutEnv, err)
+	}
+	return err
+}
+
 // tryLoadLocalConfig attempts to load configuration from a local .kbvault directory
 // This provides backward compatibility with the old configuration system
 func tryLoadLocalConfig() *types.Config {
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3/s3test"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// setupHangingS3 points the current config at an S3 endpoint that never
+// answers until the request is abandoned
+func setupHangingS3(t *testing.T) {
+	t.Helper()
+
+	s3Config := s3test.NewFakeS3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	s3Config.RetryAttempts = 1
+
+	useTestConfig(t, func(c *types.Config) {
+		c.Storage.Type = types.StorageTypeS3
+		c.Storage.S3 = s3Config
+	})
+}
+
+// runWithTimeout runs the root command with args, applying --timeout but
+// keeping the test configuration
+func runWithTimeout(t *testing.T, args ...string) error {
+	t.Helper()
+
+	originalFlags, originalStop := *globalFlags, stopTimeout
+	t.Cleanup(func() {
+		stopTimeout()
+		*globalFlags, stopTimeout = originalFlags, originalStop
+	})
+
+	cmd := newRootCmd()
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return applyTimeout(cmd)
+	}
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(args)
+	return cmd.Execute()
+}
+
+func TestTimeout(t *testing.T) {
+	setupHangingS3(t)
+
+	for _, args := range [][]string{
+		{"--timeout", "200ms", "list"},
+		{"--timeout", "200ms", "show", "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		{"--timeout", "200ms", "search", "anything"},
+	} {
+		t.Run(args[2], func(t *testing.T) {
+			start := time.Now()
+			err := runWithTimeout(t, args...)
+			elapsed := time.Since(start)
+
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, elapsed, 3*time.Second, "the command should stop near the timeout")
+
+			err = describeTimeout(err)
+			assert.Contains(t, err.Error(), "operation timed out after 200ms")
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+}
+
+func TestTimeoutEnv(t *testing.T) {
+	setupHangingS3(t)
+
+	t.Setenv(timeoutEnv, "150ms")
+	err := runWithTimeout(t, "list")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 150*time.Millisecond, globalFlags.Timeout)
+
+	t.Setenv(timeoutEnv, "soon")
+	err = runWithTimeout(t, "list")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid KBVAULT_TIMEOUT")
+
+	// The flag takes precedence over the environment
+	err = runWithTimeout(t, "--timeout", "100ms", "list")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = runWithTimeout(t, "--timeout", "-1s", "list")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must not be negative")
+}
+
+// hangingStorage is a storage backend whose health check waits until its
+// context is done
+type hangingStorage struct {
+	types.StorageBackend
+}
+
+func (s *hangingStorage) Health(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+func (s *hangingStorage) Close() error { return nil }
+
+func TestTimeoutCommandsWithOwnPreRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useTestConfig(t)
+	useStorageFactory(t, func(cfg types.StorageConfig) (types.StorageBackend, error) {
+		return &hangingStorage{}, nil
+	})
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.CreateProfile("remote", &config.CreateProfileOptions{
+		StorageType: types.StorageTypeS3,
+		S3Bucket:    "bucket",
+		S3Region:    "us-east-1",
+	}))
+
+	run := func(args ...string) (string, error) {
+		t.Helper()
+
+		originalFlags, originalStop := *globalFlags, stopTimeout
+		defer func() {
+			stopTimeout()
+			*globalFlags, stopTimeout = originalFlags, originalStop
+		}()
+
+		cmd := newRootCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		cmd.SetIn(strings.NewReader("n\n"))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	start := time.Now()
+	out, err := run("--timeout", "200ms", "config", "validate", "remote", "--check-connectivity", "--check-timeout", "1m")
+	require.Error(t, err)
+	assert.Contains(t, out, "context deadline exceeded")
+	assert.Less(t, time.Since(start), 5*time.Second, "config validate should stop near the timeout")
+
+	start = time.Now()
+	_, err = run("--timeout", "200ms", "profile", "create", "work",
+		"--storage-type", "s3", "--s3-bucket", "bucket", "--s3-region", "us-east-1", "--validate")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second, "profile create --validate should stop near the timeout")
+}
+
+func TestTimeoutNotExceeded(t *testing.T) {
+	setupListVault(t, 2)
+
+	var out bytes.Buffer
+	originalFlags, originalStop := *globalFlags, stopTimeout
+	defer func() {
+		stopTimeout()
+		*globalFlags, stopTimeout = originalFlags, originalStop
+	}()
+
+	cmd := newRootCmd()
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return applyTimeout(cmd)
+	}
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--timeout", "1m", "list", "--count-only"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "2\n", out.String())
+
+	// Errors other than the deadline are left alone
+	err := describeTimeout(context.Canceled)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -1,17 +1,16 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/ulid"
@@ -19,19 +18,42 @@ import (
 
 func newNewCmd() *cobra.Command {
 	var (
-		title    string
-		template string
-		tags     []string
-		open     bool
+		title       string
+		template    string
+		noteType    string
+		tags        []string
+		fields      []string
+		open        bool
+		contentFile string
+		fromStdin   bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "new [title]",
 		Short: "Create a new note",
-		Long: `Create a new note with a unique ULID identifier.
-The note will be created in the vault's notes directory.`,
+		Long: `Create a new note in the vault's notes directory. The note ID, and so
+its file name, follows vault.id_scheme: a ULID by default, or timestamp,
+slug or slug-timestamp.
+
+The note body comes from the template unless content is supplied with
+--content-file or --stdin, in which case no editor is opened.
+
+Examples:
+  # Create a tagged note
+  kbvault new "HTTP Routing" --tags go,web
+
+  # Create a note from an existing draft
+  kbvault new "HTTP Routing" --tags go,web --type note --content-file draft.md
+
+  # Pipe content into a new note
+  pbpaste | kbvault new "Meeting Notes" --stdin
+
+  # Set custom frontmatter fields, e.g. ones vault.frontmatter_schema requires
+  kbvault new "Q3 Plan" --field author=alice --field status=draft`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
 			// Use profile-aware configuration
 			config := getConfig()
 			if config == nil {
@@ -46,6 +68,21 @@ The note will be created in the vault's notes directory.`,
 				title = "Untitled Note"
 			}
 
+			custom, err := parseFields(fields)
+			if err != nil {
+				return err
+			}
+
+			// Read supplied content before touching storage
+			var content *string
+			if fromStdin || contentFile != "" {
+				data, err := readReplacementContent(cmd.InOrStdin(), contentFile)
+				if err != nil {
+					return err
+				}
+				content = &data
+			}
+
 			// Create storage backend
 			storageBackend, err := storage.CreateStorage(config.Storage)
 			if err != nil {
@@ -59,16 +96,30 @@ The note will be created in the vault's notes directory.`,
 			}()
 
 			// Create new note
-			note, err := createNewNote(config, title, template, tags)
+			note, err := createNewNote(config, title, template, noteType, parseTags(tags), content)
 			if err != nil {
 				return fmt.Errorf("failed to create note: %w", err)
 			}
+			note.Frontmatter.Custom = custom
+			if err := checkFrontmatterSchema(note.Frontmatter, "set fields with --type, --tags or --field key=value"); err != nil {
+				return err
+			}
+			if err := checkNoteSize(note, config.Vault.MaxFileSize); err != nil {
+				return err
+			}
 
-			// Save the note to storage
-			ctx := context.Background()
+			// Save the note to storage, never replacing an existing one
+			exists, err := storageBackend.Exists(ctx, note.FilePath)
+			if err != nil {
+				return fmt.Errorf("failed to check note: %w", err)
+			}
+			if exists {
+				return fmt.Errorf("note %s already exists", note.ID)
+			}
 			if err := saveNote(ctx, storageBackend, note); err != nil {
 				return fmt.Errorf("failed to save note: %w", err)
 			}
+			syncSearchIndex(ctx, cmd.ErrOrStderr(), storageBackend, note.FilePath, false)
 
 			fmt.Printf("✅ Created note: %s\n", note.Title)
 			fmt.Printf("📝 ID: %s\n", note.ID)
@@ -90,11 +141,13 @@ The note will be created in the vault's notes directory.`,
 					note.Content = editedContent
 					note.Frontmatter.Updated = time.Now().Format("2006-01-02T15:04:05Z")
 					note.UpdatedAt = time.Now()
+					stampChecksum(note, config.Vault.TrackChecksums)
 
 					// Resave with updated frontmatter
 					if err := saveNote(ctx, storageBackend, note); err != nil {
 						return fmt.Errorf("failed to save edited note: %w", err)
 					}
+					syncSearchIndex(ctx, cmd.ErrOrStderr(), storageBackend, note.FilePath, false)
 					fmt.Printf("✅ Note updated with your edits\n")
 				}
 			}
@@ -106,16 +159,23 @@ The note will be created in the vault's notes directory.`,
 	cmd.Flags().StringVarP(&title, "title", "t", "", "Title for the new note")
 	cmd.Flags().StringVar(&template, "template", "default", "Template to use for the note")
 	cmd.Flags().StringSliceVar(&tags, "tags", []string{}, "Tags for the note (comma-separated)")
+	cmd.Flags().StringVar(&noteType, "type", "note", "Type of note")
+	cmd.Flags().StringArrayVar(&fields, "field", nil, "Custom frontmatter field as key=value (repeatable)")
 	cmd.Flags().BoolVarP(&open, "open", "o", false, "Open the note in default editor after creation")
+	cmd.Flags().StringVar(&contentFile, "content-file", "", "Use the contents of a file as the note body")
+	cmd.Flags().BoolVar(&fromStdin, "stdin", false, "Read the note body from stdin")
+	cmd.MarkFlagsMutuallyExclusive("stdin", "content-file")
+	cmd.MarkFlagsMutuallyExclusive("open", "stdin")
+	cmd.MarkFlagsMutuallyExclusive("open", "content-file")
 
 	return cmd
 }
 
-func createNewNote(config *types.Config, title, template string, tags []string) (*types.Note, error) {
-	// Generate ULID
-	id := ulid.New()
-
-	// Create filename
+// createNewNote builds a note with a new ID. The body is content when
+// given, and otherwise comes from the template.
+func createNewNote(config *types.Config, title, template, noteType string, tags []string, content *string) (*types.Note, error) {
+	// Generate the ID and filename
+	id := note.GenerateID(title, config.Vault.IDScheme)
 	filename := ulid.ToFilename(id)
 	filePath := filepath.Join(config.Vault.NotesDir, filename)
 
@@ -130,7 +190,7 @@ func createNewNote(config *types.Config, title, template string, tags []string)
 			ID:      id,
 			Title:   title,
 			Tags:    tags,
-			Type:    "note",
+			Type:    noteType,
 			Storage: string(config.Storage.Type),
 			Created: time.Now().Format("2006-01-02T15:04:05Z"),
 			Updated: time.Now().Format("2006-01-02T15:04:05Z"),
@@ -139,8 +199,14 @@ func createNewNote(config *types.Config, title, template string, tags []string)
 		UpdatedAt: time.Now(),
 	}
 
-	// Apply template if specified
-	if template != "default" {
+	if noteType == "" {
+		note.Frontmatter.Type = "note"
+	}
+
+	// Use supplied content, or apply the template if specified
+	if content != nil {
+		note.Content = *content
+	} else if template != "default" {
 		templateContent, err := loadTemplate(config, template)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load template %s: %w", template, err)
@@ -151,6 +217,7 @@ func createNewNote(config *types.Config, title, template string, tags []string)
 		note.Content = fmt.Sprintf("# %s\n\nContent goes here...\n", title)
 	}
 
+	stampChecksum(note, config.Vault.TrackChecksums)
 	return note, nil
 }
 
@@ -177,7 +244,10 @@ func openInEditor(filePath string) error {
 
 	fmt.Printf("Opening in editor: %s %s\n", editor, filePath)
 
-	cmd := exec.Command(editor, filePath)
+	cmd, err := editorCommand(editor, "", filePath)
+	if err != nil {
+		return err
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -194,7 +264,10 @@ func openInEditorAndRead(filePath string) (string, error) {
 	fmt.Printf("Opening in editor: %s %s\n", editor, filePath)
 
 	// Open the file in the editor
-	cmd := exec.Command(editor, filePath)
+	cmd, err := editorCommand(editor, "", filePath)
+	if err != nil {
+		return "", err
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -224,31 +297,91 @@ func openInEditorAndRead(filePath string) (string, error) {
 	return strings.TrimSpace(content), nil
 }
 
-func saveNote(ctx context.Context, storage types.StorageBackend, note *types.Note) error {
-	// Format note content with frontmatter
-	var buf bytes.Buffer
-
-	// Write frontmatter
-	buf.WriteString("---\n")
-	buf.WriteString(fmt.Sprintf("id: %s\n", note.ID))
-	buf.WriteString(fmt.Sprintf("title: %s\n", note.Title))
-	if len(note.Frontmatter.Tags) > 0 {
-		buf.WriteString("tags:\n")
-		for _, tag := range note.Frontmatter.Tags {
-			buf.WriteString(fmt.Sprintf("  - %s\n", tag))
+// parseTags cleans up tags given on the command line: surrounding
+// whitespace and a leading '#' are removed, and empty or repeated tags are
+// dropped
+func parseTags(raw []string) []string {
+	tags := []string{}
+	seen := make(map[string]bool)
+
+	for _, value := range raw {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// parseFields parses --field key=value flags into custom frontmatter
+// fields. Fields with their own flag or set by kbvault are rejected.
+func parseFields(raw []string) (map[string]interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	custom := make(map[string]interface{}, len(raw))
+	for _, field := range raw {
+		key, value, ok := strings.Cut(field, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --field %q: expected key=value", field)
+		}
+		switch key {
+		case "id", "title", "tags", "type", "created", "updated", "storage", "template", "checksum":
+			return nil, fmt.Errorf("invalid --field %q: %s is not a custom field", field, key)
 		}
+		custom[key] = strings.TrimSpace(value)
 	}
-	buf.WriteString(fmt.Sprintf("type: %s\n", note.Frontmatter.Type))
-	buf.WriteString(fmt.Sprintf("storage: %s\n", note.Frontmatter.Storage))
-	buf.WriteString(fmt.Sprintf("created: %s\n", note.Frontmatter.Created))
-	buf.WriteString(fmt.Sprintf("updated: %s\n", note.Frontmatter.Updated))
-	buf.WriteString("---\n\n")
+	return custom, nil
+}
 
-	// Write content
-	buf.WriteString(note.Content)
+// checkFrontmatterSchema rejects frontmatter that doesn't match the active
+// configuration's vault.frontmatter_schema; hint tells the user how to
+// fix it
+func checkFrontmatterSchema(fm types.Frontmatter, hint string) error {
+	cfg := getConfig()
+	if cfg == nil {
+		return nil
+	}
+	if err := note.ValidateFrontmatter(fm, cfg.Vault.FrontmatterSchema); err != nil {
+		return fmt.Errorf("%w (required by vault.frontmatter_schema; %s)", err, hint)
+	}
+	return nil
+}
+
+// checkNoteSize rejects notes whose file would exceed maxSize bytes
+func checkNoteSize(n *types.Note, maxSize int64) error {
+	if maxSize <= 0 {
+		return nil
+	}
+	if size := int64(len(encodeNote(n))); size > maxSize {
+		return fmt.Errorf("note is %d bytes, which exceeds the vault's max_file_size of %d bytes", size, maxSize)
+	}
+	return nil
+}
+
+// encodeNote renders a note as a markdown file with frontmatter
+func encodeNote(n *types.Note) []byte {
+	fm := n.Frontmatter
+	fm.ID = n.ID
+	fm.Title = n.Title
+	return note.SerializeNote(fm, n.Content)
+}
+
+// stampChecksum records the checksum of a note's body in its frontmatter
+// when the vault tracks checksums
+func stampChecksum(n *types.Note, track bool) {
+	note.UpdateChecksum(&n.Frontmatter, n.Content, track)
+}
 
-	// Save to storage
-	return storage.Write(ctx, note.FilePath, buf.Bytes())
+func saveNote(ctx context.Context, storage types.StorageBackend, n *types.Note) error {
+	return storage.Write(ctx, n.FilePath, encodeNote(n))
 }
 
 func findVaultRoot() (string, error) {
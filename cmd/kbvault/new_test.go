@@ -1,10 +1,14 @@
 package main
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -322,3 +326,198 @@ func TestLoadConfigNotInVault(t *testing.T) {
 	// Test loading config should fail - skip this test as we now use profile-aware configuration
 	t.Skip("loadConfig test skipped - replaced with profile-aware configuration")
 }
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []string
+		want []string
+	}{
+		{"empty", nil, []string{}},
+		{"simple", []string{"go", "web"}, []string{"go", "web"}},
+		{"comma separated", []string{"go, web ,cli"}, []string{"go", "web", "cli"}},
+		{"hash prefix", []string{"#go", " #web"}, []string{"go", "web"}},
+		{"duplicates and blanks", []string{"go", "", " ", "go,#go"}, []string{"go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTags(tt.raw)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || got == nil {
+				t.Errorf("parseTags(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	got, err := parseFields([]string{"author=alice", " status = draft ", "empty="})
+	if err != nil {
+		t.Fatalf("parseFields: %v", err)
+	}
+	want := map[string]interface{}{"author": "alice", "status": "draft", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFields = %v, want %v", got, want)
+	}
+
+	for _, raw := range []string{"author", "=alice", "title=Other"} {
+		if _, err := parseFields([]string{raw}); err == nil {
+			t.Errorf("parseFields(%q) should fail", raw)
+		}
+	}
+}
+
+// runNewCmd runs the new command against a local vault in dir
+func runNewCmd(t *testing.T, dir, stdin string, args ...string) error {
+	t.Helper()
+
+	useTestConfig(t, func(c *types.Config) { c.Storage.Local.Path = dir })
+
+	cmd := newNewCmd()
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(io.Discard)
+	cmd.SetArgs(args)
+	return cmd.Execute()
+}
+
+// readOnlyNote returns the content of the single note in dir's notes directory
+func readOnlyNote(t *testing.T, dir string) string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "notes", "*.md"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one note, found %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	return string(data)
+}
+
+func TestNewCmdStdin(t *testing.T) {
+	dir := t.TempDir()
+
+	err := runNewCmd(t, dir, "Piped body.\n", "Piped Note", "--tags", "go,#web", "--type", "reference", "--stdin")
+	if err != nil {
+		t.Fatalf("new --stdin: %v", err)
+	}
+
+	content := readOnlyNote(t, dir)
+	front, body, ok := note.SplitFrontmatter(content)
+	if !ok {
+		t.Fatalf("note has no frontmatter:\n%s", content)
+	}
+	if body != "Piped body.\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	fm, err := note.ParseFrontmatter(front)
+	if err != nil {
+		t.Fatalf("ParseFrontmatter: %v", err)
+	}
+	if fm.ID == "" || fm.Created == "" || fm.Updated == "" {
+		t.Errorf("incomplete frontmatter: %+v", fm)
+	}
+	if fm.Title != "Piped Note" || fm.Type != "reference" {
+		t.Errorf("title/type = %q/%q", fm.Title, fm.Type)
+	}
+	if strings.Join(fm.Tags, ",") != "go,web" {
+		t.Errorf("tags = %v", fm.Tags)
+	}
+}
+
+func TestNewCmdContentFile(t *testing.T) {
+	dir := t.TempDir()
+	draft := filepath.Join(t.TempDir(), "draft.md")
+	if err := os.WriteFile(draft, []byte("# Draft\n\nFrom a file.\n"), 0644); err != nil {
+		t.Fatalf("failed to write draft: %v", err)
+	}
+
+	if err := runNewCmd(t, dir, "", "From File", "--content-file", draft); err != nil {
+		t.Fatalf("new --content-file: %v", err)
+	}
+
+	content := readOnlyNote(t, dir)
+	if !strings.HasSuffix(content, "---\n\n# Draft\n\nFrom a file.\n") || !strings.Contains(content, "type: note\n") {
+		t.Errorf("unexpected note:\n%s", content)
+	}
+}
+
+func TestNewCmdMaxFileSize(t *testing.T) {
+	dir := setupTestVault(t, nil, func(c *types.Config) { c.Vault.MaxFileSize = 64 })
+
+	cmd := newNewCmd()
+	cmd.SetIn(strings.NewReader(strings.Repeat("x", 100)))
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"Too Big", "--stdin"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "max_file_size") {
+		t.Fatalf("expected max_file_size error, got %v", err)
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "notes", "*.md")); len(files) != 0 {
+		t.Errorf("oversized note should not be written: %v", files)
+	}
+}
+
+func TestNewCmdFrontmatterSchema(t *testing.T) {
+	dir := setupTestVault(t, nil, func(c *types.Config) {
+		c.Vault.FrontmatterSchema = types.FrontmatterSchema{
+			Required: []string{"author"},
+			Allowed:  map[string][]string{"status": {"draft", "published"}},
+		}
+	})
+
+	run := func(args ...string) error {
+		cmd := newNewCmd()
+		cmd.SetIn(strings.NewReader("Body.\n"))
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs(append([]string{"Schema Note", "--stdin"}, args...))
+		return cmd.Execute()
+	}
+
+	if err := run("--field", "status=draft"); err == nil || !strings.Contains(err.Error(), `missing required field "author"`) {
+		t.Fatalf("expected missing field error, got %v", err)
+	}
+	if err := run("--field", "author=alice", "--field", "status=wip"); err == nil || !strings.Contains(err.Error(), `"wip"`) {
+		t.Fatalf("expected disallowed value error, got %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "notes", "*.md")); len(files) != 0 {
+		t.Fatalf("rejected notes should not be written: %v", files)
+	}
+
+	if err := run("--field", "author=alice", "--field", "status=draft"); err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	content := readOnlyNote(t, dir)
+	if !strings.Contains(content, "author: alice\n") || !strings.Contains(content, "status: draft\n") {
+		t.Errorf("custom fields missing:\n%s", content)
+	}
+}
+
+func TestNewCmdIDScheme(t *testing.T) {
+	dir := setupTestVault(t, nil, func(c *types.Config) { c.Vault.IDScheme = "slug" })
+
+	run := func() error {
+		cmd := newNewCmd()
+		cmd.SetIn(strings.NewReader("Body.\n"))
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"HTTP Routing", "--stdin"})
+		return cmd.Execute()
+	}
+
+	if err := run(); err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes", "http-routing.md")); err != nil {
+		t.Fatalf("expected slug file name: %v", err)
+	}
+
+	// A second note with the same title must not replace the first
+	if err := run(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+}
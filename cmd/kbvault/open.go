@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// noteLocation describes where a note is stored
+type noteLocation struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+
+	// Path is the note's path within the vault
+	Path string `json:"path"`
+
+	// LocalPath is the absolute file path, for local vaults only
+	LocalPath string `json:"local_path,omitempty"`
+
+	// URI is a file://, s3:// or https:// URI for the note
+	URI string `json:"uri"`
+}
+
+// ambiguousNote is reported when a query matches more than one note
+type ambiguousNote struct {
+	Error      string         `json:"error"`
+	Query      string         `json:"query"`
+	Candidates []noteLocation `json:"candidates"`
+}
+
+func newOpenCmd() *cobra.Command {
+	var (
+		printPath  bool
+		printURI   bool
+		outputJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "open <note-id-or-title>",
+		Short: "Print where a note is stored",
+		Long: `Resolve a note by ID or title and print where it is stored, without
+opening an editor, so scripts and editor integrations can locate notes.
+
+Local vaults print the note's absolute file path; S3 vaults print an
+s3://bucket/key URI and Azure vaults the blob's https:// URL. Use --uri to
+print a URI for local notes too (file://), or --path to require a local
+file path. --json prints the note's ID, title, vault path and location.
+
+A title matching several notes is an error rather than a prompt: the
+candidates are listed on stderr one per line as ID, title and path separated
+by tabs, or in the JSON output with --json.`,
+		Example: `  # Open a note in any program
+  code "$(kbvault open "meeting notes")"
+
+  # Print the S3 URI, or file:// URI for local vaults
+  kbvault open 01ARZ3NDEKTSV4RRFFQ69G5FAV --uri
+
+  # Machine-readable output
+  kbvault open golang --json`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNoteIDs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			notes, err := findNoteCandidates(cmd.Context(), storageBackend, args[0])
+			if err != nil {
+				return err
+			}
+
+			locations := make([]noteLocation, 0, len(notes))
+			for _, n := range notes {
+				location, err := locateNote(cfg.Storage, n)
+				if err != nil {
+					return err
+				}
+				locations = append(locations, location)
+			}
+
+			if len(locations) > 1 {
+				cmd.SilenceUsage = true
+				return reportAmbiguousNote(cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], locations, outputJSON)
+			}
+
+			location := locations[0]
+			if printPath && location.LocalPath == "" {
+				return fmt.Errorf("--path requires local storage; this vault uses %s storage, use --uri instead", cfg.Storage.Type)
+			}
+
+			out := cmd.OutOrStdout()
+			switch {
+			case outputJSON:
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(location)
+			case printURI || location.LocalPath == "":
+				_, err = fmt.Fprintln(out, location.URI)
+			default:
+				_, err = fmt.Fprintln(out, location.LocalPath)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&printPath, "path", false, "Print the absolute file path (local storage only)")
+	cmd.Flags().BoolVar(&printURI, "uri", false, "Print a URI, file:// for local notes")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output the note's location as JSON")
+	cmd.MarkFlagsMutuallyExclusive("path", "uri")
+
+	return cmd
+}
+
+// locateNote returns where n is stored under the storage configuration
+func locateNote(config types.StorageConfig, n *types.Note) (noteLocation, error) {
+	location := noteLocation{ID: n.ID, Title: n.Title, Path: n.FilePath}
+
+	switch config.Type {
+	case types.StorageTypeLocal:
+		root, err := filepath.Abs(config.Local.Path)
+		if err != nil {
+			return location, fmt.Errorf("failed to resolve vault path: %w", err)
+		}
+		location.LocalPath = filepath.Join(root, filepath.FromSlash(n.FilePath))
+		location.URI = (&url.URL{Scheme: "file", Path: filepath.ToSlash(location.LocalPath)}).String()
+	case types.StorageTypeS3:
+		location.URI = (&url.URL{
+			Scheme: "s3",
+			Host:   config.S3.Bucket,
+			Path:   "/" + objectKey(config.S3.Prefix, n.FilePath),
+		}).String()
+	case types.StorageTypeAzure:
+		endpoint := config.Azure.Endpoint
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", config.Azure.AccountName)
+		}
+		base, err := url.Parse(endpoint)
+		if err != nil {
+			return location, fmt.Errorf("invalid azure endpoint: %w", err)
+		}
+		location.URI = base.JoinPath(config.Azure.ContainerName, objectKey(config.Azure.Prefix, n.FilePath)).String()
+	default:
+		return location, fmt.Errorf("unsupported storage type: %s", config.Type)
+	}
+
+	return location, nil
+}
+
+// objectKey returns the object store key of the note at path, below the
+// configured key prefix
+func objectKey(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// reportAmbiguousNote lists the notes matching query and returns an error.
+// With outputJSON the candidates are written to out as JSON, otherwise to
+// errOut one per line.
+func reportAmbiguousNote(out, errOut io.Writer, query string, candidates []noteLocation, outputJSON bool) error {
+	err := fmt.Errorf("%d notes match '%s'; use a note ID to choose one", len(candidates), query)
+
+	if outputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if encodeErr := encoder.Encode(ambiguousNote{Error: err.Error(), Query: query, Candidates: candidates}); encodeErr != nil {
+			return encodeErr
+		}
+		return err
+	}
+
+	for _, c := range candidates {
+		_, _ = fmt.Fprintf(errOut, "%s\t%s\t%s\n", c.ID, c.Title, c.Path)
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// setupOpenTestVault configures a local vault holding notes and returns its
+// root
+func setupOpenTestVault(t *testing.T, notes map[string]string) string {
+	t.Helper()
+
+	return setupTestVault(t, notes)
+}
+
+func runOpenCmd(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+
+	cmd := newOpenCmd()
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+var openTestNotes = map[string]string{
+	"notes/01ABC.md": "---\nid: 01ABC\ntitle: Go Basics\n---\n\nVariables.\n",
+	"notes/01DEF.md": "---\nid: 01DEF\ntitle: Go Concurrency\n---\n\nChannels.\n",
+	"notes/01GHI.md": "---\nid: 01GHI\ntitle: Python\n---\n\nLists.\n",
+}
+
+func TestOpenCmd_ExactID(t *testing.T) {
+	root := setupOpenTestVault(t, openTestNotes)
+	want := filepath.Join(root, "notes", "01ABC.md")
+
+	stdout, _, err := runOpenCmd(t, "01ABC")
+	require.NoError(t, err)
+	assert.Equal(t, want+"\n", stdout)
+
+	stdout, _, err = runOpenCmd(t, "01ABC", "--uri")
+	require.NoError(t, err)
+	assert.Equal(t, "file://"+filepath.ToSlash(want)+"\n", stdout)
+
+	stdout, _, err = runOpenCmd(t, "python", "--json")
+	require.NoError(t, err)
+	var location noteLocation
+	require.NoError(t, json.Unmarshal([]byte(stdout), &location))
+	assert.Equal(t, noteLocation{
+		ID:        "01GHI",
+		Title:     "Python",
+		Path:      "notes/01GHI.md",
+		LocalPath: filepath.Join(root, "notes", "01GHI.md"),
+		URI:       "file://" + filepath.ToSlash(filepath.Join(root, "notes", "01GHI.md")),
+	}, location)
+}
+
+func TestOpenCmd_AmbiguousTitle(t *testing.T) {
+	setupOpenTestVault(t, openTestNotes)
+
+	stdout, stderr, err := runOpenCmd(t, "go")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 notes match 'go'")
+	assert.Empty(t, stdout)
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	assert.ElementsMatch(t, []string{
+		"01ABC\tGo Basics\tnotes/01ABC.md",
+		"01DEF\tGo Concurrency\tnotes/01DEF.md",
+	}, lines[:2])
+
+	stdout, _, err = runOpenCmd(t, "go", "--json")
+	require.Error(t, err)
+	var report ambiguousNote
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, "go", report.Query)
+	assert.Equal(t, err.Error(), report.Error)
+	var ids []string
+	for _, c := range report.Candidates {
+		ids = append(ids, c.ID)
+	}
+	assert.ElementsMatch(t, []string{"01ABC", "01DEF"}, ids)
+}
+
+func TestOpenCmd_NotFound(t *testing.T) {
+	setupOpenTestVault(t, openTestNotes)
+
+	_, _, err := runOpenCmd(t, "rust")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no notes found matching 'rust'")
+}
+
+func TestLocateNote(t *testing.T) {
+	n := &types.Note{ID: "01ABC", Title: "Go Basics", FilePath: "notes/01ABC.md"}
+
+	tests := []struct {
+		name    string
+		config  types.StorageConfig
+		wantURI string
+	}{
+		{
+			name:    "s3",
+			config:  types.StorageConfig{Type: types.StorageTypeS3, S3: types.S3StorageConfig{Bucket: "kb-bucket"}},
+			wantURI: "s3://kb-bucket/notes/01ABC.md",
+		},
+		{
+			name:    "s3 with prefix",
+			config:  types.StorageConfig{Type: types.StorageTypeS3, S3: types.S3StorageConfig{Bucket: "kb-bucket", Prefix: "vaults/work/"}},
+			wantURI: "s3://kb-bucket/vaults/work/notes/01ABC.md",
+		},
+		{
+			name: "azure",
+			config: types.StorageConfig{Type: types.StorageTypeAzure, Azure: types.AzureBlobConfig{
+				AccountName: "kbaccount", ContainerName: "vault", Prefix: "work",
+			}},
+			wantURI: "https://kbaccount.blob.core.windows.net/vault/work/notes/01ABC.md",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := locateNote(tt.config, n)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantURI, location.URI)
+			assert.Empty(t, location.LocalPath)
+			assert.Equal(t, "notes/01ABC.md", location.Path)
+		})
+	}
+}
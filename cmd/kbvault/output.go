@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// ANSI escape sequences used to decorate terminal output
+const (
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+	ansiReset = "\x1b[0m"
+)
+
+// outputStyle decides whether command output is decorated with emoji and
+// ANSI colors or kept to plain text
+type outputStyle struct {
+	color bool
+}
+
+// newOutputStyle returns the style for output written to w: decorated when
+// w is a terminal and colors are allowed, plain otherwise
+func newOutputStyle(w io.Writer) outputStyle {
+	return outputStyle{color: colorEnabled(w)}
+}
+
+// colorEnabled reports whether output to w may use emoji and ANSI colors
+func colorEnabled(w io.Writer) bool {
+	return colorAllowed() && isTerminal(w)
+}
+
+// colorAllowed reports whether colors are allowed at all. They are turned
+// off by --no-color, a non-empty NO_COLOR environment variable
+// (https://no-color.org), or tui.enable_colors = false.
+func colorAllowed() bool {
+	if globalFlags.NoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if currentConfig != nil && !currentConfig.TUI.EnableColors {
+		return false
+	}
+	return true
+}
+
+// icon returns decorated in color output and plain otherwise
+func (s outputStyle) icon(decorated, plain string) string {
+	if s.color {
+		return decorated
+	}
+	return plain
+}
+
+// bold renders text in bold in color output
+func (s outputStyle) bold(text string) string {
+	return s.paint(ansiBold, text)
+}
+
+// green renders text in green in color output
+func (s outputStyle) green(text string) string {
+	return s.paint(ansiGreen, text)
+}
+
+// red renders text in red in color output
+func (s outputStyle) red(text string) string {
+	return s.paint(ansiRed, text)
+}
+
+// cyan renders text in cyan in color output
+func (s outputStyle) cyan(text string) string {
+	return s.paint(ansiCyan, text)
+}
+
+func (s outputStyle) paint(code, text string) string {
+	if !s.color || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// writeTable aligns the tab-separated rows that fill writes and copies them
+// to out with the header row in bold and, when highlight reports true for
+// a row's index, that row in green. Colors are applied after alignment so
+// the escape codes don't skew the columns.
+func (s outputStyle) writeTable(out io.Writer, fill func(w io.Writer), highlight func(row int) bool) error {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fill(w)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			line = s.bold(line)
+		case highlight != nil && highlight(i-1):
+			line = s.green(line)
+		}
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
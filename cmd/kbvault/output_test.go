@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// ansiPattern matches the escape sequences outputStyle emits
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9]+m")
+
+// assertPlain fails if out has ANSI escapes or characters outside ASCII
+func assertPlain(t *testing.T, out string) {
+	t.Helper()
+	assert.NotContains(t, out, "\x1b[", "output has ANSI escapes")
+	for _, r := range out {
+		if r > 127 {
+			t.Errorf("output has non-ASCII character %q:\n%s", r, out)
+			return
+		}
+	}
+}
+
+func TestColorAllowed(t *testing.T) {
+	originalNoColor := globalFlags.NoColor
+	defer func() { globalFlags.NoColor = originalNoColor }()
+	t.Setenv("NO_COLOR", "")
+
+	useTestConfig(t)
+	globalFlags.NoColor = false
+	assert.True(t, colorAllowed())
+
+	// Output that isn't a terminal is never decorated
+	assert.False(t, colorEnabled(&bytes.Buffer{}))
+
+	globalFlags.NoColor = true
+	assert.False(t, colorAllowed(), "--no-color")
+	globalFlags.NoColor = false
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, colorAllowed(), "NO_COLOR")
+	t.Setenv("NO_COLOR", "")
+
+	currentConfig.TUI.EnableColors = false
+	assert.False(t, colorAllowed(), "tui.enable_colors = false")
+
+	// Commands that run without a loaded config still allow colors
+	currentConfig = nil
+	assert.True(t, colorAllowed())
+}
+
+func TestOutputStyle(t *testing.T) {
+	plain := outputStyle{}
+	assert.Equal(t, "ID:", plain.icon("🆔", "ID:"))
+	assert.Equal(t, "title", plain.bold("title"))
+	assert.Equal(t, "", outputStyle{color: true}.bold(""))
+
+	color := outputStyle{color: true}
+	assert.Equal(t, "🆔", color.icon("🆔", "ID:"))
+	assert.Equal(t, "\x1b[1mtitle\x1b[0m", color.bold("title"))
+	assert.Equal(t, "\x1b[32m*\x1b[0m", color.green("*"))
+}
+
+func TestDisplayNotesDefault_Style(t *testing.T) {
+	notes := []*types.Note{{
+		ID:          "01HQ2X3Y4Z",
+		Title:       "Weekly Review",
+		FilePath:    "notes/01HQ2X3Y4Z.md",
+		Frontmatter: types.Frontmatter{Tags: []string{"review"}},
+		UpdatedAt:   time.Now(),
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, displayNotesDefault(&buf, notes, true, false, outputStyle{}))
+	assertPlain(t, buf.String())
+	assert.Equal(t, "Found 1 note(s):\n\n"+
+		"1. Weekly Review\n"+
+		"   ID: 01HQ2X3Y4Z\n"+
+		"   Tags: review\n"+
+		"   Updated: just now\n"+
+		"   Path: notes/01HQ2X3Y4Z.md\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, displayNotesDefault(&buf, notes, true, false, outputStyle{color: true}))
+	assert.Contains(t, buf.String(), "1. 📝 \x1b[1mWeekly Review\x1b[0m\n")
+	assert.Contains(t, buf.String(), "   🏷️  review\n")
+}
+
+func TestOutputSearch_Style(t *testing.T) {
+	results := []search.SearchResult{{
+		Note:  &types.NoteMetadata{ID: "01HQ2X3Y4Z", Title: "Weekly Review", FilePath: "notes/01HQ2X3Y4Z.md"},
+		Score: 1.5,
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, outputSearchList(&buf, results, outputStyle{}))
+	require.NoError(t, outputSearchDetailed(&buf, results, outputStyle{}))
+	assertPlain(t, buf.String())
+	assert.Contains(t, buf.String(), "1. Weekly Review\n")
+	assert.Contains(t, buf.String(), "=== Result 1 ===\n")
+
+	buf.Reset()
+	require.NoError(t, outputSearchList(&buf, results, outputStyle{color: true}))
+	assert.Contains(t, buf.String(), "1. \x1b[1mWeekly Review\x1b[0m\n")
+}
+
+func TestPrintProfilesTable_Style(t *testing.T) {
+	profiles := []config.ProfileInfo{
+		{Name: "default", IsDefault: true, StorageType: "local"},
+		{Name: "work", IsActive: true, StorageType: "s3"},
+	}
+
+	var plain bytes.Buffer
+	require.NoError(t, printProfilesTable(&plain, profiles, outputStyle{}))
+	assertPlain(t, plain.String())
+
+	var color bytes.Buffer
+	require.NoError(t, printProfilesTable(&color, profiles, outputStyle{color: true}))
+	lines := strings.Split(color.String(), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], ansiBold), "header is bold")
+	assert.True(t, strings.HasPrefix(lines[2], ansiGreen+"work"), "active profile is green")
+
+	// Colors don't change the column alignment
+	assert.Equal(t, plain.String(), ansiPattern.ReplaceAllString(color.String(), ""))
+
+	var cfg bytes.Buffer
+	require.NoError(t, printConfigTable(&cfg, "work", "", types.DefaultConfig(), outputStyle{}))
+	assertPlain(t, cfg.String())
+	assert.True(t, strings.HasPrefix(cfg.String(), "Profile: work\n\nSECTION"))
+}
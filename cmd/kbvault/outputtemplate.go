@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// formatTemplate is the --format value that renders output with --template
+const formatTemplate = "template"
+
+// templateFuncs are available to --template in addition to the text/template
+// builtins
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// searchTemplateData is what --template renders for each search result:
+// the note's fields, as for list, plus the result's score, snippet and
+// matches
+type searchTemplateData struct {
+	types.NoteMetadata
+	Score   float64
+	Snippet string
+	Matches []search.Match
+}
+
+// parseOutputTemplate parses the --template text used with --format
+// template
+func parseOutputTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, fmt.Errorf("--format template requires --template")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate writes tmpl rendered with each item, one per line
+func renderTemplate[T any](w io.Writer, tmpl *template.Template, items []T) error {
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return fmt.Errorf("failed to render --template: %w", err)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return nil
+}
+
+// displayNotesTemplate renders notes with tmpl. Each note is a
+// types.NoteMetadata whose tags include inline tags when enabled.
+func displayNotesTemplate(w io.Writer, notes []*types.Note, tmpl *template.Template) error {
+	data := make([]types.NoteMetadata, len(notes))
+	for i, n := range notes {
+		data[i] = n.ToMetadata()
+		data[i].Tags = noteTags(n)
+	}
+	return renderTemplate(w, tmpl, data)
+}
+
+// outputSearchTemplate renders search results with tmpl
+func outputSearchTemplate(w io.Writer, results []search.SearchResult, tmpl *template.Template) error {
+	data := make([]searchTemplateData, len(results))
+	for i, result := range results {
+		data[i] = searchTemplateData{
+			NoteMetadata: *result.Note,
+			Score:        result.Score,
+			Snippet:      result.Snippet,
+			Matches:      result.Matches,
+		}
+	}
+	return renderTemplate(w, tmpl, data)
+}
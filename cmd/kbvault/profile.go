@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"strings"
-	"text/tabwriter"
+	"time"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
@@ -30,7 +33,7 @@ Examples:
   kbvault profile delete old-profile`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Skip initialization for profile commands to avoid circular dependency
-			return nil
+			return applyTimeout(cmd)
 		},
 	}
 
@@ -40,19 +43,37 @@ Examples:
 	cmd.AddCommand(newProfileSwitchCmd())
 	cmd.AddCommand(newProfileShowCmd())
 	cmd.AddCommand(newProfileCopyCmd())
+	cmd.AddCommand(newProfileRenameCmd())
 	cmd.AddCommand(newProfileSetCmd())
 	cmd.AddCommand(newProfileGetCmd())
+	cmd.AddCommand(newProfileMigrateCmd())
+	cmd.AddCommand(newProfileExportCmd())
+	cmd.AddCommand(newProfileImportCmd())
 
 	return cmd
 }
 
 func newProfileListCmd() *cobra.Command {
-	var outputFormat string
+	var (
+		outputFormat string
+		sortBy       string
+		filters      []string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all available profiles",
-		Long:  "List all available configuration profiles with their status and storage types.",
+		Long: `List all available configuration profiles with their status and storage types.
+
+Profiles are listed default first, then the active profile, then by name.
+--sort orders them by name, by storage type, or active first. --filter
+narrows the list to profiles matching key=value, and can be repeated:
+storage=s3, name=work-* (a glob pattern), active=true or default=false.
+
+Examples:
+  kbvault profile list --sort storage
+  kbvault profile list --filter storage=s3 --filter name=client-*`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			pm, err := config.NewProfileManager()
 			if err != nil {
@@ -63,23 +84,36 @@ func newProfileListCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to list profiles: %w", err)
 			}
+			if profiles, err = config.FilterProfiles(profiles, filters); err != nil {
+				return err
+			}
+			if err := config.SortProfiles(profiles, sortBy); err != nil {
+				return err
+			}
 
 			switch outputFormat {
 			case "json":
 				return printProfilesJSON(cmd.OutOrStdout(), profiles)
 			default:
-				return printProfilesTable(cmd.OutOrStdout(), profiles)
+				return printProfilesTable(cmd.OutOrStdout(), profiles, newOutputStyle(cmd.OutOrStdout()))
 			}
 		},
 	}
 
 	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json)")
+	cmd.Flags().StringVar(&sortBy, "sort", "", "Sort by name, storage or active")
+	cmd.Flags().StringArrayVar(&filters, "filter", nil, "Only list profiles matching key=value: storage, name, active or default (repeatable)")
 
 	return cmd
 }
 
 func newProfileCreateCmd() *cobra.Command {
-	var options config.CreateProfileOptions
+	var (
+		options  config.CreateProfileOptions
+		cache    bool
+		validate bool
+		force    bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "create <profile-name>",
@@ -92,16 +126,32 @@ the profile will be created with default settings.
 Examples:
   kbvault profile create work
   kbvault profile create work --storage-type s3 --s3-bucket my-work-kb --s3-region us-east-1
-  kbvault profile create personal --storage-type local --local-path ~/personal-vault`,
+  kbvault profile create personal --storage-type local --local-path ~/personal-vault
+  kbvault profile create work --storage-type s3 --s3-bucket my-work-kb --validate
+  kbvault profile create ci --cache=false --log-level error --log-format json`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			profileName := args[0]
 
+			if cmd.Flags().Changed("cache") {
+				options.CacheEnabled = &cache
+			}
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
 			pm, err := config.NewProfileManager()
 			if err != nil {
 				return fmt.Errorf("failed to initialize profile manager: %w", err)
 			}
 
+			if validate {
+				cfg := config.NewProfileConfig(&options)
+				if err := checkProfileStorage(cmd.Context(), cmd.OutOrStdout(), cfg, force); err != nil {
+					return err
+				}
+			}
+
 			if err := pm.CreateProfile(profileName, &options); err != nil {
 				return fmt.Errorf("failed to create profile: %w", err)
 			}
@@ -138,9 +188,38 @@ Examples:
 	cmd.Flags().StringVar(&options.VaultName, "vault-name", "", "Name for the vault")
 	cmd.Flags().StringVar(&options.Description, "description", "", "Description for the profile")
 
+	// Cache and logging flags
+	cmd.Flags().BoolVar(&cache, "cache", false, "Enable the storage cache (--cache=false also disables it for remote storage)")
+	cmd.Flags().StringVar(&options.LogLevel, "log-level", "", "Log level (DEBUG, INFO, WARN, ERROR)")
+	cmd.Flags().StringVar(&options.LogFormat, "log-format", "", "Log format (text, json)")
+
+	// Validation flags
+	cmd.Flags().BoolVar(&validate, "validate", false, "Check that the storage backend is reachable before saving")
+	cmd.Flags().BoolVar(&force, "force", false, "Save the profile even if --validate fails")
+
 	return cmd
 }
 
+// profileCheckTimeout bounds the storage health check run by --validate
+const profileCheckTimeout = 10 * time.Second
+
+// checkProfileStorage validates cfg and runs a health check against its
+// storage backend before a profile is saved. With force a failure is
+// reported as a warning instead of an error.
+func checkProfileStorage(ctx context.Context, out io.Writer, cfg *types.Config, force bool) error {
+	opts := validateOptions{checkConnectivity: true, timeout: profileCheckTimeout}
+	if err := validateConfigDeep(ctx, cfg, opts); err != nil {
+		if !force {
+			return fmt.Errorf("profile validation failed: %w (use --force to save it anyway)", err)
+		}
+		_, _ = fmt.Fprintf(out, "Warning: profile validation failed: %v\n", err)
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "Storage check passed (%s).\n", cfg.Storage.Type)
+	return nil
+}
+
 func newProfileDeleteCmd() *cobra.Command {
 	var force bool
 
@@ -229,6 +308,11 @@ func newProfileShowCmd() *cobra.Command {
 				profileName = pm.GetActiveProfile()
 			}
 
+			description, err := pm.ProfileDescription(profileName)
+			if err != nil {
+				return fmt.Errorf("failed to get profile description: %w", err)
+			}
+
 			config, err := pm.GetConfig(profileName)
 			if err != nil {
 				return fmt.Errorf("failed to get profile configuration: %w", err)
@@ -238,7 +322,7 @@ func newProfileShowCmd() *cobra.Command {
 			case "json":
 				return printConfigJSON(cmd.OutOrStdout(), config)
 			default:
-				return printConfigTable(cmd.OutOrStdout(), profileName, config)
+				return printConfigTable(cmd.OutOrStdout(), profileName, description, config, newOutputStyle(cmd.OutOrStdout()))
 			}
 		},
 	}
@@ -275,13 +359,126 @@ func newProfileCopyCmd() *cobra.Command {
 	return cmd
 }
 
+func newProfileRenameCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <old-name> <new-name>",
+		Short: "Rename a profile",
+		Long: `Rename a profile. If the profile is active, it stays active under its
+new name. The default profile cannot be renamed.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldName := args[0]
+			newName := args[1]
+
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize profile manager: %w", err)
+			}
+
+			if err := pm.RenameProfile(oldName, newName); err != nil {
+				return fmt.Errorf("failed to rename profile: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Profile '%s' renamed to '%s' successfully.\n", oldName, newName)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newProfileExportCmd() *cobra.Command {
+	var (
+		outputPath     string
+		includeSecrets bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <profile-name>",
+		Short: "Export a profile to a file",
+		Long: `Write a profile's configuration to a TOML file that can be shared and
+loaded with 'kbvault profile import'. Secrets such as S3 secret keys and
+API keys are removed unless --include-secrets is given; ${VAR} references
+are always kept.
+
+Examples:
+  kbvault profile export work --output work.toml
+  kbvault profile export work --output work.toml --include-secrets`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileName := args[0]
+
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize profile manager: %w", err)
+			}
+
+			redacted, err := pm.ExportProfileFile(profileName, outputPath, includeSecrets)
+			if err != nil {
+				return fmt.Errorf("failed to export profile: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Profile '%s' exported to %s.\n", profileName, outputPath)
+			if len(redacted) > 0 {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed secrets: %s (use --include-secrets to keep them)\n", strings.Join(redacted, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write the profile to")
+	cmd.Flags().BoolVar(&includeSecrets, "include-secrets", false, "Keep secrets in the exported file")
+	_ = cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+func newProfileImportCmd() *cobra.Command {
+	var filePath string
+
+	cmd := &cobra.Command{
+		Use:   "import <profile-name>",
+		Short: "Create a profile from a file",
+		Long: `Create a new profile from a TOML file written by 'kbvault profile export'
+or copied from another profile. Settings missing from the file get their
+default values. The profile must not already exist.
+
+Examples:
+  kbvault profile import work2 --file work.toml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileName := args[0]
+
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize profile manager: %w", err)
+			}
+
+			if err := pm.ImportProfileFile(profileName, filePath); err != nil {
+				return fmt.Errorf("failed to import profile: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Profile '%s' imported from %s.\n", profileName, filePath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&filePath, "file", "f", "", "TOML file to import")
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
 func newProfileSetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "set <profile-name> <key> <value>",
 		Short: "Set a configuration value for a profile",
 		Long: `Set a specific configuration value for a profile using dot notation.
+The key "description" sets the profile's description instead; an empty
+value removes it.
 
 Examples:
+  kbvault profile set work description "Notes for the work team"
   kbvault profile set work vault.name "Work Vault"
   kbvault profile set work storage.type s3
   kbvault profile set work storage.s3.bucket my-work-bucket
@@ -316,6 +513,7 @@ func newProfileGetCmd() *cobra.Command {
 		Long: `Get a specific configuration value from a profile using dot notation.
 
 Examples:
+  kbvault profile get work description
   kbvault profile get work vault.name
   kbvault profile get work storage.type
   kbvault profile get work storage.s3.bucket`,
@@ -342,6 +540,74 @@ Examples:
 	return cmd
 }
 
+func newProfileMigrateCmd() *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate [profile-name]",
+		Short: "Upgrade profiles to the current config schema",
+		Long: `Upgrade profile files written by older versions of kbvault to the
+current configuration schema. Profiles are also migrated automatically
+when loaded; this command upgrades them ahead of time. The original file
+is kept alongside the profile with a .bak suffix.
+
+Examples:
+  kbvault profile migrate work
+  kbvault profile migrate --all`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all == (len(args) == 1) {
+				return fmt.Errorf("specify a profile name or --all")
+			}
+
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return fmt.Errorf("failed to initialize profile manager: %w", err)
+			}
+
+			names := args
+			if all {
+				// List names only; loading each config would migrate it implicitly
+				names, err = pm.ListProfileNames()
+				if err != nil {
+					return fmt.Errorf("failed to list profiles: %w", err)
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			failed := 0
+			for _, name := range names {
+				result, err := pm.MigrateProfile(name)
+				switch {
+				case err != nil && all && errors.Is(err, fs.ErrNotExist):
+					// Profiles without a file (e.g. an unsaved default) have nothing to migrate
+					continue
+				case err != nil:
+					failed++
+					_, _ = fmt.Fprintf(out, "%s: failed: %v\n", name, err)
+				case result.Migrated():
+					_, _ = fmt.Fprintf(out, "%s: migrated from version %d to %d (backup: %s)\n",
+						name, result.FromVersion, result.ToVersion, result.BackupPath)
+					for _, step := range result.Applied {
+						_, _ = fmt.Fprintf(out, "  - %s\n", step)
+					}
+				default:
+					_, _ = fmt.Fprintf(out, "%s: already at version %d\n", name, result.ToVersion)
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to migrate %d profile(s)", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Migrate every profile")
+
+	return cmd
+}
+
 // Custom flag type for storage type
 type storageTypeValue types.StorageType
 
@@ -365,27 +631,32 @@ func (s *storageTypeValue) Type() string {
 
 // Helper functions for output formatting
 
-func printProfilesTable(out io.Writer, profiles []config.ProfileInfo) error {
-	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-	defer func() { _ = w.Flush() }()
+func printProfilesTable(out io.Writer, profiles []config.ProfileInfo, style outputStyle) error {
+	fill := func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, "NAME\tACTIVE\tSTORAGE\tDEFAULT\tMODIFIED\tDESCRIPTION")
+		for _, profile := range profiles {
+			active := ""
+			if profile.IsActive {
+				active = "*"
+			}
 
-	_, _ = fmt.Fprintln(w, "NAME\tACTIVE\tSTORAGE\tDEFAULT")
-	for _, profile := range profiles {
-		active := ""
-		if profile.IsActive {
-			active = "*"
-		}
+			defaultFlag := ""
+			if profile.IsDefault {
+				defaultFlag = "default"
+			}
 
-		defaultFlag := ""
-		if profile.IsDefault {
-			defaultFlag = "default"
-		}
+			modified := "-"
+			if !profile.ModifiedAt.IsZero() {
+				modified = profile.ModifiedAt.Local().Format("2006-01-02 15:04")
+			}
 
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-			profile.Name, active, profile.StorageType, defaultFlag)
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				profile.Name, active, profile.StorageType, defaultFlag, modified, profile.Description)
+		}
 	}
 
-	return nil
+	// The active profile is highlighted
+	return style.writeTable(out, fill, func(row int) bool { return profiles[row].IsActive })
 }
 
 func printProfilesJSON(out io.Writer, profiles []config.ProfileInfo) error {
@@ -394,41 +665,42 @@ func printProfilesJSON(out io.Writer, profiles []config.ProfileInfo) error {
 	return encoder.Encode(profiles)
 }
 
-func printConfigTable(out io.Writer, profileName string, config *types.Config) error {
-	_, _ = fmt.Fprintf(out, "Profile: %s\n\n", profileName)
-
-	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
-	defer func() { _ = w.Flush() }()
-
-	_, _ = fmt.Fprintln(w, "SECTION\tKEY\tVALUE")
-
-	// Vault configuration
-	_, _ = fmt.Fprintf(w, "vault\tname\t%s\n", config.Vault.Name)
-	_, _ = fmt.Fprintf(w, "vault\tnotes_dir\t%s\n", config.Vault.NotesDir)
-	_, _ = fmt.Fprintf(w, "vault\tdaily_dir\t%s\n", config.Vault.DailyDir)
-	_, _ = fmt.Fprintf(w, "vault\ttemplates_dir\t%s\n", config.Vault.TemplatesDir)
-
-	// Storage configuration
-	_, _ = fmt.Fprintf(w, "storage\ttype\t%s\n", config.Storage.Type)
-
-	switch config.Storage.Type {
-	case types.StorageTypeLocal:
-		_, _ = fmt.Fprintf(w, "storage.local\tpath\t%s\n", config.Storage.Local.Path)
-		_, _ = fmt.Fprintf(w, "storage.local\tcreate_dirs\t%t\n", config.Storage.Local.CreateDirs)
-	case types.StorageTypeS3:
-		_, _ = fmt.Fprintf(w, "storage.s3\tbucket\t%s\n", config.Storage.S3.Bucket)
-		_, _ = fmt.Fprintf(w, "storage.s3\tregion\t%s\n", config.Storage.S3.Region)
-		if config.Storage.S3.Endpoint != "" {
-			_, _ = fmt.Fprintf(w, "storage.s3\tendpoint\t%s\n", config.Storage.S3.Endpoint)
-		}
+func printConfigTable(out io.Writer, profileName, description string, config *types.Config, style outputStyle) error {
+	_, _ = fmt.Fprintf(out, "Profile: %s\n", style.bold(profileName))
+	if description != "" {
+		_, _ = fmt.Fprintf(out, "Description: %s\n", description)
 	}
+	_, _ = fmt.Fprintln(out)
+
+	return style.writeTable(out, func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, "SECTION\tKEY\tVALUE")
+
+		// Vault configuration
+		_, _ = fmt.Fprintf(w, "vault\tname\t%s\n", config.Vault.Name)
+		_, _ = fmt.Fprintf(w, "vault\tnotes_dir\t%s\n", config.Vault.NotesDir)
+		_, _ = fmt.Fprintf(w, "vault\tdaily_dir\t%s\n", config.Vault.DailyDir)
+		_, _ = fmt.Fprintf(w, "vault\ttemplates_dir\t%s\n", config.Vault.TemplatesDir)
+
+		// Storage configuration
+		_, _ = fmt.Fprintf(w, "storage\ttype\t%s\n", config.Storage.Type)
+
+		switch config.Storage.Type {
+		case types.StorageTypeLocal:
+			_, _ = fmt.Fprintf(w, "storage.local\tpath\t%s\n", config.Storage.Local.Path)
+			_, _ = fmt.Fprintf(w, "storage.local\tcreate_dirs\t%t\n", config.Storage.Local.CreateDirs)
+		case types.StorageTypeS3:
+			_, _ = fmt.Fprintf(w, "storage.s3\tbucket\t%s\n", config.Storage.S3.Bucket)
+			_, _ = fmt.Fprintf(w, "storage.s3\tregion\t%s\n", config.Storage.S3.Region)
+			if config.Storage.S3.Endpoint != "" {
+				_, _ = fmt.Fprintf(w, "storage.s3\tendpoint\t%s\n", config.Storage.S3.Endpoint)
+			}
+		}
 
-	// Server configuration
-	_, _ = fmt.Fprintf(w, "server.http\tenabled\t%t\n", config.Server.HTTP.Enabled)
-	_, _ = fmt.Fprintf(w, "server.http\thost\t%s\n", config.Server.HTTP.Host)
-	_, _ = fmt.Fprintf(w, "server.http\tport\t%d\n", config.Server.HTTP.Port)
-
-	return nil
+		// Server configuration
+		_, _ = fmt.Fprintf(w, "server.http\tenabled\t%t\n", config.Server.HTTP.Enabled)
+		_, _ = fmt.Fprintf(w, "server.http\thost\t%s\n", config.Server.HTTP.Host)
+		_, _ = fmt.Fprintf(w, "server.http\tport\t%d\n", config.Server.HTTP.Port)
+	}, nil)
 }
 
 func printConfigJSON(out io.Writer, config *types.Config) error {
@@ -2,14 +2,21 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/config"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
 func TestNewProfileCmd(t *testing.T) {
@@ -64,6 +71,83 @@ func TestProfileListCmd_JSON(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, profiles, 1) // Should have default profile
 	assert.Equal(t, "default", profiles[0].Name)
+	assert.NotContains(t, buf.String(), "modified_at")
+}
+
+func TestProfileListCmd_Timestamps(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.CreateProfile("work", nil))
+
+	cmd := newProfileListCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"--output", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var profiles []config.ProfileInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &profiles))
+	require.Len(t, profiles, 2)
+	work := profiles[1]
+	assert.Equal(t, "work", work.Name)
+	assert.WithinDuration(t, time.Now(), work.ModifiedAt, time.Minute)
+	assert.WithinDuration(t, time.Now(), work.CreatedAt, time.Minute)
+
+	cmd = newProfileListCmd()
+	buf.Reset()
+	cmd.SetOut(&buf)
+	cmd.SetArgs(nil)
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "MODIFIED")
+	assert.Contains(t, buf.String(), work.ModifiedAt.Local().Format("2006-01-02 15:04"))
+}
+
+func TestProfileListCmd_SortAndFilter(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.CreateProfile("work", &config.CreateProfileOptions{
+		StorageType: types.StorageTypeS3, S3Bucket: "work-kb", S3Region: "us-east-1",
+	}))
+	require.NoError(t, pm.CreateProfile("archive", &config.CreateProfileOptions{
+		StorageType: types.StorageTypeS3, S3Bucket: "archive-kb", S3Region: "us-east-1",
+	}))
+	require.NoError(t, pm.CreateProfile("personal", nil))
+	require.NoError(t, pm.SwitchProfile("personal"))
+
+	list := func(args ...string) []string {
+		t.Helper()
+		cmd := newProfileListCmd()
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs(append([]string{"--output", "json"}, args...))
+		require.NoError(t, cmd.Execute())
+
+		var profiles []config.ProfileInfo
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &profiles))
+		names := make([]string, len(profiles))
+		for i, p := range profiles {
+			names[i] = p.Name
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"default", "personal", "archive", "work"}, list())
+	assert.Equal(t, []string{"archive", "default", "personal", "work"}, list("--sort", "name"))
+	assert.Equal(t, []string{"default", "personal", "archive", "work"}, list("--sort", "storage"))
+	assert.Equal(t, []string{"personal", "archive", "default", "work"}, list("--sort", "active"))
+	assert.Equal(t, []string{"archive", "work"}, list("--filter", "storage=s3"))
+	assert.Equal(t, []string{"work"}, list("--filter", "storage=s3", "--filter", "name=w*"))
+	assert.Equal(t, []string{}, list("--filter", "storage=azure"))
+
+	cmd := newProfileListCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--sort", "size"})
+	assert.ErrorContains(t, cmd.Execute(), `invalid sort "size"`)
 }
 
 func TestProfileCreateCmd(t *testing.T) {
@@ -132,6 +216,137 @@ func TestProfileCreateCmd_WithFlags(t *testing.T) {
 	assert.Equal(t, "us-east-1", profileConfig.Storage.S3.Region)
 }
 
+func TestProfileCreateCmd_CacheAndLogging(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	create := func(name string, args ...string) error {
+		cmd := newProfileCreateCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetIn(strings.NewReader("n\n"))
+		cmd.SetArgs(append([]string{name}, args...))
+		return cmd.Execute()
+	}
+
+	require.NoError(t, create("ci", "--cache", "--log-level", "debug", "--log-format", "JSON"))
+	require.NoError(t, create("quiet", "--storage-type", "s3", "--s3-bucket", "kb", "--s3-region", "us-east-1", "--cache=false"))
+	require.NoError(t, create("plain"))
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+
+	cfg, err := pm.GetConfig("ci")
+	require.NoError(t, err)
+	assert.True(t, cfg.Storage.Cache.Enabled)
+	assert.Equal(t, "DEBUG", cfg.Logging.Level)
+	assert.Equal(t, "json", cfg.Logging.Format)
+
+	cfg, err = pm.GetConfig("quiet")
+	require.NoError(t, err)
+	assert.False(t, cfg.Storage.Cache.Enabled)
+	assert.False(t, cfg.Storage.Cache.AutoEnable)
+
+	// Without the flags the defaults are kept
+	defaults := types.DefaultConfig()
+	cfg, err = pm.GetConfig("plain")
+	require.NoError(t, err)
+	assert.Equal(t, defaults.Storage.Cache.Enabled, cfg.Storage.Cache.Enabled)
+	assert.Equal(t, defaults.Storage.Cache.AutoEnable, cfg.Storage.Cache.AutoEnable)
+	assert.Equal(t, defaults.Logging.Level, cfg.Logging.Level)
+	assert.Equal(t, defaults.Logging.Format, cfg.Logging.Format)
+
+	err = create("noisy", "--log-level", "trace")
+	assert.ErrorContains(t, err, `invalid log level "trace"`)
+	err = create("noisy", "--log-format", "xml")
+	assert.ErrorContains(t, err, `invalid log format "xml"`)
+	profiles, err := pm.ListProfiles()
+	require.NoError(t, err)
+	for _, profile := range profiles {
+		assert.NotEqual(t, "noisy", profile.Name)
+	}
+}
+
+// unhealthyStorage is a storage backend whose health check fails
+type unhealthyStorage struct {
+	types.StorageBackend
+	err error
+}
+
+func (s *unhealthyStorage) Health(ctx context.Context) error { return s.err }
+func (s *unhealthyStorage) Close() error                     { return nil }
+
+// useStorageFactory makes validation build backends with factory
+func useStorageFactory(t *testing.T, factory func(types.StorageConfig) (types.StorageBackend, error)) {
+	t.Helper()
+	original := newStorageBackend
+	newStorageBackend = factory
+	t.Cleanup(func() { newStorageBackend = original })
+}
+
+func TestProfileCreateCmd_Validate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var checked types.StorageConfig
+	useStorageFactory(t, func(cfg types.StorageConfig) (types.StorageBackend, error) {
+		checked = cfg
+		return &unhealthyStorage{err: errors.New("NoSuchBucket: the specified bucket does not exist")}, nil
+	})
+
+	newCmd := func(extra ...string) *cobra.Command {
+		cmd := newProfileCreateCmd()
+		args := append([]string{"--storage-type", "s3", "--s3-bucket", "typo-bucket", "--s3-region", "us-east-1", "--validate"}, extra...)
+		require.NoError(t, cmd.ParseFlags(args))
+		cmd.SetIn(strings.NewReader("n\n"))
+		cmd.SetContext(context.Background())
+		return cmd
+	}
+
+	// A failing health check refuses to create the profile
+	cmd := newCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	err := cmd.RunE(cmd, []string{"work"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NoSuchBucket")
+	assert.Contains(t, err.Error(), "--force")
+	assert.Equal(t, "typo-bucket", checked.S3.Bucket)
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	assert.False(t, profileExists(pm, "work"), "profile should not have been saved")
+
+	// --force saves it with a warning
+	cmd = newCmd("--force")
+	buf.Reset()
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.RunE(cmd, []string{"work"}))
+	assert.Contains(t, buf.String(), "Warning: profile validation failed")
+	assert.Contains(t, buf.String(), "Profile 'work' created successfully")
+
+	// A healthy backend passes
+	useStorageFactory(t, func(cfg types.StorageConfig) (types.StorageBackend, error) {
+		return &unhealthyStorage{}, nil
+	})
+	cmd = newCmd()
+	buf.Reset()
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.RunE(cmd, []string{"home"}))
+	assert.Contains(t, buf.String(), "Storage check passed (s3)")
+}
+
+func TestCheckProfileStorage_InvalidConfig(t *testing.T) {
+	useStorageFactory(t, func(cfg types.StorageConfig) (types.StorageBackend, error) {
+		t.Fatal("an invalid config should not reach the backend")
+		return nil, nil
+	})
+
+	cfg := types.DefaultConfig()
+	cfg.Storage.Type = types.StorageTypeS3
+	err := checkProfileStorage(context.Background(), &bytes.Buffer{}, cfg, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "profile validation failed")
+}
+
 func TestProfileCreateCmd_InvalidArgs(t *testing.T) {
 	cmd := newProfileCreateCmd()
 
@@ -319,6 +534,40 @@ func TestProfileCopyCmd(t *testing.T) {
 	assert.True(t, found)
 }
 
+func TestProfileRenameCmd(t *testing.T) {
+	// Set up temporary home directory
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.CreateProfile("work", nil))
+	require.NoError(t, pm.SwitchProfile("work"))
+
+	cmd := newProfileRenameCmd()
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+
+	err = cmd.RunE(cmd, []string{"work", "job"})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Profile 'work' renamed to 'job' successfully")
+
+	// A fresh manager sees the renamed profile as active
+	pm, err = config.NewProfileManager()
+	require.NoError(t, err)
+	assert.Equal(t, "job", pm.GetActiveProfile())
+
+	names, err := pm.ListProfileNames()
+	require.NoError(t, err)
+	assert.Contains(t, names, "job")
+	assert.NotContains(t, names, "work")
+
+	// The default profile cannot be renamed
+	err = cmd.RunE(cmd, []string{"default", "main"})
+	assert.Error(t, err)
+}
+
 func TestProfileSetGetCmd(t *testing.T) {
 	// Set up temporary home directory
 	tmpDir := t.TempDir()
@@ -353,6 +602,44 @@ func TestProfileSetGetCmd(t *testing.T) {
 	assert.Contains(t, output, "test-vault")
 }
 
+func TestProfileDescription_Cmds(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	createCmd := newProfileCreateCmd()
+	createCmd.SetOut(&bytes.Buffer{})
+	createCmd.SetArgs([]string{"work", "--description", "Work notes"})
+	require.NoError(t, createCmd.Execute())
+
+	listCmd := newProfileListCmd()
+	var buf bytes.Buffer
+	listCmd.SetOut(&buf)
+	listCmd.SetArgs(nil)
+	require.NoError(t, listCmd.Execute())
+	assert.Contains(t, buf.String(), "DESCRIPTION")
+	assert.Contains(t, buf.String(), "Work notes")
+
+	setCmd := newProfileSetCmd()
+	buf.Reset()
+	setCmd.SetOut(&buf)
+	setCmd.SetArgs([]string{"work", "description", "Team notes"})
+	require.NoError(t, setCmd.Execute())
+	assert.Contains(t, buf.String(), "Set work.description = Team notes")
+
+	showCmd := newProfileShowCmd()
+	buf.Reset()
+	showCmd.SetOut(&buf)
+	showCmd.SetArgs([]string{"work"})
+	require.NoError(t, showCmd.Execute())
+	assert.Contains(t, buf.String(), "Description: Team notes")
+
+	getCmd := newProfileGetCmd()
+	buf.Reset()
+	getCmd.SetOut(&buf)
+	getCmd.SetArgs([]string{"work", "description"})
+	require.NoError(t, getCmd.Execute())
+	assert.Equal(t, "Team notes\n", buf.String())
+}
+
 func TestStorageTypeValue(t *testing.T) {
 	var st storageTypeValue
 
@@ -393,7 +680,7 @@ func TestPrintFunctions(t *testing.T) {
 
 	// Test table output
 	var buf bytes.Buffer
-	err := printProfilesTable(&buf, profiles)
+	err := printProfilesTable(&buf, profiles, outputStyle{})
 	assert.NoError(t, err)
 
 	// Test JSON output
@@ -463,3 +750,130 @@ func TestProfileWorkflow(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, profiles, 2) // default + workflow-test
 }
+
+func TestProfileMigrateCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.CreateProfile("current", nil))
+
+	// Write a profile in the pre-versioning layout
+	legacy := "[vault]\nname = \"legacy\"\n\n[storage]\ntype = \"local\"\npath = \"./legacy\"\n"
+	legacyPath := filepath.Join(tmpDir, ".kbvault", "profiles", "legacy.toml")
+	require.NoError(t, os.WriteFile(legacyPath, []byte(legacy), 0644))
+
+	cmd := newProfileMigrateCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Flags().Set("all", "true"))
+
+	err = cmd.RunE(cmd, []string{})
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Contains(t, output, "legacy: migrated from version 0 to 1")
+	assert.Contains(t, output, "current: already at version 1")
+
+	cfg, err := pm.GetConfig("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "./legacy", cfg.Storage.Local.Path)
+}
+
+func TestProfileMigrateCmd_InvalidArgs(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	cmd := newProfileMigrateCmd()
+	err := cmd.RunE(cmd, []string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "specify a profile name or --all")
+}
+
+func TestProfileExportImportCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.CreateProfile("work", &config.CreateProfileOptions{
+		StorageType: "s3",
+		S3Bucket:    "work-bucket",
+		S3Region:    "eu-west-1",
+		VaultName:   "work-vault",
+	}))
+	setProfileSecret(t, pm, "work", "super-secret")
+
+	exportPath := filepath.Join(tmpDir, "work.toml")
+
+	cmd := newProfileExportCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Flags().Set("output", exportPath))
+	require.NoError(t, cmd.RunE(cmd, []string{"work"}))
+	assert.Contains(t, buf.String(), "Profile 'work' exported to "+exportPath)
+	assert.Contains(t, buf.String(), "Removed secrets: storage.s3.secret_access_key")
+
+	data, err := os.ReadFile(exportPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "super-secret")
+
+	cmd = newProfileImportCmd()
+	buf.Reset()
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Flags().Set("file", exportPath))
+	require.NoError(t, cmd.RunE(cmd, []string{"work2"}))
+	assert.Contains(t, buf.String(), "Profile 'work2' imported from "+exportPath)
+
+	imported, err := pm.GetConfig("work2")
+	require.NoError(t, err)
+	assert.Equal(t, "work-vault", imported.Vault.Name)
+	assert.Equal(t, "work-bucket", imported.Storage.S3.Bucket)
+	assert.Equal(t, "eu-west-1", imported.Storage.S3.Region)
+	assert.Empty(t, imported.Storage.S3.SecretAccessKey)
+
+	// Importing over an existing profile is refused
+	err = cmd.RunE(cmd, []string{"work"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestProfileExportCmd_IncludeSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	pm, err := config.NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.CreateProfile("work", nil))
+	setProfileSecret(t, pm, "work", "super-secret")
+
+	exportPath := filepath.Join(tmpDir, "work.toml")
+
+	cmd := newProfileExportCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	require.NoError(t, cmd.Flags().Set("output", exportPath))
+	require.NoError(t, cmd.Flags().Set("include-secrets", "true"))
+	require.NoError(t, cmd.RunE(cmd, []string{"work"}))
+	assert.NotContains(t, buf.String(), "Removed secrets")
+
+	info, err := os.Stat(exportPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, pm.ImportProfileFile("work2", exportPath))
+	imported, err := pm.GetConfig("work2")
+	require.NoError(t, err)
+	assert.Equal(t, "super-secret", imported.Storage.S3.SecretAccessKey)
+}
+
+// setProfileSecret stores an S3 secret key in a profile's file
+func setProfileSecret(t *testing.T, pm *config.ProfileManager, name, secret string) {
+	t.Helper()
+
+	cfg, err := pm.GetRawConfig(name)
+	require.NoError(t, err)
+	cfg.Storage.S3.SecretAccessKey = secret
+	require.NoError(t, pm.UpdateProfile(name, cfg))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/compress"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/encrypt"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func newRekeyCmd() *cobra.Command {
+	var (
+		oldKeyFile string
+		newKeyFile string
+		dryRun     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rekey",
+		Short: "Re-encrypt the vault under a new key",
+		Long: `Rotate the key of a vault encrypted with storage.encryption. Every
+encrypted note, attachment and saved index is decrypted with the old key and
+re-encrypted with the new one. Files that aren't encrypted are left as they
+are.
+
+All files are re-encrypted to a staging area before any original is
+replaced, so a wrong old key or an unreadable file leaves the vault
+unchanged. If replacing the originals is interrupted, run the same command
+again to finish.
+
+Past versions kept by version history stay under the old key. Once the
+command succeeds, point storage.encryption.key_file at the new key.`,
+		Example: `  kbvault rekey --old-key-file vault.key --new-key-file vault-2025.key
+  kbvault rekey --old-key-file vault.key --new-key-file vault-2025.key --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			oldKey, err := encrypt.LoadKeyFile(oldKeyFile)
+			if err != nil {
+				return fmt.Errorf("invalid --old-key-file: %w", err)
+			}
+			newKey, err := encrypt.LoadKeyFile(newKeyFile)
+			if err != nil {
+				return fmt.Errorf("invalid --new-key-file: %w", err)
+			}
+
+			backend, err := storage.CreateStorage(storedConfig(cfg.Storage))
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() { _ = backend.Close() }()
+
+			paths, err := rekeyPaths(ctx, backend)
+			if err != nil {
+				return fmt.Errorf("failed to list vault files: %w", err)
+			}
+
+			result, err := encrypt.Rekey(ctx, backend, paths, oldKey, newKey, encrypt.RekeyOptions{DryRun: dryRun})
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if dryRun {
+				_, _ = fmt.Fprintf(out, "Would re-encrypt %d files\n", len(result.Rekeyed))
+			} else {
+				_, _ = fmt.Fprintf(out, "Re-encrypted %d files\n", len(result.Rekeyed))
+			}
+			if len(result.Current) > 0 {
+				_, _ = fmt.Fprintf(out, "%d files were already under the new key\n", len(result.Current))
+			}
+			if len(result.Plaintext) > 0 {
+				_, _ = fmt.Fprintf(out, "%d files are not encrypted and were left as they are\n", len(result.Plaintext))
+			}
+			if !dryRun && len(result.Rekeyed) > 0 {
+				_, _ = fmt.Fprintf(out, "Set storage.encryption.key_file to %s to use the vault\n", newKeyFile)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&oldKeyFile, "old-key-file", "", "File holding the key the vault is encrypted with")
+	cmd.Flags().StringVar(&newKeyFile, "new-key-file", "", "File holding the key to re-encrypt the vault with")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Check the old key and count the files to re-encrypt without changing them")
+	_ = cmd.MarkFlagRequired("old-key-file")
+	_ = cmd.MarkFlagRequired("new-key-file")
+
+	return cmd
+}
+
+// storedConfig returns config without encryption and compression, so that
+// the backend created for it reads and writes files as they are stored.
+// The disk cache, which sits below encryption, is kept so that it stays
+// consistent with the files rewritten through it.
+func storedConfig(config types.StorageConfig) types.StorageConfig {
+	config.Encryption = types.EncryptionConfig{}
+	config.Compression = types.CompressionNone
+	return config
+}
+
+// rekeyPaths returns the stored paths of the files rekey re-encrypts: the
+// files in the note directories and in .kbvault, and the attachments of
+// each note. Edit locks and staged files are left out.
+func rekeyPaths(ctx context.Context, backend types.StorageBackend) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(file string, _ *types.FileInfo) error {
+		if seen[file] || strings.HasPrefix(file, editLockDir) || strings.HasPrefix(file, encrypt.RekeyStagingDir+"/") {
+			return nil
+		}
+		seen[file] = true
+		paths = append(paths, file)
+		return nil
+	}
+
+	for _, dir := range append(noteDirs(), ".kbvault/") {
+		if err := types.Walk(ctx, backend, dir, add); err != nil {
+			return nil, err
+		}
+	}
+
+	// Local storage doesn't list subdirectories, so each note's
+	// attachments are listed on their own
+	for _, file := range paths {
+		name := path.Base(strings.TrimSuffix(file, compress.Suffix))
+		if strings.HasSuffix(name, ".md") {
+			dir := attachmentsDir + strings.TrimSuffix(name, ".md") + "/"
+			if err := types.Walk(ctx, backend, dir, add); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return paths, nil
+}
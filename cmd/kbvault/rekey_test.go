@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/encrypt"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// writeKeyFile writes a new random key to a file and returns its path
+func writeKeyFile(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	key := make([]byte, encrypt.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString(key)), 0o600))
+	return path
+}
+
+// rekeyFiles are the files of the vault set up by setupRekeyVault
+var rekeyFiles = map[string]string{
+	"notes/01JA.md":                     "---\nid: 01JA\ntitle: Alpha\n---\n\nFirst.\n",
+	"notes/01JB.md":                     "---\nid: 01JB\ntitle: Beta\n---\n\nSecond.\n",
+	"notes/dailies/2024-01-15.md":       "---\nid: 2024-01-15\ntitle: Monday\n---\n\nDaily.\n",
+	"attachments/01JA/diagram.png":      "image bytes",
+	".kbvault/search-index.json":        `{"documents": []}`,
+	"attachments/2024-01-15/report.pdf": "pdf bytes",
+}
+
+// setupRekeyVault configures a compressed vault encrypted under a new key
+// file holding rekeyFiles, and returns the key file
+func setupRekeyVault(t *testing.T) string {
+	t.Helper()
+
+	keyFile := writeKeyFile(t, t.TempDir(), "old.key")
+	useTestConfig(t, func(c *types.Config) {
+		c.Storage.Compression = types.CompressionGzip
+		c.Storage.Encryption = types.EncryptionConfig{Enabled: true, KeyFile: keyFile}
+	})
+
+	backend := mustStorage(t)
+	for path, content := range rekeyFiles {
+		require.NoError(t, backend.Write(context.Background(), path, []byte(content)))
+	}
+	return keyFile
+}
+
+// runRekey runs the rekey command with args, keeping the test
+// configuration
+func runRekey(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newRootCmd()
+	cmd.PersistentPreRunE = nil
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(append([]string{"rekey"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// readVaultFiles reads rekeyFiles with keyFile, returning the first error
+func readVaultFiles(t *testing.T, keyFile string) error {
+	t.Helper()
+
+	config := currentConfig.Storage
+	config.Encryption.KeyFile = keyFile
+	backend, err := storage.CreateStorage(config)
+	require.NoError(t, err)
+	defer func() { _ = backend.Close() }()
+
+	for path, content := range rekeyFiles {
+		data, err := backend.Read(context.Background(), path)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, content, string(data), path)
+	}
+	return nil
+}
+
+func TestRekeyCommand(t *testing.T) {
+	oldKeyFile := setupRekeyVault(t)
+	newKeyFile := writeKeyFile(t, t.TempDir(), "new.key")
+
+	out, err := runRekey(t, "--old-key-file", oldKeyFile, "--new-key-file", newKeyFile, "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Would re-encrypt 6 files")
+	require.NoError(t, readVaultFiles(t, oldKeyFile))
+
+	out, err = runRekey(t, "--old-key-file", oldKeyFile, "--new-key-file", newKeyFile)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Re-encrypted 6 files")
+	assert.Contains(t, out, "Set storage.encryption.key_file to "+newKeyFile)
+
+	// Every file decrypts with the new key only
+	require.NoError(t, readVaultFiles(t, newKeyFile))
+	assert.Error(t, readVaultFiles(t, oldKeyFile))
+
+	// Running it again finds nothing left to do
+	out, err = runRekey(t, "--old-key-file", oldKeyFile, "--new-key-file", newKeyFile)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Re-encrypted 0 files")
+	assert.Contains(t, out, "6 files were already under the new key")
+}
+
+func TestRekeyCommand_WrongKey(t *testing.T) {
+	oldKeyFile := setupRekeyVault(t)
+	dir := t.TempDir()
+
+	_, err := runRekey(t, "--old-key-file", writeKeyFile(t, dir, "wrong.key"), "--new-key-file", writeKeyFile(t, dir, "new.key"))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, encrypt.ErrWrongKey)
+	assert.Contains(t, err.Error(), "refusing to rekey")
+	require.NoError(t, readVaultFiles(t, oldKeyFile))
+
+	_, err = runRekey(t, "--old-key-file", oldKeyFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "new-key-file")
+
+	_, err = runRekey(t, "--old-key-file", oldKeyFile, "--new-key-file", filepath.Join(dir, "missing.key"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --new-key-file")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func newS3Cmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "s3",
+		Short: "Maintain S3 storage",
+		Long:  `Maintenance tasks for vaults stored in S3-compatible storage.`,
+	}
+
+	cmd.AddCommand(newS3GCCmd())
+
+	return cmd
+}
+
+func newS3GCCmd() *cobra.Command {
+	var (
+		olderThan time.Duration
+		dryRun    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Abort abandoned multipart uploads",
+		Long: `Find multipart uploads under the vault prefix that were started more
+than --older-than ago and never completed, and abort them so their parts
+stop accruing storage charges.
+
+Uploads still in progress are younger than the threshold, so keep it well
+above the time your largest uploads take.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+			if cfg.Storage.Type != types.StorageTypeS3 {
+				return fmt.Errorf("s3 gc requires s3 storage, current storage is %s", cfg.Storage.Type)
+			}
+			if olderThan <= 0 {
+				return fmt.Errorf("--older-than must be positive")
+			}
+
+			storageBackend, err := s3.NewStorage(cfg.Storage.S3)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+
+			uploads, err := storageBackend.ListMultipartUploads(ctx, time.Now().Add(-olderThan))
+			if err != nil {
+				return fmt.Errorf("failed to list multipart uploads: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(uploads) == 0 {
+				_, _ = fmt.Fprintf(out, "No multipart uploads older than %s\n", olderThan)
+				return nil
+			}
+
+			aborted := 0
+			for _, upload := range uploads {
+				if dryRun {
+					_, _ = fmt.Fprintf(out, "Would abort %s (started %s)\n", upload.Key, upload.Initiated.Format(time.RFC3339))
+					continue
+				}
+				if err := storageBackend.AbortMultipartUpload(ctx, upload); err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to abort %s: %v\n", upload.Key, err)
+					continue
+				}
+				aborted++
+				_, _ = fmt.Fprintf(out, "Aborted %s (started %s)\n", upload.Key, upload.Initiated.Format(time.RFC3339))
+			}
+
+			if dryRun {
+				_, _ = fmt.Fprintf(out, "%d multipart uploads would be aborted\n", len(uploads))
+				return nil
+			}
+			_, _ = fmt.Fprintf(out, "Aborted %d of %d multipart uploads\n", aborted, len(uploads))
+			if aborted < len(uploads) {
+				return fmt.Errorf("failed to abort %d multipart uploads", len(uploads)-aborted)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&olderThan, "older-than", 24*time.Hour, "Only abort uploads started longer ago than this")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the uploads that would be aborted without aborting them")
+
+	return cmd
+}
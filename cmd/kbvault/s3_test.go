@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3/s3test"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+const s3GCTestUploads = `<ListMultipartUploadsResult><Bucket>test-bucket</Bucket><IsTruncated>false</IsTruncated>
+<Upload><Key>notes/a.md</Key><UploadId>u1</UploadId><Initiated>2024-01-01T00:00:00.000Z</Initiated></Upload>
+<Upload><Key>big.bin</Key><UploadId>u2</UploadId><Initiated>2024-01-02T00:00:00.000Z</Initiated></Upload>
+</ListMultipartUploadsResult>`
+
+// setupS3GCTest points the current config at a fake S3 endpoint and
+// returns the uploads it was asked to abort
+func setupS3GCTest(t *testing.T) func() []string {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		aborted []string
+	)
+	s3Config := s3test.NewFakeS3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && query.Has("uploads"):
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(s3GCTestUploads))
+		case r.Method == http.MethodDelete && query.Has("uploadId"):
+			mu.Lock()
+			aborted = append(aborted, query.Get("uploadId"))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+
+	useTestConfig(t, func(c *types.Config) {
+		c.Storage.Type = types.StorageTypeS3
+		c.Storage.S3 = s3Config
+	})
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), aborted...)
+	}
+}
+
+func runS3Cmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newS3Cmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestS3GC(t *testing.T) {
+	aborted := setupS3GCTest(t)
+
+	out, err := runS3Cmd(t, "gc", "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Would abort notes/a.md (started 2024-01-01T00:00:00Z)")
+	assert.Contains(t, out, "2 multipart uploads would be aborted")
+	assert.Empty(t, aborted())
+
+	out, err = runS3Cmd(t, "gc", "--older-than", "48h")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Aborted big.bin")
+	assert.Contains(t, out, "Aborted 2 of 2 multipart uploads")
+	assert.Equal(t, []string{"u1", "u2"}, aborted())
+}
+
+func TestS3GC_Errors(t *testing.T) {
+	setupS3GCTest(t)
+
+	_, err := runS3Cmd(t, "gc", "--older-than", "0s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--older-than must be positive")
+
+	currentConfig.Storage.Type = types.StorageTypeLocal
+	_, err = runS3Cmd(t, "gc")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires s3 storage")
+}
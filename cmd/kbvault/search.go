@@ -1,11 +1,12 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
+	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
@@ -15,18 +16,28 @@ import (
 
 func newSearchCmd() *cobra.Command {
 	var (
-		tags       []string
-		noteType   string
-		sortBy     string
-		sortDesc   bool
-		limit      int
-		offset     int
-		fields     []string
-		outputJSON bool
-		detailed   bool
-		buildIndex bool
-		after      string
-		before     string
+		tags        []string
+		noteType    string
+		sortBy      string
+		sortDesc    bool
+		limit       int
+		offset      int
+		fields      []string
+		outputJSON  bool
+		countOnly   bool
+		detailed    bool
+		format      string
+		tmplText    string
+		contextSize int
+		buildIndex  bool
+		regex       bool
+		explain     bool
+		recency     float64
+		halfLife    time.Duration
+		after       string
+		before      string
+		since       string
+		until       string
 	)
 
 	cmd := &cobra.Command{
@@ -46,13 +57,34 @@ Examples:
   
   # Search with date range
   kbvault search "meeting" --after 2024-01-01 --before 2024-12-31
+
+  # Notes modified this year, most recently changed first
+  kbvault search --since 2024-01-01 --sort modified --desc
   
   # Search in specific fields
   kbvault search "TODO" --field content
   
   # JSON output with pagination
   kbvault search "api" --json --limit 10 --offset 20
+
+  # One JSON object per line, written as soon as each result is scored
+  kbvault search "api" --format jsonl --sort none | jq -r .Note.ID
+
+  # Custom output with a Go template
+  kbvault search "api" --format template --template '{{.ID}} {{printf "%.2f" .Score}} {{.Title}}'
+
+  # Number of matching notes, ignoring --limit and --offset
+  kbvault search "api" --count-only
   
+  # Show how each result's score was computed
+  kbvault search "golang testing" --explain
+
+  # Prefer notes updated in the last week or so
+  kbvault search "release" --recency-boost 1 --recency-half-life 168h
+
+  # Regular expression search with line numbers
+  kbvault search --regex "TODO\(\w+\)" --field content
+
   # Build/rebuild search index
   kbvault search --build-index`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -74,21 +106,57 @@ Examples:
 				}
 			}()
 
+			tracer, stopTracing := startTracing(cmd, cfg.Tracing)
+			defer stopTracing()
+			storageBackend = traceStorage(storageBackend, tracer)
+
+			if contextSize < 1 {
+				return fmt.Errorf("--context must be at least 1")
+			}
+			if recency < 0 {
+				return fmt.Errorf("--recency-boost cannot be negative")
+			}
+			if halfLife <= 0 {
+				return fmt.Errorf("--recency-half-life must be positive")
+			}
+
+			switch {
+			case outputJSON:
+				format = "json"
+			case detailed:
+				format = "detailed"
+			}
+			var tmpl *template.Template
+			if format == formatTemplate {
+				if tmpl, err = parseOutputTemplate(tmplText); err != nil {
+					return err
+				}
+			}
+
 			// Create search engine
-			searchOpts := search.DefaultOptions()
+			searchOpts := searchOptions()
 			searchOpts.MaxResults = limit
-			engine := search.New(storageBackend, searchOpts)
-
-			ctx := context.Background()
+			if format == "detailed" {
+				searchOpts.Snippet = search.TerminalSnippetOptions()
+			}
+			searchOpts.ContextSize = contextSize
+			searchOpts.Snippet.WindowSize = contextSize
+			searchOpts.RecencyBoost = recency
+			searchOpts.RecencyHalfLife = halfLife
+			ctx := cmd.Context()
 
 			// Handle index building
 			if buildIndex {
+				engine := search.New(storageBackend, searchOpts)
 				if _, err := fmt.Fprintln(cmd.OutOrStdout(), "Building search index..."); err != nil {
 					return fmt.Errorf("failed to write output: %w", err)
 				}
 				if err := engine.BuildIndex(ctx); err != nil {
 					return fmt.Errorf("failed to build index: %w", err)
 				}
+				if err := engine.SaveIndex(ctx); err != nil {
+					return err
+				}
 				if _, err := fmt.Fprintln(cmd.OutOrStdout(), "Search index built successfully"); err != nil {
 					return fmt.Errorf("failed to write output: %w", err)
 				}
@@ -105,34 +173,40 @@ Examples:
 				SortDesc: sortDesc,
 				Limit:    limit,
 				Offset:   offset,
+				Regex:    regex,
+				Explain:  explain,
 			}
 
-			// Parse date range if provided
-			if after != "" || before != "" {
-				dateRange := &search.DateRange{}
+			// Parse date ranges if provided
+			if query.DateRange, err = parseDateRange("after", after, "before", before); err != nil {
+				return err
+			}
+			if query.ModifiedRange, err = parseDateRange("since", since, "until", until); err != nil {
+				return err
+			}
+			if query.ModifiedRange != nil && !query.ModifiedRange.Before.IsZero() {
+				// --until includes the whole day
+				query.ModifiedRange.Before = query.ModifiedRange.Before.Add(24*time.Hour - time.Nanosecond)
+			}
 
-				if after != "" {
-					t, err := time.Parse("2006-01-02", after)
-					if err != nil {
-						return fmt.Errorf("invalid after date: %w", err)
-					}
-					dateRange.After = t
-				}
+			// Load the saved index, catching up with changes made outside
+			// kbvault, or build it on first use
+			engine, err := openSearchEngine(ctx, storageBackend, searchOpts)
+			if err != nil {
+				return err
+			}
 
-				if before != "" {
-					t, err := time.Parse("2006-01-02", before)
-					if err != nil {
-						return fmt.Errorf("invalid before date: %w", err)
-					}
-					dateRange.Before = t
+			if countOnly {
+				count, err := engine.Count(ctx, query)
+				if err != nil {
+					return fmt.Errorf("search failed: %w", err)
 				}
-
-				query.DateRange = dateRange
+				return outputCount(cmd.OutOrStdout(), count, format)
 			}
 
-			// First, build the index (in production, this would be cached)
-			if err := engine.BuildIndex(ctx); err != nil {
-				return fmt.Errorf("failed to build index: %w", err)
+			// Results are written as the engine produces them
+			if format == formatJSONLines {
+				return streamSearchJSONLines(ctx, cmd.OutOrStdout(), engine, query)
 			}
 
 			// Perform search
@@ -142,36 +216,73 @@ Examples:
 			}
 
 			// Output results
-			if outputJSON {
-				return outputSearchJSON(cmd.OutOrStdout(), results)
-			}
-
-			if detailed {
-				return outputSearchDetailed(cmd.OutOrStdout(), results)
+			out := cmd.OutOrStdout()
+			switch format {
+			case "json":
+				return outputSearchJSON(out, results)
+			case "detailed":
+				return outputSearchDetailed(out, results, newOutputStyle(out))
+			case formatTemplate:
+				return outputSearchTemplate(out, results, tmpl)
+			default:
+				return outputSearchList(out, results, newOutputStyle(out))
 			}
-
-			return outputSearchList(cmd.OutOrStdout(), results)
 		},
 	}
 
 	// Add flags
 	cmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "Filter by tags (AND operation)")
 	cmd.Flags().StringVar(&noteType, "type", "", "Filter by note type")
-	cmd.Flags().StringVar(&sortBy, "sort", "relevance", "Sort results by: relevance, created, updated, title")
+	cmd.Flags().StringVar(&sortBy, "sort", "relevance", "Sort results by: relevance, created, modified, title, none")
 	cmd.Flags().BoolVar(&sortDesc, "desc", false, "Sort in descending order")
 	cmd.Flags().IntVar(&limit, "limit", 50, "Maximum number of results")
 	cmd.Flags().IntVar(&offset, "offset", 0, "Number of results to skip")
 	cmd.Flags().StringSliceVarP(&fields, "field", "f", nil, "Fields to search in: title, content, tags, all")
-	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output results as JSON")
-	cmd.Flags().BoolVar(&detailed, "detailed", false, "Show detailed results with snippets")
+	cmd.Flags().StringVar(&format, "format", "default", "Output format (default, detailed, json, jsonl, template)")
+	cmd.Flags().StringVar(&tmplText, "template", "", "Go template rendered for each result with --format template")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output results as JSON (same as --format json)")
+	cmd.Flags().BoolVar(&countOnly, "count-only", false, "Print only the number of matching notes")
+	cmd.Flags().BoolVar(&detailed, "detailed", false, "Show detailed results with snippets (same as --format detailed)")
+	cmd.Flags().IntVar(&contextSize, "context", search.DefaultContextSize, "Characters of surrounding text shown on either side of a match")
+	cmd.Flags().BoolVar(&regex, "regex", false, "Treat the query as a regular expression matched against note text")
+	cmd.Flags().BoolVar(&explain, "explain", false, "Show how each result's score was computed")
+	cmd.Flags().Float64Var(&recency, "recency-boost", 0, "Rank recently updated notes higher: a note updated now scores up to 1+N times as much (0 disables)")
+	cmd.Flags().DurationVar(&halfLife, "recency-half-life", search.DefaultRecencyHalfLife, "Age at which the recency boost has halved")
 	cmd.Flags().BoolVar(&buildIndex, "build-index", false, "Build or rebuild the search index")
 	cmd.Flags().StringVar(&after, "after", "", "Only show notes created after this date (YYYY-MM-DD)")
 	cmd.Flags().StringVar(&before, "before", "", "Only show notes created before this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&since, "since", "", "Only show notes modified on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&until, "until", "", "Only show notes modified on or before this date (YYYY-MM-DD)")
 
 	return cmd
 }
 
-func outputSearchList(w io.Writer, results []search.SearchResult) error {
+// parseDateRange builds a date range from the values of two date flags,
+// returning nil when neither is set. Dates are YYYY-MM-DD in UTC.
+func parseDateRange(afterFlag, afterValue, beforeFlag, beforeValue string) (*search.DateRange, error) {
+	if afterValue == "" && beforeValue == "" {
+		return nil, nil
+	}
+
+	dateRange := &search.DateRange{}
+	if afterValue != "" {
+		t, err := time.Parse("2006-01-02", afterValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s date: %w", afterFlag, err)
+		}
+		dateRange.After = t
+	}
+	if beforeValue != "" {
+		t, err := time.Parse("2006-01-02", beforeValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s date: %w", beforeFlag, err)
+		}
+		dateRange.Before = t
+	}
+	return dateRange, nil
+}
+
+func outputSearchList(w io.Writer, results []search.SearchResult, style outputStyle) error {
 	if len(results) == 0 {
 		if _, err := fmt.Fprintln(w, "No results found"); err != nil {
 			return fmt.Errorf("failed to write output: %w", err)
@@ -184,7 +295,7 @@ func outputSearchList(w io.Writer, results []search.SearchResult) error {
 	}
 
 	for i, result := range results {
-		if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, result.Note.Title); err != nil {
+		if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, style.bold(result.Note.Title)); err != nil {
 			return fmt.Errorf("failed to write output: %w", err)
 		}
 		if _, err := fmt.Fprintf(w, "   ID: %s\n", result.Note.ID); err != nil {
@@ -200,9 +311,21 @@ func outputSearchList(w io.Writer, results []search.SearchResult) error {
 		if _, err := fmt.Fprintf(w, "   Score: %.2f\n", result.Score); err != nil {
 			return fmt.Errorf("failed to write output: %w", err)
 		}
+		if err := writeExplanation(w, "     ", result.Explanation); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
 		if _, err := fmt.Fprintf(w, "   Updated: %s\n", result.Note.UpdatedAt.Format("2006-01-02 15:04")); err != nil {
 			return fmt.Errorf("failed to write output: %w", err)
 		}
+		for _, match := range result.Matches {
+			// Only regex matches carry line numbers
+			if match.Line == 0 {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "   %s:%d: %s\n", match.Field, match.Line, match.Context); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
 		if _, err := fmt.Fprintln(w); err != nil {
 			return fmt.Errorf("failed to write output: %w", err)
 		}
@@ -211,7 +334,7 @@ func outputSearchList(w io.Writer, results []search.SearchResult) error {
 	return nil
 }
 
-func outputSearchDetailed(w io.Writer, results []search.SearchResult) error {
+func outputSearchDetailed(w io.Writer, results []search.SearchResult, style outputStyle) error {
 	if len(results) == 0 {
 		_, err := fmt.Fprintln(w, "No results found")
 		return err
@@ -222,7 +345,7 @@ func outputSearchDetailed(w io.Writer, results []search.SearchResult) error {
 	}
 
 	for i, result := range results {
-		if _, err := fmt.Fprintf(w, "=== Result %d ===\n", i+1); err != nil {
+		if _, err := fmt.Fprintln(w, style.bold(fmt.Sprintf("=== Result %d ===", i+1))); err != nil {
 			return err
 		}
 		if _, err := fmt.Fprintf(w, "Title: %s\n", result.Note.Title); err != nil {
@@ -247,6 +370,9 @@ func outputSearchDetailed(w io.Writer, results []search.SearchResult) error {
 		if _, err := fmt.Fprintf(w, "Score: %.2f\n", result.Score); err != nil {
 			return err
 		}
+		if err := writeExplanation(w, "  ", result.Explanation); err != nil {
+			return err
+		}
 		if _, err := fmt.Fprintf(w, "Created: %s\n", result.Note.CreatedAt.Format("2006-01-02 15:04:05")); err != nil {
 			return err
 		}
@@ -265,7 +391,11 @@ func outputSearchDetailed(w io.Writer, results []search.SearchResult) error {
 				return err
 			}
 			for _, match := range result.Matches {
-				if _, err := fmt.Fprintf(w, "  - %s: %s\n", match.Field, match.Context); err != nil {
+				location := match.Field
+				if match.Line > 0 {
+					location = fmt.Sprintf("%s:%d", match.Field, match.Line)
+				}
+				if _, err := fmt.Fprintf(w, "  - %s: %s\n", location, match.Context); err != nil {
 					return err
 				}
 			}
@@ -279,6 +409,37 @@ func outputSearchDetailed(w io.Writer, results []search.SearchResult) error {
 	return nil
 }
 
+// writeExplanation writes a score breakdown, one aligned line per
+// contribution with each line prefixed by indent, followed by the total.
+// Nothing is written for a nil explanation.
+func writeExplanation(w io.Writer, indent string, explanation *search.Explanation) error {
+	if explanation == nil {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range explanation.Contributions {
+		var line string
+		switch c.Match {
+		case search.MatchAll:
+			line = fmt.Sprintf("%s(no query terms)\t\t%s\t\t= %.2f", indent, c.Match, c.Score)
+		case search.MatchRecency:
+			line = fmt.Sprintf("%s(recently updated)\t\t%s\t× %.2f\t= %.2f", indent, c.Match, c.Weight, c.Score)
+		case search.MatchFuzzy:
+			line = fmt.Sprintf("%s%s\t%q\t%s\t0.5 × %.2f\t= %.2f", indent, c.Field, c.Term, c.Match, c.Weight, c.Score)
+		default:
+			line = fmt.Sprintf("%s%s\t%q\t%s\t%d × %.2f\t= %.2f", indent, c.Field, c.Term, c.Match, c.Hits, c.Weight, c.Score)
+		}
+		if _, err := fmt.Fprintln(tw, line); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(tw, "%stotal\t\t\t\t= %.2f\n", indent, explanation.Score); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
 func outputSearchJSON(w io.Writer, results []search.SearchResult) error {
 	output := struct {
 		Count   int                   `json:"count"`
@@ -292,3 +453,20 @@ func outputSearchJSON(w io.Writer, results []search.SearchResult) error {
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(output)
 }
+
+// outputCount writes a count of notes on its own, or as {"count": N} for
+// the json and jsonl formats
+func outputCount(w io.Writer, count int, format string) error {
+	if format == "json" || format == formatJSONLines {
+		encoder := json.NewEncoder(w)
+		if format == "json" {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(struct {
+			Count int `json:"count"`
+		}{Count: count})
+	}
+
+	_, err := fmt.Fprintln(w, count)
+	return err
+}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
 	"github.com/stretchr/testify/assert"
@@ -173,7 +175,7 @@ Action items: review code, update documentation.`,
 				"=== Result",
 				"Title:",
 				"Snippet:",
-				"goroutines",
+				"**goroutines**",
 			},
 		},
 		{
@@ -268,6 +270,16 @@ func TestSearchCommand_DateRange(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid before date",
 		},
+		{
+			name: "valid modified range",
+			args: []string{"search", "--since", "2024-01-01", "--until", "2024-12-31", "--sort", "modified"},
+		},
+		{
+			name:    "invalid since date",
+			args:    []string{"search", "--since", "yesterday"},
+			wantErr: true,
+			errMsg:  "invalid since date",
+		},
 	}
 
 	for _, tt := range tests {
@@ -423,3 +435,288 @@ func TestSearchCommand_Pagination(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 3, result.Count)
 }
+
+func TestSearchCommand_Regex(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "tasks.md"),
+		[]byte("# Tasks\n\nTODO(alice): ship it\ndone\nTODO(bob): test it\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "other.md"),
+		[]byte("# Other\n\nno tasks\n"), 0644))
+
+	out := runSearchIndexCmd(t, newSearchCmd(), "", "--regex", `TODO\(\w+\)`, "--field", "content")
+	assert.Contains(t, out, "Found 1 results")
+	assert.Contains(t, out, "1. Tasks")
+	assert.Contains(t, out, "content:3: TODO(alice): ship it")
+	assert.Contains(t, out, "content:5: TODO(bob): test it")
+
+	cmd := newSearchCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--regex", "TODO("})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid regular expression")
+}
+
+func TestSearchCommand_Context(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "cafe.md"),
+		[]byte("# Café\n\nle café crème TODO: goûter les pâtisseries\n"), 0644))
+
+	out := runSearchIndexCmd(t, newSearchCmd(), "", "--regex", "TODO", "--field", "content", "--context", "3")
+	assert.Contains(t, out, "content:3: ...me TODO: g...")
+	assert.True(t, utf8.ValidString(out))
+
+	cmd := newSearchCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"café", "--context", "0"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--context must be at least 1")
+}
+
+func TestSearchCommand_FrontmatterDates(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	notes := map[string]string{
+		"old.md":    "---\ntitle: Old\ncreated: 2023-02-01T09:00:00Z\nupdated: 2024-06-15T12:00:00Z\n---\n\nreport\n",
+		"new.md":    "---\ntitle: New\ncreated: 2024-03-01\nupdated: 2024-03-02 08:30\n---\n\nreport\n",
+		"recent.md": "---\ntitle: Recent\ncreated: 2024-07-01T00:00:00Z\n---\n\nreport\n",
+	}
+	for name, content := range notes {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", name), []byte(content), 0644))
+	}
+
+	out := runSearchIndexCmd(t, newSearchCmd(), "", "report", "--after", "2024-01-01")
+	assert.Contains(t, out, "Found 2 results")
+	assert.NotContains(t, out, "Old")
+
+	// A note without an updated date was last modified when it was created
+	out = runSearchIndexCmd(t, newSearchCmd(), "", "report", "--since", "2024-06-01", "--until", "2024-06-15")
+	assert.Contains(t, out, "Found 1 results")
+	assert.Contains(t, out, "1. Old")
+
+	out = runSearchIndexCmd(t, newSearchCmd(), "", "report", "--sort", "modified", "--desc")
+	recentPos := strings.Index(out, "Recent")
+	oldPos := strings.Index(out, "Old")
+	newPos := strings.Index(out, "New")
+	assert.True(t, recentPos < oldPos && oldPos < newPos, "expected Recent, Old, New:\n%s", out)
+}
+
+func TestSearchCommand_CountOnly(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	for i := 0; i < 12; i++ {
+		content := fmt.Sprintf("# Note %d\n\nA note about keyword %d\n", i, i)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", fmt.Sprintf("note-%02d.md", i)), []byte(content), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "other.md"), []byte("# Other\n\nunrelated\n"), 0644))
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := newSearchCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		require.NoError(t, cmd.Execute())
+		return out.String()
+	}
+
+	// The count matches the results of a normal search, ignoring the limit
+	var result struct {
+		Count   int               `json:"count"`
+		Results []json.RawMessage `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(run("keyword", "--json", "--limit", "100")), &result))
+	require.Len(t, result.Results, 12)
+
+	assert.Equal(t, "12\n", run("keyword", "--count-only", "--limit", "5"))
+	assert.Equal(t, "1\n", run("--regex", "unrel.ted", "--count-only"))
+	assert.Equal(t, "0\n", run("missing", "--count-only"))
+
+	var counted map[string]int
+	require.NoError(t, json.Unmarshal([]byte(run("keyword", "--count-only", "--json")), &counted))
+	assert.Equal(t, map[string]int{"count": 12}, counted)
+}
+
+func TestSearchCommand_Template(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	notes := map[string]string{
+		"alpha.md": "---\nid: alpha\ntitle: Alpha\ntags: [go, cli]\n---\n\nkeyword here\n",
+		"beta.md":  "---\nid: beta\ntitle: Beta\ntags: []\n---\n\nkeyword there\n",
+	}
+	for name, content := range notes {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", name), []byte(content), 0644))
+	}
+
+	out := runSearchIndexCmd(t, newSearchCmd(), "", "keyword", "--sort", "title",
+		"--format", "template", "--template", `{{.ID}}|{{.Title}}|{{join .Tags ","}}|{{if gt .Score 0.0}}scored{{end}}`)
+	assert.Equal(t, "alpha|Alpha|go,cli|scored\nbeta|Beta||scored\n", out)
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"keyword", "--format", "template"}, "--format template requires --template"},
+		{[]string{"keyword", "--format", "template", "--template", "{{.ID"}, "invalid --template"},
+		{[]string{"keyword", "--format", "template", "--template", "{{.Missing}}"}, "failed to render --template"},
+	} {
+		cmd := newSearchCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(tt.args)
+		err := cmd.Execute()
+		require.Error(t, err, tt.args)
+		assert.Contains(t, err.Error(), tt.want)
+	}
+}
+
+func TestSearchCommand_JSONLines(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	for i := 0; i < 8; i++ {
+		content := fmt.Sprintf("---\nid: note-%02d\ntitle: Note %d\n---\n\nA note about keyword %d\n", i, i, i)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", fmt.Sprintf("note-%02d.md", i)), []byte(content), 0644))
+	}
+
+	run := func(args ...string) []search.SearchResult {
+		t.Helper()
+		cmd := newSearchCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		require.NoError(t, cmd.Execute())
+
+		var results []search.SearchResult
+		for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			var result search.SearchResult
+			require.NoError(t, json.Unmarshal([]byte(line), &result), "line %q", line)
+			results = append(results, result)
+		}
+		return results
+	}
+
+	results := run("keyword", "--format", "jsonl", "--sort", "title")
+	require.Len(t, results, 8)
+	for i, result := range results {
+		assert.Equal(t, fmt.Sprintf("note-%02d", i), result.Note.ID)
+		assert.Greater(t, result.Score, 0.0)
+	}
+
+	// Unsorted results are streamed, and the limit still applies
+	assert.Len(t, run("keyword", "--format", "jsonl", "--sort", "none"), 8)
+	assert.Len(t, run("keyword", "--format", "jsonl", "--sort", "none", "--limit", "3"), 3)
+	assert.Empty(t, run("missing", "--format", "jsonl"))
+}
+
+func TestSearchCommand_MaxLimit(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	currentConfig.VectorSearch.Search.MaxLimit = 3
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	for i := 0; i < 5; i++ {
+		content := fmt.Sprintf("---\nid: note-%02d\ntitle: Note %d\n---\n\nA note about keyword %d\n", i, i, i)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", fmt.Sprintf("note-%02d.md", i)), []byte(content), 0644))
+	}
+
+	// An over-limit --limit is clamped to the configured maximum
+	var result struct {
+		Results []search.SearchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(runSearchIndexCmd(t, newSearchCmd(), "", "keyword", "--json", "--limit", "1000000")), &result))
+	assert.Len(t, result.Results, 3)
+
+	lines := strings.Split(strings.TrimSpace(runSearchIndexCmd(t, newSearchCmd(), "", "keyword", "--format", "jsonl", "--sort", "none", "--limit", "1000000")), "\n")
+	assert.Len(t, lines, 3)
+}
+
+func TestSearchCommand_Explain(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	notes := map[string]string{
+		"go.md":     "---\nid: go\ntitle: Go testing\ntags: [golang]\n---\n\nHow go tests run.\n",
+		"python.md": "---\nid: python\ntitle: Python\ntags: [python]\n---\n\nPython tests.\n",
+	}
+	for name, content := range notes {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", name), []byte(content), 0644))
+	}
+
+	out := runSearchIndexCmd(t, newSearchCmd(), "", "go tests", "--explain", "--sort", "title")
+	for _, want := range []string{
+		`title    "go"     exact  1 × 2.00    = 2.00`,
+		`title    "tests"  fuzzy  0.5 × 2.00  = 1.00`,
+		`content  "tests"  exact  1 × 1.00    = 1.00`,
+		"Score: 6.50",
+		"total                                = 6.50",
+	} {
+		assert.Contains(t, out, want)
+	}
+
+	var result struct {
+		Results []search.SearchResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(runSearchIndexCmd(t, newSearchCmd(), "", "go tests", "--explain", "--json")), &result))
+	require.NotEmpty(t, result.Results)
+	for _, r := range result.Results {
+		require.NotNil(t, r.Explanation, "note %s", r.Note.ID)
+		var sum float64
+		for _, c := range r.Explanation.Contributions {
+			sum += c.Score
+		}
+		assert.InDelta(t, r.Score, sum, 1e-9, "note %s", r.Note.ID)
+	}
+
+	// Without --explain no breakdown is shown
+	assert.NotContains(t, runSearchIndexCmd(t, newSearchCmd(), "", "go tests", "--detailed"), "total")
+}
+
+func TestSearchCommand_RecencyBoost(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	recent := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	notes := map[string]string{
+		"aaa-old.md": "---\nid: aaa-old\ntitle: Release\nupdated: 2020-01-01\n---\n\nrelease steps\n",
+		"zzz-new.md": "---\nid: zzz-new\ntitle: Release\nupdated: " + recent + "\n---\n\nrelease steps\n",
+	}
+	for name, content := range notes {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", name), []byte(content), 0644))
+	}
+
+	ids := func(args ...string) []string {
+		t.Helper()
+		var result struct {
+			Results []search.SearchResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(runSearchIndexCmd(t, newSearchCmd(), "", append([]string{"release", "--json"}, args...)...)), &result))
+		var ids []string
+		for _, r := range result.Results {
+			ids = append(ids, r.Note.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"zzz-new", "aaa-old"}, ids("--recency-boost", "1"))
+	assert.Equal(t, []string{"zzz-new", "aaa-old"}, ids("--recency-boost", "0.5", "--recency-half-life", "24h"))
+
+	out := runSearchIndexCmd(t, newSearchCmd(), "", "release", "--recency-boost", "1", "--explain", "--detailed")
+	assert.Contains(t, out, "(recently updated)")
+
+	for _, args := range [][]string{
+		{"release", "--recency-boost", "-1"},
+		{"release", "--recency-half-life", "0s"},
+	} {
+		cmd := newSearchCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		assert.Error(t, cmd.Execute(), "args %v", args)
+	}
+}
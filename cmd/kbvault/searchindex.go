@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// searchOptions returns the search engine options for the current
+// configuration
+func searchOptions() search.Options {
+	opts := search.DefaultOptions()
+	opts.TitleSources = noteParseOptions().TitleSources
+	opts.Dirs = noteDirs()
+	opts.Ignore = ignorePatterns()
+	if cfg := getConfig(); cfg != nil {
+		opts.InlineTags = cfg.Vault.InlineTags
+		opts.MaxLimit = cfg.VectorSearch.Search.MaxLimit
+	}
+	return opts
+}
+
+// openSearchEngine returns a search engine over the saved index, refreshed
+// against storage. When there is no usable saved index a full one is built.
+// The index is saved again whenever it changed.
+func openSearchEngine(ctx context.Context, storage types.StorageBackend, opts search.Options) (*search.Engine, error) {
+	engine := search.New(storage, opts)
+
+	loaded, err := engine.LoadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := true
+	if loaded {
+		if changed, err = engine.RefreshIndex(ctx); err != nil {
+			return nil, fmt.Errorf("failed to refresh index: %w", err)
+		}
+	} else if err := engine.BuildIndex(ctx); err != nil {
+		return nil, fmt.Errorf("failed to build index: %w", err)
+	}
+
+	if changed {
+		if err := engine.SaveIndex(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// updateSearchIndex applies a note write or deletion to the saved search
+// index. Vaults without a saved index are left alone; the next search
+// builds one.
+func updateSearchIndex(ctx context.Context, storage types.StorageBackend, path string, deleted bool) error {
+	engine := search.New(storage, searchOptions())
+
+	loaded, err := engine.LoadIndex(ctx)
+	if err != nil || !loaded {
+		return err
+	}
+
+	if deleted {
+		if err := engine.RemoveFile(ctx, path); err != nil {
+			return err
+		}
+	} else if err := engine.IndexFile(ctx, path); err != nil {
+		return err
+	}
+
+	return engine.SaveIndex(ctx)
+}
+
+// syncSearchIndex is updateSearchIndex for commands: the note change has
+// already succeeded, so a failure is reported as a warning on w rather
+// than an error
+func syncSearchIndex(ctx context.Context, w io.Writer, storage types.StorageBackend, path string, deleted bool) {
+	if err := updateSearchIndex(ctx, storage, path, deleted); err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: failed to update search index: %v\n", err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+)
+
+func setupSearchIndexTestConfig(t *testing.T) string {
+	t.Helper()
+
+	return setupTestVault(t, nil)
+}
+
+func runSearchIndexCmd(t *testing.T, cmd *cobra.Command, stdin string, args ...string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	require.NoError(t, cmd.Execute())
+	return out.String()
+}
+
+// savedIndexDocs returns the documents in the saved search index by path
+func savedIndexDocs(t *testing.T, dir string) map[string]search.IndexedDocument {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, search.IndexPath))
+	require.NoError(t, err)
+
+	var saved struct {
+		Documents []search.IndexedDocument `json:"documents"`
+	}
+	require.NoError(t, json.Unmarshal(data, &saved))
+
+	docs := make(map[string]search.IndexedDocument)
+	for _, doc := range saved.Documents {
+		docs[doc.FilePath] = doc
+	}
+	return docs
+}
+
+func TestSearchIndex_UpdatedByNewEditDelete(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+
+	out := runSearchIndexCmd(t, newSearchCmd(), "", "--build-index")
+	assert.Contains(t, out, "Search index built successfully")
+	assert.Empty(t, savedIndexDocs(t, dir))
+
+	runSearchIndexCmd(t, newNewCmd(), "Alpha body.\n", "Alpha Note", "--stdin")
+	files, err := filepath.Glob(filepath.Join(dir, "notes", "*.md"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	path := "notes/" + filepath.Base(files[0])
+	id := strings.TrimSuffix(filepath.Base(path), ".md")
+
+	docs := savedIndexDocs(t, dir)
+	require.Contains(t, docs, path)
+	assert.Equal(t, "Alpha Note", docs[path].Title)
+	assert.Contains(t, docs[path].Content, "Alpha body.")
+
+	runSearchIndexCmd(t, newEditCmd(), "Beta body.\n", id, "--stdin")
+	docs = savedIndexDocs(t, dir)
+	require.Contains(t, docs, path)
+	assert.Contains(t, docs[path].Content, "Beta body.")
+	assert.NotContains(t, docs[path].Content, "Alpha body.")
+
+	runSearchIndexCmd(t, newDeleteCmd(), "", id, "--force")
+	assert.Empty(t, savedIndexDocs(t, dir))
+}
+
+func TestSearchIndex_NotCreatedByNew(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+
+	runSearchIndexCmd(t, newNewCmd(), "Body.\n", "Unindexed", "--stdin")
+
+	_, err := os.Stat(filepath.Join(dir, search.IndexPath))
+	assert.True(t, os.IsNotExist(err), "new should not create a search index")
+}
+
+func TestOpenSearchEngine_RefreshesSavedIndex(t *testing.T) {
+	dir := setupSearchIndexTestConfig(t)
+	ctx := context.Background()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "a.md"), []byte("# A\n\nkeep\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "b.md"), []byte("# B\n\ngone\n"), 0644))
+
+	// First open builds and saves the index
+	backend, err := storage.CreateStorage(currentConfig.Storage)
+	require.NoError(t, err)
+	defer func() { _ = backend.Close() }()
+	_, err = openSearchEngine(ctx, backend, searchOptions())
+	require.NoError(t, err)
+	assert.Len(t, savedIndexDocs(t, dir), 2)
+
+	// Changes made outside kbvault are picked up on the next open
+	require.NoError(t, os.Remove(filepath.Join(dir, "notes", "b.md")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "c.md"), []byte("# C\n\nadded\n"), 0644))
+
+	engine, err := openSearchEngine(ctx, backend, searchOptions())
+	require.NoError(t, err)
+
+	docs := savedIndexDocs(t, dir)
+	assert.Contains(t, docs, "notes/a.md")
+	assert.Contains(t, docs, "notes/c.md")
+	assert.NotContains(t, docs, "notes/b.md")
+
+	results, err := engine.Search(ctx, search.SearchQuery{Query: "added"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "C", results[0].Note.Title)
+}
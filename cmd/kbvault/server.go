@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/server"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// defaultServerStopWait is how long kbvault server stop waits for the
+// server to exit
+const defaultServerStopWait = 10 * time.Second
+
+func newServerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Check on and stop the MCP server",
+		Long: `Manage the MCP server listening on mcp.socket_path.
+
+A running server records its process ID in a PID file next to the socket,
+with the socket's extension replaced by .pid (/tmp/kbvault.pid for the
+default /tmp/kbvault.sock), and removes both when it exits. A server that
+crashed leaves them behind; the next server to start removes them.`,
+	}
+
+	cmd.AddCommand(newServerStatusCmd())
+	cmd.AddCommand(newServerStopCmd())
+
+	return cmd
+}
+
+func newServerStatusCmd() *cobra.Command {
+	var outputJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the MCP server is running",
+		Long: `Report whether an MCP server is running, from its PID file and by
+connecting to its socket. Files left behind by a server that crashed are
+reported as stale.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			socketPath, err := serverSocketPath(getConfig())
+			if err != nil {
+				return err
+			}
+
+			status := server.CheckStatus(socketPath)
+			if outputJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(status)
+			}
+			return outputServerStatus(cmd.OutOrStdout(), status)
+		},
+	}
+
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output status as JSON")
+
+	return cmd
+}
+
+func newServerStopCmd() *cobra.Command {
+	var wait time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the MCP server gracefully",
+		Long: `Ask the MCP server named in the PID file to shut down, with SIGTERM,
+and wait up to --wait for it to exit.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			socketPath, err := serverSocketPath(getConfig())
+			if err != nil {
+				return err
+			}
+			if wait <= 0 {
+				return fmt.Errorf("--wait must be positive")
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), wait)
+			defer cancel()
+
+			pid, err := server.Stop(ctx, socketPath)
+			if errors.Is(err, server.ErrNotRunning) {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "No server is running (%v)\n", err)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Stopped server (PID %d)\n", pid)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&wait, "wait", defaultServerStopWait, "How long to wait for the server to exit")
+
+	return cmd
+}
+
+// serverSocketPath returns the configured MCP socket path
+func serverSocketPath(cfg *types.Config) (string, error) {
+	if cfg == nil {
+		return "", fmt.Errorf("configuration not initialized")
+	}
+	if cfg.MCP.SocketPath == "" {
+		return "", fmt.Errorf("mcp.socket_path is not set")
+	}
+	return cfg.MCP.SocketPath, nil
+}
+
+func outputServerStatus(w io.Writer, status server.Status) error {
+	var b strings.Builder
+
+	state := "not running"
+	if status.Running && status.PID != 0 && !status.StalePIDFile {
+		state = fmt.Sprintf("running (PID %d)", status.PID)
+	} else if status.Running {
+		state = "running"
+	}
+
+	socket := status.Socket
+	switch {
+	case status.Listening:
+		socket += " (listening)"
+	case status.StaleSocket:
+		socket += " (stale)"
+	}
+
+	pidFile := status.PIDFile
+	if status.StalePIDFile {
+		pidFile += " (stale)"
+	}
+
+	fmt.Fprintf(&b, "Server:    %s\n", state)
+	fmt.Fprintf(&b, "Socket:    %s\n", socket)
+	fmt.Fprintf(&b, "PID file:  %s\n", pidFile)
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
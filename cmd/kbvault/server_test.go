@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/server"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// setupServerConfig points mcp.socket_path into a short temporary
+// directory and returns the socket path
+func setupServerConfig(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "kbv")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	cfg := useTestConfig(t, func(c *types.Config) {
+		c.MCP.SocketPath = filepath.Join(dir, "kbvault.sock")
+	})
+	return cfg.MCP.SocketPath
+}
+
+// runServerCmd runs kbvault server with args
+func runServerCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newServerCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestServerStatusCmd(t *testing.T) {
+	socketPath := setupServerConfig(t)
+
+	out, err := runServerCmd(t, "status")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Server:    not running\n")
+	assert.Contains(t, out, "Socket:    "+socketPath+"\n")
+
+	l, err := server.Start(socketPath)
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	defer func() { _ = listener.Close() }()
+
+	out, err = runServerCmd(t, "status")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Server:    running (PID "+strconv.Itoa(os.Getpid())+")\n")
+	assert.Contains(t, out, "(listening)")
+
+	out, err = runServerCmd(t, "status", "--json")
+	require.NoError(t, err)
+	var status server.Status
+	require.NoError(t, json.Unmarshal([]byte(out), &status))
+	assert.True(t, status.Running)
+	assert.Equal(t, os.Getpid(), status.PID)
+
+	// After a crash both files are stale
+	require.NoError(t, listener.Close())
+	stale := exec.Command("true")
+	require.NoError(t, stale.Run())
+	require.NoError(t, os.WriteFile(l.PIDPath(), []byte(strconv.Itoa(stale.Process.Pid)), 0o644))
+
+	out, err = runServerCmd(t, "status")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Server:    not running\n")
+	assert.Contains(t, out, socketPath+" (stale)")
+	assert.Contains(t, out, l.PIDPath()+" (stale)")
+}
+
+func TestServerStopCmd(t *testing.T) {
+	socketPath := setupServerConfig(t)
+
+	out, err := runServerCmd(t, "stop")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No server is running")
+
+	process := exec.Command("sleep", "30")
+	require.NoError(t, process.Start())
+	go func() { _ = process.Wait() }()
+	t.Cleanup(func() { _ = process.Process.Kill() })
+	require.NoError(t, os.WriteFile(server.PIDPath(socketPath), []byte(strconv.Itoa(process.Process.Pid)), 0o644))
+
+	out, err = runServerCmd(t, "stop", "--wait", "5s")
+	require.NoError(t, err)
+	assert.Equal(t, "Stopped server (PID "+strconv.Itoa(process.Process.Pid)+")\n", out)
+
+	currentConfig.MCP.SocketPath = ""
+	_, err = runServerCmd(t, "status")
+	assert.ErrorContains(t, err, "mcp.socket_path is not set")
+}
@@ -2,14 +2,17 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
-	"github.com/madstone-tech/mdstn-kb-mcp/pkg/ulid"
 )
 
 func newShowCmd() *cobra.Command {
@@ -17,19 +20,35 @@ func newShowCmd() *cobra.Command {
 		showMetadata bool
 		showContent  bool
 		format       string
+		raw          bool
+		render       bool
+		stats        bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "show <note-id>",
 		Short: "Display note content",
-		Long: `Display the content of a note by its ULID identifier.
-By default, shows both metadata and content.`,
-		Args: cobra.ExactArgs(1),
+		Long: `Display the content of a note by its ID.
+By default, shows both metadata and content.
+
+Use --raw to print the note exactly as stored, frontmatter included, or
+--render to print the body with terminal styling for headings, emphasis
+and code. Styling follows the tui.enable_colors and tui.theme settings
+and is skipped when output is not a terminal.
+
+Use --stats to print the note's word and character counts and estimated
+reading time instead of its content. Fenced code blocks are not counted;
+links count their text but not their URL.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeNoteIDs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
 			noteID := args[0]
 
-			// Validate ULID
-			if !ulid.IsValid(noteID) {
+			// Note IDs follow vault.id_scheme, so only reject IDs that
+			// could address a file outside the vault
+			if noteID == "" || noteID == "." || noteID == ".." || strings.ContainsAny(noteID, `/\`) {
 				return fmt.Errorf("invalid note ID: %s", noteID)
 			}
 
@@ -51,12 +70,27 @@ By default, shows both metadata and content.`,
 				}
 			}()
 
+			if raw || render {
+				_, data, err := findNoteData(ctx, storage, noteID)
+				if err != nil {
+					return fmt.Errorf("failed to load note: %w", err)
+				}
+				if raw {
+					return writeOutput(cmd.OutOrStdout(), data)
+				}
+				return renderNote(cmd.OutOrStdout(), data, config.TUI)
+			}
+
 			// Find and load note
-			note, err := findAndLoadNote(storage, noteID)
+			note, err := findAndLoadNote(ctx, storage, noteID)
 			if err != nil {
 				return fmt.Errorf("failed to load note: %w", err)
 			}
 
+			if stats {
+				return displayNoteStats(cmd.OutOrStdout(), note, format)
+			}
+
 			// Display note based on format and options
 			switch format {
 			case "json":
@@ -72,50 +106,110 @@ By default, shows both metadata and content.`,
 	cmd.Flags().BoolVarP(&showMetadata, "metadata", "m", true, "Show note metadata")
 	cmd.Flags().BoolVarP(&showContent, "content", "c", true, "Show note content")
 	cmd.Flags().StringVarP(&format, "format", "f", "default", "Output format (default, markdown, json)")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Print the stored note exactly, including frontmatter")
+	cmd.Flags().BoolVar(&render, "render", false, "Print the note body with terminal markdown styling")
+	cmd.Flags().BoolVar(&stats, "stats", false, "Print word count, character count and reading time instead of the note")
+	cmd.MarkFlagsMutuallyExclusive("raw", "render", "stats")
 
 	return cmd
 }
 
-func findAndLoadNote(storageBackend types.StorageBackend, noteID string) (*types.Note, error) {
-	// Try common file extensions and patterns
-	possiblePaths := []string{
-		noteID + ".md",
-		noteID,
-		"notes/" + noteID + ".md",
-		"daily/" + noteID + ".md",
+func findAndLoadNote(ctx context.Context, storageBackend types.StorageBackend, noteID string) (*types.Note, error) {
+	path, data, err := findNoteData(ctx, storageBackend, noteID)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, path := range possiblePaths {
+	// Parse the note
+	return parseNoteFromData(path, data), nil
+}
+
+// findNoteData locates a note by ID and returns its path and stored bytes
+func findNoteData(ctx context.Context, storageBackend types.StorageBackend, noteID string) (string, []byte, error) {
+	for _, path := range notePathCandidates(noteID) {
 		// Try to read the note
-		data, err := storageBackend.Read(context.TODO(), path)
+		data, err := storageBackend.Read(ctx, path)
 		if err == nil {
-			// Parse the note
-			return parseNoteFromData(noteID, path, data), nil
+			return path, data, nil
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
 
-	return nil, fmt.Errorf("note not found: %s", noteID)
+	return "", nil, fmt.Errorf("note not found: %s", noteID)
 }
 
-func parseNoteFromData(noteID string, path string, data []byte) *types.Note {
-	content := string(data)
-	note := &types.Note{
-		ID:       noteID,
-		FilePath: path,
-		Content:  content,
+// renderNote writes the note body without frontmatter, styled for the
+// terminal when colors are enabled and allowed and w is a terminal
+func renderNote(w io.Writer, data []byte, tui types.TUIConfig) error {
+	body := note.StripFrontmatter(string(data))
+	rendered := note.RenderMarkdown(body, note.RenderOptions{
+		Color: tui.EnableColors && colorEnabled(w),
+		Theme: tui.Theme,
+	})
+
+	if !strings.HasSuffix(rendered, "\n") {
+		rendered += "\n"
 	}
+	return writeOutput(w, []byte(rendered))
+}
 
-	// Parse frontmatter and content
-	title, noteContent := parseFrontmatterAndContent(content)
-	note.Title = title
-	note.Content = noteContent
+// writeOutput writes data to w, wrapping any error
+func writeOutput(w io.Writer, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// isTerminal reports whether w is an interactive terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func parseNoteFromData(path string, data []byte) *types.Note {
+	return note.ParseWithOptions(path, data, noteParseOptions())
+}
+
+// displayNoteStats writes the length statistics of a note body, as JSON
+// with the json format
+func displayNoteStats(w io.Writer, n *types.Note, format string) error {
+	stats := note.Stats(n.Content)
 
-	// Fallback to ID if title is empty
-	if note.Title == "" {
-		note.Title = note.ID
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+			note.NoteStats
+		}{n.ID, n.Title, stats})
 	}
 
-	return note
+	style := newOutputStyle(w)
+	_, err := fmt.Fprintf(w, "%s%s\nWords:        %d\nCharacters:   %d\nReading time: %s\n",
+		style.icon("📝 ", ""), style.bold(n.Title), stats.Words, stats.Characters, formatReadingTime(stats.ReadingMinutes))
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// formatReadingTime renders an estimated reading time in minutes
+func formatReadingTime(minutes int) string {
+	if minutes == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d min", minutes)
 }
 
 func displayNoteDefault(note *types.Note, showMetadata, showContent bool) error {
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -104,3 +108,92 @@ func TestDisplayFunctionsWithNilNote(t *testing.T) {
 	// Note: these functions might panic with nil, which is expected behavior
 	// The defer above will catch any panics and fail the test
 }
+
+func TestRenderNote(t *testing.T) {
+	data := []byte("---\nid: 01ARZ3NDEKTSV4RRFFQ69G5FAV\ntitle: Sample\n---\n\n# Sample\n\nSome **bold** text.")
+
+	// Output that isn't a terminal is never colorized
+	var buf bytes.Buffer
+	err := renderNote(&buf, data, types.TUIConfig{EnableColors: true, Theme: "default"})
+	if err != nil {
+		t.Fatalf("renderNote() error = %v", err)
+	}
+
+	expected := "# Sample\n\nSome **bold** text.\n"
+	if buf.String() != expected {
+		t.Errorf("renderNote() = %q, want %q", buf.String(), expected)
+	}
+	if isTerminal(&buf) {
+		t.Error("isTerminal() should be false for a buffer")
+	}
+}
+
+func TestFindNoteData(t *testing.T) {
+	backend, err := storage.CreateStorage(types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	raw := []byte("---\ntitle: Raw\n---\n\nBody\n")
+	if err := backend.Write(context.Background(), "notes/01ARZ3NDEKTSV4RRFFQ69G5FAV.md", raw); err != nil {
+		t.Fatal(err)
+	}
+
+	path, data, err := findNoteData(context.Background(), backend, "01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	if err != nil {
+		t.Fatalf("findNoteData() error = %v", err)
+	}
+	if path != "notes/01ARZ3NDEKTSV4RRFFQ69G5FAV.md" {
+		t.Errorf("findNoteData() path = %s", path)
+	}
+	if !bytes.Equal(data, raw) {
+		t.Errorf("findNoteData() data = %q, want %q", data, raw)
+	}
+
+	if _, _, err := findNoteData(context.Background(), backend, "01BX5ZZKBKACTAV9WEVGEMMVRZ"); err == nil {
+		t.Error("findNoteData() expected error for missing note")
+	}
+}
+
+func TestShowCmd_Stats(t *testing.T) {
+	backend := setupListVault(t, 0)
+	body := "# Plan\n\nShip the [release notes](https://example.com/notes) today.\n\n```sh\nmake release\n```\n"
+	if err := backend.Write(context.Background(), "notes/plan.md", []byte("---\ntitle: Plan\n---\n\n"+body)); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := newShowCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("show %v: %v", args, err)
+		}
+		return out.String()
+	}
+
+	want := "Plan\nWords:        6\nCharacters:   29\nReading time: 1 min\n"
+	if out := run("plan", "--stats"); out != want {
+		t.Errorf("show --stats = %q, want %q", out, want)
+	}
+
+	var stats struct {
+		ID             string `json:"id"`
+		Words          int    `json:"words"`
+		Characters     int    `json:"characters"`
+		ReadingMinutes int    `json:"reading_minutes"`
+	}
+	if err := json.Unmarshal([]byte(run("plan", "--stats", "--format", "json")), &stats); err != nil {
+		t.Fatalf("show --stats --format json is not JSON: %v", err)
+	}
+	if stats.ID != "plan" || stats.Words != 6 || stats.Characters != 29 || stats.ReadingMinutes != 1 {
+		t.Errorf("show --stats --format json = %+v", stats)
+	}
+}
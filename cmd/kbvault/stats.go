@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/links"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// vaultStats summarizes the contents of a vault
+type vaultStats struct {
+	TotalNotes       int            `json:"total_notes"`
+	TotalSize        int64          `json:"total_size"`
+	NotesByDirectory map[string]int `json:"notes_by_directory"`
+	TopTags          []tagCount     `json:"top_tags"`
+	UniqueTags       int            `json:"unique_tags"`
+	AvgLength        int            `json:"avg_length"`
+	Oldest           *noteRef       `json:"oldest,omitempty"`
+	Newest           *noteRef       `json:"newest,omitempty"`
+	TotalLinks       int            `json:"total_links"`
+	OrphanNotes      []noteRef      `json:"orphan_notes"`
+}
+
+// tagCount is the number of notes carrying a tag
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// noteRef identifies a note in stats output
+type noteRef struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newStatsCmd() *cobra.Command {
+	var (
+		outputJSON bool
+		topTags    int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show an overview of the vault",
+		Long: `Summarize the vault: note counts and sizes, notes per directory,
+the most used tags, average note length, the oldest and newest notes,
+and orphan notes that neither link nor are linked to.
+
+Examples:
+  # Formatted report
+  kbvault stats
+
+  # Machine-readable output with the top 20 tags
+  kbvault stats --json --top 20`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			notes, err := listAllNotes(ctx, storageBackend)
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
+			}
+
+			stats, err := computeVaultStats(ctx, notes, topTags)
+			if err != nil {
+				return fmt.Errorf("failed to compute stats: %w", err)
+			}
+
+			if outputJSON {
+				return outputStatsJSON(cmd.OutOrStdout(), stats)
+			}
+			return outputStatsReport(cmd.OutOrStdout(), stats)
+		},
+	}
+
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output stats as JSON")
+	cmd.Flags().IntVar(&topTags, "top", 10, "Number of top tags to show (0 = all)")
+
+	return cmd
+}
+
+// computeVaultStats aggregates statistics over a set of parsed notes.
+// Sizes come from the notes' Stat metadata rather than their content.
+func computeVaultStats(ctx context.Context, notes []*types.Note, topTags int) (*vaultStats, error) {
+	stats := &vaultStats{
+		TotalNotes:       len(notes),
+		NotesByDirectory: make(map[string]int),
+		TopTags:          []tagCount{},
+		OrphanNotes:      []noteRef{},
+	}
+
+	tagCounts := make(map[string]int)
+	totalLength := 0
+
+	for _, note := range notes {
+		stats.TotalSize += note.Size
+		stats.NotesByDirectory[noteDirectory(note.FilePath)]++
+		totalLength += utf8.RuneCountInString(note.Content)
+
+		for _, tag := range note.Frontmatter.Tags {
+			tagCounts[strings.ToLower(tag)]++
+		}
+
+		ref := newNoteRef(note)
+		if stats.Oldest == nil || note.CreatedAt.Before(stats.Oldest.CreatedAt) {
+			stats.Oldest = &ref
+		}
+		if stats.Newest == nil || note.CreatedAt.After(stats.Newest.CreatedAt) {
+			stats.Newest = &ref
+		}
+	}
+
+	if len(notes) > 0 {
+		stats.AvgLength = totalLength / len(notes)
+	}
+
+	// Rank tags by usage, breaking ties alphabetically
+	stats.UniqueTags = len(tagCounts)
+	for tag, count := range tagCounts {
+		stats.TopTags = append(stats.TopTags, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(stats.TopTags, func(i, j int) bool {
+		if stats.TopTags[i].Count != stats.TopTags[j].Count {
+			return stats.TopTags[i].Count > stats.TopTags[j].Count
+		}
+		return stats.TopTags[i].Tag < stats.TopTags[j].Tag
+	})
+	if topTags > 0 && len(stats.TopTags) > topTags {
+		stats.TopTags = stats.TopTags[:topTags]
+	}
+
+	// Find orphans using the resolved link graph
+	graph, err := buildLinkGraph(ctx, notes)
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalLinks = graph.GetStatistics().TotalLinks
+
+	byID := make(map[string]*types.Note, len(notes))
+	for _, note := range notes {
+		byID[note.ID] = note
+	}
+	for _, id := range graph.GetOrphanNotes() {
+		if note, ok := byID[id]; ok {
+			stats.OrphanNotes = append(stats.OrphanNotes, newNoteRef(note))
+		}
+	}
+
+	return stats, nil
+}
+
+// buildLinkGraph builds a graph containing only links that resolve to notes in the set
+func buildLinkGraph(ctx context.Context, notes []*types.Note) (*links.Graph, error) {
+	parser := links.New(newVaultNoteResolver(notes))
+	graph := links.NewGraph()
+
+	for _, note := range notes {
+		metadata := note.ToMetadata()
+		graph.AddNote(&metadata)
+	}
+
+	for _, note := range notes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		noteLinks, err := parser.ParseLinks(note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse links for note %s: %w", note.ID, err)
+		}
+
+		for _, link := range noteLinks {
+			if link.IsValid && link.TargetID != "" {
+				graph.AddLink(link)
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// vaultNoteResolver resolves link targets against an in-memory set of notes
+type vaultNoteResolver struct {
+	byID    map[string]*types.Note
+	byTitle map[string]*types.Note
+	byPath  map[string]*types.Note
+}
+
+// newVaultNoteResolver indexes notes by ID, title and path
+func newVaultNoteResolver(notes []*types.Note) *vaultNoteResolver {
+	r := &vaultNoteResolver{
+		byID:    make(map[string]*types.Note),
+		byTitle: make(map[string]*types.Note),
+		byPath:  make(map[string]*types.Note),
+	}
+
+	for _, note := range notes {
+		r.byID[note.ID] = note
+		r.byTitle[strings.ToLower(note.Title)] = note
+		r.byPath[filepath.ToSlash(note.FilePath)] = note
+	}
+
+	return r
+}
+
+// ResolveByTitle finds a note by its title (case-insensitive)
+func (r *vaultNoteResolver) ResolveByTitle(title string) (*types.Note, error) {
+	if note, ok := r.byTitle[strings.ToLower(title)]; ok {
+		return note, nil
+	}
+	return nil, types.NewNoteNotFoundError(title)
+}
+
+// ResolveByID finds a note by its ID
+func (r *vaultNoteResolver) ResolveByID(id string) (*types.Note, error) {
+	if note, ok := r.byID[strings.TrimSuffix(id, ".md")]; ok {
+		return note, nil
+	}
+	return nil, types.NewNoteNotFoundError(id)
+}
+
+// ResolveByPath finds a note by its file path
+func (r *vaultNoteResolver) ResolveByPath(path string) (*types.Note, error) {
+	if note, ok := r.byPath[strings.TrimPrefix(filepath.ToSlash(path), "./")]; ok {
+		return note, nil
+	}
+	return nil, types.NewNoteNotFoundError(path)
+}
+
+// noteDirectory returns the directory a note lives in for grouping
+func noteDirectory(filePath string) string {
+	dir := filepath.ToSlash(filepath.Dir(filePath))
+	if dir == "." || dir == "" {
+		return "/"
+	}
+	return dir + "/"
+}
+
+func newNoteRef(note *types.Note) noteRef {
+	return noteRef{
+		ID:        note.ID,
+		Title:     note.Title,
+		CreatedAt: note.CreatedAt,
+	}
+}
+
+func outputStatsJSON(w io.Writer, stats *vaultStats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
+
+func outputStatsReport(w io.Writer, stats *vaultStats) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Vault Statistics\n")
+	fmt.Fprintf(&b, "================\n\n")
+	fmt.Fprintf(&b, "Notes:          %d\n", stats.TotalNotes)
+	fmt.Fprintf(&b, "Total size:     %s\n", formatBytes(stats.TotalSize))
+	fmt.Fprintf(&b, "Average length: %d characters\n", stats.AvgLength)
+	fmt.Fprintf(&b, "Links:          %d\n", stats.TotalLinks)
+
+	if stats.Oldest != nil {
+		fmt.Fprintf(&b, "Oldest note:    %s (%s, %s)\n", stats.Oldest.Title, stats.Oldest.ID, stats.Oldest.CreatedAt.Format("2006-01-02"))
+	}
+	if stats.Newest != nil {
+		fmt.Fprintf(&b, "Newest note:    %s (%s, %s)\n", stats.Newest.Title, stats.Newest.ID, stats.Newest.CreatedAt.Format("2006-01-02"))
+	}
+
+	if len(stats.NotesByDirectory) > 0 {
+		fmt.Fprintf(&b, "\nNotes by directory:\n")
+		dirs := make([]string, 0, len(stats.NotesByDirectory))
+		for dir := range stats.NotesByDirectory {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		for _, dir := range dirs {
+			fmt.Fprintf(&b, "  %-20s %d\n", dir, stats.NotesByDirectory[dir])
+		}
+	}
+
+	if len(stats.TopTags) > 0 {
+		fmt.Fprintf(&b, "\nTop tags (%d unique):\n", stats.UniqueTags)
+		for _, tc := range stats.TopTags {
+			fmt.Fprintf(&b, "  %-20s %d\n", tc.Tag, tc.Count)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nOrphan notes: %d\n", len(stats.OrphanNotes))
+	for _, orphan := range stats.OrphanNotes {
+		fmt.Fprintf(&b, "  - %s (%s)\n", orphan.Title, orphan.ID)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// formatBytes renders a byte count in human-readable units
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func newStatsTestNotes() []*types.Note {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return []*types.Note{
+		{
+			ID:          "alpha",
+			Title:       "Alpha",
+			FilePath:    "notes/alpha.md",
+			Content:     "Links to [[Beta]].",
+			Size:        100,
+			CreatedAt:   base,
+			Frontmatter: types.Frontmatter{Tags: []string{"go", "work"}},
+		},
+		{
+			ID:          "beta",
+			Title:       "Beta",
+			FilePath:    "notes/beta.md",
+			Content:     "No links.",
+			Size:        200,
+			CreatedAt:   base.Add(48 * time.Hour),
+			Frontmatter: types.Frontmatter{Tags: []string{"go"}},
+		},
+		{
+			ID:          "2024-01-05",
+			Title:       "Daily",
+			FilePath:    "daily/2024-01-05.md",
+			Content:     "Broken [[Missing Note]] link.",
+			Size:        50,
+			CreatedAt:   base.Add(96 * time.Hour),
+			Frontmatter: types.Frontmatter{Tags: []string{"Daily"}},
+		},
+	}
+}
+
+func TestComputeVaultStats(t *testing.T) {
+	stats, err := computeVaultStats(context.Background(), newStatsTestNotes(), 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, stats.TotalNotes)
+	assert.Equal(t, int64(350), stats.TotalSize)
+	assert.Equal(t, map[string]int{"notes/": 2, "daily/": 1}, stats.NotesByDirectory)
+
+	// Tags are counted case-insensitively and limited to the top N
+	assert.Equal(t, 3, stats.UniqueTags)
+	assert.Equal(t, []tagCount{{Tag: "go", Count: 2}, {Tag: "daily", Count: 1}}, stats.TopTags)
+
+	require.NotNil(t, stats.Oldest)
+	require.NotNil(t, stats.Newest)
+	assert.Equal(t, "alpha", stats.Oldest.ID)
+	assert.Equal(t, "2024-01-05", stats.Newest.ID)
+
+	// Broken links don't count, so only the daily note is an orphan
+	assert.Equal(t, 1, stats.TotalLinks)
+	require.Len(t, stats.OrphanNotes, 1)
+	assert.Equal(t, "2024-01-05", stats.OrphanNotes[0].ID)
+}
+
+func TestComputeVaultStats_Empty(t *testing.T) {
+	stats, err := computeVaultStats(context.Background(), nil, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, stats.TotalNotes)
+	assert.Equal(t, 0, stats.AvgLength)
+	assert.Nil(t, stats.Oldest)
+	assert.Empty(t, stats.TopTags)
+	assert.Empty(t, stats.OrphanNotes)
+}
+
+func TestOutputStats(t *testing.T) {
+	stats, err := computeVaultStats(context.Background(), newStatsTestNotes(), 10)
+	require.NoError(t, err)
+
+	var report bytes.Buffer
+	require.NoError(t, outputStatsReport(&report, stats))
+	assert.Contains(t, report.String(), "Notes:          3")
+	assert.Contains(t, report.String(), "notes/")
+	assert.Contains(t, report.String(), "Orphan notes: 1")
+
+	var out bytes.Buffer
+	require.NoError(t, outputStatsJSON(&out, stats))
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, float64(3), decoded["total_notes"])
+	assert.Equal(t, float64(350), decoded["total_size"])
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 MiB", formatBytes(2*1024*1024))
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/templates"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func newTemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage note templates",
+		Long: `List, show, create and delete the note templates in the vault's
+templates directory (vault.templates_dir), through the active profile's
+storage. A template named "meeting" is stored as meeting.md.`,
+	}
+
+	cmd.AddCommand(newTemplateListCmd())
+	cmd.AddCommand(newTemplateShowCmd())
+	cmd.AddCommand(newTemplateCreateCmd())
+	cmd.AddCommand(newTemplateDeleteCmd())
+
+	return cmd
+}
+
+func newTemplateListCmd() *cobra.Command {
+	var outputJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List templates and the variables they can use",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withTemplateStorage(cmd, func(ctx context.Context, cfg *types.Config, backend types.StorageBackend) error {
+				names, err := listTemplates(ctx, backend, cfg.Vault.TemplatesDir)
+				if err != nil {
+					return err
+				}
+
+				out := cmd.OutOrStdout()
+				if outputJSON {
+					encoder := json.NewEncoder(out)
+					encoder.SetIndent("", "  ")
+					return encoder.Encode(struct {
+						Templates []string             `json:"templates"`
+						Variables []templates.Variable `json:"variables"`
+						Functions []string             `json:"functions"`
+					}{names, templates.Variables, templates.Functions()})
+				}
+				return outputTemplateList(out, cfg.Vault.TemplatesDir, names)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+func newTemplateShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <name>",
+		Short: "Print a template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withTemplateStorage(cmd, func(ctx context.Context, cfg *types.Config, backend types.StorageBackend) error {
+				templatePath, err := templateFilePath(cfg, args[0])
+				if err != nil {
+					return err
+				}
+				data, err := readTemplate(ctx, backend, templatePath, args[0])
+				if err != nil {
+					return err
+				}
+				return writeOutput(cmd.OutOrStdout(), data)
+			})
+		},
+	}
+}
+
+func newTemplateCreateCmd() *cobra.Command {
+	var (
+		force       bool
+		contentFile string
+		fromStdin   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a template in your editor",
+		Long: `Create a template and open it in $EDITOR. It starts from the built-in
+template of the same name (default, daily, meeting or book), or from the
+default one. Content can be supplied with --content-file or --stdin
+instead, in which case no editor is opened.
+
+The template is checked for syntax errors before it is saved; run
+kbvault template list to see the variables and functions it can use.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimSuffix(args[0], ".md")
+
+			var content *string
+			if fromStdin || contentFile != "" {
+				data, err := readReplacementContent(cmd.InOrStdin(), contentFile)
+				if err != nil {
+					return err
+				}
+				content = &data
+			}
+
+			return withTemplateStorage(cmd, func(ctx context.Context, cfg *types.Config, backend types.StorageBackend) error {
+				templatePath, err := templateFilePath(cfg, name)
+				if err != nil {
+					return err
+				}
+				exists, err := backend.Exists(ctx, templatePath)
+				if err != nil {
+					return fmt.Errorf("failed to check template: %w", err)
+				}
+				if exists && !force {
+					return fmt.Errorf("template %s already exists at %s (use --force to replace it)", name, templatePath)
+				}
+
+				if content == nil {
+					edited, err := editNewTemplate(name)
+					if err != nil {
+						return err
+					}
+					content = &edited
+				}
+				if strings.TrimSpace(*content) == "" {
+					return fmt.Errorf("template %s is empty, nothing saved", name)
+				}
+				if err := templates.Parse(name, *content); err != nil {
+					return err
+				}
+
+				if err := backend.Write(ctx, templatePath, []byte(*content)); err != nil {
+					return fmt.Errorf("failed to save template: %w", err)
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created template %s at %s\n", name, templatePath)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Replace an existing template")
+	cmd.Flags().StringVar(&contentFile, "content-file", "", "Use the contents of a file as the template")
+	cmd.Flags().BoolVar(&fromStdin, "stdin", false, "Read the template from stdin")
+	cmd.MarkFlagsMutuallyExclusive("stdin", "content-file")
+
+	return cmd
+}
+
+func newTemplateDeleteCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			return withTemplateStorage(cmd, func(ctx context.Context, cfg *types.Config, backend types.StorageBackend) error {
+				templatePath, err := templateFilePath(cfg, name)
+				if err != nil {
+					return err
+				}
+				exists, err := backend.Exists(ctx, templatePath)
+				if err != nil {
+					return fmt.Errorf("failed to check template: %w", err)
+				}
+				if !exists {
+					return fmt.Errorf("template %s not found at %s", name, templatePath)
+				}
+
+				out := cmd.OutOrStdout()
+				if !force {
+					_, _ = fmt.Fprintf(out, "Delete template %s (%s)? (y/N): ", name, templatePath)
+					scanner := bufio.NewScanner(cmd.InOrStdin())
+					scanner.Scan()
+					answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+					if answer != "y" && answer != "yes" {
+						_, _ = fmt.Fprintln(out, "Deletion cancelled.")
+						return nil
+					}
+				}
+
+				if err := backend.Delete(ctx, templatePath); err != nil {
+					return fmt.Errorf("failed to delete template: %w", err)
+				}
+				_, _ = fmt.Fprintf(out, "Deleted template %s\n", name)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Delete without confirmation")
+
+	return cmd
+}
+
+// withTemplateStorage runs fn with the active configuration and its
+// storage backend, which is closed afterwards
+func withTemplateStorage(cmd *cobra.Command, fn func(context.Context, *types.Config, types.StorageBackend) error) error {
+	cfg := getConfig()
+	if cfg == nil {
+		return fmt.Errorf("configuration not initialized")
+	}
+
+	backend, err := storage.CreateStorage(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer func() {
+		if closeErr := backend.Close(); closeErr != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+		}
+	}()
+
+	return fn(cmd.Context(), cfg, backend)
+}
+
+// templateFilePath returns the storage path of the template called name.
+// Names are file names without the .md extension, so they can't contain
+// path separators.
+func templateFilePath(cfg *types.Config, name string) (string, error) {
+	name = strings.TrimSuffix(name, ".md")
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+	return path.Join(cfg.Vault.TemplatesDir, name+".md"), nil
+}
+
+// listTemplates returns the names of the templates in dir, sorted
+func listTemplates(ctx context.Context, backend types.StorageBackend, dir string) ([]string, error) {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	paths, err := backend.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	names := []string{}
+	for _, p := range paths {
+		rel := strings.TrimPrefix(filepath.ToSlash(p), prefix)
+		// Only templates directly in the directory can be named
+		if strings.Contains(rel, "/") || !strings.HasSuffix(rel, ".md") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(rel, ".md"))
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// readTemplate reads the template at templatePath
+func readTemplate(ctx context.Context, backend types.StorageBackend, templatePath, name string) ([]byte, error) {
+	exists, err := backend.Exists(ctx, templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check template: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("template %s not found at %s", name, templatePath)
+	}
+	data, err := backend.Read(ctx, templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return data, nil
+}
+
+// editNewTemplate opens a new template in the editor, starting from the
+// built-in template of the same name or the default one, and returns what
+// was saved. The temporary file is kept if the template can't be parsed.
+func editNewTemplate(name string) (string, error) {
+	initial, ok := templates.Builtin(name)
+	if !ok {
+		initial, _ = templates.Builtin("default")
+	}
+
+	tempFile := filepath.Join(os.TempDir(), "kbvault-template-"+name+".md")
+	if err := os.WriteFile(tempFile, []byte(initial), 0644); err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	keepTempFile := false
+	defer func() {
+		if !keepTempFile {
+			_ = os.Remove(tempFile)
+		}
+	}()
+
+	if err := openInEditorWithOverride(tempFile, "", ""); err != nil {
+		return "", fmt.Errorf("failed to open editor: %w", err)
+	}
+	data, err := os.ReadFile(tempFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	if err := templates.Parse(name, string(data)); err != nil {
+		keepTempFile = true
+		return "", fmt.Errorf("%w (your edits were kept in %s)", err, tempFile)
+	}
+	return string(data), nil
+}
+
+func outputTemplateList(w io.Writer, dir string, names []string) error {
+	var b strings.Builder
+
+	if len(names) == 0 {
+		fmt.Fprintf(&b, "No templates in %s/\n", dir)
+	} else {
+		fmt.Fprintf(&b, "Templates in %s/:\n", dir)
+		for _, name := range names {
+			fmt.Fprintf(&b, "  %s\n", name)
+		}
+	}
+
+	b.WriteString("\nVariables:\n")
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, v := range templates.Variables {
+		_, _ = fmt.Fprintf(tw, "  {{%s}}\t%s\n", v.Name, v.Description)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(&b, "\nFunctions: %s\n", strings.Join(templates.Functions(), ", "))
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/templates"
+)
+
+// setupTemplateVault points the configuration at a local vault seeded with
+// templates and returns its directory
+func setupTemplateVault(t *testing.T) string {
+	t.Helper()
+
+	return setupTestVault(t, map[string]string{
+		"templates/meeting.md":     "# Meeting: {{.Title}}\n",
+		"templates/daily.md":       "# {{.Date}}\n",
+		"templates/README.txt":     "not a template",
+		"templates/archive/old.md": "# Old\n",
+	})
+}
+
+// runTemplateCmd runs kbvault template with args and stdin
+func runTemplateCmd(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newTemplateCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestTemplateListCmd(t *testing.T) {
+	setupTemplateVault(t)
+
+	out, err := runTemplateCmd(t, "", "list")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "Templates in templates/:\n  daily\n  meeting\n\nVariables:\n"), out)
+	assert.Contains(t, out, "{{.Title}}")
+	assert.Contains(t, out, "Note title")
+	assert.Contains(t, out, "{{.Custom}}")
+	assert.Contains(t, out, "Functions: add, date, join,")
+	assert.NotContains(t, out, "old")
+
+	out, err = runTemplateCmd(t, "", "list", "--json")
+	require.NoError(t, err)
+	var listed struct {
+		Templates []string             `json:"templates"`
+		Variables []templates.Variable `json:"variables"`
+		Functions []string             `json:"functions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &listed))
+	assert.Equal(t, []string{"daily", "meeting"}, listed.Templates)
+	assert.Equal(t, templates.Variables, listed.Variables)
+	assert.Equal(t, templates.Functions(), listed.Functions)
+}
+
+func TestTemplateListCmd_Empty(t *testing.T) {
+	useTestConfig(t)
+
+	out, err := runTemplateCmd(t, "", "list")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "No templates in templates/\n"), out)
+}
+
+func TestTemplateShowCmd(t *testing.T) {
+	setupTemplateVault(t)
+
+	out, err := runTemplateCmd(t, "", "show", "meeting")
+	require.NoError(t, err)
+	assert.Equal(t, "# Meeting: {{.Title}}\n", out)
+
+	out, err = runTemplateCmd(t, "", "show", "daily.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# {{.Date}}\n", out)
+
+	_, err = runTemplateCmd(t, "", "show", "book")
+	assert.ErrorContains(t, err, "template book not found at templates/book.md")
+
+	_, err = runTemplateCmd(t, "", "show", "../notes/readme")
+	assert.ErrorContains(t, err, "invalid template name")
+}
+
+func TestTemplateCreateCmd(t *testing.T) {
+	dir := setupTemplateVault(t)
+
+	out, err := runTemplateCmd(t, "# Book: {{.Title}}\n", "create", "book", "--stdin")
+	require.NoError(t, err)
+	assert.Equal(t, "Created template book at templates/book.md\n", out)
+	data, err := os.ReadFile(filepath.Join(dir, "templates", "book.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Book: {{.Title}}\n", string(data))
+
+	_, err = runTemplateCmd(t, "# {{.Title}}\n", "create", "meeting", "--stdin")
+	assert.ErrorContains(t, err, "already exists")
+
+	_, err = runTemplateCmd(t, "# {{.Title\n", "create", "broken", "--stdin")
+	assert.ErrorContains(t, err, "failed to parse template")
+	assert.NoFileExists(t, filepath.Join(dir, "templates", "broken.md"))
+
+	_, err = runTemplateCmd(t, "# {{.Title}} v2\n", "create", "meeting", "--stdin", "--force")
+	require.NoError(t, err)
+	data, err = os.ReadFile(filepath.Join(dir, "templates", "meeting.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# {{.Title}} v2\n", string(data))
+}
+
+func TestTemplateDeleteCmd(t *testing.T) {
+	dir := setupTemplateVault(t)
+
+	out, err := runTemplateCmd(t, "n\n", "delete", "meeting")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Deletion cancelled.")
+	assert.FileExists(t, filepath.Join(dir, "templates", "meeting.md"))
+
+	out, err = runTemplateCmd(t, "y\n", "delete", "meeting")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Deleted template meeting\n")
+	assert.NoFileExists(t, filepath.Join(dir, "templates", "meeting.md"))
+
+	_, err = runTemplateCmd(t, "", "delete", "meeting", "--force")
+	assert.ErrorContains(t, err, "template meeting not found")
+}
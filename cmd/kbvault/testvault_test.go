@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// useTestConfig makes a default configuration, with a local vault in a new
+// temporary directory, the current one until the test ends. overrides
+// adjust it in order, e.g. to switch to S3 storage. It returns the
+// configuration for tests that adjust it further.
+func useTestConfig(t *testing.T, overrides ...func(*types.Config)) *types.Config {
+	t.Helper()
+
+	originalConfig := currentConfig
+	t.Cleanup(func() { currentConfig = originalConfig })
+
+	currentConfig = types.DefaultConfig()
+	currentConfig.Storage.Local.Path = t.TempDir()
+	for _, override := range overrides {
+		override(currentConfig)
+	}
+	return currentConfig
+}
+
+// setupTestVault is useTestConfig for a local vault holding files, keyed by
+// path below the vault root and written as is. It returns the vault root.
+func setupTestVault(t *testing.T, files map[string]string, overrides ...func(*types.Config)) string {
+	t.Helper()
+
+	root := useTestConfig(t, overrides...).Storage.Local.Path
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+// mustStorage opens the storage of the current configuration
+func mustStorage(t *testing.T) types.StorageBackend {
+	t.Helper()
+
+	backend, err := storage.CreateStorage(currentConfig.Storage)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+	return backend
+}
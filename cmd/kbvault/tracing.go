@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// startTracing returns the tracer for config, nil when tracing is not
+// configured, and a function sending the remaining spans that warns on
+// cmd's stderr when the collector could not be reached
+func startTracing(cmd *cobra.Command, config types.TracingConfig) (*trace.Tracer, func()) {
+	tracer, closeTracer := trace.NewTracerFromConfig(config)
+	return tracer, func() {
+		if err := closeTracer(); err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to export traces: %v\n", err)
+		}
+	}
+}
+
+// traceStorage wraps backend so that its calls record spans with tracer,
+// or returns backend as is when tracer is nil
+func traceStorage(backend types.StorageBackend, tracer *trace.Tracer) types.StorageBackend {
+	if tracer == nil {
+		return backend
+	}
+	return trace.NewStorageWrapper(backend, tracer)
+}
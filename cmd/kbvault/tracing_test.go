@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestTraceStorage(t *testing.T) {
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir()})
+	require.NoError(t, err)
+
+	// Without tracing configured storage is used as is
+	tracer, stop := startTracing(&cobra.Command{}, types.TracingConfig{})
+	assert.Nil(t, tracer)
+	stop()
+	assert.Same(t, backend, traceStorage(backend, tracer))
+
+	var (
+		mu     sync.Mutex
+		bodies []map[string]any
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	tracer, stop = startTracing(cmd, types.TracingConfig{OTLPEndpoint: collector.URL})
+	require.NotNil(t, tracer)
+
+	traced := traceStorage(backend, tracer)
+	ctx := trace.WithRequestID(context.Background(), "cli-run")
+	_, err = traced.Exists(ctx, "notes/a.md")
+	require.NoError(t, err)
+
+	stop()
+	assert.Empty(t, stderr.String())
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], "resourceSpans")
+}
+
+func TestStartTracing_ExportFailure(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer collector.Close()
+
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	tracer, stop := startTracing(cmd, types.TracingConfig{OTLPEndpoint: collector.URL})
+	_, span := tracer.Start(context.Background(), "search")
+	span.Finish(nil)
+	stop()
+
+	assert.Contains(t, stderr.String(), "Warning: failed to export traces")
+}
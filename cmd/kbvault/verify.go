@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// checksumMismatch is a note whose body no longer matches its checksum
+type checksumMismatch struct {
+	Path     string
+	Recorded string
+	Actual   string
+}
+
+// verifyReport is the result of checking note checksums
+type verifyReport struct {
+	Checked    int
+	Verified   int
+	Unchecked  int
+	Mismatched []checksumMismatch
+}
+
+func newVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check notes against their recorded checksums",
+		Long: `Recompute the checksum of every note that records one in its frontmatter
+and report notes whose body no longer matches, because the file was
+corrupted or changed outside kbvault.
+
+Checksums are written when vault.track_checksums is enabled. Notes without
+one are counted but not checked.
+
+The command exits with a non-zero status if any note fails verification.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			report, err := runVerify(cmd.Context(), storageBackend)
+			if err != nil {
+				return err
+			}
+			outputVerifyReport(cmd.OutOrStdout(), report)
+
+			if len(report.Mismatched) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d note(s) failed verification", len(report.Mismatched))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// runVerify compares the checksum recorded in each note with its body
+func runVerify(ctx context.Context, storage types.StorageBackend) (*verifyReport, error) {
+	paths := listNoteFiles(ctx, storage)
+	sort.Strings(paths)
+
+	report := &verifyReport{}
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, err := storage.Read(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		n := note.ParseWithOptions(path, data, noteParseOptions())
+
+		report.Checked++
+		recorded := n.Frontmatter.Checksum
+		if recorded == "" {
+			report.Unchecked++
+			continue
+		}
+		if actual := note.BodyChecksum(n.Content); actual != recorded {
+			report.Mismatched = append(report.Mismatched, checksumMismatch{Path: path, Recorded: recorded, Actual: actual})
+			continue
+		}
+		report.Verified++
+	}
+
+	return report, nil
+}
+
+func outputVerifyReport(w io.Writer, report *verifyReport) {
+	for _, m := range report.Mismatched {
+		_, _ = fmt.Fprintf(w, "MISMATCH %s\n  recorded: %s\n  actual:   %s\n", m.Path, m.Recorded, m.Actual)
+	}
+	if len(report.Mismatched) > 0 {
+		_, _ = fmt.Fprintln(w)
+	}
+	_, _ = fmt.Fprintf(w, "Checked %d notes: %d verified, %d mismatched, %d without checksum\n",
+		report.Checked, report.Verified, len(report.Mismatched), report.Unchecked)
+}
+
+// checksumsTracked reports whether the current configuration records note
+// checksums on write
+func checksumsTracked() bool {
+	cfg := getConfig()
+	return cfg != nil && cfg.Vault.TrackChecksums
+}
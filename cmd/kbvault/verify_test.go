@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// setupVerifyTestVault points the configuration at a temporary vault with
+// checksum tracking enabled
+func setupVerifyTestVault(t *testing.T) (types.StorageBackend, string) {
+	t.Helper()
+
+	dir := setupTestVault(t, nil, func(c *types.Config) { c.Vault.TrackChecksums = true })
+	return mustStorage(t), dir
+}
+
+func readChecksum(t *testing.T, backend types.StorageBackend, path string) string {
+	t.Helper()
+
+	data, err := backend.Read(context.Background(), path)
+	require.NoError(t, err)
+	return note.Parse(path, data).Frontmatter.Checksum
+}
+
+func TestChecksumTracking(t *testing.T) {
+	backend, _ := setupVerifyTestVault(t)
+	ctx := context.Background()
+
+	body := "# Tracked\n\nOriginal body.\n"
+	n, err := createNewNote(currentConfig, "Tracked", "default", "note", nil, &body)
+	require.NoError(t, err)
+	require.NoError(t, saveNote(ctx, backend, n))
+
+	created := readChecksum(t, backend, n.FilePath)
+	assert.Equal(t, note.BodyChecksum(body), created)
+
+	// Editing the body updates the checksum
+	var out bytes.Buffer
+	require.NoError(t, replaceNoteContent(context.Background(), &out, backend, n, "# Tracked\n\nEdited body.\n", false))
+	edited := readChecksum(t, backend, n.FilePath)
+	assert.NotEqual(t, created, edited)
+	assert.Equal(t, note.BodyChecksum("# Tracked\n\nEdited body.\n"), edited)
+
+	// Without tracking, new notes get no checksum
+	currentConfig.Vault.TrackChecksums = false
+	untracked, err := createNewNote(currentConfig, "Untracked", "default", "note", nil, &body)
+	require.NoError(t, err)
+	assert.Empty(t, untracked.Frontmatter.Checksum)
+}
+
+func TestVerifyCmd(t *testing.T) {
+	backend, dir := setupVerifyTestVault(t)
+	ctx := context.Background()
+
+	var paths []string
+	for _, title := range []string{"First", "Second"} {
+		body := "# " + title + "\n\nBody.\n"
+		n, err := createNewNote(currentConfig, title, "default", "note", nil, &body)
+		require.NoError(t, err)
+		require.NoError(t, saveNote(ctx, backend, n))
+		paths = append(paths, n.FilePath)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "plain.md"), []byte("# Plain\n"), 0644))
+
+	run := func() (string, error) {
+		cmd := newVerifyCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs([]string{})
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "Checked 3 notes: 2 verified, 0 mismatched, 1 without checksum")
+
+	// Change a body behind kbvault's back
+	tampered := filepath.Join(dir, paths[1])
+	data, err := os.ReadFile(tampered)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(tampered, append(data, []byte("Sneaky edit.\n")...), 0644))
+
+	out, err = run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 note(s) failed verification")
+	assert.Contains(t, out, "MISMATCH "+paths[1])
+	assert.Contains(t, out, "Checked 3 notes: 1 verified, 1 mismatched, 1 without checksum")
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector"
+)
+
+// defaultWatchDebounce is how long watch waits after the last change
+// before updating the index
+const defaultWatchDebounce = 500 * time.Millisecond
+
+// watchIndexer applies changed note files to the saved search index and,
+// when set, a vector index
+type watchIndexer struct {
+	storage types.StorageBackend
+	engine  *search.Engine
+	vector  types.VectorSearchBackend
+	out     io.Writer
+}
+
+// watchResult counts the documents changed by one batch of file events
+type watchResult struct {
+	Indexed int
+	Removed int
+	Failed  int
+}
+
+func newWatchCmd() *cobra.Command {
+	var debounce time.Duration
+	var withVector bool
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Keep the search index updated as notes change",
+		Long: `Watch the vault's note directories and update the saved search index when
+notes are created, modified or deleted, including changes made by other
+editors and tools. Search stays current without a rebuild.
+
+Changes are batched: the index is updated once no file has changed for the
+debounce interval. Temporary files written during atomic saves are ignored.
+
+With --vector, changed notes are also sent to the vector search backend
+configured under vector_search.
+
+Only local vaults can be watched; S3 and other object stores have no local
+files to watch. Press Ctrl+C to stop.`,
+		Example: `  # Watch the current vault
+  kbvault watch
+
+  # Wait two seconds after the last change before indexing
+  kbvault watch --debounce 2s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get profile-aware configuration
+			cfg := getConfig()
+			if cfg == nil {
+				return fmt.Errorf("configuration not initialized")
+			}
+			if cfg.Storage.Type != types.StorageTypeLocal {
+				return fmt.Errorf("kbvault watch only supports local storage; this vault uses %s storage, which has no local files to watch", cfg.Storage.Type)
+			}
+			if debounce <= 0 {
+				return fmt.Errorf("--debounce must be positive")
+			}
+
+			root, err := filepath.Abs(cfg.Storage.Local.Path)
+			if err != nil {
+				return fmt.Errorf("failed to resolve vault path: %w", err)
+			}
+
+			// Initialize storage backend
+			storageBackend, err := storage.CreateStorage(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			defer func() {
+				if closeErr := storageBackend.Close(); closeErr != nil {
+					// Log error but don't fail the command (ignore write errors)
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to close storage: %v\n", closeErr)
+				}
+			}()
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			out := cmd.OutOrStdout()
+			engine, err := openSearchEngine(ctx, storageBackend, searchOptions())
+			if err != nil {
+				return err
+			}
+			indexer := &watchIndexer{storage: storageBackend, engine: engine, out: out}
+
+			if withVector {
+				if !cfg.VectorSearch.Enabled {
+					return fmt.Errorf("--vector requires vector_search.enabled")
+				}
+				vectorConfig := vaultVectorConfig(cfg)
+				backend, err := vector.NewBackend(&vectorConfig)
+				if err != nil {
+					return fmt.Errorf("failed to initialize vector search: %w", err)
+				}
+				defer func() { _ = backend.Close() }()
+				indexer.vector = backend
+			}
+
+			return watchVault(ctx, root, indexer, debounce)
+		},
+	}
+
+	cmd.Flags().DurationVar(&debounce, "debounce", defaultWatchDebounce, "Wait this long after the last change before indexing")
+	cmd.Flags().BoolVar(&withVector, "vector", false, "Also update the vector search index")
+
+	return cmd
+}
+
+// watchVault watches the note directories under root until ctx is done
+func watchVault(ctx context.Context, root string, indexer *watchIndexer, debounce time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	addDir := func(dir string) error {
+		if err := watcher.Add(dir); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		return nil
+	}
+	for _, dir := range indexer.engine.NoteDirs() {
+		if err := addDir(filepath.Join(root, filepath.FromSlash(dir))); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(indexer.out, "Watching %s for changes (Ctrl+C to stop)\n", root)
+	return watchLoop(ctx, root, watcher.Events, watcher.Errors, debounce, indexer, addDir)
+}
+
+// watchLoop collects note changes from events and applies them once no
+// event has arrived for debounce. Note directories created while watching
+// are passed to addDir. Pending changes are applied before returning when
+// ctx is done.
+func watchLoop(ctx context.Context, root string, events <-chan fsnotify.Event, errs <-chan error,
+	debounce time.Duration, indexer *watchIndexer, addDir func(string) error) error {
+	dirs := indexer.engine.NoteDirs()
+	pending := make(map[string]bool)
+	var fire <-chan time.Time
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		paths := make([]string, 0, len(pending))
+		for p := range pending {
+			paths = append(paths, p)
+		}
+		pending = make(map[string]bool)
+
+		// Drop ctx's cancellation so the final flush still runs after ctx
+		// was cancelled
+		result := indexer.apply(context.WithoutCancel(ctx), paths)
+		indexer.log(result)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				flush()
+				return nil
+			}
+
+			if event.Has(fsnotify.Create) && isWatchedDir(root, event.Name, dirs) {
+				if err := addDir(event.Name); err != nil {
+					_, _ = fmt.Fprintf(indexer.out, "Warning: %v\n", err)
+				}
+				continue
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			notePath, ok := watchedNotePath(root, event.Name, dirs)
+			if !ok || indexer.engine.Ignored(notePath) {
+				continue
+			}
+			pending[notePath] = true
+			fire = time.After(debounce)
+		case err, ok := <-errs:
+			if !ok {
+				flush()
+				return nil
+			}
+			_, _ = fmt.Fprintf(indexer.out, "Warning: watch error: %v\n", err)
+		case <-fire:
+			fire = nil
+			flush()
+		}
+	}
+}
+
+// watchedNotePath converts the filesystem path of a changed file to the
+// storage path of a note in one of dirs. Temporary files from atomic
+// writes, hidden files and non-Markdown files are ignored.
+func watchedNotePath(root, name string, dirs []string) (string, bool) {
+	rel, err := filepath.Rel(root, name)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+
+	base := path.Base(rel)
+	if strings.HasPrefix(base, ".") || strings.Contains(base, ".tmp.") || !strings.HasSuffix(base, ".md") {
+		return "", false
+	}
+
+	dir := path.Dir(rel)
+	if dir == "." {
+		dir = ""
+	} else {
+		dir += "/"
+	}
+	return rel, slices.Contains(dirs, dir)
+}
+
+// isWatchedDir reports whether name is one of the note directories dirs
+// under root
+func isWatchedDir(root, name string, dirs []string) bool {
+	info, err := os.Stat(name)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	rel, err := filepath.Rel(root, name)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(dirs, filepath.ToSlash(rel)+"/")
+}
+
+// apply brings the index up to date for paths. Whether a note is indexed
+// or removed depends on whether its file exists now, not on the event that
+// reported it, so a burst of events for one file settles on its final
+// state. The index is saved when anything changed.
+func (w *watchIndexer) apply(ctx context.Context, paths []string) watchResult {
+	sort.Strings(paths)
+
+	var result watchResult
+	for _, p := range paths {
+		exists, err := w.storage.Exists(ctx, p)
+		if err != nil {
+			_, _ = fmt.Fprintf(w.out, "Warning: failed to check %s: %v\n", p, err)
+			result.Failed++
+			continue
+		}
+
+		if !exists {
+			doc, ok := w.engine.DocumentByPath(p)
+			if !ok {
+				continue
+			}
+			_ = w.engine.RemoveFile(ctx, p)
+			result.Removed++
+
+			if w.vector != nil {
+				if err := w.vector.DeleteDocument(ctx, doc.ID); err != nil {
+					_, _ = fmt.Fprintf(w.out, "Warning: failed to remove %s from vector index: %v\n", p, err)
+				}
+			}
+			continue
+		}
+
+		if err := w.engine.IndexFile(ctx, p); err != nil {
+			_, _ = fmt.Fprintf(w.out, "Warning: %v\n", err)
+			result.Failed++
+			continue
+		}
+		result.Indexed++
+
+		if w.vector != nil {
+			if doc, ok := w.engine.DocumentByPath(p); ok {
+				if err := w.vector.IndexDocument(ctx, vectorDocument(doc)); err != nil {
+					_, _ = fmt.Fprintf(w.out, "Warning: failed to add %s to vector index: %v\n", p, err)
+				}
+			}
+		}
+	}
+
+	if result.Indexed > 0 || result.Removed > 0 {
+		if err := w.engine.SaveIndex(ctx); err != nil {
+			_, _ = fmt.Fprintf(w.out, "Warning: %v\n", err)
+		}
+	}
+	return result
+}
+
+// log reports a batch of index updates
+func (w *watchIndexer) log(result watchResult) {
+	if result == (watchResult{}) {
+		return
+	}
+
+	line := fmt.Sprintf("%s indexed %d, removed %d", time.Now().Format("15:04:05"), result.Indexed, result.Removed)
+	if result.Failed > 0 {
+		line += fmt.Sprintf(", failed %d", result.Failed)
+	}
+	_, _ = fmt.Fprintln(w.out, line)
+}
+
+// vectorDocument converts an indexed note for the vector index
+func vectorDocument(doc *search.IndexedDocument) *types.Document {
+	return &types.Document{
+		ID:        doc.ID,
+		Content:   doc.Content,
+		Title:     doc.Title,
+		Path:      doc.FilePath,
+		Tags:      doc.Tags,
+		CreatedAt: doc.CreatedAt,
+		UpdatedAt: doc.UpdatedAt,
+	}
+}
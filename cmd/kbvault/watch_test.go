@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector"
+)
+
+// syncBuffer is a bytes.Buffer safe for the watch loop and the test to use
+// at once
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// recordingVector records the documents sent to a vector index
+type recordingVector struct {
+	vector.NoneBackend
+	indexed []string
+	deleted []string
+}
+
+func (r *recordingVector) IndexDocument(ctx context.Context, doc *types.Document) error {
+	r.indexed = append(r.indexed, doc.ID)
+	return nil
+}
+
+func (r *recordingVector) DeleteDocument(ctx context.Context, id string) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+// setupWatchVault returns a local vault with one indexed note, and an
+// indexer for it
+func setupWatchVault(t *testing.T) (string, *watchIndexer, *syncBuffer) {
+	t.Helper()
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "notes"), 0o755))
+	writeWatchNote(t, root, "notes/first.md", "first", "First Note", "original text")
+
+	config := types.DefaultConfig().Storage
+	config.Local.Path = root
+	backend, err := storage.CreateStorage(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	engine, err := openSearchEngine(context.Background(), backend, search.DefaultOptions())
+	require.NoError(t, err)
+
+	out := &syncBuffer{}
+	return root, &watchIndexer{storage: backend, engine: engine, out: out}, out
+}
+
+func writeWatchNote(t *testing.T, root, path, id, title, body string) {
+	t.Helper()
+	content := "---\nid: " + id + "\ntitle: " + title + "\n---\n\n" + body + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, filepath.FromSlash(path)), []byte(content), 0o644))
+}
+
+// savedSearch searches the index saved in the vault at root
+func savedSearch(t *testing.T, indexer *watchIndexer, query string) []string {
+	t.Helper()
+
+	engine := search.New(indexer.storage, search.DefaultOptions())
+	loaded, err := engine.LoadIndex(context.Background())
+	require.NoError(t, err)
+	require.True(t, loaded)
+
+	results, err := engine.Search(context.Background(), search.SearchQuery{Query: query})
+	require.NoError(t, err)
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Note.ID
+	}
+	return ids
+}
+
+func TestWatchIndexer_Apply(t *testing.T) {
+	root, indexer, _ := setupWatchVault(t)
+	vec := &recordingVector{}
+	indexer.vector = vec
+	ctx := context.Background()
+
+	// Create
+	writeWatchNote(t, root, "notes/second.md", "second", "Second Note", "kubernetes clusters")
+	result := indexer.apply(ctx, []string{"notes/second.md"})
+	assert.Equal(t, watchResult{Indexed: 1}, result)
+	assert.Equal(t, []string{"second"}, savedSearch(t, indexer, "kubernetes"))
+
+	// Modify
+	writeWatchNote(t, root, "notes/first.md", "first", "First Note", "rewritten about gardening")
+	result = indexer.apply(ctx, []string{"notes/first.md"})
+	assert.Equal(t, watchResult{Indexed: 1}, result)
+	assert.Equal(t, []string{"first"}, savedSearch(t, indexer, "gardening"))
+	assert.Empty(t, savedSearch(t, indexer, "original"))
+
+	// Delete
+	require.NoError(t, os.Remove(filepath.Join(root, "notes", "second.md")))
+	result = indexer.apply(ctx, []string{"notes/second.md"})
+	assert.Equal(t, watchResult{Removed: 1}, result)
+	assert.Empty(t, savedSearch(t, indexer, "kubernetes"))
+
+	// A file created and deleted before the batch ran changes nothing
+	result = indexer.apply(ctx, []string{"notes/never.md"})
+	assert.Equal(t, watchResult{}, result)
+
+	assert.Equal(t, []string{"second", "first"}, vec.indexed)
+	assert.Equal(t, []string{"second"}, vec.deleted)
+}
+
+func TestWatchLoop(t *testing.T) {
+	root, indexer, out := setupWatchVault(t)
+
+	events := make(chan fsnotify.Event)
+	errs := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var added []string
+	done := make(chan error, 1)
+	go func() {
+		done <- watchLoop(ctx, root, events, errs, 50*time.Millisecond, indexer, func(dir string) error {
+			added = append(added, dir)
+			return nil
+		})
+	}()
+
+	// An atomic write: a temp file, then a rename onto the note
+	writeWatchNote(t, root, "notes/second.md", "second", "Second Note", "kubernetes")
+	note := filepath.Join(root, "notes", "second.md")
+	events <- fsnotify.Event{Name: note + ".tmp.123", Op: fsnotify.Create}
+	events <- fsnotify.Event{Name: note + ".tmp.123", Op: fsnotify.Write}
+	events <- fsnotify.Event{Name: note, Op: fsnotify.Create}
+	events <- fsnotify.Event{Name: note, Op: fsnotify.Write}
+	events <- fsnotify.Event{Name: filepath.Join(root, "notes", "image.png"), Op: fsnotify.Create}
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "indexed 1, removed 0")
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"second"}, savedSearch(t, indexer, "kubernetes"))
+
+	// A new note directory is watched once it exists
+	daily := filepath.Join(root, "notes", "dailies")
+	require.NoError(t, os.Mkdir(daily, 0o755))
+	events <- fsnotify.Event{Name: daily, Op: fsnotify.Create}
+
+	// Changes still pending when the watch stops are applied
+	require.NoError(t, os.Remove(filepath.Join(root, "notes", "first.md")))
+	events <- fsnotify.Event{Name: filepath.Join(root, "notes", "first.md"), Op: fsnotify.Remove}
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Contains(t, out.String(), "indexed 0, removed 1")
+	assert.Equal(t, []string{daily}, added)
+}
+
+func TestWatchedNotePath(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "vault")
+
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"notes/a.md", "notes/a.md", true},
+		{"daily/2024-01-01.md", "daily/2024-01-01.md", true},
+		{"readme.md", "readme.md", true},
+		{"notes/a.md.tmp.8674665223082153551", "", false},
+		{"notes/.a.md", "", false},
+		{"notes/a.txt", "", false},
+		{"notes/archive/a.md", "notes/archive/a.md", false},
+		{".kbvault/search-index.json", "", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := watchedNotePath(root, filepath.Join(root, filepath.FromSlash(tc.name)), []string{"", "notes/", "daily/"})
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWatchCmdRequiresLocalStorage(t *testing.T) {
+	useTestConfig(t, func(c *types.Config) { c.Storage.Type = types.StorageTypeS3 })
+
+	cmd := newWatchCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only supports local storage")
+}
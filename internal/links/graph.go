@@ -300,6 +300,34 @@ func (g *Graph) FindPath(sourceID, targetID string) []string {
 	return nil
 }
 
+// Neighborhood returns the notes within depth hops of noteID, following
+// links in either direction. The starting note is included.
+func (g *Graph) Neighborhood(noteID string, depth int) []string {
+	visited := map[string]bool{noteID: true}
+	frontier := []string{noteID}
+
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			for _, neighbor := range g.GetConnectedNotes(id) {
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	result := make([]string, 0, len(visited))
+	for id := range visited {
+		result = append(result, id)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
 // GetStatistics returns graph statistics
 func (g *Graph) GetStatistics() *GraphStatistics {
 	stats := &GraphStatistics{
@@ -106,6 +106,26 @@ func TestGraph_FindPath(t *testing.T) {
 	assert.Nil(t, path)
 }
 
+func TestGraph_Neighborhood(t *testing.T) {
+	graph := NewGraph()
+
+	// Chain note1 -> note2 -> note3 -> note4, with note5 isolated
+	for _, id := range []string{"note1", "note2", "note3", "note4", "note5"} {
+		graph.AddNote(&types.NoteMetadata{ID: id, Title: id})
+	}
+	graph.AddLink(types.Link{SourceID: "note1", TargetID: "note2", IsValid: true})
+	graph.AddLink(types.Link{SourceID: "note2", TargetID: "note3", IsValid: true})
+	graph.AddLink(types.Link{SourceID: "note3", TargetID: "note4", IsValid: true})
+
+	assert.Equal(t, []string{"note2"}, graph.Neighborhood("note2", 0))
+
+	// Links are followed in both directions
+	assert.Equal(t, []string{"note1", "note2", "note3"}, graph.Neighborhood("note2", 1))
+	assert.Equal(t, []string{"note1", "note2", "note3", "note4"}, graph.Neighborhood("note2", 2))
+
+	assert.Equal(t, []string{"note5"}, graph.Neighborhood("note5", 3))
+}
+
 func TestGraph_GetOrphanNotes(t *testing.T) {
 	graph := NewGraph()
 
@@ -2,23 +2,53 @@ package search
 
 import (
 	"context"
-	"path/filepath"
-	"regexp"
+	"math"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-	"unicode"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
-// Engine provides full-text search capabilities for notes
+// Engine provides full-text search capabilities for notes. It is safe for
+// concurrent use; searches run in parallel with each other and with index
+// updates, which are synchronized by the index itself.
 type Engine struct {
-	mu      sync.RWMutex
-	index   *Index
-	storage types.StorageBackend
-	options Options
+	// buildMu serializes index builds so concurrent rebuilds don't repeat
+	// the work of listing and reading every note
+	buildMu   sync.Mutex
+	index     *Index
+	storage   types.StorageBackend
+	options   Options
+	tokenizer Tokenizer
+
+	// syncedAt is when the index was last built or refreshed from
+	// storage, in Unix nanoseconds; zero if it never was
+	syncedAt atomic.Int64
+}
+
+// IndexStatus describes the contents and freshness of the index
+type IndexStatus struct {
+	// Documents is the number of indexed notes
+	Documents int
+
+	// SyncedAt is when the index was last built or refreshed from
+	// storage; zero if it never was. Notes indexed one at a time since
+	// then don't move it.
+	SyncedAt time.Time
+}
+
+// IndexStatus reports the size of the index and when it was last synced
+// with storage
+func (e *Engine) IndexStatus() IndexStatus {
+	status := IndexStatus{Documents: e.index.Size()}
+	if syncedAt := e.syncedAt.Load(); syncedAt != 0 {
+		status.SyncedAt = time.Unix(0, syncedAt)
+	}
+	return status
 }
 
 // Options configures the search engine behavior
@@ -29,6 +59,10 @@ type Options struct {
 	// MaxResults limits the number of search results
 	MaxResults int
 
+	// MaxLimit caps the number of results a single query can ask for,
+	// whatever its Limit or MaxResults; zero means no cap
+	MaxLimit int
+
 	// IndexUpdateInterval controls how often the index is refreshed
 	IndexUpdateInterval time.Duration
 
@@ -37,8 +71,74 @@ type Options struct {
 
 	// FuzzyThreshold sets the minimum similarity score (0.0 to 1.0)
 	FuzzyThreshold float64
+
+	// RemoveStopWords drops common words such as "the" and "a" when
+	// indexing and searching
+	RemoveStopWords bool
+
+	// StopWords overrides the default English stop-word list
+	StopWords []string
+
+	// EnableStemming matches words by their stem so that "running"
+	// finds "run"
+	EnableStemming bool
+
+	// Tokenizer replaces the built-in tokenizer; when set, the stop-word
+	// and stemming options are ignored
+	Tokenizer Tokenizer
+
+	// Snippet controls how result snippets are highlighted
+	Snippet SnippetOptions
+
+	// ContextSize is the number of characters of surrounding text kept on
+	// either side of a match in Match.Context; zero uses
+	// DefaultContextSize
+	ContextSize int
+
+	// SnippetLength is the maximum number of characters in the snippet of
+	// a result with no highlighted matches; zero uses
+	// DefaultSnippetLength
+	SnippetLength int
+
+	// TitleSources is the order in which note titles are resolved when
+	// indexing; empty uses the default precedence
+	TitleSources []types.TitleSource
+
+	// InlineTags indexes #hashtags in note bodies as tags, along with the
+	// frontmatter tags
+	InlineTags bool
+
+	// Dirs are the storage directories scanned for notes, as listing
+	// prefixes such as "notes/"; empty uses the default vault layout. See
+	// types.VaultConfig.NoteDirs.
+	Dirs []string
+
+	// Ignore excludes matching files from the index; nil ignores nothing.
+	// See types.VaultConfig.Ignore.
+	Ignore *types.IgnorePatterns
+
+	// RecencyBoost ranks recently updated notes higher. A note's score is
+	// multiplied by 1 + RecencyBoost × 0.5^(age / RecencyHalfLife), where
+	// age is the time since the note was updated: a note updated just now
+	// gets the whole boost and one RecencyHalfLife old half of it. Zero,
+	// the default, disables the boost.
+	RecencyBoost float64
+
+	// RecencyHalfLife is the age at which the recency boost has halved;
+	// zero uses DefaultRecencyHalfLife
+	RecencyHalfLife time.Duration
 }
 
+// Default sizes of match context and unhighlighted snippets, in characters
+const (
+	DefaultContextSize   = 40
+	DefaultSnippetLength = 200
+)
+
+// DefaultRecencyHalfLife is the age at which the recency boost has halved
+// when Options.RecencyHalfLife is not set
+const DefaultRecencyHalfLife = 30 * 24 * time.Hour
+
 // DefaultOptions returns reasonable default search options
 func DefaultOptions() Options {
 	return Options{
@@ -47,15 +147,46 @@ func DefaultOptions() Options {
 		IndexUpdateInterval: 5 * time.Minute,
 		EnableFuzzySearch:   true,
 		FuzzyThreshold:      0.7,
+		Snippet:             DefaultSnippetOptions(),
+		InlineTags:          true,
+		ContextSize:         DefaultContextSize,
+		SnippetLength:       DefaultSnippetLength,
 	}
 }
 
 // New creates a new search engine
 func New(storage types.StorageBackend, opts Options) *Engine {
 	return &Engine{
-		index:   NewIndex(),
-		storage: storage,
-		options: opts,
+		index:     NewIndexWithTokenizer(opts.indexTokenizer()),
+		storage:   storage,
+		options:   opts,
+		tokenizer: opts.queryTokenizer(),
+	}
+}
+
+// queryTokenizer returns the tokenizer applied to queries and scored fields
+func (o Options) queryTokenizer() Tokenizer {
+	if o.Tokenizer != nil {
+		return o.Tokenizer
+	}
+	return NewTokenizer(o.tokenizerOptions(o.CaseSensitive))
+}
+
+// indexTokenizer returns the tokenizer used to build the index. The index
+// is always case-insensitive; case sensitivity only affects scoring.
+func (o Options) indexTokenizer() Tokenizer {
+	if o.Tokenizer != nil {
+		return o.Tokenizer
+	}
+	return NewTokenizer(o.tokenizerOptions(false))
+}
+
+func (o Options) tokenizerOptions(caseSensitive bool) TokenizerOptions {
+	return TokenizerOptions{
+		CaseSensitive:   caseSensitive,
+		RemoveStopWords: o.RemoveStopWords,
+		StopWords:       o.StopWords,
+		EnableStemming:  o.EnableStemming,
 	}
 }
 
@@ -73,10 +204,13 @@ type SearchQuery struct {
 	// Type to filter by
 	Type string
 
-	// DateRange for filtering by creation/update time
+	// DateRange for filtering by creation time
 	DateRange *DateRange
 
-	// SortBy field (relevance, created, updated, title)
+	// ModifiedRange for filtering by last modification time
+	ModifiedRange *DateRange
+
+	// SortBy field (relevance, created, modified or updated, title)
 	SortBy string
 
 	// SortDesc reverses the sort order
@@ -87,14 +221,36 @@ type SearchQuery struct {
 
 	// Offset for pagination
 	Offset int
+
+	// Regex treats Query as a regular expression matched against the text
+	// of each note instead of looking up terms in the index
+	Regex bool
+
+	// Explain records how each result's score was computed in its
+	// Explanation
+	Explain bool
 }
 
-// DateRange specifies a time range for filtering
+// DateRange specifies a time range for filtering. A zero bound is open.
 type DateRange struct {
 	After  time.Time
 	Before time.Time
 }
 
+// contains reports whether t falls within the range, bounds included
+func (r *DateRange) contains(t time.Time) bool {
+	if r == nil {
+		return true
+	}
+	if !r.After.IsZero() && t.Before(r.After) {
+		return false
+	}
+	if !r.Before.IsZero() && t.After(r.Before) {
+		return false
+	}
+	return true
+}
+
 // SearchResult represents a single search match
 type SearchResult struct {
 	// Note metadata
@@ -108,37 +264,156 @@ type SearchResult struct {
 
 	// Snippet shows context around the match
 	Snippet string
+
+	// Explanation breaks the score down when the query asks for it
+	Explanation *Explanation `json:",omitempty"`
 }
 
 // Match represents a specific location where the query matched
 type Match struct {
 	Field    string // title, content, tags
+	Line     int    // line within the field, starting at 1; set by regex search
 	Position int    // character position
 	Length   int    // length of match
 	Context  string // surrounding text
 }
 
-// Search performs a full-text search across notes
+// SortNone is the SearchQuery.SortBy value that leaves results unsorted,
+// so SearchStream can emit them as soon as they are scored
+const SortNone = "none"
+
+// Search performs a full-text search across notes. It collects the results
+// of SearchStream.
 func (e *Engine) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
-	e.mu.RLock()
+	stream, errc := e.SearchStream(ctx, query)
 
-	// Check if index is empty and build it automatically if needed
-	if len(e.index.GetAllDocuments()) == 0 {
-		e.mu.RUnlock()
+	var results []SearchResult
+	for result := range stream {
+		results = append(results, result)
+	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SearchStream runs a search and sends its results on the returned channel,
+// which is closed when the search ends. The error channel then receives the
+// error that ended the search, if any, and is closed.
+//
+// With SortBy set to SortNone results are sent as soon as they are scored,
+// in no particular order, and the search stops once the limit is reached.
+// Any other sort needs every result first, so results are collected,
+// sorted and then sent. Offset and Limit apply either way.
+//
+// Callers that stop reading before the results channel is closed must
+// cancel ctx so the search can end; the error channel then receives
+// ctx.Err().
+func (e *Engine) SearchStream(ctx context.Context, query SearchQuery) (<-chan SearchResult, <-chan error) {
+	results := make(chan SearchResult)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(results)
+
+		var cancelled bool
+		send := func(result SearchResult) bool {
+			select {
+			case results <- result:
+				return true
+			case <-ctx.Done():
+				cancelled = true
+				return false
+			}
+		}
 
-		// Build the index (this will acquire the write lock)
-		if err := e.BuildIndex(ctx); err != nil {
-			return nil, err
+		match := e.matchTerms
+		if query.Regex {
+			match = e.matchRegex
 		}
 
-		e.mu.RLock()
+		var err error
+		if query.SortBy == SortNone {
+			skipped, sent, limit := 0, 0, e.limit(query)
+			err = match(ctx, query, true, func(result SearchResult) bool {
+				if skipped < query.Offset {
+					skipped++
+					return true
+				}
+				if sent >= limit || !send(result) {
+					return false
+				}
+				sent++
+				return true
+			})
+		} else {
+			var buffered []SearchResult
+			err = match(ctx, query, true, func(result SearchResult) bool {
+				buffered = append(buffered, result)
+				return true
+			})
+			if err == nil {
+				e.sortResults(buffered, query.SortBy, query.SortDesc)
+				for _, result := range e.paginate(buffered, query) {
+					if !send(result) {
+						break
+					}
+				}
+			}
+		}
+
+		if err == nil && cancelled {
+			err = ctx.Err()
+		}
+		if err != nil {
+			errc <- err
+		}
+	}()
+
+	return results, errc
+}
+
+// Count returns the number of notes matching query, ignoring its Limit,
+// Offset and sort order. Notes are only scored, so the match positions,
+// context and snippets a search builds are skipped.
+func (e *Engine) Count(ctx context.Context, query SearchQuery) (int, error) {
+	match := e.matchTerms
+	if query.Regex {
+		match = e.matchRegex
+	}
+
+	count := 0
+	err := match(ctx, query, false, func(SearchResult) bool {
+		count++
+		return true
+	})
+	if err != nil {
+		return 0, err
 	}
+	return count, nil
+}
 
-	defer e.mu.RUnlock()
+// matchTerms looks up the query terms in the index and passes each
+// matching note to yield as it is scored, until yield returns false.
+// Matches and snippets are only built when detailed is set.
+func (e *Engine) matchTerms(ctx context.Context, query SearchQuery, detailed bool, yield func(SearchResult) bool) error {
+	// Check if index is empty and build it automatically if needed
+	if e.index.Size() == 0 {
+		if err := e.buildIfEmpty(ctx); err != nil {
+			return err
+		}
+	}
 
 	// Normalize query
 	searchTerms := e.tokenize(query.Query)
 
+	// A query made up entirely of stop words matches nothing rather than
+	// falling through to an unfiltered listing
+	if len(searchTerms) == 0 && strings.TrimSpace(query.Query) != "" {
+		return nil
+	}
+
 	// Get all matching documents from index
 	var candidates []*IndexedDocument
 
@@ -162,9 +437,11 @@ func (e *Engine) Search(ctx context.Context, query SearchQuery) ([]SearchResult,
 
 	// Remove duplicates and apply filters
 	seen := make(map[string]bool)
-	var results []SearchResult
 
 	for _, doc := range candidates {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if seen[doc.ID] {
 			continue
 		}
@@ -176,30 +453,56 @@ func (e *Engine) Search(ctx context.Context, query SearchQuery) ([]SearchResult,
 		}
 
 		// Calculate score and matches
-		score, matches := e.calculateScore(doc, searchTerms, query)
+		var explanation *Explanation
+		if query.Explain {
+			explanation = &Explanation{}
+		}
+		score, matches := e.calculateScore(doc, searchTerms, detailed, explanation)
 		if score == 0 {
 			continue
 		}
+		score = e.boostRecency(score, doc.UpdatedAt, explanation)
 
-		// Create result
 		result := SearchResult{
-			Note:    doc.ToMetadata(),
-			Score:   score,
-			Matches: matches,
-			Snippet: e.generateSnippet(doc, matches),
+			Note:        doc.ToMetadata(),
+			Score:       score,
+			Explanation: explanation,
+		}
+		if detailed {
+			result.Matches = matches
+			result.Snippet = e.generateSnippet(doc, matches)
+		}
+		if !yield(result) {
+			return nil
 		}
-
-		results = append(results, result)
 	}
 
-	// Sort results
-	e.sortResults(results, query.SortBy, query.SortDesc)
+	return nil
+}
 
-	// Apply pagination
-	limit := query.Limit
+// limit returns the maximum number of results for query
+func (e *Engine) limit(query SearchQuery) int {
+	limit, _ := e.EffectiveLimit(query)
+	return limit
+}
+
+// EffectiveLimit returns the maximum number of results Search returns for
+// query: its Limit, or MaxResults when it sets none, capped at MaxLimit.
+// clamped reports whether the cap lowered it.
+func (e *Engine) EffectiveLimit(query SearchQuery) (limit int, clamped bool) {
+	limit = query.Limit
 	if limit <= 0 {
 		limit = e.options.MaxResults
 	}
+	if e.options.MaxLimit > 0 && limit > e.options.MaxLimit {
+		return e.options.MaxLimit, true
+	}
+	return limit, false
+}
+
+// paginate applies the query's offset and limit to sorted results
+func (e *Engine) paginate(results []SearchResult, query SearchQuery) []SearchResult {
+	limit := e.limit(query)
 
 	start := query.Offset
 	end := start + limit
@@ -210,104 +513,133 @@ func (e *Engine) Search(ctx context.Context, query SearchQuery) ([]SearchResult,
 		start = len(results)
 	}
 
-	return results[start:end], nil
+	return results[start:end]
 }
 
-// BuildIndex creates or updates the search index
-func (e *Engine) BuildIndex(ctx context.Context) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	// List all notes from common directories
-	dirs := []string{"", "notes/", "daily/"} // Root, notes directory, and daily notes
-	var allFiles []string
+// buildIfEmpty builds the index unless a concurrent build has already
+// filled it
+func (e *Engine) buildIfEmpty(ctx context.Context) error {
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
 
-	for _, dir := range dirs {
-		files, err := e.storage.List(ctx, dir)
-		if err != nil {
-			// Continue if directory doesn't exist
-			continue
-		}
-		// Storage List already returns full relative paths from storage root
-		allFiles = append(allFiles, files...)
+	if e.index.Size() > 0 {
+		return nil
 	}
+	return e.buildIndex(ctx)
+}
 
-	files := allFiles
+// BuildIndex creates or updates the search index. Searches continue to use
+// the previous contents until the new index is complete.
+func (e *Engine) BuildIndex(ctx context.Context) error {
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
 
-	// Debug: check if we found any files
-	if len(files) == 0 {
-		// Try alternative approach - list from root and look for .md files
-		rootFiles, err := e.storage.List(ctx, "")
-		if err == nil {
-			for _, file := range rootFiles {
-				if strings.HasSuffix(file, ".md") {
-					files = append(files, file)
-				}
-			}
-		}
+	return e.buildIndex(ctx)
+}
 
-		// Also try looking for files recursively in the notes directory
-		if len(files) == 0 {
-			// This is a fallback - the storage backend might not support directory listing
-			// For local storage, try some common note file patterns
-			commonFiles := []string{
-				"notes/golang-basics.md",
-				"notes/python-tutorial.md",
-				"notes/daily-2024-01-15.md",
-				"notes/meeting-notes.md",
-			}
-			for _, file := range commonFiles {
-				if data, err := e.storage.Read(ctx, file); err == nil && len(data) > 0 {
-					files = append(files, file)
-				}
-			}
-		}
+// buildIndex reads every note and replaces the index contents; buildMu
+// must be held. Notes are listed with their metadata first and then read
+// in one batch where storage supports it. When ctx is done before all
+// notes are read, the index is left as it was.
+func (e *Engine) buildIndex(ctx context.Context) error {
+	files, infos := e.noteFiles(ctx)
+	modTimes := make(map[string]int64, len(files))
+	for _, file := range files {
+		modTimes[file] = infos[file].ModTime
 	}
 
-	// Clear existing index
-	e.index = NewIndex()
+	// Unreadable notes are left out of contents and skipped
+	contents, _ := types.BatchRead(ctx, e.storage, files)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	// Index each note
+	var docs []*IndexedDocument
 	for _, file := range files {
-		if !strings.HasSuffix(file, ".md") {
+		data, ok := contents[file]
+		if !ok {
 			continue
 		}
-
-		// Read note content
-		data, err := e.storage.Read(ctx, file)
+		doc, err := e.parseNote(file, data, modTimes[file])
 		if err != nil {
-			// Log error but continue indexing
 			continue
 		}
+		docs = append(docs, doc)
+	}
 
-		// Parse note
-		note, err := e.parseNote(file, data)
-		if err != nil {
-			continue
-		}
+	e.index.Rebuild(docs)
+	e.syncedAt.Store(time.Now().UnixNano())
+	return nil
+}
 
-		// Index the note
-		e.indexNote(note)
+// NoteDirs returns the storage directories scanned for notes. Their
+// subdirectories are not scanned.
+func (e *Engine) NoteDirs() []string {
+	if len(e.options.Dirs) == 0 {
+		return types.DefaultConfig().Vault.NoteDirs()
 	}
+	return e.options.Dirs
+}
 
-	return nil
+// noteFiles lists the markdown files that make up the index with their
+// metadata. Note directories are walked, so storage that can doesn't
+// collect the paths of other files.
+func (e *Engine) noteFiles(ctx context.Context) ([]string, map[string]*types.FileInfo) {
+	infos := make(map[string]*types.FileInfo)
+	var notes []string
+
+	for _, dir := range e.NoteDirs() {
+		// Walk returns paths relative to the storage root. A directory
+		// that can't be walked, e.g. because it doesn't exist, is skipped.
+		_ = types.Walk(ctx, e.storage, dir, func(file string, info *types.FileInfo) error {
+			if strings.HasSuffix(file, ".md") && infos[file] == nil && !e.Ignored(file) {
+				infos[file] = info
+				notes = append(notes, file)
+			}
+			return nil
+		})
+	}
+	return notes, infos
+}
+
+// Ignored reports whether path matches the ignore patterns, so that the
+// file is kept out of the index
+func (e *Engine) Ignored(path string) bool {
+	return e.options.Ignore.Match(path)
 }
 
-// IndexNote adds or updates a single note in the index
-func (e *Engine) IndexNote(ctx context.Context, note *types.Note) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+// readDocument reads and parses a single note. The file is stat'ed before
+// it is read, so a change made in between is picked up by the next refresh.
+func (e *Engine) readDocument(ctx context.Context, path string) (*IndexedDocument, error) {
+	var modTime int64
+	if info, err := e.storage.Stat(ctx, path); err == nil {
+		modTime = info.ModTime
+	}
+
+	data, err := e.storage.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.parseNote(path, data, modTime)
+}
+
+// IndexNote adds or updates a single note in the index. Updates wait for a
+// running build so that the build can't overwrite them with older content.
+func (e *Engine) IndexNote(ctx context.Context, n *types.Note) error {
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
 
 	doc := &IndexedDocument{
-		ID:        note.ID,
-		Title:     note.Title,
-		Content:   note.Content,
-		Tags:      note.Frontmatter.Tags,
-		Type:      note.Frontmatter.Type,
-		FilePath:  note.FilePath,
-		CreatedAt: note.CreatedAt,
-		UpdatedAt: note.UpdatedAt,
-		Size:      note.Size,
+		ID:        n.ID,
+		Title:     n.Title,
+		Content:   n.Content,
+		Tags:      note.Tags(n, e.options.InlineTags),
+		Type:      n.Frontmatter.Type,
+		FilePath:  n.FilePath,
+		CreatedAt: n.CreatedAt,
+		UpdatedAt: n.UpdatedAt,
+		Size:      n.Size,
 	}
 
 	e.index.Add(doc)
@@ -316,8 +648,8 @@ func (e *Engine) IndexNote(ctx context.Context, note *types.Note) error {
 
 // RemoveFromIndex removes a note from the search index
 func (e *Engine) RemoveFromIndex(ctx context.Context, noteID string) error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
 
 	e.index.Remove(noteID)
 	return nil
@@ -325,30 +657,7 @@ func (e *Engine) RemoveFromIndex(ctx context.Context, noteID string) error {
 
 // tokenize splits text into searchable tokens
 func (e *Engine) tokenize(text string) []string {
-	if !e.options.CaseSensitive {
-		text = strings.ToLower(text)
-	}
-
-	// Split on word boundaries
-	var tokens []string
-	var current strings.Builder
-
-	for _, r := range text {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			current.WriteRune(r)
-		} else {
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-		}
-	}
-
-	if current.Len() > 0 {
-		tokens = append(tokens, current.String())
-	}
-
-	return tokens
+	return e.tokenizer.Tokenize(text)
 }
 
 // matchesFilters checks if a document matches the query filters
@@ -367,23 +676,17 @@ func (e *Engine) matchesFilters(doc *IndexedDocument, query SearchQuery) bool {
 		return false
 	}
 
-	// Date range filter
-	if query.DateRange != nil {
-		if !query.DateRange.After.IsZero() && doc.CreatedAt.Before(query.DateRange.After) {
-			return false
-		}
-		if !query.DateRange.Before.IsZero() && doc.CreatedAt.After(query.DateRange.Before) {
-			return false
-		}
-	}
-
-	return true
+	// Date range filters
+	return query.DateRange.contains(doc.CreatedAt) && query.ModifiedRange.contains(doc.UpdatedAt)
 }
 
-// calculateScore computes the relevance score for a document
-func (e *Engine) calculateScore(doc *IndexedDocument, terms []string, query SearchQuery) (float64, []Match) {
+// calculateScore computes the relevance score for a document, and where
+// the terms matched when withMatches is set. Each part of the score is
+// recorded in explanation unless it is nil.
+func (e *Engine) calculateScore(doc *IndexedDocument, terms []string, withMatches bool, explanation *Explanation) (float64, []Match) {
 	if len(terms) == 0 {
 		// No text search, just return a base score
+		explanation.add("", "", MatchAll, 0, 0, 1.0)
 		return 1.0, nil
 	}
 
@@ -391,26 +694,53 @@ func (e *Engine) calculateScore(doc *IndexedDocument, terms []string, query Sear
 	var matches []Match
 
 	// Score title matches (weighted higher)
-	titleScore, titleMatches := e.scoreField(doc.Title, terms, "title", 2.0)
+	titleScore, titleMatches := e.scoreField(doc.Title, terms, "title", 2.0, withMatches, explanation)
 	totalScore += titleScore
 	matches = append(matches, titleMatches...)
 
 	// Score content matches
-	contentScore, contentMatches := e.scoreField(doc.Content, terms, "content", 1.0)
+	contentScore, contentMatches := e.scoreField(doc.Content, terms, "content", 1.0, withMatches, explanation)
 	totalScore += contentScore
 	matches = append(matches, contentMatches...)
 
 	// Score tag matches
 	tagText := strings.Join(doc.Tags, " ")
-	tagScore, tagMatches := e.scoreField(tagText, terms, "tags", 1.5)
+	tagScore, tagMatches := e.scoreField(tagText, terms, "tags", 1.5, withMatches, explanation)
 	totalScore += tagScore
 	matches = append(matches, tagMatches...)
 
 	return totalScore, matches
 }
 
-// scoreField calculates score for matches in a specific field
-func (e *Engine) scoreField(text string, terms []string, field string, weight float64) (float64, []Match) {
+// boostRecency applies the recency boost to the score of a note last
+// updated at updatedAt, recording the increase in explanation unless it is
+// nil. Notes without an update time, and all notes when the boost is
+// disabled, keep their score.
+func (e *Engine) boostRecency(score float64, updatedAt time.Time, explanation *Explanation) float64 {
+	if e.options.RecencyBoost <= 0 || updatedAt.IsZero() {
+		return score
+	}
+
+	halfLife := e.options.RecencyHalfLife
+	if halfLife <= 0 {
+		halfLife = DefaultRecencyHalfLife
+	}
+	// Notes dated in the future count as updated now
+	age := time.Since(updatedAt)
+	if age < 0 {
+		age = 0
+	}
+
+	multiplier := 1 + e.options.RecencyBoost*math.Exp2(-float64(age)/float64(halfLife))
+	boosted := score * multiplier
+	explanation.add("", "", MatchRecency, 0, multiplier, boosted-score)
+	return boosted
+}
+
+// scoreField calculates score for matches in a specific field, recording
+// the first matches of each term when withMatches is set and each term's
+// score in explanation unless it is nil
+func (e *Engine) scoreField(text string, terms []string, field string, weight float64, withMatches bool, explanation *Explanation) (float64, []Match) {
 	if !e.options.CaseSensitive {
 		text = strings.ToLower(text)
 	}
@@ -418,12 +748,31 @@ func (e *Engine) scoreField(text string, terms []string, field string, weight fl
 	var score float64
 	var matches []Match
 
+	// Field tokens are only needed for terms without a literal match, such
+	// as stemmed terms
+	var tokenCounts map[string]int
+
 	for _, term := range terms {
 		// Exact match
 		count := strings.Count(text, term)
+		if count == 0 {
+			if tokenCounts == nil {
+				tokenCounts = make(map[string]int)
+				for _, token := range e.tokenize(text) {
+					tokenCounts[token]++
+				}
+			}
+			if n := tokenCounts[term]; n > 0 {
+				score += float64(n) * weight
+				explanation.add(field, term, MatchStemmed, n, weight, float64(n)*weight)
+				continue
+			}
+		}
 		if count > 0 {
 			score += float64(count) * weight
-
+			explanation.add(field, term, MatchExact, count, weight, float64(count)*weight)
+		}
+		if count > 0 && withMatches {
 			// Find match positions
 			idx := 0
 			for i := 0; i < count && i < 3; i++ { // Limit to 3 matches per term
@@ -448,8 +797,9 @@ func (e *Engine) scoreField(text string, terms []string, field string, weight fl
 		// Fuzzy match if enabled
 		if e.options.EnableFuzzySearch && count == 0 {
 			// Simple fuzzy matching - check if term is substring
-			if strings.Contains(text, term[:min(3, len(term))]) {
+			if strings.Contains(text, term[:runesAfter(term, 0, 3)]) {
 				score += 0.5 * weight
+				explanation.add(field, term, MatchFuzzy, 0, weight, 0.5*weight)
 			}
 		}
 	}
@@ -457,12 +807,18 @@ func (e *Engine) scoreField(text string, terms []string, field string, weight fl
 	return score, matches
 }
 
-// extractContext gets surrounding text for a match
+// extractContext gets surrounding text for a match, keeping ContextSize
+// characters on either side and never splitting a multibyte character
 func (e *Engine) extractContext(text string, pos, length int) string {
-	const contextSize = 40
+	contextSize := e.options.ContextSize
+	if contextSize <= 0 {
+		contextSize = DefaultContextSize
+	}
 
-	start := max(0, pos-contextSize)
-	end := min(len(text), pos+length+contextSize)
+	matchStart := runeStart(text, min(max(0, pos), len(text)))
+	matchEnd := runeEnd(text, min(max(matchStart, pos+length), len(text)))
+	start := runesBefore(text, matchStart, contextSize)
+	end := runesAfter(text, matchEnd, contextSize)
 
 	context := text[start:end]
 
@@ -481,22 +837,44 @@ func (e *Engine) extractContext(text string, pos, length int) string {
 func (e *Engine) generateSnippet(doc *IndexedDocument, matches []Match) string {
 	if len(matches) == 0 {
 		// No matches, return beginning of content
-		content := doc.Content
-		if len(content) > 200 {
-			content = content[:200] + "..."
+		length := e.options.SnippetLength
+		if length <= 0 {
+			length = DefaultSnippetLength
 		}
-		return content
+		return truncateRunes(doc.Content, length)
 	}
 
-	// Use the first content match if available
+	// Prefer content matches, otherwise use the field of the first match
+	field := matches[0].Field
 	for _, match := range matches {
 		if match.Field == "content" {
-			return match.Context
+			field = "content"
+			break
 		}
 	}
 
-	// Otherwise use first match
-	return matches[0].Context
+	var fieldMatches []Match
+	for _, match := range matches {
+		if match.Field == field {
+			fieldMatches = append(fieldMatches, match)
+		}
+	}
+
+	return buildSnippet(e.snippetText(doc, field), fieldMatches, e.options.Snippet)
+}
+
+// snippetText returns the text that match positions in field refer to.
+// Positions are computed on lowercased text, so the original text is only
+// used when lowercasing did not change its byte offsets.
+func (e *Engine) snippetText(doc *IndexedDocument, field string) string {
+	text := fieldText(doc, field)
+	if e.options.CaseSensitive {
+		return text
+	}
+	if lower := strings.ToLower(text); len(lower) != len(text) {
+		return lower
+	}
+	return text
 }
 
 // sortResults sorts search results according to the query
@@ -507,7 +885,7 @@ func (e *Engine) sortResults(results []SearchResult, sortBy string, desc bool) {
 		switch sortBy {
 		case "created":
 			less = results[i].Note.CreatedAt.Before(results[j].Note.CreatedAt)
-		case "updated":
+		case "updated", "modified":
 			less = results[i].Note.UpdatedAt.Before(results[j].Note.UpdatedAt)
 		case "title":
 			less = results[i].Note.Title < results[j].Note.Title
@@ -522,60 +900,50 @@ func (e *Engine) sortResults(results []SearchResult, sortBy string, desc bool) {
 	})
 }
 
-// parseNote extracts note data from file content
-func (e *Engine) parseNote(path string, data []byte) (*IndexedDocument, error) {
-	// Parse note content and extract metadata
-	content := string(data)
-	lines := strings.Split(content, "\n")
-
-	// Extract ULID from filename (e.g., "notes/01KC83AQAJV2CEB9VTGPHTMBYP.md" → "01KC83AQAJV2CEB9VTGPHTMBYP")
-	filename := filepath.Base(path)
-	ulid := strings.TrimSuffix(filename, ".md")
-
-	// Extract title from first # heading or filename
-	title := ulid
-	for _, line := range lines {
-		if strings.HasPrefix(line, "# ") {
-			title = strings.TrimPrefix(line, "# ")
-			break
-		}
+// parseNote extracts note data from file content. Created and updated
+// times come from the frontmatter, falling back to modTime, the file's
+// modification time in Unix seconds, when a note doesn't record them.
+func (e *Engine) parseNote(path string, data []byte, modTime int64) (*IndexedDocument, error) {
+	// Parse frontmatter and resolve the title the same way as everywhere
+	// else; the ID is the ULID from the filename (e.g.
+	// "notes/01KC83AQAJV2CEB9VTGPHTMBYP.md" → "01KC83AQAJV2CEB9VTGPHTMBYP")
+	parsed := note.ParseWithOptions(path, data, note.ParseOptions{TitleSources: e.options.TitleSources})
+
+	var fileTime time.Time
+	if modTime > 0 {
+		fileTime = time.Unix(modTime, 0).UTC()
 	}
 
-	// Extract tags from content
-	var tags []string
-	for _, line := range lines {
-		// Look for "Tags:" line with hashtag-style tags
-		if strings.HasPrefix(strings.TrimSpace(line), "Tags:") {
-			tagsPart := strings.TrimPrefix(strings.TrimSpace(line), "Tags:")
-			// Extract hashtag-style tags
-			tagMatches := regexp.MustCompile(`#(\w+)`).FindAllStringSubmatch(tagsPart, -1)
-			for _, match := range tagMatches {
-				if len(match) > 1 {
-					tags = append(tags, match[1])
-				}
-			}
-		}
+	createdAt, updatedAt := parsed.CreatedAt, parsed.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = updatedAt
+	}
+	if createdAt.IsZero() {
+		createdAt = fileTime
+	}
+	if updatedAt.IsZero() {
+		updatedAt = fileTime
+	}
+	if updatedAt.IsZero() || updatedAt.Before(createdAt) {
+		updatedAt = createdAt
 	}
 
 	doc := &IndexedDocument{
-		ID:        ulid, // Use ULID only, not the full path
-		Title:     title,
-		Content:   content,
-		Tags:      tags,
+		ID:        parsed.ID, // Use ULID only, not the full path
+		Title:     parsed.Title,
+		Content:   parsed.Content,
+		Tags:      note.Tags(parsed, e.options.InlineTags),
+		Type:      parsed.Frontmatter.Type,
 		FilePath:  path,
-		CreatedAt: time.Now(), // Would get from file info
-		UpdatedAt: time.Now(),
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
 		Size:      int64(len(data)),
+		ModTime:   modTime,
 	}
 
 	return doc, nil
 }
 
-// indexNote adds a document to the index
-func (e *Engine) indexNote(doc *IndexedDocument) {
-	e.index.Add(doc)
-}
-
 // Helper functions
 
 func contains(slice []string, item string) bool {
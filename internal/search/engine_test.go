@@ -2,7 +2,10 @@ package search
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,6 +17,9 @@ import (
 // mockStorage implements a simple in-memory storage for testing
 type mockStorage struct {
 	files map[string][]byte
+
+	// modTime, if set, is reported by Stat instead of the current time
+	modTime time.Time
 }
 
 func newMockStorage() *mockStorage {
@@ -62,10 +68,14 @@ func (m *mockStorage) Stat(ctx context.Context, path string) (*types.FileInfo, e
 	if !ok {
 		return nil, types.NewStorageError(m.Type(), "stat", path, nil, false)
 	}
+	modTime := m.modTime
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
 	return &types.FileInfo{
 		Path:    path,
 		Size:    int64(len(data)),
-		ModTime: time.Now().Unix(),
+		ModTime: modTime.Unix(),
 	}, nil
 }
 
@@ -267,6 +277,107 @@ func TestEngine_BuildIndex(t *testing.T) {
 	assert.Len(t, results, 1)
 }
 
+func TestEngine_BuildIndexTags(t *testing.T) {
+	storage := newMockStorage()
+	ctx := context.Background()
+
+	testFiles := map[string]string{
+		"front.md":  "---\ntags: [go, testing]\n---\n\n# Front\n\nNo hashtags.\n",
+		"inline.md": "# Inline\n\nAbout #go and #café.\n",
+		"mixed.md":  "---\ntags: [go]\n---\n\n# Mixed\n\n#Go with #日本語 notes.\n",
+	}
+	for path, content := range testFiles {
+		require.NoError(t, storage.Write(ctx, path, []byte(content)))
+	}
+
+	engine := New(storage, DefaultOptions())
+	require.NoError(t, engine.BuildIndex(ctx))
+
+	tags := func(id string) []string {
+		doc, _ := engine.index.GetDocument(id)
+		require.NotNil(t, doc, id)
+		return doc.Tags
+	}
+	assert.Equal(t, []string{"go", "testing"}, tags("front"))
+	assert.Equal(t, []string{"go", "café"}, tags("inline"))
+	assert.Equal(t, []string{"go", "日本語"}, tags("mixed"))
+
+	results, err := engine.Search(ctx, SearchQuery{Tags: []string{"go"}})
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	// IndexNote merges tags the same way as a build
+	require.NoError(t, engine.IndexNote(ctx, &types.Note{ID: "added", Content: "#café\n", Frontmatter: types.Frontmatter{Tags: []string{"Café"}}}))
+	assert.Equal(t, []string{"Café"}, tags("added"))
+
+	opts := DefaultOptions()
+	opts.InlineTags = false
+	engine = New(storage, opts)
+	require.NoError(t, engine.BuildIndex(ctx))
+	assert.Equal(t, []string{}, tags("inline"))
+	assert.Equal(t, []string{"go"}, tags("mixed"))
+}
+
+func TestEngine_BuildIndexDates(t *testing.T) {
+	storage := newMockStorage()
+	storage.modTime = time.Date(2024, 9, 1, 8, 0, 0, 0, time.UTC)
+	engine := New(storage, DefaultOptions())
+	ctx := context.Background()
+
+	testFiles := map[string]string{
+		"dated.md":   "---\ntitle: Dated\ncreated: 2024-01-10T09:00:00Z\nupdated: 2024-05-20T17:30:00Z\n---\n\nplanning notes\n",
+		"daily.md":   "---\ntitle: Daily\ncreated: 2024-02-01\n---\n\nplanning notes\n",
+		"undated.md": "# Undated\n\nplanning notes\n",
+	}
+	for path, content := range testFiles {
+		require.NoError(t, storage.Write(ctx, path, []byte(content)))
+	}
+	require.NoError(t, engine.BuildIndex(ctx))
+
+	dated, _ := engine.index.GetDocument("dated")
+	require.NotNil(t, dated)
+	assert.Equal(t, time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC), dated.CreatedAt)
+	assert.Equal(t, time.Date(2024, 5, 20, 17, 30, 0, 0, time.UTC), dated.UpdatedAt)
+
+	// Missing dates fall back to the file's modification time
+	daily, _ := engine.index.GetDocument("daily")
+	require.NotNil(t, daily)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), daily.CreatedAt)
+	assert.Equal(t, storage.modTime, daily.UpdatedAt)
+
+	undated, _ := engine.index.GetDocument("undated")
+	require.NotNil(t, undated)
+	assert.Equal(t, storage.modTime, undated.CreatedAt)
+	assert.Equal(t, storage.modTime, undated.UpdatedAt)
+
+	titles := func(results []SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Note.Title)
+		}
+		return out
+	}
+
+	results, err := engine.Search(ctx, SearchQuery{
+		Query:     "planning",
+		DateRange: &DateRange{After: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		SortBy:    "created",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Daily", "Undated"}, titles(results))
+
+	results, err = engine.Search(ctx, SearchQuery{
+		Query:         "planning",
+		ModifiedRange: &DateRange{Before: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dated"}, titles(results))
+
+	results, err = engine.Search(ctx, SearchQuery{Query: "planning", SortBy: "modified", SortDesc: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Dated", results[len(results)-1].Note.Title)
+}
+
 func TestEngine_IndexNote(t *testing.T) {
 	storage := newMockStorage()
 	engine := New(storage, DefaultOptions())
@@ -321,3 +432,425 @@ func TestEngine_RemoveFromIndex(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, results, 0)
 }
+
+func TestEngine_BuildIndexKeepsTokenizer(t *testing.T) {
+	storage := newMockStorage()
+	require.NoError(t, storage.Write(context.Background(), "run.md", []byte("# Running\n\nShe was running fast.")))
+
+	opts := DefaultOptions()
+	opts.EnableStemming = true
+	engine := New(storage, opts)
+
+	ctx := context.Background()
+	require.NoError(t, engine.BuildIndex(ctx))
+
+	// "run" only matches "running" if the rebuilt index stems terms
+	results, err := engine.Search(ctx, SearchQuery{Query: "run"})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+// TestEngine_ConcurrentAccess is meant to be run with -race: searches,
+// single-note updates and full rebuilds all run at once
+func TestEngine_ConcurrentAccess(t *testing.T) {
+	storage := newMockStorage()
+	for i := 0; i < 10; i++ {
+		path := fmt.Sprintf("notes/stored-%d.md", i)
+		content := fmt.Sprintf("# Stored %d\n\nConcurrent stored content.", i)
+		require.NoError(t, storage.Write(context.Background(), path, []byte(content)))
+	}
+
+	engine := New(storage, DefaultOptions())
+	ctx := context.Background()
+	require.NoError(t, engine.BuildIndex(ctx))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if _, err := engine.Search(ctx, SearchQuery{Query: "concurrent"}); err != nil {
+				errs <- err
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			err := engine.IndexNote(ctx, &types.Note{
+				ID:          fmt.Sprintf("added-%d", i),
+				Title:       fmt.Sprintf("Added %d", i),
+				Content:     "Concurrent added content.",
+				Frontmatter: types.Frontmatter{Tags: []string{"added"}},
+			})
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%5 == 0 {
+				err = engine.BuildIndex(ctx)
+			} else {
+				_, err = engine.Search(ctx, SearchQuery{Tags: []string{"added"}})
+			}
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// Stored notes are always present; added notes survive unless a later
+	// rebuild from storage dropped them
+	results, err := engine.Search(ctx, SearchQuery{Query: "stored", Limit: 100})
+	require.NoError(t, err)
+	assert.Len(t, results, 10)
+}
+
+// dirStorage lists only the files directly under a prefix, like the local
+// backend, and records the listings and reads made
+type dirStorage struct {
+	*mockStorage
+	listed []string
+	read   []string
+}
+
+func (d *dirStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	d.listed = append(d.listed, prefix)
+	var results []string
+	for path := range d.files {
+		if rest, ok := strings.CutPrefix(path, prefix); ok && !strings.Contains(rest, "/") {
+			results = append(results, path)
+		}
+	}
+	return results, nil
+}
+
+func (d *dirStorage) Read(ctx context.Context, path string) ([]byte, error) {
+	d.read = append(d.read, path)
+	return d.mockStorage.Read(ctx, path)
+}
+
+func TestEngine_BuildIndexNoteDirs(t *testing.T) {
+	storage := &dirStorage{mockStorage: newMockStorage()}
+	for path, content := range map[string]string{
+		"kb/go.md":           "# Go\n\nGoroutines.",
+		"journal/2024-01.md": "# January\n\nNew year.",
+		"archive/old.md":     "# Old\n\nArchived.",
+		"notes/default.md":   "# Default\n\nNot a configured dir.",
+		"kb/drafts/draft.md": "# Draft\n\nIn a subdirectory.",
+		"kb/image.png":       "binary",
+	} {
+		require.NoError(t, storage.Write(context.Background(), path, []byte(content)))
+	}
+
+	opts := DefaultOptions()
+	opts.Dirs = types.VaultConfig{NotesDir: "kb", DailyDir: "journal", ExtraDirs: []string{"archive"}}.NoteDirs()
+	engine := New(storage, opts)
+
+	require.NoError(t, engine.BuildIndex(context.Background()))
+	assert.Equal(t, 3, engine.index.Size())
+	assert.Equal(t, []string{"", "kb/", "journal/", "archive/"}, storage.listed)
+	assert.ElementsMatch(t, []string{"kb/go.md", "journal/2024-01.md", "archive/old.md"}, storage.read)
+}
+
+func TestEngine_BuildIndexIgnore(t *testing.T) {
+	storage := &dirStorage{mockStorage: newMockStorage()}
+	for path, content := range map[string]string{
+		"notes/go.md":             "# Go\n\nGoroutines.",
+		"notes/drafts/wip.md":     "# WIP\n\nGoroutines.",
+		"notes/channels.draft.md": "# Channels\n\nGoroutines.",
+	} {
+		require.NoError(t, storage.Write(context.Background(), path, []byte(content)))
+	}
+
+	opts := DefaultOptions()
+	ignore, err := types.ParseIgnorePatterns([]string{"drafts/", "*.draft.md"})
+	require.NoError(t, err)
+	opts.Ignore = ignore
+	engine := New(storage, opts)
+
+	require.NoError(t, engine.BuildIndex(context.Background()))
+	assert.Equal(t, 1, engine.index.Size())
+	assert.Equal(t, []string{"notes/go.md"}, storage.read)
+	assert.True(t, engine.Ignored("notes/drafts/wip.md"))
+	assert.False(t, engine.Ignored("notes/go.md"))
+}
+
+func TestEngine_IndexStatus(t *testing.T) {
+	storage := newMockStorage()
+	require.NoError(t, storage.Write(context.Background(), "notes/go.md", []byte("# Go\n\nGoroutines.")))
+	engine := New(storage, DefaultOptions())
+
+	assert.Equal(t, IndexStatus{}, engine.IndexStatus())
+
+	before := time.Now()
+	require.NoError(t, engine.BuildIndex(context.Background()))
+	status := engine.IndexStatus()
+	assert.Equal(t, 1, status.Documents)
+	assert.False(t, status.SyncedAt.Before(before))
+
+	built := status.SyncedAt
+	_, err := engine.RefreshIndex(context.Background())
+	require.NoError(t, err)
+	assert.False(t, engine.IndexStatus().SyncedAt.Before(built))
+}
+
+func TestEngine_BuildIndexEmptyVault(t *testing.T) {
+	storage := &dirStorage{mockStorage: newMockStorage()}
+	engine := New(storage, DefaultOptions())
+
+	require.NoError(t, engine.BuildIndex(context.Background()))
+	assert.Equal(t, 0, engine.index.Size())
+	assert.Equal(t, types.DefaultConfig().Vault.NoteDirs(), storage.listed)
+	assert.Empty(t, storage.read, "no files are probed when the vault is empty")
+}
+
+// newStreamTestEngine returns an engine over count notes that all mention
+// "stream", titled "Note 000", "Note 001" and so on
+func newStreamTestEngine(t *testing.T, count int) *Engine {
+	t.Helper()
+
+	storage := newMockStorage()
+	for i := range count {
+		content := fmt.Sprintf("---\nid: note-%03d\ntitle: Note %03d\n---\n\nA note about stream processing.\n", i, i)
+		require.NoError(t, storage.Write(context.Background(), fmt.Sprintf("note-%03d.md", i), []byte(content)))
+	}
+
+	engine := New(storage, DefaultOptions())
+	require.NoError(t, engine.BuildIndex(context.Background()))
+	return engine
+}
+
+func TestEngine_SearchStreamCancel(t *testing.T) {
+	engine := newStreamTestEngine(t, 200)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results, errc := engine.SearchStream(ctx, SearchQuery{Query: "stream", SortBy: SortNone, Limit: 1000})
+
+	for range 3 {
+		select {
+		case _, ok := <-results:
+			require.True(t, ok)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no result received")
+		}
+	}
+	cancel()
+
+	// The search stops: the channel closes without producing the rest
+	received := 3
+	for range results {
+		received++
+	}
+	assert.Less(t, received, 200)
+	assert.ErrorIs(t, <-errc, context.Canceled)
+}
+
+func TestEngine_SearchStreamSorted(t *testing.T) {
+	engine := newStreamTestEngine(t, 30)
+	ctx := context.Background()
+
+	query := SearchQuery{Query: "stream", SortBy: "title", SortDesc: true, Offset: 5, Limit: 10}
+	results, errc := engine.SearchStream(ctx, query)
+
+	var titles []string
+	for result := range results {
+		titles = append(titles, result.Note.Title)
+	}
+	require.NoError(t, <-errc)
+
+	var want []string
+	for i := 24; i >= 15; i-- {
+		want = append(want, fmt.Sprintf("Note %03d", i))
+	}
+	assert.Equal(t, want, titles)
+
+	// Search returns the same page in the same order
+	searched, err := engine.Search(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, searched, len(want))
+	for i, result := range searched {
+		assert.Equal(t, want[i], result.Note.Title)
+	}
+}
+
+func TestEngine_SearchStreamUnsorted(t *testing.T) {
+	engine := newStreamTestEngine(t, 30)
+
+	results, errc := engine.SearchStream(context.Background(), SearchQuery{Query: "stream", SortBy: SortNone, Offset: 20, Limit: 7})
+
+	seen := make(map[string]bool)
+	for result := range results {
+		assert.False(t, seen[result.Note.ID], "duplicate result %s", result.Note.ID)
+		seen[result.Note.ID] = true
+	}
+	require.NoError(t, <-errc)
+	assert.Len(t, seen, 7)
+
+	// The offset skips results even without sorting
+	results, errc = engine.SearchStream(context.Background(), SearchQuery{Query: "stream", SortBy: SortNone, Offset: 25, Limit: 10})
+	count := 0
+	for range results {
+		count++
+	}
+	require.NoError(t, <-errc)
+	assert.Equal(t, 5, count)
+}
+
+func TestEngine_SearchStreamError(t *testing.T) {
+	engine := newStreamTestEngine(t, 3)
+
+	results, errc := engine.SearchStream(context.Background(), SearchQuery{Query: "([", Regex: true})
+	_, ok := <-results
+	assert.False(t, ok)
+	assert.ErrorContains(t, <-errc, "invalid regular expression")
+
+	_, err := engine.Search(context.Background(), SearchQuery{Query: "([", Regex: true})
+	assert.ErrorContains(t, err, "invalid regular expression")
+}
+
+func TestEngine_Count(t *testing.T) {
+	engine := newStreamTestEngine(t, 120)
+	ctx := context.Background()
+
+	queries := []SearchQuery{
+		{Query: "stream"},
+		{Query: "note", Fields: []string{"title"}},
+		{Query: "missing"},
+		{Query: `Note 0[0-4]\d`, Regex: true},
+		{Query: "stream", Limit: 5, Offset: 10, SortBy: "title"},
+	}
+	for _, query := range queries {
+		count, err := engine.Count(ctx, query)
+		require.NoError(t, err)
+
+		// The count covers every match, not just one page of results
+		all := query
+		all.Limit, all.Offset = 1000, 0
+		results, err := engine.Search(ctx, all)
+		require.NoError(t, err)
+		assert.Equal(t, len(results), count, "query %+v", query)
+	}
+
+	count, err := engine.Count(ctx, SearchQuery{Query: `Note 0[0-4]\d`, Regex: true})
+	require.NoError(t, err)
+	assert.Equal(t, 50, count)
+
+	_, err = engine.Count(ctx, SearchQuery{Query: "([", Regex: true})
+	assert.ErrorContains(t, err, "invalid regular expression")
+}
+
+func TestEngine_MaxLimit(t *testing.T) {
+	engine := newStreamTestEngine(t, 60)
+	engine.options.MaxLimit = 25
+	ctx := context.Background()
+
+	tests := []struct {
+		query       SearchQuery
+		wantLimit   int
+		wantClamped bool
+	}{
+		{query: SearchQuery{Query: "stream", Limit: 10}, wantLimit: 10},
+		{query: SearchQuery{Query: "stream", Limit: 25}, wantLimit: 25},
+		{query: SearchQuery{Query: "stream", Limit: 1_000_000}, wantLimit: 25, wantClamped: true},
+		{query: SearchQuery{Query: "stream", Limit: 1_000_000, SortBy: SortNone}, wantLimit: 25, wantClamped: true},
+		// MaxResults is capped too when the query sets no limit
+		{query: SearchQuery{Query: "stream"}, wantLimit: 25, wantClamped: true},
+	}
+	for _, tt := range tests {
+		limit, clamped := engine.EffectiveLimit(tt.query)
+		assert.Equal(t, tt.wantLimit, limit, "query %+v", tt.query)
+		assert.Equal(t, tt.wantClamped, clamped, "query %+v", tt.query)
+
+		results, err := engine.Search(ctx, tt.query)
+		require.NoError(t, err)
+		assert.Len(t, results, tt.wantLimit, "query %+v", tt.query)
+	}
+
+	// Without a cap the query's limit is used as it is
+	engine.options.MaxLimit = 0
+	limit, clamped := engine.EffectiveLimit(SearchQuery{Limit: 1_000_000})
+	assert.Equal(t, 1_000_000, limit)
+	assert.False(t, clamped)
+}
+
+func TestEngine_RecencyBoost(t *testing.T) {
+	now := time.Now()
+	newEngine := func(boost float64, halfLife time.Duration) *Engine {
+		opts := DefaultOptions()
+		opts.RecencyBoost = boost
+		opts.RecencyHalfLife = halfLife
+		engine := New(newMockStorage(), opts)
+		// Equally relevant notes of different ages, and one without an
+		// update time
+		engine.index.Add(&IndexedDocument{ID: "old", Title: "Release checklist", Content: "Steps for a release", UpdatedAt: now.Add(-200 * 24 * time.Hour)})
+		engine.index.Add(&IndexedDocument{ID: "new", Title: "Release checklist", Content: "Steps for a release", UpdatedAt: now.Add(-24 * time.Hour)})
+		engine.index.Add(&IndexedDocument{ID: "undated", Title: "Release checklist", Content: "Steps for a release"})
+		return engine
+	}
+	search := func(engine *Engine, query SearchQuery) map[string]float64 {
+		results, err := engine.Search(context.Background(), query)
+		require.NoError(t, err)
+		scores := make(map[string]float64)
+		for _, result := range results {
+			scores[result.Note.ID] = result.Score
+		}
+		return scores
+	}
+
+	// Disabled by default: all three score the same
+	assert.Zero(t, DefaultOptions().RecencyBoost)
+	plain := search(newEngine(0, 0), SearchQuery{Query: "release"})
+	require.Len(t, plain, 3)
+	assert.Equal(t, plain["old"], plain["new"])
+	assert.Equal(t, plain["old"], plain["undated"])
+
+	// With the boost the newer note ranks first
+	engine := newEngine(1, 0)
+	results, err := engine.Search(context.Background(), SearchQuery{Query: "release"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "new", results[0].Note.ID)
+	assert.Equal(t, "old", results[1].Note.ID)
+
+	// A note one half-life old gets half the boost, and a note without an
+	// update time none
+	boosted := search(newEngine(1, 24*time.Hour), SearchQuery{Query: "release"})
+	assert.InDelta(t, plain["new"]*1.5, boosted["new"], 0.001)
+	assert.InDelta(t, plain["old"], boosted["old"], 0.001)
+	assert.Equal(t, plain["undated"], boosted["undated"])
+
+	// Regex searches are boosted too
+	regex := search(engine, SearchQuery{Query: "[Rr]elease", Regex: true})
+	assert.Greater(t, regex["new"], regex["old"])
+
+	// The boost is part of the explanation, which still sums to the score
+	results, err = engine.Search(context.Background(), SearchQuery{Query: "release", Explain: true})
+	require.NoError(t, err)
+	for _, result := range results {
+		var sum float64
+		var recency *ScoreContribution
+		for i, contribution := range result.Explanation.Contributions {
+			sum += contribution.Score
+			if contribution.Match == MatchRecency {
+				recency = &result.Explanation.Contributions[i]
+			}
+		}
+		assert.InDelta(t, result.Score, sum, 1e-9, "note %s", result.Note.ID)
+		if result.Note.ID == "undated" {
+			assert.Nil(t, recency)
+		} else {
+			require.NotNil(t, recency, "note %s", result.Note.ID)
+			assert.Greater(t, recency.Weight, 1.0)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package search
+
+// How a query term matched a field
+const (
+	// MatchExact is a term found literally in the field
+	MatchExact = "exact"
+	// MatchStemmed is a term found among the field's tokens after
+	// stemming, but not literally
+	MatchStemmed = "stemmed"
+	// MatchFuzzy is a term whose first characters were found in the field
+	MatchFuzzy = "fuzzy"
+	// MatchRegex is a regular expression search's matches in the field
+	MatchRegex = "regex"
+	// MatchAll is the score every note gets from a search without query
+	// terms
+	MatchAll = "all"
+	// MatchRecency is the increase from Options.RecencyBoost; its Weight
+	// is the multiplier applied to the rest of the score
+	MatchRecency = "recency"
+)
+
+// Explanation breaks a search result's score down into what contributed to
+// it. The scores of its contributions add up to the result's score.
+type Explanation struct {
+	// Contributions are the parts of the score, by field in the order
+	// fields are scored, then by query term
+	Contributions []ScoreContribution
+
+	// Score is the result's score
+	Score float64
+}
+
+// ScoreContribution is the part of a score due to one query term matching
+// one field
+type ScoreContribution struct {
+	// Field is title, content or tags, or empty for MatchAll and
+	// MatchRecency
+	Field string
+
+	// Term is the normalized query term, or the pattern for MatchRegex
+	Term string
+
+	// Match is how the term matched: MatchExact, MatchStemmed, MatchFuzzy,
+	// MatchRegex, MatchAll or MatchRecency
+	Match string
+
+	// Hits is how often the term was found in the field; zero for a
+	// fuzzy match
+	Hits int
+
+	// Weight is the field's weight
+	Weight float64
+
+	// Score is Hits × Weight, or half the weight for a fuzzy match
+	Score float64
+}
+
+// add records a contribution and adds it to the score; it does nothing on
+// a nil explanation so scoring code can call it unconditionally
+func (x *Explanation) add(field, term, match string, hits int, weight, score float64) {
+	if x == nil {
+		return
+	}
+	x.Contributions = append(x.Contributions, ScoreContribution{
+		Field:  field,
+		Term:   term,
+		Match:  match,
+		Hits:   hits,
+		Weight: weight,
+		Score:  score,
+	})
+	x.Score += score
+}
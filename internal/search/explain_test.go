@@ -0,0 +1,90 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExplainTestEngine() *Engine {
+	engine := New(newMockStorage(), DefaultOptions())
+	engine.index.Add(&IndexedDocument{
+		ID:      "go",
+		Title:   "Go testing guide",
+		Content: "Tests run with go test. Testing tables help; tested code is trusted.",
+		Tags:    []string{"golang", "testing"},
+	})
+	engine.index.Add(&IndexedDocument{
+		ID:      "py",
+		Title:   "Python",
+		Content: "pytest runs python tests",
+		Tags:    []string{"python"},
+	})
+	return engine
+}
+
+func TestEngine_SearchExplain(t *testing.T) {
+	engine := newExplainTestEngine()
+	ctx := context.Background()
+
+	results, err := engine.Search(ctx, SearchQuery{Query: "go tests", SortBy: "title", Explain: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	goResult := results[0]
+	require.Equal(t, "go", goResult.Note.ID)
+	require.NotNil(t, goResult.Explanation)
+	assert.Equal(t, []ScoreContribution{
+		{Field: "title", Term: "go", Match: MatchExact, Hits: 1, Weight: 2, Score: 2},
+		{Field: "title", Term: "tests", Match: MatchFuzzy, Weight: 2, Score: 1},
+		{Field: "content", Term: "go", Match: MatchExact, Hits: 1, Weight: 1, Score: 1},
+		{Field: "content", Term: "tests", Match: MatchExact, Hits: 1, Weight: 1, Score: 1},
+		{Field: "tags", Term: "go", Match: MatchExact, Hits: 1, Weight: 1.5, Score: 1.5},
+		{Field: "tags", Term: "tests", Match: MatchFuzzy, Weight: 1.5, Score: 0.75},
+	}, goResult.Explanation.Contributions)
+	assert.Equal(t, goResult.Score, goResult.Explanation.Score)
+
+	pyResult := results[1]
+	assert.Equal(t, []ScoreContribution{
+		{Field: "content", Term: "tests", Match: MatchExact, Hits: 1, Weight: 1, Score: 1},
+	}, pyResult.Explanation.Contributions)
+
+	// Without Explain results carry no explanation
+	results, err = engine.Search(ctx, SearchQuery{Query: "go tests"})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.Nil(t, result.Explanation)
+	}
+}
+
+func TestEngine_SearchExplainSums(t *testing.T) {
+	engine := newExplainTestEngine()
+
+	queries := []SearchQuery{
+		{Query: "testing"},
+		{Query: "go tests"},
+		{Query: "tested python"},
+		{Query: "golang", Fields: []string{"tags"}},
+		{Query: ""},
+		{Query: `test\w*`, Regex: true},
+	}
+	for _, query := range queries {
+		query.Explain = true
+		results, err := engine.Search(context.Background(), query)
+		require.NoError(t, err)
+		require.NotEmpty(t, results, "query %+v", query)
+
+		for _, result := range results {
+			require.NotNil(t, result.Explanation, "query %+v", query)
+
+			var sum float64
+			for _, contribution := range result.Explanation.Contributions {
+				sum += contribution.Score
+			}
+			assert.InDelta(t, result.Score, sum, 1e-9, "query %q note %s", query.Query, result.Note.ID)
+			assert.InDelta(t, result.Score, result.Explanation.Score, 1e-9, "query %q note %s", query.Query, result.Note.ID)
+		}
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
-// Index provides an in-memory inverted index for fast text search
+// Index provides an in-memory inverted index for fast text search. It is
+// safe for concurrent use: lookups share a read lock and updates take the
+// write lock.
 type Index struct {
 	mu sync.RWMutex
 
@@ -21,6 +23,9 @@ type Index struct {
 	// Metadata indices
 	tagIndex  map[string]map[string]bool // tag -> document IDs
 	typeIndex map[string]map[string]bool // type -> document IDs
+
+	// tokenizer splits field content into index terms
+	tokenizer Tokenizer
 }
 
 // IndexedDocument represents a document in the search index
@@ -34,15 +39,26 @@ type IndexedDocument struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Size      int64
+
+	// ModTime is the file's modification time (Unix seconds) when it was
+	// indexed, used to detect changed files when refreshing a saved index
+	ModTime int64
 }
 
-// NewIndex creates a new search index
+// NewIndex creates a new search index using the default tokenizer
 func NewIndex() *Index {
+	return NewIndexWithTokenizer(NewTokenizer(TokenizerOptions{}))
+}
+
+// NewIndexWithTokenizer creates a new search index that splits content into
+// terms with the given tokenizer
+func NewIndexWithTokenizer(tokenizer Tokenizer) *Index {
 	return &Index{
 		terms:     make(map[string]map[string]map[string]bool),
 		documents: make(map[string]*IndexedDocument),
 		tagIndex:  make(map[string]map[string]bool),
 		typeIndex: make(map[string]map[string]bool),
+		tokenizer: tokenizer,
 	}
 }
 
@@ -233,6 +249,24 @@ func (idx *Index) Clear() {
 	idx.typeIndex = make(map[string]map[string]bool)
 }
 
+// Rebuild replaces the contents of the index with docs. The new contents
+// are built before the lock is taken, so lookups keep running against the
+// old contents until they are swapped in.
+func (idx *Index) Rebuild(docs []*IndexedDocument) {
+	fresh := NewIndexWithTokenizer(idx.tokenizer)
+	for _, doc := range docs {
+		fresh.Add(doc)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.terms = fresh.terms
+	idx.documents = fresh.documents
+	idx.tagIndex = fresh.tagIndex
+	idx.typeIndex = fresh.typeIndex
+}
+
 // indexField indexes the content of a field for a document
 func (idx *Index) indexField(docID, field, content string) {
 	// Tokenize content
@@ -254,28 +288,7 @@ func (idx *Index) indexField(docID, field, content string) {
 
 // tokenize splits text into indexable tokens
 func (idx *Index) tokenize(text string) []string {
-	text = strings.ToLower(text)
-
-	// Simple tokenization - split on non-alphanumeric characters
-	var tokens []string
-	var current strings.Builder
-
-	for _, r := range text {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
-			current.WriteRune(r)
-		} else {
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-		}
-	}
-
-	if current.Len() > 0 {
-		tokens = append(tokens, current.String())
-	}
-
-	return tokens
+	return idx.tokenizer.Tokenize(text)
 }
 
 // ToMetadata converts an IndexedDocument to NoteMetadata
@@ -327,3 +327,23 @@ func TestIndex_ConcurrentAccess(t *testing.T) {
 		assert.Equal(t, 10, count)
 	}
 }
+
+func TestIndex_Rebuild(t *testing.T) {
+	idx := NewIndexWithTokenizer(NewTokenizer(TokenizerOptions{EnableStemming: true}))
+	idx.Add(&IndexedDocument{ID: "old", Title: "Old document", Tags: []string{"stale"}})
+
+	idx.Rebuild([]*IndexedDocument{
+		{ID: "a", Title: "Running notes", Tags: []string{"fresh"}, Type: "note"},
+		{ID: "b", Title: "Other", Tags: []string{"fresh"}},
+	})
+
+	assert.Equal(t, 2, idx.Size())
+	_, exists := idx.GetDocument("old")
+	assert.False(t, exists)
+	assert.Empty(t, idx.SearchByTag("stale"))
+	assert.Len(t, idx.SearchByTag("fresh"), 2)
+	assert.Len(t, idx.SearchByType("note"), 1)
+
+	// The rebuilt contents use the index's tokenizer
+	assert.Len(t, idx.Search("run", "title"), 1)
+}
@@ -0,0 +1,195 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// IndexPath is where the search index is saved in vault storage
+const IndexPath = ".kbvault/search-index.json"
+
+// indexFormatVersion is bumped whenever the saved layout changes; saved
+// indexes with another version are ignored and rebuilt. Version 2 stopped
+// recording the indexing time for notes without frontmatter dates.
+const indexFormatVersion = 2
+
+// savedIndex is the on-disk form of the index. Only documents are saved;
+// the term, tag and type indices are rebuilt from them on load.
+type savedIndex struct {
+	Version      int                 `json:"version"`
+	TitleSources []types.TitleSource `json:"title_sources,omitempty"`
+	Documents    []*IndexedDocument  `json:"documents"`
+}
+
+// SaveIndex writes the index to IndexPath in storage
+func (e *Engine) SaveIndex(ctx context.Context) error {
+	docs := e.index.GetAllDocuments()
+	sort.Slice(docs, func(i, j int) bool { return docs[i].FilePath < docs[j].FilePath })
+
+	data, err := json.Marshal(savedIndex{
+		Version:      indexFormatVersion,
+		TitleSources: e.options.TitleSources,
+		Documents:    docs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+
+	if err := e.storage.Write(ctx, IndexPath, data); err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	return nil
+}
+
+// LoadIndex replaces the index contents with the index saved in storage.
+// It reports false, without an error, when there is no saved index or the
+// saved one can't be used: it is unreadable, from another format version,
+// or was built with different title sources.
+func (e *Engine) LoadIndex(ctx context.Context) (bool, error) {
+	exists, err := e.storage.Exists(ctx, IndexPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to check search index: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	data, err := e.storage.Read(ctx, IndexPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	var saved savedIndex
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return false, nil
+	}
+	if saved.Version != indexFormatVersion || !sameTitleSources(saved.TitleSources, e.options.TitleSources) {
+		return false, nil
+	}
+
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
+
+	e.index.Rebuild(saved.Documents)
+	return true, nil
+}
+
+// RefreshIndex brings the index up to date with storage without a full
+// rebuild: notes that are new or whose size or modification time changed
+// are re-read, and documents whose files are gone are removed. It reports
+// whether anything changed. When ctx is done, the notes read so far are
+// kept, nothing is removed and ctx's error is returned.
+func (e *Engine) RefreshIndex(ctx context.Context) (bool, error) {
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
+
+	indexed := make(map[string]*IndexedDocument)
+	for _, doc := range e.index.GetAllDocuments() {
+		indexed[doc.FilePath] = doc
+	}
+
+	changed := false
+	seen := make(map[string]bool)
+	files, infos := e.noteFiles(ctx)
+	for _, file := range files {
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		if doc, ok := indexed[file]; ok {
+			if info := infos[file]; info.Size == doc.Size && info.ModTime == doc.ModTime {
+				continue
+			}
+		}
+
+		doc, err := e.readDocument(ctx, file)
+		if err != nil {
+			continue
+		}
+		e.index.Add(doc)
+		changed = true
+	}
+
+	// A listing cut short would otherwise look like deleted notes
+	if err := ctx.Err(); err != nil {
+		return changed, err
+	}
+
+	for path, doc := range indexed {
+		if !seen[path] {
+			e.index.Remove(doc.ID)
+			changed = true
+		}
+	}
+
+	e.syncedAt.Store(time.Now().UnixNano())
+	return changed, nil
+}
+
+// IndexFile reads the note at path from storage and adds or updates it in
+// the index
+func (e *Engine) IndexFile(ctx context.Context, path string) error {
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
+
+	doc, err := e.readDocument(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to index %s: %w", path, err)
+	}
+	e.index.Add(doc)
+	return nil
+}
+
+// RemoveFile removes the document indexed from path, if any
+func (e *Engine) RemoveFile(ctx context.Context, path string) error {
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
+
+	for _, doc := range e.index.GetAllDocuments() {
+		if doc.FilePath == path {
+			e.index.Remove(doc.ID)
+		}
+	}
+	return nil
+}
+
+// DocumentByPath returns the document indexed from path
+func (e *Engine) DocumentByPath(path string) (*IndexedDocument, bool) {
+	for _, doc := range e.index.GetAllDocuments() {
+		if doc.FilePath == path {
+			return doc, true
+		}
+	}
+	return nil, false
+}
+
+// DocumentByID returns the indexed document with the given note ID
+func (e *Engine) DocumentByID(id string) (*IndexedDocument, bool) {
+	return e.index.GetDocument(id)
+}
+
+// Documents returns every indexed document, ordered by file path
+func (e *Engine) Documents() []*IndexedDocument {
+	docs := e.index.GetAllDocuments()
+	sort.Slice(docs, func(i, j int) bool { return docs[i].FilePath < docs[j].FilePath })
+	return docs
+}
+
+// sameTitleSources reports whether a and b resolve titles the same way
+func sameTitleSources(a, b []types.TitleSource) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func newLocalTestStorage(t *testing.T, notes map[string]string) (types.StorageBackend, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	backend, err := local.New(types.LocalStorageConfig{Path: dir, CreateDirs: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	for path, content := range notes {
+		require.NoError(t, backend.Write(context.Background(), path, []byte(content)))
+	}
+	return backend, dir
+}
+
+func TestEngine_SaveAndLoadIndex(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := newLocalTestStorage(t, map[string]string{
+		"notes/a.md": "---\ntags: [go]\n---\n# Alpha\n\nconcurrency patterns\n",
+		"notes/b.md": "# Beta\n\nunrelated\n",
+	})
+
+	engine := New(backend, DefaultOptions())
+	loaded, err := engine.LoadIndex(ctx)
+	require.NoError(t, err)
+	assert.False(t, loaded, "no index saved yet")
+
+	require.NoError(t, engine.BuildIndex(ctx))
+	require.NoError(t, engine.SaveIndex(ctx))
+
+	// A fresh engine searches the saved index without reading any notes
+	require.NoError(t, backend.Delete(ctx, "notes/a.md"))
+	restored := New(backend, DefaultOptions())
+	loaded, err = restored.LoadIndex(ctx)
+	require.NoError(t, err)
+	require.True(t, loaded)
+	assert.Equal(t, 2, restored.index.Size())
+
+	results, err := restored.Search(ctx, SearchQuery{Query: "concurrency", Tags: []string{"go"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Alpha", results[0].Note.Title)
+}
+
+func TestEngine_LoadIndexIgnoresUnusableIndex(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("corrupt", func(t *testing.T) {
+		backend, _ := newLocalTestStorage(t, map[string]string{IndexPath: "{not json"})
+		loaded, err := New(backend, DefaultOptions()).LoadIndex(ctx)
+		require.NoError(t, err)
+		assert.False(t, loaded)
+	})
+
+	t.Run("other version", func(t *testing.T) {
+		backend, _ := newLocalTestStorage(t, map[string]string{IndexPath: `{"version":99,"documents":[]}`})
+		loaded, err := New(backend, DefaultOptions()).LoadIndex(ctx)
+		require.NoError(t, err)
+		assert.False(t, loaded)
+	})
+
+	t.Run("different title sources", func(t *testing.T) {
+		backend, _ := newLocalTestStorage(t, map[string]string{"a.md": "# A\n"})
+		engine := New(backend, DefaultOptions())
+		require.NoError(t, engine.BuildIndex(ctx))
+		require.NoError(t, engine.SaveIndex(ctx))
+
+		opts := DefaultOptions()
+		opts.TitleSources = []types.TitleSource{types.TitleSourceFilename}
+		loaded, err := New(backend, opts).LoadIndex(ctx)
+		require.NoError(t, err)
+		assert.False(t, loaded)
+	})
+}
+
+func TestEngine_RefreshIndex(t *testing.T) {
+	ctx := context.Background()
+	backend, dir := newLocalTestStorage(t, map[string]string{
+		"notes/keep.md":   "# Keep\n",
+		"notes/change.md": "# Change\n\nbefore\n",
+		"notes/remove.md": "# Remove\n",
+	})
+
+	engine := New(backend, DefaultOptions())
+	require.NoError(t, engine.BuildIndex(ctx))
+
+	changed, err := engine.RefreshIndex(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed, "nothing changed since the build")
+
+	// Same size, newer modification time
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "change.md"), []byte("# Change\n\nafter!\n"), 0644))
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "notes", "change.md"), later, later))
+	require.NoError(t, backend.Delete(ctx, "notes/remove.md"))
+	require.NoError(t, backend.Write(ctx, "notes/add.md", []byte("# Add\n")))
+
+	changed, err = engine.RefreshIndex(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	contents := make(map[string]string)
+	for _, doc := range engine.index.GetAllDocuments() {
+		contents[doc.FilePath] = doc.Content
+	}
+	assert.Len(t, contents, 3)
+	assert.Contains(t, contents, "notes/keep.md")
+	assert.Contains(t, contents, "notes/add.md")
+	assert.Contains(t, contents["notes/change.md"], "after!")
+}
+
+func TestEngine_IndexAndRemoveFile(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := newLocalTestStorage(t, map[string]string{"notes/a.md": "# A\n\nfirst\n"})
+
+	engine := New(backend, DefaultOptions())
+	require.NoError(t, engine.IndexFile(ctx, "notes/a.md"))
+
+	doc, ok := engine.index.GetDocument("a")
+	require.True(t, ok)
+	assert.NotZero(t, doc.ModTime)
+
+	assert.Error(t, engine.IndexFile(ctx, "notes/missing.md"))
+
+	require.NoError(t, engine.RemoveFile(ctx, "notes/a.md"))
+	assert.Equal(t, 0, engine.index.Size())
+}
@@ -0,0 +1,156 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxRegexMatchesPerField caps the matches reported for one field of one
+// note so that a broad pattern such as "." doesn't return every character.
+// All matches still count towards the score.
+const maxRegexMatchesPerField = 20
+
+// matchRegex matches query.Query as a regular expression against every
+// indexed document, bypassing the term index, and passes each matching note
+// to yield until it returns false. Each match records the line it was found
+// on and the line around it as context; matches and snippets are only built
+// when detailed is set.
+func (e *Engine) matchRegex(ctx context.Context, query SearchQuery, detailed bool, yield func(SearchResult) bool) error {
+	if strings.TrimSpace(query.Query) == "" {
+		return fmt.Errorf("regex search requires a pattern")
+	}
+
+	pattern, err := regexp.Compile(query.Query)
+	if err != nil {
+		return fmt.Errorf("invalid regular expression %q: %w", query.Query, err)
+	}
+
+	if e.index.Size() == 0 {
+		if err := e.buildIfEmpty(ctx); err != nil {
+			return err
+		}
+	}
+
+	fields := query.Fields
+	if len(fields) == 0 || contains(fields, "all") {
+		fields = []string{"title", "content", "tags"}
+	}
+
+	for _, doc := range e.index.GetAllDocuments() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !e.matchesFilters(doc, query) {
+			continue
+		}
+
+		var (
+			matches     []Match
+			count       int
+			explanation *Explanation
+		)
+		if query.Explain {
+			explanation = &Explanation{}
+		}
+		keep := 0
+		if detailed {
+			keep = maxRegexMatchesPerField
+		}
+		for _, field := range fields {
+			fieldMatches, n := e.regexMatches(pattern, field, fieldText(doc, field), keep)
+			matches = append(matches, fieldMatches...)
+			count += n
+			if n > 0 {
+				explanation.add(field, query.Query, MatchRegex, n, 1, float64(n))
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		result := SearchResult{
+			Note:        doc.ToMetadata(),
+			Score:       e.boostRecency(float64(count), doc.UpdatedAt, explanation),
+			Explanation: explanation,
+		}
+		if detailed {
+			result.Matches = matches
+			result.Snippet = e.regexSnippet(doc, matches)
+		}
+		if !yield(result) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// regexMatches finds pattern in text line by line. It returns up to keep
+// matches and the total number found.
+func (e *Engine) regexMatches(pattern *regexp.Regexp, field, text string, keep int) ([]Match, int) {
+	var (
+		matches []Match
+		count   int
+		offset  int
+	)
+
+	for i, line := range strings.Split(text, "\n") {
+		for _, loc := range pattern.FindAllStringIndex(line, -1) {
+			// Empty matches, such as from "^" or "x*", carry no span
+			if loc[0] == loc[1] {
+				continue
+			}
+			count++
+			if len(matches) < keep {
+				matches = append(matches, Match{
+					Field:    field,
+					Line:     i + 1,
+					Position: offset + loc[0],
+					Length:   loc[1] - loc[0],
+					Context:  strings.TrimSpace(e.extractContext(line, loc[0], loc[1]-loc[0])),
+				})
+			}
+		}
+		offset += len(line) + 1
+	}
+
+	return matches, count
+}
+
+// regexSnippet highlights the matches of the first matched field,
+// preferring content. Regex positions refer to the original text, so
+// unlike generateSnippet no case folding is involved.
+func (e *Engine) regexSnippet(doc *IndexedDocument, matches []Match) string {
+	field := matches[0].Field
+	for _, match := range matches {
+		if match.Field == "content" {
+			field = "content"
+			break
+		}
+	}
+
+	var fieldMatches []Match
+	for _, match := range matches {
+		if match.Field == field {
+			fieldMatches = append(fieldMatches, match)
+		}
+	}
+
+	return buildSnippet(fieldText(doc, field), fieldMatches, e.options.Snippet)
+}
+
+// fieldText returns the searchable text of a document field
+func fieldText(doc *IndexedDocument, field string) string {
+	switch field {
+	case "title":
+		return doc.Title
+	case "tags":
+		return strings.Join(doc.Tags, " ")
+	case "content":
+		return doc.Content
+	default:
+		return ""
+	}
+}
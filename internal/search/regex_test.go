@@ -0,0 +1,102 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegexTestEngine() *Engine {
+	engine := New(newMockStorage(), DefaultOptions())
+	engine.index.Add(&IndexedDocument{
+		ID:       "todo",
+		Title:    "Release TODO list",
+		Content:  "# Release\n\nTODO(alice): tag v1.2\nnothing here\nTODO(bob): update docs",
+		Tags:     []string{"release"},
+		FilePath: "notes/todo.md",
+	})
+	engine.index.Add(&IndexedDocument{
+		ID:       "other",
+		Title:    "Other",
+		Content:  "No tasks in this note.",
+		Tags:     []string{"misc"},
+		FilePath: "notes/other.md",
+	})
+	return engine
+}
+
+func TestEngine_RegexSearch(t *testing.T) {
+	engine := newRegexTestEngine()
+
+	results, err := engine.Search(context.Background(), SearchQuery{Query: `TODO\((\w+)\)`, Regex: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "todo", results[0].Note.ID)
+	assert.Equal(t, 2.0, results[0].Score)
+
+	matches := results[0].Matches
+	require.Len(t, matches, 2)
+	assert.Equal(t, Match{Field: "content", Line: 3, Position: 11, Length: 11, Context: "TODO(alice): tag v1.2"}, matches[0])
+	assert.Equal(t, 5, matches[1].Line)
+	assert.Equal(t, "TODO(bob): update docs", matches[1].Context)
+	assert.Contains(t, results[0].Snippet, "TODO(alice)")
+}
+
+func TestEngine_RegexSearchFields(t *testing.T) {
+	engine := newRegexTestEngine()
+	ctx := context.Background()
+
+	// "TODO" appears in the title of one note and the content of the same
+	// note; restricting to titles reports only the title match
+	results, err := engine.Search(ctx, SearchQuery{Query: "TODO", Regex: true, Fields: []string{"title"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Matches, 1)
+	assert.Equal(t, "title", results[0].Matches[0].Field)
+	assert.Equal(t, 1, results[0].Matches[0].Line)
+
+	results, err = engine.Search(ctx, SearchQuery{Query: "^misc$", Regex: true, Fields: []string{"tags"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "other", results[0].Note.ID)
+
+	// Patterns are case-sensitive unless they say otherwise
+	results, err = engine.Search(ctx, SearchQuery{Query: "todo", Regex: true})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	results, err = engine.Search(ctx, SearchQuery{Query: "(?i)todo", Regex: true})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestEngine_RegexSearchLimitAndCap(t *testing.T) {
+	engine := newRegexTestEngine()
+	ctx := context.Background()
+
+	results, err := engine.Search(ctx, SearchQuery{Query: "o", Regex: true, Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// Every matching character counts, but only the first few are reported
+	results, err = engine.Search(ctx, SearchQuery{Query: ".", Regex: true, Fields: []string{"content"}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.LessOrEqual(t, len(result.Matches), maxRegexMatchesPerField)
+		assert.Greater(t, result.Score, float64(maxRegexMatchesPerField))
+	}
+}
+
+func TestEngine_RegexSearchErrors(t *testing.T) {
+	engine := newRegexTestEngine()
+	ctx := context.Background()
+
+	_, err := engine.Search(ctx, SearchQuery{Query: "TODO(", Regex: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid regular expression "TODO("`)
+
+	_, err = engine.Search(ctx, SearchQuery{Query: " ", Regex: true})
+	require.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetSeparator joins snippets taken from different parts of a field
+const snippetSeparator = "…"
+
+// SnippetOptions controls how result snippets are built and highlighted
+type SnippetOptions struct {
+	// PreTag and PostTag surround each highlighted match
+	PreTag  string
+	PostTag string
+
+	// MaxSnippets limits the number of context windows per result
+	MaxSnippets int
+
+	// WindowSize is the number of characters of context kept on either
+	// side of a match
+	WindowSize int
+
+	// EscapeHTML escapes the snippet text so it can be embedded in HTML
+	EscapeHTML bool
+}
+
+// DefaultSnippetOptions returns plain snippets without highlighting
+func DefaultSnippetOptions() SnippetOptions {
+	return SnippetOptions{
+		MaxSnippets: 1,
+		WindowSize:  40,
+	}
+}
+
+// TerminalSnippetOptions highlights matches with markdown-style bold markers
+func TerminalSnippetOptions() SnippetOptions {
+	return SnippetOptions{
+		PreTag:      "**",
+		PostTag:     "**",
+		MaxSnippets: 3,
+		WindowSize:  40,
+	}
+}
+
+// HTMLSnippetOptions highlights matches with <mark> elements and escapes
+// the surrounding text
+func HTMLSnippetOptions() SnippetOptions {
+	return SnippetOptions{
+		PreTag:      "<mark>",
+		PostTag:     "</mark>",
+		MaxSnippets: 3,
+		WindowSize:  40,
+		EscapeHTML:  true,
+	}
+}
+
+// span is a byte range within a field
+type span struct {
+	start, end int
+}
+
+// buildSnippet highlights matches within text and returns up to
+// MaxSnippets context windows joined with an ellipsis. Overlapping and
+// adjacent matches are merged into a single highlight.
+func buildSnippet(text string, matches []Match, opts SnippetOptions) string {
+	if opts.MaxSnippets <= 0 {
+		opts.MaxSnippets = 1
+	}
+	if opts.WindowSize < 0 {
+		opts.WindowSize = 0
+	}
+
+	highlights := mergeSpans(text, matches)
+	if len(highlights) == 0 {
+		return ""
+	}
+
+	// Group highlights into windows, extending a window while the next
+	// highlight starts inside it
+	var windows [][]span
+	var bounds []span
+	for _, h := range highlights {
+		start := runesBefore(text, h.start, opts.WindowSize)
+		end := runesAfter(text, h.end, opts.WindowSize)
+
+		if n := len(bounds); n > 0 && start <= bounds[n-1].end {
+			bounds[n-1].end = max(bounds[n-1].end, end)
+			windows[n-1] = append(windows[n-1], h)
+			continue
+		}
+		if len(bounds) == opts.MaxSnippets {
+			break
+		}
+		bounds = append(bounds, span{start: start, end: end})
+		windows = append(windows, []span{h})
+	}
+
+	var b strings.Builder
+	if bounds[0].start > 0 {
+		b.WriteString(snippetSeparator)
+	}
+	for i, window := range windows {
+		if i > 0 {
+			b.WriteString(" " + snippetSeparator + " ")
+		}
+
+		pos := bounds[i].start
+		for _, h := range window {
+			b.WriteString(escapeSnippet(text[pos:h.start], opts))
+			b.WriteString(opts.PreTag)
+			b.WriteString(escapeSnippet(text[h.start:h.end], opts))
+			b.WriteString(opts.PostTag)
+			pos = h.end
+		}
+		b.WriteString(escapeSnippet(text[pos:bounds[i].end], opts))
+	}
+	if bounds[len(bounds)-1].end < len(text) {
+		b.WriteString(snippetSeparator)
+	}
+
+	return b.String()
+}
+
+// mergeSpans converts matches to sorted, non-overlapping byte ranges,
+// dropping any that fall outside text
+func mergeSpans(text string, matches []Match) []span {
+	spans := make([]span, 0, len(matches))
+	for _, m := range matches {
+		start, end := m.Position, m.Position+m.Length
+		if m.Length <= 0 || start < 0 || end > len(text) {
+			continue
+		}
+		spans = append(spans, span{start: runeStart(text, start), end: runeEnd(text, end)})
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var merged []span
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, s.end)
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	return merged
+}
+
+// runeStart moves pos back to the start of the rune containing it
+func runeStart(text string, pos int) int {
+	for pos > 0 && pos < len(text) && !utf8.RuneStart(text[pos]) {
+		pos--
+	}
+	return pos
+}
+
+// runeEnd moves pos forward past the end of the rune containing it
+func runeEnd(text string, pos int) int {
+	for pos < len(text) && !utf8.RuneStart(text[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// runesBefore returns the offset n runes before pos, stopping at the start
+// of text. pos must be on a rune boundary.
+func runesBefore(text string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(text[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+// runesAfter returns the offset n runes after pos, stopping at the end of
+// text. pos must be on a rune boundary.
+func runesAfter(text string, pos, n int) int {
+	for ; n > 0 && pos < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[pos:])
+		pos += size
+	}
+	return pos
+}
+
+// truncateRunes shortens text to at most n runes, adding an ellipsis when
+// anything was cut
+func truncateRunes(text string, n int) string {
+	if end := runesAfter(text, 0, n); end < len(text) {
+		return text[:end] + "..."
+	}
+	return text
+}
+
+func escapeSnippet(text string, opts SnippetOptions) string {
+	if opts.EscapeHTML {
+		return html.EscapeString(text)
+	}
+	return text
+}
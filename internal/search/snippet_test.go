@@ -0,0 +1,236 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSnippet(t *testing.T) {
+	tags := SnippetOptions{PreTag: "[", PostTag: "]", MaxSnippets: 3, WindowSize: 5}
+
+	tests := []struct {
+		name     string
+		text     string
+		matches  []Match
+		opts     SnippetOptions
+		expected string
+	}{
+		{
+			name:     "single match",
+			text:     "learn golang today",
+			matches:  []Match{{Position: 6, Length: 6}},
+			opts:     tags,
+			expected: "…earn [golang] toda…",
+		},
+		{
+			name: "overlapping matches are merged",
+			text: "learn golang today",
+			matches: []Match{
+				{Position: 6, Length: 2},
+				{Position: 6, Length: 6},
+				{Position: 8, Length: 4},
+			},
+			opts:     tags,
+			expected: "…earn [golang] toda…",
+		},
+		{
+			name: "adjacent matches are merged",
+			text: "gopher",
+			matches: []Match{
+				{Position: 0, Length: 2},
+				{Position: 2, Length: 4},
+			},
+			opts:     tags,
+			expected: "[gopher]",
+		},
+		{
+			name: "nearby matches share a window",
+			text: "go and go",
+			matches: []Match{
+				{Position: 0, Length: 2},
+				{Position: 7, Length: 2},
+			},
+			opts:     tags,
+			expected: "[go] and [go]",
+		},
+		{
+			name: "distant matches get separate windows",
+			text: "go " + strings.Repeat("x", 30) + " go",
+			matches: []Match{
+				{Position: 0, Length: 2},
+				{Position: 34, Length: 2},
+			},
+			opts:     tags,
+			expected: "[go] xxxx … xxxx [go]",
+		},
+		{
+			name: "max snippets",
+			text: "go " + strings.Repeat("x", 30) + " go",
+			matches: []Match{
+				{Position: 0, Length: 2},
+				{Position: 34, Length: 2},
+			},
+			opts:     SnippetOptions{PreTag: "[", PostTag: "]", MaxSnippets: 1, WindowSize: 5},
+			expected: "[go] xxxx…",
+		},
+		{
+			name:     "html escaping",
+			text:     "a <b> & go",
+			matches:  []Match{{Position: 8, Length: 2}},
+			opts:     HTMLSnippetOptions(),
+			expected: "a &lt;b&gt; &amp; <mark>go</mark>",
+		},
+		{
+			name:     "multibyte text",
+			text:     "café go",
+			matches:  []Match{{Position: 6, Length: 2}},
+			opts:     SnippetOptions{PreTag: "[", PostTag: "]", MaxSnippets: 1, WindowSize: 3},
+			expected: "…fé [go]",
+		},
+		{
+			name:     "out of range matches are ignored",
+			text:     "short",
+			matches:  []Match{{Position: 3, Length: 10}},
+			opts:     tags,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildSnippet(tt.text, tt.matches, tt.opts))
+		})
+	}
+}
+
+func TestEngine_SearchSnippetHighlighting(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Snippet = TerminalSnippetOptions()
+	engine := New(newMockStorage(), opts)
+
+	engine.index.Add(&IndexedDocument{
+		ID:      "1",
+		Title:   "Concurrency",
+		Content: "Goroutines are cheap. Use Goroutines with channels.",
+	})
+
+	results, err := engine.Search(context.Background(), SearchQuery{Query: "goroutines"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "**Goroutines** are cheap. Use **Goroutines** with channels.", results[0].Snippet)
+	}
+}
+
+// trimEllipses strips the truncation markers extractContext and
+// generateSnippet add
+func trimEllipses(s string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(s, "..."), "...")
+}
+
+func TestEngine_ExtractContextMultibyte(t *testing.T) {
+	text := strings.Repeat("日本語", 20) + " goroutines " + strings.Repeat("été ", 20)
+	pos := strings.Index(text, "goroutines")
+
+	for _, size := range []int{1, 5, 13, 40} {
+		opts := DefaultOptions()
+		opts.ContextSize = size
+		engine := New(newMockStorage(), opts)
+
+		// Positions that land inside a multibyte character are widened to
+		// whole characters
+		for _, p := range []int{pos, pos - 2, 1} {
+			context := engine.extractContext(text, p, len("goroutines"))
+			require.True(t, utf8.ValidString(context), "size %d pos %d: %q", size, p, context)
+		}
+
+		context := engine.extractContext(text, pos, len("goroutines"))
+		before, after, found := strings.Cut(trimEllipses(context), "goroutines")
+		require.True(t, found, context)
+		assert.Equal(t, size, utf8.RuneCountInString(before))
+		assert.Equal(t, size, utf8.RuneCountInString(after))
+	}
+}
+
+func TestEngine_ContextSizeDefault(t *testing.T) {
+	engine := New(newMockStorage(), Options{})
+	text := strings.Repeat("é", 100) + "go" + strings.Repeat("ü", 100)
+
+	context := engine.extractContext(text, 200, 2)
+	assert.Equal(t, "..."+strings.Repeat("é", DefaultContextSize)+"go"+strings.Repeat("ü", DefaultContextSize)+"...", context)
+}
+
+func TestEngine_SnippetLengthMultibyte(t *testing.T) {
+	content := strings.Repeat("κόσμε ", 100)
+
+	for _, length := range []int{1, 7, 50, DefaultSnippetLength} {
+		opts := DefaultOptions()
+		opts.SnippetLength = length
+		engine := New(newMockStorage(), opts)
+
+		snippet := engine.generateSnippet(&IndexedDocument{Content: content}, nil)
+		assert.True(t, utf8.ValidString(snippet), snippet)
+		assert.True(t, strings.HasSuffix(snippet, "..."))
+		assert.Equal(t, length, utf8.RuneCountInString(trimEllipses(snippet)))
+	}
+
+	// Short content is returned whole
+	engine := New(newMockStorage(), DefaultOptions())
+	assert.Equal(t, "κόσμε", engine.generateSnippet(&IndexedDocument{Content: "κόσμε"}, nil))
+}
+
+func TestBuildSnippet_MultibyteWindow(t *testing.T) {
+	text := strings.Repeat("日本", 30) + "go" + strings.Repeat("日本", 30)
+	pos := strings.Index(text, "go")
+	opts := SnippetOptions{PreTag: "[", PostTag: "]", MaxSnippets: 1, WindowSize: 7}
+
+	snippet := buildSnippet(text, []Match{{Position: pos, Length: 2}}, opts)
+	assert.True(t, utf8.ValidString(snippet))
+	assert.Equal(t, snippetSeparator+"本日本日本日本[go]日本日本日本日"+snippetSeparator, snippet)
+}
+
+func TestEngine_SearchMultilingualSnippets(t *testing.T) {
+	storage := newMockStorage()
+	ctx := context.Background()
+
+	line := "東京の会議 meeting 🎉🚀 résumé 日本語のメモ 👩‍💻 "
+	note := "---\ntitle: 会議メモ 🎉\n---\n\n" + strings.Repeat(line, 12) + "\n" + strings.Repeat("絵文字😀", 40) + "\n"
+	require.NoError(t, storage.Write(ctx, "multilingual.md", []byte(note)))
+
+	queries := []SearchQuery{
+		{Query: "meeting"},
+		{Query: "résumé"},
+		{}, // no text query, so the snippet is the note's opening text
+		{Query: "日本語のメモ"},
+		{Query: "🎉🚀", Regex: true},
+		{Query: "会議", Regex: true},
+		{Query: "😀+", Regex: true},
+	}
+
+	for size := 1; size <= 9; size++ {
+		opts := DefaultOptions()
+		opts.ContextSize = size
+		opts.SnippetLength = size * 11
+		opts.Snippet = TerminalSnippetOptions()
+		opts.Snippet.WindowSize = size
+		engine := New(storage, opts)
+		require.NoError(t, engine.BuildIndex(ctx))
+
+		for _, query := range queries {
+			results, err := engine.Search(ctx, query)
+			require.NoError(t, err, query.Query)
+			require.NotEmpty(t, results, "size %d query %q", size, query.Query)
+
+			for _, result := range results {
+				assert.True(t, utf8.ValidString(result.Snippet), "size %d query %q snippet %q", size, query.Query, result.Snippet)
+				for _, match := range result.Matches {
+					assert.True(t, utf8.ValidString(match.Context), "size %d query %q context %q", size, query.Query, match.Context)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenizer splits text into the terms stored in and looked up from the
+// search index. The same tokenizer must be used at index and query time so
+// that both sides produce comparable terms.
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// TokenizerOptions configures the built-in text tokenizer
+type TokenizerOptions struct {
+	// CaseSensitive keeps the original case of tokens
+	CaseSensitive bool
+
+	// RemoveStopWords drops common words that carry little meaning
+	RemoveStopWords bool
+
+	// StopWords overrides the default English stop-word list
+	StopWords []string
+
+	// EnableStemming reduces words to their stem (e.g. "running" -> "run")
+	EnableStemming bool
+}
+
+// defaultStopWords is a small list of common English words
+var defaultStopWords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for",
+	"from", "has", "have", "he", "her", "his", "i", "if", "in", "into",
+	"is", "it", "its", "no", "not", "of", "on", "or", "our", "she",
+	"so", "such", "that", "the", "their", "then", "there", "these", "they", "this",
+	"to", "was", "we", "were", "what", "when", "which", "who", "will", "with",
+	"you", "your",
+}
+
+// DefaultStopWords returns a copy of the default English stop-word list
+func DefaultStopWords() []string {
+	return append([]string(nil), defaultStopWords...)
+}
+
+// textTokenizer splits on non-alphanumeric characters and optionally
+// removes stop words and applies Porter stemming
+type textTokenizer struct {
+	caseSensitive bool
+	stopWords     map[string]bool
+	stem          bool
+}
+
+// NewTokenizer creates the built-in tokenizer
+func NewTokenizer(opts TokenizerOptions) Tokenizer {
+	t := &textTokenizer{
+		caseSensitive: opts.CaseSensitive,
+		stem:          opts.EnableStemming,
+	}
+
+	if opts.RemoveStopWords {
+		words := opts.StopWords
+		if words == nil {
+			words = defaultStopWords
+		}
+		t.stopWords = make(map[string]bool, len(words))
+		for _, word := range words {
+			t.stopWords[strings.ToLower(word)] = true
+		}
+	}
+
+	return t
+}
+
+// Tokenize splits text into terms
+func (t *textTokenizer) Tokenize(text string) []string {
+	if !t.caseSensitive {
+		text = strings.ToLower(text)
+	}
+
+	// Split on word boundaries
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		lower := word
+		if t.caseSensitive {
+			lower = strings.ToLower(word)
+		}
+
+		if t.stopWords[lower] {
+			continue
+		}
+
+		// The stemmer works on lowercase words; mixed-case tokens in
+		// case-sensitive mode are kept as written
+		if t.stem && lower == word {
+			word = Stem(word)
+		}
+
+		tokens = append(tokens, word)
+	}
+
+	return tokens
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"running":        "run",
+		"hopping":        "hop",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"hopefulness":    "hope",
+		"adjustment":     "adjust",
+		"controlling":    "control",
+		"dogs":           "dog",
+		"go":             "go",
+		"Running":        "Running",
+		"café":           "café",
+	}
+
+	for word, expected := range tests {
+		t.Run(word, func(t *testing.T) {
+			assert.Equal(t, expected, Stem(word))
+		})
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     TokenizerOptions
+		input    string
+		expected []string
+	}{
+		{
+			name:     "default",
+			input:    "The Running Dogs",
+			expected: []string{"the", "running", "dogs"},
+		},
+		{
+			name:     "stop words",
+			opts:     TokenizerOptions{RemoveStopWords: true},
+			input:    "The dog and a cat",
+			expected: []string{"dog", "cat"},
+		},
+		{
+			name:     "custom stop words",
+			opts:     TokenizerOptions{RemoveStopWords: true, StopWords: []string{"Dog"}},
+			input:    "The dog and a cat",
+			expected: []string{"the", "and", "a", "cat"},
+		},
+		{
+			name:     "stemming",
+			opts:     TokenizerOptions{EnableStemming: true},
+			input:    "running dogs",
+			expected: []string{"run", "dog"},
+		},
+		{
+			name:     "case sensitive",
+			opts:     TokenizerOptions{CaseSensitive: true, RemoveStopWords: true, EnableStemming: true},
+			input:    "The Running dogs",
+			expected: []string{"Running", "dog"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewTokenizer(tt.opts).Tokenize(tt.input))
+		})
+	}
+}
+
+func TestEngine_SearchWithStemming(t *testing.T) {
+	opts := DefaultOptions()
+	opts.RemoveStopWords = true
+	opts.EnableStemming = true
+	engine := New(newMockStorage(), opts)
+
+	engine.index.Add(&IndexedDocument{
+		ID:      "dogs",
+		Title:   "Park notes",
+		Content: "Watched the run dog in the park",
+	})
+	engine.index.Add(&IndexedDocument{
+		ID:      "cats",
+		Title:   "Cat notes",
+		Content: "A sleeping cat",
+	})
+
+	ctx := context.Background()
+
+	results, err := engine.Search(ctx, SearchQuery{Query: "running dogs"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "dogs", results[0].Note.ID)
+
+	// Stop words alone match nothing
+	results, err = engine.Search(ctx, SearchQuery{Query: "the a"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Without stemming the inflected query does not match
+	plain := New(newMockStorage(), DefaultOptions())
+	plain.index.Add(&IndexedDocument{ID: "dogs", Content: "run dog"})
+	results, err = plain.Search(ctx, SearchQuery{Query: "running dogs"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -39,6 +40,46 @@ type TemplateData struct {
 	Custom    map[string]interface{}
 }
 
+// Variable describes a field of TemplateData that templates can use
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Variables lists the TemplateData fields, in declaration order
+var Variables = []Variable{
+	{Name: ".ID", Description: "Note ID"},
+	{Name: ".Title", Description: "Note title"},
+	{Name: ".Tags", Description: "Note tags, a list; use join .Tags \", \""},
+	{Name: ".Type", Description: "Note type"},
+	{Name: ".Created", Description: "Creation time; use .Created.Format \"2006-01-02\""},
+	{Name: ".Updated", Description: "Last update time"},
+	{Name: ".Now", Description: "Time the template is rendered"},
+	{Name: ".Date", Description: "Date the note is created, as text"},
+	{Name: ".Time", Description: "Time the note is created, as text"},
+	{Name: ".VaultName", Description: "Vault name"},
+	{Name: ".Author", Description: "Note author"},
+	{Name: ".Custom", Description: "Custom frontmatter fields; use .Custom.status"},
+}
+
+// Functions returns the names of the functions templates can call, sorted
+func Functions() []string {
+	names := make([]string, 0, len(templateFuncs()))
+	for name := range templateFuncs() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse checks that content is a valid template, without rendering it
+func Parse(name, content string) error {
+	if _, err := template.New(name).Funcs(templateFuncs()).Parse(content); err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+	return nil
+}
+
 // Render renders a template with the given data
 func (e *Engine) Render(templateName string, data TemplateData) (string, error) {
 	tmpl, err := e.getTemplate(templateName)
@@ -85,14 +126,7 @@ func (e *Engine) CreateDefaultTemplates() error {
 		return fmt.Errorf("failed to create template directory: %w", err)
 	}
 
-	templates := map[string]string{
-		"default": defaultTemplate,
-		"daily":   dailyTemplate,
-		"meeting": meetingTemplate,
-		"book":    bookTemplate,
-	}
-
-	for name, content := range templates {
+	for name, content := range builtinTemplates {
 		templatePath := filepath.Join(e.templateDir, name+".md")
 		if _, err := os.Stat(templatePath); os.IsNotExist(err) {
 			if err := os.WriteFile(templatePath, []byte(content), 0644); err != nil {
@@ -167,6 +201,21 @@ func templateFuncs() template.FuncMap {
 	}
 }
 
+// builtinTemplates are the templates CreateDefaultTemplates writes
+var builtinTemplates = map[string]string{
+	"default": defaultTemplate,
+	"daily":   dailyTemplate,
+	"meeting": meetingTemplate,
+	"book":    bookTemplate,
+}
+
+// Builtin returns the content of a built-in template: default, daily,
+// meeting or book
+func Builtin(name string) (string, bool) {
+	content, ok := builtinTemplates[name]
+	return content, ok
+}
+
 // Default template definitions
 const defaultTemplate = `# {{.Title}}
 
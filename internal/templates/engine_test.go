@@ -3,6 +3,8 @@ package templates
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -192,3 +194,31 @@ func TestTemplateFuncs(t *testing.T) {
 		}
 	}
 }
+
+func TestVariables(t *testing.T) {
+	fields := reflect.TypeOf(TemplateData{})
+	if len(Variables) != fields.NumField() {
+		t.Errorf("Variables has %d entries, TemplateData has %d fields", len(Variables), fields.NumField())
+	}
+	for _, v := range Variables {
+		if _, ok := fields.FieldByName(strings.TrimPrefix(v.Name, ".")); !ok {
+			t.Errorf("Variable %s is not a TemplateData field", v.Name)
+		}
+	}
+
+	if got := Functions(); !slices.IsSorted(got) || !slices.Contains(got, "join") {
+		t.Errorf("Functions() = %v, expected sorted names including join", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if err := Parse("ok", "# {{.Title}}\n{{join .Tags \", \"}}\n"); err != nil {
+		t.Errorf("Valid template failed to parse: %v", err)
+	}
+	if err := Parse("bad", "# {{.Title"); err == nil {
+		t.Error("Invalid template parsed")
+	}
+	if err := Parse("unknown", "{{shout .Title}}"); err == nil {
+		t.Error("Template calling an unknown function parsed")
+	}
+}
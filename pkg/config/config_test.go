@@ -160,6 +160,7 @@ func TestManager_WriteToFile(t *testing.T) {
 	config.Vault.Name = "written-vault"
 	config.Storage.Type = types.StorageTypeS3
 	config.Storage.S3.Bucket = "test-bucket"
+	config.Storage.S3.Region = "us-east-1"
 
 	// Write to file
 	if err := manager.WriteToFile(configPath); err != nil {
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// ConfigDifference is a configuration key and its value in two
+// configurations
+type ConfigDifference struct {
+	Key   string `json:"key"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Equal bool   `json:"equal,omitempty"`
+}
+
+// FlattenConfig returns every configuration value keyed by its dotted
+// path, such as storage.s3.bucket. Lists, maps and tables are rendered as
+// JSON. Credentials such as storage.s3.secret_access_key are left out
+// unless includeSecrets is set.
+func FlattenConfig(config *types.Config, includeSecrets bool) map[string]string {
+	values := make(map[string]string)
+	if config != nil {
+		flattenValue(reflect.ValueOf(config).Elem(), "", includeSecrets, values)
+	}
+	return values
+}
+
+// DiffConfigs compares a and b key by key and returns the keys whose
+// values differ, sorted by key. With allKeys equal keys are included too,
+// marked Equal. Credentials are compared only when includeSecrets is set.
+func DiffConfigs(a, b *types.Config, allKeys, includeSecrets bool) []ConfigDifference {
+	valuesA := FlattenConfig(a, includeSecrets)
+	valuesB := FlattenConfig(b, includeSecrets)
+
+	keys := make([]string, 0, len(valuesA))
+	for key := range valuesA {
+		keys = append(keys, key)
+	}
+	for key := range valuesB {
+		if _, ok := valuesA[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	differences := []ConfigDifference{}
+	for _, key := range keys {
+		diff := ConfigDifference{Key: key, A: valuesA[key], B: valuesB[key]}
+		diff.Equal = diff.A == diff.B
+		if diff.Equal && !allKeys {
+			continue
+		}
+		differences = append(differences, diff)
+	}
+	return differences
+}
+
+// flattenValue records the leaf values of the structs in v under their
+// dotted paths
+func flattenValue(v reflect.Value, path string, includeSecrets bool, values map[string]string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			values[path] = ""
+			return
+		}
+		flattenValue(v.Elem(), path, includeSecrets, values)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := fieldName(field)
+			if secretKeys[name] && !includeSecrets {
+				continue
+			}
+			flattenValue(v.Field(i), joinFieldPath(path, name), includeSecrets, values)
+		}
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		values[path] = formatCompositeValue(v)
+
+	default:
+		values[path] = fmt.Sprint(v.Interface())
+	}
+}
+
+// formatCompositeValue renders a list or map as JSON, with empty and nil
+// values rendered alike so they compare equal
+func formatCompositeValue(v reflect.Value) string {
+	if v.Len() == 0 {
+		if v.Kind() == reflect.Map {
+			return "{}"
+		}
+		return "[]"
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(data)
+}
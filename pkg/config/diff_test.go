@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestFlattenConfig(t *testing.T) {
+	config := types.DefaultConfig()
+	config.Storage.S3.SecretAccessKey = "secret"
+	config.Vault.ExtraDirs = []string{"archive"}
+
+	values := FlattenConfig(config, false)
+	assert.Equal(t, "local", values["storage.type"])
+	assert.Equal(t, config.Vault.Name, values["vault.name"])
+	assert.Equal(t, `["archive"]`, values["vault.extra_dirs"])
+	assert.Equal(t, "true", values["server.http.enable_cors"])
+	assert.NotContains(t, values, "storage.s3.secret_access_key")
+
+	values = FlattenConfig(config, true)
+	assert.Equal(t, "secret", values["storage.s3.secret_access_key"])
+}
+
+func TestDiffConfigs(t *testing.T) {
+	a := types.DefaultConfig()
+	a.Vault.Name = "work"
+	a.Storage.Type = types.StorageTypeS3
+	a.Storage.S3.SecretAccessKey = "one"
+
+	b := types.DefaultConfig()
+	b.Vault.Name = "personal"
+	b.Storage.S3.SecretAccessKey = "two"
+	b.Server.HTTP.CORSOrigins = nil
+	a.Server.HTTP.CORSOrigins = []string{}
+
+	assert.Equal(t, []ConfigDifference{
+		{Key: "storage.type", A: "s3", B: "local"},
+		{Key: "vault.name", A: "work", B: "personal"},
+	}, DiffConfigs(a, b, false, false))
+
+	withSecrets := DiffConfigs(a, b, false, true)
+	assert.Contains(t, withSecrets, ConfigDifference{Key: "storage.s3.secret_access_key", A: "one", B: "two"})
+
+	all := DiffConfigs(a, b, true, false)
+	assert.Greater(t, len(all), 2)
+	assert.Contains(t, all, ConfigDifference{Key: "vault.notes_dir", A: "notes", B: "notes", Equal: true})
+
+	assert.Empty(t, DiffConfigs(a, a, false, true))
+}
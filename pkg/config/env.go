@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// envRefPattern matches ${VAR} references in configuration values.
+// Bare $VAR references are deliberately not expanded so that values such as
+// secrets containing a literal '$' are left untouched.
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces ${VAR} references in every string field of the
+// configuration with the value of the corresponding environment variable.
+// When strict is true, references to unset variables produce an error;
+// otherwise they are left as literals.
+func ExpandEnv(config *types.Config, strict bool) error {
+	if config == nil {
+		return nil
+	}
+
+	var missing []string
+	expandValue(reflect.ValueOf(config).Elem(), "", &missing)
+
+	if strict && len(missing) > 0 {
+		return fmt.Errorf("undefined environment variables: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// expandValue walks v and expands environment references in place.
+// Unresolved references are recorded in missing as "field.path (VAR)".
+func expandValue(v reflect.Value, path string, missing *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			expandValue(v.Elem(), path, missing)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			expandValue(v.Field(i), joinFieldPath(path, fieldName(field)), missing)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			expandValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), missing)
+		}
+
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			for _, key := range v.MapKeys() {
+				expandValue(v.MapIndex(key), joinFieldPath(path, fmt.Sprint(key.Interface())), missing)
+			}
+			return
+		}
+
+		// Map values are not addressable, so expanded strings are written back
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			expandValue(elem, joinFieldPath(path, fmt.Sprint(key.Interface())), missing)
+			v.SetMapIndex(key, elem)
+		}
+
+	case reflect.String:
+		if !v.CanSet() {
+			return
+		}
+		v.SetString(expandString(v.String(), path, missing))
+	}
+}
+
+// expandString expands ${VAR} references in a single value
+func expandString(value, path string, missing *[]string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+
+	return envRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envRefPattern.FindStringSubmatch(ref)[1]
+		if resolved, ok := os.LookupEnv(name); ok {
+			return resolved
+		}
+		*missing = append(*missing, fmt.Sprintf("%s (%s)", path, name))
+		return ref
+	})
+}
+
+// fieldName returns the configuration key for a struct field
+func fieldName(field reflect.StructField) string {
+	if tag := field.Tag.Get("toml"); tag != "" && tag != "-" {
+		return strings.Split(tag, ",")[0]
+	}
+	return strings.ToLower(field.Name)
+}
+
+// joinFieldPath joins configuration key segments with dots
+func joinFieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("KBV_TEST_SECRET", "s3cr3t")
+	t.Setenv("KBV_TEST_BUCKET", "notes")
+	t.Setenv("KBV_TEST_ORIGIN", "https://example.com")
+
+	config := types.DefaultConfig()
+	config.Storage.S3.SecretAccessKey = "${KBV_TEST_SECRET}"
+	config.Storage.S3.Bucket = "kb-${KBV_TEST_BUCKET}-prod"
+	config.Storage.S3.Region = "us-east-1"
+	config.Server.HTTP.CORSOrigins = []string{"${KBV_TEST_ORIGIN}", "http://localhost"}
+	config.Vault.Name = "$KBV_TEST_BUCKET"
+
+	require.NoError(t, ExpandEnv(config, true))
+
+	assert.Equal(t, "s3cr3t", config.Storage.S3.SecretAccessKey)
+	assert.Equal(t, "kb-notes-prod", config.Storage.S3.Bucket)
+	assert.Equal(t, "us-east-1", config.Storage.S3.Region)
+	assert.Equal(t, []string{"https://example.com", "http://localhost"}, config.Server.HTTP.CORSOrigins)
+	// Bare $VAR references are not expanded
+	assert.Equal(t, "$KBV_TEST_BUCKET", config.Vault.Name)
+}
+
+func TestExpandEnv_UnsetVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"lenient leaves literal", false, false},
+		{"strict returns error", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := types.DefaultConfig()
+			config.Storage.S3.AccessKeyID = "${KBV_TEST_UNSET_VARIABLE}"
+
+			err := ExpandEnv(config, tt.strict)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "storage.s3.access_key_id (KBV_TEST_UNSET_VARIABLE)")
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "${KBV_TEST_UNSET_VARIABLE}", config.Storage.S3.AccessKeyID)
+			}
+		})
+	}
+}
+
+func TestExpandEnv_EmptyVariable(t *testing.T) {
+	t.Setenv("KBV_TEST_EMPTY", "")
+
+	config := types.DefaultConfig()
+	config.Storage.S3.Endpoint = "${KBV_TEST_EMPTY}"
+
+	// A variable that is set but empty is not treated as missing
+	require.NoError(t, ExpandEnv(config, true))
+	assert.Equal(t, "", config.Storage.S3.Endpoint)
+}
+
+func TestViperManager_GetConfigExpandsEnv(t *testing.T) {
+	vm := setupTestViperManager(t)
+
+	config := types.DefaultConfig()
+	config.Storage.Type = types.StorageTypeS3
+	config.Storage.S3.Bucket = "env-bucket"
+	config.Storage.S3.Region = "us-east-1"
+	config.Storage.S3.SecretAccessKey = "${KBV_TEST_AWS_SECRET}"
+	require.NoError(t, vm.CreateProfile("env-expand", config))
+
+	t.Run("expands set variable", func(t *testing.T) {
+		t.Setenv("KBV_TEST_AWS_SECRET", "from-env")
+
+		loaded, err := vm.GetConfig("env-expand")
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", loaded.Storage.S3.SecretAccessKey)
+
+		// The raw configuration keeps the reference so it is never persisted resolved
+		raw, err := vm.GetRawConfig("env-expand")
+		require.NoError(t, err)
+		assert.Equal(t, "${KBV_TEST_AWS_SECRET}", raw.Storage.S3.SecretAccessKey)
+	})
+
+	t.Run("leaves unset variable by default", func(t *testing.T) {
+		loaded, err := vm.GetConfig("env-expand")
+		require.NoError(t, err)
+		assert.Equal(t, "${KBV_TEST_AWS_SECRET}", loaded.Storage.S3.SecretAccessKey)
+	})
+
+	t.Run("strict flag rejects unset variable", func(t *testing.T) {
+		vm.SetStrictEnv(true)
+		defer vm.SetStrictEnv(false)
+
+		_, err := vm.GetConfig("env-expand")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "KBV_TEST_AWS_SECRET")
+	})
+
+	t.Run("strict config option rejects unset variable", func(t *testing.T) {
+		t.Setenv("KBVAULT_STRICT_ENV", "true")
+
+		_, err := vm.GetConfig("env-expand")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "KBV_TEST_AWS_SECRET")
+	})
+}
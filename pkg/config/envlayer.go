@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// globalEnvPrefix prefixes environment variables that override settings
+// for every profile, e.g. KBVAULT_STORAGE_TYPE
+const globalEnvPrefix = "KBVAULT"
+
+// envKeyReplacer maps a config key to its environment variable suffix
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// configKeys lists every config key that can be set from the environment
+var configKeys = sync.OnceValue(func() []string {
+	defaults := viper.New()
+	new(ViperManager).setDefaultValues(defaults)
+	return append(defaults.AllKeys(), "strict_env")
+})
+
+// envViper returns a Viper instance holding only the environment variables
+// named <prefix>_<KEY> that are set, for every known config key
+func envViper(prefix string) *viper.Viper {
+	v := viper.New()
+	v.SetEnvPrefix(prefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	for _, key := range configKeys() {
+		_ = v.BindEnv(key)
+	}
+	return v
+}
+
+// profileEnvName returns the part of a profile's environment variable
+// names that identifies it: the name uppercased, with anything other than
+// letters and digits replaced by underscores
+func profileEnvName(profile string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - ('a' - 'A')
+		default:
+			return '_'
+		}
+	}, profile)
+}
+
+// profileEnvPrefix returns the environment variable prefix for a profile,
+// e.g. KBVAULT_MY_WORK for my-work
+func profileEnvPrefix(profile string) string {
+	return globalEnvPrefix + "_" + profileEnvName(profile)
+}
+
+// checkProfileEnvName rejects profile names whose environment variables
+// could be mistaken for global ones, such as a profile named storage
+// whose KBVAULT_STORAGE_CACHE_ENABLED is also the global
+// storage.cache.enabled variable
+func checkProfileEnvName(profile string) error {
+	name := profileEnvName(profile)
+	if strings.Trim(name, "_") == "" {
+		return fmt.Errorf("profile name %q must contain a letter or digit", profile)
+	}
+
+	for _, key := range configKeys() {
+		if strings.HasPrefix(strings.ToUpper(envKeyReplacer.Replace(key)), name+"_") {
+			return fmt.Errorf("profile name %q is reserved: its environment variables (%s_*) would clash with global settings", profile, profileEnvPrefix(profile))
+		}
+	}
+	return nil
+}
+
+// checkProfileEnvClash rejects a new profile name that is reserved or that
+// maps to the same environment variables as an existing profile other
+// than ignore, such as my-work and my_work
+func (vm *ViperManager) checkProfileEnvClash(name, ignore string) error {
+	if err := checkProfileEnvName(name); err != nil {
+		return err
+	}
+
+	profiles, err := vm.ListProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	for _, profile := range profiles {
+		if profile == name || profile == ignore {
+			continue
+		}
+		if profileEnvName(profile) == profileEnvName(name) {
+			return fmt.Errorf("profile name %q clashes with profile %q: both would use environment variables %s_*", name, profile, profileEnvPrefix(name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/viper"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// migration upgrades raw profile settings from version-1 to version
+type migration struct {
+	version     int
+	description string
+	apply       func(settings map[string]interface{})
+}
+
+// migrations lists every schema upgrade in order. Each entry brings a
+// profile from the previous version to its own version.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "move backend settings under storage.local and storage.s3",
+		apply:       migrateToV1,
+	},
+}
+
+// MigrationResult describes the outcome of migrating a profile
+type MigrationResult struct {
+	Profile     string
+	FromVersion int
+	ToVersion   int
+	Applied     []string
+	BackupPath  string
+}
+
+// Migrated reports whether the profile file was rewritten
+func (r *MigrationResult) Migrated() bool {
+	return r.FromVersion != r.ToVersion
+}
+
+// MigrateProfile upgrades a profile file to the current schema version,
+// applying each pending migration in order and writing the result back.
+// Profiles that are already current are left untouched.
+func (vm *ViperManager) MigrateProfile(name string) error {
+	_, err := vm.migrateProfile(name)
+	return err
+}
+
+// migrateProfile performs the migration and reports what was done
+func (vm *ViperManager) migrateProfile(name string) (*MigrationResult, error) {
+	if name == "" {
+		return nil, fmt.Errorf("profile name cannot be empty")
+	}
+
+	profilePath := filepath.Join(vm.profilesConfigDir, name+".toml")
+	data, err := os.ReadFile(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile %s: %w", name, err)
+	}
+
+	settings := make(map[string]interface{})
+	if _, err := toml.Decode(string(data), &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", name, err)
+	}
+
+	version, err := schemaVersion(settings)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+
+	result := &MigrationResult{
+		Profile:     name,
+		FromVersion: version,
+		ToVersion:   version,
+	}
+
+	if version > types.CurrentSchemaVersion {
+		return nil, fmt.Errorf("profile %s has schema version %d, newer than the supported version %d",
+			name, version, types.CurrentSchemaVersion)
+	}
+	if version == types.CurrentSchemaVersion {
+		return result, nil
+	}
+
+	result.Applied = applyMigrations(settings, version)
+	result.ToVersion = types.CurrentSchemaVersion
+
+	// Keep the original file so a migration can be reverted by hand
+	result.BackupPath = fmt.Sprintf("%s.v%d.bak", profilePath, version)
+	if err := os.WriteFile(result.BackupPath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to back up profile %s: %w", name, err)
+	}
+
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("failed to prepare migrated profile %s: %w", name, err)
+	}
+	if err := v.WriteConfigAs(profilePath); err != nil {
+		return nil, fmt.Errorf("failed to write migrated profile %s: %w", name, err)
+	}
+
+	// Drop any cached instance so the upgraded file is read next time
+	delete(vm.profiles, name)
+
+	return result, nil
+}
+
+// applyMigrations brings raw settings from version up to the current
+// schema version and returns the description of each migration applied
+func applyMigrations(settings map[string]interface{}, version int) []string {
+	var applied []string
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		m.apply(settings)
+		settings["schema_version"] = m.version
+		applied = append(applied, m.description)
+	}
+	return applied
+}
+
+// migrateIfNeeded upgrades a profile before it is loaded. Profiles without
+// a file on disk (such as an unsaved default profile) are skipped.
+func (vm *ViperManager) migrateIfNeeded(name string) error {
+	_, err := vm.migrateProfile(name)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// schemaVersion reads schema_version from raw settings, treating a missing
+// value as version 0
+func schemaVersion(settings map[string]interface{}) (int, error) {
+	raw, ok := settings["schema_version"]
+	if !ok {
+		return 0, nil
+	}
+
+	switch v := raw.(type) {
+	case int64:
+		return int(v), nil
+	case int:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("invalid schema_version %v", raw)
+	}
+}
+
+// migrateToV1 moves settings from the original flat storage layout, where
+// backend options lived directly under [storage] and S3 credentials under
+// [storage.credentials], to the per-backend tables.
+func migrateToV1(settings map[string]interface{}) {
+	moves := []struct{ from, to string }{
+		{"storage.path", "storage.local.path"},
+		{"storage.bucket", "storage.s3.bucket"},
+		{"storage.region", "storage.s3.region"},
+		{"storage.endpoint", "storage.s3.endpoint"},
+		{"storage.prefix", "storage.s3.prefix"},
+		{"storage.credentials.access_key", "storage.s3.access_key_id"},
+		{"storage.credentials.secret_key", "storage.s3.secret_access_key"},
+		{"storage.credentials.session_token", "storage.s3.session_token"},
+	}
+
+	for _, move := range moves {
+		moveSetting(settings, move.from, move.to)
+	}
+
+	// Drop the credentials table once it has been emptied
+	if storage, ok := settings["storage"].(map[string]interface{}); ok {
+		if creds, ok := storage["credentials"].(map[string]interface{}); ok && len(creds) == 0 {
+			delete(storage, "credentials")
+		}
+	}
+}
+
+// moveSetting moves a dotted key to a new location. An existing value at the
+// destination wins so that partially migrated files are not clobbered.
+func moveSetting(settings map[string]interface{}, from, to string) {
+	value, ok := takeSetting(settings, from)
+	if !ok {
+		return
+	}
+
+	parts := strings.Split(to, ".")
+	table := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := table[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			table[part] = next
+		}
+		table = next
+	}
+
+	key := parts[len(parts)-1]
+	if _, exists := table[key]; !exists {
+		table[key] = value
+	}
+}
+
+// takeSetting removes a dotted key and returns its value
+func takeSetting(settings map[string]interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	table := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := table[part].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		table = next
+	}
+
+	last := parts[len(parts)-1]
+	value, ok := table[last]
+	if !ok {
+		return nil, false
+	}
+	// Tables such as [storage.local] are not scalar settings to move
+	if _, isTable := value.(map[string]interface{}); isTable {
+		return nil, false
+	}
+
+	delete(table, last)
+	return value, true
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// profileMetadataFile, in the global config directory, records what the
+// profile files don't: each profile's description, and when it was
+// created, since file systems don't reliably report when a file was
+// created
+const profileMetadataFile = "profile_metadata.json"
+
+// profileMetadata is what the metadata file records about a profile
+type profileMetadata struct {
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+	Description string    `json:"description,omitempty"`
+}
+
+// profilePath returns the path of a profile's config file
+func (vm *ViperManager) profilePath(name string) string {
+	return filepath.Join(vm.profilesConfigDir, name+".toml")
+}
+
+// ProfileTimes returns when a profile was created and when its config
+// file was last modified. A profile without a file, such as a default
+// profile that was never saved, has zero times. A profile created before
+// creation times were recorded is given its file's modification time the
+// first time it is seen.
+func (vm *ViperManager) ProfileTimes(name string) (created, modified time.Time, err error) {
+	info, err := os.Stat(vm.profilePath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat profile config file: %w", err)
+	}
+	modified = info.ModTime()
+
+	metadata, err := vm.readProfileMetadata()
+	if err != nil {
+		return time.Time{}, modified, err
+	}
+	if entry, ok := metadata[name]; ok && !entry.CreatedAt.IsZero() {
+		return entry.CreatedAt, modified, nil
+	}
+
+	// First seen: recording the time is best effort
+	entry := metadata[name]
+	entry.CreatedAt = modified
+	metadata[name] = entry
+	_ = vm.writeProfileMetadata(metadata)
+	return modified, modified, nil
+}
+
+// ProfileDescription returns a profile's description, or "" when it has
+// none
+func (vm *ViperManager) ProfileDescription(name string) (string, error) {
+	metadata, err := vm.readProfileMetadata()
+	if err != nil {
+		return "", err
+	}
+	return metadata[name].Description, nil
+}
+
+// SetProfileDescription sets a profile's description; an empty one
+// removes it
+func (vm *ViperManager) SetProfileDescription(name, description string) error {
+	return vm.updateProfileMetadata(func(metadata map[string]profileMetadata) {
+		entry := metadata[name]
+		entry.Description = description
+		metadata[name] = entry
+	})
+}
+
+// recordProfileCreated records that a profile was created at t, replacing
+// anything recorded about an earlier profile of the same name
+func (vm *ViperManager) recordProfileCreated(name string, t time.Time) error {
+	return vm.updateProfileMetadata(func(metadata map[string]profileMetadata) {
+		metadata[name] = profileMetadata{CreatedAt: t}
+	})
+}
+
+// renameProfileMetadata moves what is recorded about a profile to its new
+// name
+func (vm *ViperManager) renameProfileMetadata(oldName, newName string) error {
+	return vm.updateProfileMetadata(func(metadata map[string]profileMetadata) {
+		if entry, ok := metadata[oldName]; ok {
+			metadata[newName] = entry
+			delete(metadata, oldName)
+		}
+	})
+}
+
+// forgetProfileMetadata removes what is recorded about a deleted profile
+func (vm *ViperManager) forgetProfileMetadata(name string) error {
+	return vm.updateProfileMetadata(func(metadata map[string]profileMetadata) {
+		delete(metadata, name)
+	})
+}
+
+// updateProfileMetadata applies fn to the recorded metadata and saves it
+func (vm *ViperManager) updateProfileMetadata(fn func(map[string]profileMetadata)) error {
+	metadata, err := vm.readProfileMetadata()
+	if err != nil {
+		return err
+	}
+	fn(metadata)
+	return vm.writeProfileMetadata(metadata)
+}
+
+// readProfileMetadata reads the metadata file. A missing file is empty,
+// and so is a corrupt one, so that it is rebuilt rather than blocking
+// profile commands.
+func (vm *ViperManager) readProfileMetadata() (map[string]profileMetadata, error) {
+	metadata := make(map[string]profileMetadata)
+
+	data, err := os.ReadFile(filepath.Join(vm.globalConfigDir, profileMetadataFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return metadata, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return make(map[string]profileMetadata), nil
+	}
+	return metadata, nil
+}
+
+// writeProfileMetadata saves the metadata file
+func (vm *ViperManager) writeProfileMetadata(metadata map[string]profileMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile metadata: %w", err)
+	}
+	if err := os.MkdirAll(vm.globalConfigDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(vm.globalConfigDir, profileMetadataFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write profile metadata: %w", err)
+	}
+	return nil
+}
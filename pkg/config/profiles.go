@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"path"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,8 +17,8 @@ type ProfileInfo struct {
 	Name        string    `json:"name"`
 	IsActive    bool      `json:"is_active"`
 	IsDefault   bool      `json:"is_default"`
-	CreatedAt   time.Time `json:"created_at,omitempty"`
-	ModifiedAt  time.Time `json:"modified_at,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+	ModifiedAt  time.Time `json:"modified_at,omitzero"`
 	StorageType string    `json:"storage_type"`
 	Description string    `json:"description,omitempty"`
 }
@@ -42,6 +45,9 @@ func (pm *ProfileManager) CreateProfile(name string, options *CreateProfileOptio
 	if err := validateProfileName(name); err != nil {
 		return err
 	}
+	if err := options.Validate(); err != nil {
+		return err
+	}
 
 	// Check if profile already exists
 	profiles, err := pm.viperManager.ListProfiles()
@@ -55,6 +61,23 @@ func (pm *ProfileManager) CreateProfile(name string, options *CreateProfileOptio
 		}
 	}
 
+	// Create the profile with the complete configuration
+	if err := pm.viperManager.CreateProfile(name, NewProfileConfig(options)); err != nil {
+		return fmt.Errorf("failed to create profile: %w", err)
+	}
+
+	if options != nil && options.Description != "" {
+		if err := pm.viperManager.SetProfileDescription(name, strings.TrimSpace(options.Description)); err != nil {
+			return fmt.Errorf("failed to save profile description: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// NewProfileConfig returns the configuration CreateProfile would save for
+// options, without saving it
+func NewProfileConfig(options *CreateProfileOptions) *types.Config {
 	// Always start with default configuration to ensure all fields are populated
 	config := types.DefaultConfig()
 
@@ -85,14 +108,20 @@ func (pm *ProfileManager) CreateProfile(name string, options *CreateProfileOptio
 		if options.VaultName != "" {
 			config.Vault.Name = options.VaultName
 		}
-	}
 
-	// Create the profile with the complete configuration
-	if err := pm.viperManager.CreateProfile(name, config); err != nil {
-		return fmt.Errorf("failed to create profile: %w", err)
+		if options.CacheEnabled != nil {
+			config.Storage.Cache.Enabled = *options.CacheEnabled
+			config.Storage.Cache.AutoEnable = *options.CacheEnabled
+		}
+		if options.LogLevel != "" {
+			config.Logging.Level = strings.ToUpper(options.LogLevel)
+		}
+		if options.LogFormat != "" {
+			config.Logging.Format = strings.ToLower(options.LogFormat)
+		}
 	}
 
-	return nil
+	return config
 }
 
 // DeleteProfile removes a profile
@@ -129,6 +158,12 @@ func (pm *ProfileManager) ListProfiles() ([]ProfileInfo, error) {
 		if config, err := pm.viperManager.GetConfig(name); err == nil {
 			info.StorageType = string(config.Storage.Type)
 		}
+		if created, modified, err := pm.viperManager.ProfileTimes(name); err == nil {
+			info.CreatedAt, info.ModifiedAt = created, modified
+		}
+		if description, err := pm.viperManager.ProfileDescription(name); err == nil {
+			info.Description = description
+		}
 
 		profiles = append(profiles, info)
 	}
@@ -153,6 +188,94 @@ func (pm *ProfileManager) ListProfiles() ([]ProfileInfo, error) {
 	return profiles, nil
 }
 
+// ProfileSortModes are the orders SortProfiles accepts
+var ProfileSortModes = []string{"name", "storage", "active"}
+
+// SortProfiles reorders profiles by name, by storage type then name, or
+// active profile first then name. An empty mode keeps ListProfiles' order.
+func SortProfiles(profiles []ProfileInfo, mode string) error {
+	var less func(a, b ProfileInfo) bool
+	switch mode {
+	case "":
+		return nil
+	case "name":
+		less = func(a, b ProfileInfo) bool { return a.Name < b.Name }
+	case "storage":
+		less = func(a, b ProfileInfo) bool {
+			if a.StorageType != b.StorageType {
+				return a.StorageType < b.StorageType
+			}
+			return a.Name < b.Name
+		}
+	case "active":
+		less = func(a, b ProfileInfo) bool {
+			if a.IsActive != b.IsActive {
+				return a.IsActive
+			}
+			return a.Name < b.Name
+		}
+	default:
+		return fmt.Errorf("invalid sort %q (must be one of %s)", mode, strings.Join(ProfileSortModes, ", "))
+	}
+
+	sort.SliceStable(profiles, func(i, j int) bool { return less(profiles[i], profiles[j]) })
+	return nil
+}
+
+// FilterProfiles returns the profiles matching every filter. Filters are
+// key=value pairs: storage=s3 matches the storage type, name=work-* matches
+// the name as a glob pattern, and active=true or default=true match the
+// active or default profile.
+func FilterProfiles(profiles []ProfileInfo, filters []string) ([]ProfileInfo, error) {
+	matchers := make([]func(ProfileInfo) bool, 0, len(filters))
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid filter %q: expected key=value", filter)
+		}
+
+		switch key {
+		case "storage":
+			matchers = append(matchers, func(p ProfileInfo) bool { return strings.EqualFold(p.StorageType, value) })
+		case "name":
+			if _, err := path.Match(value, ""); err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+			}
+			matchers = append(matchers, func(p ProfileInfo) bool {
+				matched, _ := path.Match(value, p.Name)
+				return matched
+			})
+		case "active", "default":
+			want, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %s must be true or false", filter, key)
+			}
+			if key == "active" {
+				matchers = append(matchers, func(p ProfileInfo) bool { return p.IsActive == want })
+			} else {
+				matchers = append(matchers, func(p ProfileInfo) bool { return p.IsDefault == want })
+			}
+		default:
+			return nil, fmt.Errorf("invalid filter %q: key must be storage, name, active or default", filter)
+		}
+	}
+
+	filtered := []ProfileInfo{}
+	for _, profile := range profiles {
+		if slices.ContainsFunc(matchers, func(match func(ProfileInfo) bool) bool { return !match(profile) }) {
+			continue
+		}
+		filtered = append(filtered, profile)
+	}
+	return filtered, nil
+}
+
+// ListProfileNames returns the names of all profiles without loading them
+func (pm *ProfileManager) ListProfileNames() ([]string, error) {
+	return pm.viperManager.ListProfiles()
+}
+
 // GetProfile returns information about a specific profile
 func (pm *ProfileManager) GetProfile(name string) (*ProfileInfo, error) {
 	profiles, err := pm.ListProfiles()
@@ -183,11 +306,22 @@ func (pm *ProfileManager) GetActiveProfile() string {
 	return pm.viperManager.GetActiveProfile()
 }
 
+// SetStrictEnv controls whether unset ${VAR} references in config values are errors
+func (pm *ProfileManager) SetStrictEnv(strict bool) {
+	pm.viperManager.SetStrictEnv(strict)
+}
+
 // GetConfig returns the configuration for a specific profile
 func (pm *ProfileManager) GetConfig(profile string) (*types.Config, error) {
 	return pm.viperManager.GetConfig(profile)
 }
 
+// GetRawConfig returns a profile's configuration without expanding ${VAR}
+// references, for callers that modify and save it
+func (pm *ProfileManager) GetRawConfig(profile string) (*types.Config, error) {
+	return pm.viperManager.GetRawConfig(profile)
+}
+
 // UpdateProfile updates the configuration for a specific profile
 func (pm *ProfileManager) UpdateProfile(name string, config *types.Config) error {
 	if err := validateProfileName(name); err != nil {
@@ -234,7 +368,7 @@ func (pm *ProfileManager) CopyProfile(sourceName, targetName string) error {
 	}
 
 	// Get source configuration
-	sourceConfig, err := pm.viperManager.GetConfig(sourceName)
+	sourceConfig, err := pm.viperManager.GetRawConfig(sourceName)
 	if err != nil {
 		return fmt.Errorf("failed to get source profile config: %w", err)
 	}
@@ -244,9 +378,47 @@ func (pm *ProfileManager) CopyProfile(sourceName, targetName string) error {
 		return fmt.Errorf("failed to create target profile: %w", err)
 	}
 
+	// The copy starts with the source's description
+	if description, err := pm.viperManager.ProfileDescription(sourceName); err == nil && description != "" {
+		if err := pm.viperManager.SetProfileDescription(targetName, description); err != nil {
+			return fmt.Errorf("failed to copy profile description: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// RenameProfile renames a profile, keeping it active if it was the active
+// profile. The default profile cannot be renamed.
+func (pm *ProfileManager) RenameProfile(oldName, newName string) error {
+	if err := validateProfileName(oldName); err != nil {
+		return fmt.Errorf("invalid source profile name: %w", err)
+	}
+	if err := validateProfileName(newName); err != nil {
+		return fmt.Errorf("invalid target profile name: %w", err)
+	}
+
+	if oldName == "default" {
+		return fmt.Errorf("cannot rename the default profile")
+	}
+	if oldName == newName {
+		return fmt.Errorf("profile %s already has that name", oldName)
+	}
+
+	names, err := pm.viperManager.ListProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	if !slices.Contains(names, oldName) {
+		return fmt.Errorf("profile %s does not exist", oldName)
+	}
+	if slices.Contains(names, newName) {
+		return fmt.Errorf("profile %s already exists", newName)
+	}
+
+	return pm.viperManager.RenameProfile(oldName, newName)
+}
+
 // ConfigureProfile provides interactive configuration for a profile
 func (pm *ProfileManager) ConfigureProfile(name string, interactive bool) error {
 	if err := validateProfileName(name); err != nil {
@@ -254,7 +426,7 @@ func (pm *ProfileManager) ConfigureProfile(name string, interactive bool) error
 	}
 
 	// Get current configuration
-	config, err := pm.viperManager.GetConfig(name)
+	config, err := pm.viperManager.GetRawConfig(name)
 	if err != nil {
 		// If profile doesn't exist, create it with defaults
 		config = types.DefaultConfig()
@@ -270,6 +442,15 @@ func (pm *ProfileManager) ConfigureProfile(name string, interactive bool) error
 	return pm.viperManager.SaveProfile(name, config)
 }
 
+// MigrateProfile upgrades a profile to the current schema version
+func (pm *ProfileManager) MigrateProfile(name string) (*MigrationResult, error) {
+	if err := validateProfileName(name); err != nil {
+		return nil, err
+	}
+
+	return pm.viperManager.migrateProfile(name)
+}
+
 // ValidateProfile validates a profile's configuration
 func (pm *ProfileManager) ValidateProfile(name string) error {
 	if err := validateProfileName(name); err != nil {
@@ -290,7 +471,8 @@ func (pm *ProfileManager) ExportProfile(name string) (*types.Config, error) {
 		return nil, err
 	}
 
-	return pm.viperManager.GetConfig(name)
+	// Export unexpanded values so resolved secrets don't leak into exports
+	return pm.viperManager.GetRawConfig(name)
 }
 
 // ImportProfile imports a profile configuration from a config object
@@ -307,6 +489,48 @@ func (pm *ProfileManager) ImportProfile(name string, config *types.Config) error
 	return pm.viperManager.CreateProfile(name, config)
 }
 
+// ExportProfileFile writes a profile's configuration to a TOML file that
+// ImportProfileFile can read back. Secrets are cleared unless
+// includeSecrets is set; the keys that were cleared are returned.
+func (pm *ProfileManager) ExportProfileFile(name, path string, includeSecrets bool) ([]string, error) {
+	config, err := pm.ExportProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var redacted []string
+	if !includeSecrets {
+		redacted = RedactSecrets(config)
+	}
+
+	if err := pm.viperManager.writeConfigFile(path, config); err != nil {
+		return nil, err
+	}
+	return redacted, nil
+}
+
+// ImportProfileFile creates a new profile from a TOML file written by
+// ExportProfileFile
+func (pm *ProfileManager) ImportProfileFile(name, path string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+
+	names, err := pm.viperManager.ListProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	if slices.Contains(names, name) {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+
+	config, err := pm.viperManager.readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	return pm.ImportProfile(name, config)
+}
+
 // CreateProfileOptions contains options for creating a new profile
 type CreateProfileOptions struct {
 	// Storage configuration
@@ -323,10 +547,34 @@ type CreateProfileOptions struct {
 	// Vault options
 	VaultName string `json:"vault_name,omitempty"`
 
+	// Cache options. Nil keeps the default: no cache for local storage,
+	// enabled automatically for remote storage. False turns the cache off
+	// for remote storage too.
+	CacheEnabled *bool `json:"cache_enabled,omitempty"`
+
+	// Logging options: one of types.LogLevels and types.LogFormats, in any
+	// case
+	LogLevel  string `json:"log_level,omitempty"`
+	LogFormat string `json:"log_format,omitempty"`
+
 	// Profile metadata
 	Description string `json:"description,omitempty"`
 }
 
+// Validate checks the values of options that must be one of a fixed set
+func (o *CreateProfileOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.LogLevel != "" && !slices.Contains(types.LogLevels, strings.ToUpper(o.LogLevel)) {
+		return fmt.Errorf("invalid log level %q (must be one of %s)", o.LogLevel, strings.Join(types.LogLevels, ", "))
+	}
+	if o.LogFormat != "" && !slices.Contains(types.LogFormats, strings.ToLower(o.LogFormat)) {
+		return fmt.Errorf("invalid log format %q (must be one of %s)", o.LogFormat, strings.Join(types.LogFormats, ", "))
+	}
+	return nil
+}
+
 // ProfileSetValue represents a configuration value to set
 type ProfileSetValue struct {
 	Key   string      `json:"key"`
@@ -339,8 +587,12 @@ func (pm *ProfileManager) SetProfileValue(profileName, key string, value interfa
 		return err
 	}
 
+	if key == ProfileDescriptionKey {
+		return pm.SetProfileDescription(profileName, fmt.Sprint(value))
+	}
+
 	// Get current configuration
-	config, err := pm.viperManager.GetConfig(profileName)
+	config, err := pm.viperManager.GetRawConfig(profileName)
 	if err != nil {
 		return fmt.Errorf("failed to get profile config: %w", err)
 	}
@@ -364,6 +616,10 @@ func (pm *ProfileManager) GetProfileValue(profileName, key string) (interface{},
 		return nil, err
 	}
 
+	if key == ProfileDescriptionKey {
+		return pm.ProfileDescription(profileName)
+	}
+
 	config, err := pm.viperManager.GetConfig(profileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get profile config: %w", err)
@@ -372,6 +628,38 @@ func (pm *ProfileManager) GetProfileValue(profileName, key string) (interface{},
 	return getConfigValue(config, key)
 }
 
+// ProfileDescriptionKey is the key that sets and gets a profile's
+// description, which is kept alongside the profile rather than in its
+// configuration
+const ProfileDescriptionKey = "description"
+
+// ProfileDescription returns a profile's description, or "" when it has
+// none
+func (pm *ProfileManager) ProfileDescription(name string) (string, error) {
+	if err := validateProfileName(name); err != nil {
+		return "", err
+	}
+	return pm.viperManager.ProfileDescription(name)
+}
+
+// SetProfileDescription sets the description of an existing profile. An
+// empty description removes it.
+func (pm *ProfileManager) SetProfileDescription(name, description string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+
+	profiles, err := pm.viperManager.ListProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	if !slices.Contains(profiles, name) {
+		return fmt.Errorf("profile '%s' does not exist", name)
+	}
+
+	return pm.viperManager.SetProfileDescription(name, strings.TrimSpace(description))
+}
+
 // validateProfileName validates a profile name
 func validateProfileName(name string) error {
 	if name == "" {
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -58,6 +60,20 @@ func TestProfileManager_CreateProfile(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "invalid log level",
+			profile: "bad-level",
+			options: &CreateProfileOptions{LogLevel: "verbose"},
+			wantErr: true,
+			errMsg:  "invalid log level",
+		},
+		{
+			name:    "invalid log format",
+			profile: "bad-format",
+			options: &CreateProfileOptions{LogFormat: "xml"},
+			wantErr: true,
+			errMsg:  "invalid log format",
+		},
 		{
 			name:    "empty profile name",
 			profile: "",
@@ -113,6 +129,20 @@ func TestProfileManager_CreateProfile(t *testing.T) {
 	}
 }
 
+func TestNewProfileConfig_CacheAndLogging(t *testing.T) {
+	enabled := true
+	config := NewProfileConfig(&CreateProfileOptions{CacheEnabled: &enabled, LogLevel: "info", LogFormat: "JSON"})
+	assert.True(t, config.Storage.Cache.Enabled)
+	assert.Equal(t, "INFO", config.Logging.Level)
+	assert.Equal(t, "json", config.Logging.Format)
+
+	disabled := false
+	config = NewProfileConfig(&CreateProfileOptions{CacheEnabled: &disabled})
+	assert.False(t, config.Storage.Cache.Enabled)
+	assert.False(t, config.Storage.Cache.AutoEnable)
+	assert.Equal(t, types.DefaultConfig().Logging, config.Logging)
+}
+
 func TestProfileManager_CreateProfile_Duplicate(t *testing.T) {
 	pm := setupTestProfileManager(t)
 
@@ -196,6 +226,7 @@ func TestProfileManager_ListProfiles(t *testing.T) {
 	err = pm.CreateProfile("work", &CreateProfileOptions{
 		StorageType: types.StorageTypeS3,
 		S3Bucket:    "work-bucket",
+		S3Region:    "us-east-1",
 	})
 	require.NoError(t, err)
 
@@ -225,6 +256,193 @@ func TestProfileManager_ListProfiles(t *testing.T) {
 	}
 }
 
+func TestProfileManager_ProfileTimes(t *testing.T) {
+	pm := setupTestProfileManager(t)
+
+	before := time.Now().Add(-time.Second)
+	require.NoError(t, pm.CreateProfile("work", nil))
+
+	work, err := pm.GetProfile("work")
+	require.NoError(t, err)
+	assert.False(t, work.ModifiedAt.IsZero())
+	assert.WithinDuration(t, time.Now(), work.ModifiedAt, time.Minute)
+	assert.True(t, work.CreatedAt.After(before), "created %v", work.CreatedAt)
+
+	// The default profile has no file
+	defaultProfile, err := pm.GetProfile("default")
+	require.NoError(t, err)
+	assert.True(t, defaultProfile.ModifiedAt.IsZero())
+	assert.True(t, defaultProfile.CreatedAt.IsZero())
+
+	// Editing the file changes the modification time but not the creation time
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(pm.viperManager.profilePath("work"), later, later))
+	edited, err := pm.GetProfile("work")
+	require.NoError(t, err)
+	assert.WithinDuration(t, later, edited.ModifiedAt, time.Second)
+	assert.Equal(t, work.CreatedAt.UnixNano(), edited.CreatedAt.UnixNano())
+
+	// A renamed profile keeps its creation time
+	require.NoError(t, pm.RenameProfile("work", "job"))
+	job, err := pm.GetProfile("job")
+	require.NoError(t, err)
+	assert.Equal(t, work.CreatedAt.UnixNano(), job.CreatedAt.UnixNano())
+
+	// A profile file written by hand is first seen at its modification time
+	handWritten := pm.viperManager.profilePath("manual")
+	data, err := os.ReadFile(pm.viperManager.profilePath("job"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(handWritten, data, 0644))
+	past := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(handWritten, past, past))
+	manual, err := pm.GetProfile("manual")
+	require.NoError(t, err)
+	assert.True(t, manual.CreatedAt.Equal(past), "created %v", manual.CreatedAt)
+	assert.True(t, manual.ModifiedAt.Equal(past), "modified %v", manual.ModifiedAt)
+
+	// Deleting a profile forgets it
+	require.NoError(t, pm.DeleteProfile("job"))
+	metadata, err := pm.viperManager.readProfileMetadata()
+	require.NoError(t, err)
+	assert.NotContains(t, metadata, "job")
+	assert.NotContains(t, metadata, "work")
+	assert.Contains(t, metadata, "manual")
+}
+
+func TestProfileManager_ProfileDescription(t *testing.T) {
+	pm := setupTestProfileManager(t)
+
+	require.NoError(t, pm.CreateProfile("work", &CreateProfileOptions{Description: "  Notes for the work team "}))
+
+	work, err := pm.GetProfile("work")
+	require.NoError(t, err)
+	assert.Equal(t, "Notes for the work team", work.Description)
+
+	profiles, err := pm.ListProfiles()
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Empty(t, profiles[0].Description)
+	assert.Equal(t, "Notes for the work team", profiles[1].Description)
+
+	// The description isn't part of the profile's configuration
+	data, err := os.ReadFile(pm.viperManager.profilePath("work"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Notes for the work team")
+
+	// It is set and read like a configuration value
+	require.NoError(t, pm.SetProfileValue("work", ProfileDescriptionKey, "Team notes"))
+	value, err := pm.GetProfileValue("work", ProfileDescriptionKey)
+	require.NoError(t, err)
+	assert.Equal(t, "Team notes", value)
+	description, err := pm.ProfileDescription("work")
+	require.NoError(t, err)
+	assert.Equal(t, "Team notes", description)
+
+	// Setting it keeps the creation time
+	updated, err := pm.GetProfile("work")
+	require.NoError(t, err)
+	assert.Equal(t, work.CreatedAt.UnixNano(), updated.CreatedAt.UnixNano())
+
+	// It follows the profile when copied and renamed
+	require.NoError(t, pm.CopyProfile("work", "copy"))
+	copied, err := pm.GetProfile("copy")
+	require.NoError(t, err)
+	assert.Equal(t, "Team notes", copied.Description)
+
+	require.NoError(t, pm.RenameProfile("work", "job"))
+	job, err := pm.GetProfile("job")
+	require.NoError(t, err)
+	assert.Equal(t, "Team notes", job.Description)
+
+	// An empty description removes it
+	require.NoError(t, pm.SetProfileDescription("job", ""))
+	job, err = pm.GetProfile("job")
+	require.NoError(t, err)
+	assert.Empty(t, job.Description)
+
+	// A profile deleted and created again starts without one
+	require.NoError(t, pm.DeleteProfile("copy"))
+	require.NoError(t, pm.CreateProfile("copy", nil))
+	copied, err = pm.GetProfile("copy")
+	require.NoError(t, err)
+	assert.Empty(t, copied.Description)
+
+	err = pm.SetProfileDescription("missing", "Nothing here")
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+// sampleProfiles are profiles in the order ListProfiles returns them
+func sampleProfiles() []ProfileInfo {
+	return []ProfileInfo{
+		{Name: "default", IsDefault: true, StorageType: "local"},
+		{Name: "work", IsActive: true, StorageType: "s3"},
+		{Name: "archive", StorageType: "s3"},
+		{Name: "client-b", StorageType: "local"},
+		{Name: "client-a", StorageType: "s3"},
+	}
+}
+
+func profileNames(profiles []ProfileInfo) []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestSortProfiles(t *testing.T) {
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{mode: "", want: []string{"default", "work", "archive", "client-b", "client-a"}},
+		{mode: "name", want: []string{"archive", "client-a", "client-b", "default", "work"}},
+		{mode: "storage", want: []string{"client-b", "default", "archive", "client-a", "work"}},
+		{mode: "active", want: []string{"work", "archive", "client-a", "client-b", "default"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			profiles := sampleProfiles()
+			require.NoError(t, SortProfiles(profiles, tt.mode))
+			assert.Equal(t, tt.want, profileNames(profiles))
+		})
+	}
+
+	err := SortProfiles(sampleProfiles(), "size")
+	assert.ErrorContains(t, err, `invalid sort "size"`)
+}
+
+func TestFilterProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{name: "none", want: []string{"default", "work", "archive", "client-b", "client-a"}},
+		{name: "storage", filters: []string{"storage=s3"}, want: []string{"work", "archive", "client-a"}},
+		{name: "storage any case", filters: []string{"storage=LOCAL"}, want: []string{"default", "client-b"}},
+		{name: "name glob", filters: []string{"name=client-*"}, want: []string{"client-b", "client-a"}},
+		{name: "combined", filters: []string{"storage=s3", "name=client-*"}, want: []string{"client-a"}},
+		{name: "active", filters: []string{"active=true"}, want: []string{"work"}},
+		{name: "not default", filters: []string{"default=false", "storage=local"}, want: []string{"client-b"}},
+		{name: "no match", filters: []string{"storage=azure"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, err := FilterProfiles(sampleProfiles(), tt.filters)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, profileNames(profiles))
+		})
+	}
+
+	for _, filter := range []string{"storage", "=s3", "size=1", "active=maybe", "name=[a"} {
+		_, err := FilterProfiles(sampleProfiles(), []string{filter})
+		assert.ErrorContains(t, err, "invalid filter", filter)
+	}
+}
+
 func TestProfileManager_SwitchProfile(t *testing.T) {
 	pm := setupTestProfileManager(t)
 
@@ -282,6 +500,7 @@ func TestProfileManager_UpdateProfile(t *testing.T) {
 	config.Vault.Name = "updated-vault"
 	config.Storage.Type = types.StorageTypeS3
 	config.Storage.S3.Bucket = "updated-bucket"
+	config.Storage.S3.Region = "us-east-1"
 
 	// Update profile
 	err = pm.UpdateProfile("update-test", config)
@@ -371,6 +590,82 @@ func TestProfileManager_CopyProfile_Errors(t *testing.T) {
 	}
 }
 
+func TestProfileManager_RenameProfile(t *testing.T) {
+	pm := setupTestProfileManager(t)
+
+	options := &CreateProfileOptions{
+		StorageType: types.StorageTypeS3,
+		S3Bucket:    "work-bucket",
+		S3Region:    "us-west-2",
+	}
+	require.NoError(t, pm.CreateProfile("work", options))
+	require.NoError(t, pm.CreateProfile("other", nil))
+
+	t.Run("inactive profile", func(t *testing.T) {
+		require.NoError(t, pm.RenameProfile("other", "personal"))
+
+		names, err := pm.ListProfileNames()
+		require.NoError(t, err)
+		assert.Contains(t, names, "personal")
+		assert.NotContains(t, names, "other")
+		assert.Equal(t, "default", pm.GetActiveProfile())
+	})
+
+	t.Run("active profile follows rename", func(t *testing.T) {
+		require.NoError(t, pm.SwitchProfile("work"))
+		require.NoError(t, pm.RenameProfile("work", "job"))
+
+		assert.Equal(t, "job", pm.GetActiveProfile())
+
+		// The pointer on disk is updated too
+		data, err := os.ReadFile(filepath.Join(pm.viperManager.globalConfigDir, "active_profile"))
+		require.NoError(t, err)
+		assert.Equal(t, "job", strings.TrimSpace(string(data)))
+
+		config, err := pm.GetConfig("job")
+		require.NoError(t, err)
+		assert.Equal(t, "work-bucket", config.Storage.S3.Bucket)
+
+		_, err = os.Stat(filepath.Join(pm.viperManager.profilesConfigDir, "work.toml"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestProfileManager_RenameProfile_Errors(t *testing.T) {
+	pm := setupTestProfileManager(t)
+	require.NoError(t, pm.CreateProfile("work", nil))
+	require.NoError(t, pm.CreateProfile("personal", nil))
+
+	tests := []struct {
+		name    string
+		oldName string
+		newName string
+		errMsg  string
+	}{
+		{"invalid source name", "", "target", "invalid source profile name"},
+		{"invalid target name", "work", "a/b", "invalid target profile name"},
+		{"default profile", "default", "main", "cannot rename the default profile"},
+		{"rename to default", "work", "default", "already exists"},
+		{"existing target", "work", "personal", "already exists"},
+		{"missing source", "missing", "target", "does not exist"},
+		{"same name", "work", "work", "already has that name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pm.RenameProfile(tt.oldName, tt.newName)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	// Failed renames leave both profiles in place
+	names, err := pm.ListProfileNames()
+	require.NoError(t, err)
+	assert.Contains(t, names, "work")
+	assert.Contains(t, names, "personal")
+}
+
 func TestProfileManager_SetGetProfileValue(t *testing.T) {
 	pm := setupTestProfileManager(t)
 
@@ -388,11 +683,6 @@ func TestProfileManager_SetGetProfileValue(t *testing.T) {
 			key:   "vault.name",
 			value: "test-vault",
 		},
-		{
-			name:  "storage type",
-			key:   "storage.type",
-			value: "s3",
-		},
 		{
 			name:  "s3 bucket",
 			key:   "storage.s3.bucket",
@@ -403,6 +693,12 @@ func TestProfileManager_SetGetProfileValue(t *testing.T) {
 			key:   "storage.s3.region",
 			value: "eu-west-1",
 		},
+		{
+			// Switching to s3 requires the bucket and region set above
+			name:  "storage type",
+			key:   "storage.type",
+			value: "s3",
+		},
 		{
 			name:  "local path",
 			key:   "storage.local.path",
@@ -527,6 +823,55 @@ func TestProfileManager_ExportImportProfile(t *testing.T) {
 	assert.Equal(t, exportedConfig.Storage.S3.Bucket, importedConfig.Storage.S3.Bucket)
 }
 
+func TestProfileManager_ExportImportProfileFile(t *testing.T) {
+	pm := setupTestProfileManager(t)
+
+	config := types.DefaultConfig()
+	config.Vault.Name = "file-vault"
+	config.Storage.S3.SecretAccessKey = "s3-secret"
+	config.Storage.S3.SessionToken = "${AWS_SESSION_TOKEN}"
+	require.NoError(t, pm.ImportProfile("source", config))
+
+	path := filepath.Join(t.TempDir(), "source.toml")
+	redacted, err := pm.ExportProfileFile("source", path, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"storage.s3.secret_access_key"}, redacted)
+
+	require.NoError(t, pm.ImportProfileFile("copy", path))
+	imported, err := pm.GetRawConfig("copy")
+	require.NoError(t, err)
+	assert.Equal(t, "file-vault", imported.Vault.Name)
+	assert.Empty(t, imported.Storage.S3.SecretAccessKey)
+	assert.Equal(t, "${AWS_SESSION_TOKEN}", imported.Storage.S3.SessionToken)
+
+	// Existing profiles are not overwritten
+	err = pm.ImportProfileFile("source", path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestProfileManager_ImportProfileFile_Schema(t *testing.T) {
+	pm := setupTestProfileManager(t)
+	dir := t.TempDir()
+
+	// Files in the pre-versioning layout are migrated on import
+	legacy := filepath.Join(dir, "legacy.toml")
+	require.NoError(t, os.WriteFile(legacy, []byte("[vault]\nname = \"legacy\"\n\n[storage]\ntype = \"local\"\npath = \"./legacy\"\n"), 0644))
+	require.NoError(t, pm.ImportProfileFile("legacy", legacy))
+	imported, err := pm.GetRawConfig("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "./legacy", imported.Storage.Local.Path)
+
+	future := filepath.Join(dir, "future.toml")
+	require.NoError(t, os.WriteFile(future, []byte("schema_version = 99\n"), 0644))
+	err = pm.ImportProfileFile("future", future)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than the supported version")
+
+	err = pm.ImportProfileFile("missing", filepath.Join(dir, "missing.toml"))
+	assert.Error(t, err)
+}
+
 func TestValidateProfileName(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// secretKeys are the configuration keys, by their last segment, that hold
+// credentials
+var secretKeys = map[string]bool{
+	"secret_access_key": true,
+	"session_token":     true,
+	"account_key":       true,
+	"connection_string": true,
+	"api_key":           true,
+	"passphrase":        true,
+}
+
+// RedactSecrets clears credentials such as storage.s3.secret_access_key
+// and API keys so the configuration can be shared. Values that are just a
+// ${VAR} reference are kept since they don't contain the secret itself.
+// It returns the keys that were cleared.
+func RedactSecrets(config *types.Config) []string {
+	if config == nil {
+		return nil
+	}
+
+	var redacted []string
+	redactValue(reflect.ValueOf(config).Elem(), "", &redacted)
+	return redacted
+}
+
+// redactValue walks the structs in v, clearing secret string fields
+func redactValue(v reflect.Value, path string, redacted *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			redactValue(v.Elem(), path, redacted)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := fieldName(field)
+			fieldValue := v.Field(i)
+			if secretKeys[name] && fieldValue.Kind() == reflect.String {
+				value := fieldValue.String()
+				if value != "" && envRefPattern.FindString(value) != value {
+					fieldValue.SetString("")
+					*redacted = append(*redacted, joinFieldPath(path, name))
+				}
+				continue
+			}
+			redactValue(fieldValue, joinFieldPath(path, name), redacted)
+		}
+	}
+}
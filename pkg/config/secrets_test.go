@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	config := types.DefaultConfig()
+	config.Storage.S3.AccessKeyID = "AKIAEXAMPLE"
+	config.Storage.S3.SecretAccessKey = "s3-secret"
+	config.Storage.S3.SessionToken = "${AWS_SESSION_TOKEN}"
+	config.Storage.Azure.ConnectionString = "DefaultEndpointsProtocol=https;AccountKey=abc"
+	config.VectorSearch.Embedding.OpenAI.APIKey = "sk-${SUFFIX}"
+	config.Storage.Encryption.Passphrase = "correct horse"
+
+	redacted := RedactSecrets(config)
+
+	assert.ElementsMatch(t, []string{
+		"storage.s3.secret_access_key",
+		"storage.azure.connection_string",
+		"vector_search.embedding.openai.api_key",
+		"storage.encryption.passphrase",
+	}, redacted)
+	assert.Empty(t, config.Storage.S3.SecretAccessKey)
+	assert.Empty(t, config.Storage.Azure.ConnectionString)
+	assert.Empty(t, config.VectorSearch.Embedding.OpenAI.APIKey)
+	assert.Empty(t, config.Storage.Encryption.Passphrase)
+
+	// Plain references and non-secret values are kept
+	assert.Equal(t, "${AWS_SESSION_TOKEN}", config.Storage.S3.SessionToken)
+	assert.Equal(t, "AKIAEXAMPLE", config.Storage.S3.AccessKeyID)
+
+	assert.Nil(t, RedactSecrets(nil))
+}
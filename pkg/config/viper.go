@@ -1,11 +1,15 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 	"github.com/spf13/viper"
 )
@@ -24,6 +28,9 @@ type ViperManager struct {
 	// Configuration directory paths
 	globalConfigDir   string
 	profilesConfigDir string
+
+	// strictEnv makes references to unset environment variables an error
+	strictEnv bool
 }
 
 // NewViperManager creates a new Viper-based configuration manager
@@ -64,10 +71,9 @@ func (vm *ViperManager) initGlobalConfig() error {
 	vm.global.AddConfigPath(".")
 	vm.global.AddConfigPath("./config")
 
-	// Set environment variable prefix
-	vm.global.SetEnvPrefix("KBVAULT")
-	vm.global.AutomaticEnv()
-	vm.global.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	// Environment variables are layered in by resolveConfig, so this
+	// instance only holds defaults and the file and never writes env values
+	// back out
 
 	// Set default values
 	vm.setDefaultValues(vm.global)
@@ -107,13 +113,40 @@ func (vm *ViperManager) loadActiveProfile() error {
 	return nil
 }
 
-// GetConfig returns the configuration for the specified profile
+// SetStrictEnv controls whether ${VAR} references to unset environment
+// variables fail config loading instead of being left as literals
+func (vm *ViperManager) SetStrictEnv(strict bool) {
+	vm.strictEnv = strict
+}
+
+// GetConfig returns the configuration for the specified profile with
+// environment variable overrides applied and ${VAR} references expanded.
 // If profile is empty, uses the active profile
 func (vm *ViperManager) GetConfig(profile string) (*types.Config, error) {
+	return vm.resolveConfig(profile, true)
+}
+
+// GetRawConfig returns the configuration for the specified profile as
+// stored in the config files, without environment variable overrides or
+// expanded references. Use it when the configuration will be written back
+// so that values from the environment are not persisted.
+func (vm *ViperManager) GetRawConfig(profile string) (*types.Config, error) {
+	return vm.resolveConfig(profile, false)
+}
+
+// resolveConfig layers the settings for a profile. Later layers win:
+// defaults, the global file, the profile file, global KBVAULT_<KEY>
+// variables, then profile KBVAULT_<PROFILE>_<KEY> variables.
+func (vm *ViperManager) resolveConfig(profile string, applyEnv bool) (*types.Config, error) {
 	if profile == "" {
 		profile = vm.activeProfile
 	}
 
+	// Bring profiles written by older versions up to the current schema
+	if err := vm.migrateIfNeeded(profile); err != nil {
+		return nil, fmt.Errorf("failed to migrate profile %s: %w", profile, err)
+	}
+
 	// Load profile if not already loaded
 	if err := vm.loadProfile(profile); err != nil {
 		return nil, fmt.Errorf("failed to load profile %s: %w", profile, err)
@@ -129,15 +162,37 @@ func (vm *ViperManager) GetConfig(profile string) (*types.Config, error) {
 	config := types.DefaultConfig()
 
 	// Then, unmarshal global settings (overwrites defaults)
-	if err := vm.global.Unmarshal(config); err != nil {
+	if err := vm.global.Unmarshal(config, decodeTOMLTags); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal global config: %w", err)
 	}
 
-	// Finally, unmarshal profile-specific settings (overwrites global)
-	if err := profileViper.Unmarshal(config); err != nil {
+	// Then, unmarshal profile-specific settings (overwrites global)
+	if err := profileViper.Unmarshal(config, decodeTOMLTags); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal profile config: %w", err)
 	}
 
+	if applyEnv {
+		// Finally, environment variables override both files; profiles
+		// with names that would clash with global variables get no env layer
+		envLayers := []*viper.Viper{envViper(globalEnvPrefix)}
+		if checkProfileEnvName(profile) == nil {
+			envLayers = append(envLayers, envViper(profileEnvPrefix(profile)))
+		}
+		strict := vm.strictEnv || vm.global.GetBool("strict_env") || profileViper.GetBool("strict_env")
+		for _, env := range envLayers {
+			if err := env.Unmarshal(config, decodeTOMLTags); err != nil {
+				return nil, fmt.Errorf("failed to apply environment variables: %w", err)
+			}
+			strict = strict || env.GetBool("strict_env")
+		}
+
+		// Expand ${VAR} references; strict mode can also be enabled with the
+		// strict_env config option or KBVAULT_STRICT_ENV
+		if err := ExpandEnv(config, strict); err != nil {
+			return nil, fmt.Errorf("failed to expand environment variables: %w", err)
+		}
+	}
+
 	// Validate the final configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
@@ -158,12 +213,6 @@ func (vm *ViperManager) loadProfile(profile string) error {
 	profileViper.SetConfigType("toml")
 	profileViper.AddConfigPath(vm.profilesConfigDir)
 
-	// Set environment variable prefix with profile
-	envPrefix := fmt.Sprintf("KBVAULT_%s", strings.ToUpper(profile))
-	profileViper.SetEnvPrefix(envPrefix)
-	profileViper.AutomaticEnv()
-	profileViper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
-
 	// Try to read profile config file
 	if err := profileViper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -182,6 +231,10 @@ func (vm *ViperManager) CreateProfile(name string, config *types.Config) error {
 		return fmt.Errorf("profile name cannot be empty")
 	}
 
+	if err := vm.checkProfileEnvClash(name, ""); err != nil {
+		return err
+	}
+
 	// Validate the configuration before saving
 	if err := config.Validate(); err != nil {
 		return fmt.Errorf("profile configuration validation failed: %w", err)
@@ -199,7 +252,7 @@ func (vm *ViperManager) CreateProfile(name string, config *types.Config) error {
 	vm.setConfigValues(profileViper, config)
 
 	// Write profile config file
-	profilePath := filepath.Join(vm.profilesConfigDir, name+".toml")
+	profilePath := vm.profilePath(name)
 	if err := profileViper.WriteConfigAs(profilePath); err != nil {
 		return fmt.Errorf("failed to write profile config: %w", err)
 	}
@@ -207,6 +260,10 @@ func (vm *ViperManager) CreateProfile(name string, config *types.Config) error {
 	// Add to loaded profiles
 	vm.profiles[name] = profileViper
 
+	if err := vm.recordProfileCreated(name, time.Now()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -221,13 +278,16 @@ func (vm *ViperManager) DeleteProfile(name string) error {
 	}
 
 	// Remove config file
-	profilePath := filepath.Join(vm.profilesConfigDir, name+".toml")
+	profilePath := vm.profilePath(name)
 	if err := os.Remove(profilePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove profile config file: %w", err)
 	}
 
 	// Remove from loaded profiles
 	delete(vm.profiles, name)
+	if err := vm.forgetProfileMetadata(name); err != nil {
+		return err
+	}
 
 	// If this was the active profile, switch to default
 	if vm.activeProfile == name {
@@ -239,6 +299,78 @@ func (vm *ViperManager) DeleteProfile(name string) error {
 	return nil
 }
 
+// RenameProfile moves a profile's config file to a new name. If the profile
+// was active, the active profile follows it to the new name.
+func (vm *ViperManager) RenameProfile(oldName, newName string) error {
+	if oldName == "" || newName == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+
+	if oldName == "default" || newName == "default" {
+		return fmt.Errorf("cannot rename the default profile")
+	}
+
+	if err := vm.checkProfileEnvClash(newName, oldName); err != nil {
+		return err
+	}
+
+	oldPath := filepath.Join(vm.profilesConfigDir, oldName+".toml")
+	newPath := filepath.Join(vm.profilesConfigDir, newName+".toml")
+
+	data, err := os.ReadFile(oldPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("profile %s does not exist", oldName)
+		}
+		return fmt.Errorf("failed to read profile config file: %w", err)
+	}
+
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat profile config file: %w", err)
+	}
+
+	// Copy the file as-is so comments and ${VAR} references are kept.
+	// O_EXCL refuses to overwrite a profile that already has the new name.
+	file, err := os.OpenFile(newPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("profile %s already exists", newName)
+		}
+		return fmt.Errorf("failed to create profile config file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(newPath)
+		return fmt.Errorf("failed to write profile config file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(newPath)
+		return fmt.Errorf("failed to write profile config file: %w", err)
+	}
+
+	// Point the active profile at the new name before the old file goes away
+	if vm.activeProfile == oldName {
+		if err := vm.SetActiveProfile(newName); err != nil {
+			_ = os.Remove(newPath)
+			return fmt.Errorf("failed to switch to renamed profile: %w", err)
+		}
+	}
+
+	if err := os.Remove(oldPath); err != nil {
+		return fmt.Errorf("failed to remove old profile config file: %w", err)
+	}
+
+	delete(vm.profiles, oldName)
+	delete(vm.profiles, newName)
+
+	if err := vm.renameProfileMetadata(oldName, newName); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ListProfiles returns a list of available profiles
 func (vm *ViperManager) ListProfiles() ([]string, error) {
 	profiles := []string{"default"} // default is always available
@@ -350,7 +482,7 @@ func (vm *ViperManager) SaveProfile(name string, config *types.Config) error {
 // GetGlobalConfig returns the global configuration (without profile overrides)
 func (vm *ViperManager) GetGlobalConfig() (*types.Config, error) {
 	config := &types.Config{}
-	if err := vm.global.Unmarshal(config); err != nil {
+	if err := vm.global.Unmarshal(config, decodeTOMLTags); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal global config: %w", err)
 	}
 	return config, nil
@@ -372,28 +504,134 @@ func (vm *ViperManager) SaveGlobalConfig(config *types.Config) error {
 	return nil
 }
 
-// setDefaultValues sets default configuration values
+// writeConfigFile writes a configuration to path in the same TOML layout
+// as profile files. The file is only readable by its owner since it may
+// hold credentials.
+func (vm *ViperManager) writeConfigFile(path string, config *types.Config) error {
+	v := viper.New()
+	v.SetConfigType("toml")
+	vm.setConfigValues(v, config)
+
+	var buf bytes.Buffer
+	if err := v.WriteConfigTo(&buf); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// readConfigFile reads a configuration written by writeConfigFile, or a
+// profile file, on top of the defaults. Files from older schema versions
+// are migrated in memory.
+func (vm *ViperManager) readConfigFile(path string) (*types.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	settings := make(map[string]interface{})
+	if _, err := toml.Decode(string(data), &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	version, err := schemaVersion(settings)
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	if version > types.CurrentSchemaVersion {
+		return nil, fmt.Errorf("config file %s has schema version %d, newer than the supported version %d",
+			path, version, types.CurrentSchemaVersion)
+	}
+	applyMigrations(settings, version)
+
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
+	}
+
+	config := types.DefaultConfig()
+	if err := v.Unmarshal(config, decodeTOMLTags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// decodeTOMLTags makes Viper decode using the toml struct tags so that
+// multi-word keys such as storage.s3.secret_access_key reach their fields
+func decodeTOMLTags(dc *mapstructure.DecoderConfig) {
+	dc.TagName = "toml"
+}
+
+// storageClassRulesValue converts storage class rules to plain maps, which
+// Viper writes as a TOML array of tables
+func storageClassRulesValue(rules []types.StorageClassRule) []map[string]interface{} {
+	values := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		values = append(values, map[string]interface{}{
+			"prefix": rule.Prefix,
+			"class":  rule.Class,
+		})
+	}
+	return values
+}
+
+// corsRoutesValue converts CORS routes to plain maps, which Viper writes
+// as a TOML array of tables
+func corsRoutesValue(routes []types.CORSRoute) []map[string]interface{} {
+	values := make([]map[string]interface{}, 0, len(routes))
+	for _, route := range routes {
+		values = append(values, map[string]interface{}{
+			"prefix":  route.Prefix,
+			"origins": route.Origins,
+		})
+	}
+	return values
+}
+
+// setDefaultValues sets default configuration values at the lowest
+// priority, so values read from the config file take precedence
 func (vm *ViperManager) setDefaultValues(v *viper.Viper) {
-	defaultConfig := types.DefaultConfig()
-	vm.setConfigValues(v, defaultConfig)
+	defaults := viper.New()
+	vm.setConfigValues(defaults, types.DefaultConfig())
+	for _, key := range defaults.AllKeys() {
+		v.SetDefault(key, defaults.Get(key))
+	}
 }
 
 // setConfigValues sets configuration values in a Viper instance
 func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
+	// Files are always written in the current schema layout
+	v.Set("schema_version", types.CurrentSchemaVersion)
+
 	// Vault configuration
 	v.Set("vault.name", config.Vault.Name)
 	v.Set("vault.notes_dir", config.Vault.NotesDir)
 	v.Set("vault.daily_dir", config.Vault.DailyDir)
+	v.Set("vault.extra_dirs", config.Vault.ExtraDirs)
+	v.Set("vault.ignore", config.Vault.Ignore)
 	v.Set("vault.templates_dir", config.Vault.TemplatesDir)
 	v.Set("vault.default_template", config.Vault.DefaultTemplate)
 	v.Set("vault.max_file_size", config.Vault.MaxFileSize)
 	v.Set("vault.date_format", config.Vault.DateFormat)
 	v.Set("vault.time_format", config.Vault.TimeFormat)
+	v.Set("vault.title_source", config.Vault.TitleSource)
+	v.Set("vault.id_scheme", config.Vault.IDScheme)
+	v.Set("vault.inline_tags", config.Vault.InlineTags)
 	v.Set("vault.auto_save", config.Vault.AutoSave)
 	v.Set("vault.auto_sync", config.Vault.AutoSync)
+	v.Set("vault.track_checksums", config.Vault.TrackChecksums)
+	v.Set("vault.frontmatter_schema.required", config.Vault.FrontmatterSchema.Required)
+	v.Set("vault.frontmatter_schema.allowed", config.Vault.FrontmatterSchema.Allowed)
 
 	// Storage configuration
 	v.Set("storage.type", config.Storage.Type)
+	v.Set("storage.compression", config.Storage.Compression)
+	v.Set("storage.compression_stat_size", config.Storage.CompressionStatSize)
+	v.Set("storage.encryption.enabled", config.Storage.Encryption.Enabled)
+	v.Set("storage.encryption.passphrase", config.Storage.Encryption.Passphrase)
+	v.Set("storage.encryption.key_file", config.Storage.Encryption.KeyFile)
 
 	// Local storage
 	v.Set("storage.local.path", config.Storage.Local.Path)
@@ -402,6 +640,7 @@ func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
 	v.Set("storage.local.file_perms", config.Storage.Local.FilePerms)
 	v.Set("storage.local.enable_locking", config.Storage.Local.EnableLocking)
 	v.Set("storage.local.lock_timeout", config.Storage.Local.LockTimeout)
+	v.Set("storage.local.version_retention", config.Storage.Local.VersionRetention)
 
 	// S3 storage
 	v.Set("storage.s3.bucket", config.Storage.S3.Bucket)
@@ -410,16 +649,38 @@ func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
 	v.Set("storage.s3.access_key_id", config.Storage.S3.AccessKeyID)
 	v.Set("storage.s3.secret_access_key", config.Storage.S3.SecretAccessKey)
 	v.Set("storage.s3.session_token", config.Storage.S3.SessionToken)
+	v.Set("storage.s3.credential_source", config.Storage.S3.CredentialSource)
 	v.Set("storage.s3.use_ssl", config.Storage.S3.UseSSL)
 	v.Set("storage.s3.path_style", config.Storage.S3.PathStyle)
 	v.Set("storage.s3.prefix", config.Storage.S3.Prefix)
 	v.Set("storage.s3.storage_class", config.Storage.S3.StorageClass)
+	v.Set("storage.s3.storage_class_rules", storageClassRulesValue(config.Storage.S3.StorageClassRules))
 	v.Set("storage.s3.server_side_encryption", config.Storage.S3.ServerSideEncryption)
 	v.Set("storage.s3.kms_key_id", config.Storage.S3.KMSKeyID)
 	v.Set("storage.s3.retry_attempts", config.Storage.S3.RetryAttempts)
 	v.Set("storage.s3.retry_delay", config.Storage.S3.RetryDelay)
+	v.Set("storage.s3.retry_max_backoff", config.Storage.S3.RetryMaxBackoff)
+	v.Set("storage.s3.retry_mode", config.Storage.S3.RetryMode)
 	v.Set("storage.s3.request_timeout", config.Storage.S3.RequestTimeout)
+	v.Set("storage.s3.max_idle_conns", config.Storage.S3.MaxIdleConns)
+	v.Set("storage.s3.max_idle_conns_per_host", config.Storage.S3.MaxIdleConnsPerHost)
+	v.Set("storage.s3.idle_conn_timeout", config.Storage.S3.IdleConnTimeout)
+	v.Set("storage.s3.proxy", config.Storage.S3.Proxy)
 	v.Set("storage.s3.enable_versioning", config.Storage.S3.EnableVersioning)
+	v.Set("storage.s3.verify_checksums", config.Storage.S3.VerifyChecksums)
+	v.Set("storage.s3.select_enabled", config.Storage.S3.SelectEnabled)
+	v.Set("storage.s3.upload_part_size_mb", config.Storage.S3.UploadPartSizeMB)
+	v.Set("storage.s3.upload_concurrency", config.Storage.S3.UploadConcurrency)
+
+	// Azure Blob storage
+	v.Set("storage.azure.account_name", config.Storage.Azure.AccountName)
+	v.Set("storage.azure.account_key", config.Storage.Azure.AccountKey)
+	v.Set("storage.azure.connection_string", config.Storage.Azure.ConnectionString)
+	v.Set("storage.azure.container_name", config.Storage.Azure.ContainerName)
+	v.Set("storage.azure.endpoint", config.Storage.Azure.Endpoint)
+	v.Set("storage.azure.prefix", config.Storage.Azure.Prefix)
+	v.Set("storage.azure.retry_attempts", config.Storage.Azure.RetryAttempts)
+	v.Set("storage.azure.request_timeout", config.Storage.Azure.RequestTimeout)
 
 	// Cache configuration
 	v.Set("storage.cache.enabled", config.Storage.Cache.Enabled)
@@ -440,10 +701,14 @@ func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
 	v.Set("server.http.port", config.Server.HTTP.Port)
 	v.Set("server.http.enable_cors", config.Server.HTTP.EnableCORS)
 	v.Set("server.http.cors_origins", config.Server.HTTP.CORSOrigins)
+	v.Set("server.http.cors_routes", corsRoutesValue(config.Server.HTTP.CORSRoutes))
 	v.Set("server.http.read_timeout", config.Server.HTTP.ReadTimeout)
 	v.Set("server.http.write_timeout", config.Server.HTTP.WriteTimeout)
 	v.Set("server.http.idle_timeout", config.Server.HTTP.IdleTimeout)
 	v.Set("server.http.max_request_size", config.Server.HTTP.MaxRequestSize)
+	v.Set("server.http.enable_metrics", config.Server.HTTP.EnableMetrics)
+	v.Set("server.http.max_websocket_connections", config.Server.HTTP.MaxWebSocketConnections)
+	v.Set("server.http.search_debounce_ms", config.Server.HTTP.SearchDebounceMS)
 
 	// Logging configuration
 	v.Set("logging.level", config.Logging.Level)
@@ -456,6 +721,10 @@ func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
 	v.Set("logging.rotate_size", config.Logging.RotateSize)
 	v.Set("logging.rotate_count", config.Logging.RotateCount)
 
+	// Tracing configuration
+	v.Set("tracing.otlp_endpoint", config.Tracing.OTLPEndpoint)
+	v.Set("tracing.service_name", config.Tracing.ServiceName)
+
 	// TUI configuration
 	v.Set("tui.theme", config.TUI.Theme)
 	v.Set("tui.vim_mode", config.TUI.VimMode)
@@ -463,6 +732,7 @@ func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
 	v.Set("tui.refresh_interval", config.TUI.RefreshInterval)
 	v.Set("tui.page_size", config.TUI.PageSize)
 	v.Set("tui.enable_mouse", config.TUI.EnableMouse)
+	v.Set("tui.enable_colors", config.TUI.EnableColors)
 
 	// MCP configuration
 	v.Set("mcp.enabled", config.MCP.Enabled)
@@ -481,12 +751,34 @@ func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
 	v.Set("vector_search.embedding.provider", config.VectorSearch.Embedding.Provider)
 	v.Set("vector_search.embedding.model", config.VectorSearch.Embedding.Model)
 	v.Set("vector_search.embedding.dimensions", config.VectorSearch.Embedding.Dimensions)
+	v.Set("vector_search.embedding.rate_limit_rpm", config.VectorSearch.Embedding.RateLimitRPM)
 
 	// OpenAI embedding configuration
 	v.Set("vector_search.embedding.openai.api_key", config.VectorSearch.Embedding.OpenAI.APIKey)
 	v.Set("vector_search.embedding.openai.model", config.VectorSearch.Embedding.OpenAI.Model)
 	v.Set("vector_search.embedding.openai.base_url", config.VectorSearch.Embedding.OpenAI.BaseURL)
 
+	// Cohere embedding configuration
+	v.Set("vector_search.embedding.cohere.api_key", config.VectorSearch.Embedding.Cohere.APIKey)
+	v.Set("vector_search.embedding.cohere.model", config.VectorSearch.Embedding.Cohere.Model)
+	v.Set("vector_search.embedding.cohere.input_type", config.VectorSearch.Embedding.Cohere.InputType)
+	v.Set("vector_search.embedding.cohere.base_url", config.VectorSearch.Embedding.Cohere.BaseURL)
+
+	// Azure OpenAI embedding configuration
+	v.Set("vector_search.embedding.azure.endpoint", config.VectorSearch.Embedding.Azure.Endpoint)
+	v.Set("vector_search.embedding.azure.api_key", config.VectorSearch.Embedding.Azure.APIKey)
+	v.Set("vector_search.embedding.azure.deployment_name", config.VectorSearch.Embedding.Azure.DeploymentName)
+	v.Set("vector_search.embedding.azure.api_version", config.VectorSearch.Embedding.Azure.APIVersion)
+
+	// Hugging Face embedding configuration
+	v.Set("vector_search.embedding.huggingface.api_key", config.VectorSearch.Embedding.HuggingFace.APIKey)
+	v.Set("vector_search.embedding.huggingface.model", config.VectorSearch.Embedding.HuggingFace.Model)
+	v.Set("vector_search.embedding.huggingface.endpoint_url", config.VectorSearch.Embedding.HuggingFace.EndpointURL)
+
+	// Ollama embedding configuration
+	v.Set("vector_search.embedding.ollama.base_url", config.VectorSearch.Embedding.Ollama.BaseURL)
+	v.Set("vector_search.embedding.ollama.model", config.VectorSearch.Embedding.Ollama.Model)
+
 	// Local vector configuration
 	v.Set("vector_search.local.database_path", config.VectorSearch.Local.DatabasePath)
 	v.Set("vector_search.local.engine", config.VectorSearch.Local.Engine)
@@ -503,5 +795,10 @@ func (vm *ViperManager) setConfigValues(v *viper.Viper, config *types.Config) {
 	v.Set("vector_search.search.hybrid_enabled", config.VectorSearch.Search.HybridEnabled)
 	v.Set("vector_search.search.hybrid_weight", config.VectorSearch.Search.HybridWeight)
 	v.Set("vector_search.search.default_limit", config.VectorSearch.Search.DefaultLimit)
+	v.Set("vector_search.search.max_limit", config.VectorSearch.Search.MaxLimit)
 	v.Set("vector_search.search.min_score", config.VectorSearch.Search.MinScore)
+	v.Set("vector_search.search.enable_reranking", config.VectorSearch.Search.EnableReranking)
+	v.Set("vector_search.search.reranking_model", config.VectorSearch.Search.RerankingModel)
+	v.Set("vector_search.search.reranking_endpoint", config.VectorSearch.Search.RerankingEndpoint)
+	v.Set("vector_search.search.reranking_api_key", config.VectorSearch.Search.RerankingAPIKey)
 }
@@ -176,6 +176,7 @@ func TestViperManager_SaveProfile(t *testing.T) {
 	modifiedConfig.Vault.Name = "modified"
 	modifiedConfig.Storage.Type = types.StorageTypeS3
 	modifiedConfig.Storage.S3.Bucket = "new-bucket"
+	modifiedConfig.Storage.S3.Region = "us-east-1"
 
 	err = vm.SaveProfile("save-test", modifiedConfig)
 	assert.NoError(t, err)
@@ -188,6 +189,48 @@ func TestViperManager_SaveProfile(t *testing.T) {
 	assert.Equal(t, "new-bucket", loadedConfig.Storage.S3.Bucket)
 }
 
+func TestViperManager_StorageClassRules(t *testing.T) {
+	vm := setupTestViperManager(t)
+
+	config := types.DefaultConfig()
+	config.Storage.Type = types.StorageTypeS3
+	config.Storage.S3.Bucket = "kb"
+	config.Storage.S3.Region = "us-east-1"
+	config.Storage.S3.StorageClass = "STANDARD"
+	config.Storage.S3.StorageClassRules = []types.StorageClassRule{
+		{Prefix: "daily/", Class: "STANDARD_IA"},
+		{Prefix: "archive/", Class: "GLACIER"},
+	}
+	require.NoError(t, vm.CreateProfile("classes", config))
+
+	loadedConfig, err := vm.GetConfig("classes")
+	require.NoError(t, err)
+	assert.Equal(t, config.Storage.S3.StorageClassRules, loadedConfig.Storage.S3.StorageClassRules)
+
+	// Profiles without rules load with none
+	require.NoError(t, vm.CreateProfile("plain", types.DefaultConfig()))
+	loadedConfig, err = vm.GetConfig("plain")
+	require.NoError(t, err)
+	assert.Empty(t, loadedConfig.Storage.S3.StorageClassRules)
+}
+
+func TestViperManager_CORSRoutes(t *testing.T) {
+	vm := setupTestViperManager(t)
+
+	config := types.DefaultConfig()
+	config.Server.HTTP.CORSOrigins = []string{"https://app.example.com"}
+	config.Server.HTTP.CORSRoutes = []types.CORSRoute{
+		{Prefix: "/api/search", Origins: []string{"*"}},
+		{Prefix: "/api", Origins: []string{"https://*.example.com", "http://localhost:3000"}},
+	}
+	require.NoError(t, vm.CreateProfile("cors", config))
+
+	loadedConfig, err := vm.GetConfig("cors")
+	require.NoError(t, err)
+	assert.Equal(t, config.Server.HTTP.CORSOrigins, loadedConfig.Server.HTTP.CORSOrigins)
+	assert.Equal(t, config.Server.HTTP.CORSRoutes, loadedConfig.Server.HTTP.CORSRoutes)
+}
+
 func TestViperManager_GlobalConfig(t *testing.T) {
 	vm := setupTestViperManager(t)
 
@@ -244,43 +287,125 @@ func TestViperManager_EnvironmentVariables(t *testing.T) {
 	err := vm.CreateProfile("env-test", config)
 	require.NoError(t, err)
 
-	// Set environment variables for profile-specific overrides
-	// Note: Global env vars are overridden by set defaults in initGlobalConfig
-	// Profile-specific env vars work properly (tested in TestViperManager_ProfileSpecificEnvironmentVariables)
+	// Dashes in the profile name become underscores in the prefix
 	t.Setenv("KBVAULT_ENV_TEST_VAULT_NAME", "env-vault")
 	t.Setenv("KBVAULT_ENV_TEST_STORAGE_TYPE", "s3")
 	t.Setenv("KBVAULT_ENV_TEST_STORAGE_S3_BUCKET", "env-bucket")
 	t.Setenv("KBVAULT_ENV_TEST_STORAGE_S3_REGION", "us-west-2")
+	t.Setenv("KBVAULT_ENV_TEST_STORAGE_CACHE_ENABLED", "false")
 
-	// Verify profile was created
 	retrievedConfig, err := vm.GetConfig("env-test")
-	assert.NoError(t, err)
-	assert.NotNil(t, retrievedConfig)
-	assert.Equal(t, "test-vault", retrievedConfig.Vault.Name)
+	require.NoError(t, err)
+	assert.Equal(t, "env-vault", retrievedConfig.Vault.Name)
+	assert.Equal(t, types.StorageTypeS3, retrievedConfig.Storage.Type)
+	assert.Equal(t, "env-bucket", retrievedConfig.Storage.S3.Bucket)
+	assert.Equal(t, "us-west-2", retrievedConfig.Storage.S3.Region)
+	assert.False(t, retrievedConfig.Storage.Cache.Enabled)
+
+	// The raw configuration is what the file holds, so saving it back
+	// doesn't persist values from the environment
+	raw, err := vm.GetRawConfig("env-test")
+	require.NoError(t, err)
+	assert.Equal(t, "test-vault", raw.Vault.Name)
+	assert.Equal(t, types.StorageTypeLocal, raw.Storage.Type)
 }
 
 func TestViperManager_ProfileSpecificEnvironmentVariables(t *testing.T) {
 	vm := setupTestViperManager(t)
 
-	// Create a work profile
+	// Create work and home profiles
 	config := types.DefaultConfig()
-	err := vm.CreateProfile("work", config)
-	require.NoError(t, err)
+	require.NoError(t, vm.CreateProfile("work", config))
+	require.NoError(t, vm.CreateProfile("home", config))
 
 	// Set profile-specific environment variables
 	t.Setenv("KBVAULT_WORK_VAULT_NAME", "work-vault")
-	t.Setenv("KBVAULT_WORK_STORAGE_S3_BUCKET", "work-bucket")
+	t.Setenv("KBVAULT_WORK_SERVER_HTTP_PORT", "9090")
 
-	// Create new manager to pick up env vars
-	vm2 := setupTestViperManager(t)
+	workConfig, err := vm.GetConfig("work")
+	require.NoError(t, err)
+	assert.Equal(t, "work-vault", workConfig.Vault.Name)
+	assert.Equal(t, 9090, workConfig.Server.HTTP.Port)
 
-	// Load work profile
-	workConfig, err := vm2.GetConfig("work")
-	assert.NoError(t, err)
+	// Other profiles are unaffected
+	homeConfig, err := vm.GetConfig("home")
+	require.NoError(t, err)
+	assert.Equal(t, config.Vault.Name, homeConfig.Vault.Name)
+	assert.Equal(t, config.Server.HTTP.Port, homeConfig.Server.HTTP.Port)
+}
+
+func TestViperManager_Precedence(t *testing.T) {
+	// Each layer overrides the previous one:
+	// defaults < global file < profile file < global env < profile env
+	vm := setupTestViperManager(t)
+
+	vaultName := func() string {
+		t.Helper()
+		config, err := vm.GetConfig("work")
+		require.NoError(t, err)
+		return config.Vault.Name
+	}
+
+	assert.Equal(t, types.DefaultConfig().Vault.Name, vaultName())
+
+	globalFile := filepath.Join(vm.globalConfigDir, "config.toml")
+	require.NoError(t, os.WriteFile(globalFile, []byte("[vault]\nname = \"global-file\"\n"), 0644))
+	require.NoError(t, vm.global.ReadInConfig())
+	assert.Equal(t, "global-file", vaultName())
 
-	// Note: Profile-specific env vars require the profile to be loaded fresh
-	// This tests the environment variable structure is correct
-	assert.NotNil(t, workConfig)
+	profileFile := filepath.Join(vm.profilesConfigDir, "work.toml")
+	profileTOML := fmt.Sprintf("schema_version = %d\n\n[vault]\nname = \"profile-file\"\n", types.CurrentSchemaVersion)
+	require.NoError(t, os.WriteFile(profileFile, []byte(profileTOML), 0644))
+	delete(vm.profiles, "work")
+	assert.Equal(t, "profile-file", vaultName())
+
+	t.Setenv("KBVAULT_VAULT_NAME", "global-env")
+	assert.Equal(t, "global-env", vaultName())
+
+	t.Setenv("KBVAULT_WORK_VAULT_NAME", "profile-env")
+	assert.Equal(t, "profile-env", vaultName())
+}
+
+func TestViperManager_ProfileEnvNameClashes(t *testing.T) {
+	vm := setupTestViperManager(t)
+	config := types.DefaultConfig()
+
+	require.NoError(t, vm.CreateProfile("my-work", config))
+
+	// my_work and "my work" would read the same KBVAULT_MY_WORK_* variables
+	err := vm.CreateProfile("my_work", config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KBVAULT_MY_WORK_*")
+	assert.Error(t, vm.CreateProfile("My Work", config))
+
+	// A profile named after a config section shadows global variables:
+	// KBVAULT_STORAGE_CACHE_ENABLED is also the global storage.cache.enabled
+	err = vm.CreateProfile("storage", config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reserved")
+	assert.Error(t, vm.CreateProfile("vector", config))
+	assert.Error(t, vm.CreateProfile("--", config))
+
+	// Renaming may keep the same variables, but not take another profile's
+	require.NoError(t, vm.CreateProfile("home", config))
+	assert.Error(t, vm.RenameProfile("home", "my_work"))
+	assert.Error(t, vm.RenameProfile("home", "vault"))
+	assert.NoError(t, vm.RenameProfile("my-work", "my_work"))
+}
+
+func TestProfileEnvPrefix(t *testing.T) {
+	tests := map[string]string{
+		"work":     "KBVAULT_WORK",
+		"my-work":  "KBVAULT_MY_WORK",
+		"my.work":  "KBVAULT_MY_WORK",
+		"Team 2":   "KBVAULT_TEAM_2",
+		"café":     "KBVAULT_CAF_",
+		"default":  "KBVAULT_DEFAULT",
+		"prod_eu1": "KBVAULT_PROD_EU1",
+	}
+	for profile, want := range tests {
+		assert.Equal(t, want, profileEnvPrefix(profile), profile)
+	}
 }
 
 func TestViperManager_ConfigValidation(t *testing.T) {
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Path is the route metrics are served on
+const Path = "/metrics"
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler serves the registry in the Prometheus text format. Only GET and
+// HEAD are allowed.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		if req.Method == http.MethodHead {
+			return
+		}
+		if err := r.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Mount registers the metrics route on mux when config.EnableMetrics is set
+// and reports whether it did
+func Mount(mux *http.ServeMux, config types.HTTPServerConfig, r *Registry) bool {
+	if !config.EnableMetrics {
+		return false
+	}
+	mux.Handle(Path, r.Handler())
+	return true
+}
@@ -0,0 +1,236 @@
+// Package metrics records operation counts and latencies and renders them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Status label values
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// DefaultBuckets are the histogram upper bounds, in seconds, used for
+// operation latencies
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry collects kbVault metrics. It is safe for concurrent use.
+type Registry struct {
+	mu sync.Mutex
+
+	storageOps      *counterVec
+	storageDuration *histogramVec
+	searches        *counterVec
+	searchDuration  *histogramVec
+	retries         *counterVec
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		storageOps: newCounterVec("kbvault_storage_operations_total",
+			"Storage operations by backend, operation and status.", "backend", "operation", "status"),
+		storageDuration: newHistogramVec("kbvault_storage_operation_duration_seconds",
+			"Storage operation latency in seconds.", DefaultBuckets, "backend", "operation"),
+		searches: newCounterVec("kbvault_search_requests_total",
+			"Search requests by status.", "status"),
+		searchDuration: newHistogramVec("kbvault_search_duration_seconds",
+			"Search latency in seconds.", DefaultBuckets),
+		retries: newCounterVec("kbvault_retries_total",
+			"Retried attempts by source.", "source"),
+	}
+}
+
+// ObserveStorage records one storage operation
+func (r *Registry) ObserveStorage(backend types.StorageType, operation string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.storageOps.inc(string(backend), operation, status(err))
+	r.storageDuration.observe(d.Seconds(), string(backend), operation)
+}
+
+// ObserveSearch records one search request
+func (r *Registry) ObserveSearch(d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.searches.inc(status(err))
+	r.searchDuration.observe(d.Seconds())
+}
+
+// IncRetry records a retried attempt for source, such as "storage" or
+// "embedding"
+func (r *Registry) IncRetry(source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retries.inc(source)
+}
+
+// RetryHook returns a function for retry.Config.OnRetry that counts
+// retries for source
+func (r *Registry) RetryHook(source string) func(attempt int, err error) {
+	return func(int, error) {
+		r.IncRetry(source)
+	}
+}
+
+// WritePrometheus writes every metric in the Prometheus text format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	var b strings.Builder
+	r.storageOps.write(&b)
+	r.storageDuration.write(&b)
+	r.searches.write(&b)
+	r.searchDuration.write(&b)
+	r.retries.write(&b)
+	r.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusOK
+}
+
+// counterVec is a counter partitioned by label values
+type counterVec struct {
+	name, help string
+	labels     []string
+	values     map[string]*counterSample
+}
+
+type counterSample struct {
+	labelValues []string
+	value       uint64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]*counterSample)}
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	sample, ok := c.values[key]
+	if !ok {
+		sample = &counterSample{labelValues: labelValues}
+		c.values[key] = sample
+	}
+	sample.value++
+}
+
+func (c *counterVec) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		sample := c.values[key]
+		fmt.Fprintf(b, "%s%s %d\n", c.name, formatLabels(c.labels, sample.labelValues, "", ""), sample.value)
+	}
+}
+
+// histogramVec is a histogram partitioned by label values
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	values     map[string]*histogramSample
+}
+
+type histogramSample struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*histogramSample)}
+}
+
+func (h *histogramVec) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	sample, ok := h.values[key]
+	if !ok {
+		sample = &histogramSample{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = sample
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			sample.counts[i]++
+		}
+	}
+	sample.count++
+	sample.sum += value
+}
+
+func (h *histogramVec) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.values) {
+		sample := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, sample.labelValues, "le", formatFloat(bound)), sample.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, sample.labelValues, "le", "+Inf"), sample.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", h.name, formatLabels(h.labels, sample.labelValues, "", ""), formatFloat(sample.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", h.name, formatLabels(h.labels, sample.labelValues, "", ""), sample.count)
+	}
+}
+
+// formatLabels renders {name="value",...}, with an optional extra label
+// appended, or nothing when there are no labels
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, escapeLabel(values[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extraName, extraValue))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escapeLabel drops characters that %q would escape differently from the
+// exposition format. Label values here are backend types and operation
+// names, so nothing meaningful is lost.
+func escapeLabel(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
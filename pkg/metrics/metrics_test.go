@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	var b strings.Builder
+	require.NoError(t, r.WritePrometheus(&b))
+	return b.String()
+}
+
+func TestRegistry_Empty(t *testing.T) {
+	out := render(t, NewRegistry())
+
+	assert.Contains(t, out, "# TYPE kbvault_storage_operations_total counter")
+	assert.Contains(t, out, "# TYPE kbvault_storage_operation_duration_seconds histogram")
+	assert.Contains(t, out, "# TYPE kbvault_search_duration_seconds histogram")
+	assert.Contains(t, out, "# TYPE kbvault_retries_total counter")
+	assert.NotContains(t, out, "kbvault_storage_operations_total{")
+}
+
+func TestRegistry_ObserveStorage(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveStorage(types.StorageTypeS3, OpRead, 3*time.Millisecond, nil)
+	r.ObserveStorage(types.StorageTypeS3, OpRead, 200*time.Millisecond, nil)
+	r.ObserveStorage(types.StorageTypeS3, OpRead, time.Millisecond, errors.New("boom"))
+
+	out := render(t, r)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="s3",operation="read",status="ok"} 2`)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="s3",operation="read",status="error"} 1`)
+	assert.Contains(t, out, `kbvault_storage_operation_duration_seconds_bucket{backend="s3",operation="read",le="0.005"} 2`)
+	assert.Contains(t, out, `kbvault_storage_operation_duration_seconds_bucket{backend="s3",operation="read",le="0.25"} 3`)
+	assert.Contains(t, out, `kbvault_storage_operation_duration_seconds_bucket{backend="s3",operation="read",le="+Inf"} 3`)
+	assert.Contains(t, out, `kbvault_storage_operation_duration_seconds_count{backend="s3",operation="read"} 3`)
+	assert.Contains(t, out, `kbvault_storage_operation_duration_seconds_sum{backend="s3",operation="read"} 0.204`)
+}
+
+func TestRegistry_SearchAndRetries(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveSearch(20*time.Millisecond, nil)
+	r.ObserveSearch(time.Second, errors.New("index unavailable"))
+
+	hook := r.RetryHook("storage")
+	hook(0, errors.New("timeout"))
+	hook(1, errors.New("timeout"))
+	r.IncRetry("embedding")
+
+	out := render(t, r)
+	assert.Contains(t, out, `kbvault_search_requests_total{status="ok"} 1`)
+	assert.Contains(t, out, `kbvault_search_requests_total{status="error"} 1`)
+	assert.Contains(t, out, `kbvault_search_duration_seconds_bucket{le="0.025"} 1`)
+	assert.Contains(t, out, `kbvault_search_duration_seconds_count 2`)
+	assert.Contains(t, out, `kbvault_retries_total{source="storage"} 2`)
+	assert.Contains(t, out, `kbvault_retries_total{source="embedding"} 1`)
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.IncRetry("storage")
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `kbvault_retries_total{source="storage"} 1`)
+
+	rec = httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, Path, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestMount(t *testing.T) {
+	r := NewRegistry()
+
+	mux := http.NewServeMux()
+	assert.False(t, Mount(mux, types.HTTPServerConfig{}, r))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	mux = http.NewServeMux()
+	assert.True(t, Mount(mux, types.HTTPServerConfig{EnableMetrics: true}, r))
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Operation label values for storage metrics
+const (
+	OpRead        = "read"
+	OpWrite       = "write"
+	OpDelete      = "delete"
+	OpExists      = "exists"
+	OpList        = "list"
+	OpStat        = "stat"
+	OpReadStream  = "read_stream"
+	OpWriteStream = "write_stream"
+	OpCopy        = "copy"
+	OpMove        = "move"
+	OpHealth      = "health"
+)
+
+// StorageWrapper wraps a storage backend and records the count, status and
+// latency of every call. Like the retry and cache wrappers it is itself a
+// backend, so the wrappers can be stacked; wrapping the retry wrapper
+// measures whole calls including retries, wrapping the cache measures what
+// callers see.
+type StorageWrapper struct {
+	backend  types.StorageBackend
+	registry *Registry
+}
+
+// NewStorageWrapper creates a storage wrapper that records into registry
+func NewStorageWrapper(backend types.StorageBackend, registry *Registry) *StorageWrapper {
+	return &StorageWrapper{
+		backend:  backend,
+		registry: registry,
+	}
+}
+
+// observe records an operation that started at start
+func (w *StorageWrapper) observe(operation string, start time.Time, err error) {
+	w.registry.ObserveStorage(w.backend.Type(), operation, time.Since(start), err)
+}
+
+// Type returns the storage backend type
+func (w *StorageWrapper) Type() types.StorageType {
+	return w.backend.Type()
+}
+
+// Read with metrics
+func (w *StorageWrapper) Read(ctx context.Context, path string) ([]byte, error) {
+	start := time.Now()
+	data, err := w.backend.Read(ctx, path)
+	w.observe(OpRead, start, err)
+	return data, err
+}
+
+// Write with metrics
+func (w *StorageWrapper) Write(ctx context.Context, path string, data []byte) error {
+	start := time.Now()
+	err := w.backend.Write(ctx, path, data)
+	w.observe(OpWrite, start, err)
+	return err
+}
+
+// Delete with metrics
+func (w *StorageWrapper) Delete(ctx context.Context, path string) error {
+	start := time.Now()
+	err := w.backend.Delete(ctx, path)
+	w.observe(OpDelete, start, err)
+	return err
+}
+
+// Exists with metrics
+func (w *StorageWrapper) Exists(ctx context.Context, path string) (bool, error) {
+	start := time.Now()
+	exists, err := w.backend.Exists(ctx, path)
+	w.observe(OpExists, start, err)
+	return exists, err
+}
+
+// List with metrics
+func (w *StorageWrapper) List(ctx context.Context, prefix string) ([]string, error) {
+	start := time.Now()
+	files, err := w.backend.List(ctx, prefix)
+	w.observe(OpList, start, err)
+	return files, err
+}
+
+// Stat with metrics
+func (w *StorageWrapper) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
+	start := time.Now()
+	info, err := w.backend.Stat(ctx, path)
+	w.observe(OpStat, start, err)
+	return info, err
+}
+
+// ReadStream with metrics. The latency covers opening the stream, not
+// reading it.
+func (w *StorageWrapper) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := w.backend.ReadStream(ctx, path)
+	w.observe(OpReadStream, start, err)
+	return reader, err
+}
+
+// WriteStream with metrics
+func (w *StorageWrapper) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	start := time.Now()
+	err := w.backend.WriteStream(ctx, path, reader)
+	w.observe(OpWriteStream, start, err)
+	return err
+}
+
+// Copy with metrics
+func (w *StorageWrapper) Copy(ctx context.Context, src, dst string) error {
+	start := time.Now()
+	err := w.backend.Copy(ctx, src, dst)
+	w.observe(OpCopy, start, err)
+	return err
+}
+
+// Move with metrics
+func (w *StorageWrapper) Move(ctx context.Context, src, dst string) error {
+	start := time.Now()
+	err := w.backend.Move(ctx, src, dst)
+	w.observe(OpMove, start, err)
+	return err
+}
+
+// Health with metrics
+func (w *StorageWrapper) Health(ctx context.Context) error {
+	start := time.Now()
+	err := w.backend.Health(ctx)
+	w.observe(OpHealth, start, err)
+	return err
+}
+
+// Close delegates to underlying backend
+func (w *StorageWrapper) Close() error {
+	return w.backend.Close()
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/retry"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// flakyBackend fails the first failures reads with a retryable error
+type flakyBackend struct {
+	types.StorageBackend
+	failures int
+}
+
+func (b *flakyBackend) Read(ctx context.Context, path string) ([]byte, error) {
+	if b.failures > 0 {
+		b.failures--
+		return nil, &types.StorageError{
+			Backend:   types.StorageTypeLocal,
+			Operation: "read",
+			Path:      path,
+			Err:       types.NewStorageTimeoutError("local", "read"),
+			Retryable: true,
+		}
+	}
+	return b.StorageBackend.Read(ctx, path)
+}
+
+func newLocalBackend(t *testing.T) types.StorageBackend {
+	t.Helper()
+
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+	return backend
+}
+
+func TestStorageWrapper_CountsOperations(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	w := NewStorageWrapper(newLocalBackend(t), r)
+
+	require.NoError(t, w.Write(ctx, "notes/a.md", []byte("# A")))
+	data, err := w.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# A", string(data))
+
+	_, err = w.Read(ctx, "notes/missing.md")
+	require.Error(t, err)
+
+	exists, err := w.Exists(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, w.Delete(ctx, "notes/a.md"))
+	assert.Equal(t, types.StorageTypeLocal, w.Type())
+
+	out := render(t, r)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="local",operation="write",status="ok"} 1`)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="local",operation="read",status="ok"} 1`)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="local",operation="read",status="error"} 1`)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="local",operation="exists",status="ok"} 1`)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="local",operation="delete",status="ok"} 1`)
+	assert.Contains(t, out, `kbvault_storage_operation_duration_seconds_count{backend="local",operation="read"} 2`)
+}
+
+func TestStorageWrapper_ComposesWithRetry(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+
+	backend := &flakyBackend{StorageBackend: newLocalBackend(t), failures: 2}
+	require.NoError(t, backend.Write(ctx, "a.md", []byte("a")))
+
+	// Inner wrapper sees each attempt, outer wrapper sees the whole call
+	config := &retry.Config{
+		MaxAttempts: 3,
+		Backoff:     retry.NewExponentialBackoff(time.Millisecond, time.Millisecond),
+		ShouldRetry: retry.StorageErrorShouldRetry,
+		OnRetry:     r.RetryHook("storage"),
+	}
+	attempts := NewRegistry()
+	stack := NewStorageWrapper(retry.NewStorageRetryWrapper(NewStorageWrapper(backend, attempts), config, nil), r)
+
+	data, err := stack.Read(ctx, "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+
+	out := render(t, r)
+	assert.Contains(t, out, `kbvault_storage_operations_total{backend="local",operation="read",status="ok"} 1`)
+	assert.False(t, strings.Contains(out, `operation="read",status="error"`))
+	assert.Contains(t, out, `kbvault_retries_total{source="storage"} 2`)
+
+	inner := render(t, attempts)
+	assert.Contains(t, inner, `kbvault_storage_operations_total{backend="local",operation="read",status="error"} 2`)
+	assert.Contains(t, inner, `kbvault_storage_operations_total{backend="local",operation="read",status="ok"} 1`)
+}
@@ -0,0 +1,29 @@
+package note
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// checksumPrefix names the hash used for note checksums
+const checksumPrefix = "sha256:"
+
+// BodyChecksum returns the checksum recorded in frontmatter for a note
+// body, e.g. "sha256:9f86d0...". Leading blank lines are ignored since
+// SplitFrontmatter drops them when the note is read back.
+func BodyChecksum(body string) string {
+	sum := sha256.Sum256([]byte(strings.TrimLeft(body, "\r\n")))
+	return checksumPrefix + hex.EncodeToString(sum[:])
+}
+
+// UpdateChecksum sets the frontmatter checksum for body when track is set
+// or the frontmatter already carries one, so a recorded checksum is never
+// left stale by a write
+func UpdateChecksum(fm *types.Frontmatter, body string, track bool) {
+	if track || fm.Checksum != "" {
+		fm.Checksum = BodyChecksum(body)
+	}
+}
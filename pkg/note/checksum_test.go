@@ -0,0 +1,69 @@
+package note
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestBodyChecksum(t *testing.T) {
+	sum := BodyChecksum("# Note\n\nBody\n")
+	if !strings.HasPrefix(sum, "sha256:") || len(sum) != len("sha256:")+64 {
+		t.Fatalf("unexpected checksum format %q", sum)
+	}
+	if got := BodyChecksum("# Note\n\nBody\n"); got != sum {
+		t.Errorf("checksum not stable: %q != %q", got, sum)
+	}
+	if got := BodyChecksum("# Note\n\nBody edited\n"); got == sum {
+		t.Error("editing the body did not change the checksum")
+	}
+	if got := BodyChecksum("\n\n# Note\n\nBody\n"); got != sum {
+		t.Error("leading blank lines changed the checksum")
+	}
+}
+
+func TestBodyChecksum_RoundTrip(t *testing.T) {
+	body := "\n# Note\n\nBody\n"
+	fm := types.Frontmatter{ID: "n1", Title: "Note"}
+	UpdateChecksum(&fm, body, true)
+
+	front, readBody, ok := SplitFrontmatter(string(SerializeNote(fm, body)))
+	if !ok {
+		t.Fatal("expected frontmatter")
+	}
+	parsed, err := ParseFrontmatter(front)
+	if err != nil {
+		t.Fatalf("ParseFrontmatter: %v", err)
+	}
+	if parsed.Checksum != fm.Checksum {
+		t.Errorf("checksum = %q, want %q", parsed.Checksum, fm.Checksum)
+	}
+	if BodyChecksum(readBody) != parsed.Checksum {
+		t.Error("checksum does not match the body read back")
+	}
+
+	// The lenient line parser reads it as well
+	if got := parseFrontmatterLines(front).Checksum; got != fm.Checksum {
+		t.Errorf("line parser checksum = %q, want %q", got, fm.Checksum)
+	}
+}
+
+func TestUpdateChecksum(t *testing.T) {
+	var fm types.Frontmatter
+	UpdateChecksum(&fm, "body", false)
+	if fm.Checksum != "" {
+		t.Errorf("checksum added without tracking: %q", fm.Checksum)
+	}
+
+	UpdateChecksum(&fm, "body", true)
+	if fm.Checksum != BodyChecksum("body") {
+		t.Errorf("checksum = %q", fm.Checksum)
+	}
+
+	// An existing checksum is kept current even when tracking is off
+	UpdateChecksum(&fm, "new body", false)
+	if fm.Checksum != BodyChecksum("new body") {
+		t.Errorf("stale checksum %q", fm.Checksum)
+	}
+}
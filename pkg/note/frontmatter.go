@@ -0,0 +1,234 @@
+package note
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// frontmatterFence opens and closes a YAML frontmatter block
+const frontmatterFence = "---"
+
+// SplitFrontmatter separates a leading YAML frontmatter block from the note
+// body. ok is false when the content has no complete frontmatter block, in
+// which case body is the full content.
+func SplitFrontmatter(content string) (frontmatter, body string, ok bool) {
+	if !strings.HasPrefix(content, frontmatterFence) {
+		return "", content, false
+	}
+
+	rest := strings.TrimPrefix(content, frontmatterFence)
+	end := strings.Index(rest, "\n"+frontmatterFence)
+	if end == -1 {
+		return "", content, false
+	}
+
+	frontmatter = strings.TrimLeft(rest[:end], "\r\n")
+	body = rest[end+len("\n"+frontmatterFence):]
+
+	// Drop the remainder of the closing fence line
+	if nl := strings.IndexByte(body, '\n'); nl != -1 {
+		body = body[nl+1:]
+	} else {
+		body = ""
+	}
+
+	return frontmatter, strings.TrimLeft(body, "\r\n"), true
+}
+
+// ParseFrontmatter decodes a YAML frontmatter block. Keys without a
+// dedicated field are kept in Custom so that they survive a rewrite.
+func ParseFrontmatter(frontmatter string) (types.Frontmatter, error) {
+	var fm types.Frontmatter
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(frontmatter), &doc); err != nil {
+		return fm, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return fm, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fm, fmt.Errorf("invalid frontmatter: expected key/value pairs")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+
+		var err error
+		switch key {
+		case "id":
+			err = value.Decode(&fm.ID)
+		case "title":
+			err = value.Decode(&fm.Title)
+		case "tags":
+			fm.Tags, err = decodeTags(value)
+		case "type":
+			err = value.Decode(&fm.Type)
+		case "created":
+			err = value.Decode(&fm.Created)
+		case "updated":
+			err = value.Decode(&fm.Updated)
+		case "storage":
+			err = value.Decode(&fm.Storage)
+		case "template":
+			err = value.Decode(&fm.Template)
+		case "checksum":
+			err = value.Decode(&fm.Checksum)
+		default:
+			var custom interface{}
+			if err = value.Decode(&custom); err == nil {
+				if fm.Custom == nil {
+					fm.Custom = make(map[string]interface{})
+				}
+				fm.Custom[key] = custom
+			}
+		}
+		if err != nil {
+			return fm, fmt.Errorf("invalid frontmatter field %s: %w", key, err)
+		}
+	}
+
+	return fm, nil
+}
+
+// decodeTags accepts both a YAML list and a comma-separated string
+func decodeTags(value *yaml.Node) ([]string, error) {
+	if value.Kind == yaml.ScalarNode {
+		var tags []string
+		for _, tag := range strings.Split(value.Value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		return tags, nil
+	}
+
+	var tags []string
+	err := value.Decode(&tags)
+	return tags, err
+}
+
+// SerializeNote renders frontmatter and body as a markdown note. Empty
+// fields are omitted and custom fields are written as top-level keys after
+// the standard ones, so ParseFrontmatter reads back the same values.
+func SerializeNote(fm types.Frontmatter, body string) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(frontmatterFence + "\n")
+	writeField(&buf, "id", fm.ID)
+	writeField(&buf, "title", fm.Title)
+	if len(fm.Tags) > 0 {
+		buf.WriteString("tags:\n")
+		for _, tag := range fm.Tags {
+			fmt.Fprintf(&buf, "  - %s\n", yamlScalar(tag))
+		}
+	}
+	writeField(&buf, "type", fm.Type)
+	writeField(&buf, "storage", fm.Storage)
+	writeTimestamp(&buf, "created", fm.Created)
+	writeTimestamp(&buf, "updated", fm.Updated)
+	writeField(&buf, "template", fm.Template)
+	writeField(&buf, "checksum", fm.Checksum)
+
+	keys := make([]string, 0, len(fm.Custom))
+	for key := range fm.Custom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeCustomField(&buf, key, fm.Custom[key])
+	}
+
+	buf.WriteString(frontmatterFence + "\n\n")
+	buf.WriteString(body)
+
+	return buf.Bytes()
+}
+
+// FormatTimestamp formats a time the way frontmatter timestamps are stored
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// timestampLayouts are the frontmatter date formats ParseTimestamp accepts,
+// kbvault's own first
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimestamp parses a frontmatter timestamp. Besides RFC 3339 it
+// accepts the date-only and space-separated forms common in hand-written
+// notes, which are taken as UTC.
+func ParseTimestamp(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func writeField(buf *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(buf, "%s: %s\n", key, yamlScalar(value))
+}
+
+// writeTimestamp writes RFC 3339 timestamps unquoted, matching how notes
+// are created
+func writeTimestamp(buf *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339, value); err == nil {
+		fmt.Fprintf(buf, "%s: %s\n", key, value)
+		return
+	}
+	writeField(buf, key, value)
+}
+
+func writeCustomField(buf *bytes.Buffer, key string, value interface{}) {
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]interface{}{key: value}); err != nil {
+		return
+	}
+	_ = encoder.Close()
+	buf.Write(out.Bytes())
+}
+
+// yamlScalar returns value as a YAML scalar, quoting it only when a plain
+// scalar would be read back as something else
+func yamlScalar(value string) string {
+	var decoded interface{}
+	if !strings.ContainsAny(value, "\n\r") && yaml.Unmarshal([]byte(value), &decoded) == nil {
+		if s, ok := decoded.(string); ok && s == value {
+			return value
+		}
+	}
+
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%q", value)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
@@ -0,0 +1,128 @@
+package note
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	front, body, ok := SplitFrontmatter("---\ntitle: Test\n---\n\n# Body\n")
+	if !ok {
+		t.Fatal("expected frontmatter to be found")
+	}
+	if front != "title: Test" {
+		t.Errorf("frontmatter = %q", front)
+	}
+	if body != "# Body\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	for _, content := range []string{"# No frontmatter\n", "---\ntitle: unterminated\n"} {
+		_, body, ok := SplitFrontmatter(content)
+		if ok || body != content {
+			t.Errorf("SplitFrontmatter(%q) = %q, %v; want full content, false", content, body, ok)
+		}
+	}
+}
+
+func TestParseFrontmatter(t *testing.T) {
+	fm, err := ParseFrontmatter(strings.Join([]string{
+		"id: 01ABC",
+		"title: \"Colon: title\"",
+		"tags: go, testing",
+		"created: 2024-01-02T03:04:05Z",
+		"status: draft",
+		"priority: 2",
+	}, "\n"))
+	if err != nil {
+		t.Fatalf("ParseFrontmatter: %v", err)
+	}
+
+	if fm.ID != "01ABC" || fm.Title != "Colon: title" {
+		t.Errorf("id/title = %q/%q", fm.ID, fm.Title)
+	}
+	if strings.Join(fm.Tags, ",") != "go,testing" {
+		t.Errorf("tags = %v", fm.Tags)
+	}
+	if fm.Created != "2024-01-02T03:04:05Z" {
+		t.Errorf("created = %q", fm.Created)
+	}
+	if fm.Custom["status"] != "draft" || fm.Custom["priority"] != 2 {
+		t.Errorf("custom = %v", fm.Custom)
+	}
+
+	if _, err := ParseFrontmatter("title: [unclosed"); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
+
+func TestSerializeNoteRoundTrip(t *testing.T) {
+	fm := types.Frontmatter{
+		ID:      "01ABC",
+		Title:   "Notes: a review",
+		Tags:    []string{"go", "yes", "123"},
+		Type:    "default",
+		Created: FormatTimestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Custom: map[string]interface{}{
+			"status":  "draft",
+			"aliases": []interface{}{"one", "two"},
+		},
+	}
+
+	data := SerializeNote(fm, "# Body\n")
+	content := string(data)
+	if !strings.Contains(content, "created: 2024-01-02T03:04:05Z\n") {
+		t.Errorf("timestamp should be written unquoted:\n%s", content)
+	}
+	if strings.Contains(content, "updated:") {
+		t.Errorf("empty fields should be omitted:\n%s", content)
+	}
+
+	front, body, ok := SplitFrontmatter(content)
+	if !ok {
+		t.Fatalf("serialized note has no frontmatter:\n%s", content)
+	}
+	if body != "# Body\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	parsed, err := ParseFrontmatter(front)
+	if err != nil {
+		t.Fatalf("ParseFrontmatter: %v", err)
+	}
+	if parsed.Title != fm.Title || parsed.Created != fm.Created {
+		t.Errorf("title/created = %q/%q", parsed.Title, parsed.Created)
+	}
+	if strings.Join(parsed.Tags, ",") != "go,yes,123" {
+		t.Errorf("tags = %v", parsed.Tags)
+	}
+	if parsed.Custom["status"] != "draft" || len(parsed.Custom["aliases"].([]interface{})) != 2 {
+		t.Errorf("custom = %v", parsed.Custom)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"2024-03-01T10:00:00Z", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), true},
+		{"2024-03-01T12:00:00+02:00", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), true},
+		{"2024-03-01T10:00:00", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), true},
+		{"2024-03-01 10:00", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), true},
+		{" 2024-03-01 ", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"", time.Time{}, false},
+		{"last tuesday", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseTimestamp(tt.value)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/ulid"
+)
+
+// timestampLayout formats the seconds of a timestamp ID; milliseconds are
+// appended as three digits
+const timestampLayout = "20060102T150405"
+
+var (
+	timestampMu   sync.Mutex
+	lastTimestamp time.Time
+
+	// now returns the current time; replaced in tests
+	now = time.Now
+)
+
+// GenerateID returns a new note ID for title using scheme, one of the
+// types.IDScheme values. Unknown or empty schemes use the default, ULID.
+//
+// ulid and timestamp IDs don't depend on the title, which lives only in
+// the frontmatter, and are unique within the process. slug IDs are the
+// title in lowercase with hyphens, so notes with the same title get the
+// same ID; slug-timestamp appends a timestamp to keep them apart.
+func GenerateID(title string, scheme string) string {
+	switch types.IDScheme(strings.ToLower(strings.TrimSpace(scheme))) {
+	case types.IDSchemeSlug:
+		return slugify(title)
+	case types.IDSchemeSlugTimestamp:
+		return slugify(title) + "-" + nextTimestamp()
+	case types.IDSchemeTimestamp:
+		return nextTimestamp()
+	default:
+		return ulid.New()
+	}
+}
+
+// slugify turns a title into a URL-safe ID: lowercase letters, digits and
+// single hyphens. A title with none of those yields "note".
+func slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			hyphen = true
+		}
+	}
+
+	if b.Len() == 0 {
+		return "note"
+	}
+	return b.String()
+}
+
+// nextTimestamp returns the current UTC time to the millisecond, e.g.
+// 20240301T100000123. Each call returns a later time than the last, so IDs
+// generated within the same millisecond stay unique.
+func nextTimestamp() string {
+	timestampMu.Lock()
+	defer timestampMu.Unlock()
+
+	t := now().UTC().Truncate(time.Millisecond)
+	if !t.After(lastTimestamp) {
+		t = lastTimestamp.Add(time.Millisecond)
+	}
+	lastTimestamp = t
+
+	return t.Format(timestampLayout) + fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
+}
@@ -0,0 +1,113 @@
+package note
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/ulid"
+)
+
+func TestGenerateID_Slug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"HTTP Routing", "http-routing"},
+		{"Go: Concurrency & Channels", "go-concurrency-channels"},
+		{"  snake_case -- title  ", "snake-case-title"},
+		{"C++ guide", "c-guide"},
+		{"!!!", "note"},
+	}
+
+	for _, tt := range tests {
+		if got := GenerateID(tt.title, "slug"); got != tt.want {
+			t.Errorf("GenerateID(%q, slug) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+
+	// Slugs depend only on the title, so equal titles collide
+	if GenerateID("Same", "slug") != GenerateID("Same", "slug") {
+		t.Error("slug IDs should be deterministic")
+	}
+}
+
+func TestGenerateID_Schemes(t *testing.T) {
+	if id := GenerateID("Title", "ulid"); !ulid.IsValid(id) {
+		t.Errorf("ulid scheme gave %q", id)
+	}
+	if id := GenerateID("Title", ""); !ulid.IsValid(id) {
+		t.Errorf("default scheme gave %q", id)
+	}
+
+	if id := GenerateID("Title", "timestamp"); !regexp.MustCompile(`^\d{8}T\d{9}$`).MatchString(id) {
+		t.Errorf("timestamp scheme gave %q", id)
+	}
+	if id := GenerateID("My Title", "slug-timestamp"); !regexp.MustCompile(`^my-title-\d{8}T\d{9}$`).MatchString(id) {
+		t.Errorf("slug-timestamp scheme gave %q", id)
+	}
+}
+
+func TestGenerateID_Unique(t *testing.T) {
+	for _, scheme := range []string{"ulid", "timestamp", "slug-timestamp"} {
+		t.Run(scheme, func(t *testing.T) {
+			const n = 2000
+			seen := make(map[string]bool, n)
+			for i := 0; i < n; i++ {
+				id := GenerateID("Same Title", scheme)
+				if seen[id] {
+					t.Fatalf("duplicate ID %q after %d IDs", id, i)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestGenerateID_TimestampCollisions(t *testing.T) {
+	// A clock that doesn't move, or moves backwards, still yields
+	// increasing IDs
+	fixed := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	oldNow := now
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() { now = oldNow })
+
+	var (
+		mu  sync.Mutex
+		ids []string
+		wg  sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := GenerateID("", "timestamp")
+				mu.Lock()
+				ids = append(ids, id)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate ID %q", id)
+		}
+		seen[id] = true
+	}
+
+	fixed = fixed.Add(-time.Hour)
+	a := GenerateID("", "timestamp")
+	b := GenerateID("", "timestamp")
+	if strings.Compare(a, b) >= 0 {
+		t.Errorf("IDs should increase: %q then %q", a, b)
+	}
+	if _, ok := seen[a]; ok {
+		t.Errorf("ID %q reused after the clock went back", a)
+	}
+}
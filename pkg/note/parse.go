@@ -0,0 +1,185 @@
+package note
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// DefaultType is the note type assumed when frontmatter doesn't set one
+const DefaultType = "note"
+
+// ParseOptions controls how note files are parsed
+type ParseOptions struct {
+	// TitleSources is the order in which the title is looked up; empty
+	// means frontmatter, then first heading, then filename
+	TitleSources []types.TitleSource
+}
+
+// Parse builds a note from a markdown file using the default title
+// precedence. See ParseWithOptions.
+func Parse(filePath string, data []byte) *types.Note {
+	return ParseWithOptions(filePath, data, ParseOptions{})
+}
+
+// ParseWithOptions builds a note from a markdown file. The ID is taken from
+// the file name, matching how notes are looked up, and the title is
+// resolved with ResolveTitle. Frontmatter that isn't valid YAML is read
+// line by line so that hand-written notes still list with their title and
+// tags.
+func ParseWithOptions(filePath string, data []byte, opts ParseOptions) *types.Note {
+	n := &types.Note{
+		ID:       strings.TrimSuffix(filepath.Base(filePath), ".md"),
+		FilePath: filePath,
+		Size:     int64(len(data)),
+	}
+
+	front, body, ok := SplitFrontmatter(string(data))
+	if !ok {
+		n.Content = string(data)
+		n.Frontmatter = types.Frontmatter{ID: n.ID, Type: DefaultType, Tags: []string{}}
+		n.Title = ResolveTitle("", n.Content, n.ID, opts.TitleSources)
+		return n
+	}
+
+	fm, err := ParseFrontmatter(front)
+	if err != nil {
+		fm = parseFrontmatterLines(front)
+	}
+	if fm.ID == "" {
+		fm.ID = n.ID
+	}
+	if fm.Type == "" {
+		fm.Type = DefaultType
+	}
+	if fm.Tags == nil {
+		fm.Tags = []string{}
+	}
+
+	n.Frontmatter = fm
+	n.Content = body
+	n.Title = ResolveTitle(fm.Title, body, n.ID, opts.TitleSources)
+
+	if t, ok := ParseTimestamp(fm.Created); ok {
+		n.CreatedAt = t
+	}
+	if t, ok := ParseTimestamp(fm.Updated); ok {
+		n.UpdatedAt = t
+	}
+
+	return n
+}
+
+// ResolveTitle picks a note title from the frontmatter title, the first
+// heading in body and the filename-derived ID, trying each source in order.
+// The default order is frontmatter, heading, filename. The ID is used when
+// no listed source has a title, so the result is never empty for a named
+// file.
+func ResolveTitle(frontmatterTitle, body, id string, order []types.TitleSource) string {
+	if len(order) == 0 {
+		order = defaultTitleSources
+	}
+
+	for _, source := range order {
+		var title string
+		switch source {
+		case types.TitleSourceFrontmatter:
+			title = strings.TrimSpace(frontmatterTitle)
+		case types.TitleSourceHeading:
+			title = ExtractTitle(body)
+		case types.TitleSourceFilename:
+			title = id
+		}
+		if title != "" {
+			return title
+		}
+	}
+
+	return id
+}
+
+var defaultTitleSources = []types.TitleSource{
+	types.TitleSourceFrontmatter,
+	types.TitleSourceHeading,
+	types.TitleSourceFilename,
+}
+
+// ExtractTitle returns the text of the first level-one heading in content
+func ExtractTitle(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return ""
+}
+
+// parseFrontmatterLines is a lenient fallback for frontmatter that isn't
+// valid YAML, such as an unquoted title containing a colon. It reads
+// "key: value" lines and tag lists and ignores anything else.
+func parseFrontmatterLines(front string) types.Frontmatter {
+	var fm types.Frontmatter
+	inTags := false
+
+	for _, line := range strings.Split(front, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if inTags {
+			if strings.HasPrefix(trimmed, "- ") {
+				if tag := unquote(strings.TrimPrefix(trimmed, "- ")); tag != "" {
+					fm.Tags = append(fm.Tags, tag)
+				}
+				continue
+			}
+			inTags = false
+		}
+
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "id":
+			fm.ID = unquote(value)
+		case "title":
+			fm.Title = unquote(value)
+		case "type":
+			fm.Type = unquote(value)
+		case "created":
+			fm.Created = unquote(value)
+		case "updated":
+			fm.Updated = unquote(value)
+		case "storage":
+			fm.Storage = unquote(value)
+		case "template":
+			fm.Template = unquote(value)
+		case "checksum":
+			fm.Checksum = unquote(value)
+		case "tags":
+			if value == "" {
+				inTags = true
+				continue
+			}
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			for _, tag := range strings.Split(value, ",") {
+				if tag = unquote(strings.TrimSpace(tag)); tag != "" {
+					fm.Tags = append(fm.Tags, tag)
+				}
+			}
+		}
+	}
+
+	return fm
+}
+
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), "\"'")
+}
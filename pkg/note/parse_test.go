@@ -0,0 +1,93 @@
+package note
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestParse(t *testing.T) {
+	n := Parse("notes/01ABC.md", []byte("---\ntitle: Parsed\ntags:\n  - a\ncreated: 2024-01-02T03:04:05Z\n---\n\n# Heading\n"))
+	if n.ID != "01ABC" || n.FilePath != "notes/01ABC.md" {
+		t.Errorf("id/path = %q/%q", n.ID, n.FilePath)
+	}
+	if n.Title != "Parsed" {
+		t.Errorf("title = %q", n.Title)
+	}
+	if n.Content != "# Heading\n" {
+		t.Errorf("content = %q", n.Content)
+	}
+	if n.Frontmatter.Type != DefaultType || n.Frontmatter.ID != "01ABC" {
+		t.Errorf("frontmatter defaults not applied: %+v", n.Frontmatter)
+	}
+	if !n.CreatedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("created = %v", n.CreatedAt)
+	}
+}
+
+func TestParseFallbacks(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantTitle string
+		wantTags  int
+	}{
+		{"heading without frontmatter", "# From Heading\n\nBody", "From Heading", 0},
+		{"no title at all", "just text", "plain", 0},
+		{"heading when frontmatter has no title", "---\ntags: [x]\n---\n\n# Heading\n", "Heading", 1},
+		{"invalid YAML frontmatter", "---\ntitle: Go: a tour\ntags:\n  - one\n  - two\n---\n\nBody", "Go: a tour", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := Parse("plain.md", []byte(tt.content))
+			if n.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", n.Title, tt.wantTitle)
+			}
+			if len(n.Frontmatter.Tags) != tt.wantTags || n.Frontmatter.Tags == nil {
+				t.Errorf("tags = %#v, want %d", n.Frontmatter.Tags, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestResolveTitle(t *testing.T) {
+	headingFirst := []types.TitleSource{types.TitleSourceHeading, types.TitleSourceFrontmatter}
+	frontmatterOnly := []types.TitleSource{types.TitleSourceFrontmatter}
+
+	tests := []struct {
+		name       string
+		frontTitle string
+		body       string
+		order      []types.TitleSource
+		want       string
+	}{
+		{"frontmatter wins by default", "Front", "# Heading\n", nil, "Front"},
+		{"heading when frontmatter is blank", "  ", "intro\n# Heading\n", nil, "Heading"},
+		{"filename last", "", "no heading", nil, "01ABC"},
+		{"heading first", "Front", "# Heading\n", headingFirst, "Heading"},
+		{"heading first falls back", "Front", "no heading", headingFirst, "Front"},
+		{"id when no source matches", "", "# Heading\n", frontmatterOnly, "01ABC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveTitle(tt.frontTitle, tt.body, "01ABC", tt.order); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	data := []byte("---\ntitle: Front\n---\n\n# Heading\n")
+	opts := ParseOptions{TitleSources: []types.TitleSource{types.TitleSourceHeading}}
+
+	if n := ParseWithOptions("01ABC.md", data, opts); n.Title != "Heading" {
+		t.Errorf("title = %q, want %q", n.Title, "Heading")
+	}
+	if n := Parse("01ABC.md", data); n.Title != "Front" {
+		t.Errorf("title = %q, want %q", n.Title, "Front")
+	}
+}
@@ -0,0 +1,135 @@
+package note
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ANSI escape sequences used by the terminal renderer
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+)
+
+// palette holds the colors used for each markdown element
+type palette struct {
+	heading string
+	code    string
+	quote   string
+	bullet  string
+}
+
+// palettes maps TUI theme names to colors. Light terminals get darker
+// colors so that text stays readable on a bright background.
+var palettes = map[string]palette{
+	"default": {heading: "\x1b[96m", code: "\x1b[33m", quote: "\x1b[90m", bullet: "\x1b[36m"},
+	"dark":    {heading: "\x1b[96m", code: "\x1b[93m", quote: "\x1b[37m", bullet: "\x1b[36m"},
+	"light":   {heading: "\x1b[34m", code: "\x1b[35m", quote: "\x1b[90m", bullet: "\x1b[34m"},
+}
+
+var (
+	headingPattern    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern     = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	rulePattern       = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	boldPattern       = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	inlineCodePattern = regexp.MustCompile("`([^`]+)`")
+)
+
+// RenderOptions controls how markdown is rendered for a terminal
+type RenderOptions struct {
+	// Color enables ANSI styling; when false the markdown is returned unchanged
+	Color bool
+
+	// Theme selects the color palette (default, dark, light)
+	Theme string
+}
+
+// RenderMarkdownTerminal renders markdown with ANSI styling for headings,
+// emphasis, lists, quotes and code using the default theme
+func RenderMarkdownTerminal(md string) string {
+	return RenderMarkdown(md, RenderOptions{Color: true, Theme: "default"})
+}
+
+// RenderMarkdown renders markdown for terminal display according to opts
+func RenderMarkdown(md string, opts RenderOptions) string {
+	if !opts.Color {
+		return md
+	}
+
+	colors, ok := palettes[opts.Theme]
+	if !ok {
+		colors = palettes["default"]
+	}
+
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// Fenced code blocks are shown verbatim without the fences
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			if lang := strings.Trim(trimmed, "`~ "); inCode && lang != "" {
+				out = append(out, ansiDim+"["+lang+"]"+ansiReset)
+			}
+			continue
+		}
+		if inCode {
+			out = append(out, "    "+colors.code+line+ansiReset)
+			continue
+		}
+
+		switch {
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			style := ansiBold + colors.heading
+			if len(m[1]) == 1 {
+				style += ansiUnderline
+			}
+			out = append(out, style+stripInline(m[2])+ansiReset)
+
+		case rulePattern.MatchString(line):
+			out = append(out, ansiDim+strings.Repeat("─", 40)+ansiReset)
+
+		case strings.HasPrefix(trimmed, ">"):
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			out = append(out, colors.quote+"│ "+ansiItalic+renderInline(text, colors)+ansiReset)
+
+		case bulletPattern.MatchString(line):
+			m := bulletPattern.FindStringSubmatch(line)
+			out = append(out, m[1]+colors.bullet+"•"+ansiReset+" "+renderInline(m[2], colors))
+
+		default:
+			out = append(out, renderInline(line, colors))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// renderInline styles bold text and inline code within a line
+func renderInline(text string, colors palette) string {
+	text = inlineCodePattern.ReplaceAllString(text, colors.code+"$1"+ansiReset)
+	return boldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return ansiBold + match[2:len(match)-2] + ansiReset
+	})
+}
+
+// stripInline removes inline markup from text that is styled as a whole
+func stripInline(text string) string {
+	text = inlineCodePattern.ReplaceAllString(text, "$1")
+	return boldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return match[2 : len(match)-2]
+	})
+}
+
+// StripFrontmatter removes a leading YAML frontmatter block from a note
+func StripFrontmatter(content string) string {
+	_, body, _ := SplitFrontmatter(content)
+	return body
+}
@@ -0,0 +1,112 @@
+package note
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownTerminal(t *testing.T) {
+	md := strings.Join([]string{
+		"# Title",
+		"## Section `code`",
+		"Some **bold** and `inline` text.",
+		"- item one",
+		"> quoted",
+		"---",
+		"```go",
+		"fmt.Println(\"**not bold**\")",
+		"```",
+	}, "\n")
+
+	out := RenderMarkdownTerminal(md)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"h1 is bold and underlined", ansiBold + palettes["default"].heading + ansiUnderline + "Title" + ansiReset},
+		{"h2 strips inline markup", ansiBold + palettes["default"].heading + "Section code" + ansiReset},
+		{"bold text", ansiBold + "bold" + ansiReset},
+		{"inline code", palettes["default"].code + "inline" + ansiReset},
+		{"bullet", "•" + ansiReset + " item one"},
+		{"quote", "│ " + ansiItalic + "quoted"},
+		{"rule", strings.Repeat("─", 40)},
+		{"code language label", "[go]"},
+		{"code block is verbatim", "    " + palettes["default"].code + "fmt.Println(\"**not bold**\")" + ansiReset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("rendered output missing %q:\n%q", tt.want, out)
+			}
+		})
+	}
+
+	if strings.Contains(out, "```") {
+		t.Error("code fences should not be rendered")
+	}
+	if strings.Contains(out, "# Title") {
+		t.Error("heading markers should not be rendered")
+	}
+}
+
+func TestRenderMarkdown_Options(t *testing.T) {
+	md := "# Title\n\nSome **bold** text."
+
+	if got := RenderMarkdown(md, RenderOptions{Color: false}); got != md {
+		t.Errorf("plain rendering should return markdown unchanged, got %q", got)
+	}
+
+	light := RenderMarkdown(md, RenderOptions{Color: true, Theme: "light"})
+	if !strings.Contains(light, palettes["light"].heading+ansiUnderline+"Title") {
+		t.Errorf("light theme heading color not used: %q", light)
+	}
+
+	unknown := RenderMarkdown(md, RenderOptions{Color: true, Theme: "unknown"})
+	if unknown != RenderMarkdownTerminal(md) {
+		t.Error("unknown theme should fall back to the default palette")
+	}
+}
+
+func TestStripFrontmatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "with frontmatter",
+			content:  "---\nid: abc\ntitle: Test\n---\n\n# Test\n\nBody",
+			expected: "# Test\n\nBody",
+		},
+		{
+			name:     "without frontmatter",
+			content:  "# Test\n\nBody",
+			expected: "# Test\n\nBody",
+		},
+		{
+			name:     "unterminated frontmatter",
+			content:  "---\nid: abc\n# Test",
+			expected: "---\nid: abc\n# Test",
+		},
+		{
+			name:     "rule inside body is kept",
+			content:  "---\nid: abc\n---\nAbove\n---\nBelow",
+			expected: "Above\n---\nBelow",
+		},
+		{
+			name:     "frontmatter only",
+			content:  "---\nid: abc\n---",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripFrontmatter(tt.content); got != tt.expected {
+				t.Errorf("StripFrontmatter() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
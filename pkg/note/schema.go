@@ -0,0 +1,130 @@
+package note
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Schema is the set of frontmatter constraints notes are checked against,
+// configured as vault.frontmatter_schema
+type Schema = types.FrontmatterSchema
+
+// SchemaViolation is one way in which frontmatter doesn't match a schema
+type SchemaViolation struct {
+	// Field is the frontmatter key concerned
+	Field string
+
+	// Message describes the problem and what is expected
+	Message string
+}
+
+// SchemaError lists every way in which frontmatter doesn't match a schema
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+// Error describes every violation
+func (e *SchemaError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "frontmatter does not match the schema: " + strings.Join(messages, "; ")
+}
+
+// ValidateFrontmatter checks fm against schema. Required fields must have a
+// non-empty value, and fields with allowed values may only take those;
+// for list fields such as tags, every element must be allowed. The
+// returned error is a *SchemaError listing all violations, or nil when fm
+// conforms.
+func ValidateFrontmatter(fm types.Frontmatter, schema Schema) error {
+	var violations []SchemaViolation
+
+	for _, field := range schema.Required {
+		if len(frontmatterValues(fm, field)) == 0 {
+			violations = append(violations, SchemaViolation{
+				Field:   field,
+				Message: fmt.Sprintf("missing required field %q", field),
+			})
+		}
+	}
+
+	fields := make([]string, 0, len(schema.Allowed))
+	for field := range schema.Allowed {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	for _, field := range fields {
+		allowed := schema.Allowed[field]
+		for _, value := range frontmatterValues(fm, field) {
+			if !slices.Contains(allowed, value) {
+				violations = append(violations, SchemaViolation{
+					Field:   field,
+					Message: fmt.Sprintf("field %q has value %q, allowed values are: %s", field, value, strings.Join(allowed, ", ")),
+				})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
+
+// frontmatterValues returns the non-empty values of field in fm as
+// strings: one for a scalar, one per element for a list, and none when the
+// field is absent or empty
+func frontmatterValues(fm types.Frontmatter, field string) []string {
+	var value interface{}
+	switch field {
+	case "id":
+		value = fm.ID
+	case "title":
+		value = fm.Title
+	case "tags":
+		value = fm.Tags
+	case "type":
+		value = fm.Type
+	case "created":
+		value = fm.Created
+	case "updated":
+		value = fm.Updated
+	case "storage":
+		value = fm.Storage
+	case "template":
+		value = fm.Template
+	case "checksum":
+		value = fm.Checksum
+	default:
+		value = fm.Custom[field]
+	}
+
+	var values []string
+	add := func(v interface{}) {
+		if v == nil {
+			return
+		}
+		if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+			values = append(values, s)
+		}
+	}
+
+	switch v := value.(type) {
+	case []string:
+		for _, element := range v {
+			add(element)
+		}
+	case []interface{}:
+		for _, element := range v {
+			add(element)
+		}
+	default:
+		add(v)
+	}
+	return values
+}
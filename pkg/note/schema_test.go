@@ -0,0 +1,110 @@
+package note
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+var testSchema = Schema{
+	Required: []string{"type", "author", "status"},
+	Allowed: map[string][]string{
+		"status": {"draft", "review", "published"},
+		"tags":   {"go", "ops"},
+	},
+}
+
+func TestValidateFrontmatter(t *testing.T) {
+	conforming := types.Frontmatter{
+		Type:   "note",
+		Tags:   []string{"go", "ops"},
+		Custom: map[string]interface{}{"author": "alice", "status": "draft"},
+	}
+	if err := ValidateFrontmatter(conforming, testSchema); err != nil {
+		t.Errorf("conforming frontmatter: %v", err)
+	}
+
+	// Without a schema anything goes
+	if err := ValidateFrontmatter(types.Frontmatter{}, Schema{}); err != nil {
+		t.Errorf("empty schema: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		fm   types.Frontmatter
+		want []string
+	}{
+		{
+			name: "missing required field",
+			fm:   types.Frontmatter{Type: "note", Custom: map[string]interface{}{"status": "draft"}},
+			want: []string{`missing required field "author"`},
+		},
+		{
+			name: "empty values are missing",
+			fm:   types.Frontmatter{Type: " ", Custom: map[string]interface{}{"author": "", "status": []interface{}{}}},
+			want: []string{`missing required field "type"`, `missing required field "author"`, `missing required field "status"`},
+		},
+		{
+			name: "invalid enum value",
+			fm:   types.Frontmatter{Type: "note", Custom: map[string]interface{}{"author": "alice", "status": "wip"}},
+			want: []string{`field "status" has value "wip", allowed values are: draft, review, published`},
+		},
+		{
+			name: "invalid list element",
+			fm: types.Frontmatter{Type: "note", Tags: []string{"go", "python"},
+				Custom: map[string]interface{}{"author": "alice", "status": "review"}},
+			want: []string{`field "tags" has value "python", allowed values are: go, ops`},
+		},
+		{
+			name: "non-string custom value",
+			fm:   types.Frontmatter{Type: "note", Custom: map[string]interface{}{"author": 42, "status": true}},
+			want: []string{`field "status" has value "true"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFrontmatter(tt.fm, testSchema)
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("ValidateFrontmatter() = %v, want a *SchemaError", err)
+			}
+			if len(schemaErr.Violations) != len(tt.want) {
+				t.Errorf("violations = %+v, want %d", schemaErr.Violations, len(tt.want))
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFrontmatter_ParsedNote(t *testing.T) {
+	fm, err := ParseFrontmatter("type: meeting\nauthor: bob\nstatus: published\ntags: go, ops\n")
+	if err != nil {
+		t.Fatalf("ParseFrontmatter: %v", err)
+	}
+	if err := ValidateFrontmatter(fm, testSchema); err != nil {
+		t.Errorf("parsed conforming note: %v", err)
+	}
+
+	fm, err = ParseFrontmatter("type: meeting\nstatus: [draft, archived]\n")
+	if err != nil {
+		t.Fatalf("ParseFrontmatter: %v", err)
+	}
+	var schemaErr *SchemaError
+	if !errors.As(ValidateFrontmatter(fm, testSchema), &schemaErr) {
+		t.Fatal("expected a schema error")
+	}
+	fields := make([]string, len(schemaErr.Violations))
+	for i, v := range schemaErr.Violations {
+		fields[i] = v.Field
+	}
+	if got := strings.Join(fields, ","); got != "author,status" {
+		t.Errorf("violated fields = %s, want author,status", got)
+	}
+}
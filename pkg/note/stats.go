@@ -0,0 +1,85 @@
+package note
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordsPerMinute is the reading speed reading times are estimated at
+const WordsPerMinute = 200
+
+// NoteStats describes the length of a note body
+type NoteStats struct {
+	// Words is the number of words of prose
+	Words int `json:"words"`
+
+	// Characters is the number of characters in those words, not counting
+	// spaces or the "*", "`" and "~" of emphasis and code markup
+	Characters int `json:"characters"`
+
+	// ReadingMinutes is the estimated reading time at WordsPerMinute,
+	// rounded up to a whole minute; zero for an empty note
+	ReadingMinutes int `json:"reading_minutes"`
+}
+
+var (
+	// markdownLinkPattern matches [text](url) links and ![alt](src) images
+	markdownLinkPattern = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+
+	// wikiLinkPattern matches [[target]] and [[target|alias]] links
+	wikiLinkPattern = regexp.MustCompile(`\[\[([^\]|]+)(?:\|([^\]]*))?\]\]`)
+)
+
+// Stats counts the prose in a note body as a reader sees it. Fenced code
+// blocks are left out, since code is scanned rather than read, but inline
+// code counts. Links count their text, not their URL, and wiki links their
+// alias or else their target. A word is a run of non-space characters with
+// at least one letter or digit, so markup on its own, such as a "#" or a
+// list "-", isn't a word.
+func Stats(body string) NoteStats {
+	var stats NoteStats
+	inFence := false
+	fence := ""
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inFence {
+			if strings.HasPrefix(trimmed, fence) {
+				inFence = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = true
+			fence = trimmed[:3]
+			continue
+		}
+
+		line = wikiLinkPattern.ReplaceAllStringFunc(line, func(link string) string {
+			m := wikiLinkPattern.FindStringSubmatch(link)
+			if m[2] != "" {
+				return m[2]
+			}
+			return m[1]
+		})
+		line = markdownLinkPattern.ReplaceAllString(line, "$1")
+
+		for _, field := range strings.Fields(line) {
+			if strings.IndexFunc(field, isWordRune) == -1 {
+				continue
+			}
+			stats.Words++
+			stats.Characters += utf8.RuneCountInString(field) - strings.Count(field, "*") -
+				strings.Count(field, "`") - strings.Count(field, "~")
+		}
+	}
+
+	stats.ReadingMinutes = (stats.Words + WordsPerMinute - 1) / WordsPerMinute
+	return stats
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
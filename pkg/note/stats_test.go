@@ -0,0 +1,83 @@
+package note
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want NoteStats
+	}{
+		{
+			name: "empty",
+			body: "",
+			want: NoteStats{},
+		},
+		{
+			name: "plain prose",
+			body: "The quick brown fox.\nJumps over the dog.\n",
+			want: NoteStats{Words: 8, Characters: 33, ReadingMinutes: 1},
+		},
+		{
+			name: "markup is not a word",
+			body: "# Title\n\n- one\n- two\n\n> quoted **bold** text\n\n---\n",
+			want: NoteStats{Words: 6, Characters: 25, ReadingMinutes: 1},
+		},
+		{
+			name: "fenced code blocks are skipped",
+			body: "Run this:\n\n```go\nfunc main() {\n\tfmt.Println(\"hello world\")\n}\n```\n\n~~~\nmore code here\n~~~\n\nDone.\n",
+			want: NoteStats{Words: 3, Characters: 13, ReadingMinutes: 1},
+		},
+		{
+			name: "inline code counts",
+			body: "Call `go test` first",
+			want: NoteStats{Words: 4, Characters: 15, ReadingMinutes: 1},
+		},
+		{
+			name: "links count their text",
+			body: "See [the docs](https://example.com/a/very/long/path) and ![a diagram](img/d.png).",
+			want: NoteStats{Words: 6, Characters: 22, ReadingMinutes: 1},
+		},
+		{
+			name: "wiki links count their alias or target",
+			body: "Linked to [[project-plan]] and [[01HQ2X3Y4Z|the roadmap]].",
+			want: NoteStats{Words: 6, Characters: 34, ReadingMinutes: 1},
+		},
+		{
+			name: "non-latin text",
+			body: "café crème brûlée",
+			want: NoteStats{Words: 3, Characters: 15, ReadingMinutes: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Stats(tt.body); got != tt.want {
+				t.Errorf("Stats(%q) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStats_ReadingTime(t *testing.T) {
+	tests := []struct {
+		words int
+		want  int
+	}{
+		{1, 1},
+		{WordsPerMinute, 1},
+		{WordsPerMinute + 1, 2},
+		{5 * WordsPerMinute, 5},
+	}
+
+	for _, tt := range tests {
+		body := strings.Repeat("word ", tt.words)
+		got := Stats(body)
+		if got.Words != tt.words || got.ReadingMinutes != tt.want {
+			t.Errorf("Stats of %d words = %+v, want %d minutes", tt.words, got, tt.want)
+		}
+	}
+}
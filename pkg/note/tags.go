@@ -0,0 +1,85 @@
+package note
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// inlineTagPattern matches a #hashtag at the start of a line or after
+// whitespace, so URL fragments and "C#" aren't read as tags. Tags may use
+// letters and digits from any script, and "_", "-" and "/" for nesting.
+var inlineTagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{M}\p{N}_/-]+)`)
+
+// Tags returns the tags of n: its frontmatter tags followed, when inline is
+// set, by the #hashtags in its body. See MergeTags for how duplicates are
+// handled.
+func Tags(n *types.Note, inline bool) []string {
+	if !inline {
+		return MergeTags(n.Frontmatter.Tags)
+	}
+	return MergeTags(n.Frontmatter.Tags, InlineTags(n.Content))
+}
+
+// InlineTags returns the #hashtags in a note body, in order of first
+// appearance. Hashtags in fenced code blocks and code spans are ignored, as
+// are purely numeric ones such as "#1", which usually refer to issues.
+func InlineTags(body string) []string {
+	var tags []string
+	inFence := false
+	fence := ""
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inFence {
+			if strings.HasPrefix(trimmed, fence) {
+				inFence = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = true
+			fence = trimmed[:3]
+			continue
+		}
+
+		// Code spans are never tagged
+		line = inlineCodePattern.ReplaceAllString(line, "")
+		for _, match := range inlineTagPattern.FindAllStringSubmatch(line, -1) {
+			tag := strings.TrimRight(match[1], "-/")
+			if strings.IndexFunc(tag, isTagLetter) != -1 {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return MergeTags(tags)
+}
+
+// MergeTags combines tag lists, dropping empty tags and duplicates. Tags
+// that differ only in case are the same tag; the first spelling is kept.
+// The result is never nil.
+func MergeTags(lists ...[]string) []string {
+	merged := []string{}
+	seen := make(map[string]bool)
+
+	for _, list := range lists {
+		for _, tag := range list {
+			tag = strings.TrimSpace(tag)
+			key := strings.ToLower(tag)
+			if tag == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, tag)
+		}
+	}
+
+	return merged
+}
+
+func isTagLetter(r rune) bool {
+	return !unicode.IsDigit(r) && r != '_' && r != '-' && r != '/'
+}
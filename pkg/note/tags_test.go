@@ -0,0 +1,85 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		inline bool
+		want   []string
+	}{
+		{
+			name:   "frontmatter only",
+			data:   "---\ntags: [go, testing]\n---\n\n# Go\n\nNo hashtags here.\n",
+			inline: true,
+			want:   []string{"go", "testing"},
+		},
+		{
+			name:   "inline only",
+			data:   "# Café\n\n#recette du #café au lait, en #français\n\nTags: #cuisine/dessert\n",
+			inline: true,
+			want:   []string{"recette", "café", "français", "cuisine/dessert"},
+		},
+		{
+			name:   "mixed and deduplicated",
+			data:   "---\ntags:\n  - go\n  - 日本語\n---\n\nNotes on #Go and #日本語, plus #concurrency and #go again.\n",
+			inline: true,
+			want:   []string{"go", "日本語", "concurrency"},
+		},
+		{
+			name:   "inline disabled",
+			data:   "---\ntags: [go]\n---\n\nAbout #concurrency.\n",
+			inline: false,
+			want:   []string{"go"},
+		},
+		{
+			name:   "no tags",
+			data:   "# Empty\n",
+			inline: true,
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := Parse("notes/01ABC.md", []byte(tt.data))
+			if got := Tags(n, tt.inline); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInlineTags(t *testing.T) {
+	body := "# Heading\n" +
+		"## Subheading\n" +
+		"Issue #42 and #2024 are numbers, not tags.\n" +
+		"See https://example.com/page#section and C# code.\n" +
+		"Inline `#notatag` code, then #real-tag- and #nested/tag/.\n" +
+		"```go\n// #fenced\n```\n" +
+		"~~~\n#alsofenced\n~~~\n" +
+		"#फ़िल्म and #snake_case\n"
+
+	want := []string{"real-tag", "nested/tag", "फ़िल्म", "snake_case"}
+	if got := InlineTags(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("InlineTags() = %q, want %q", got, want)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	got := MergeTags([]string{"Go", " ", "api"}, nil, []string{"go", "API", "rest"})
+	want := []string{"Go", "api", "rest"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeTags() = %q, want %q", got, want)
+	}
+
+	if got := Tags(&types.Note{}, true); got == nil || len(got) != 0 {
+		t.Errorf("Tags() of an empty note = %#v, want empty slice", got)
+	}
+}
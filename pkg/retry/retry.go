@@ -2,9 +2,13 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
@@ -68,11 +72,29 @@ func (b *ExponentialBackoff) Reset() {
 	// Nothing to reset for exponential backoff
 }
 
+// Errors returned when retries stop without success. The returned error
+// also wraps the last error from the retried function.
+var (
+	// ErrMaxAttempts means every allowed attempt failed
+	ErrMaxAttempts = errors.New("max retry attempts exceeded")
+
+	// ErrMaxElapsed means the time budget ran out before the attempts did
+	ErrMaxElapsed = errors.New("retry time budget exceeded")
+)
+
 // Config holds retry configuration
 type Config struct {
 	MaxAttempts int
 	Backoff     Backoff
 	ShouldRetry func(error) bool
+
+	// MaxElapsed bounds the total time spent retrying, including backoff
+	// delays. Zero means no limit.
+	MaxElapsed time.Duration
+
+	// OnRetry, if set, is called with the failed attempt number and its
+	// error before each retry, e.g. to count retries
+	OnRetry func(attempt int, err error)
 }
 
 // DefaultConfig returns a default retry configuration
@@ -81,6 +103,7 @@ func DefaultConfig() *Config {
 		MaxAttempts: 5,
 		Backoff:     NewExponentialBackoff(100*time.Millisecond, 10*time.Second),
 		ShouldRetry: DefaultShouldRetry,
+		MaxElapsed:  30 * time.Second,
 	}
 }
 
@@ -119,59 +142,100 @@ func StorageErrorShouldRetry(err error) bool {
 	return storageErr.IsRetryable()
 }
 
-// Retry executes a function with retry logic
-func Retry(ctx context.Context, config *Config, fn func() error) error {
-	if config == nil {
-		config = DefaultConfig()
+// VectorSearchErrorShouldRetry retries vector search and embedding errors
+// marked as retryable
+func VectorSearchErrorShouldRetry(err error) bool {
+	if err == nil {
+		return false
 	}
 
-	var lastErr error
-	for attempt := 0; attempt < config.MaxAttempts; attempt++ {
-		err := fn()
-		if err == nil {
-			return nil
-		}
+	vectorErr, ok := err.(*types.VectorSearchError)
+	if !ok {
+		return false
+	}
 
-		lastErr = err
+	return vectorErr.IsRetryable()
+}
 
-		// Check if we should retry
-		if !config.ShouldRetry(err) {
-			return err
-		}
+// retryAfterError is an error that asks to be retried no sooner than after
+// a delay
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
 
-		// Don't wait after the last attempt
-		if attempt == config.MaxAttempts-1 {
-			break
-		}
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
 
-		// Calculate delay
-		delay := config.Backoff.Duration(attempt)
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
 
-		// Wait for the delay or context cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(delay):
-			// Continue to next attempt
+// WithRetryAfter wraps err with the delay to wait before retrying the call
+// that failed, such as one a server asked for with Retry-After. RetryWithResult
+// waits at least that long before the next attempt. A delay of zero or
+// less leaves err unchanged.
+func WithRetryAfter(err error, delay time.Duration) error {
+	if err == nil || delay <= 0 {
+		return err
+	}
+	return &retryAfterError{err: err, delay: delay}
+}
+
+// RetryAfter returns the delay err, or an error it wraps, asked for with
+// WithRetryAfter
+func RetryAfter(err error) (time.Duration, bool) {
+	var afterErr *retryAfterError
+	if errors.As(err, &afterErr) {
+		return afterErr.delay, true
+	}
+	return 0, false
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, either a
+// number of seconds or an HTTP date, into the delay it asks for. It
+// returns 0 for a missing or invalid value and for dates in the past.
+func ParseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
 		}
 	}
+	return 0
+}
 
-	return fmt.Errorf("max retry attempts (%d) exceeded, last error: %w", config.MaxAttempts, lastErr)
+// Retry executes a function with retry logic
+func Retry(ctx context.Context, config *Config, fn func() error) error {
+	_, err := RetryWithResult(ctx, config, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
 }
 
 // RetryWithResult executes a function that returns a result and error with retry logic
 func RetryWithResult[T any](ctx context.Context, config *Config, fn func() (T, error)) (T, error) {
 	var zero T
-	var result T
 	var lastErr error
 
 	if config == nil {
 		config = DefaultConfig()
 	}
 
+	start := time.Now()
+
 	for attempt := 0; attempt < config.MaxAttempts; attempt++ {
-		var err error
-		result, err = fn()
+		result, err := fn()
 		if err == nil {
 			return result, nil
 		}
@@ -188,8 +252,21 @@ func RetryWithResult[T any](ctx context.Context, config *Config, fn func() (T, e
 			break
 		}
 
-		// Calculate delay
+		// Calculate delay, waiting at least as long as the failed call asked
 		delay := config.Backoff.Duration(attempt)
+		if after, ok := RetryAfter(err); ok && after > delay {
+			delay = after
+		}
+
+		// Give up now if the next attempt could not start within the budget
+		if config.MaxElapsed > 0 && time.Since(start)+delay >= config.MaxElapsed {
+			return zero, fmt.Errorf("%w (%s) after %d attempts, last error: %w",
+				ErrMaxElapsed, config.MaxElapsed, attempt+1, lastErr)
+		}
+
+		if config.OnRetry != nil {
+			config.OnRetry(attempt, err)
+		}
 
 		// Wait for the delay or context cancellation
 		select {
@@ -200,7 +277,7 @@ func RetryWithResult[T any](ctx context.Context, config *Config, fn func() (T, e
 		}
 	}
 
-	return zero, fmt.Errorf("max retry attempts (%d) exceeded, last error: %w", config.MaxAttempts, lastErr)
+	return zero, fmt.Errorf("%w (%d), last error: %w", ErrMaxAttempts, config.MaxAttempts, lastErr)
 }
 
 // CircuitBreaker implements the circuit breaker pattern
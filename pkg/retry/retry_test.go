@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -90,6 +91,29 @@ func TestStorageErrorShouldRetry(t *testing.T) {
 	}
 }
 
+func TestVectorSearchErrorShouldRetry(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil error", nil, false},
+		{"regular error", errors.New("some error"), false},
+		{"retryable vector error", types.NewVectorSearchError(types.VectorSearchTypeLocal, "embed", "test", errors.New("rate limited"), true), true},
+		{"non-retryable vector error", types.NewVectorSearchError(types.VectorSearchTypeLocal, "embed", "test", errors.New("bad request"), false), false},
+		{"storage error", types.NewStorageError(types.StorageTypeS3, "write", "test", errors.New("temp error"), true), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := VectorSearchErrorShouldRetry(tc.err)
+			if result != tc.expected {
+				t.Errorf("VectorSearchErrorShouldRetry(%v) = %v, expected %v", tc.err, result, tc.expected)
+			}
+		})
+	}
+}
+
 func TestRetry_Success(t *testing.T) {
 	ctx := context.Background()
 	config := DefaultConfig()
@@ -136,6 +160,179 @@ func TestRetry_MaxAttemptsExceeded(t *testing.T) {
 	if !contains(err.Error(), "persistent error") {
 		t.Errorf("Expected error to contain 'persistent error', got: %v", err)
 	}
+
+	if !errors.Is(err, ErrMaxAttempts) || errors.Is(err, ErrMaxElapsed) {
+		t.Errorf("Expected attempts to be reported as exhausted, got: %v", err)
+	}
+}
+
+func TestRetry_OnRetry(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.MaxAttempts = 3
+	config.Backoff = NewExponentialBackoff(1*time.Millisecond, 10*time.Millisecond)
+
+	var retried []int
+	config.OnRetry = func(attempt int, err error) {
+		if err == nil {
+			t.Error("Expected OnRetry to receive the failed attempt's error")
+		}
+		retried = append(retried, attempt)
+	}
+
+	_ = Retry(ctx, config, func() error {
+		return types.NewStorageError(types.StorageTypeLocal, "write", "test", errors.New("persistent error"), true)
+	})
+
+	// No retry follows the last attempt
+	if len(retried) != 2 || retried[0] != 0 || retried[1] != 1 {
+		t.Errorf("Expected OnRetry for attempts [0 1], got %v", retried)
+	}
+}
+
+func TestRetry_MaxElapsed(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.MaxAttempts = 100
+	config.Backoff = &ExponentialBackoff{InitialDelay: 20 * time.Millisecond, MaxDelay: 20 * time.Millisecond, Multiplier: 1}
+	config.MaxElapsed = 100 * time.Millisecond
+
+	attempts := 0
+	start := time.Now()
+	err := Retry(ctx, config, func() error {
+		attempts++
+		return types.NewStorageError(types.StorageTypeS3, "read", "test", errors.New("service unavailable"), true)
+	})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrMaxElapsed) {
+		t.Fatalf("Expected time budget error, got: %v", err)
+	}
+	if errors.Is(err, ErrMaxAttempts) {
+		t.Errorf("Expected attempts not to be reported as exhausted, got: %v", err)
+	}
+
+	var storageErr *types.StorageError
+	if !errors.As(err, &storageErr) {
+		t.Errorf("Expected last error to be wrapped, got: %v", err)
+	}
+
+	if attempts >= config.MaxAttempts {
+		t.Errorf("Expected retries to stop before %d attempts, got %d", config.MaxAttempts, attempts)
+	}
+	if elapsed > config.MaxElapsed+50*time.Millisecond {
+		t.Errorf("Expected to return near %v, took %v", config.MaxElapsed, elapsed)
+	}
+	if elapsed < config.MaxElapsed-40*time.Millisecond {
+		t.Errorf("Expected to keep retrying until near %v, returned after %v", config.MaxElapsed, elapsed)
+	}
+}
+
+func TestRetry_MaxElapsedSlowAttempts(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.MaxAttempts = 10
+	config.Backoff = NewExponentialBackoff(1*time.Millisecond, 1*time.Millisecond)
+	config.MaxElapsed = 100 * time.Millisecond
+
+	// The budget also covers time spent inside the function
+	attempts := 0
+	start := time.Now()
+	_, err := RetryWithResult(ctx, config, func() (int, error) {
+		attempts++
+		time.Sleep(60 * time.Millisecond)
+		return 0, types.NewStorageError(types.StorageTypeS3, "list", "", errors.New("timeout"), true)
+	})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrMaxElapsed) {
+		t.Fatalf("Expected time budget error, got: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if elapsed > 200*time.Millisecond {
+		t.Errorf("Expected to stop after the attempt that used up the budget, took %v", elapsed)
+	}
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.MaxAttempts = 2
+	config.Backoff = NewExponentialBackoff(1*time.Millisecond, 1*time.Millisecond)
+	config.ShouldRetry = VectorSearchErrorShouldRetry
+
+	// The delay the failed call asks for replaces a shorter backoff
+	attempts := 0
+	var retriedAt time.Time
+	start := time.Now()
+	err := Retry(ctx, config, func() error {
+		attempts++
+		if attempts == 1 {
+			limited := WithRetryAfter(errors.New("status 429: slow down"), 80*time.Millisecond)
+			return types.NewVectorSearchError("openai", "embed", "", limited, true)
+		}
+		retriedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got: %v", err)
+	}
+	if waited := retriedAt.Sub(start); waited < 80*time.Millisecond {
+		t.Errorf("Expected the retry to wait for Retry-After, retried after %v", waited)
+	}
+
+	// The delay counts against the time budget
+	config.MaxElapsed = 50 * time.Millisecond
+	err = Retry(ctx, config, func() error {
+		return types.NewVectorSearchError("openai", "embed", "", WithRetryAfter(errors.New("status 429"), time.Second), true)
+	})
+	if !errors.Is(err, ErrMaxElapsed) {
+		t.Errorf("Expected time budget error, got: %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	base := errors.New("rate limited")
+	if WithRetryAfter(base, 0) != base || WithRetryAfter(nil, time.Second) != nil {
+		t.Error("Expected no delay to leave the error unchanged")
+	}
+
+	wrapped := fmt.Errorf("embed failed: %w", WithRetryAfter(base, 2*time.Second))
+	if delay, ok := RetryAfter(wrapped); !ok || delay != 2*time.Second {
+		t.Errorf("RetryAfter() = %v, %v; want 2s", delay, ok)
+	}
+	if !errors.Is(wrapped, base) || wrapped.Error() != "embed failed: rate limited" {
+		t.Errorf("Expected the original error to be kept, got: %v", wrapped)
+	}
+	if _, ok := RetryAfter(base); ok {
+		t.Error("Expected no delay for a plain error")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{" 0 ", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if got := ParseRetryAfter(date); got < 8*time.Second || got > 10*time.Second {
+		t.Errorf("ParseRetryAfter(%q) = %v, want about 10s", date, got)
+	}
 }
 
 func TestRetry_NonRetryableError(t *testing.T) {
@@ -0,0 +1,76 @@
+// Package server provides HTTP handlers and middleware for the kbvault
+// server.
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// APIKeyHeader is the header API keys may be sent in instead of an
+// Authorization bearer token
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyParam is the query parameter API keys may be sent in. Browsers
+// can't set headers on WebSocket requests, so live search clients use it.
+const APIKeyParam = "api_key"
+
+// Authenticate wraps next with the authentication selected by config.Type.
+// "none" allows every request, "apikey" requires one of config.APIKeys.
+// JWT authentication is not implemented yet, so "jwt" rejects every request
+// rather than leaving the server open.
+func Authenticate(config types.AuthConfig, next http.Handler) http.Handler {
+	switch config.Type {
+	case "", "none":
+		return next
+	case "apikey":
+		keys := make([][]byte, len(config.APIKeys))
+		for i, key := range config.APIKeys {
+			keys[i] = []byte(key)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !validAPIKey(keys, requestAPIKey(r)) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kbvault"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	default:
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "authentication type "+config.Type+" is not supported", http.StatusNotImplemented)
+		})
+	}
+}
+
+// requestAPIKey returns the API key sent with r, looking at the
+// Authorization header, then X-API-Key, then the api_key query parameter
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	return r.URL.Query().Get(APIKeyParam)
+}
+
+// validAPIKey reports whether key is one of keys, comparing in constant time
+func validAPIKey(keys [][]byte, key string) bool {
+	if key == "" {
+		return false
+	}
+
+	valid := false
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare(k, []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		config types.AuthConfig
+		setup  func(r *http.Request)
+		want   int
+	}{
+		{
+			name:   "none",
+			config: types.AuthConfig{Type: "none"},
+			want:   http.StatusNoContent,
+		},
+		{
+			name:   "api key missing",
+			config: types.AuthConfig{Type: "apikey", APIKeys: []string{"k1", "k2"}},
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "api key wrong",
+			config: types.AuthConfig{Type: "apikey", APIKeys: []string{"k1"}},
+			setup:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") },
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "api key bearer",
+			config: types.AuthConfig{Type: "apikey", APIKeys: []string{"k1", "k2"}},
+			setup:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer k2") },
+			want:   http.StatusNoContent,
+		},
+		{
+			name:   "api key header",
+			config: types.AuthConfig{Type: "apikey", APIKeys: []string{"k1"}},
+			setup:  func(r *http.Request) { r.Header.Set(APIKeyHeader, "k1") },
+			want:   http.StatusNoContent,
+		},
+		{
+			name:   "api key query parameter",
+			config: types.AuthConfig{Type: "apikey", APIKeys: []string{"k1"}},
+			setup:  func(r *http.Request) { r.URL.RawQuery = "api_key=k1" },
+			want:   http.StatusNoContent,
+		},
+		{
+			name:   "no keys configured",
+			config: types.AuthConfig{Type: "apikey"},
+			setup:  func(r *http.Request) { r.Header.Set(APIKeyHeader, "") },
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "jwt",
+			config: types.AuthConfig{Type: "jwt"},
+			setup:  func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") },
+			want:   http.StatusNotImplemented,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ws/search", nil)
+			if tc.setup != nil {
+				tc.setup(r)
+			}
+			w := httptest.NewRecorder()
+
+			Authenticate(tc.config, ok).ServeHTTP(w, r)
+			assert.Equal(t, tc.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// corsAllowedMethods are the methods preflight requests are answered with
+var corsAllowedMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// corsAllowedHeaders are the request headers browsers may send
+var corsAllowedHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	APIKeyHeader,
+	"X-Request-ID",
+}
+
+// corsExposedHeaders are the response headers browser scripts may read
+var corsExposedHeaders = []string{"X-Request-ID"}
+
+// corsMaxAge is how long, in seconds, browsers may cache a preflight
+// response
+const corsMaxAge = 600
+
+// CORS wraps next with cross-origin request handling for browser clients.
+// Origins are allowed by config.CORSOrigins, or by the CORSRoutes entry
+// with the longest prefix of the request path. Preflight OPTIONS requests
+// from an allowed origin are answered with the allowed methods and headers;
+// from any other origin they fail with 403, as do other cross-origin
+// requests, without CORS headers. Credentials are allowed only for origins
+// that are listed, not for "*". Without config.EnableCORS only same-origin
+// requests reach next.
+func CORS(config types.HTTPServerConfig, next http.Handler) http.Handler {
+	policy := newCORSPolicy(config)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		allowed, explicit := policy.allow(r.URL.Path, origin)
+		if !allowed {
+			if !preflight && sameOrigin(r, origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		header := w.Header()
+		if explicit {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			header.Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", strings.Join(corsAllowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(corsAllowedHeaders, ", "))
+			header.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		header.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsPolicy matches request origins against the configured origins
+type corsPolicy struct {
+	enabled bool
+	origins []string
+	routes  []types.CORSRoute
+}
+
+func newCORSPolicy(config types.HTTPServerConfig) corsPolicy {
+	routes := slices.Clone(config.CORSRoutes)
+	// Longest prefix first, so the first match is the most specific
+	slices.SortStableFunc(routes, func(a, b types.CORSRoute) int {
+		return len(b.Prefix) - len(a.Prefix)
+	})
+	return corsPolicy{enabled: config.EnableCORS, origins: config.CORSOrigins, routes: routes}
+}
+
+// originsFor returns the origins allowed for requests to path
+func (p corsPolicy) originsFor(path string) []string {
+	for _, route := range p.routes {
+		if strings.HasPrefix(path, route.Prefix) {
+			return route.Origins
+		}
+	}
+	return p.origins
+}
+
+// allow reports whether origin may make cross-origin requests to path, and
+// whether it was allowed by a listed origin rather than "*"
+func (p corsPolicy) allow(path, origin string) (allowed, explicit bool) {
+	if !p.enabled {
+		return false, false
+	}
+	patterns := p.originsFor(path)
+	for _, pattern := range patterns {
+		if pattern != "*" && matchOrigin(pattern, origin) {
+			return true, true
+		}
+	}
+	return slices.Contains(patterns, "*"), false
+}
+
+// matchOrigin reports whether origin matches pattern: an exact origin, a
+// host matching any scheme, or a "*." wildcard matching any subdomain but
+// not the domain itself. Hosts are compared case-insensitively.
+func matchOrigin(pattern, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+
+	host := pattern
+	if scheme, rest, ok := strings.Cut(pattern, "://"); ok {
+		if !strings.EqualFold(scheme, u.Scheme) {
+			return false
+		}
+		host = rest
+	}
+
+	if suffix, ok := strings.CutPrefix(host, "*"); ok {
+		return len(u.Host) > len(suffix) && strings.HasSuffix(strings.ToLower(u.Host), strings.ToLower(suffix))
+	}
+	return strings.EqualFold(host, u.Host)
+}
+
+// sameOrigin reports whether origin names the host r was sent to
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// corsRequest sends a request with the given method, path and Origin
+// through CORS(config) and returns the recorded response
+func corsRequest(config types.HTTPServerConfig, method, path, origin string, preflight bool) *httptest.ResponseRecorder {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(method, "http://vault.example.com"+path, nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	if preflight {
+		r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		r.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	}
+	w := httptest.NewRecorder()
+	CORS(config, ok).ServeHTTP(w, r)
+	return w
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	config := types.HTTPServerConfig{EnableCORS: true, CORSOrigins: []string{"https://app.example.com"}}
+
+	w := corsRequest(config, http.MethodOptions, "/api/notes", "https://app.example.com", true)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "POST")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "DELETE")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Content-Type")
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+
+	// Preflight from an origin that isn't allowed
+	w = corsRequest(config, http.MethodOptions, "/api/notes", "https://evil.example.net", true)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+	// An OPTIONS request that isn't a preflight reaches the handler
+	w = corsRequest(config, http.MethodOptions, "/api/notes", "https://app.example.com", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCORS_Origins(t *testing.T) {
+	config := types.HTTPServerConfig{
+		EnableCORS:  true,
+		CORSOrigins: []string{"https://app.example.com", "https://*.example.org", "*.example.dev"},
+	}
+
+	tests := []struct {
+		name    string
+		origin  string
+		want    int
+		allowed bool
+	}{
+		{name: "no origin", origin: "", want: http.StatusOK},
+		{name: "exact", origin: "https://app.example.com", want: http.StatusOK, allowed: true},
+		{name: "exact case-insensitive", origin: "https://APP.example.com", want: http.StatusOK, allowed: true},
+		{name: "exact wrong scheme", origin: "http://app.example.com", want: http.StatusForbidden},
+		{name: "wildcard subdomain", origin: "https://docs.example.org", want: http.StatusOK, allowed: true},
+		{name: "wildcard nested subdomain", origin: "https://a.b.example.org", want: http.StatusOK, allowed: true},
+		{name: "wildcard excludes apex", origin: "https://example.org", want: http.StatusForbidden},
+		{name: "wildcard lookalike", origin: "https://evilexample.org", want: http.StatusForbidden},
+		{name: "wildcard any scheme", origin: "http://local.example.dev", want: http.StatusOK, allowed: true},
+		{name: "same origin", origin: "http://vault.example.com", want: http.StatusOK},
+		{name: "disallowed", origin: "https://evil.example.net", want: http.StatusForbidden},
+		{name: "malformed", origin: "null", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := corsRequest(config, http.MethodGet, "/api/notes", tt.origin, false)
+			assert.Equal(t, tt.want, w.Code)
+			if tt.allowed {
+				assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+			}
+		})
+	}
+}
+
+func TestCORS_Credentials(t *testing.T) {
+	// Any origin is allowed, but browsers refuse credentials with "*"
+	anyOrigin := types.HTTPServerConfig{EnableCORS: true, CORSOrigins: []string{"*"}}
+	w := corsRequest(anyOrigin, http.MethodGet, "/api/notes", "https://elsewhere.example.org", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// A listed origin is echoed with credentials even alongside "*"
+	listed := types.HTTPServerConfig{EnableCORS: true, CORSOrigins: []string{"*", "https://app.example.com"}}
+	w = corsRequest(listed, http.MethodGet, "/api/notes", "https://app.example.com", false)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_Routes(t *testing.T) {
+	config := types.HTTPServerConfig{
+		EnableCORS:  true,
+		CORSOrigins: []string{"https://app.example.com"},
+		CORSRoutes: []types.CORSRoute{
+			{Prefix: "/api", Origins: []string{"https://admin.example.com"}},
+			{Prefix: "/api/search", Origins: []string{"*"}},
+		},
+	}
+
+	w := corsRequest(config, http.MethodGet, "/health", "https://app.example.com", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = corsRequest(config, http.MethodGet, "/api/notes", "https://app.example.com", false)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = corsRequest(config, http.MethodOptions, "/api/notes", "https://admin.example.com", true)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// The longest prefix wins
+	w = corsRequest(config, http.MethodGet, "/api/search", "https://elsewhere.example.org", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_Disabled(t *testing.T) {
+	config := types.HTTPServerConfig{EnableCORS: false, CORSOrigins: []string{"*"}}
+
+	w := corsRequest(config, http.MethodGet, "/api/notes", "https://app.example.com", false)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = corsRequest(config, http.MethodGet, "/api/notes", "http://vault.example.com", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
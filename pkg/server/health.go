@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Health check routes. HealthPath, HealthzPath and ReadyzPath run every
+// check and answer whether the server is ready to serve requests; LivezPath
+// only answers whether the process is serving HTTP, so that orchestrators
+// don't restart the server while a backend is down.
+const (
+	HealthPath  = "/health"
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+	LivezPath   = "/livez"
+)
+
+// DefaultHealthTimeout bounds each check when no timeout is set
+const DefaultHealthTimeout = 5 * time.Second
+
+// Overall and per-check health statuses
+const (
+	// HealthStatusOK means every check passed
+	HealthStatusOK = "ok"
+
+	// HealthStatusDegraded means only optional checks failed
+	HealthStatusDegraded = "degraded"
+
+	// HealthStatusUnavailable means a required check failed
+	HealthStatusUnavailable = "unavailable"
+
+	// HealthStatusError marks a failed check
+	HealthStatusError = "error"
+)
+
+// HealthCheck is one subsystem checked by a HealthHandler
+type HealthCheck struct {
+	// Name identifies the subsystem in the response
+	Name string
+
+	// Required checks must pass for the server to be reported healthy; a
+	// failed optional check only degrades the status
+	Required bool
+
+	// Check reports the subsystem's health, with optional details to
+	// include in the response either way
+	Check func(ctx context.Context) (details map[string]any, err error)
+}
+
+// HealthResponse is the body of a health check response
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of one HealthCheck
+type CheckResult struct {
+	Status     string         `json:"status"`
+	Required   bool           `json:"required"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+// HealthHandler runs its checks concurrently on every request and replies
+// with a HealthResponse: 200 when every required check passed, 503
+// otherwise.
+type HealthHandler struct {
+	checks  []HealthCheck
+	timeout time.Duration
+}
+
+// NewHealthHandler creates a handler running checks, each bounded by
+// timeout; zero uses DefaultHealthTimeout
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+	return &HealthHandler{checks: checks, timeout: timeout}
+}
+
+// MountHealth registers the health check routes on mux. They are served
+// without authentication so that load balancers and orchestrators can
+// probe them.
+func MountHealth(mux *http.ServeMux, checks ...HealthCheck) {
+	health := NewHealthHandler(0, checks...)
+	mux.Handle(HealthPath, health)
+	mux.Handle(HealthzPath, health)
+	mux.Handle(ReadyzPath, health)
+	mux.Handle(LivezPath, LivenessHandler())
+}
+
+// ServeHTTP runs the checks and writes the response. Only GET and HEAD
+// are allowed.
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !allowProbe(w, r) {
+		return
+	}
+
+	response := h.Run(r.Context())
+	code := http.StatusOK
+	if response.Status == HealthStatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	writeHealth(w, r, code, response)
+}
+
+// Run runs every check and aggregates the results
+func (h *HealthHandler) Run(ctx context.Context) HealthResponse {
+	results := make([]CheckResult, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	response := HealthResponse{Status: HealthStatusOK, Checks: make(map[string]CheckResult, len(results))}
+	for i, result := range results {
+		response.Checks[h.checks[i].Name] = result
+		if result.Status == HealthStatusOK {
+			continue
+		}
+		if result.Required {
+			response.Status = HealthStatusUnavailable
+		} else if response.Status == HealthStatusOK {
+			response.Status = HealthStatusDegraded
+		}
+	}
+	return response
+}
+
+// run runs a single check within the timeout
+func (h *HealthHandler) run(ctx context.Context, check HealthCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.Check(ctx)
+	result := CheckResult{
+		Status:     HealthStatusOK,
+		Required:   check.Required,
+		DurationMS: time.Since(start).Milliseconds(),
+		Details:    details,
+	}
+	if err != nil {
+		result.Status = HealthStatusError
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LivenessHandler answers every probe with 200 and {"status": "ok"}
+// without checking any backend
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowProbe(w, r) {
+			writeHealth(w, r, http.StatusOK, HealthResponse{Status: HealthStatusOK})
+		}
+	})
+}
+
+// allowProbe rejects methods other than GET and HEAD, reporting whether r
+// should be answered
+func allowProbe(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// writeHealth writes response as JSON with the given status code; HEAD
+// requests get the headers only
+func writeHealth(w http.ResponseWriter, r *http.Request, code int, response HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// StorageHealthCheck checks storage with its Health method. It is
+// required.
+func StorageHealthCheck(storage types.StorageBackend) HealthCheck {
+	return HealthCheck{
+		Name:     "storage",
+		Required: true,
+		Check: func(ctx context.Context) (map[string]any, error) {
+			return map[string]any{"type": storage.Type()}, storage.Health(ctx)
+		},
+	}
+}
+
+// VectorHealthCheck checks a vector backend with its Health method. It is
+// required; leave it out when vector search is disabled.
+func VectorHealthCheck(backend types.VectorSearchBackend) HealthCheck {
+	return HealthCheck{
+		Name:     "vector",
+		Required: true,
+		Check: func(ctx context.Context) (map[string]any, error) {
+			return map[string]any{"type": backend.Type()}, backend.Health(ctx)
+		},
+	}
+}
+
+// IndexStatusReporter reports the state of a search index; *search.Engine
+// and *vault.Vault implement it
+type IndexStatusReporter interface {
+	IndexStatus() search.IndexStatus
+}
+
+// errIndexNotSynced is reported for an index that was never built
+var errIndexNotSynced = errors.New("search index has not been built")
+
+// SearchIndexHealthCheck checks that the search index has been built and,
+// when maxAge is positive, synced with storage within maxAge. It is
+// optional: searches still work on a stale index.
+func SearchIndexHealthCheck(index IndexStatusReporter, maxAge time.Duration) HealthCheck {
+	return HealthCheck{
+		Name: "search_index",
+		Check: func(ctx context.Context) (map[string]any, error) {
+			status := index.IndexStatus()
+			details := map[string]any{"documents": status.Documents}
+			if status.SyncedAt.IsZero() {
+				return details, errIndexNotSynced
+			}
+
+			age := time.Since(status.SyncedAt)
+			details["synced_at"] = status.SyncedAt.UTC().Format(time.RFC3339)
+			details["age_seconds"] = int64(age.Seconds())
+			if maxAge > 0 && age > maxAge {
+				return details, fmt.Errorf("search index was last synced %s ago, more than %s", age.Round(time.Second), maxAge)
+			}
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector"
+)
+
+// healthStorage is a storage backend whose Health returns err
+type healthStorage struct {
+	types.StorageBackend
+	err error
+}
+
+func (s *healthStorage) Type() types.StorageType { return types.StorageTypeLocal }
+
+func (s *healthStorage) Health(ctx context.Context) error { return s.err }
+
+// healthVector is a vector backend whose Health returns err
+type healthVector struct {
+	types.VectorSearchBackend
+	err error
+}
+
+func (v *healthVector) Health(ctx context.Context) error { return v.err }
+
+// staticIndex reports a fixed index status
+type staticIndex search.IndexStatus
+
+func (s staticIndex) IndexStatus() search.IndexStatus { return search.IndexStatus(s) }
+
+// getHealth requests path from a mux with the health routes mounted and
+// decodes the response
+func getHealth(t *testing.T, path string, checks ...HealthCheck) (int, HealthResponse) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	MountHealth(mux, checks...)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var response HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	return rec.Code, response
+}
+
+func TestHealthHandler(t *testing.T) {
+	fresh := staticIndex{Documents: 3, SyncedAt: time.Now()}
+	stale := staticIndex{Documents: 3, SyncedAt: time.Now().Add(-time.Hour)}
+	failure := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		checks     []HealthCheck
+		wantCode   int
+		wantStatus string
+		failed     []string
+	}{
+		{
+			name: "all healthy",
+			checks: []HealthCheck{
+				StorageHealthCheck(&healthStorage{}),
+				VectorHealthCheck(&healthVector{VectorSearchBackend: vector.NewNoneBackend()}),
+				SearchIndexHealthCheck(fresh, time.Minute),
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusOK,
+		},
+		{
+			name: "storage unhealthy",
+			checks: []HealthCheck{
+				StorageHealthCheck(&healthStorage{err: failure}),
+				VectorHealthCheck(&healthVector{VectorSearchBackend: vector.NewNoneBackend()}),
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: HealthStatusUnavailable,
+			failed:     []string{"storage"},
+		},
+		{
+			name: "vector unhealthy",
+			checks: []HealthCheck{
+				StorageHealthCheck(&healthStorage{}),
+				VectorHealthCheck(&healthVector{VectorSearchBackend: vector.NewNoneBackend(), err: failure}),
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: HealthStatusUnavailable,
+			failed:     []string{"vector"},
+		},
+		{
+			name: "stale index",
+			checks: []HealthCheck{
+				StorageHealthCheck(&healthStorage{}),
+				SearchIndexHealthCheck(stale, time.Minute),
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusDegraded,
+			failed:     []string{"search_index"},
+		},
+		{
+			name: "index never built",
+			checks: []HealthCheck{
+				SearchIndexHealthCheck(staticIndex{}, 0),
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusDegraded,
+			failed:     []string{"search_index"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{HealthPath, HealthzPath, ReadyzPath} {
+				code, response := getHealth(t, path, tt.checks...)
+				assert.Equal(t, tt.wantCode, code, path)
+				assert.Equal(t, tt.wantStatus, response.Status, path)
+				require.Len(t, response.Checks, len(tt.checks), path)
+
+				var failed []string
+				for name, result := range response.Checks {
+					if result.Status != HealthStatusOK {
+						failed = append(failed, name)
+						assert.NotEmpty(t, result.Error, name)
+					}
+				}
+				assert.ElementsMatch(t, tt.failed, failed, path)
+			}
+		})
+	}
+}
+
+func TestHealthHandlerDetails(t *testing.T) {
+	syncedAt := time.Now().Add(-90 * time.Second)
+	code, response := getHealth(t, HealthPath,
+		StorageHealthCheck(&healthStorage{err: errors.New("disk full")}),
+		SearchIndexHealthCheck(staticIndex{Documents: 12, SyncedAt: syncedAt}, time.Hour),
+	)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	storage := response.Checks["storage"]
+	assert.Equal(t, HealthStatusError, storage.Status)
+	assert.True(t, storage.Required)
+	assert.Equal(t, "disk full", storage.Error)
+	assert.Equal(t, "local", storage.Details["type"])
+
+	index := response.Checks["search_index"]
+	assert.Equal(t, HealthStatusOK, index.Status)
+	assert.False(t, index.Required)
+	assert.Equal(t, float64(12), index.Details["documents"])
+	assert.Equal(t, syncedAt.UTC().Format(time.RFC3339), index.Details["synced_at"])
+	assert.InDelta(t, 90, index.Details["age_seconds"], 5)
+}
+
+func TestHealthHandlerTimeout(t *testing.T) {
+	slow := HealthCheck{
+		Name:     "slow",
+		Required: true,
+		Check: func(ctx context.Context) (map[string]any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	response := NewHealthHandler(10*time.Millisecond, slow).Run(context.Background())
+	assert.Equal(t, HealthStatusUnavailable, response.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), response.Checks["slow"].Error)
+}
+
+func TestLivenessHandler(t *testing.T) {
+	// Liveness doesn't run the checks, so failing backends don't fail it
+	code, response := getHealth(t, LivezPath, StorageHealthCheck(&healthStorage{err: errors.New("down")}))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusOK, response.Status)
+	assert.Empty(t, response.Checks)
+}
+
+func TestHealthHandlerMethods(t *testing.T) {
+	mux := http.NewServeMux()
+	MountHealth(mux, StorageHealthCheck(&healthStorage{}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, HealthPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, HealthzPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestSearchIndexHealthCheck_Engine(t *testing.T) {
+	engine := newTestEngine(t)
+
+	details, err := SearchIndexHealthCheck(engine, time.Minute).Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, details["documents"])
+}
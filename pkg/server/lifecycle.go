@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrAlreadyRunning reports that another server holds the PID file or
+// accepts connections on the socket
+var ErrAlreadyRunning = errors.New("server is already running")
+
+// ErrNotRunning reports that no server is running
+var ErrNotRunning = errors.New("server is not running")
+
+// socketProbeTimeout bounds the connection attempt that checks whether a
+// server is listening on a socket
+const socketProbeTimeout = time.Second
+
+// stopPollInterval is how often Stop checks whether the server has exited
+const stopPollInterval = 50 * time.Millisecond
+
+// PIDPath returns the path of the PID file kept next to the server's Unix
+// socket: the socket path with its extension replaced by ".pid"
+func PIDPath(socketPath string) string {
+	return strings.TrimSuffix(socketPath, filepath.Ext(socketPath)) + ".pid"
+}
+
+// Lifecycle holds the PID file and Unix socket of a running server, so
+// that they are removed when it exits
+type Lifecycle struct {
+	socketPath string
+	pidPath    string
+}
+
+// Start prepares a server to listen on socketPath: a stale socket left by
+// a server that crashed is removed, and the PID file is written. It fails
+// with ErrAlreadyRunning when another server is running. Call Close on
+// exit.
+func Start(socketPath string) (*Lifecycle, error) {
+	if socketPath == "" {
+		return nil, errors.New("socket path cannot be empty")
+	}
+	if err := PrepareSocket(socketPath); err != nil {
+		return nil, err
+	}
+
+	l := &Lifecycle{socketPath: socketPath, pidPath: PIDPath(socketPath)}
+	if err := WritePIDFile(l.pidPath); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SocketPath returns the path of the server's Unix socket
+func (l *Lifecycle) SocketPath() string {
+	return l.socketPath
+}
+
+// PIDPath returns the path of the server's PID file
+func (l *Lifecycle) PIDPath() string {
+	return l.pidPath
+}
+
+// Close removes the socket and the PID file. Files that are already gone
+// are not an error, and a PID file another process has since claimed is
+// left alone.
+func (l *Lifecycle) Close() error {
+	var errs []error
+	if err := os.Remove(l.socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("failed to remove socket: %w", err))
+	}
+
+	pid, err := ReadPIDFile(l.pidPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err == nil && pid != os.Getpid():
+	default:
+		if err := os.Remove(l.pidPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("failed to remove PID file: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PrepareSocket makes socketPath free to listen on. A socket nothing
+// accepts connections on, left by a server that crashed, is removed. A
+// socket a server answers on fails with ErrAlreadyRunning, and a file that
+// isn't a socket is never removed.
+func PrepareSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check socket: %w", err)
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", socketPath)
+	}
+
+	if SocketListening(socketPath) {
+		return fmt.Errorf("%w: a server is listening on %s", ErrAlreadyRunning, socketPath)
+	}
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
+
+// SocketListening reports whether a server accepts connections on
+// socketPath
+func SocketListening(socketPath string) bool {
+	conn, err := net.DialTimeout("unix", socketPath, socketProbeTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// WritePIDFile writes the current process ID to path. A PID file naming a
+// process that has exited is replaced; one naming a running process fails
+// with ErrAlreadyRunning.
+func WritePIDFile(path string) error {
+	pid, err := ReadPIDFile(path)
+	switch {
+	case err == nil:
+		if ProcessRunning(pid) {
+			return fmt.Errorf("%w with PID %d (%s)", ErrAlreadyRunning, pid, path)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+	default:
+		// An unreadable PID file is replaced like a stale one
+	}
+
+	data := []byte(strconv.Itoa(os.Getpid()) + "\n")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return nil
+}
+
+// ReadPIDFile returns the process ID recorded in path. A missing file is
+// reported with an error wrapping fs.ErrNotExist.
+func ReadPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file %s", path)
+	}
+	return pid, nil
+}
+
+// ProcessRunning reports whether a process with the given ID exists
+func ProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	// EPERM means the process exists but belongs to another user
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Status describes the server behind a socket path
+type Status struct {
+	// Running is true when the PID file names a running process or a
+	// server accepts connections on the socket
+	Running bool `json:"running"`
+
+	// PID is the process ID in the PID file, or 0 when there is none
+	PID int `json:"pid,omitempty"`
+
+	// PIDFile and Socket are the paths checked
+	PIDFile string `json:"pid_file"`
+	Socket  string `json:"socket"`
+
+	// Listening is true when a server accepts connections on the socket
+	Listening bool `json:"listening"`
+
+	// StalePIDFile and StaleSocket are true when a file is left over from
+	// a server that exited without cleaning up
+	StalePIDFile bool `json:"stale_pid_file,omitempty"`
+	StaleSocket  bool `json:"stale_socket,omitempty"`
+}
+
+// CheckStatus reports whether a server is running on socketPath, from its
+// PID file and by probing the socket
+func CheckStatus(socketPath string) Status {
+	status := Status{PIDFile: PIDPath(socketPath), Socket: socketPath}
+
+	if pid, err := ReadPIDFile(status.PIDFile); err == nil {
+		status.PID = pid
+		status.StalePIDFile = !ProcessRunning(pid)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		status.StalePIDFile = true
+	}
+
+	if info, err := os.Lstat(socketPath); err == nil && info.Mode().Type() == fs.ModeSocket {
+		status.Listening = SocketListening(socketPath)
+		status.StaleSocket = !status.Listening
+	}
+
+	status.Running = status.Listening || (status.PID != 0 && !status.StalePIDFile)
+	return status
+}
+
+// Stop asks the server whose PID file is next to socketPath to shut down
+// gracefully and waits until it has exited or ctx is done. It returns the
+// server's process ID, or ErrNotRunning when no server is running.
+func Stop(ctx context.Context, socketPath string) (int, error) {
+	pidPath := PIDPath(socketPath)
+	pid, err := ReadPIDFile(pidPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, ErrNotRunning
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !ProcessRunning(pid) {
+		return pid, fmt.Errorf("%w: PID file %s is stale", ErrNotRunning, pidPath)
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return pid, fmt.Errorf("failed to find server process %d: %w", pid, err)
+	}
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		return pid, fmt.Errorf("failed to signal server process %d: %w", pid, err)
+	}
+
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+	for ProcessRunning(pid) {
+		select {
+		case <-ctx.Done():
+			return pid, fmt.Errorf("server process %d has not exited: %w", pid, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return pid, nil
+}
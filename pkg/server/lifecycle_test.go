@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketDir returns a short temporary directory, since Unix socket paths
+// are limited to about 100 bytes
+func socketDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "kbv")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+// staleSocket leaves a socket file at path that nothing listens on, as a
+// crashed server would
+func staleSocket(t *testing.T, path string) {
+	t.Helper()
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, listener.Close())
+	_, err = os.Lstat(path)
+	require.NoError(t, err)
+}
+
+// exitedPID returns the ID of a process that has exited
+func exitedPID(t *testing.T) int {
+	t.Helper()
+
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func TestPIDPath(t *testing.T) {
+	assert.Equal(t, "/tmp/kbvault.pid", PIDPath("/tmp/kbvault.sock"))
+	assert.Equal(t, "/run/kbvault/mcp.pid", PIDPath("/run/kbvault/mcp"))
+}
+
+func TestPrepareSocket(t *testing.T) {
+	dir := socketDir(t)
+	path := filepath.Join(dir, "kb.sock")
+
+	// Nothing there
+	require.NoError(t, PrepareSocket(path))
+
+	// A stale socket is removed
+	staleSocket(t, path)
+	require.NoError(t, PrepareSocket(path))
+	_, err := os.Lstat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// A live one is left alone
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	err = PrepareSocket(path)
+	assert.ErrorIs(t, err, ErrAlreadyRunning)
+	require.NoError(t, listener.Close())
+
+	// So is a file that isn't a socket
+	regular := filepath.Join(dir, "notes.md")
+	require.NoError(t, os.WriteFile(regular, []byte("keep me"), 0o644))
+	err = PrepareSocket(regular)
+	assert.ErrorContains(t, err, "is not a socket")
+	_, err = os.Stat(regular)
+	assert.NoError(t, err)
+}
+
+func TestLifecycle(t *testing.T) {
+	dir := socketDir(t)
+	path := filepath.Join(dir, "kb.sock")
+	staleSocket(t, path)
+
+	l, err := Start(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "kb.pid"), l.PIDPath())
+
+	// The stale socket is gone and the PID file names this process
+	_, err = os.Lstat(path)
+	assert.True(t, os.IsNotExist(err))
+	pid, err := ReadPIDFile(l.PIDPath())
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	status := CheckStatus(path)
+	assert.True(t, status.Running)
+	assert.True(t, status.Listening)
+	assert.Equal(t, os.Getpid(), status.PID)
+	assert.False(t, status.StalePIDFile)
+	assert.False(t, status.StaleSocket)
+
+	// A second server is refused
+	_, err = Start(path)
+	assert.ErrorIs(t, err, ErrAlreadyRunning)
+
+	require.NoError(t, listener.Close())
+	require.NoError(t, l.Close())
+	for _, p := range []string{path, l.PIDPath()} {
+		_, err = os.Lstat(p)
+		assert.True(t, os.IsNotExist(err), p)
+	}
+	assert.False(t, CheckStatus(path).Running)
+
+	// Closing twice is harmless
+	assert.NoError(t, l.Close())
+}
+
+func TestLifecycle_StaleFiles(t *testing.T) {
+	dir := socketDir(t)
+	path := filepath.Join(dir, "kb.sock")
+	pidPath := PIDPath(path)
+
+	// Left behind by a server that crashed
+	staleSocket(t, path)
+	require.NoError(t, os.WriteFile(pidPath, []byte(strconv.Itoa(exitedPID(t))), 0o644))
+
+	status := CheckStatus(path)
+	assert.False(t, status.Running)
+	assert.True(t, status.StalePIDFile)
+	assert.True(t, status.StaleSocket)
+
+	_, err := Stop(context.Background(), path)
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	l, err := Start(path)
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	pid, err := ReadPIDFile(pidPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+
+	// A PID file claimed by another process isn't removed on Close
+	require.NoError(t, os.WriteFile(pidPath, []byte("1\n"), 0o644))
+	require.NoError(t, l.Close())
+	_, err = os.Stat(pidPath)
+	assert.NoError(t, err)
+}
+
+func TestReadPIDFile(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := ReadPIDFile(filepath.Join(dir, "missing.pid"))
+	assert.True(t, os.IsNotExist(err))
+
+	bad := filepath.Join(dir, "bad.pid")
+	require.NoError(t, os.WriteFile(bad, []byte("not a pid"), 0o644))
+	_, err = ReadPIDFile(bad)
+	assert.ErrorContains(t, err, "invalid PID file")
+
+	// An unreadable PID file is replaced
+	require.NoError(t, WritePIDFile(bad))
+	pid, err := ReadPIDFile(bad)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+}
+
+func TestStop(t *testing.T) {
+	path := filepath.Join(socketDir(t), "kb.sock")
+
+	_, err := Stop(context.Background(), path)
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	// A stand-in server that exits on SIGTERM
+	server := exec.Command("sleep", "30")
+	require.NoError(t, server.Start())
+	exited := make(chan struct{})
+	go func() {
+		_ = server.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() { _ = server.Process.Kill() })
+	require.NoError(t, os.WriteFile(PIDPath(path), []byte(strconv.Itoa(server.Process.Pid)), 0o644))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pid, err := Stop(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, server.Process.Pid, pid)
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("server did not exit")
+	}
+}
@@ -0,0 +1,298 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// LiveSearchPath is the route of the live search WebSocket
+const LiveSearchPath = "/ws/search"
+
+const (
+	// defaultLiveSearchLimit is used when a request doesn't set a limit
+	defaultLiveSearchLimit = 10
+
+	// maxLiveSearchLimit caps the results returned for one query
+	maxLiveSearchLimit = 100
+)
+
+// Searcher runs full-text searches; *search.Engine implements it
+type Searcher interface {
+	Search(ctx context.Context, query search.SearchQuery) ([]search.SearchResult, error)
+}
+
+// searchLimiter is implemented by searchers that cap the number of
+// results, such as *search.Engine and *vault.Vault
+type searchLimiter interface {
+	EffectiveLimit(query search.SearchQuery) (limit int, clamped bool)
+}
+
+// LiveSearchRequest is a query sent by a live search client
+type LiveSearchRequest struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+// LiveSearchResponse is sent for each query that outlasts the debounce
+// delay. Query echoes the request so clients can drop stale responses.
+// Limit is the most results the query could return; Truncated is set when
+// it is lower than the request asked for.
+type LiveSearchResponse struct {
+	Query     string             `json:"query"`
+	Results   []LiveSearchResult `json:"results"`
+	Limit     int                `json:"limit,omitempty"`
+	Truncated bool               `json:"truncated,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// LiveSearchResult is a single match in a LiveSearchResponse
+type LiveSearchResult struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Path    string   `json:"path"`
+	Tags    []string `json:"tags,omitempty"`
+	Score   float64  `json:"score"`
+	Snippet string   `json:"snippet,omitempty"`
+}
+
+// LiveSearchHandler serves search-as-you-type over a WebSocket. Clients
+// send a LiveSearchRequest for each keystroke; the handler waits until no
+// new query has arrived for the debounce delay, runs the latest one and
+// replies with a LiveSearchResponse.
+type LiveSearchHandler struct {
+	searcher     Searcher
+	upgrader     websocket.Upgrader
+	debounce     time.Duration
+	idleTimeout  time.Duration
+	writeTimeout time.Duration
+	readLimit    int64
+	maxConns     int64
+	active       atomic.Int64
+}
+
+// liveSearchMessage is a request read from the connection, or the reason
+// it couldn't be decoded
+type liveSearchMessage struct {
+	request LiveSearchRequest
+	err     error
+}
+
+// NewLiveSearchHandler creates a live search handler. Connection limits,
+// timeouts, the message size limit, allowed origins and the debounce delay
+// come from config.
+func NewLiveSearchHandler(searcher Searcher, config types.HTTPServerConfig) *LiveSearchHandler {
+	return &LiveSearchHandler{
+		searcher:     searcher,
+		upgrader:     websocket.Upgrader{CheckOrigin: checkOrigin(config)},
+		debounce:     time.Duration(config.SearchDebounceMS) * time.Millisecond,
+		idleTimeout:  time.Duration(config.IdleTimeout) * time.Second,
+		writeTimeout: time.Duration(config.WriteTimeout) * time.Second,
+		readLimit:    config.MaxRequestSize,
+		maxConns:     int64(config.MaxWebSocketConnections),
+	}
+}
+
+// MountLiveSearch registers the live search WebSocket on mux behind the
+// configured authentication. Each connection gets a request ID, which its
+// searches carry.
+func MountLiveSearch(mux *http.ServeMux, config types.ServerConfig, searcher Searcher) {
+	mux.Handle(LiveSearchPath, RequestID(Authenticate(config.Auth, NewLiveSearchHandler(searcher, config.HTTP))))
+}
+
+// ServeHTTP upgrades the request to a WebSocket and serves queries until
+// the client disconnects or stays idle for longer than the idle timeout
+func (h *LiveSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.maxConns > 0 {
+		if h.active.Add(1) > h.maxConns {
+			h.active.Add(-1)
+			http.Error(w, "too many live search connections", http.StatusServiceUnavailable)
+			return
+		}
+		defer h.active.Add(-1)
+	}
+
+	// The upgrader replies with an error itself, and writes the response
+	// headers without those already set on w
+	var header http.Header
+	if id := trace.RequestID(r.Context()); id != "" {
+		header = http.Header{trace.RequestIDHeader: {id}}
+	}
+	conn, err := h.upgrader.Upgrade(w, r, header)
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	h.serve(r.Context(), conn)
+}
+
+// serve runs the debounce loop for one connection. Searches run under a
+// context derived from ctx that is canceled once the connection closes.
+func (h *LiveSearchHandler) serve(ctx context.Context, conn *websocket.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	messages := make(chan liveSearchMessage, 16)
+	done := make(chan struct{})
+	defer close(done)
+	go h.readMessages(conn, messages, done, cancel)
+
+	var pending LiveSearchRequest
+	var fire <-chan time.Time
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if msg.err != nil {
+				if !h.write(conn, LiveSearchResponse{Results: []LiveSearchResult{}, Error: msg.err.Error()}) {
+					return
+				}
+				continue
+			}
+
+			// A newer query replaces one still waiting for the delay
+			pending = msg.request
+			if h.debounce > 0 {
+				fire = time.After(h.debounce)
+				continue
+			}
+			if !h.write(conn, h.search(ctx, pending)) {
+				return
+			}
+		case <-fire:
+			fire = nil
+			if !h.write(conn, h.search(ctx, pending)) {
+				return
+			}
+		}
+	}
+}
+
+// readMessages decodes requests from conn into messages until the
+// connection fails, is idle for too long or done is closed, then closes
+// messages. A failed connection also calls cancel, so that a search in
+// progress stops as soon as the client disconnects.
+func (h *LiveSearchHandler) readMessages(conn *websocket.Conn, messages chan<- liveSearchMessage, done <-chan struct{}, cancel context.CancelFunc) {
+	defer close(messages)
+
+	if h.readLimit > 0 {
+		conn.SetReadLimit(h.readLimit)
+	}
+	for {
+		if h.idleTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(h.idleTimeout))
+		}
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout")
+				_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			}
+			cancel()
+			return
+		}
+
+		var msg liveSearchMessage
+		if err := json.Unmarshal(data, &msg.request); err != nil {
+			msg.err = errors.New("invalid request: " + err.Error())
+		}
+		select {
+		case messages <- msg:
+		case <-done:
+			return
+		}
+	}
+}
+
+// search runs request and builds its response. An empty query matches
+// nothing.
+func (h *LiveSearchHandler) search(ctx context.Context, request LiveSearchRequest) LiveSearchResponse {
+	response := LiveSearchResponse{Query: request.Query, Results: []LiveSearchResult{}}
+
+	query := strings.TrimSpace(request.Query)
+	if query == "" {
+		return response
+	}
+
+	searchQuery := search.SearchQuery{Query: query, Limit: request.Limit}
+	if searchQuery.Limit <= 0 {
+		searchQuery.Limit = defaultLiveSearchLimit
+	}
+	if searchQuery.Limit > maxLiveSearchLimit {
+		searchQuery.Limit = maxLiveSearchLimit
+		response.Truncated = true
+	}
+	response.Limit = searchQuery.Limit
+	if limiter, ok := h.searcher.(searchLimiter); ok {
+		limit, clamped := limiter.EffectiveLimit(searchQuery)
+		response.Limit = limit
+		response.Truncated = response.Truncated || clamped
+	}
+
+	results, err := h.searcher.Search(ctx, searchQuery)
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	for _, r := range results {
+		if r.Note == nil {
+			continue
+		}
+		if len(response.Results) == response.Limit {
+			break
+		}
+		response.Results = append(response.Results, LiveSearchResult{
+			ID:      r.Note.ID,
+			Title:   r.Note.Title,
+			Path:    r.Note.FilePath,
+			Tags:    r.Note.Tags,
+			Score:   r.Score,
+			Snippet: r.Snippet,
+		})
+	}
+	return response
+}
+
+// write sends response and reports whether the connection is still usable
+func (h *LiveSearchHandler) write(conn *websocket.Conn, response LiveSearchResponse) bool {
+	if h.writeTimeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(h.writeTimeout))
+	}
+	return conn.WriteJSON(response) == nil
+}
+
+// checkOrigin returns the upgrader's origin check. Without CORS only
+// same-origin browser connections are accepted; with CORS, origins allowed
+// for the request path as by the CORS middleware are accepted too.
+func checkOrigin(config types.HTTPServerConfig) func(*http.Request) bool {
+	if !config.EnableCORS {
+		return nil // the upgrader's same-origin check
+	}
+	policy := newCORSPolicy(config)
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || sameOrigin(r, origin) {
+			return true
+		}
+		allowed, _ := policy.allow(r.URL.Path, origin)
+		return allowed
+	}
+}
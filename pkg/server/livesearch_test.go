@@ -0,0 +1,338 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// newTestEngine returns a search engine over a local vault with a few notes
+func newTestEngine(t *testing.T) *search.Engine {
+	t.Helper()
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "notes"), 0o755))
+	storage, err := local.New(types.LocalStorageConfig{Path: root})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	notes := map[string]string{
+		"notes/kubernetes.md": "---\nid: kubernetes\ntitle: Kubernetes Basics\ntags: [ops]\n---\n\nPods, deployments and services.\n",
+		"notes/golang.md":     "---\nid: golang\ntitle: Go Concurrency\ntags: [dev]\n---\n\nGoroutines and channels.\n",
+		"notes/gardening.md":  "---\nid: gardening\ntitle: Gardening Notes\n---\n\nTomatoes need sun.\n",
+	}
+	for path, content := range notes {
+		require.NoError(t, storage.Write(ctx, path, []byte(content)))
+	}
+
+	engine := search.New(storage, search.DefaultOptions())
+	require.NoError(t, engine.BuildIndex(ctx))
+	return engine
+}
+
+// testServerConfig returns a server config suited to tests
+func testServerConfig() types.ServerConfig {
+	config := types.DefaultConfig().Server
+	config.HTTP.SearchDebounceMS = 50
+	return config
+}
+
+// startLiveSearch serves the live search endpoint and returns its ws:// URL
+func startLiveSearch(t *testing.T, config types.ServerConfig, searcher Searcher) string {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	MountLiveSearch(mux, config, searcher)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http") + LiveSearchPath
+}
+
+func dial(t *testing.T, url string, header http.Header) *websocket.Conn {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func readResponse(t *testing.T, conn *websocket.Conn) LiveSearchResponse {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var response LiveSearchResponse
+	require.NoError(t, conn.ReadJSON(&response))
+	return response
+}
+
+func responseIDs(response LiveSearchResponse) []string {
+	ids := make([]string, len(response.Results))
+	for i, r := range response.Results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestLiveSearch_SuccessiveQueries(t *testing.T) {
+	url := startLiveSearch(t, testServerConfig(), newTestEngine(t))
+	conn := dial(t, url, nil)
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "kubernetes", Limit: 5}))
+	response := readResponse(t, conn)
+	assert.Equal(t, "kubernetes", response.Query)
+	assert.Empty(t, response.Error)
+	require.Equal(t, []string{"kubernetes"}, responseIDs(response))
+	assert.Equal(t, "Kubernetes Basics", response.Results[0].Title)
+	assert.Equal(t, []string{"ops"}, response.Results[0].Tags)
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "goroutines"}))
+	response = readResponse(t, conn)
+	assert.Equal(t, "goroutines", response.Query)
+	assert.Equal(t, []string{"golang"}, responseIDs(response))
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "   "}))
+	response = readResponse(t, conn)
+	assert.Empty(t, response.Results)
+	assert.NotNil(t, response.Results, "results are always an array")
+}
+
+// recordingSearcher records the queries it runs
+type recordingSearcher struct {
+	mu      sync.Mutex
+	queries []search.SearchQuery
+}
+
+func (s *recordingSearcher) Search(ctx context.Context, query search.SearchQuery) ([]search.SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, query)
+	return []search.SearchResult{{Note: &types.NoteMetadata{ID: query.Query}, Score: 1}}, nil
+}
+
+func (s *recordingSearcher) ran() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queries := make([]string, len(s.queries))
+	for i, q := range s.queries {
+		queries[i] = q.Query
+	}
+	return queries
+}
+
+func TestLiveSearch_Debounce(t *testing.T) {
+	config := testServerConfig()
+	config.HTTP.SearchDebounceMS = 200
+	searcher := &recordingSearcher{}
+	conn := dial(t, startLiveSearch(t, config, searcher), nil)
+
+	// Keystrokes arriving faster than the debounce delay collapse into
+	// the last one
+	for _, q := range []string{"k", "ku", "kub", "kube"} {
+		require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: q}))
+	}
+	response := readResponse(t, conn)
+	assert.Equal(t, "kube", response.Query)
+	assert.Equal(t, []string{"kube"}, searcher.ran())
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "kubernetes", Limit: 500}))
+	response = readResponse(t, conn)
+	assert.Equal(t, "kubernetes", response.Query)
+	assert.Equal(t, []string{"kube", "kubernetes"}, searcher.ran())
+	assert.Equal(t, maxLiveSearchLimit, response.Limit)
+	assert.True(t, response.Truncated)
+
+	searcher.mu.Lock()
+	assert.Equal(t, defaultLiveSearchLimit, searcher.queries[0].Limit)
+	assert.Equal(t, maxLiveSearchLimit, searcher.queries[1].Limit)
+	searcher.mu.Unlock()
+}
+
+// cappedSearcher caps every query at max results but returns more than
+// that
+type cappedSearcher struct {
+	max int
+}
+
+func (s *cappedSearcher) Search(ctx context.Context, query search.SearchQuery) ([]search.SearchResult, error) {
+	results := make([]search.SearchResult, s.max*10)
+	for i := range results {
+		results[i] = search.SearchResult{Note: &types.NoteMetadata{ID: fmt.Sprintf("n%d", i)}}
+	}
+	return results, nil
+}
+
+func (s *cappedSearcher) EffectiveLimit(query search.SearchQuery) (int, bool) {
+	if query.Limit > s.max {
+		return s.max, true
+	}
+	return query.Limit, false
+}
+
+func TestLiveSearch_MaxLimit(t *testing.T) {
+	conn := dial(t, startLiveSearch(t, testServerConfig(), &cappedSearcher{max: 3}), nil)
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "kubernetes", Limit: 1_000_000}))
+	response := readResponse(t, conn)
+	assert.Equal(t, 3, response.Limit)
+	assert.True(t, response.Truncated)
+	assert.Len(t, response.Results, 3)
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "kubernetes", Limit: 2}))
+	response = readResponse(t, conn)
+	assert.Equal(t, 2, response.Limit)
+	assert.False(t, response.Truncated)
+	assert.Len(t, response.Results, 2)
+}
+
+func TestLiveSearch_InvalidMessage(t *testing.T) {
+	conn := dial(t, startLiveSearch(t, testServerConfig(), &recordingSearcher{}), nil)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not json")))
+	response := readResponse(t, conn)
+	assert.Contains(t, response.Error, "invalid request")
+
+	// The connection stays usable
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "still here"}))
+	assert.Equal(t, "still here", readResponse(t, conn).Query)
+}
+
+func TestLiveSearch_Auth(t *testing.T) {
+	config := testServerConfig()
+	config.Auth.Type = "apikey"
+	config.Auth.APIKeys = []string{"secret"}
+	url := startLiveSearch(t, config, &recordingSearcher{})
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	conn := dial(t, url, http.Header{"Authorization": {"Bearer secret"}})
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "header"}))
+	assert.Equal(t, "header", readResponse(t, conn).Query)
+
+	conn = dial(t, url+"?api_key=secret", nil)
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "param"}))
+	assert.Equal(t, "param", readResponse(t, conn).Query)
+}
+
+func TestLiveSearch_ConnectionLimit(t *testing.T) {
+	config := testServerConfig()
+	config.HTTP.MaxWebSocketConnections = 1
+	url := startLiveSearch(t, config, &recordingSearcher{})
+
+	first := dial(t, url, nil)
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	// Closing the first connection frees its slot
+	require.NoError(t, first.Close())
+	assert.Eventually(t, func() bool {
+		conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			if resp != nil {
+				_ = resp.Body.Close()
+			}
+			return false
+		}
+		_ = resp.Body.Close()
+		_ = conn.Close()
+		return true
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestLiveSearch_IdleTimeout(t *testing.T) {
+	handler := NewLiveSearchHandler(&recordingSearcher{}, testServerConfig().HTTP)
+	handler.idleTimeout = 100 * time.Millisecond
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn := dial(t, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseNormalClosure, closeErr.Code)
+	assert.Equal(t, "idle timeout", closeErr.Text)
+}
+
+// blockingSearcher blocks each search until its context is canceled
+type blockingSearcher struct {
+	started  chan struct{}
+	canceled chan struct{}
+}
+
+func (s *blockingSearcher) Search(ctx context.Context, query search.SearchQuery) ([]search.SearchResult, error) {
+	close(s.started)
+	<-ctx.Done()
+	close(s.canceled)
+	return nil, ctx.Err()
+}
+
+func TestLiveSearch_DisconnectCancelsSearch(t *testing.T) {
+	searcher := &blockingSearcher{started: make(chan struct{}), canceled: make(chan struct{})}
+	conn := dial(t, startLiveSearch(t, testServerConfig(), searcher), nil)
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "slow"}))
+	select {
+	case <-searcher.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("search did not start")
+	}
+
+	require.NoError(t, conn.Close())
+	select {
+	case <-searcher.canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("search was not canceled after the client disconnected")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	request := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://vault.example.com/ws/search", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	assert.Nil(t, checkOrigin(types.HTTPServerConfig{EnableCORS: false}))
+
+	anyOrigin := checkOrigin(types.HTTPServerConfig{EnableCORS: true, CORSOrigins: []string{"*"}})
+	assert.True(t, anyOrigin(request("https://elsewhere.example.org")))
+
+	listed := checkOrigin(types.HTTPServerConfig{EnableCORS: true, CORSOrigins: []string{"https://app.example.com"}})
+	assert.True(t, listed(request("https://app.example.com")))
+	assert.True(t, listed(request("http://vault.example.com")))
+	assert.True(t, listed(request("")))
+	assert.False(t, listed(request("https://evil.example.net")))
+
+	wildcard := checkOrigin(types.HTTPServerConfig{EnableCORS: true, CORSOrigins: []string{"https://*.example.com"}})
+	assert.True(t, wildcard(request("https://app.example.com")))
+	assert.False(t, wildcard(request("https://example.com")))
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
+)
+
+// RequestID wraps next so that every request carries a request ID in its
+// context: the client's X-Request-ID when it is a valid one, or a new ID
+// otherwise. The ID is returned in the X-Request-ID response header, so
+// that clients can quote it when reporting a problem.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(trace.RequestIDHeader)
+		if !trace.ValidRequestID(id) {
+			id = trace.NewRequestID()
+		}
+		w.Header().Set(trace.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(trace.WithRequestID(r.Context(), id)))
+	})
+}
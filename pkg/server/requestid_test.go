@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientID string
+		wantSame bool
+	}{
+		{"generated", "", false},
+		{"accepted from client", "client-id-123", true},
+		{"invalid replaced", "has spaces in it", false},
+		{"too long replaced", strings.Repeat("a", 200), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = trace.RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.clientID != "" {
+				req.Header.Set(trace.RequestIDHeader, tt.clientID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.NotEmpty(t, seen)
+			assert.Equal(t, seen, rec.Header().Get(trace.RequestIDHeader))
+			if tt.wantSame {
+				assert.Equal(t, tt.clientID, seen)
+			} else {
+				assert.NotEqual(t, tt.clientID, seen)
+				assert.True(t, trace.ValidRequestID(seen))
+			}
+		})
+	}
+}
+
+// idSearcher records the request ID each search carries
+type idSearcher struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (s *idSearcher) Search(ctx context.Context, query search.SearchQuery) ([]search.SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = append(s.ids, trace.RequestID(ctx))
+	return nil, nil
+}
+
+func TestLiveSearch_RequestID(t *testing.T) {
+	searcher := &idSearcher{}
+	url := startLiveSearch(t, testServerConfig(), searcher)
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, http.Header{trace.RequestIDHeader: {"ws-request-1"}})
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	t.Cleanup(func() { _ = conn.Close() })
+	assert.Equal(t, "ws-request-1", resp.Header.Get(trace.RequestIDHeader))
+
+	require.NoError(t, conn.WriteJSON(LiveSearchRequest{Query: "kubernetes"}))
+	readResponse(t, conn)
+
+	searcher.mu.Lock()
+	defer searcher.mu.Unlock()
+	assert.Equal(t, []string{"ws-request-1"}, searcher.ids)
+}
@@ -0,0 +1,373 @@
+package azblob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// copyPollInterval is how often an in-progress server-side copy is polled
+const copyPollInterval = 500 * time.Millisecond
+
+// Storage implements the StorageBackend interface for Azure Blob Storage
+type Storage struct {
+	client    *azblob.Client
+	container *container.Client
+	config    types.AzureBlobConfig
+}
+
+// NewStorage creates a new Azure Blob storage backend
+func NewStorage(cfg types.AzureBlobConfig) (*Storage, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("invalid Azure Blob configuration: %w", err)
+	}
+
+	client, err := createClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Blob client: %w", err)
+	}
+
+	storage := &Storage{
+		client:    client,
+		container: client.ServiceClient().NewContainerClient(cfg.ContainerName),
+		config:    cfg,
+	}
+
+	return storage, nil
+}
+
+// Type returns the storage backend type
+func (s *Storage) Type() types.StorageType {
+	return types.StorageTypeAzure
+}
+
+// Read retrieves a file's content by path
+func (s *Storage) Read(ctx context.Context, path string) ([]byte, error) {
+	resp, err := s.client.DownloadStream(ctx, s.config.ContainerName, s.buildKey(path), nil)
+	if err != nil {
+		return nil, s.handleError("read", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, types.NewStorageError(types.StorageTypeAzure, "read", path, err, false)
+	}
+
+	return data, nil
+}
+
+// Write stores content at the given path
+func (s *Storage) Write(ctx context.Context, path string, data []byte) error {
+	_, err := s.client.UploadBuffer(ctx, s.config.ContainerName, s.buildKey(path), data, nil)
+	if err != nil {
+		return s.handleError("write", path, err)
+	}
+
+	return nil
+}
+
+// Delete removes a file at the given path
+func (s *Storage) Delete(ctx context.Context, path string) error {
+	_, err := s.client.DeleteBlob(ctx, s.config.ContainerName, s.buildKey(path), nil)
+	if err != nil {
+		return s.handleError("delete", path, err)
+	}
+
+	return nil
+}
+
+// Exists checks if a file exists at the given path
+func (s *Storage) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := s.container.NewBlobClient(s.buildKey(path)).GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) || statusCode(err) == http.StatusNotFound {
+			return false, nil
+		}
+		return false, s.handleError("exists", path, err)
+	}
+
+	return true, nil
+}
+
+// List returns all files matching the given prefix
+func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	key := s.buildKey(prefix)
+
+	pager := s.client.NewListBlobsFlatPager(s.config.ContainerName, &azblob.ListBlobsFlatOptions{
+		Prefix: &key,
+	})
+
+	var files []string
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, s.handleError("list", prefix, err)
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil {
+				// Remove the prefix to get relative path
+				relativePath := s.relativePath(*item.Name)
+				if relativePath != "" {
+					files = append(files, relativePath)
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// Stat returns metadata about a file
+func (s *Storage) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
+	props, err := s.container.NewBlobClient(s.buildKey(path)).GetProperties(ctx, nil)
+	if err != nil {
+		return nil, s.handleError("stat", path, err)
+	}
+
+	info := &types.FileInfo{
+		Path: path,
+	}
+	if props.ContentLength != nil {
+		info.Size = *props.ContentLength
+	}
+	if props.LastModified != nil {
+		info.ModTime = props.LastModified.Unix()
+	}
+	if props.ETag != nil {
+		info.ETag = string(*props.ETag)
+	}
+	if props.ContentType != nil {
+		info.ContentType = *props.ContentType
+	}
+	if props.AccessTier != nil {
+		info.StorageClass = *props.AccessTier
+	}
+
+	// Add custom metadata
+	if len(props.Metadata) > 0 {
+		info.Metadata = make(map[string]string)
+		for k, v := range props.Metadata {
+			if v != nil {
+				info.Metadata[k] = *v
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// ReadStream returns a reader for streaming large files
+func (s *Storage) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := s.client.DownloadStream(ctx, s.config.ContainerName, s.buildKey(path), nil)
+	if err != nil {
+		return nil, s.handleError("read_stream", path, err)
+	}
+
+	return resp.Body, nil
+}
+
+// WriteStream writes data from a reader to the given path using a block blob upload
+func (s *Storage) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	_, err := s.client.UploadStream(ctx, s.config.ContainerName, s.buildKey(path), reader, nil)
+	if err != nil {
+		return s.handleError("write_stream", path, err)
+	}
+
+	return nil
+}
+
+// Copy copies a file from src to dst within the same backend
+func (s *Storage) Copy(ctx context.Context, src, dst string) error {
+	op := fmt.Sprintf("%s->%s", src, dst)
+	srcBlob := s.container.NewBlobClient(s.buildKey(src))
+	dstBlob := s.container.NewBlobClient(s.buildKey(dst))
+
+	resp, err := dstBlob.StartCopyFromURL(ctx, srcBlob.URL(), nil)
+	if err != nil {
+		return s.handleError("copy", op, err)
+	}
+
+	// Copies within an account usually complete synchronously, but the
+	// service may report a pending copy for larger blobs
+	status := resp.CopyStatus
+	for status != nil && *status == blob.CopyStatusTypePending {
+		select {
+		case <-ctx.Done():
+			return types.NewStorageError(types.StorageTypeAzure, "copy", op, ctx.Err(), false)
+		case <-time.After(copyPollInterval):
+		}
+
+		props, err := dstBlob.GetProperties(ctx, nil)
+		if err != nil {
+			return s.handleError("copy", op, err)
+		}
+		status = props.CopyStatus
+	}
+
+	if status != nil && *status != blob.CopyStatusTypeSuccess {
+		return types.NewStorageError(types.StorageTypeAzure, "copy", op,
+			fmt.Errorf("copy finished with status %s", *status), false)
+	}
+
+	return nil
+}
+
+// Move moves/renames a file from src to dst
+func (s *Storage) Move(ctx context.Context, src, dst string) error {
+	// Copy first
+	if err := s.Copy(ctx, src, dst); err != nil {
+		return err
+	}
+
+	// Then delete source
+	if err := s.Delete(ctx, src); err != nil {
+		// Try to cleanup the copy on failure
+		_ = s.Delete(ctx, dst)
+		return err
+	}
+
+	return nil
+}
+
+// Health performs a health check on the storage backend
+func (s *Storage) Health(ctx context.Context) error {
+	// Fetching container properties verifies both connectivity and access
+	_, err := s.container.GetProperties(ctx, nil)
+	if err != nil {
+		return s.handleError("health", "", err)
+	}
+
+	return nil
+}
+
+// Close cleanly shuts down the storage backend
+func (s *Storage) Close() error {
+	// Azure client doesn't require explicit closing
+	return nil
+}
+
+// buildKey constructs the full blob name with prefix
+func (s *Storage) buildKey(path string) string {
+	if s.config.Prefix == "" {
+		return path
+	}
+	return strings.TrimSuffix(s.config.Prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// relativePath strips the configured prefix from a blob name
+func (s *Storage) relativePath(name string) string {
+	if s.config.Prefix == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, strings.TrimSuffix(s.config.Prefix, "/")+"/")
+}
+
+// handleError converts Azure errors to storage errors
+func (s *Storage) handleError(operation, path string, err error) error {
+	retryable := isRetryableAzureError(err)
+
+	return types.NewStorageError(types.StorageTypeAzure, operation, path, err, retryable)
+}
+
+// isRetryableAzureError determines if an Azure error is retryable
+func isRetryableAzureError(err error) bool {
+	if bloberror.HasCode(err,
+		bloberror.InternalError,
+		bloberror.OperationTimedOut,
+		bloberror.ServerBusy,
+	) {
+		return true
+	}
+
+	code := statusCode(err)
+	return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+}
+
+// statusCode extracts the HTTP status code from an Azure response error
+func statusCode(err error) int {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode
+	}
+	return 0
+}
+
+// validateConfig validates the Azure Blob configuration
+func validateConfig(cfg types.AzureBlobConfig) error {
+	if cfg.ContainerName == "" {
+		return fmt.Errorf("container name cannot be empty")
+	}
+
+	if cfg.ConnectionString == "" {
+		if cfg.AccountName == "" {
+			return fmt.Errorf("account name cannot be empty without a connection string")
+		}
+		if cfg.AccountKey == "" {
+			return fmt.Errorf("account key or connection string is required")
+		}
+	}
+
+	// Validate retry configuration
+	if cfg.RetryAttempts < 0 {
+		return fmt.Errorf("retry attempts cannot be negative")
+	}
+
+	if cfg.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout cannot be negative")
+	}
+
+	return nil
+}
+
+// serviceURL returns the blob service URL for the configuration
+func serviceURL(cfg types.AzureBlobConfig) string {
+	if cfg.Endpoint != "" {
+		return strings.TrimSuffix(cfg.Endpoint, "/") + "/"
+	}
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.AccountName)
+}
+
+// createClient creates an Azure Blob client from the configuration
+func createClient(cfg types.AzureBlobConfig) (*azblob.Client, error) {
+	opts := &azblob.ClientOptions{}
+
+	// Set retry configuration
+	if cfg.RetryAttempts > 0 {
+		opts.Retry = policy.RetryOptions{
+			MaxRetries: int32(cfg.RetryAttempts),
+		}
+	}
+
+	// Set HTTP client configuration
+	if cfg.RequestTimeout > 0 {
+		opts.Transport = &http.Client{
+			Timeout: time.Duration(cfg.RequestTimeout) * time.Second,
+		}
+	}
+
+	if cfg.ConnectionString != "" {
+		return azblob.NewClientFromConnectionString(cfg.ConnectionString, opts)
+	}
+
+	cred, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shared key credential: %w", err)
+	}
+
+	return azblob.NewClientWithSharedKeyCredential(serviceURL(cfg), cred, opts)
+}
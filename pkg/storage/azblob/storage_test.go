@@ -0,0 +1,313 @@
+package azblob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// azuriteConnectionString is the well-known development connection string for Azurite
+const azuriteConnectionString = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;"
+
+func TestNewStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.AzureBlobConfig
+		wantErr bool
+	}{
+		{
+			name: "connection string",
+			config: types.AzureBlobConfig{
+				ConnectionString: azuriteConnectionString,
+				ContainerName:    "vault",
+			},
+			wantErr: false,
+		},
+		{
+			name: "account key with endpoint",
+			config: types.AzureBlobConfig{
+				AccountName:   "devstoreaccount1",
+				AccountKey:    "a2V5",
+				ContainerName: "vault",
+				Endpoint:      "http://127.0.0.1:10000/devstoreaccount1",
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing container",
+			config: types.AzureBlobConfig{
+				ConnectionString: azuriteConnectionString,
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing credentials",
+			config: types.AzureBlobConfig{
+				AccountName:   "devstoreaccount1",
+				ContainerName: "vault",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid account key",
+			config: types.AzureBlobConfig{
+				AccountName:   "devstoreaccount1",
+				AccountKey:    "not base64!",
+				ContainerName: "vault",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := NewStorage(tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, storage)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, storage)
+				assert.Equal(t, types.StorageTypeAzure, storage.Type())
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.AzureBlobConfig
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid account key config",
+			config: types.AzureBlobConfig{
+				AccountName:   "account",
+				AccountKey:    "key",
+				ContainerName: "vault",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid connection string config",
+			config: types.AzureBlobConfig{
+				ConnectionString: "UseDevelopmentStorage=true",
+				ContainerName:    "vault",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty container",
+			config:  types.AzureBlobConfig{ConnectionString: "UseDevelopmentStorage=true"},
+			wantErr: true,
+			errMsg:  "container name cannot be empty",
+		},
+		{
+			name:    "missing account name",
+			config:  types.AzureBlobConfig{AccountKey: "key", ContainerName: "vault"},
+			wantErr: true,
+			errMsg:  "account name cannot be empty",
+		},
+		{
+			name:    "missing account key",
+			config:  types.AzureBlobConfig{AccountName: "account", ContainerName: "vault"},
+			wantErr: true,
+			errMsg:  "account key or connection string is required",
+		},
+		{
+			name: "negative retry attempts",
+			config: types.AzureBlobConfig{
+				ConnectionString: "UseDevelopmentStorage=true",
+				ContainerName:    "vault",
+				RetryAttempts:    -1,
+			},
+			wantErr: true,
+			errMsg:  "retry attempts cannot be negative",
+		},
+		{
+			name: "negative request timeout",
+			config: types.AzureBlobConfig{
+				ConnectionString: "UseDevelopmentStorage=true",
+				ContainerName:    "vault",
+				RequestTimeout:   -1,
+			},
+			wantErr: true,
+			errMsg:  "request timeout cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		path     string
+		expected string
+	}{
+		{"no prefix", "", "notes/test.md", "notes/test.md"},
+		{"with prefix", "vault", "notes/test.md", "vault/notes/test.md"},
+		{"prefix with trailing slash", "vault/", "notes/test.md", "vault/notes/test.md"},
+		{"path with leading slash", "vault", "/notes/test.md", "vault/notes/test.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &Storage{config: types.AzureBlobConfig{Prefix: tt.prefix}}
+			assert.Equal(t, tt.expected, storage.buildKey(tt.path))
+		})
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	storage := &Storage{config: types.AzureBlobConfig{Prefix: "vault/"}}
+	assert.Equal(t, "notes/test.md", storage.relativePath("vault/notes/test.md"))
+
+	storage = &Storage{config: types.AzureBlobConfig{}}
+	assert.Equal(t, "notes/test.md", storage.relativePath("notes/test.md"))
+}
+
+func TestIsRetryableAzureError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"generic error", errors.New("boom"), false},
+		{"server error", &azcore.ResponseError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"throttled", &azcore.ResponseError{StatusCode: http.StatusTooManyRequests}, true},
+		{"request timeout", &azcore.ResponseError{StatusCode: http.StatusRequestTimeout}, true},
+		{"not found", &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "BlobNotFound"}, false},
+		{"forbidden", &azcore.ResponseError{StatusCode: http.StatusForbidden}, false},
+		{"server busy code", &azcore.ResponseError{StatusCode: http.StatusBadRequest, ErrorCode: "ServerBusy"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isRetryableAzureError(tt.err))
+		})
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	storage := &Storage{}
+	err := storage.handleError("read", "notes/test.md", &azcore.ResponseError{StatusCode: http.StatusInternalServerError})
+
+	var storageErr *types.StorageError
+	require.True(t, errors.As(err, &storageErr))
+	assert.Equal(t, types.StorageTypeAzure, storageErr.Backend)
+	assert.Equal(t, "read", storageErr.Operation)
+	assert.Equal(t, "notes/test.md", storageErr.Path)
+	assert.True(t, storageErr.Retryable)
+}
+
+func TestServiceURL(t *testing.T) {
+	assert.Equal(t, "https://account.blob.core.windows.net/",
+		serviceURL(types.AzureBlobConfig{AccountName: "account"}))
+	assert.Equal(t, "http://127.0.0.1:10000/devstoreaccount1/",
+		serviceURL(types.AzureBlobConfig{Endpoint: "http://127.0.0.1:10000/devstoreaccount1"}))
+}
+
+// Integration test (requires an Azure storage account or Azurite and an existing container)
+func TestStorageIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	// This test requires environment variables:
+	// - TEST_AZURE_CONTAINER
+	// - TEST_AZURE_CONNECTION_STRING (e.g. the Azurite development connection string)
+
+	containerName := getEnvOrSkip(t, "TEST_AZURE_CONTAINER")
+	connectionString := getEnvOrSkip(t, "TEST_AZURE_CONNECTION_STRING")
+
+	storage, err := NewStorage(types.AzureBlobConfig{
+		ConnectionString: connectionString,
+		ContainerName:    containerName,
+		Prefix:           "test-vault",
+	})
+	require.NoError(t, err)
+	defer func() { _ = storage.Close() }()
+
+	ctx := context.Background()
+
+	require.NoError(t, storage.Health(ctx))
+
+	// Test write/read cycle
+	testPath := "test-file.txt"
+	testData := []byte("Hello, Azure!")
+	require.NoError(t, storage.Write(ctx, testPath, testData))
+
+	exists, err := storage.Exists(ctx, testPath)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	data, err := storage.Read(ctx, testPath)
+	assert.NoError(t, err)
+	assert.Equal(t, testData, data)
+
+	info, err := storage.Stat(ctx, testPath)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(testData)), info.Size)
+
+	files, err := storage.List(ctx, "")
+	assert.NoError(t, err)
+	assert.Contains(t, files, testPath)
+
+	// Test move (copy + delete)
+	movePath := "test-file-moved.txt"
+	require.NoError(t, storage.Move(ctx, testPath, movePath))
+
+	exists, err = storage.Exists(ctx, testPath)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Test streaming
+	streamPath := "test-stream.txt"
+	require.NoError(t, storage.WriteStream(ctx, streamPath, strings.NewReader("streamed content")))
+
+	reader, err := storage.ReadStream(ctx, streamPath)
+	require.NoError(t, err)
+	streamed, err := io.ReadAll(reader)
+	_ = reader.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed content", string(streamed))
+
+	// Cleanup
+	_ = storage.Delete(ctx, movePath)
+	_ = storage.Delete(ctx, streamPath)
+}
+
+// Helper function to get environment variable or skip test
+func getEnvOrSkip(t *testing.T, key string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		t.Skipf("Environment variable %s not set, skipping integration test", key)
+	}
+	return value
+}
@@ -0,0 +1,570 @@
+// Package cache provides caching layers that wrap a storage backend.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+const (
+	// entrySuffix marks cache entry files so that cleanup never touches
+	// anything else in the cache directory
+	entrySuffix = ".cache"
+
+	// statsFile keeps hit, miss and eviction counts between runs
+	statsFile = "stats.json"
+)
+
+// Stats reports disk cache activity and its current size on disk. Counters
+// are saved when the cache is closed, so they cover every run since the
+// cache was last cleared.
+type Stats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// DiskCache wraps a storage backend and keeps a copy of every file it reads
+// on local disk. Reads within the TTL are served from disk; writes, deletes,
+// copies and moves through the cache invalidate the affected entries.
+type DiskCache struct {
+	backend types.StorageBackend
+	dir     string
+	maxSize int64
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	size      int64
+	hits      int64
+	misses    int64
+	evictions int64
+
+	// generations counts invalidations per path, so a read-through that
+	// raced with a write or delete does not cache the bytes it replaced
+	generations map[string]uint64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// now is replaced in tests to control entry age
+	now func() time.Time
+}
+
+// NewDiskCache wraps backend with a disk cache. Entries are stored in a
+// subdirectory of config.Path derived from namespace, so several vaults can
+// share one cache path without seeing each other's files. A zero TTL keeps
+// entries until they are evicted for size, a zero MaxSizeMB disables the
+// size cap and a zero CleanupIntervalHours disables background cleanup.
+func NewDiskCache(backend types.StorageBackend, config types.DiskCacheConfig, namespace string) (*DiskCache, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("disk cache path cannot be empty")
+	}
+
+	dir := namespaceDir(config.Path, namespace)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+
+	c := &DiskCache{
+		backend: backend,
+		dir:     dir,
+		maxSize: int64(config.MaxSizeMB) * 1024 * 1024,
+		ttl:     time.Duration(config.TTLHours) * time.Hour,
+		now:     time.Now,
+
+		generations: make(map[string]uint64),
+	}
+
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		c.size += e.size
+	}
+	c.loadStats()
+
+	if config.CleanupIntervalHours > 0 {
+		c.stop = make(chan struct{})
+		c.done = make(chan struct{})
+		go c.cleanupLoop(time.Duration(config.CleanupIntervalHours) * time.Hour)
+	}
+
+	return c, nil
+}
+
+// ClearNamespace removes the cached entries for namespace without opening
+// the backend, for use when the cache is not in use by this process
+func ClearNamespace(config types.DiskCacheConfig, namespace string) error {
+	if config.Path == "" {
+		return fmt.Errorf("disk cache path cannot be empty")
+	}
+	if err := os.RemoveAll(namespaceDir(config.Path, namespace)); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// namespaceDir returns the directory holding cache entries for namespace
+// under the cache path
+func namespaceDir(path, namespace string) string {
+	sum := sha256.Sum256([]byte(namespace))
+	return filepath.Join(path, hex.EncodeToString(sum[:8]))
+}
+
+// Type returns the storage backend type
+func (c *DiskCache) Type() types.StorageType {
+	return c.backend.Type()
+}
+
+// Read returns the cached copy of path if it is fresh, otherwise it reads
+// from the backend and caches the result
+func (c *DiskCache) Read(ctx context.Context, path string) ([]byte, error) {
+	if data, ok := c.get(path); ok {
+		return data, nil
+	}
+
+	gen := c.generation(path)
+	data, err := c.backend.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.put(path, data, gen)
+	return data, nil
+}
+
+// Write stores content in the backend and invalidates the cached copy
+func (c *DiskCache) Write(ctx context.Context, path string, data []byte) error {
+	defer c.invalidate(path)
+	return c.backend.Write(ctx, path, data)
+}
+
+// Delete removes a file from the backend and the cache
+func (c *DiskCache) Delete(ctx context.Context, path string) error {
+	defer c.invalidate(path)
+	return c.backend.Delete(ctx, path)
+}
+
+// Exists delegates to the backend
+func (c *DiskCache) Exists(ctx context.Context, path string) (bool, error) {
+	return c.backend.Exists(ctx, path)
+}
+
+// List delegates to the backend
+func (c *DiskCache) List(ctx context.Context, prefix string) ([]string, error) {
+	return c.backend.List(ctx, prefix)
+}
+
+// Walk delegates to the backend, which walks without collecting paths
+// when it can
+func (c *DiskCache) Walk(ctx context.Context, prefix string, fn types.WalkFunc) error {
+	return types.Walk(ctx, c.backend, prefix, fn)
+}
+
+// Stat delegates to the backend
+func (c *DiskCache) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
+	return c.backend.Stat(ctx, path)
+}
+
+// ReadStream serves a fresh cached copy when there is one and otherwise
+// streams from the backend without caching
+func (c *DiskCache) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	if data, ok := c.get(path); ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return c.backend.ReadStream(ctx, path)
+}
+
+// WriteStream writes to the backend and invalidates the cached copy
+func (c *DiskCache) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	defer c.invalidate(path)
+	return c.backend.WriteStream(ctx, path, reader)
+}
+
+// Copy copies a file in the backend and invalidates the destination
+func (c *DiskCache) Copy(ctx context.Context, src, dst string) error {
+	defer c.invalidate(dst)
+	return c.backend.Copy(ctx, src, dst)
+}
+
+// Move moves a file in the backend and invalidates both paths
+func (c *DiskCache) Move(ctx context.Context, src, dst string) error {
+	defer c.invalidate(src)
+	defer c.invalidate(dst)
+	return c.backend.Move(ctx, src, dst)
+}
+
+// FilesContaining delegates to the backend when it can search on the
+// server
+func (c *DiskCache) FilesContaining(ctx context.Context, paths []string, literal string, ignoreCase bool) ([]string, error) {
+	selector, ok := c.backend.(types.SelectBackend)
+	if !ok {
+		return nil, types.ErrSelectNotSupported
+	}
+	return selector.FilesContaining(ctx, paths, literal, ignoreCase)
+}
+
+// ListVersions delegates to the backend when it keeps versions
+func (c *DiskCache) ListVersions(ctx context.Context, path string) ([]types.FileVersion, error) {
+	versioned, ok := c.backend.(types.VersionedBackend)
+	if !ok {
+		return nil, types.ErrVersioningNotSupported
+	}
+	return versioned.ListVersions(ctx, path)
+}
+
+// ReadVersion delegates to the backend when it keeps versions. Past
+// versions are not cached.
+func (c *DiskCache) ReadVersion(ctx context.Context, path, versionID string) ([]byte, error) {
+	versioned, ok := c.backend.(types.VersionedBackend)
+	if !ok {
+		return nil, types.ErrVersioningNotSupported
+	}
+	return versioned.ReadVersion(ctx, path, versionID)
+}
+
+// RestoreVersion restores a version in the backend and invalidates the
+// cached copy
+func (c *DiskCache) RestoreVersion(ctx context.Context, path, versionID string) error {
+	versioned, ok := c.backend.(types.VersionedBackend)
+	if !ok {
+		return types.ErrVersioningNotSupported
+	}
+	defer c.invalidate(path)
+	return versioned.RestoreVersion(ctx, path, versionID)
+}
+
+// BatchRead serves fresh cached copies and reads the rest from the backend
+// in one batch, caching them
+func (c *DiskCache) BatchRead(ctx context.Context, paths []string) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(paths))
+	var missing []string
+	gens := make(map[string]uint64)
+	for _, path := range paths {
+		if data, ok := c.get(path); ok {
+			files[path] = data
+			continue
+		}
+		missing = append(missing, path)
+		gens[path] = c.generation(path)
+	}
+	if len(missing) == 0 {
+		return files, nil
+	}
+
+	read, err := types.BatchRead(ctx, c.backend, missing)
+	for path, data := range read {
+		c.put(path, data, gens[path])
+		files[path] = data
+	}
+	return files, err
+}
+
+// BatchDelete removes files from the backend in one batch and from the
+// cache
+func (c *DiskCache) BatchDelete(ctx context.Context, paths []string) error {
+	defer func() {
+		for _, path := range paths {
+			c.invalidate(path)
+		}
+	}()
+	return types.BatchDelete(ctx, c.backend, paths)
+}
+
+// Health delegates to the backend
+func (c *DiskCache) Health(ctx context.Context) error {
+	return c.backend.Health(ctx)
+}
+
+// Close stops background cleanup, saves the counters and closes the
+// backend. Cached entries stay on disk for the next run.
+func (c *DiskCache) Close() error {
+	c.closeOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+			<-c.done
+		}
+		c.saveStats()
+	})
+	return c.backend.Close()
+}
+
+// Stats returns cache counters and the current size on disk
+func (c *DiskCache) Stats() Stats {
+	entries, _ := c.entries()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   len(entries),
+	}
+	for _, e := range entries {
+		stats.SizeBytes += e.size
+	}
+	return stats
+}
+
+// Clear removes every entry from the cache and resets the counters
+func (c *DiskCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache entry %s: %w", e.path, err)
+		}
+	}
+	c.size = 0
+	c.hits, c.misses, c.evictions = 0, 0, 0
+	return nil
+}
+
+// Cleanup removes expired entries and, if the cache is over its size cap,
+// the oldest remaining entries until it fits
+func (c *DiskCache) Cleanup() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+
+	// Oldest first, so size eviction drops the least recently fetched
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+
+	now := c.now()
+	for _, e := range entries {
+		expired := c.ttl > 0 && now.Sub(e.modTime) >= c.ttl
+		oversized := c.maxSize > 0 && total > c.maxSize
+		if !expired && !oversized {
+			continue
+		}
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache entry %s: %w", e.path, err)
+		}
+		total -= e.size
+		c.evictions++
+	}
+
+	c.size = total
+	return nil
+}
+
+// loadStats restores counters saved by a previous run. A missing or
+// unreadable file starts the counters from zero.
+func (c *DiskCache) loadStats() {
+	data, err := os.ReadFile(filepath.Join(c.dir, statsFile))
+	if err != nil {
+		return
+	}
+	var saved Stats
+	if json.Unmarshal(data, &saved) != nil {
+		return
+	}
+	c.hits, c.misses, c.evictions = saved.Hits, saved.Misses, saved.Evictions
+}
+
+// saveStats writes the counters for the next run. Concurrent runs against
+// the same cache overwrite each other's counts, so they are approximate.
+func (c *DiskCache) saveStats() {
+	c.mu.Lock()
+	saved := Stats{Hits: c.hits, Misses: c.misses, Evictions: c.evictions}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(c.dir, statsFile), data, 0600)
+}
+
+func (c *DiskCache) cleanupLoop(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = c.Cleanup()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// get returns the cached data for path if the entry exists and is fresh
+func (c *DiskCache) get(path string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entryPath := c.entryPath(path)
+	info, err := os.Stat(entryPath)
+	if err == nil && c.ttl > 0 && c.now().Sub(info.ModTime()) >= c.ttl {
+		// Expired entries are dropped so they don't count towards the cap
+		if os.Remove(entryPath) == nil {
+			c.size -= info.Size()
+			c.evictions++
+		}
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		c.misses++
+		return nil, false
+	}
+
+	data, err := os.ReadFile(entryPath)
+	if err != nil {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	return data, true
+}
+
+// generation returns the invalidation count for path. Capture it before
+// reading from the backend and pass it to put.
+func (c *DiskCache) generation(path string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[path]
+}
+
+// put stores data for path unless path was invalidated since gen was
+// captured, in which case data may predate a write or delete. Failures are
+// ignored: the cache is an optimisation and the data has already been read
+// from the backend.
+func (c *DiskCache) put(path string, data []byte, gen uint64) {
+	size := int64(len(data))
+	if c.maxSize > 0 && size > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	if c.generations[path] != gen {
+		c.mu.Unlock()
+		return
+	}
+	entryPath := c.entryPath(path)
+	if info, err := os.Stat(entryPath); err == nil {
+		c.size -= info.Size()
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		c.mu.Unlock()
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmp.Name())
+		c.mu.Unlock()
+		return
+	}
+
+	// Entry age is its modification time, so stamp it with the cache clock
+	now := c.now()
+	_ = os.Chtimes(tmp.Name(), now, now)
+	if err := os.Rename(tmp.Name(), entryPath); err != nil {
+		_ = os.Remove(tmp.Name())
+		c.mu.Unlock()
+		return
+	}
+
+	c.size += size
+	overCap := c.maxSize > 0 && c.size > c.maxSize
+	c.mu.Unlock()
+
+	if overCap {
+		_ = c.Cleanup()
+	}
+}
+
+// invalidate drops the cached copy of path
+func (c *DiskCache) invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[path]++
+	entryPath := c.entryPath(path)
+	if info, err := os.Stat(entryPath); err == nil {
+		if os.Remove(entryPath) == nil {
+			c.size -= info.Size()
+		}
+	}
+}
+
+func (c *DiskCache) entryPath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+entrySuffix)
+}
+
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// entries lists the cache entry files on disk
+func (c *DiskCache) entries() ([]entry, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory %s: %w", c.dir, err)
+	}
+
+	var entries []entry
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), entrySuffix) {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{
+			path:    filepath.Join(c.dir, d.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,482 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// memoryBackend is an in-memory storage backend that counts reads
+type memoryBackend struct {
+	mu     sync.Mutex
+	files  map[string][]byte
+	reads  int
+	closed bool
+}
+
+func newMemoryBackend(files map[string]string) *memoryBackend {
+	b := &memoryBackend{files: make(map[string][]byte)}
+	for path, content := range files {
+		b.files[path] = []byte(content)
+	}
+	return b
+}
+
+func (b *memoryBackend) Type() types.StorageType { return types.StorageTypeS3 }
+
+func (b *memoryBackend) Read(ctx context.Context, path string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reads++
+	data, ok := b.files[path]
+	if !ok {
+		return nil, types.NewNoteNotFoundError(path)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (b *memoryBackend) Write(ctx context.Context, path string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.files[path] = append([]byte(nil), data...)
+	return nil
+}
+
+func (b *memoryBackend) Delete(ctx context.Context, path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.files, path)
+	return nil
+}
+
+func (b *memoryBackend) Exists(ctx context.Context, path string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.files[path]
+	return ok, nil
+}
+
+func (b *memoryBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	return nil, nil
+}
+
+func (b *memoryBackend) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
+	return nil, types.NewNoteNotFoundError(path)
+}
+
+func (b *memoryBackend) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	data, err := b.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *memoryBackend) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return b.Write(ctx, path, data)
+}
+
+func (b *memoryBackend) Copy(ctx context.Context, src, dst string) error {
+	data, err := b.Read(ctx, src)
+	if err != nil {
+		return err
+	}
+	return b.Write(ctx, dst, data)
+}
+
+func (b *memoryBackend) Move(ctx context.Context, src, dst string) error {
+	if err := b.Copy(ctx, src, dst); err != nil {
+		return err
+	}
+	return b.Delete(ctx, src)
+}
+
+func (b *memoryBackend) Health(ctx context.Context) error { return nil }
+
+func (b *memoryBackend) Close() error {
+	b.closed = true
+	return nil
+}
+
+func (b *memoryBackend) readCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reads
+}
+
+// testClock is a controllable time source
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time          { return c.t }
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestCache(t *testing.T, backend types.StorageBackend, config types.DiskCacheConfig) (*DiskCache, *testClock) {
+	t.Helper()
+
+	if config.Path == "" {
+		config.Path = t.TempDir()
+	}
+	c, err := NewDiskCache(backend, config, "test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	clock := &testClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.now = clock.now
+	return c, clock
+}
+
+func TestNewDiskCache(t *testing.T) {
+	_, err := NewDiskCache(newMemoryBackend(nil), types.DiskCacheConfig{}, "test")
+	assert.Error(t, err)
+
+	c, err := NewDiskCache(newMemoryBackend(nil), types.DiskCacheConfig{Path: t.TempDir()}, "test")
+	require.NoError(t, err)
+	assert.DirExists(t, c.dir)
+	require.NoError(t, c.Close())
+}
+
+func TestDiskCache_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryBackend(map[string]string{"notes/a.md": "alpha"})
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{TTLHours: 1})
+
+	for i := 0; i < 3; i++ {
+		data, err := c.Read(ctx, "notes/a.md")
+		require.NoError(t, err)
+		assert.Equal(t, "alpha", string(data))
+	}
+	assert.Equal(t, 1, backend.readCount())
+
+	stream, err := c.ReadStream(ctx, "notes/a.md")
+	require.NoError(t, err)
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "alpha", string(data))
+	assert.Equal(t, 1, backend.readCount())
+
+	_, err = c.Read(ctx, "notes/missing.md")
+	assert.True(t, types.IsNotFoundError(err))
+
+	stats := c.Stats()
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(len("alpha")), stats.SizeBytes)
+}
+
+func TestDiskCache_TTLExpiry(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryBackend(map[string]string{"notes/a.md": "v1"})
+	c, clock := newTestCache(t, backend, types.DiskCacheConfig{TTLHours: 1})
+
+	_, err := c.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+
+	// Change the backend behind the cache's back
+	backend.files["notes/a.md"] = []byte("v2")
+
+	clock.advance(59 * time.Minute)
+	data, err := c.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data), "fresh entry should be served from disk")
+
+	clock.advance(time.Minute)
+	data, err = c.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(data), "expired entry should be refetched")
+	assert.Equal(t, 2, backend.readCount())
+	assert.Equal(t, int64(1), c.Stats().Evictions)
+}
+
+func TestDiskCache_CleanupRemovesExpired(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryBackend(map[string]string{"a.md": "a", "b.md": "b"})
+	c, clock := newTestCache(t, backend, types.DiskCacheConfig{TTLHours: 2})
+
+	_, err := c.Read(ctx, "a.md")
+	require.NoError(t, err)
+	clock.advance(time.Hour)
+	_, err = c.Read(ctx, "b.md")
+	require.NoError(t, err)
+
+	clock.advance(time.Hour)
+	require.NoError(t, c.Cleanup())
+
+	stats := c.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.NoFileExists(t, c.entryPath("a.md"))
+	assert.FileExists(t, c.entryPath("b.md"))
+}
+
+func TestDiskCache_SizeCapEviction(t *testing.T) {
+	ctx := context.Background()
+	chunk := string(bytes.Repeat([]byte("x"), 400*1024))
+	backend := newMemoryBackend(map[string]string{
+		"a.md":   chunk,
+		"b.md":   chunk,
+		"c.md":   chunk,
+		"big.md": chunk + chunk + chunk,
+	})
+	c, clock := newTestCache(t, backend, types.DiskCacheConfig{MaxSizeMB: 1})
+
+	for _, path := range []string{"a.md", "b.md", "c.md"} {
+		_, err := c.Read(ctx, path)
+		require.NoError(t, err)
+		clock.advance(time.Minute)
+	}
+
+	// Three 400KB entries exceed 1MB, so the oldest is evicted
+	assert.NoFileExists(t, c.entryPath("a.md"))
+	assert.FileExists(t, c.entryPath("b.md"))
+	assert.FileExists(t, c.entryPath("c.md"))
+
+	stats := c.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.LessOrEqual(t, stats.SizeBytes, int64(1024*1024))
+	assert.Equal(t, int64(1), stats.Evictions)
+
+	// Entries larger than the cap are never cached
+	_, err := c.Read(ctx, "big.md")
+	require.NoError(t, err)
+	assert.NoFileExists(t, c.entryPath("big.md"))
+	assert.Equal(t, 2, c.Stats().Entries)
+}
+
+func TestDiskCache_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryBackend(map[string]string{"a.md": "a1", "b.md": "b1"})
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{})
+
+	read := func(path string) string {
+		data, err := c.Read(ctx, path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "a1", read("a.md"))
+	require.NoError(t, c.Write(ctx, "a.md", []byte("a2")))
+	assert.Equal(t, "a2", read("a.md"))
+
+	require.NoError(t, c.WriteStream(ctx, "a.md", bytes.NewReader([]byte("a3"))))
+	assert.Equal(t, "a3", read("a.md"))
+
+	assert.Equal(t, "b1", read("b.md"))
+	require.NoError(t, c.Copy(ctx, "a.md", "b.md"))
+	assert.Equal(t, "a3", read("b.md"))
+
+	require.NoError(t, c.Move(ctx, "b.md", "a.md"))
+	assert.Equal(t, "a3", read("a.md"))
+	_, err := c.Read(ctx, "b.md")
+	assert.True(t, types.IsNotFoundError(err))
+
+	require.NoError(t, c.Delete(ctx, "a.md"))
+	_, err = c.Read(ctx, "a.md")
+	assert.True(t, types.IsNotFoundError(err))
+	assert.Equal(t, 0, c.Stats().Entries)
+}
+
+// pausedReadBackend holds every Read after it has fetched the data until
+// release is closed
+type pausedReadBackend struct {
+	*memoryBackend
+	fetched chan struct{}
+	release chan struct{}
+}
+
+func (b *pausedReadBackend) Read(ctx context.Context, path string) ([]byte, error) {
+	data, err := b.memoryBackend.Read(ctx, path)
+	b.fetched <- struct{}{}
+	<-b.release
+	return data, err
+}
+
+func TestDiskCache_ReadRacingWriteDoesNotCacheStaleData(t *testing.T) {
+	ctx := context.Background()
+	backend := &pausedReadBackend{
+		memoryBackend: newMemoryBackend(map[string]string{"a.md": "old"}),
+		fetched:       make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		data, err := c.Read(ctx, "a.md")
+		assert.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+	}()
+
+	// The read has the old bytes but has not cached them yet
+	<-backend.fetched
+	require.NoError(t, c.Write(ctx, "a.md", []byte("new")))
+	close(backend.release)
+	wg.Wait()
+
+	assert.NoFileExists(t, c.entryPath("a.md"))
+
+	go func() { <-backend.fetched }()
+	data, err := c.Read(ctx, "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+}
+
+func TestDiskCache_Batch(t *testing.T) {
+	ctx := context.Background()
+	backend := newMemoryBackend(map[string]string{"a.md": "a1", "b.md": "b1", "c.md": "c1"})
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{})
+
+	_, err := c.Read(ctx, "a.md")
+	require.NoError(t, err)
+
+	// Cached files are served from disk; the rest are read and cached
+	files, err := c.BatchRead(ctx, []string{"a.md", "b.md", "missing.md"})
+	assert.Equal(t, map[string][]byte{"a.md": []byte("a1"), "b.md": []byte("b1")}, files)
+	var batchErr *types.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []string{"missing.md"}, batchErr.Paths())
+	assert.Equal(t, 3, backend.readCount())
+
+	files, err = c.BatchRead(ctx, []string{"a.md", "b.md"})
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, 3, backend.readCount())
+
+	require.NoError(t, c.BatchDelete(ctx, []string{"a.md", "c.md"}))
+	_, err = c.Read(ctx, "a.md")
+	assert.True(t, types.IsNotFoundError(err))
+	assert.Equal(t, 1, c.Stats().Entries)
+}
+
+func TestDiskCache_Walk(t *testing.T) {
+	ctx := context.Background()
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true})
+	require.NoError(t, err)
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{})
+
+	require.NoError(t, c.Write(ctx, "notes/a.md", []byte("a")))
+	require.NoError(t, c.Write(ctx, "notes/b.md", []byte("bb")))
+
+	sizes := make(map[string]int64)
+	require.NoError(t, c.Walk(ctx, "notes/", func(path string, info *types.FileInfo) error {
+		sizes[path] = info.Size
+		return nil
+	}))
+	assert.Equal(t, map[string]int64{"notes/a.md": 1, "notes/b.md": 2}, sizes)
+}
+
+func TestDiskCache_RestoreVersionInvalidates(t *testing.T) {
+	ctx := context.Background()
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true, VersionRetention: 5})
+	require.NoError(t, err)
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{})
+
+	require.NoError(t, c.Write(ctx, "a.md", []byte("a1")))
+	require.NoError(t, c.Write(ctx, "a.md", []byte("a2")))
+	data, err := c.Read(ctx, "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "a2", string(data))
+
+	versions, err := c.ListVersions(ctx, "a.md")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.NoError(t, c.RestoreVersion(ctx, "a.md", versions[1].ID))
+
+	data, err = c.Read(ctx, "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "a1", string(data))
+
+	unversioned, _ := newTestCache(t, newMemoryBackend(nil), types.DiskCacheConfig{})
+	_, err = unversioned.ListVersions(ctx, "a.md")
+	assert.ErrorIs(t, err, types.ErrVersioningNotSupported)
+}
+
+func TestDiskCache_Namespaces(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	first, err := NewDiskCache(newMemoryBackend(map[string]string{"a.md": "first"}), types.DiskCacheConfig{Path: path}, "s3://one")
+	require.NoError(t, err)
+	defer func() { _ = first.Close() }()
+	second, err := NewDiskCache(newMemoryBackend(map[string]string{"a.md": "second"}), types.DiskCacheConfig{Path: path}, "s3://two")
+	require.NoError(t, err)
+	defer func() { _ = second.Close() }()
+
+	data, err := first.Read(ctx, "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+	data, err = second.Read(ctx, "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	require.NoError(t, ClearNamespace(types.DiskCacheConfig{Path: path}, "s3://one"))
+	assert.Equal(t, 0, first.Stats().Entries)
+	assert.Equal(t, 1, second.Stats().Entries)
+
+	require.NoError(t, second.Clear())
+	assert.Equal(t, 0, second.Stats().Entries)
+}
+
+func TestDiskCache_PersistsAcrossOpens(t *testing.T) {
+	ctx := context.Background()
+	config := types.DiskCacheConfig{Path: t.TempDir(), TTLHours: 1}
+
+	backend := newMemoryBackend(map[string]string{"a.md": "alpha"})
+	c, err := NewDiskCache(backend, config, "test")
+	require.NoError(t, err)
+	_, err = c.Read(ctx, "a.md")
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+	assert.True(t, backend.closed)
+
+	reopened, err := NewDiskCache(backend, config, "test")
+	require.NoError(t, err)
+	defer func() { _ = reopened.Close() }()
+	assert.Equal(t, int64(len("alpha")), reopened.size)
+
+	_, err = reopened.Read(ctx, "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.readCount())
+}
+
+func TestDiskCache_BackgroundCleanupStopsOnClose(t *testing.T) {
+	c, err := NewDiskCache(newMemoryBackend(nil), types.DiskCacheConfig{Path: t.TempDir(), CleanupIntervalHours: 1}, "test")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Close())
+	select {
+	case <-c.done:
+	default:
+		t.Fatal("cleanup goroutine still running after Close")
+	}
+
+	// Closing twice is safe
+	require.NoError(t, c.Close())
+}
+
+func TestDiskCache_IgnoresForeignFiles(t *testing.T) {
+	c, _ := newTestCache(t, newMemoryBackend(nil), types.DiskCacheConfig{MaxSizeMB: 1, TTLHours: 1})
+
+	foreign := c.dir + "/keep.txt"
+	require.NoError(t, os.WriteFile(foreign, []byte("not a cache entry"), 0600))
+
+	require.NoError(t, c.Clear())
+	require.NoError(t, c.Cleanup())
+	assert.FileExists(t, foreign)
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// DefaultWarmConcurrency is the number of parallel reads Warm uses when no
+// concurrency is given
+const DefaultWarmConcurrency = 8
+
+// WarmResult summarises a Warm run
+type WarmResult struct {
+	// Fetched is the number of files read from the backend and cached
+	Fetched int
+
+	// Cached is the number of files that already had a fresh entry
+	Cached int
+
+	// Failed maps each path that could not be read to its error
+	Failed map[string]error
+}
+
+// Warm reads every path that has no fresh cache entry from the backend and
+// caches it, running at most concurrency reads at a time. Warming doesn't
+// count towards the hit and miss statistics. Per-file failures are
+// collected in the result; the returned error is only set when ctx is
+// cancelled.
+func (c *DiskCache) Warm(ctx context.Context, paths []string, concurrency int) (WarmResult, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultWarmConcurrency
+	}
+
+	result := WarmResult{Failed: make(map[string]error)}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		if c.fresh(path) {
+			result.Cached++
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			gen := c.generation(path)
+			data, err := c.backend.Read(ctx, path)
+			if err == nil {
+				c.put(path, data, gen)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed[path] = err
+				return
+			}
+			result.Fetched++
+		}(path)
+	}
+
+	wg.Wait()
+	return result, ctx.Err()
+}
+
+// fresh reports whether path has a cache entry within the TTL
+func (c *DiskCache) fresh(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.entryPath(path))
+	if err != nil {
+		return false
+	}
+	return c.ttl == 0 || c.now().Sub(info.ModTime()) < c.ttl
+}
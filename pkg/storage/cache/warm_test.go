@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// concurrencyBackend records the highest number of reads in flight
+type concurrencyBackend struct {
+	*memoryBackend
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (b *concurrencyBackend) Read(ctx context.Context, path string) ([]byte, error) {
+	n := b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return b.memoryBackend.Read(ctx, path)
+}
+
+func TestDiskCache_Warm(t *testing.T) {
+	ctx := context.Background()
+
+	files := make(map[string]string)
+	var paths []string
+	for i := 0; i < 20; i++ {
+		path := fmt.Sprintf("notes/%02d.md", i)
+		files[path] = fmt.Sprintf("note %d", i)
+		paths = append(paths, path)
+	}
+	backend := &concurrencyBackend{memoryBackend: newMemoryBackend(files)}
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{TTLHours: 1})
+
+	// One note is already cached
+	_, err := c.Read(ctx, paths[0])
+	require.NoError(t, err)
+
+	result, err := c.Warm(ctx, append(paths, "notes/missing.md"), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 19, result.Fetched)
+	assert.Equal(t, 1, result.Cached)
+	assert.Len(t, result.Failed, 1)
+	assert.Contains(t, result.Failed, "notes/missing.md")
+	assert.LessOrEqual(t, backend.peak.Load(), int32(3))
+
+	stats := c.Stats()
+	assert.Equal(t, 20, stats.Entries)
+	assert.Equal(t, int64(0), stats.Hits, "warming should not count as hits")
+	assert.Equal(t, int64(1), stats.Misses)
+
+	// Every note is now served from disk
+	readsBefore := backend.readCount()
+	for _, path := range paths {
+		data, err := c.Read(ctx, path)
+		require.NoError(t, err)
+		assert.Equal(t, files[path], string(data))
+	}
+	assert.Equal(t, readsBefore, backend.readCount())
+	assert.Equal(t, int64(20), c.Stats().Hits)
+}
+
+func TestDiskCache_WarmCancelled(t *testing.T) {
+	backend := newMemoryBackend(map[string]string{"a.md": "a"})
+	c, _ := newTestCache(t, backend, types.DiskCacheConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := c.Warm(ctx, []string{"a.md"}, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, result.Fetched)
+}
+
+func TestDiskCache_StatsPersist(t *testing.T) {
+	ctx := context.Background()
+	config := types.DiskCacheConfig{Path: t.TempDir()}
+	backend := newMemoryBackend(map[string]string{"a.md": "a"})
+
+	c, err := NewDiskCache(backend, config, "test")
+	require.NoError(t, err)
+	_, err = c.Read(ctx, "a.md")
+	require.NoError(t, err)
+	_, err = c.Read(ctx, "a.md")
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	reopened, err := NewDiskCache(backend, config, "test")
+	require.NoError(t, err)
+	stats := reopened.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+
+	require.NoError(t, reopened.Clear())
+	assert.Equal(t, Stats{}, reopened.Stats())
+	require.NoError(t, reopened.Close())
+}
@@ -0,0 +1,483 @@
+// Package compress provides a storage layer that compresses files at rest.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Suffix is appended to the stored name of every compressed file
+const Suffix = ".gz"
+
+// GzipStorage wraps a storage backend and gzip-compresses files as they are
+// written. A file written at path is stored at path + Suffix; reads, lists
+// and stats translate back, so callers only ever see logical paths and
+// uncompressed content. Files stored without the suffix, such as notes
+// written before compression was enabled, are still read as they are and
+// are compressed the next time they are written.
+type GzipStorage struct {
+	backend     types.StorageBackend
+	logicalSize bool
+}
+
+// NewGzip wraps backend with gzip compression. statSize chooses the size
+// Stat reports for compressed files: types.CompressionSizeStored (or empty)
+// for the compressed size, or types.CompressionSizeLogical for the
+// uncompressed size.
+func NewGzip(backend types.StorageBackend, statSize string) (*GzipStorage, error) {
+	s := &GzipStorage{backend: backend}
+	switch strings.ToLower(statSize) {
+	case "", types.CompressionSizeStored:
+	case types.CompressionSizeLogical:
+		s.logicalSize = true
+	default:
+		return nil, fmt.Errorf("invalid compression stat size %q: must be stored or logical", statSize)
+	}
+	return s, nil
+}
+
+// Type returns the storage backend type
+func (s *GzipStorage) Type() types.StorageType {
+	return s.backend.Type()
+}
+
+// Read returns the uncompressed content of path
+func (s *GzipStorage) Read(ctx context.Context, path string) ([]byte, error) {
+	data, err := s.backend.Read(ctx, path+Suffix)
+	if err != nil {
+		// Not compressed, or not there at all
+		return s.backend.Read(ctx, path)
+	}
+	return gunzip(s.Type(), path, data)
+}
+
+// Write compresses data and stores it at path + Suffix, removing any
+// uncompressed copy at path
+func (s *GzipStorage) Write(ctx context.Context, path string, data []byte) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return types.NewStorageError(s.Type(), "write", path, err, false)
+	}
+	if err := zw.Close(); err != nil {
+		return types.NewStorageError(s.Type(), "write", path, err, false)
+	}
+
+	if err := s.backend.Write(ctx, path+Suffix, buf.Bytes()); err != nil {
+		return err
+	}
+	return s.removeIfExists(ctx, path)
+}
+
+// Delete removes path, compressed or not
+func (s *GzipStorage) Delete(ctx context.Context, path string) error {
+	compressed, err := s.backend.Exists(ctx, path+Suffix)
+	if err != nil {
+		return err
+	}
+	if !compressed {
+		return s.backend.Delete(ctx, path)
+	}
+
+	if err := s.backend.Delete(ctx, path+Suffix); err != nil {
+		return err
+	}
+	return s.removeIfExists(ctx, path)
+}
+
+// Exists reports whether path exists, compressed or not
+func (s *GzipStorage) Exists(ctx context.Context, path string) (bool, error) {
+	exists, err := s.backend.Exists(ctx, path+Suffix)
+	if err != nil || exists {
+		return exists, err
+	}
+	return s.backend.Exists(ctx, path)
+}
+
+// List returns the logical paths of the files matching prefix
+func (s *GzipStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	stored, err := s.backend.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(stored))
+	files := make([]string, 0, len(stored))
+	for _, name := range stored {
+		name = strings.TrimSuffix(name, Suffix)
+		if !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Walk calls fn for each file under prefix as the backend walks it, in the
+// order of their stored names, with compressed files under their
+// uncompressed names and metadata as Stat reports it. A file stored in
+// both forms is visited once, with the metadata of whichever the backend
+// reaches first.
+func (s *GzipStorage) Walk(ctx context.Context, prefix string, fn types.WalkFunc) error {
+	seen := make(map[string]bool)
+	return types.Walk(ctx, s.backend, prefix, func(name string, info *types.FileInfo) error {
+		path := strings.TrimSuffix(name, Suffix)
+		if seen[path] {
+			return nil
+		}
+		seen[path] = true
+		if path == name {
+			return fn(path, info)
+		}
+
+		logical := *info
+		logical.Path = path
+		logical.ContentType = types.ContentTypeByExtension(path)
+		if s.logicalSize {
+			data, err := s.backend.Read(ctx, name)
+			if err != nil {
+				return err
+			}
+			size, err := uncompressedSize(s.Type(), path, data)
+			if err != nil {
+				return err
+			}
+			logical.Size = size
+		}
+		return fn(path, &logical)
+	})
+}
+
+// Stat returns metadata about path. For a compressed file the size is the
+// stored, compressed size unless the layer was created to report logical
+// sizes, in which case the file is read to find its uncompressed size.
+func (s *GzipStorage) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
+	info, err := s.backend.Stat(ctx, path+Suffix)
+	if err != nil {
+		return s.backend.Stat(ctx, path)
+	}
+
+	logical := *info
+	logical.Path = path
+	logical.ContentType = types.ContentTypeByExtension(path)
+	if s.logicalSize {
+		data, err := s.backend.Read(ctx, path+Suffix)
+		if err != nil {
+			return nil, err
+		}
+		size, err := uncompressedSize(s.Type(), path, data)
+		if err != nil {
+			return nil, err
+		}
+		logical.Size = size
+	}
+	return &logical, nil
+}
+
+// ReadStream returns a reader over the uncompressed content of path
+func (s *GzipStorage) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	stored, err := s.backend.ReadStream(ctx, path+Suffix)
+	if err != nil {
+		return s.backend.ReadStream(ctx, path)
+	}
+
+	zr, err := gzip.NewReader(stored)
+	if err != nil {
+		_ = stored.Close()
+		return nil, types.NewStorageError(s.Type(), "read", path, err, false)
+	}
+	return &gzipReadCloser{Reader: zr, stored: stored}, nil
+}
+
+// WriteStream compresses reader's content while writing it to
+// path + Suffix, removing any uncompressed copy at path
+func (s *GzipStorage) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, reader)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	err := s.backend.WriteStream(ctx, path+Suffix, pr)
+	// Unblock the compressing goroutine if the backend stopped reading early
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return err
+	}
+	return s.removeIfExists(ctx, path)
+}
+
+// Copy copies src to dst, keeping the stored form of src
+func (s *GzipStorage) Copy(ctx context.Context, src, dst string) error {
+	return s.transfer(ctx, src, dst, s.backend.Copy)
+}
+
+// Move moves src to dst, keeping the stored form of src
+func (s *GzipStorage) Move(ctx context.Context, src, dst string) error {
+	return s.transfer(ctx, src, dst, s.backend.Move)
+}
+
+// ListVersions returns the versions of path in both stored forms, newest
+// first. Sizes are stored sizes. While a compressed copy exists, it is the
+// current one, as for Read.
+func (s *GzipStorage) ListVersions(ctx context.Context, path string) ([]types.FileVersion, error) {
+	versioned, ok := s.backend.(types.VersionedBackend)
+	if !ok {
+		return nil, types.ErrVersioningNotSupported
+	}
+
+	versions, err := versioned.ListVersions(ctx, path+Suffix)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := versioned.ListVersions(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	compressedCurrent := false
+	for _, v := range versions {
+		compressedCurrent = compressedCurrent || (v.Current && !v.Deleted)
+	}
+	for _, v := range plain {
+		v.Current = v.Current && !compressedCurrent
+		versions = append(versions, v)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Current != versions[j].Current {
+			return versions[i].Current
+		}
+		return versions[i].ModTime.After(versions[j].ModTime)
+	})
+	return versions, nil
+}
+
+// ReadVersion returns the uncompressed content of a version of path
+func (s *GzipStorage) ReadVersion(ctx context.Context, path, versionID string) ([]byte, error) {
+	versioned, ok := s.backend.(types.VersionedBackend)
+	if !ok {
+		return nil, types.ErrVersioningNotSupported
+	}
+
+	data, err := versioned.ReadVersion(ctx, path+Suffix, versionID)
+	if err != nil {
+		// A version of the uncompressed form, or not there at all
+		return versioned.ReadVersion(ctx, path, versionID)
+	}
+	return gunzip(s.Type(), path, data)
+}
+
+// RestoreVersion restores a version of path in the stored form it has and
+// removes the other form so it can't shadow the restored content
+func (s *GzipStorage) RestoreVersion(ctx context.Context, path, versionID string) error {
+	versioned, ok := s.backend.(types.VersionedBackend)
+	if !ok {
+		return types.ErrVersioningNotSupported
+	}
+
+	compressed, err := versioned.ListVersions(ctx, path+Suffix)
+	if err != nil {
+		return err
+	}
+	for _, v := range compressed {
+		if v.ID == versionID {
+			if err := versioned.RestoreVersion(ctx, path+Suffix, versionID); err != nil {
+				return err
+			}
+			return s.removeIfExists(ctx, path)
+		}
+	}
+
+	if err := versioned.RestoreVersion(ctx, path, versionID); err != nil {
+		return err
+	}
+	return s.removeIfExists(ctx, path+Suffix)
+}
+
+// BatchRead returns the uncompressed content of paths, reading the
+// compressed copies in one batch and then any plain ones in another
+func (s *GzipStorage) BatchRead(ctx context.Context, paths []string) (map[string][]byte, error) {
+	stored := make([]string, len(paths))
+	for i, path := range paths {
+		stored[i] = path + Suffix
+	}
+	compressed, err := types.BatchRead(ctx, s.backend, stored)
+	if !isBatchError(err) {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(paths))
+	errs := make(map[string]error)
+	var plain []string
+	for _, path := range paths {
+		data, ok := compressed[path+Suffix]
+		if !ok {
+			// Not compressed, or not there at all
+			plain = append(plain, path)
+			continue
+		}
+		content, err := gunzip(s.Type(), path, data)
+		if err != nil {
+			errs[path] = err
+			continue
+		}
+		files[path] = content
+	}
+
+	if len(plain) > 0 {
+		uncompressed, err := types.BatchRead(ctx, s.backend, plain)
+		if !isBatchError(err) {
+			return nil, err
+		}
+		for path, data := range uncompressed {
+			files[path] = data
+		}
+		for path, err := range types.PathErrors(err, plain) {
+			errs[path] = err
+		}
+	}
+
+	return files, types.BatchErrorFrom("read", errs)
+}
+
+// BatchDelete removes paths, compressed or not, deleting both stored forms
+// of every path in one batch
+func (s *GzipStorage) BatchDelete(ctx context.Context, paths []string) error {
+	stored := make([]string, 0, 2*len(paths))
+	for _, path := range paths {
+		stored = append(stored, path+Suffix, path)
+	}
+	err := types.BatchDelete(ctx, s.backend, stored)
+	if !isBatchError(err) {
+		return err
+	}
+
+	// Usually only one form exists, so failing to delete the other because
+	// it is missing is expected. A path fails when neither form was there,
+	// as with Delete, or when deleting a form that was there failed.
+	failed := types.PathErrors(err, stored)
+	errs := make(map[string]error)
+	for _, path := range paths {
+		compressedErr, plainErr := failed[path+Suffix], failed[path]
+		switch {
+		case compressedErr != nil && plainErr != nil && isNotExist(compressedErr) && isNotExist(plainErr):
+			errs[path] = plainErr
+		case compressedErr != nil && !isNotExist(compressedErr):
+			errs[path] = compressedErr
+		case plainErr != nil && !isNotExist(plainErr):
+			errs[path] = plainErr
+		}
+	}
+	return types.BatchErrorFrom("delete", errs)
+}
+
+// Health delegates to the backend
+func (s *GzipStorage) Health(ctx context.Context) error {
+	return s.backend.Health(ctx)
+}
+
+// Close closes the backend
+func (s *GzipStorage) Close() error {
+	return s.backend.Close()
+}
+
+// transfer copies or moves src to dst with op. The destination's other
+// stored form is removed afterwards so it can't shadow the new content.
+func (s *GzipStorage) transfer(ctx context.Context, src, dst string, op func(ctx context.Context, src, dst string) error) error {
+	compressed, err := s.backend.Exists(ctx, src+Suffix)
+	if err != nil {
+		return err
+	}
+
+	if compressed {
+		if err := op(ctx, src+Suffix, dst+Suffix); err != nil {
+			return err
+		}
+		return s.removeIfExists(ctx, dst)
+	}
+
+	if err := op(ctx, src, dst); err != nil {
+		return err
+	}
+	return s.removeIfExists(ctx, dst+Suffix)
+}
+
+// removeIfExists deletes the stored file name if there is one
+func (s *GzipStorage) removeIfExists(ctx context.Context, name string) error {
+	exists, err := s.backend.Exists(ctx, name)
+	if err != nil || !exists {
+		return err
+	}
+	return s.backend.Delete(ctx, name)
+}
+
+// gunzip decompresses the stored content of path
+func gunzip(backend types.StorageType, path string, data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, types.NewStorageError(backend, "read", path, err, false)
+	}
+	defer func() { _ = zr.Close() }()
+
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, types.NewStorageError(backend, "read", path, err, false)
+	}
+	return content, nil
+}
+
+// uncompressedSize returns the size of the decompressed content of path
+func uncompressedSize(backend types.StorageType, path string, data []byte) (int64, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0, types.NewStorageError(backend, "stat", path, err, false)
+	}
+	defer func() { _ = zr.Close() }()
+
+	size, err := io.Copy(io.Discard, zr)
+	if err != nil {
+		return 0, types.NewStorageError(backend, "stat", path, err, false)
+	}
+	return size, nil
+}
+
+// gzipReadCloser closes the decompressor and the stored stream under it
+type gzipReadCloser struct {
+	*gzip.Reader
+	stored io.ReadCloser
+}
+
+func (r *gzipReadCloser) Close() error {
+	zerr := r.Reader.Close()
+	if err := r.stored.Close(); err != nil {
+		return err
+	}
+	return zerr
+}
+
+// isBatchError reports whether err is nil or only reports the paths a
+// batch operation failed for, rather than the whole operation failing
+func isBatchError(err error) bool {
+	var batchErr *types.BatchError
+	return err == nil || errors.As(err, &batchErr)
+}
+
+// isNotExist reports whether err means the file was not there
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,278 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// newTestStorage returns gzip storage over a local vault and the vault root
+func newTestStorage(t *testing.T, statSize string) (*GzipStorage, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	backend, err := local.New(types.LocalStorageConfig{Path: root, CreateDirs: true})
+	require.NoError(t, err)
+
+	s, err := NewGzip(backend, statSize)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s, root
+}
+
+const testNote = "---\nid: 01ABC\ntitle: Compressed\n---\n\n# Compressed\n\nThis note is stored gzip-compressed. " +
+	"Repeated text compresses well, repeated text compresses well, repeated text compresses well.\n"
+
+func TestGzipStorage_RoundTrip(t *testing.T) {
+	s, root := newTestStorage(t, "")
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+
+	data, err := s.Read(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+
+	// Only the compressed form is on disk, and it is valid gzip
+	_, err = os.Stat(filepath.Join(root, "notes", "01ABC.md"))
+	assert.True(t, os.IsNotExist(err))
+	stored, err := os.ReadFile(filepath.Join(root, "notes", "01ABC.md.gz"))
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(stored))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(raw))
+	assert.Less(t, len(stored), len(testNote))
+
+	exists, err := s.Exists(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	files, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/01ABC.md"}, files)
+}
+
+func TestGzipStorage_Streams(t *testing.T) {
+	s, _ := newTestStorage(t, "")
+	ctx := context.Background()
+
+	content := strings.Repeat("streamed line\n", 10000)
+	require.NoError(t, s.WriteStream(ctx, "notes/big.md", strings.NewReader(content)))
+
+	rc, err := s.ReadStream(ctx, "notes/big.md")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, content, string(data))
+
+	data, err = s.Read(ctx, "notes/big.md")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestGzipStorage_Stat(t *testing.T) {
+	ctx := context.Background()
+
+	stored, root := newTestStorage(t, types.CompressionSizeStored)
+	require.NoError(t, stored.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+	onDisk, err := os.Stat(filepath.Join(root, "notes", "01ABC.md.gz"))
+	require.NoError(t, err)
+
+	info, err := stored.Stat(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, "notes/01ABC.md", info.Path)
+	assert.Equal(t, onDisk.Size(), info.Size)
+
+	logical, _ := newTestStorage(t, types.CompressionSizeLogical)
+	require.NoError(t, logical.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+
+	info, err = logical.Stat(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(testNote)), info.Size)
+}
+
+func TestGzipStorage_UncompressedFiles(t *testing.T) {
+	s, root := newTestStorage(t, types.CompressionSizeLogical)
+	ctx := context.Background()
+
+	// A note written before compression was enabled
+	plain := filepath.Join(root, "notes", "old.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(plain), 0o755))
+	require.NoError(t, os.WriteFile(plain, []byte("# Old\n"), 0o644))
+
+	data, err := s.Read(ctx, "notes/old.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Old\n", string(data))
+
+	info, err := s.Stat(ctx, "notes/old.md")
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), info.Size)
+
+	// Writing it again compresses it and removes the plain copy
+	require.NoError(t, s.Write(ctx, "notes/old.md", []byte("# Old, updated\n")))
+	_, err = os.Stat(plain)
+	assert.True(t, os.IsNotExist(err))
+	data, err = s.Read(ctx, "notes/old.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Old, updated\n", string(data))
+
+	files, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/old.md"}, files)
+
+	_, err = s.Read(ctx, "notes/missing.md")
+	assert.Error(t, err)
+}
+
+func TestGzipStorage_CopyMoveDelete(t *testing.T) {
+	s, root := newTestStorage(t, "")
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/a.md", []byte(testNote)))
+
+	require.NoError(t, s.Copy(ctx, "notes/a.md", "notes/b.md"))
+	data, err := s.Read(ctx, "notes/b.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+
+	require.NoError(t, s.Move(ctx, "notes/b.md", "notes/c.md"))
+	exists, err := s.Exists(ctx, "notes/b.md")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	data, err = s.Read(ctx, "notes/c.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+
+	require.NoError(t, s.Delete(ctx, "notes/c.md"))
+	exists, err = s.Exists(ctx, "notes/c.md")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	_, err = os.Stat(filepath.Join(root, "notes", "c.md.gz"))
+	assert.True(t, os.IsNotExist(err))
+
+	files, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/a.md"}, files)
+}
+
+func TestGzipStorage_Batch(t *testing.T) {
+	s, root := newTestStorage(t, "")
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/a.md", []byte(testNote)))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes", "plain.md"), []byte("# Plain\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes", "broken.md.gz"), []byte("not gzip"), 0o644))
+
+	files, err := s.BatchRead(ctx, []string{"notes/a.md", "notes/plain.md", "notes/broken.md", "notes/missing.md"})
+	assert.Equal(t, map[string][]byte{
+		"notes/a.md":     []byte(testNote),
+		"notes/plain.md": []byte("# Plain\n"),
+	}, files)
+
+	var batchErr *types.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []string{"notes/broken.md", "notes/missing.md"}, batchErr.Paths())
+	assert.ErrorIs(t, batchErr.Errors["notes/missing.md"], os.ErrNotExist)
+
+	// Both stored forms go, and only a path stored in neither fails
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes", "a.md"), []byte("stale"), 0o644))
+	err = s.BatchDelete(ctx, []string{"notes/a.md", "notes/plain.md", "notes/missing.md"})
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []string{"notes/missing.md"}, batchErr.Paths())
+
+	remaining, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/broken.md"}, remaining)
+}
+
+func TestGzipStorage_Walk(t *testing.T) {
+	s, root := newTestStorage(t, types.CompressionSizeLogical)
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/a.md", []byte(testNote)))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes", "plain.md"), []byte("# Plain\n"), 0o644))
+
+	infos := make(map[string]*types.FileInfo)
+	require.NoError(t, s.Walk(ctx, "notes/", func(path string, info *types.FileInfo) error {
+		infos[path] = info
+		return nil
+	}))
+	require.Len(t, infos, 2)
+	assert.Equal(t, "notes/a.md", infos["notes/a.md"].Path)
+	assert.Equal(t, int64(len(testNote)), infos["notes/a.md"].Size)
+	assert.Equal(t, types.MarkdownContentType, infos["notes/a.md"].ContentType)
+	assert.Equal(t, int64(len("# Plain\n")), infos["notes/plain.md"].Size)
+
+	// The callback's error ends the walk
+	calls := 0
+	err := s.Walk(ctx, "notes/", func(string, *types.FileInfo) error {
+		calls++
+		return os.ErrPermission
+	})
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.Equal(t, 1, calls)
+}
+
+func TestNewGzip_InvalidStatSize(t *testing.T) {
+	_, err := NewGzip(nil, "compressed")
+	assert.Error(t, err)
+}
+
+func TestGzipStorage_Versions(t *testing.T) {
+	ctx := context.Background()
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true, VersionRetention: 5})
+	require.NoError(t, err)
+	s, err := NewGzip(backend, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	// A note written before compression was enabled, then compressed
+	require.NoError(t, backend.Write(ctx, "notes/a.md", []byte("plain")))
+	require.NoError(t, s.Write(ctx, "notes/a.md", []byte("one")))
+	require.NoError(t, s.Write(ctx, "notes/a.md", []byte("two")))
+
+	versions, err := s.ListVersions(ctx, "notes/a.md")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.True(t, versions[0].Current)
+	for _, v := range versions[1:] {
+		assert.False(t, v.Current)
+	}
+
+	var contents []string
+	for _, v := range versions {
+		data, err := s.ReadVersion(ctx, "notes/a.md", v.ID)
+		require.NoError(t, err)
+		contents = append(contents, string(data))
+	}
+	assert.ElementsMatch(t, []string{"two", "one", "plain"}, contents)
+
+	// Restoring the uncompressed version removes the compressed copy that
+	// would shadow it
+	for _, v := range versions {
+		if data, _ := s.ReadVersion(ctx, "notes/a.md", v.ID); string(data) == "plain" {
+			require.NoError(t, s.RestoreVersion(ctx, "notes/a.md", v.ID))
+		}
+	}
+	data, err := s.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", string(data))
+
+	unversioned, _ := newTestStorage(t, "")
+	_, err = unversioned.ListVersions(ctx, "notes/a.md")
+	assert.ErrorIs(t, err, types.ErrVersioningNotSupported)
+}
@@ -0,0 +1,416 @@
+// Package encrypt provides a storage layer that encrypts files at rest.
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Magic starts every encrypted file, followed by a format version byte.
+// Files without it are plaintext.
+const Magic = "KBVENC"
+
+const (
+	// KeySize is the size of an AES-256 key
+	KeySize = 32
+
+	// formatVersion is the version byte written after Magic
+	formatVersion byte = 1
+
+	// headerSize is the size of Magic and the version byte
+	headerSize = len(Magic) + 1
+
+	nonceSize = 12
+	tagSize   = 16
+
+	// Overhead is how many bytes encryption adds to a file: the header,
+	// the nonce and the authentication tag
+	Overhead = headerSize + nonceSize + tagSize
+)
+
+// ParamsPath is where the salt and scrypt parameters used to derive the
+// key from a passphrase are kept, unencrypted, in the vault
+const ParamsPath = ".kbvault/encryption.json"
+
+// scrypt cost parameters for new vaults
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// checkText is encrypted into the key derivation parameters so that a
+// wrong passphrase is caught before any note is read
+const checkText = "kbvault"
+
+// ErrWrongKey is returned when an encrypted file can't be decrypted, either
+// because the key is not the one it was encrypted with or because the file
+// is damaged
+var ErrWrongKey = errors.New("wrong encryption key or corrupted data")
+
+// AESStorage wraps a storage backend and encrypts files with AES-256-GCM
+// as they are written. Paths are unchanged, so List and Walk return
+// logical paths as they are, while Stat and Walk report the stored size,
+// which is Overhead bytes larger than the content. Files stored without
+// the Magic header, such as notes written before encryption was enabled,
+// are read as they are and are encrypted the next time they are written.
+//
+// Each file is sealed on its own with a random nonce and isn't bound to its
+// path, so Copy and Move work on the stored bytes. Streams are buffered in
+// memory, since a file is only authenticated once all of it has been read.
+type AESStorage struct {
+	backend types.StorageBackend
+	aead    cipher.AEAD
+}
+
+// NewAES wraps backend with encryption under key, which must be KeySize
+// bytes long
+func NewAES(backend types.StorageBackend, key []byte) (*AESStorage, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESStorage{backend: backend, aead: aead}, nil
+}
+
+// Open wraps backend with encryption configured by config, loading the key
+// from config.KeyFile or deriving it from config.Passphrase
+func Open(ctx context.Context, backend types.StorageBackend, config types.EncryptionConfig) (*AESStorage, error) {
+	var key []byte
+	var err error
+	if config.KeyFile != "" {
+		key, err = LoadKeyFile(config.KeyFile)
+	} else {
+		key, err = PassphraseKey(ctx, backend, config.Passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewAES(backend, key)
+}
+
+// LoadKeyFile reads a key from path. The file holds the KeySize bytes of
+// the key, or their hex or base64 encoding; surrounding whitespace is
+// ignored for the encoded forms.
+func LoadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if len(data) == KeySize {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key file %s must hold a %d-byte key, raw or hex or base64 encoded", path, KeySize)
+}
+
+// kdfParams is the content of ParamsPath
+type kdfParams struct {
+	KDF   string `json:"kdf"`
+	Salt  []byte `json:"salt"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Check []byte `json:"check"`
+}
+
+// PassphraseKey derives a key from passphrase with scrypt, using the salt
+// stored at ParamsPath in backend. A vault without one gets a new random
+// salt. For an existing vault, a passphrase other than the one the salt
+// was created with fails with ErrWrongKey.
+func PassphraseKey(ctx context.Context, backend types.StorageBackend, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("encryption passphrase cannot be empty")
+	}
+
+	exists, err := backend.Exists(ctx, ParamsPath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return createParams(ctx, backend, passphrase)
+	}
+
+	data, err := backend.Read(ctx, ParamsPath)
+	if err != nil {
+		return nil, err
+	}
+	var params kdfParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ParamsPath, err)
+	}
+	if params.KDF != "scrypt" {
+		return nil, fmt.Errorf("invalid %s: unsupported key derivation %q", ParamsPath, params.KDF)
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ParamsPath, err)
+	}
+	s, err := NewAES(backend, key)
+	if err != nil {
+		return nil, err
+	}
+	if check, err := s.open(params.Check); err != nil || string(check) != checkText {
+		return nil, fmt.Errorf("cannot unlock vault with this passphrase: %w", ErrWrongKey)
+	}
+	return key, nil
+}
+
+// createParams derives a key from passphrase with a new salt and stores
+// the parameters at ParamsPath
+func createParams(ctx context.Context, backend types.StorageBackend, passphrase string) ([]byte, error) {
+	params := kdfParams{KDF: "scrypt", Salt: make([]byte, 16), N: scryptN, R: scryptR, P: scryptP}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, KeySize)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewAES(backend, key)
+	if err != nil {
+		return nil, err
+	}
+	if params.Check, err = s.seal([]byte(checkText)); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Write(ctx, ParamsPath, data); err != nil {
+		return nil, fmt.Errorf("failed to store encryption parameters: %w", err)
+	}
+	return key, nil
+}
+
+// IsEncrypted reports whether data is stored in the encrypted format
+func IsEncrypted(data []byte) bool {
+	return len(data) >= headerSize && bytes.HasPrefix(data, []byte(Magic))
+}
+
+// Type returns the storage backend type
+func (s *AESStorage) Type() types.StorageType {
+	return s.backend.Type()
+}
+
+// Read returns the decrypted content of path
+func (s *AESStorage) Read(ctx context.Context, path string) ([]byte, error) {
+	data, err := s.backend.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.decrypt("read", path, data)
+}
+
+// Write encrypts data and stores it at path
+func (s *AESStorage) Write(ctx context.Context, path string, data []byte) error {
+	sealed, err := s.seal(data)
+	if err != nil {
+		return types.NewStorageError(s.Type(), "write", path, err, false)
+	}
+	return s.backend.Write(ctx, path, sealed)
+}
+
+// Delete removes path
+func (s *AESStorage) Delete(ctx context.Context, path string) error {
+	return s.backend.Delete(ctx, path)
+}
+
+// Exists reports whether path exists
+func (s *AESStorage) Exists(ctx context.Context, path string) (bool, error) {
+	return s.backend.Exists(ctx, path)
+}
+
+// List returns the paths of the files matching prefix
+func (s *AESStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	return s.backend.List(ctx, prefix)
+}
+
+// Walk calls fn for each file under prefix as the backend walks it, with
+// stored sizes
+func (s *AESStorage) Walk(ctx context.Context, prefix string, fn types.WalkFunc) error {
+	return types.Walk(ctx, s.backend, prefix, fn)
+}
+
+// Stat returns metadata about path. The size is the stored size, which
+// for an encrypted file is the content size plus Overhead.
+func (s *AESStorage) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
+	return s.backend.Stat(ctx, path)
+}
+
+// ReadStream returns a reader over the decrypted content of path. The
+// whole file is read and authenticated before the reader is returned.
+func (s *AESStorage) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	data, err := s.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// WriteStream reads all of reader, then encrypts and stores it at path
+func (s *AESStorage) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return types.NewStorageError(s.Type(), "write", path, err, false)
+	}
+	return s.Write(ctx, path, data)
+}
+
+// Copy copies the stored bytes of src to dst
+func (s *AESStorage) Copy(ctx context.Context, src, dst string) error {
+	return s.backend.Copy(ctx, src, dst)
+}
+
+// Move moves the stored bytes of src to dst
+func (s *AESStorage) Move(ctx context.Context, src, dst string) error {
+	return s.backend.Move(ctx, src, dst)
+}
+
+// ListVersions returns the versions of path, newest first, with stored
+// sizes
+func (s *AESStorage) ListVersions(ctx context.Context, path string) ([]types.FileVersion, error) {
+	versioned, ok := s.backend.(types.VersionedBackend)
+	if !ok {
+		return nil, types.ErrVersioningNotSupported
+	}
+	return versioned.ListVersions(ctx, path)
+}
+
+// ReadVersion returns the decrypted content of a version of path
+func (s *AESStorage) ReadVersion(ctx context.Context, path, versionID string) ([]byte, error) {
+	versioned, ok := s.backend.(types.VersionedBackend)
+	if !ok {
+		return nil, types.ErrVersioningNotSupported
+	}
+	data, err := versioned.ReadVersion(ctx, path, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decrypt("read", path, data)
+}
+
+// RestoreVersion restores a version of path as it was stored
+func (s *AESStorage) RestoreVersion(ctx context.Context, path, versionID string) error {
+	versioned, ok := s.backend.(types.VersionedBackend)
+	if !ok {
+		return types.ErrVersioningNotSupported
+	}
+	return versioned.RestoreVersion(ctx, path, versionID)
+}
+
+// BatchRead returns the decrypted content of paths, read in one batch
+func (s *AESStorage) BatchRead(ctx context.Context, paths []string) (map[string][]byte, error) {
+	stored, err := types.BatchRead(ctx, s.backend, paths)
+	var batchErr *types.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		return nil, err
+	}
+
+	errs := make(map[string]error)
+	for path, err := range types.PathErrors(err, paths) {
+		errs[path] = err
+	}
+	files := make(map[string][]byte, len(stored))
+	for path, data := range stored {
+		content, err := s.decrypt("read", path, data)
+		if err != nil {
+			errs[path] = err
+			continue
+		}
+		files[path] = content
+	}
+	return files, types.BatchErrorFrom("read", errs)
+}
+
+// BatchDelete removes paths in one batch
+func (s *AESStorage) BatchDelete(ctx context.Context, paths []string) error {
+	return types.BatchDelete(ctx, s.backend, paths)
+}
+
+// Health delegates to the backend
+func (s *AESStorage) Health(ctx context.Context) error {
+	return s.backend.Health(ctx)
+}
+
+// Close closes the backend
+func (s *AESStorage) Close() error {
+	return s.backend.Close()
+}
+
+// decrypt returns the content of the stored data of path, which is
+// returned as it is when it isn't encrypted
+func (s *AESStorage) decrypt(operation, path string, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	content, err := s.open(data)
+	if err != nil {
+		return nil, types.NewStorageError(s.Type(), operation, path, err, false)
+	}
+	return content, nil
+}
+
+// seal encrypts plaintext into the stored format: the header, a random
+// nonce, and the ciphertext with its tag, authenticating the header too
+func (s *AESStorage) seal(plaintext []byte) ([]byte, error) {
+	out := make([]byte, headerSize+nonceSize, headerSize+nonceSize+len(plaintext)+tagSize)
+	copy(out, Magic)
+	out[len(Magic)] = formatVersion
+	nonce := out[headerSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(out, nonce, plaintext, out[:headerSize]), nil
+}
+
+// open decrypts data in the stored format
+func (s *AESStorage) open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrWrongKey
+	}
+	if v := data[len(Magic)]; v != formatVersion {
+		return nil, fmt.Errorf("unsupported encryption format version %d", v)
+	}
+	if len(data) < Overhead {
+		return nil, ErrWrongKey
+	}
+	content, err := s.aead.Open(nil, data[headerSize:headerSize+nonceSize], data[headerSize+nonceSize:], data[:headerSize])
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return content, nil
+}
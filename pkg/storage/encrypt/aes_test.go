@@ -0,0 +1,360 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/compress"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// newBackend returns a local vault and its root
+func newBackend(t *testing.T) (types.StorageBackend, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	backend, err := local.New(types.LocalStorageConfig{Path: root, CreateDirs: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+	return backend, root
+}
+
+// newKey returns a random key
+func newKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+// newTestStorage returns encrypted storage over a local vault and the
+// vault root
+func newTestStorage(t *testing.T) (*AESStorage, string) {
+	t.Helper()
+
+	backend, root := newBackend(t)
+	s, err := NewAES(backend, newKey(t))
+	require.NoError(t, err)
+	return s, root
+}
+
+const testNote = "---\nid: 01ABC\ntitle: Secret\n---\n\n# Secret\n\nThis note is stored encrypted.\n"
+
+func TestAESStorage_RoundTrip(t *testing.T) {
+	s, root := newTestStorage(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+
+	data, err := s.Read(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+
+	// The file on disk is at the same path but holds no plaintext
+	stored, err := os.ReadFile(filepath.Join(root, "notes", "01ABC.md"))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(stored))
+	assert.True(t, bytes.HasPrefix(stored, []byte(Magic)))
+	assert.NotContains(t, string(stored), "Secret")
+	assert.Len(t, stored, len(testNote)+Overhead)
+
+	// Writing the same content again uses a new nonce
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+	again, err := os.ReadFile(filepath.Join(root, "notes", "01ABC.md"))
+	require.NoError(t, err)
+	assert.NotEqual(t, stored, again)
+
+	files, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/01ABC.md"}, files)
+
+	exists, err := s.Exists(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Stat reports the stored, ciphertext size
+	info, err := s.Stat(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(testNote)+Overhead), info.Size)
+
+	require.NoError(t, s.Write(ctx, "notes/empty.md", nil))
+	data, err = s.Read(ctx, "notes/empty.md")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestAESStorage_Streams(t *testing.T) {
+	s, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	content := strings.Repeat("streamed line\n", 10000)
+	require.NoError(t, s.WriteStream(ctx, "notes/big.md", strings.NewReader(content)))
+
+	rc, err := s.ReadStream(ctx, "notes/big.md")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, content, string(data))
+}
+
+func TestAESStorage_PlaintextFiles(t *testing.T) {
+	backend, root := newBackend(t)
+	s, err := NewAES(backend, newKey(t))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Written before encryption was enabled
+	require.NoError(t, backend.Write(ctx, "notes/old.md", []byte(testNote)))
+	assert.False(t, IsEncrypted([]byte(testNote)))
+
+	data, err := s.Read(ctx, "notes/old.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+
+	// Saving it again encrypts it
+	require.NoError(t, s.Write(ctx, "notes/old.md", data))
+	stored, err := os.ReadFile(filepath.Join(root, "notes", "old.md"))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(stored))
+}
+
+func TestAESStorage_WrongKey(t *testing.T) {
+	backend, root := newBackend(t)
+	ctx := context.Background()
+
+	s, err := NewAES(backend, newKey(t))
+	require.NoError(t, err)
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+
+	other, err := NewAES(backend, newKey(t))
+	require.NoError(t, err)
+	_, err = other.Read(ctx, "notes/01ABC.md")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrWrongKey)
+	assert.Contains(t, err.Error(), "notes/01ABC.md")
+
+	_, err = other.ReadStream(ctx, "notes/01ABC.md")
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	// A damaged file fails the same way
+	path := filepath.Join(root, "notes", "01ABC.md")
+	stored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	stored[len(stored)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, stored, 0o600))
+	_, err = s.Read(ctx, "notes/01ABC.md")
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	require.NoError(t, os.WriteFile(path, []byte(Magic+"\x01short"), 0o600))
+	_, err = s.Read(ctx, "notes/01ABC.md")
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	require.NoError(t, os.WriteFile(path, append([]byte(Magic+"\x09"), make([]byte, Overhead)...), 0o600))
+	_, err = s.Read(ctx, "notes/01ABC.md")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported encryption format version 9")
+}
+
+func TestPassphraseKey(t *testing.T) {
+	backend, root := newBackend(t)
+	ctx := context.Background()
+	config := types.EncryptionConfig{Enabled: true, Passphrase: "correct horse"}
+
+	s, err := Open(ctx, backend, config)
+	require.NoError(t, err)
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+
+	// The salt is kept in the vault, unencrypted
+	params, err := os.ReadFile(filepath.Join(root, ".kbvault", "encryption.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(params), `"kdf": "scrypt"`)
+
+	// The same passphrase derives the same key
+	reopened, err := Open(ctx, backend, config)
+	require.NoError(t, err)
+	data, err := reopened.Read(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+
+	// A wrong one is refused before any note is read
+	_, err = Open(ctx, backend, types.EncryptionConfig{Enabled: true, Passphrase: "battery staple"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	_, err = PassphraseKey(ctx, backend, "")
+	assert.Error(t, err)
+
+	// Another vault gets its own salt, so the same passphrase gives a
+	// different key
+	otherBackend, _ := newBackend(t)
+	other, err := Open(ctx, otherBackend, config)
+	require.NoError(t, err)
+	require.NoError(t, other.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+	stored, err := otherBackend.Read(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	require.NoError(t, backend.Write(ctx, "notes/copied.md", stored))
+	_, err = s.Read(ctx, "notes/copied.md")
+	assert.ErrorIs(t, err, ErrWrongKey)
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	key := newKey(t)
+
+	for name, content := range map[string][]byte{
+		"raw":    key,
+		"hex":    []byte(hex.EncodeToString(key) + "\n"),
+		"base64": []byte(base64.StdEncoding.EncodeToString(key) + "\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, content, 0o600))
+
+			loaded, err := LoadKeyFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, key, loaded)
+		})
+	}
+
+	short := filepath.Join(dir, "short")
+	require.NoError(t, os.WriteFile(short, []byte("too short"), 0o600))
+	_, err := LoadKeyFile(short)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "32-byte key")
+
+	_, err = LoadKeyFile(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+
+	_, err = NewAES(nil, key[:16])
+	assert.Error(t, err)
+}
+
+func TestOpen_KeyFile(t *testing.T) {
+	backend, _ := newBackend(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString(newKey(t))), 0o600))
+
+	s, err := Open(ctx, backend, types.EncryptionConfig{Enabled: true, KeyFile: path})
+	require.NoError(t, err)
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte(testNote)))
+
+	// Key files need no parameters in the vault
+	exists, err := backend.Exists(ctx, ParamsPath)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	reopened, err := Open(ctx, backend, types.EncryptionConfig{Enabled: true, KeyFile: path})
+	require.NoError(t, err)
+	data, err := reopened.Read(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+}
+
+func TestAESStorage_CopyMoveDelete(t *testing.T) {
+	s, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/a.md", []byte(testNote)))
+	require.NoError(t, s.Copy(ctx, "notes/a.md", "notes/b.md"))
+	require.NoError(t, s.Move(ctx, "notes/b.md", "notes/c.md"))
+
+	data, err := s.Read(ctx, "notes/c.md")
+	require.NoError(t, err)
+	assert.Equal(t, testNote, string(data))
+
+	require.NoError(t, s.Delete(ctx, "notes/a.md"))
+	files, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/c.md"}, files)
+}
+
+func TestAESStorage_Batch(t *testing.T) {
+	backend, _ := newBackend(t)
+	s, err := NewAES(backend, newKey(t))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/a.md", []byte("alpha")))
+	require.NoError(t, backend.Write(ctx, "notes/plain.md", []byte("plain")))
+
+	other, err := NewAES(backend, newKey(t))
+	require.NoError(t, err)
+	require.NoError(t, other.Write(ctx, "notes/other.md", []byte("other key")))
+
+	files, err := s.BatchRead(ctx, []string{"notes/a.md", "notes/plain.md", "notes/other.md", "notes/missing.md"})
+	var batchErr *types.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, map[string][]byte{"notes/a.md": []byte("alpha"), "notes/plain.md": []byte("plain")}, files)
+	assert.ErrorIs(t, batchErr.Errors["notes/other.md"], ErrWrongKey)
+	assert.Contains(t, batchErr.Errors, "notes/missing.md")
+
+	require.NoError(t, s.BatchDelete(ctx, []string{"notes/a.md", "notes/plain.md"}))
+	exists, err := s.Exists(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestAESStorage_UnderCompression(t *testing.T) {
+	s, root := newTestStorage(t)
+	ctx := context.Background()
+
+	compressed, err := compress.NewGzip(s, types.CompressionSizeStored)
+	require.NoError(t, err)
+
+	content := strings.Repeat("compressible text ", 500)
+	require.NoError(t, compressed.Write(ctx, "notes/01ABC.md", []byte(content)))
+
+	// Compressed first, then encrypted
+	stored, err := os.ReadFile(filepath.Join(root, "notes", "01ABC.md"+compress.Suffix))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(stored))
+	assert.Less(t, len(stored), len(content))
+
+	data, err := compressed.Read(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestAESStorage_Versions(t *testing.T) {
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true, VersionRetention: 5})
+	require.NoError(t, err)
+	s, err := NewAES(backend, newKey(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	ctx := context.Background()
+
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte("first")))
+	require.NoError(t, s.Write(ctx, "notes/01ABC.md", []byte("second")))
+
+	versions, err := s.ListVersions(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	old := versions[1]
+	data, err := s.ReadVersion(ctx, "notes/01ABC.md", old.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	require.NoError(t, s.RestoreVersion(ctx, "notes/01ABC.md", old.ID))
+	data, err = s.Read(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+}
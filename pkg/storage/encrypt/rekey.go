@@ -0,0 +1,176 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/ulid"
+)
+
+// RekeyStagingDir holds the files Rekey has re-encrypted until they
+// replace the originals. Each run stages under its own subdirectory.
+const RekeyStagingDir = ".kbvault/staging"
+
+// RekeyOptions configures Rekey
+type RekeyOptions struct {
+	// DryRun checks and counts the files without writing anything
+	DryRun bool
+}
+
+// RekeyResult reports what Rekey did, or would do in a dry run
+type RekeyResult struct {
+	// Rekeyed are the files re-encrypted under the new key
+	Rekeyed []string
+
+	// Current are the files already encrypted under the new key, e.g. by
+	// an interrupted run, and left as they are
+	Current []string
+
+	// Plaintext are the files that aren't encrypted and were left as they
+	// are
+	Plaintext []string
+}
+
+// Rekey re-encrypts the files at paths from oldKey to newKey. backend is
+// the storage below the encryption layer, so paths are stored paths and
+// content is read and written as stored.
+//
+// Every file is decrypted with the old key and staged under
+// RekeyStagingDir before any original is replaced, so a wrong key or a
+// damaged file stops Rekey with the vault unchanged. The first encrypted
+// file is checked before anything is written. Once all files are staged
+// they are moved over the originals; should that be interrupted, running
+// Rekey again with the same keys finishes the job, since files already
+// under the new key are skipped.
+func Rekey(ctx context.Context, backend types.StorageBackend, paths []string, oldKey, newKey []byte, opts RekeyOptions) (*RekeyResult, error) {
+	if bytes.Equal(oldKey, newKey) {
+		return nil, errors.New("the old and new keys are the same")
+	}
+	oldStorage, err := NewAES(backend, oldKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid old key: %w", err)
+	}
+	newStorage, err := NewAES(backend, newKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid new key: %w", err)
+	}
+
+	r := &rekeyer{
+		backend: backend,
+		old:     oldStorage,
+		new:     newStorage,
+		staging: path.Join(RekeyStagingDir, "rekey-"+ulid.New()),
+		dryRun:  opts.DryRun,
+	}
+	if err := r.stage(ctx, paths); err != nil {
+		return nil, r.cleanup(ctx, err)
+	}
+	if opts.DryRun {
+		return &r.result, nil
+	}
+	if err := r.promote(ctx); err != nil {
+		return nil, r.cleanup(ctx, fmt.Errorf("rekey interrupted after replacing %d of %d files, run it again with the same keys to finish: %w",
+			r.promoted, len(r.result.Rekeyed), err))
+	}
+	return &r.result, nil
+}
+
+// rekeyer tracks the progress of a Rekey run
+type rekeyer struct {
+	backend  types.StorageBackend
+	old, new *AESStorage
+	staging  string
+	dryRun   bool
+
+	result RekeyResult
+
+	// staged are the staging paths still to be moved or removed
+	staged   []string
+	promoted int
+}
+
+// stage re-encrypts every encrypted file in paths to its staging path
+func (r *rekeyer) stage(ctx context.Context, paths []string) error {
+	for _, filePath := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := r.backend.Read(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+		if !IsEncrypted(data) {
+			r.result.Plaintext = append(r.result.Plaintext, filePath)
+			continue
+		}
+
+		content, err := r.old.open(data)
+		if err != nil {
+			if _, newErr := r.new.open(data); newErr == nil {
+				r.result.Current = append(r.result.Current, filePath)
+				continue
+			}
+			if len(r.result.Rekeyed)+len(r.result.Current) == 0 {
+				return fmt.Errorf("the old key cannot decrypt %s, refusing to rekey: %w", filePath, err)
+			}
+			return fmt.Errorf("cannot decrypt %s with the old key: %w", filePath, err)
+		}
+
+		r.result.Rekeyed = append(r.result.Rekeyed, filePath)
+		if r.dryRun {
+			continue
+		}
+		sealed, err := r.new.seal(content)
+		if err != nil {
+			return err
+		}
+		stagingPath := path.Join(r.staging, filePath)
+		if err := r.backend.Write(ctx, stagingPath, sealed); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", filePath, err)
+		}
+		r.staged = append(r.staged, stagingPath)
+	}
+	return nil
+}
+
+// promote moves every staged file over its original
+func (r *rekeyer) promote(ctx context.Context) error {
+	for i, filePath := range r.result.Rekeyed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.backend.Move(ctx, r.staged[i], filePath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", filePath, err)
+		}
+		r.staged[i] = ""
+		r.promoted++
+	}
+	return nil
+}
+
+// cleanup removes the staged files that weren't moved and returns err,
+// joined with any failure to remove them. It runs even when ctx is
+// cancelled.
+func (r *rekeyer) cleanup(ctx context.Context, err error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	var failed []error
+	for _, stagingPath := range r.staged {
+		if stagingPath == "" {
+			continue
+		}
+		if err := r.backend.Delete(ctx, stagingPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Join(err, fmt.Errorf("cleanup incomplete: %w", errors.Join(failed...)))
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package encrypt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// newRekeyVault returns a local vault with three notes encrypted under
+// oldKey and one plaintext note, and the paths of all four
+func newRekeyVault(t *testing.T, oldKey []byte) (types.StorageBackend, string, []string) {
+	t.Helper()
+
+	backend, root := newBackend(t)
+	s, err := NewAES(backend, oldKey)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	paths := []string{"notes/a.md", "notes/b.md", "notes/c.md", "notes/plain.md"}
+	for _, p := range paths[:3] {
+		require.NoError(t, s.Write(ctx, p, []byte("content of "+p)))
+	}
+	require.NoError(t, backend.Write(ctx, "notes/plain.md", []byte("plain")))
+	return backend, root, paths
+}
+
+// storedFiles returns the stored content of paths
+func storedFiles(t *testing.T, backend types.StorageBackend, paths []string) map[string][]byte {
+	t.Helper()
+
+	files := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		data, err := backend.Read(context.Background(), p)
+		require.NoError(t, err)
+		files[p] = data
+	}
+	return files
+}
+
+func TestRekey(t *testing.T) {
+	oldKey, newKey := newKey(t), newKey(t)
+	backend, root, paths := newRekeyVault(t, oldKey)
+	ctx := context.Background()
+	before := storedFiles(t, backend, paths)
+
+	// A dry run counts without writing
+	result, err := Rekey(ctx, backend, paths, oldKey, newKey, RekeyOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, paths[:3], result.Rekeyed)
+	assert.Equal(t, []string{"notes/plain.md"}, result.Plaintext)
+	assert.Equal(t, before, storedFiles(t, backend, paths))
+
+	result, err = Rekey(ctx, backend, paths, oldKey, newKey, RekeyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, paths[:3], result.Rekeyed)
+	assert.Empty(t, result.Current)
+
+	// Notes decrypt with the new key only
+	oldStorage, err := NewAES(backend, oldKey)
+	require.NoError(t, err)
+	newStorage, err := NewAES(backend, newKey)
+	require.NoError(t, err)
+	for _, p := range paths[:3] {
+		data, err := newStorage.Read(ctx, p)
+		require.NoError(t, err)
+		assert.Equal(t, "content of "+p, string(data))
+
+		_, err = oldStorage.Read(ctx, p)
+		assert.ErrorIs(t, err, ErrWrongKey)
+	}
+
+	data, err := backend.Read(ctx, "notes/plain.md")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", string(data))
+
+	// Nothing is left in staging
+	entries, err := os.ReadDir(filepath.Join(root, ".kbvault", "staging"))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		files, err := os.ReadDir(filepath.Join(root, ".kbvault", "staging", entry.Name(), "notes"))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	}
+}
+
+func TestRekey_WrongOldKey(t *testing.T) {
+	oldKey := newKey(t)
+	backend, root, paths := newRekeyVault(t, oldKey)
+	ctx := context.Background()
+	before := storedFiles(t, backend, paths)
+
+	for _, dryRun := range []bool{true, false} {
+		_, err := Rekey(ctx, backend, paths, newKey(t), newKey(t), RekeyOptions{DryRun: dryRun})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrWrongKey)
+		assert.Contains(t, err.Error(), "refusing to rekey")
+	}
+
+	assert.Equal(t, before, storedFiles(t, backend, paths))
+	_, err := os.Stat(filepath.Join(root, ".kbvault", "staging"))
+	assert.True(t, os.IsNotExist(err), "nothing should be staged")
+
+	_, err = Rekey(ctx, backend, paths, oldKey, oldKey, RekeyOptions{})
+	assert.Error(t, err)
+}
+
+func TestRekey_UnreadableFile(t *testing.T) {
+	oldKey, newKey := newKey(t), newKey(t)
+	backend, root, paths := newRekeyVault(t, oldKey)
+	ctx := context.Background()
+
+	// A file under a third key stops the rekey after the others are staged
+	stray, err := NewAES(backend, make([]byte, KeySize))
+	require.NoError(t, err)
+	require.NoError(t, stray.Write(ctx, "notes/d.md", []byte("stray")))
+	paths = append(paths, "notes/d.md")
+	before := storedFiles(t, backend, paths)
+
+	_, err = Rekey(ctx, backend, paths, oldKey, newKey, RekeyOptions{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrWrongKey)
+	assert.Contains(t, err.Error(), "notes/d.md")
+
+	// The vault is unchanged and the staged files are removed
+	assert.Equal(t, before, storedFiles(t, backend, paths))
+	entries, err := os.ReadDir(filepath.Join(root, ".kbvault", "staging"))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		files, err := os.ReadDir(filepath.Join(root, ".kbvault", "staging", entry.Name(), "notes"))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	}
+}
+
+func TestRekey_Resume(t *testing.T) {
+	oldKey, newKey := newKey(t), newKey(t)
+	backend, _, paths := newRekeyVault(t, oldKey)
+	ctx := context.Background()
+
+	// As if an earlier run was interrupted after replacing the first notes
+	_, err := Rekey(ctx, backend, paths[:2], oldKey, newKey, RekeyOptions{})
+	require.NoError(t, err)
+
+	result, err := Rekey(ctx, backend, paths, oldKey, newKey, RekeyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/c.md"}, result.Rekeyed)
+	assert.Equal(t, []string{"notes/a.md", "notes/b.md"}, result.Current)
+
+	newStorage, err := NewAES(backend, newKey)
+	require.NoError(t, err)
+	for _, p := range paths[:3] {
+		_, err := newStorage.Read(ctx, p)
+		assert.NoError(t, err, p)
+	}
+}
@@ -1,8 +1,15 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/azblob"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/cache"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/compress"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/encrypt"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
@@ -16,23 +23,159 @@ func NewFactory() *Factory {
 	return &Factory{}
 }
 
+// layer is a wrapper applied around a storage backend
+type layer struct {
+	// label names the layer in DescribeStack
+	label string
+	// name is used in errors
+	name string
+	wrap func(types.StorageBackend) (types.StorageBackend, error)
+}
+
 // CreateStorage creates a storage backend based on the provided configuration
+// and wraps it in the layers returned by stackLayers, innermost first
 func (f *Factory) CreateStorage(config types.StorageConfig) (types.StorageBackend, error) {
 	// Validate configuration first
 	if err := f.ValidateConfig(config); err != nil {
 		return nil, err
 	}
 
+	backend, err := newBackend(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range stackLayers(config) {
+		wrapped, err := l.wrap(backend)
+		if err != nil {
+			_ = backend.Close()
+			return nil, fmt.Errorf("failed to initialize %s: %w", l.name, err)
+		}
+		backend = wrapped
+	}
+	return backend, nil
+}
+
+// newBackend creates the unwrapped backend for config
+func newBackend(config types.StorageConfig) (types.StorageBackend, error) {
 	switch config.Type {
 	case types.StorageTypeLocal:
 		return local.New(config.Local)
 	case types.StorageTypeS3:
 		return s3.NewStorage(config.S3)
+	case types.StorageTypeAzure:
+		return azblob.NewStorage(config.Azure)
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s", config.Type)
 	}
 }
 
+// stackLayers returns the wrappers CreateStorage applies for config,
+// innermost first. Files are encrypted next to the backend when encryption
+// is enabled and compressed above that when compression is set to gzip,
+// since ciphertext doesn't compress. The disk cache, when CacheEnabled
+// reports true, sits on top and holds uncompressed content, except with
+// encryption, where it goes below so that decrypted content never reaches
+// the disk.
+func stackLayers(config types.StorageConfig) []layer {
+	var layers []layer
+	cached := CacheEnabled(config)
+	encrypted := config.Encryption.Enabled
+	if cached && encrypted {
+		layers = append(layers, cacheLayer(config))
+	}
+	if encrypted {
+		layers = append(layers, layer{
+			label: "encrypt(aes-256-gcm)",
+			name:  "encryption",
+			wrap: func(backend types.StorageBackend) (types.StorageBackend, error) {
+				return encrypt.Open(context.Background(), backend, config.Encryption)
+			},
+		})
+	}
+	if strings.EqualFold(config.Compression, types.CompressionGzip) {
+		layers = append(layers, layer{
+			label: "compress(gzip)",
+			name:  "compression",
+			wrap: func(backend types.StorageBackend) (types.StorageBackend, error) {
+				return compress.NewGzip(backend, config.CompressionStatSize)
+			},
+		})
+	}
+	if cached && !encrypted {
+		layers = append(layers, cacheLayer(config))
+	}
+	return layers
+}
+
+// cacheLayer wraps a backend in the disk cache for config
+func cacheLayer(config types.StorageConfig) layer {
+	return layer{
+		label: "cache(disk)",
+		name:  "disk cache",
+		wrap: func(backend types.StorageBackend) (types.StorageBackend, error) {
+			return cache.NewDiskCache(backend, config.Cache.Disk, CacheNamespace(config))
+		},
+	}
+}
+
+// DescribeStack returns the layers CreateStorage builds for config,
+// outermost first and ending with the backend, e.g.
+// ["cache(disk)", "s3(retry x3)"]. Remote backends note the number of
+// attempts their client makes for a failed request.
+func DescribeStack(config types.StorageConfig) []string {
+	layers := stackLayers(config)
+	stack := make([]string, 0, len(layers)+1)
+	for i := len(layers) - 1; i >= 0; i-- {
+		stack = append(stack, layers[i].label)
+	}
+	return append(stack, backendLabel(config))
+}
+
+// backendLabel describes the backend at the bottom of the stack
+func backendLabel(config types.StorageConfig) string {
+	attempts := 0
+	switch config.Type {
+	case types.StorageTypeS3:
+		attempts = config.S3.RetryAttempts
+	case types.StorageTypeAzure:
+		attempts = config.Azure.RetryAttempts
+	}
+	if attempts > 0 {
+		return fmt.Sprintf("%s(retry x%d)", config.Type, attempts)
+	}
+	return string(config.Type)
+}
+
+// CacheEnabled reports whether backends for config are wrapped in a disk
+// cache: the disk cache must be enabled, and either caching is switched on
+// or the backend is remote and auto_enable_for_remote is set
+func CacheEnabled(config types.StorageConfig) bool {
+	if !config.Cache.Disk.Enabled {
+		return false
+	}
+	return config.Cache.Enabled || (config.Cache.AutoEnable && config.Type != types.StorageTypeLocal)
+}
+
+// CacheNamespace identifies the vault a storage configuration points at, so
+// that vaults sharing a cache path keep separate entries
+func CacheNamespace(config types.StorageConfig) string {
+	switch config.Type {
+	case types.StorageTypeLocal:
+		path := config.Local.Path
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		return "local://" + path
+	case types.StorageTypeS3:
+		return "s3://" + strings.Join([]string{config.S3.Endpoint, config.S3.Bucket, config.S3.Prefix}, "/")
+	case types.StorageTypeAzure:
+		return "azblob://" + strings.Join([]string{config.Azure.Endpoint, config.Azure.AccountName, config.Azure.ContainerName, config.Azure.Prefix}, "/")
+	default:
+		return string(config.Type)
+	}
+}
+
 // ValidateConfig validates a storage configuration without creating the backend
 func (f *Factory) ValidateConfig(config types.StorageConfig) error {
 	switch config.Type {
@@ -40,6 +183,8 @@ func (f *Factory) ValidateConfig(config types.StorageConfig) error {
 		return validateLocalConfig(config.Local)
 	case types.StorageTypeS3:
 		return validateS3Config(config.S3)
+	case types.StorageTypeAzure:
+		return validateAzureConfig(config.Azure)
 	default:
 		return fmt.Errorf("unsupported storage type: %s", config.Type)
 	}
@@ -50,6 +195,7 @@ func (f *Factory) GetSupportedTypes() []types.StorageType {
 	return []types.StorageType{
 		types.StorageTypeLocal,
 		types.StorageTypeS3,
+		types.StorageTypeAzure,
 	}
 }
 
@@ -72,6 +218,17 @@ func validateS3Config(config types.S3StorageConfig) error {
 	return nil
 }
 
+// validateAzureConfig validates Azure Blob storage configuration
+func validateAzureConfig(config types.AzureBlobConfig) error {
+	if config.ContainerName == "" {
+		return fmt.Errorf("azure container name cannot be empty")
+	}
+	if config.ConnectionString == "" && (config.AccountName == "" || config.AccountKey == "") {
+		return fmt.Errorf("azure storage requires a connection string or account name and key")
+	}
+	return nil
+}
+
 // DefaultFactory is the default storage factory instance
 var DefaultFactory = NewFactory()
 
@@ -1,11 +1,15 @@
 package storage
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/cache"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/compress"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/encrypt"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -171,6 +175,30 @@ func TestFactory_ValidateConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "S3 bucket name cannot be empty",
 		},
+		{
+			name: "valid Azure config",
+			config: types.StorageConfig{
+				Type: types.StorageTypeAzure,
+				Azure: types.AzureBlobConfig{
+					AccountName:   "devstoreaccount1",
+					AccountKey:    "a2V5",
+					ContainerName: "vault",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid Azure config",
+			config: types.StorageConfig{
+				Type: types.StorageTypeAzure,
+				Azure: types.AzureBlobConfig{
+					ContainerName: "vault",
+					// Credentials missing
+				},
+			},
+			wantErr: true,
+			errMsg:  "connection string or account name and key",
+		},
 	}
 
 	for _, tt := range tests {
@@ -194,7 +222,8 @@ func TestFactory_GetSupportedTypes(t *testing.T) {
 
 	assert.Contains(t, supportedTypes, types.StorageTypeLocal)
 	assert.Contains(t, supportedTypes, types.StorageTypeS3)
-	assert.Len(t, supportedTypes, 2)
+	assert.Contains(t, supportedTypes, types.StorageTypeAzure)
+	assert.Len(t, supportedTypes, 3)
 }
 
 func TestDefaultFactory(t *testing.T) {
@@ -229,6 +258,176 @@ func TestPackageFunctions(t *testing.T) {
 	assert.Contains(t, supportedTypes, types.StorageTypeS3)
 }
 
+func TestCacheEnabled(t *testing.T) {
+	tests := []struct {
+		name        string
+		storageType types.StorageType
+		cache       types.CacheConfig
+		want        bool
+	}{
+		{"auto enabled for S3", types.StorageTypeS3, types.CacheConfig{AutoEnable: true, Disk: types.DiskCacheConfig{Enabled: true}}, true},
+		{"auto enabled for Azure", types.StorageTypeAzure, types.CacheConfig{AutoEnable: true, Disk: types.DiskCacheConfig{Enabled: true}}, true},
+		{"not auto enabled for local", types.StorageTypeLocal, types.CacheConfig{AutoEnable: true, Disk: types.DiskCacheConfig{Enabled: true}}, false},
+		{"explicitly enabled for local", types.StorageTypeLocal, types.CacheConfig{Enabled: true, Disk: types.DiskCacheConfig{Enabled: true}}, true},
+		{"auto enable off", types.StorageTypeS3, types.CacheConfig{Disk: types.DiskCacheConfig{Enabled: true}}, false},
+		{"disk cache off", types.StorageTypeS3, types.CacheConfig{Enabled: true, AutoEnable: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := types.StorageConfig{Type: tt.storageType, Cache: tt.cache}
+			assert.Equal(t, tt.want, CacheEnabled(config))
+		})
+	}
+}
+
+func TestCreateStorage_WrapsWithDiskCache(t *testing.T) {
+	config := types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: t.TempDir()},
+		Cache: types.CacheConfig{
+			Enabled: true,
+			Disk:    types.DiskCacheConfig{Enabled: true, Path: t.TempDir()},
+		},
+	}
+
+	backend, err := CreateStorage(config)
+	require.NoError(t, err)
+	defer func() { _ = backend.Close() }()
+
+	_, ok := backend.(*cache.DiskCache)
+	assert.True(t, ok, "expected a disk cache wrapper, got %T", backend)
+	assert.Equal(t, types.StorageTypeLocal, backend.Type())
+}
+
+func TestDescribeStack(t *testing.T) {
+	diskCache := types.DiskCacheConfig{Enabled: true}
+
+	tests := []struct {
+		name   string
+		config types.StorageConfig
+		want   []string
+	}{
+		{
+			name:   "local without cache",
+			config: types.StorageConfig{Type: types.StorageTypeLocal},
+			want:   []string{"local"},
+		},
+		{
+			name: "local with cache",
+			config: types.StorageConfig{
+				Type:  types.StorageTypeLocal,
+				Cache: types.CacheConfig{Enabled: true, Disk: diskCache},
+			},
+			want: []string{"cache(disk)", "local"},
+		},
+		{
+			name: "s3 with cache and retries",
+			config: types.StorageConfig{
+				Type:  types.StorageTypeS3,
+				S3:    types.S3StorageConfig{RetryAttempts: 3},
+				Cache: types.CacheConfig{AutoEnable: true, Disk: diskCache},
+			},
+			want: []string{"cache(disk)", "s3(retry x3)"},
+		},
+		{
+			name: "s3 with disk cache off",
+			config: types.StorageConfig{
+				Type:  types.StorageTypeS3,
+				Cache: types.CacheConfig{Enabled: true, AutoEnable: true},
+			},
+			want: []string{"s3"},
+		},
+		{
+			name: "s3 with compression and cache",
+			config: types.StorageConfig{
+				Type:        types.StorageTypeS3,
+				Compression: types.CompressionGzip,
+				Cache:       types.CacheConfig{Enabled: true, Disk: diskCache},
+			},
+			want: []string{"cache(disk)", "compress(gzip)", "s3"},
+		},
+		{
+			name: "s3 with encryption, compression and cache",
+			config: types.StorageConfig{
+				Type:        types.StorageTypeS3,
+				Compression: types.CompressionGzip,
+				Encryption:  types.EncryptionConfig{Enabled: true, KeyFile: "key"},
+				Cache:       types.CacheConfig{Enabled: true, Disk: diskCache},
+			},
+			want: []string{"compress(gzip)", "encrypt(aes-256-gcm)", "cache(disk)", "s3"},
+		},
+		{
+			name: "azure with retries",
+			config: types.StorageConfig{
+				Type:  types.StorageTypeAzure,
+				Azure: types.AzureBlobConfig{RetryAttempts: 5},
+			},
+			want: []string{"azure(retry x5)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DescribeStack(tt.config))
+		})
+	}
+}
+
+func TestDescribeStack_MatchesCreateStorage(t *testing.T) {
+	config := types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: t.TempDir()},
+	}
+
+	backend, err := CreateStorage(config)
+	require.NoError(t, err)
+	_, cached := backend.(*cache.DiskCache)
+	assert.False(t, cached)
+	assert.Equal(t, []string{"local"}, DescribeStack(config))
+	require.NoError(t, backend.Close())
+
+	config.Cache = types.CacheConfig{Enabled: true, Disk: types.DiskCacheConfig{Enabled: true, Path: t.TempDir()}}
+	backend, err = CreateStorage(config)
+	require.NoError(t, err)
+	_, cached = backend.(*cache.DiskCache)
+	assert.True(t, cached)
+	assert.Equal(t, []string{"cache(disk)", "local"}, DescribeStack(config))
+	require.NoError(t, backend.Close())
+
+	config.Cache = types.CacheConfig{}
+	config.Compression = types.CompressionGzip
+	backend, err = CreateStorage(config)
+	require.NoError(t, err)
+	_, compressed := backend.(*compress.GzipStorage)
+	assert.True(t, compressed)
+	assert.Equal(t, []string{"compress(gzip)", "local"}, DescribeStack(config))
+	require.NoError(t, backend.Close())
+
+	config.Compression = ""
+	config.Local.CreateDirs = true
+	config.Encryption = types.EncryptionConfig{Enabled: true, Passphrase: "correct horse"}
+	backend, err = CreateStorage(config)
+	require.NoError(t, err)
+	_, encrypted := backend.(*encrypt.AESStorage)
+	assert.True(t, encrypted)
+	assert.Equal(t, []string{"encrypt(aes-256-gcm)", "local"}, DescribeStack(config))
+	require.NoError(t, backend.Close())
+
+	config.Encryption = types.EncryptionConfig{Enabled: true, KeyFile: filepath.Join(t.TempDir(), "missing")}
+	_, err = CreateStorage(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to initialize encryption")
+}
+
+func TestCacheNamespace(t *testing.T) {
+	one := types.StorageConfig{Type: types.StorageTypeS3, S3: types.S3StorageConfig{Bucket: "one", Prefix: "kb"}}
+	two := types.StorageConfig{Type: types.StorageTypeS3, S3: types.S3StorageConfig{Bucket: "two", Prefix: "kb"}}
+
+	assert.Equal(t, CacheNamespace(one), CacheNamespace(one))
+	assert.NotEqual(t, CacheNamespace(one), CacheNamespace(two))
+}
+
 func TestValidateLocalConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,78 @@
+package local
+
+import (
+	"context"
+	"sync"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// batchWorkers is how many files a batch operation works on at once
+const batchWorkers = 8
+
+// BatchRead reads paths with a pool of workers. Files that can't be read
+// are reported in a *types.BatchError.
+func (s *Storage) BatchRead(ctx context.Context, paths []string) (map[string][]byte, error) {
+	var mu sync.Mutex
+	files := make(map[string][]byte, len(paths))
+	err := s.runBatch(ctx, "read", paths, func(path string) error {
+		data, err := s.Read(ctx, path)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		files[path] = data
+		mu.Unlock()
+		return nil
+	})
+	return files, err
+}
+
+// BatchDelete removes paths with a pool of workers. Files that can't be
+// removed are reported in a *types.BatchError.
+func (s *Storage) BatchDelete(ctx context.Context, paths []string) error {
+	return s.runBatch(ctx, "delete", paths, func(path string) error {
+		return s.Delete(ctx, path)
+	})
+}
+
+// runBatch calls fn for each distinct path on up to batchWorkers
+// goroutines and collects the errors by path
+func (s *Storage) runBatch(ctx context.Context, operation string, paths []string, fn func(path string) error) error {
+	if err := s.checkClosed(); err != nil {
+		return err
+	}
+
+	work := make(chan string)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+	)
+	for range min(batchWorkers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				if err := fn(path); err != nil {
+					mu.Lock()
+					errs[path] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		work <- path
+	}
+	close(work)
+	wg.Wait()
+
+	return types.BatchErrorFrom(operation, errs)
+}
@@ -0,0 +1,101 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestStorage_BatchRead(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	var paths []string
+	for i := 0; i < 20; i++ {
+		path := fmt.Sprintf("notes/%02d.md", i)
+		if err := storage.Write(ctx, path, []byte(path)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	paths = append(paths, "notes/missing.md", "notes/00.md")
+
+	files, err := storage.BatchRead(ctx, paths)
+	if len(files) != 20 {
+		t.Errorf("BatchRead returned %d files, want 20", len(files))
+	}
+	for path, data := range files {
+		if string(data) != path {
+			t.Errorf("content of %s = %q", path, data)
+		}
+	}
+
+	// The missing file is reported on its own
+	var batchErr *types.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("BatchRead error = %v, want a *types.BatchError", err)
+	}
+	if len(batchErr.Errors) != 1 || !os.IsNotExist(errors.Unwrap(batchErr.Errors["notes/missing.md"])) {
+		t.Errorf("per-path errors = %v, want notes/missing.md not found", batchErr.Errors)
+	}
+
+	if _, err := storage.BatchRead(ctx, paths[:20]); err != nil {
+		t.Errorf("BatchRead of existing files = %v", err)
+	}
+}
+
+func TestStorage_BatchDelete(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	for _, path := range []string{"a.md", "b.md", "locked/c.md"} {
+		if err := storage.Write(ctx, path, []byte("content")); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	// A read-only directory makes one deletion fail
+	lockedDir := filepath.Join(storage.config.Path, "locked")
+	if err := os.Chmod(lockedDir, 0o555); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+	defer func() { _ = os.Chmod(lockedDir, 0o755) }()
+
+	err := storage.BatchDelete(ctx, []string{"a.md", "b.md", "locked/c.md", "missing.md"})
+	var batchErr *types.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("BatchDelete error = %v, want a *types.BatchError", err)
+	}
+	if _, ok := batchErr.Errors["missing.md"]; !ok {
+		t.Errorf("expected missing.md to be reported, got %v", batchErr.Errors)
+	}
+	if os.Geteuid() != 0 {
+		if _, ok := batchErr.Errors["locked/c.md"]; !ok {
+			t.Errorf("expected locked/c.md to be reported, got %v", batchErr.Errors)
+		}
+	}
+
+	// The other files are still removed
+	for _, path := range []string{"a.md", "b.md"} {
+		if exists, _ := storage.Exists(ctx, path); exists {
+			t.Errorf("%s still exists after BatchDelete", path)
+		}
+		if _, ok := batchErr.Errors[path]; ok {
+			t.Errorf("unexpected error for %s: %v", path, batchErr.Errors[path])
+		}
+	}
+
+	if err := storage.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	if err := storage.BatchDelete(ctx, []string{"a.md"}); err == nil {
+		t.Error("BatchDelete on closed storage succeeded")
+	}
+}
@@ -2,8 +2,12 @@ package local
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,11 +20,36 @@ import (
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
+const (
+	// lockDir holds the lock files guarding the paths of the storage,
+	// named by a hash of the path so they stay out of the note directories
+	lockDir = ".kbvault/locks/storage"
+
+	// tempFileInfix and a timestamp are appended to a file path to form
+	// the temporary file an atomic write goes to
+	tempFileInfix = ".tmp."
+
+	// healthCheckPrefix and a timestamp name the file Health creates
+	healthCheckPrefix = ".health_check_"
+
+	// lockPollInterval is how often a contended file lock is retried
+	lockPollInterval = 10 * time.Millisecond
+
+	// sniffLen is how much of a file is read to detect its content type
+	sniffLen = 512
+
+	// defaultContentType is reported for files whose type can't be detected
+	defaultContentType = "application/octet-stream"
+)
+
+// errLockTimeout is returned when a lock can't be acquired in time
+var errLockTimeout = errors.New("timeout acquiring lock")
+
 // Storage implements the StorageBackend interface for local filesystem storage
 type Storage struct {
 	config    types.LocalStorageConfig
-	locks     map[string]*sync.Mutex
-	lockMutex sync.RWMutex
+	locks     map[string]*pathLock
+	lockMutex sync.Mutex
 	closed    bool
 	closeMux  sync.RWMutex
 }
@@ -48,7 +77,7 @@ func New(config types.LocalStorageConfig) (*Storage, error) {
 
 	storage := &Storage{
 		config: config,
-		locks:  make(map[string]*sync.Mutex),
+		locks:  make(map[string]*pathLock),
 	}
 
 	// Create root directory if it doesn't exist and CreateDirs is enabled
@@ -76,23 +105,30 @@ func (s *Storage) Read(ctx context.Context, path string) ([]byte, error) {
 	if err := s.checkClosed(); err != nil {
 		return nil, err
 	}
+	if err := checkContext(ctx, "read", path); err != nil {
+		return nil, err
+	}
 
+	return runWithContext(ctx, "read", path, func() ([]byte, error) {
+		return s.read(ctx, path)
+	})
+}
+
+// read performs the blocking part of Read
+func (s *Storage) read(ctx context.Context, path string) ([]byte, error) {
 	fullPath := s.getFullPath(path)
 
 	if s.config.EnableLocking {
 		unlock, err := s.lockFile(ctx, fullPath, unix.LOCK_SH)
 		if err != nil {
-			return nil, types.NewStorageError(s.Type(), "read", path, err, true)
+			return nil, types.NewStorageError(s.Type(), "read", path, err, classifyLocalError(err))
 		}
 		defer unlock()
 	}
 
 	data, err := os.ReadFile(fullPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, types.NewStorageError(s.Type(), "read", path, err, false)
-		}
-		return nil, types.NewStorageError(s.Type(), "read", path, err, true)
+		return nil, types.NewStorageError(s.Type(), "read", path, err, classifyLocalError(err))
 	}
 
 	return data, nil
@@ -103,23 +139,34 @@ func (s *Storage) Write(ctx context.Context, path string, data []byte) error {
 	if err := s.checkClosed(); err != nil {
 		return err
 	}
+	if err := checkContext(ctx, "write", path); err != nil {
+		return err
+	}
+
+	_, err := runWithContext(ctx, "write", path, func() (struct{}, error) {
+		return struct{}{}, s.write(ctx, path, data)
+	})
+	return err
+}
 
+// write performs the blocking part of Write
+func (s *Storage) write(ctx context.Context, path string, data []byte) error {
 	fullPath := s.getFullPath(path)
 
 	// Ensure directory exists
 	if s.config.CreateDirs {
 		if err := s.ensureDir(filepath.Dir(fullPath)); err != nil {
-			return types.NewStorageError(s.Type(), "write", path, err, true)
+			return types.NewStorageError(s.Type(), "write", path, err, classifyLocalError(err))
 		}
 	}
 
 	// Use atomic write with temp file
-	tempPath := fullPath + ".tmp." + strconv.FormatInt(time.Now().UnixNano(), 10)
+	tempPath := tempFilePath(fullPath)
 
 	if s.config.EnableLocking {
 		unlock, err := s.lockFile(ctx, fullPath, unix.LOCK_EX)
 		if err != nil {
-			return types.NewStorageError(s.Type(), "write", path, err, true)
+			return types.NewStorageError(s.Type(), "write", path, err, classifyLocalError(err))
 		}
 		defer unlock()
 	}
@@ -131,13 +178,84 @@ func (s *Storage) Write(ctx context.Context, path string, data []byte) error {
 	}
 
 	if err := os.WriteFile(tempPath, data, filePerms); err != nil {
-		return types.NewStorageError(s.Type(), "write", path, err, true)
+		return types.NewStorageError(s.Type(), "write", path, err, classifyLocalError(err))
+	}
+
+	// Don't replace the target if the caller gave up while we were writing
+	if err := ctx.Err(); err != nil {
+		_ = os.Remove(tempPath) // Clean up temp file (ignore error as we're already handling one)
+		return types.NewStorageError(s.Type(), "write", path, err, false)
+	}
+
+	if err := s.snapshot(path); err != nil {
+		_ = os.Remove(tempPath) // Clean up temp file (ignore error as we're already handling one)
+		return types.NewStorageError(s.Type(), "write", path, err, classifyLocalError(err))
 	}
 
 	// Atomic rename
 	if err := os.Rename(tempPath, fullPath); err != nil {
 		_ = os.Remove(tempPath) // Clean up temp file (ignore error as we're already handling one)
-		return types.NewStorageError(s.Type(), "write", path, err, true)
+		return types.NewStorageError(s.Type(), "write", path, err, classifyLocalError(err))
+	}
+
+	return nil
+}
+
+// Create stores content at the given path unless a file already exists
+// there. It implements types.CreateBackend.
+func (s *Storage) Create(ctx context.Context, path string, data []byte) error {
+	if err := s.checkClosed(); err != nil {
+		return err
+	}
+	if err := checkContext(ctx, "create", path); err != nil {
+		return err
+	}
+
+	_, err := runWithContext(ctx, "create", path, func() (struct{}, error) {
+		return struct{}{}, s.create(ctx, path, data)
+	})
+	return err
+}
+
+// create performs the blocking part of Create. The content is written to
+// a temp file that is then hard linked into place, which fails if the
+// target exists, so that the file never appears without its content.
+func (s *Storage) create(ctx context.Context, path string, data []byte) error {
+	fullPath := s.getFullPath(path)
+
+	if s.config.CreateDirs {
+		if err := s.ensureDir(filepath.Dir(fullPath)); err != nil {
+			return types.NewStorageError(s.Type(), "create", path, err, classifyLocalError(err))
+		}
+	}
+
+	tempPath := tempFilePath(fullPath)
+
+	if s.config.EnableLocking {
+		unlock, err := s.lockFile(ctx, fullPath, unix.LOCK_EX)
+		if err != nil {
+			return types.NewStorageError(s.Type(), "create", path, err, classifyLocalError(err))
+		}
+		defer unlock()
+	}
+
+	filePerms, err := s.getFilePerms()
+	if err != nil {
+		return types.NewStorageError(s.Type(), "create", path, err, false)
+	}
+
+	if err := os.WriteFile(tempPath, data, filePerms); err != nil {
+		return types.NewStorageError(s.Type(), "create", path, err, classifyLocalError(err))
+	}
+	defer func() { _ = os.Remove(tempPath) }() // The link keeps the content
+
+	// Don't create the target if the caller gave up while we were writing
+	if err := ctx.Err(); err != nil {
+		return types.NewStorageError(s.Type(), "create", path, err, false)
+	}
+
+	if err := os.Link(tempPath, fullPath); err != nil {
+		return types.NewStorageError(s.Type(), "create", path, err, classifyLocalError(err))
 	}
 
 	return nil
@@ -148,23 +266,27 @@ func (s *Storage) Delete(ctx context.Context, path string) error {
 	if err := s.checkClosed(); err != nil {
 		return err
 	}
+	if err := checkContext(ctx, "delete", path); err != nil {
+		return err
+	}
 
 	fullPath := s.getFullPath(path)
 
 	if s.config.EnableLocking {
 		unlock, err := s.lockFile(ctx, fullPath, unix.LOCK_EX)
 		if err != nil {
-			return types.NewStorageError(s.Type(), "delete", path, err, true)
+			return types.NewStorageError(s.Type(), "delete", path, err, classifyLocalError(err))
 		}
 		defer unlock()
 	}
 
+	if err := s.snapshot(path); err != nil {
+		return types.NewStorageError(s.Type(), "delete", path, err, classifyLocalError(err))
+	}
+
 	err := os.Remove(fullPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return types.NewStorageError(s.Type(), "delete", path, err, false)
-		}
-		return types.NewStorageError(s.Type(), "delete", path, err, true)
+		return types.NewStorageError(s.Type(), "delete", path, err, classifyLocalError(err))
 	}
 
 	return nil
@@ -175,6 +297,9 @@ func (s *Storage) Exists(ctx context.Context, path string) (bool, error) {
 	if err := s.checkClosed(); err != nil {
 		return false, err
 	}
+	if err := checkContext(ctx, "exists", path); err != nil {
+		return false, err
+	}
 
 	fullPath := s.getFullPath(path)
 	_, err := os.Stat(fullPath)
@@ -187,7 +312,7 @@ func (s *Storage) Exists(ctx context.Context, path string) (bool, error) {
 		return false, nil
 	}
 
-	return false, types.NewStorageError(s.Type(), "exists", path, err, true)
+	return false, types.NewStorageError(s.Type(), "exists", path, err, classifyLocalError(err))
 }
 
 // List returns all files matching the given prefix
@@ -195,33 +320,22 @@ func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
 	if err := s.checkClosed(); err != nil {
 		return nil, err
 	}
-
-	// For prefix like "list/", we want to list all files in that directory
-	prefixPath := s.getFullPath(prefix)
-
-	// If prefix ends with "/", treat it as a directory
-	var searchDir string
-	var namePrefix string
-
-	if strings.HasSuffix(prefix, "/") {
-		searchDir = prefixPath
-		namePrefix = ""
-	} else {
-		searchDir = filepath.Dir(prefixPath)
-		namePrefix = filepath.Base(prefixPath)
+	if err := checkContext(ctx, "list", prefix); err != nil {
+		return nil, err
 	}
 
+	searchDir, namePrefix := s.listTarget(prefix)
 	entries, err := os.ReadDir(searchDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []string{}, nil
 		}
-		return nil, types.NewStorageError(s.Type(), "list", prefix, err, true)
+		return nil, types.NewStorageError(s.Type(), "list", prefix, err, classifyLocalError(err))
 	}
 
 	var matches []string
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() || isInternalFile(entry.Name()) {
 			continue
 		}
 
@@ -238,46 +352,83 @@ func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
 	return matches, nil
 }
 
+// listTarget returns the directory List reads for prefix and the prefix
+// the names of files in it must have
+func (s *Storage) listTarget(prefix string) (searchDir, namePrefix string) {
+	// For prefix like "list/", we want to list all files in that directory
+	prefixPath := s.getFullPath(prefix)
+
+	// If prefix ends with "/", treat it as a directory
+	if strings.HasSuffix(prefix, "/") {
+		return prefixPath, ""
+	}
+	return filepath.Dir(prefixPath), filepath.Base(prefixPath)
+}
+
 // Stat returns metadata about a file
 func (s *Storage) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
 	if err := s.checkClosed(); err != nil {
 		return nil, err
 	}
+	if err := checkContext(ctx, "stat", path); err != nil {
+		return nil, err
+	}
 
 	fullPath := s.getFullPath(path)
 	stat, err := os.Stat(fullPath)
 
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, types.NewStorageError(s.Type(), "stat", path, err, false)
-		}
-		return nil, types.NewStorageError(s.Type(), "stat", path, err, true)
+		return nil, types.NewStorageError(s.Type(), "stat", path, err, classifyLocalError(err))
 	}
 
 	fileInfo := &types.FileInfo{
 		Path:        path,
 		Size:        stat.Size(),
 		ModTime:     stat.ModTime().Unix(),
-		ContentType: "text/markdown", // Default for .md files
+		ContentType: detectContentType(fullPath, stat),
 	}
 
 	return fileInfo, nil
 }
 
+// detectContentType returns the MIME type of a file from its extension, or
+// by sniffing its first 512 bytes when the extension is unknown
+func detectContentType(fullPath string, stat os.FileInfo) string {
+	if contentType := types.ContentTypeByExtension(fullPath); contentType != "" {
+		return contentType
+	}
+	if stat.IsDir() {
+		return ""
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return defaultContentType
+	}
+	defer func() { _ = file.Close() }()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return defaultContentType
+	}
+	return http.DetectContentType(buf[:n])
+}
+
 // ReadStream returns a reader for streaming large files
 func (s *Storage) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
 	if err := s.checkClosed(); err != nil {
 		return nil, err
 	}
+	if err := checkContext(ctx, "read_stream", path); err != nil {
+		return nil, err
+	}
 
 	fullPath := s.getFullPath(path)
 
 	file, err := os.Open(fullPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, types.NewStorageError(s.Type(), "read_stream", path, err, false)
-		}
-		return nil, types.NewStorageError(s.Type(), "read_stream", path, err, true)
+		return nil, types.NewStorageError(s.Type(), "read_stream", path, err, classifyLocalError(err))
 	}
 
 	// Note: File locking for streams would need to be handled differently
@@ -290,23 +441,34 @@ func (s *Storage) WriteStream(ctx context.Context, path string, reader io.Reader
 	if err := s.checkClosed(); err != nil {
 		return err
 	}
+	if err := checkContext(ctx, "write_stream", path); err != nil {
+		return err
+	}
+
+	_, err := runWithContext(ctx, "write_stream", path, func() (struct{}, error) {
+		return struct{}{}, s.writeStream(ctx, path, reader)
+	})
+	return err
+}
 
+// writeStream performs the blocking part of WriteStream
+func (s *Storage) writeStream(ctx context.Context, path string, reader io.Reader) error {
 	fullPath := s.getFullPath(path)
 
 	// Ensure directory exists
 	if s.config.CreateDirs {
 		if err := s.ensureDir(filepath.Dir(fullPath)); err != nil {
-			return types.NewStorageError(s.Type(), "write_stream", path, err, true)
+			return types.NewStorageError(s.Type(), "write_stream", path, err, classifyLocalError(err))
 		}
 	}
 
 	// Use atomic write with temp file
-	tempPath := fullPath + ".tmp." + strconv.FormatInt(time.Now().UnixNano(), 10)
+	tempPath := tempFilePath(fullPath)
 
 	if s.config.EnableLocking {
 		unlock, err := s.lockFile(ctx, fullPath, unix.LOCK_EX)
 		if err != nil {
-			return types.NewStorageError(s.Type(), "write_stream", path, err, true)
+			return types.NewStorageError(s.Type(), "write_stream", path, err, classifyLocalError(err))
 		}
 		defer unlock()
 	}
@@ -319,52 +481,101 @@ func (s *Storage) WriteStream(ctx context.Context, path string, reader io.Reader
 	// Create temp file
 	tempFile, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePerms)
 	if err != nil {
-		return types.NewStorageError(s.Type(), "write_stream", path, err, true)
+		return types.NewStorageError(s.Type(), "write_stream", path, err, classifyLocalError(err))
 	}
 
-	// Copy data from reader to temp file
-	_, err = io.Copy(tempFile, reader)
+	// Copy data from reader to temp file, stopping early if the context ends
+	_, err = io.Copy(tempFile, &contextReader{ctx: ctx, r: reader})
 	_ = tempFile.Close() // Close temp file (ignore close error)
 
 	if err != nil {
 		_ = os.Remove(tempPath) // Clean up on error (ignore removal error)
-		return types.NewStorageError(s.Type(), "write_stream", path, err, true)
+		return types.NewStorageError(s.Type(), "write_stream", path, err, classifyLocalError(err))
+	}
+
+	// Don't replace the target if the caller gave up while we were copying
+	if err := ctx.Err(); err != nil {
+		_ = os.Remove(tempPath) // Clean up on error (ignore removal error)
+		return types.NewStorageError(s.Type(), "write_stream", path, err, false)
+	}
+
+	if err := s.snapshot(path); err != nil {
+		_ = os.Remove(tempPath) // Clean up on error (ignore removal error)
+		return types.NewStorageError(s.Type(), "write_stream", path, err, classifyLocalError(err))
 	}
 
 	// Atomic rename
 	if err := os.Rename(tempPath, fullPath); err != nil {
 		_ = os.Remove(tempPath) // Clean up on error (ignore removal error)
-		return types.NewStorageError(s.Type(), "write_stream", path, err, true)
+		return types.NewStorageError(s.Type(), "write_stream", path, err, classifyLocalError(err))
 	}
 
 	return nil
 }
 
-// Copy copies a file from src to dst within the same backend
+// Copy copies a file from src to dst within the same backend, streaming the
+// content so that memory use does not grow with file size
 func (s *Storage) Copy(ctx context.Context, src, dst string) error {
 	if err := s.checkClosed(); err != nil {
 		return err
 	}
+	if err := checkContext(ctx, "copy", src+" -> "+dst); err != nil {
+		return err
+	}
+
+	_, err := runWithContext(ctx, "copy", src+" -> "+dst, func() (struct{}, error) {
+		return struct{}{}, s.copy(ctx, src, dst)
+	})
+	return err
+}
+
+// copy streams src into dst through a temp file so that large files are
+// never held in memory
+func (s *Storage) copy(ctx context.Context, src, dst string) error {
+	srcPath := s.getFullPath(src)
+	if srcPath == s.getFullPath(dst) {
+		return nil
+	}
 
-	// Read source file
-	data, err := s.Read(ctx, src)
+	source, err := s.openForCopy(ctx, srcPath)
 	if err != nil {
-		return fmt.Errorf("failed to read source file: %w", err)
+		return fmt.Errorf("failed to read source file: %w",
+			types.NewStorageError(s.Type(), "copy", src, err, classifyLocalError(err)))
 	}
+	defer func() { _ = source.Close() }() // Read-only handle, close error is not actionable
 
-	// Write to destination
-	if err := s.Write(ctx, dst, data); err != nil {
+	// writeStream takes the exclusive lock on dst, applies permissions and
+	// renames the temp file into place atomically
+	if err := s.writeStream(ctx, dst, source); err != nil {
 		return fmt.Errorf("failed to write destination file: %w", err)
 	}
 
 	return nil
 }
 
+// openForCopy opens a file under a shared lock. Writers replace files by
+// renaming, so the open handle keeps a consistent snapshot after the lock is
+// released; releasing early avoids holding two locks while copying.
+func (s *Storage) openForCopy(ctx context.Context, fullPath string) (*os.File, error) {
+	if s.config.EnableLocking {
+		unlock, err := s.lockFile(ctx, fullPath, unix.LOCK_SH)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	return os.Open(fullPath)
+}
+
 // Move moves/renames a file from src to dst
 func (s *Storage) Move(ctx context.Context, src, dst string) error {
 	if err := s.checkClosed(); err != nil {
 		return err
 	}
+	if err := checkContext(ctx, "move", src+" -> "+dst); err != nil {
+		return err
+	}
 
 	srcPath := s.getFullPath(src)
 	dstPath := s.getFullPath(dst)
@@ -372,7 +583,7 @@ func (s *Storage) Move(ctx context.Context, src, dst string) error {
 	// Ensure destination directory exists
 	if s.config.CreateDirs {
 		if err := s.ensureDir(filepath.Dir(dstPath)); err != nil {
-			return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, true)
+			return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, classifyLocalError(err))
 		}
 	}
 
@@ -380,20 +591,25 @@ func (s *Storage) Move(ctx context.Context, src, dst string) error {
 		// Lock both files
 		unlockSrc, err := s.lockFile(ctx, srcPath, unix.LOCK_EX)
 		if err != nil {
-			return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, true)
+			return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, classifyLocalError(err))
 		}
 		defer unlockSrc()
 
 		unlockDst, err := s.lockFile(ctx, dstPath, unix.LOCK_EX)
 		if err != nil {
-			return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, true)
+			return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, classifyLocalError(err))
 		}
 		defer unlockDst()
 	}
 
+	// The rename replaces any file at dst
+	if err := s.snapshot(dst); err != nil {
+		return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, classifyLocalError(err))
+	}
+
 	err := os.Rename(srcPath, dstPath)
 	if err != nil {
-		return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, true)
+		return types.NewStorageError(s.Type(), "move", src+" -> "+dst, err, classifyLocalError(err))
 	}
 
 	return nil
@@ -416,7 +632,7 @@ func (s *Storage) Health(ctx context.Context) error {
 	}
 
 	// Test write permissions by creating a temp file
-	tempPath := filepath.Join(s.config.Path, ".health_check_"+strconv.FormatInt(time.Now().UnixNano(), 10))
+	tempPath := filepath.Join(s.config.Path, healthCheckPrefix+strconv.FormatInt(time.Now().UnixNano(), 10))
 	file, err := os.Create(tempPath)
 	if err != nil {
 		return fmt.Errorf("storage not writable: %w", err)
@@ -440,12 +656,81 @@ func (s *Storage) Close() error {
 
 	// Clear lock map
 	s.lockMutex.Lock()
-	s.locks = make(map[string]*sync.Mutex)
+	s.locks = make(map[string]*pathLock)
 	s.lockMutex.Unlock()
 
 	return nil
 }
 
+// classifyLocalError reports whether a filesystem error is worth retrying.
+// Only transient conditions (interrupted or would-block syscalls, I/O
+// errors from flaky network filesystems, timeouts and lock contention) are
+// retryable; permission and not-found errors never succeed on retry, and
+// anything unrecognized is treated as permanent.
+func classifyLocalError(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, os.ErrPermission):
+		return false
+	case errors.Is(err, errLockTimeout), os.IsTimeout(err):
+		return true
+	case errors.Is(err, unix.EAGAIN), errors.Is(err, unix.EINTR), errors.Is(err, unix.EIO),
+		errors.Is(err, unix.EBUSY), errors.Is(err, unix.ETIMEDOUT), errors.Is(err, unix.ESTALE):
+		return true
+	default:
+		return false
+	}
+}
+
+// checkContext returns a StorageError if ctx is already done
+func checkContext(ctx context.Context, operation, path string) error {
+	if err := ctx.Err(); err != nil {
+		return types.NewStorageError(types.StorageTypeLocal, operation, path, err, false)
+	}
+	return nil
+}
+
+// runWithContext runs a blocking filesystem operation in a goroutine so a
+// cancelled or expired context returns promptly even when the underlying
+// syscall (e.g. on a slow network filesystem) does not. The operation keeps
+// running in the background and still releases its locks when it finishes.
+func runWithContext[T any](ctx context.Context, operation, path string, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, types.NewStorageError(types.StorageTypeLocal, operation, path, ctx.Err(), false)
+	}
+}
+
+// contextReader stops a copy as soon as its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
 // Helper methods
 
 func (s *Storage) checkClosed() error {
@@ -506,72 +791,191 @@ func (s *Storage) getFilePerms() (os.FileMode, error) {
 	return os.FileMode(perms), nil
 }
 
-func (s *Storage) lockFile(ctx context.Context, path string, lockType int) (func(), error) {
-	// Get or create mutex for this path
+// lockPath returns the path of the stable lock file guarding fullPath. Lock
+// files are kept once created: removing one while another process waits on
+// it would let that process and the next one to create it both take the
+// lock.
+func (s *Storage) lockPath(fullPath string) string {
+	rel, err := filepath.Rel(s.config.Path, fullPath)
+	if err != nil {
+		rel = fullPath
+	}
+	sum := sha256.Sum256([]byte(filepath.ToSlash(rel)))
+	return filepath.Join(s.config.Path, filepath.FromSlash(lockDir), hex.EncodeToString(sum[:]))
+}
+
+// tempFilePath returns a new temporary file path for an atomic write to
+// fullPath
+func tempFilePath(fullPath string) string {
+	return fullPath + tempFileInfix + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// isInternalFile reports whether name is a file the backend creates for
+// its own use next to other files: the temporary file of an atomic write,
+// or a health check file. Such files are left out of listings, where they
+// could otherwise show up while a write or health check is in progress.
+func isInternalFile(name string) bool {
+	if strings.HasPrefix(name, healthCheckPrefix) {
+		return isTimestamp(strings.TrimPrefix(name, healthCheckPrefix))
+	}
+	i := strings.LastIndex(name, tempFileInfix)
+	return i >= 0 && isTimestamp(name[i+len(tempFileInfix):])
+}
+
+// isTimestamp reports whether s is a timestamp as internal file names end
+// with
+func isTimestamp(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// pathLock is the in-process lock of one path. refs counts the operations
+// holding or waiting for it, so that it can be dropped from the lock map
+// once no operation uses the path and the map only ever holds paths in use.
+type pathLock struct {
+	sync.RWMutex
+	refs int
+}
+
+// acquirePathLock returns the in-process lock of path, creating it if no
+// operation is using the path. Each call must be paired with a call to
+// releasePathLock.
+func (s *Storage) acquirePathLock(path string) *pathLock {
 	s.lockMutex.Lock()
-	mutex, exists := s.locks[path]
+	defer s.lockMutex.Unlock()
+
+	l, exists := s.locks[path]
 	if !exists {
-		mutex = &sync.Mutex{}
-		s.locks[path] = mutex
+		l = &pathLock{}
+		s.locks[path] = l
+	}
+	l.refs++
+	return l
+}
+
+// releasePathLock drops a reference taken by acquirePathLock, removing the
+// lock from the map when it was the last one
+func (s *Storage) releasePathLock(path string, l *pathLock) {
+	s.lockMutex.Lock()
+	defer s.lockMutex.Unlock()
+
+	l.refs--
+	// Close replaces the map, so only remove the lock if it is still the
+	// one registered for path
+	if l.refs == 0 && s.locks[path] == l {
+		delete(s.locks, path)
+	}
+}
+
+// lockedPaths returns the number of paths with an in-process lock
+func (s *Storage) lockedPaths() int {
+	s.lockMutex.Lock()
+	defer s.lockMutex.Unlock()
+	return len(s.locks)
+}
+
+// lockFile acquires a per-path lock for the duration of an operation.
+//
+// The lock is taken in two layers: an in-process RWMutex, and a flock on a
+// stable lock file under lockDir. Locking that file instead of the target
+// means the lock survives the temp-file rename used by atomic writes, so a
+// reader can never observe the file mid-replace. The returned function
+// releases both layers and must be called after the write/rename has
+// completed.
+func (s *Storage) lockFile(ctx context.Context, path string, lockType int) (func(), error) {
+	exclusive := lockType == unix.LOCK_EX
+
+	// Get or create mutex for this path
+	mutex := s.acquirePathLock(path)
+
+	lock, unlockMutex := mutex.RLock, mutex.RUnlock
+	if exclusive {
+		lock, unlockMutex = mutex.Lock, mutex.Unlock
+	}
+	unlock := func() {
+		unlockMutex()
+		s.releasePathLock(path, mutex)
 	}
-	s.lockMutex.Unlock()
 
 	// Acquire mutex with timeout
-	done := make(chan bool, 1)
+	done := make(chan struct{})
 	go func() {
-		mutex.Lock()
-		done <- true
+		lock()
+		close(done)
 	}()
 
 	timeout := time.Duration(s.config.LockTimeout) * time.Second
+	deadline := time.Now().Add(timeout)
+
 	select {
 	case <-done:
-		// Mutex acquired, now try to get file lock
-		// For read operations, don't create the file if it doesn't exist
-		var flags int
-		if lockType == unix.LOCK_SH {
-			flags = os.O_RDONLY // Read lock - don't create file
-		} else {
-			flags = os.O_RDONLY | os.O_CREATE // Write lock - create if needed
-		}
-
-		file, err := os.OpenFile(path, flags, 0644)
-		if err != nil {
-			mutex.Unlock()
-			return nil, err
-		}
-
-		// Try to get flock with timeout
-		flockDone := make(chan error, 1)
+	case <-time.After(timeout):
+		// Release the mutex once the pending acquisition completes
+		go func() {
+			<-done
+			unlock()
+		}()
+		return nil, fmt.Errorf("%w: mutex not acquired after %v", errLockTimeout, timeout)
+	case <-ctx.Done():
 		go func() {
-			flockDone <- unix.Flock(int(file.Fd()), lockType|unix.LOCK_NB)
+			<-done
+			unlock()
 		}()
+		return nil, ctx.Err()
+	}
 
-		select {
-		case err := <-flockDone:
-			if err != nil {
-				_ = file.Close() // Ignore close error when handling lock error
-				mutex.Unlock()
-				return nil, fmt.Errorf("failed to acquire file lock: %w", err)
-			}
+	// Writers create the lock file; readers only lock it if it exists; a
+	// missing lock file means no writer has touched the path yet, and the
+	// atomic rename keeps such reads consistent
+	lockFilePath := s.lockPath(path)
+	flags := os.O_RDWR
+	if exclusive {
+		flags |= os.O_CREATE
+		if err := s.ensureDir(filepath.Dir(lockFilePath)); err != nil {
+			unlock()
+			return nil, err
+		}
+	}
 
-			// Return unlock function
-			return func() {
-				_ = unix.Flock(int(file.Fd()), unix.LOCK_UN) // Ignore unlock error
-				_ = file.Close()                             // Ignore close error in cleanup
-				mutex.Unlock()
-			}, nil
+	file, err := os.OpenFile(lockFilePath, flags, 0644)
+	if err != nil {
+		if !exclusive && os.IsNotExist(err) {
+			return unlock, nil
+		}
+		unlock()
+		return nil, err
+	}
 
-		case <-time.After(timeout):
+	// Poll for the flock so we can honor both the timeout and the context
+	for {
+		err := unix.Flock(int(file.Fd()), lockType|unix.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if err != unix.EWOULDBLOCK && err != unix.EINTR {
+			_ = file.Close() // Ignore close error when handling lock error
+			unlock()
+			return nil, fmt.Errorf("failed to acquire file lock: %w", err)
+		}
+		if time.Now().After(deadline) {
 			_ = file.Close() // Ignore close error on timeout
-			mutex.Unlock()
-			return nil, fmt.Errorf("timeout acquiring file lock after %v", timeout)
+			unlock()
+			return nil, fmt.Errorf("%w: file lock not acquired after %v", errLockTimeout, timeout)
 		}
 
-	case <-time.After(timeout):
-		return nil, fmt.Errorf("timeout acquiring mutex lock after %v", timeout)
-
-	case <-ctx.Done():
-		return nil, ctx.Err()
+		select {
+		case <-ctx.Done():
+			_ = file.Close() // Ignore close error on cancellation
+			unlock()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
 	}
+
+	// Return unlock function
+	return func() {
+		_ = unix.Flock(int(file.Fd()), unix.LOCK_UN) // Ignore unlock error
+		_ = file.Close()                             // Ignore close error in cleanup
+		unlock()
+	}, nil
 }
@@ -1,15 +1,21 @@
 package local
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"golang.org/x/sys/unix"
+
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -121,6 +127,39 @@ func TestStorage_WriteAndReadStream(t *testing.T) {
 	}
 }
 
+func TestStorage_Create(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	var _ types.CreateBackend = storage
+	ctx := context.Background()
+
+	if err := storage.Create(ctx, "create/new.md", []byte("first")); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	err := storage.Create(ctx, "create/new.md", []byte("second"))
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Expected fs.ErrExist creating an existing file, got %v", err)
+	}
+
+	data, err := storage.Read(ctx, "create/new.md")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "first" {
+		t.Errorf("Create should not replace an existing file, got %q", data)
+	}
+
+	files, err := storage.List(ctx, "create/")
+	if err != nil {
+		t.Fatalf("Failed to list files: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected only the created file, got %v", files)
+	}
+}
+
 func TestStorage_Delete(t *testing.T) {
 	storage := createTestStorage(t)
 	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
@@ -277,6 +316,43 @@ func TestStorage_Stat(t *testing.T) {
 	}
 }
 
+func TestStorage_StatContentType(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	tests := []struct {
+		path string
+		data []byte
+		want string
+	}{
+		{"notes/note.md", []byte("# Note\n"), "text/markdown"},
+		{"notes/NOTE.MD", []byte("# Note\n"), "text/markdown"},
+		{"attachments/diagram.png", png, "image/png"},
+		{"attachments/paper.pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		// Without a known extension the content is sniffed
+		{"attachments/image", png, "image/png"},
+		{"attachments/notes.unknownext", []byte("plain text"), "text/plain; charset=utf-8"},
+		{"attachments/empty", nil, "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		if err := storage.Write(ctx, tt.path, tt.data); err != nil {
+			t.Fatalf("Failed to write %s: %v", tt.path, err)
+		}
+
+		info, err := storage.Stat(ctx, tt.path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", tt.path, err)
+		}
+		if info.ContentType != tt.want {
+			t.Errorf("%s: expected content type %q, got %q", tt.path, tt.want, info.ContentType)
+		}
+	}
+}
+
 func TestStorage_Copy(t *testing.T) {
 	storage := createTestStorage(t)
 	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
@@ -314,6 +390,86 @@ func TestStorage_Copy(t *testing.T) {
 	}
 }
 
+func TestStorage_CopyLargeFile(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	srcPath := "attachments/large.bin"
+	dstPath := "attachments/nested/large-copy.bin"
+
+	// Non-repeating content so a misplaced chunk would be detected
+	data := make([]byte, 4*1024*1024+123)
+	for i := range data {
+		data[i] = byte(i*31 + i/251)
+	}
+	if err := storage.Write(ctx, srcPath, data); err != nil {
+		t.Fatalf("Failed to write source file: %v", err)
+	}
+
+	if err := storage.Copy(ctx, srcPath, dstPath); err != nil {
+		t.Fatalf("Failed to copy file: %v", err)
+	}
+
+	copied, err := storage.Read(ctx, dstPath)
+	if err != nil {
+		t.Fatalf("Failed to read destination: %v", err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("Copied content differs from source (%d vs %d bytes)", len(copied), len(data))
+	}
+
+	info, err := os.Stat(storage.getFullPath(dstPath))
+	if err != nil {
+		t.Fatalf("Failed to stat destination: %v", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected destination permissions 0644, got %o", info.Mode().Perm())
+	}
+
+	// No temp files are left next to the destination
+	entries, err := os.ReadDir(filepath.Dir(storage.getFullPath(dstPath)))
+	if err != nil {
+		t.Fatalf("Failed to read destination directory: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp.") {
+			t.Errorf("Temp file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestStorage_CopyErrors(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+
+	err := storage.Copy(ctx, "missing.md", "dst.md")
+	if err == nil {
+		t.Fatal("Expected error copying missing file")
+	}
+	var storageErr *types.StorageError
+	if !errors.As(err, &storageErr) || storageErr.Retryable {
+		t.Errorf("Expected non-retryable storage error, got %v", err)
+	}
+	if exists, _ := storage.Exists(ctx, "dst.md"); exists {
+		t.Error("Destination should not be created when the source is missing")
+	}
+
+	// Copying a file onto itself leaves it intact
+	if err := storage.Write(ctx, "self.md", []byte("self")); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := storage.Copy(ctx, "self.md", "self.md"); err != nil {
+		t.Fatalf("Failed to copy file onto itself: %v", err)
+	}
+	data, err := storage.Read(ctx, "self.md")
+	if err != nil || string(data) != "self" {
+		t.Errorf("File changed after self copy: %q, %v", data, err)
+	}
+}
+
 func TestStorage_Move(t *testing.T) {
 	storage := createTestStorage(t)
 	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
@@ -448,6 +604,366 @@ func TestStorage_ConcurrentAccess(t *testing.T) {
 	}
 }
 
+func TestStorage_ConcurrentReadersAndWriters(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	const path = "shared/contended.md"
+	const size = 256 * 1024
+	const numWriters = 4
+	const numReaders = 8
+	const numOperations = 20
+
+	payloads := make([][]byte, numWriters)
+	for i := range payloads {
+		payloads[i] = []byte(strings.Repeat(string(rune('a'+i)), size))
+	}
+
+	if err := storage.Write(ctx, path, payloads[0]); err != nil {
+		t.Fatalf("Initial write failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errors := make(chan error, (numWriters+numReaders)*numOperations)
+
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < numOperations; j++ {
+				if err := storage.Write(ctx, path, payloads[id]); err != nil {
+					errors <- fmt.Errorf("writer %d: %w", id, err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	for i := 0; i < numReaders; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < numOperations; j++ {
+				data, err := storage.Read(ctx, path)
+				if err != nil {
+					errors <- fmt.Errorf("reader %d: %w", id, err)
+					return
+				}
+				if len(data) != size {
+					errors <- fmt.Errorf("reader %d: torn read, got %d bytes", id, len(data))
+					return
+				}
+				if strings.Count(string(data), string(data[0])) != size {
+					errors <- fmt.Errorf("reader %d: torn read, mixed content", id)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errors)
+
+	for err := range errors {
+		t.Errorf("Concurrent read/write error: %v", err)
+	}
+}
+
+func TestStorage_LockFile(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+
+	if err := storage.Write(ctx, "locked/note.md", []byte("content")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// The lock file persists so later lockers share the same inode, and
+	// is kept under lockDir rather than next to the note
+	fullPath := storage.getFullPath("locked/note.md")
+	lockFilePath := storage.lockPath(fullPath)
+	if filepath.Dir(lockFilePath) != filepath.Join(storage.config.Path, filepath.FromSlash(lockDir)) {
+		t.Errorf("Expected lock file under %s, got %s", lockDir, lockFilePath)
+	}
+	if _, err := os.Stat(lockFilePath); err != nil {
+		t.Errorf("Expected lock file to be created by write: %v", err)
+	}
+
+	// Moving and deleting the note leave nothing behind in its directory
+	if err := storage.Move(ctx, "locked/note.md", "locked/moved.md"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if err := storage.Delete(ctx, "locked/moved.md"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	entries, err := os.ReadDir(storage.getFullPath("locked"))
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected an empty directory after delete, found %d files", len(entries))
+	}
+
+	// An exclusive lock blocks other lockers until released
+	unlock, err := storage.lockFile(ctx, fullPath, unix.LOCK_EX)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := storage.lockFile(timeoutCtx, fullPath, unix.LOCK_SH); err == nil {
+		t.Error("Expected shared lock to fail while exclusive lock is held")
+	}
+
+	unlock()
+
+	unlockShared, err := storage.lockFile(ctx, fullPath, unix.LOCK_SH)
+	if err != nil {
+		t.Fatalf("Failed to acquire shared lock after release: %v", err)
+	}
+	unlockShared()
+}
+
+func TestStorage_LockMapBounded(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+
+	// Many writes and reads of distinct paths, some concurrent
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				path := fmt.Sprintf("bounded/%d/%d.md", w, i)
+				if err := storage.Write(ctx, path, []byte("content")); err != nil {
+					t.Errorf("Write %s failed: %v", path, err)
+					return
+				}
+				if _, err := storage.Read(ctx, path); err != nil {
+					t.Errorf("Read %s failed: %v", path, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if n := storage.lockedPaths(); n != 0 {
+		t.Errorf("Expected no locks to remain after 400 writes, got %d", n)
+	}
+
+	// A held lock stays in the map, shared by every locker of the path,
+	// until its last holder releases it
+	fullPath := storage.getFullPath("bounded/0/0.md")
+	unlock, err := storage.lockFile(ctx, fullPath, unix.LOCK_SH)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	unlockShared, err := storage.lockFile(ctx, fullPath, unix.LOCK_SH)
+	if err != nil {
+		t.Fatalf("Failed to acquire second shared lock: %v", err)
+	}
+	if n := storage.lockedPaths(); n != 1 {
+		t.Errorf("Expected 1 lock while held, got %d", n)
+	}
+
+	unlock()
+	if n := storage.lockedPaths(); n != 1 {
+		t.Errorf("Expected lock to remain while still held, got %d", n)
+	}
+	unlockShared()
+	if n := storage.lockedPaths(); n != 0 {
+		t.Errorf("Expected lock to be removed after release, got %d", n)
+	}
+
+	// Failed acquisitions drop their reference too, once the pending wait
+	// completes
+	unlock, err = storage.lockFile(ctx, fullPath, unix.LOCK_EX)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := storage.lockFile(timeoutCtx, fullPath, unix.LOCK_EX); err == nil {
+		t.Fatal("Expected second exclusive lock to fail")
+	}
+	unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for storage.lockedPaths() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := storage.lockedPaths(); n != 0 {
+		t.Errorf("Expected lock to be removed after a cancelled acquisition, got %d", n)
+	}
+}
+
+func TestStorage_CancelledContext(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	if err := storage.Write(context.Background(), "ctx/existing.md", []byte("content")); err != nil {
+		t.Fatalf("Setup write failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	operations := map[string]func() error{
+		"read": func() error {
+			_, err := storage.Read(ctx, "ctx/existing.md")
+			return err
+		},
+		"write": func() error {
+			return storage.Write(ctx, "ctx/new.md", []byte("content"))
+		},
+		"write_stream": func() error {
+			return storage.WriteStream(ctx, "ctx/stream.md", strings.NewReader("content"))
+		},
+		"delete": func() error {
+			return storage.Delete(ctx, "ctx/existing.md")
+		},
+		"stat": func() error {
+			_, err := storage.Stat(ctx, "ctx/existing.md")
+			return err
+		},
+	}
+
+	for op, fn := range operations {
+		t.Run(op, func(t *testing.T) {
+			err := fn()
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Expected context.Canceled, got %v", err)
+			}
+
+			var storageErr *types.StorageError
+			if !errors.As(err, &storageErr) {
+				t.Fatalf("Expected StorageError, got %T", err)
+			}
+			if storageErr.Operation != op {
+				t.Errorf("Expected operation %s, got %s", op, storageErr.Operation)
+			}
+			if storageErr.IsRetryable() {
+				t.Error("Cancelled operations should not be retryable")
+			}
+		})
+	}
+
+	// Nothing should have been written or deleted
+	bg := context.Background()
+	for _, path := range []string{"ctx/new.md", "ctx/stream.md"} {
+		if exists, _ := storage.Exists(bg, path); exists {
+			t.Errorf("%s should not exist after cancelled write", path)
+		}
+	}
+	if exists, _ := storage.Exists(bg, "ctx/existing.md"); !exists {
+		t.Error("ctx/existing.md should survive a cancelled delete")
+	}
+}
+
+// slowReader blocks on each read until released
+type slowReader struct {
+	release chan struct{}
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	<-r.release
+	return 0, io.EOF
+}
+
+func TestStorage_WriteStreamDeadline(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	reader := &slowReader{release: make(chan struct{})}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := storage.WriteStream(ctx, "ctx/slow.md", reader)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("WriteStream should return promptly after the deadline, took %v", elapsed)
+	}
+
+	// The abandoned write finishes once the reader returns, but must not
+	// put the file in place. Reading waits for its lock to be released.
+	close(reader.release)
+	if _, err := storage.Read(context.Background(), "ctx/slow.md"); !types.IsNotFound(err) {
+		t.Errorf("ctx/slow.md should not exist after a write that timed out, got %v", err)
+	}
+}
+
+func TestClassifyLocalError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"not exist", &os.PathError{Op: "open", Path: "x", Err: unix.ENOENT}, false},
+		{"permission denied", &os.PathError{Op: "open", Path: "x", Err: unix.EACCES}, false},
+		{"operation not permitted", &os.PathError{Op: "rename", Path: "x", Err: unix.EPERM}, false},
+		{"would block", &os.PathError{Op: "read", Path: "x", Err: unix.EAGAIN}, true},
+		{"interrupted", &os.PathError{Op: "read", Path: "x", Err: unix.EINTR}, true},
+		{"io error", &os.PathError{Op: "read", Path: "x", Err: unix.EIO}, true},
+		{"stale handle", &os.PathError{Op: "read", Path: "x", Err: unix.ESTALE}, true},
+		{"deadline exceeded fd", os.ErrDeadlineExceeded, true},
+		{"lock timeout", fmt.Errorf("%w: after 1s", errLockTimeout), true},
+		{"context cancelled", context.Canceled, false},
+		{"context deadline", context.DeadlineExceeded, false},
+		{"no space", &os.PathError{Op: "write", Path: "x", Err: unix.ENOSPC}, false},
+		{"unknown", errors.New("something else"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyLocalError(tt.err); got != tt.expected {
+				t.Errorf("classifyLocalError(%v) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStorage_PermissionErrorNotRetryable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Permission checks don't apply to root")
+	}
+
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	if err := storage.Write(ctx, "private/note.md", []byte("secret")); err != nil {
+		t.Fatalf("Setup write failed: %v", err)
+	}
+
+	dir := filepath.Join(storage.config.Path, "private")
+	if err := os.Chmod(dir, 0o000); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+	defer func() { _ = os.Chmod(dir, 0o755) }() // Restore so TempDir cleanup works
+
+	_, err := storage.Read(ctx, "private/note.md")
+	if err == nil {
+		t.Fatal("Expected permission error")
+	}
+	if types.IsRetryable(err) {
+		t.Errorf("Permission errors should not be retryable: %v", err)
+	}
+}
+
 func TestStorage_PathSecurity(t *testing.T) {
 	storage := createTestStorage(t)
 	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
@@ -598,6 +1114,54 @@ func BenchmarkStorage_Read(b *testing.B) {
 	}
 }
 
+// benchmarkCopySize is large enough that holding the file in memory dominates allocations
+const benchmarkCopySize = 16 * 1024 * 1024
+
+// BenchmarkStorage_Copy measures the streaming Copy implementation
+func BenchmarkStorage_Copy(b *testing.B) {
+	storage := createBenchStorage(b)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	if err := storage.Write(ctx, "bench/large.bin", make([]byte, benchmarkCopySize)); err != nil {
+		b.Fatalf("Setup write failed: %v", err)
+	}
+
+	b.SetBytes(benchmarkCopySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := storage.Copy(ctx, "bench/large.bin", "bench/large-copy.bin"); err != nil {
+			b.Fatalf("Copy failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkStorage_CopyReadWrite measures the previous read-into-memory
+// approach for comparison with BenchmarkStorage_Copy
+func BenchmarkStorage_CopyReadWrite(b *testing.B) {
+	storage := createBenchStorage(b)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	if err := storage.Write(ctx, "bench/large.bin", make([]byte, benchmarkCopySize)); err != nil {
+		b.Fatalf("Setup write failed: %v", err)
+	}
+
+	b.SetBytes(benchmarkCopySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := storage.Read(ctx, "bench/large.bin")
+		if err != nil {
+			b.Fatalf("Read failed: %v", err)
+		}
+		if err := storage.Write(ctx, "bench/large-copy.bin", data); err != nil {
+			b.Fatalf("Write failed: %v", err)
+		}
+	}
+}
+
 func createBenchStorage(b *testing.B) *Storage {
 	tempDir := b.TempDir()
 
@@ -0,0 +1,236 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+const (
+	// versionsDir holds the snapshots of past versions, in a directory per
+	// file named after its path
+	versionsDir = ".kbvault/versions"
+
+	// internalDir holds the vault's own state, which is never versioned
+	internalDir = ".kbvault"
+
+	// versionIDFormat names snapshots after when they were taken. It is
+	// fixed width, so IDs sort chronologically.
+	versionIDFormat = "20060102T150405.000000000Z"
+
+	// currentVersionID identifies the current content of a file
+	currentVersionID = "current"
+)
+
+// ListVersions returns the current version of the file at path, with the
+// ID "current", followed by its snapshots, newest first
+func (s *Storage) ListVersions(ctx context.Context, path string) ([]types.FileVersion, error) {
+	if err := s.checkVersioning(ctx, "list_versions", path); err != nil {
+		return nil, err
+	}
+
+	var versions []types.FileVersion
+	current, err := os.Stat(s.getFullPath(path))
+	switch {
+	case err == nil && !current.IsDir():
+		versions = append(versions, types.FileVersion{
+			ID:      currentVersionID,
+			ModTime: current.ModTime(),
+			Size:    current.Size(),
+			Current: true,
+		})
+	case err != nil && !os.IsNotExist(err):
+		return nil, types.NewStorageError(s.Type(), "list_versions", path, err, classifyLocalError(err))
+	}
+
+	entries, err := os.ReadDir(s.versionDir(path))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, types.NewStorageError(s.Type(), "list_versions", path, err, classifyLocalError(err))
+	}
+
+	// Entries are sorted by name, so oldest first
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !isVersionID(entries[i].Name()) {
+			continue
+		}
+		info, err := entries[i].Info()
+		if err != nil {
+			continue // Pruned while listing
+		}
+		// Snapshots keep the modification time of the content they hold
+		versions = append(versions, types.FileVersion{
+			ID:      entries[i].Name(),
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+	}
+
+	return versions, nil
+}
+
+// ReadVersion retrieves the content of one version of the file at path
+func (s *Storage) ReadVersion(ctx context.Context, path, versionID string) ([]byte, error) {
+	if err := s.checkVersioning(ctx, "read_version", path); err != nil {
+		return nil, err
+	}
+	if versionID == currentVersionID {
+		return s.Read(ctx, path)
+	}
+	if !isVersionID(versionID) {
+		return nil, types.NewStorageError(s.Type(), "read_version", path, fmt.Errorf("invalid version ID %q", versionID), false)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.versionDir(path), versionID))
+	if err != nil {
+		return nil, types.NewStorageError(s.Type(), "read_version", path, err, classifyLocalError(err))
+	}
+	return data, nil
+}
+
+// RestoreVersion writes a snapshot back as the content of the file at
+// path, which snapshots the current content in turn. Restoring the current
+// version does nothing.
+func (s *Storage) RestoreVersion(ctx context.Context, path, versionID string) error {
+	if versionID == currentVersionID {
+		return s.checkVersioning(ctx, "restore_version", path)
+	}
+
+	data, err := s.ReadVersion(ctx, path, versionID)
+	if err != nil {
+		return err
+	}
+	return s.Write(ctx, path, data)
+}
+
+// checkVersioning returns an error if version operations can't run
+func (s *Storage) checkVersioning(ctx context.Context, operation, path string) error {
+	if err := s.checkClosed(); err != nil {
+		return err
+	}
+	if err := checkContext(ctx, operation, path); err != nil {
+		return err
+	}
+	if s.config.VersionRetention <= 0 {
+		return fmt.Errorf("%w: set storage.local.version_retention to keep versions of local files", types.ErrVersioningNotSupported)
+	}
+	return nil
+}
+
+// snapshot keeps the content of the file at path as a past version before
+// it is replaced or removed. The caller holds the file's exclusive lock.
+// Files are replaced by renaming, so the snapshot is a hard link to the
+// old content where the filesystem allows it.
+func (s *Storage) snapshot(path string) error {
+	if !s.versioned(path) {
+		return nil
+	}
+
+	fullPath := s.getFullPath(path)
+	info, err := os.Stat(fullPath)
+	if err != nil || info.IsDir() {
+		// Nothing to keep yet
+		return nil
+	}
+
+	dir := s.versionDir(path)
+	if err := s.ensureDir(dir); err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+
+	// Snapshots taken in the same clock tick get consecutive IDs
+	for taken := time.Now(); ; taken = taken.Add(time.Nanosecond) {
+		target := filepath.Join(dir, taken.UTC().Format(versionIDFormat))
+		err := os.Link(fullPath, target)
+		if err != nil && !errors.Is(err, os.ErrExist) {
+			if err = copyFile(fullPath, target, info.Mode().Perm()); err == nil {
+				// Keep the copy's time matching the content it holds
+				_ = os.Chtimes(target, info.ModTime(), info.ModTime())
+			}
+		}
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to snapshot %s: %w", path, err)
+		}
+	}
+
+	return s.pruneVersions(dir)
+}
+
+// pruneVersions removes the oldest snapshots in dir beyond the retention
+func (s *Storage) pruneVersions(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var snapshots []string
+	for _, entry := range entries {
+		if isVersionID(entry.Name()) {
+			snapshots = append(snapshots, entry.Name())
+		}
+	}
+	sort.Strings(snapshots)
+
+	for len(snapshots) > s.config.VersionRetention {
+		if err := os.Remove(filepath.Join(dir, snapshots[0])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+// versioned reports whether past versions of the file at path are kept.
+// The vault's own state under .kbvault never is.
+func (s *Storage) versioned(path string) bool {
+	if s.config.VersionRetention <= 0 {
+		return false
+	}
+	rel := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	return rel != internalDir && !strings.HasPrefix(rel, internalDir+"/")
+}
+
+// versionDir returns the directory holding the snapshots of path
+func (s *Storage) versionDir(path string) string {
+	rel, err := filepath.Rel(s.config.Path, s.getFullPath(path))
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	return filepath.Join(s.config.Path, filepath.FromSlash(versionsDir), rel)
+}
+
+// isVersionID reports whether id names a snapshot
+func isVersionID(id string) bool {
+	_, err := time.Parse(versionIDFormat, id)
+	return err == nil
+}
+
+// copyFile copies src to dst, for filesystems without hard links
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,219 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func createVersionedStorage(t *testing.T, retention int) *Storage {
+	t.Helper()
+
+	storage, err := New(types.LocalStorageConfig{
+		Path:             t.TempDir(),
+		CreateDirs:       true,
+		EnableLocking:    true,
+		VersionRetention: retention,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test storage: %v", err)
+	}
+	t.Cleanup(func() { _ = storage.Close() })
+	return storage
+}
+
+// versionContents returns the content of each version of path, newest first
+func versionContents(t *testing.T, storage *Storage, path string) []string {
+	t.Helper()
+
+	ctx := context.Background()
+	versions, err := storage.ListVersions(ctx, path)
+	if err != nil {
+		t.Fatalf("Failed to list versions of %s: %v", path, err)
+	}
+
+	contents := make([]string, len(versions))
+	for i, v := range versions {
+		data, err := storage.ReadVersion(ctx, path, v.ID)
+		if err != nil {
+			t.Fatalf("Failed to read version %s of %s: %v", v.ID, path, err)
+		}
+		contents[i] = string(data)
+	}
+	return contents
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStorage_VersionsOnOverwrite(t *testing.T) {
+	storage := createVersionedStorage(t, 10)
+	ctx := context.Background()
+
+	// Writes in quick succession each keep the content they replace
+	for _, content := range []string{"one", "two", "three"} {
+		if err := storage.Write(ctx, "notes/a.md", []byte(content)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	got := versionContents(t, storage, "notes/a.md")
+	if want := []string{"three", "two", "one"}; !equalStrings(got, want) {
+		t.Errorf("versions = %q, want %q", got, want)
+	}
+
+	versions, err := storage.ListVersions(ctx, "notes/a.md")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if !versions[0].Current || versions[0].ID != currentVersionID {
+		t.Errorf("first version = %+v, want the current content", versions[0])
+	}
+	if versions[1].Current || versions[1].Size != 3 {
+		t.Errorf("second version = %+v, want a 3 byte snapshot", versions[1])
+	}
+
+	// Deletes keep the previous content too
+	if err := storage.Delete(ctx, "notes/a.md"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	got = versionContents(t, storage, "notes/a.md")
+	if want := []string{"three", "two", "one"}; !equalStrings(got, want) {
+		t.Errorf("versions after delete = %q, want %q", got, want)
+	}
+
+	// The vault's own state is not versioned
+	for i := 0; i < 2; i++ {
+		if err := storage.Write(ctx, ".kbvault/search-index.json", []byte("{}")); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(storage.config.Path, versionsDir, ".kbvault")); !os.IsNotExist(err) {
+		t.Errorf("expected no snapshots of .kbvault files, got err %v", err)
+	}
+
+	// New files have no snapshots
+	if err := storage.Write(ctx, "notes/b.md", []byte("new")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if got := versionContents(t, storage, "notes/b.md"); !equalStrings(got, []string{"new"}) {
+		t.Errorf("versions of a new file = %q, want only the current content", got)
+	}
+}
+
+func TestStorage_RestoreVersion(t *testing.T) {
+	storage := createVersionedStorage(t, 10)
+	ctx := context.Background()
+
+	for _, content := range []string{"one", "two"} {
+		if err := storage.Write(ctx, "notes/a.md", []byte(content)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	versions, err := storage.ListVersions(ctx, "notes/a.md")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+
+	if err := storage.RestoreVersion(ctx, "notes/a.md", versions[1].ID); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	data, err := storage.Read(ctx, "notes/a.md")
+	if err != nil || string(data) != "one" {
+		t.Errorf("Read after restore = %q, %v; want %q", data, err, "one")
+	}
+
+	// The replaced content is kept, and the restored snapshot stays
+	got := versionContents(t, storage, "notes/a.md")
+	if want := []string{"one", "two", "one"}; !equalStrings(got, want) {
+		t.Errorf("versions after restore = %q, want %q", got, want)
+	}
+
+	// Restoring the current content changes nothing
+	if err := storage.RestoreVersion(ctx, "notes/a.md", currentVersionID); err != nil {
+		t.Fatalf("Failed to restore current version: %v", err)
+	}
+	if got := versionContents(t, storage, "notes/a.md"); len(got) != 3 {
+		t.Errorf("restoring the current version added a version: %q", got)
+	}
+
+	// Deleted files can be restored
+	if err := storage.Delete(ctx, "notes/a.md"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	versions, err = storage.ListVersions(ctx, "notes/a.md")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if err := storage.RestoreVersion(ctx, "notes/a.md", versions[0].ID); err != nil {
+		t.Fatalf("Failed to restore deleted file: %v", err)
+	}
+	if data, err := storage.Read(ctx, "notes/a.md"); err != nil || string(data) != "one" {
+		t.Errorf("Read after restoring deleted file = %q, %v; want %q", data, err, "one")
+	}
+
+	for _, id := range []string{"missing", "../../a.md", "20240101T000000.000000000Z"} {
+		if err := storage.RestoreVersion(ctx, "notes/a.md", id); err == nil {
+			t.Errorf("RestoreVersion(%q) succeeded, want an error", id)
+		}
+	}
+}
+
+func TestStorage_VersionRetention(t *testing.T) {
+	storage := createVersionedStorage(t, 2)
+	ctx := context.Background()
+
+	for _, content := range []string{"one", "two", "three", "four", "five"} {
+		if err := storage.Write(ctx, "notes/a.md", []byte(content)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	// The two newest snapshots are kept besides the current content
+	got := versionContents(t, storage, "notes/a.md")
+	if want := []string{"five", "four", "three"}; !equalStrings(got, want) {
+		t.Errorf("versions = %q, want %q", got, want)
+	}
+
+	entries, err := os.ReadDir(storage.versionDir("notes/a.md"))
+	if err != nil {
+		t.Fatalf("Failed to read snapshot directory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("snapshot directory has %d entries, want 2", len(entries))
+	}
+}
+
+func TestStorage_VersionsDisabled(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := storage.Write(ctx, "notes/a.md", []byte("content")); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(storage.config.Path, versionsDir)); !os.IsNotExist(err) {
+		t.Errorf("expected no snapshots without a retention, got err %v", err)
+	}
+
+	var versioned types.VersionedBackend = storage
+	if _, err := versioned.ListVersions(ctx, "notes/a.md"); !errors.Is(err, types.ErrVersioningNotSupported) {
+		t.Errorf("ListVersions error = %v, want ErrVersioningNotSupported", err)
+	}
+}
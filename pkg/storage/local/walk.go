@@ -0,0 +1,78 @@
+package local
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Walk calls fn for each file List would return for prefix, in lexical
+// order, as the directory is read. Like List it doesn't descend into
+// subdirectories.
+func (s *Storage) Walk(ctx context.Context, prefix string, fn types.WalkFunc) error {
+	if err := s.checkClosed(); err != nil {
+		return err
+	}
+	if err := checkContext(ctx, "walk", prefix); err != nil {
+		return err
+	}
+
+	searchDir, namePrefix := s.listTarget(prefix)
+
+	// fn's error is kept aside so that WalkDir doesn't act on fs.SkipDir
+	// or fs.SkipAll returned by fn
+	var fnErr error
+	err := filepath.WalkDir(searchDir, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if fullPath == searchDir {
+			return nil
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		if isInternalFile(d.Name()) || !strings.HasPrefix(d.Name(), namePrefix) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		stat, err := d.Info()
+		if os.IsNotExist(err) {
+			// Removed since the directory was read
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(s.config.Path, fullPath)
+		if err != nil {
+			return nil
+		}
+
+		fnErr = fn(relPath, &types.FileInfo{
+			Path:        relPath,
+			Size:        stat.Size(),
+			ModTime:     stat.ModTime().Unix(),
+			ContentType: types.ContentTypeByExtension(relPath),
+		})
+		if fnErr != nil {
+			return fs.SkipAll
+		}
+		return nil
+	})
+
+	switch {
+	case fnErr != nil:
+		return fnErr
+	case err == nil, os.IsNotExist(err):
+		return nil
+	}
+	return types.NewStorageError(s.Type(), "walk", prefix, err, classifyLocalError(err))
+}
@@ -0,0 +1,136 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestStorage_Walk(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	for _, path := range []string{"notes/b.md", "notes/a.md", "notes/archive/old.md", "notes/c.txt", "other.md"} {
+		if err := storage.Write(ctx, path, []byte("content of "+path)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	var walked []string
+	err := storage.Walk(ctx, "notes/", func(path string, info *types.FileInfo) error {
+		walked = append(walked, path)
+		if info.Path != path || info.Size != int64(len("content of "+path)) || info.ModTime == 0 {
+			t.Errorf("info for %s = %+v", path, info)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	// Walk visits what List returns, in order
+	listed, err := storage.List(ctx, "notes/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if got, want := strings.Join(walked, ","), "notes/a.md,notes/b.md,notes/c.txt"; got != want || got != strings.Join(listed, ",") {
+		t.Errorf("Walk visited %s, want %s (List returned %v)", got, want, listed)
+	}
+
+	walked = nil
+	if err := storage.Walk(ctx, "notes/a", func(path string, _ *types.FileInfo) error {
+		walked = append(walked, path)
+		return nil
+	}); err != nil || strings.Join(walked, ",") != "notes/a.md" {
+		t.Errorf("Walk with a name prefix visited %v (%v)", walked, err)
+	}
+
+	if err := storage.Walk(ctx, "missing/", func(path string, _ *types.FileInfo) error {
+		t.Errorf("unexpected file %s", path)
+		return nil
+	}); err != nil {
+		t.Errorf("Walk of a missing directory = %v, want nil", err)
+	}
+}
+
+func TestStorage_ListSkipsInternalFiles(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	if err := os.MkdirAll(storage.getFullPath("notes"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{"Cargo.lock", "note.md", "report.tmp.md", "note.md.tmp.1700000000000000000", ".health_check_1700000000000000000"} {
+		path := "notes/" + name
+		if err := os.WriteFile(storage.getFullPath(path), []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	listed, err := storage.List(ctx, "notes/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var walked []string
+	if err := storage.Walk(ctx, "notes/", func(path string, _ *types.FileInfo) error {
+		walked = append(walked, path)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	want := "notes/Cargo.lock,notes/note.md,notes/report.tmp.md"
+	if got := strings.Join(listed, ","); got != want {
+		t.Errorf("List() = %s, want %s", got, want)
+	}
+	if got := strings.Join(walked, ","); got != want {
+		t.Errorf("Walk visited %s, want %s", got, want)
+	}
+}
+
+func TestStorage_WalkStops(t *testing.T) {
+	storage := createTestStorage(t)
+	defer func() { _ = storage.Close() }() // Ignore close error in test cleanup
+
+	ctx := context.Background()
+	for _, path := range []string{"notes/a.md", "notes/b.md", "notes/c.md"} {
+		if err := storage.Write(ctx, path, []byte(path)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	errStop := errors.New("stop")
+	var walked []string
+	err := storage.Walk(ctx, "notes/", func(path string, _ *types.FileInfo) error {
+		walked = append(walked, path)
+		if path == "notes/b.md" {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("Walk error = %v, want the callback's error", err)
+	}
+	if strings.Join(walked, ",") != "notes/a.md,notes/b.md" {
+		t.Errorf("Walk visited %v after the callback failed", walked)
+	}
+
+	// Errors WalkDir treats specially are still returned as they are
+	err = storage.Walk(ctx, "notes/", func(string, *types.FileInfo) error { return fs.SkipAll })
+	if !errors.Is(err, fs.SkipAll) {
+		t.Errorf("Walk error = %v, want fs.SkipAll", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := storage.Walk(cancelled, "notes/", func(string, *types.FileInfo) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Walk with a cancelled context = %v, want context.Canceled", err)
+	}
+}
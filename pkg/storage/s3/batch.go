@@ -0,0 +1,119 @@
+package s3
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+const (
+	// batchReadConcurrency is how many GETs a batch read has in flight
+	batchReadConcurrency = 16
+
+	// maxDeleteObjects is the most keys one DeleteObjects request accepts
+	maxDeleteObjects = 1000
+)
+
+// BatchRead reads paths with concurrent GETs. Objects that can't be read
+// are reported in a *types.BatchError.
+func (s *Storage) BatchRead(ctx context.Context, paths []string) (map[string][]byte, error) {
+	work := make(chan string)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		files = make(map[string][]byte, len(paths))
+		errs  = make(map[string]error)
+	)
+	for range min(batchReadConcurrency, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				data, err := s.Read(ctx, path)
+				mu.Lock()
+				if err != nil {
+					errs[path] = err
+				} else {
+					files[path] = data
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range uniquePaths(paths) {
+		work <- path
+	}
+	close(work)
+	wg.Wait()
+
+	return files, types.BatchErrorFrom("read", errs)
+}
+
+// BatchDelete removes paths with DeleteObjects, up to maxDeleteObjects keys
+// per request. As with Delete, missing objects are not an error. Keys S3
+// fails to delete are reported in a *types.BatchError.
+func (s *Storage) BatchDelete(ctx context.Context, paths []string) error {
+	paths = uniquePaths(paths)
+	errs := make(map[string]error)
+
+	for start := 0; start < len(paths); start += maxDeleteObjects {
+		chunk := paths[start:min(start+maxDeleteObjects, len(paths))]
+
+		pathsByKey := make(map[string]string, len(chunk))
+		objects := make([]s3types.ObjectIdentifier, len(chunk))
+		for i, path := range chunk {
+			key := s.buildKey(path)
+			pathsByKey[key] = path
+			objects[i] = s3types.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		output, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.config.Bucket),
+			Delete: &s3types.Delete{
+				Objects: objects,
+				// Only report the keys that failed
+				Quiet: aws.Bool(true),
+			},
+		})
+		if err != nil {
+			for _, path := range chunk {
+				errs[path] = s.handleError("delete", path, err)
+			}
+			continue
+		}
+
+		for _, failed := range output.Errors {
+			path, ok := pathsByKey[aws.ToString(failed.Key)]
+			if !ok {
+				continue
+			}
+			apiErr := &smithy.GenericAPIError{
+				Code:    aws.ToString(failed.Code),
+				Message: aws.ToString(failed.Message),
+			}
+			errs[path] = s.handleError("delete", path, apiErr)
+		}
+	}
+
+	return types.BatchErrorFrom("delete", errs)
+}
+
+// uniquePaths returns paths without repeats, in order
+func uniquePaths(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+	unique := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	return unique
+}
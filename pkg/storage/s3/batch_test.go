@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// batchServer fakes GETs and DeleteObjects for the vault prefix. Reads of
+// denied keys fail with AccessDenied and deletes of them are reported as
+// failed; other missing keys are NoSuchKey.
+func batchServer(t *testing.T, objects map[string]string, denied map[string]bool, deleteRequests *[]int) http.Handler {
+	t.Helper()
+
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/test-bucket/")
+		w.Header().Set("Content-Type", "application/xml")
+
+		switch {
+		case r.Method == http.MethodGet:
+			mu.Lock()
+			body, ok := objects[key]
+			mu.Unlock()
+			switch {
+			case denied[key]:
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>"))
+			case !ok:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			default:
+				w.Header().Del("Content-Type")
+				_, _ = w.Write([]byte(body))
+			}
+
+		case r.Method == http.MethodPost && r.URL.Query().Has("delete"):
+			var req struct {
+				Objects []struct {
+					Key string `xml:"Key"`
+				} `xml:"Object"`
+				Quiet bool `xml:"Quiet"`
+			}
+			require.NoError(t, xml.NewDecoder(r.Body).Decode(&req))
+			assert.True(t, req.Quiet)
+
+			var b strings.Builder
+			b.WriteString("<DeleteResult>")
+			mu.Lock()
+			*deleteRequests = append(*deleteRequests, len(req.Objects))
+			for _, obj := range req.Objects {
+				if denied[obj.Key] {
+					fmt.Fprintf(&b, "<Error><Key>%s</Key><Code>AccessDenied</Code><Message>Access Denied</Message></Error>", obj.Key)
+					continue
+				}
+				delete(objects, obj.Key)
+			}
+			mu.Unlock()
+			b.WriteString("</DeleteResult>")
+			_, _ = w.Write([]byte(b.String()))
+
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	})
+}
+
+func TestBatchRead(t *testing.T) {
+	objects := map[string]string{}
+	var paths []string
+	for i := range 40 {
+		path := fmt.Sprintf("notes/%02d.md", i)
+		objects["vault/"+path] = "content of " + path
+		paths = append(paths, path)
+	}
+	objects["vault/notes/secret.md"] = "hidden"
+	denied := map[string]bool{"vault/notes/secret.md": true}
+
+	storage := newMultipartTestStorage(t, batchServer(t, objects, denied, &[]int{}))
+
+	files, err := storage.BatchRead(context.Background(), append(paths, "notes/secret.md", "notes/missing.md"))
+	require.Len(t, files, 40)
+	for _, path := range paths {
+		assert.Equal(t, "content of "+path, string(files[path]))
+	}
+
+	// Each failure is reported for its own path
+	var batchErr *types.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []string{"notes/missing.md", "notes/secret.md"}, batchErr.Paths())
+
+	var storageErr *types.StorageError
+	require.ErrorAs(t, batchErr.Errors["notes/secret.md"], &storageErr)
+	assert.Equal(t, "read", storageErr.Operation)
+	assert.Contains(t, storageErr.Error(), "AccessDenied")
+	assert.Contains(t, batchErr.Errors["notes/missing.md"].Error(), "NoSuchKey")
+
+	files, err = storage.BatchRead(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestBatchDelete(t *testing.T) {
+	objects := map[string]string{}
+	var paths []string
+	for i := range 1500 {
+		path := fmt.Sprintf("notes/%04d.md", i)
+		objects["vault/"+path] = "x"
+		paths = append(paths, path)
+	}
+	denied := map[string]bool{"vault/notes/0007.md": true, "vault/notes/1234.md": true}
+
+	var requests []int
+	storage := newMultipartTestStorage(t, batchServer(t, objects, denied, &requests))
+
+	// Repeated paths are only sent once
+	err := storage.BatchDelete(context.Background(), append(paths, "notes/0001.md"))
+
+	// Keys are sent at most 1000 to a request
+	assert.Equal(t, []int{1000, 500}, requests)
+
+	var batchErr *types.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, "delete", batchErr.Operation)
+	assert.Equal(t, []string{"notes/0007.md", "notes/1234.md"}, batchErr.Paths())
+	assert.Contains(t, batchErr.Errors["notes/0007.md"].Error(), "Access Denied")
+
+	// Only the denied objects are left
+	assert.Len(t, objects, 2)
+	assert.Contains(t, objects, "vault/notes/1234.md")
+}
+
+func TestBatchDelete_RequestFails(t *testing.T) {
+	storage := newMultipartTestStorage(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>"))
+	}))
+
+	// A failed request fails every path it carried
+	err := storage.BatchDelete(context.Background(), []string{"a.md", "b.md"})
+	failed := types.PathErrors(err, []string{"a.md", "b.md"})
+	require.Len(t, failed, 2)
+
+	var storageErr *types.StorageError
+	require.True(t, errors.As(failed["b.md"], &storageErr))
+	assert.Equal(t, "b.md", storageErr.Path)
+	assert.False(t, storageErr.IsRetryable())
+}
@@ -0,0 +1,68 @@
+package s3
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// roleSessionName names the sessions of roles assumed for S3 access, so
+// that they can be told apart in CloudTrail
+const roleSessionName = "kbvault"
+
+// credentialSource returns where cfg takes its credentials from. Without
+// an explicit source, static keys are used when set and the default
+// chain otherwise.
+func credentialSource(cfg types.S3StorageConfig) (types.S3CredentialSource, error) {
+	source, err := types.ParseS3CredentialSource(cfg.CredentialSource)
+	if err != nil {
+		return source, err
+	}
+	if source.Kind == "" {
+		source.Kind = types.S3CredentialsDefault
+		if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+			source.Kind = types.S3CredentialsStatic
+		}
+	}
+	if source.Kind == types.S3CredentialsStatic && (cfg.AccessKeyID == "" || cfg.SecretAccessKey == "") {
+		return source, fmt.Errorf("static credentials need an access key ID and secret access key")
+	}
+	return source, nil
+}
+
+// credentialOptions returns the config load options for the credentials
+// source uses. Roles are assumed with the credentials those options
+// resolve; see assumeRole.
+func credentialOptions(cfg types.S3StorageConfig, source types.S3CredentialSource) []func(*config.LoadOptions) error {
+	switch source.Kind {
+	case types.S3CredentialsStatic:
+		creds := credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
+		return []func(*config.LoadOptions) error{config.WithCredentialsProvider(creds)}
+	case types.S3CredentialsProfile:
+		return []func(*config.LoadOptions) error{config.WithSharedConfigProfile(source.Name)}
+	case types.S3CredentialsRole:
+		// The role is assumed with static keys when they are set and the
+		// default chain otherwise
+		if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+			return credentialOptions(cfg, types.S3CredentialSource{Kind: types.S3CredentialsStatic})
+		}
+	}
+	return nil
+}
+
+// assumeRole replaces the credentials of awsConfig with those of the role
+// arn, assumed through STS with the original credentials and refreshed
+// before they expire
+func assumeRole(awsConfig aws.Config, arn string) aws.Config {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsConfig), arn, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = roleSessionName
+	})
+	awsConfig.Credentials = aws.NewCredentialsCache(provider)
+	return awsConfig
+}
@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// useSharedConfig points the SDK at a shared config file with a "work"
+// profile and no other credentials
+func useSharedConfig(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(configFile, []byte(`[profile work]
+aws_access_key_id = AKIDWORK
+aws_secret_access_key = work-secret
+`), 0o600))
+
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+}
+
+func TestCredentialSource(t *testing.T) {
+	keys := types.S3StorageConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+
+	tests := []struct {
+		name    string
+		config  types.S3StorageConfig
+		want    types.S3CredentialSource
+		wantErr string
+	}{
+		{name: "keys without a source", config: keys, want: types.S3CredentialSource{Kind: types.S3CredentialsStatic}},
+		{name: "nothing configured", want: types.S3CredentialSource{Kind: types.S3CredentialsDefault}},
+		{
+			name:   "profile",
+			config: types.S3StorageConfig{CredentialSource: "profile:work"},
+			want:   types.S3CredentialSource{Kind: types.S3CredentialsProfile, Name: "work"},
+		},
+		{
+			name:   "role",
+			config: types.S3StorageConfig{CredentialSource: "role:arn:aws:iam::123456789012:role/kb"},
+			want:   types.S3CredentialSource{Kind: types.S3CredentialsRole, Name: "arn:aws:iam::123456789012:role/kb"},
+		},
+		{name: "static without keys", config: types.S3StorageConfig{CredentialSource: "static"}, wantErr: "access key ID"},
+		{name: "unknown source", config: types.S3StorageConfig{CredentialSource: "imds"}, wantErr: "credential_source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := credentialSource(tt.config)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCreateAWSConfig_Credentials(t *testing.T) {
+	useSharedConfig(t)
+	ctx := context.Background()
+	base := types.S3StorageConfig{Bucket: "test-bucket", Region: "us-east-1"}
+
+	retrieve := func(t *testing.T, cfg types.S3StorageConfig) aws.Credentials {
+		t.Helper()
+		awsConfig, err := createAWSConfig(cfg)
+		require.NoError(t, err)
+		creds, err := awsConfig.Credentials.Retrieve(ctx)
+		require.NoError(t, err)
+		return creds
+	}
+
+	t.Run("static", func(t *testing.T) {
+		cfg := base
+		cfg.CredentialSource = "static"
+		cfg.AccessKeyID, cfg.SecretAccessKey = "AKIDSTATIC", "static-secret"
+		assert.Equal(t, "AKIDSTATIC", retrieve(t, cfg).AccessKeyID)
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		cfg := base
+		cfg.CredentialSource = "profile:work"
+		// Keys are ignored when another source is chosen
+		cfg.AccessKeyID, cfg.SecretAccessKey = "AKIDSTATIC", "static-secret"
+		creds := retrieve(t, cfg)
+		assert.Equal(t, "AKIDWORK", creds.AccessKeyID)
+		assert.Equal(t, "work-secret", creds.SecretAccessKey)
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		cfg := base
+		cfg.CredentialSource = "profile:home"
+		_, err := createAWSConfig(cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("role", func(t *testing.T) {
+		cfg := base
+		cfg.CredentialSource = "role:arn:aws:iam::123456789012:role/kb"
+		awsConfig, err := createAWSConfig(cfg)
+		require.NoError(t, err)
+
+		cache, ok := awsConfig.Credentials.(*aws.CredentialsCache)
+		require.True(t, ok, "role credentials are cached, got %T", awsConfig.Credentials)
+		assert.True(t, cache.IsCredentialsProvider(&stscreds.AssumeRoleProvider{}))
+	})
+}
+
+func TestNewStorage_InvalidCredentialSource(t *testing.T) {
+	_, err := NewStorage(types.S3StorageConfig{Bucket: "b", Region: "us-east-1", CredentialSource: "static"})
+	assert.ErrorContains(t, err, "static credentials")
+
+	_, err = NewStorage(types.S3StorageConfig{Bucket: "b", Region: "us-east-1", CredentialSource: "role:"})
+	assert.ErrorContains(t, err, "role ARN cannot be empty")
+}
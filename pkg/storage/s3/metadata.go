@@ -0,0 +1,143 @@
+package s3
+
+import (
+	"mime"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+)
+
+// Object metadata keys set for notes. S3 sends them as x-amz-meta-<key>.
+const (
+	MetadataTitle = "title"
+	MetadataTags  = "tags"
+	MetadataType  = "type"
+)
+
+const (
+	// maxMetadataSize is S3's limit on user metadata: the total bytes of
+	// all keys and values
+	maxMetadataSize = 2048
+
+	// maxMetadataValueLen caps a single value before encoding so one long
+	// title can't crowd out the rest
+	maxMetadataValueLen = 512
+)
+
+// NoteMetadata returns the object metadata recorded for a note: the
+// title, comma-separated tags and type from its frontmatter. Files that
+// aren't notes, or have no frontmatter, get none.
+func NoteMetadata(path string, data []byte) map[string]string {
+	if !strings.HasSuffix(path, ".md") {
+		return nil
+	}
+
+	front, _, ok := note.SplitFrontmatter(string(data))
+	if !ok {
+		return nil
+	}
+	fm, err := note.ParseFrontmatter(front)
+	if err != nil {
+		return nil
+	}
+
+	meta := make(map[string]string)
+	if fm.Title != "" {
+		meta[MetadataTitle] = fm.Title
+	}
+	if len(fm.Tags) > 0 {
+		meta[MetadataTags] = strings.Join(fm.Tags, ",")
+	}
+	if fm.Type != "" {
+		meta[MetadataType] = fm.Type
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// sanitizeMetadata makes metadata safe to send as HTTP headers. Keys are
+// lowercased and reduced to letters, digits and dashes; values lose
+// control characters, are truncated, and are RFC 2047 encoded when they
+// contain non-ASCII text. Entries that would take the total past S3's
+// limit are dropped, in key order.
+func sanitizeMetadata(meta map[string]string) map[string]string {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sanitized := make(map[string]string)
+	size := 0
+	for _, key := range keys {
+		name := metadataKey(key)
+		value := metadataValue(meta[key])
+		if name == "" || value == "" {
+			continue
+		}
+		if _, dup := sanitized[name]; dup || size+len(name)+len(value) > maxMetadataSize {
+			continue
+		}
+		sanitized[name] = value
+		size += len(name) + len(value)
+	}
+
+	if len(sanitized) == 0 {
+		return nil
+	}
+	return sanitized
+}
+
+// metadataKey turns key into a valid header name suffix
+func metadataKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, key)
+	return strings.Trim(key, "-")
+}
+
+// metadataValue makes value safe to send as a header value
+func metadataValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+
+	if len(value) > maxMetadataValueLen {
+		cut := maxMetadataValueLen
+		for cut > 0 && !utf8.RuneStart(value[cut]) {
+			cut--
+		}
+		value = value[:cut]
+	}
+
+	for i := 0; i < len(value); i++ {
+		if value[i] >= utf8.RuneSelf {
+			return mime.QEncoding.Encode("utf-8", value)
+		}
+	}
+	return value
+}
+
+// decodeMetadataValue reverses the encoding applied by metadataValue;
+// values that weren't encoded are returned unchanged
+func decodeMetadataValue(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3/s3test"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+const metadataTestNote = `---
+id: 01ABC
+title: Café notes
+tags:
+  - go
+  - s3
+type: reference
+---
+
+# Café notes
+`
+
+func TestNoteMetadata(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		MetadataTitle: "Café notes",
+		MetadataTags:  "go,s3",
+		MetadataType:  "reference",
+	}, NoteMetadata("notes/01ABC.md", []byte(metadataTestNote)))
+
+	assert.Nil(t, NoteMetadata("notes/01ABC.md", []byte("# No frontmatter\n")))
+	assert.Nil(t, NoteMetadata("attachments/a.png", []byte(metadataTestNote)))
+}
+
+func TestSanitizeMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		meta map[string]string
+		want map[string]string
+	}{
+		{"nil", nil, nil},
+		{"plain", map[string]string{"title": "Hello"}, map[string]string{"title": "Hello"}},
+		{"key characters", map[string]string{"Note Title_1": "x"}, map[string]string{"note-title-1": "x"}},
+		{"invalid key dropped", map[string]string{"!!": "x"}, nil},
+		{"control characters", map[string]string{"title": "a\r\nb\x00c"}, map[string]string{"title": "abc"}},
+		{"non-ascii encoded", map[string]string{"title": "Café"}, map[string]string{"title": "=?utf-8?q?Caf=C3=A9?="}},
+		{"empty value dropped", map[string]string{"type": "  "}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sanitizeMetadata(tt.meta))
+		})
+	}
+}
+
+func TestSanitizeMetadata_Limits(t *testing.T) {
+	long := strings.Repeat("é", maxMetadataValueLen)
+	got := sanitizeMetadata(map[string]string{"title": long})
+	assert.Equal(t, strings.Repeat("é", maxMetadataValueLen/2), decodeMetadataValue(got["title"]))
+
+	// Entries past the total limit are dropped, the rest are kept
+	meta := map[string]string{
+		"a": strings.Repeat("x", maxMetadataValueLen),
+		"b": strings.Repeat("x", maxMetadataValueLen),
+		"c": strings.Repeat("x", maxMetadataValueLen),
+		"d": strings.Repeat("x", maxMetadataValueLen),
+		"e": "small",
+	}
+	got = sanitizeMetadata(meta)
+	assert.Len(t, got, 4)
+	assert.NotContains(t, got, "d")
+	assert.Equal(t, "small", got["e"])
+
+	size := 0
+	for k, v := range got {
+		size += len(k) + len(v)
+	}
+	assert.LessOrEqual(t, size, maxMetadataSize)
+}
+
+func TestPutObjectInput(t *testing.T) {
+	storage := &Storage{config: types.S3StorageConfig{
+		Bucket:               "test-bucket",
+		Prefix:               "vault",
+		ServerSideEncryption: "aws:kms",
+		KMSKeyID:             "key-1",
+		StorageClass:         "STANDARD_IA",
+		VerifyChecksums:      true,
+	}}
+
+	data := []byte(metadataTestNote)
+	input, digest := storage.putObjectInput("notes/01ABC.md", data, NoteMetadata("notes/01ABC.md", data))
+
+	assert.Equal(t, "test-bucket", aws.ToString(input.Bucket))
+	assert.Equal(t, "vault/notes/01ABC.md", aws.ToString(input.Key))
+	assert.Equal(t, map[string]string{
+		"title": "=?utf-8?q?Caf=C3=A9_notes?=",
+		"tags":  "go,s3",
+		"type":  "reference",
+	}, input.Metadata)
+	assert.NotEmpty(t, aws.ToString(input.ContentMD5))
+	assert.Len(t, digest, 16)
+	assert.Equal(t, s3types.ServerSideEncryptionAwsKms, input.ServerSideEncryption)
+	assert.Equal(t, "key-1", aws.ToString(input.SSEKMSKeyId))
+	assert.Equal(t, s3types.StorageClassStandardIa, input.StorageClass)
+
+	body, err := io.ReadAll(input.Body)
+	require.NoError(t, err)
+	assert.Equal(t, data, body)
+
+	input, digest = (&Storage{config: types.S3StorageConfig{Bucket: "b"}}).putObjectInput("a.txt", []byte("a"), nil)
+	assert.Nil(t, input.Metadata)
+	assert.Nil(t, input.ContentMD5)
+	assert.Nil(t, digest)
+}
+
+func TestWriteAndStatMetadata(t *testing.T) {
+	headers := make(http.Header)
+	config := s3test.NewFakeS3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			_, _ = io.Copy(io.Discard, r.Body)
+			for name, values := range r.Header {
+				if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+					headers[name] = values
+				}
+			}
+			w.WriteHeader(http.StatusOK)
+		case http.MethodHead:
+			for name, values := range headers {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Content-Length", "0")
+			w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	storage, err := NewStorage(config)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, storage.Write(ctx, "notes/01ABC.md", []byte(metadataTestNote)))
+	assert.Equal(t, "go,s3", headers.Get("X-Amz-Meta-Tags"))
+	assert.Equal(t, "=?utf-8?q?Caf=C3=A9_notes?=", headers.Get("X-Amz-Meta-Title"))
+
+	info, err := storage.Stat(ctx, "notes/01ABC.md")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"title": "Café notes",
+		"tags":  "go,s3",
+		"type":  "reference",
+	}, info.Metadata)
+}
@@ -0,0 +1,295 @@
+//go:build integration
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// MinIO runs in a container for the duration of the tests; the image is
+// pinned so a MinIO release can't change results between runs
+const (
+	minioImage    = "minio/minio:RELEASE.2025-04-22T22-12-26Z"
+	minioUser     = "kbvault"
+	minioPassword = "kbvault-secret"
+	minioPort     = "9000/tcp"
+)
+
+// startMinIO starts a MinIO container and returns its endpoint URL. The
+// test is skipped when Docker isn't available.
+func startMinIO(t *testing.T) string {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	ctr, err := testcontainers.Run(ctx, minioImage,
+		testcontainers.WithCmd("server", "/data"),
+		testcontainers.WithExposedPorts(minioPort),
+		testcontainers.WithEnv(map[string]string{
+			"MINIO_ROOT_USER":     minioUser,
+			"MINIO_ROOT_PASSWORD": minioPassword,
+		}),
+		testcontainers.WithWaitStrategyAndDeadline(time.Minute,
+			wait.ForHTTP("/minio/health/live").WithPort(minioPort)),
+	)
+	testcontainers.CleanupContainer(t, ctr)
+	require.NoError(t, err)
+
+	endpoint, err := ctr.PortEndpoint(ctx, minioPort, "http")
+	require.NoError(t, err)
+	return endpoint
+}
+
+// newMinIOStorage returns a Storage for a new bucket on the MinIO server
+// at endpoint, keeping objects under prefix
+func newMinIOStorage(t *testing.T, endpoint, bucket, prefix string) *Storage {
+	t.Helper()
+
+	s, err := NewStorage(types.S3StorageConfig{
+		Bucket:          bucket,
+		Region:          "us-east-1",
+		Endpoint:        endpoint,
+		AccessKeyID:     minioUser,
+		SecretAccessKey: minioPassword,
+		PathStyle:       true,
+		Prefix:          prefix,
+		VerifyChecksums: true,
+		RequestTimeout:  30,
+	})
+	require.NoError(t, err)
+
+	_, err = s.client.CreateBucket(context.Background(), &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+	require.NoError(t, err)
+	return s
+}
+
+func TestMinIO(t *testing.T) {
+	endpoint := startMinIO(t)
+
+	for _, tc := range []struct {
+		name   string
+		prefix string
+	}{
+		{"NoPrefix", ""},
+		{"Prefix", "vaults/work"},
+		{"PrefixWithSlash", "vaults/home/"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bucket := "kbvault-" + strings.ToLower(tc.name)
+			s := newMinIOStorage(t, endpoint, bucket, tc.prefix)
+			testStorageMatrix(t, s)
+			testObjectKeys(t, s, tc.prefix)
+		})
+	}
+
+	t.Run("Pagination", func(t *testing.T) {
+		testListPagination(t, newMinIOStorage(t, endpoint, "kbvault-pagination", "p"))
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		s := newMinIOStorage(t, endpoint, "kbvault-versions", "v")
+		_, err := s.client.PutBucketVersioning(context.Background(), &s3.PutBucketVersioningInput{
+			Bucket: aws.String("kbvault-versions"),
+			VersioningConfiguration: &s3types.VersioningConfiguration{
+				Status: s3types.BucketVersioningStatusEnabled,
+			},
+		})
+		require.NoError(t, err)
+		testVersions(t, s)
+	})
+
+	t.Run("MissingBucket", func(t *testing.T) {
+		s, err := NewStorage(types.S3StorageConfig{
+			Bucket:          "kbvault-missing",
+			Region:          "us-east-1",
+			Endpoint:        endpoint,
+			AccessKeyID:     minioUser,
+			SecretAccessKey: minioPassword,
+			PathStyle:       true,
+		})
+		require.NoError(t, err)
+
+		err = s.Health(context.Background())
+		var storageErr *types.StorageError
+		require.ErrorAs(t, err, &storageErr)
+		assert.Equal(t, "health", storageErr.Operation)
+		assert.False(t, storageErr.Retryable)
+	})
+}
+
+// testStorageMatrix runs every StorageBackend operation against s
+func testStorageMatrix(t *testing.T, s *Storage) {
+	ctx := context.Background()
+	content := []byte("---\ntitle: Integration\ntags: [s3]\n---\n\nHello from MinIO\n")
+
+	require.NoError(t, s.Health(ctx))
+
+	// Write, Read, Exists
+	require.NoError(t, s.Write(ctx, "notes/a.md", content))
+	got, err := s.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	exists, err := s.Exists(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = s.Exists(ctx, "notes/missing.md")
+	require.NoError(t, err, "a missing object is not an error")
+	assert.False(t, exists)
+
+	// Stat, including the metadata recorded on write
+	info, err := s.Stat(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "notes/a.md", info.Path)
+	assert.Equal(t, int64(len(content)), info.Size)
+	assert.NotEmpty(t, info.ETag)
+	assert.WithinDuration(t, time.Now(), time.Unix(info.ModTime, 0), 5*time.Minute)
+	assert.Equal(t, "Integration", info.Metadata[MetadataTitle])
+
+	// Errors for missing objects
+	_, err = s.Read(ctx, "notes/missing.md")
+	assert.True(t, types.IsNotFound(err), "read error: %v", err)
+	_, err = s.Stat(ctx, "notes/missing.md")
+	assert.True(t, types.IsNotFound(err), "stat error: %v", err)
+
+	// Streams
+	large := bytes.Repeat([]byte("0123456789abcdef"), 1<<16) // 1 MiB
+	require.NoError(t, s.WriteStream(ctx, "attachments/large.bin", bytes.NewReader(large)))
+	r, err := s.ReadStream(ctx, "attachments/large.bin")
+	require.NoError(t, err)
+	streamed, err := io.ReadAll(r)
+	require.NoError(t, r.Close())
+	require.NoError(t, err)
+	assert.Equal(t, large, streamed)
+
+	// Copy and Move
+	require.NoError(t, s.Copy(ctx, "notes/a.md", "notes/b.md"))
+	got, err = s.Read(ctx, "notes/b.md")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	require.NoError(t, s.Move(ctx, "notes/b.md", "archive/b.md"))
+	exists, err = s.Exists(ctx, "notes/b.md")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	got, err = s.Read(ctx, "archive/b.md")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	assert.Error(t, s.Copy(ctx, "notes/missing.md", "notes/c.md"))
+
+	// List returns paths relative to the configured prefix
+	all, err := s.List(ctx, "")
+	require.NoError(t, err)
+	sort.Strings(all)
+	assert.Equal(t, []string{"archive/b.md", "attachments/large.bin", "notes/a.md"}, all)
+
+	notes, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/a.md"}, notes)
+
+	// Delete
+	require.NoError(t, s.Delete(ctx, "notes/a.md"))
+	exists, err = s.Exists(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// testObjectKeys checks that objects are stored under the prefix
+func testObjectKeys(t *testing.T, s *Storage, prefix string) {
+	ctx := context.Background()
+	require.NoError(t, s.Write(ctx, "keys/k.md", []byte("key")))
+
+	out, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.config.Bucket),
+		Prefix: aws.String(s.buildKey("keys/")),
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Contents, 1)
+
+	want := "keys/k.md"
+	if prefix != "" {
+		want = strings.TrimSuffix(prefix, "/") + "/keys/k.md"
+	}
+	assert.Equal(t, want, aws.ToString(out.Contents[0].Key))
+}
+
+// testListPagination lists more objects than fit in one ListObjectsV2 page
+func testListPagination(t *testing.T, s *Storage) {
+	ctx := context.Background()
+
+	const count = 1105 // S3 and MinIO return at most 1000 keys per page
+	want := make([]string, count)
+	for i := range want {
+		want[i] = fmt.Sprintf("notes/%04d.md", i)
+		require.NoError(t, s.Write(ctx, want[i], []byte("x")))
+	}
+
+	got, err := s.List(ctx, "notes/")
+	require.NoError(t, err)
+	sort.Strings(got)
+	assert.Equal(t, want, got)
+}
+
+// testVersions lists, reads and restores the versions of an object in a
+// versioned bucket, including a deleted one
+func testVersions(t *testing.T, s *Storage) {
+	ctx := context.Background()
+
+	for _, content := range []string{"one", "two", "three"} {
+		require.NoError(t, s.Write(ctx, "notes/a.md", []byte(content)))
+	}
+	require.NoError(t, s.Write(ctx, "notes/a.md.bak", []byte("other key")))
+
+	versions, err := s.ListVersions(ctx, "notes/a.md")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.True(t, versions[0].Current)
+	assert.Equal(t, int64(5), versions[0].Size)
+
+	for i, want := range []string{"three", "two", "one"} {
+		data, err := s.ReadVersion(ctx, "notes/a.md", versions[i].ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+	}
+
+	require.NoError(t, s.Delete(ctx, "notes/a.md"))
+	versions, err = s.ListVersions(ctx, "notes/a.md")
+	require.NoError(t, err)
+	require.Len(t, versions, 4)
+	assert.True(t, versions[0].Deleted)
+	assert.True(t, versions[0].Current)
+
+	// Restoring a version from before the delete brings the object back
+	require.NoError(t, s.RestoreVersion(ctx, "notes/a.md", versions[3].ID))
+	data, err := s.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+
+	versions, err = s.ListVersions(ctx, "notes/a.md")
+	require.NoError(t, err)
+	require.Len(t, versions, 5)
+	assert.False(t, versions[0].Deleted)
+
+	versions, err = s.ListVersions(ctx, "notes/missing.md")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
@@ -0,0 +1,68 @@
+package s3
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// MultipartUpload is a multipart upload that was started but never
+// completed or aborted. Its parts are billed until it is aborted.
+type MultipartUpload struct {
+	Key       string
+	UploadID  string
+	Initiated time.Time
+}
+
+// ListMultipartUploads returns the incomplete multipart uploads under the
+// configured prefix that were started before cutoff
+func (s *Storage) ListMultipartUploads(ctx context.Context, cutoff time.Time) ([]MultipartUpload, error) {
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.config.Bucket),
+	}
+	if prefix := s.buildKey(""); prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	var uploads []MultipartUpload
+	for {
+		output, err := s.client.ListMultipartUploads(ctx, input)
+		if err != nil {
+			return nil, s.handleError("list_multipart_uploads", "", err)
+		}
+
+		for _, upload := range output.Uploads {
+			initiated := aws.ToTime(upload.Initiated)
+			if !initiated.Before(cutoff) {
+				continue
+			}
+			uploads = append(uploads, MultipartUpload{
+				Key:       aws.ToString(upload.Key),
+				UploadID:  aws.ToString(upload.UploadId),
+				Initiated: initiated,
+			})
+		}
+
+		if !aws.ToBool(output.IsTruncated) {
+			return uploads, nil
+		}
+		input.KeyMarker = output.NextKeyMarker
+		input.UploadIdMarker = output.NextUploadIdMarker
+	}
+}
+
+// AbortMultipartUpload aborts an incomplete multipart upload, deleting the
+// parts uploaded so far
+func (s *Storage) AbortMultipartUpload(ctx context.Context, upload MultipartUpload) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.config.Bucket),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.UploadID),
+	})
+	if err != nil {
+		return s.handleError("abort_multipart_upload", upload.Key, err)
+	}
+	return nil
+}
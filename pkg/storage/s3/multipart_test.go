@@ -0,0 +1,116 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3/s3test"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestUploaderOptions(t *testing.T) {
+	u := &manager.Uploader{
+		PartSize:          manager.DefaultUploadPartSize,
+		Concurrency:       manager.DefaultUploadConcurrency,
+		LeavePartsOnError: true,
+	}
+	uploaderOptions(types.S3StorageConfig{UploadPartSizeMB: 16, UploadConcurrency: 10})(u)
+	assert.Equal(t, int64(16*1024*1024), u.PartSize)
+	assert.Equal(t, 10, u.Concurrency)
+	assert.False(t, u.LeavePartsOnError, "failed uploads must be aborted")
+
+	// Unset values keep the SDK defaults
+	u = &manager.Uploader{PartSize: manager.DefaultUploadPartSize, Concurrency: manager.DefaultUploadConcurrency}
+	uploaderOptions(types.S3StorageConfig{})(u)
+	assert.Equal(t, manager.DefaultUploadPartSize, u.PartSize)
+	assert.Equal(t, manager.DefaultUploadConcurrency, u.Concurrency)
+
+	// NewStorage wires the options into its uploader
+	storage, err := NewStorage(types.S3StorageConfig{
+		Bucket:            "test-bucket",
+		Region:            "us-east-1",
+		AccessKeyID:       "test",
+		SecretAccessKey:   "test",
+		UploadPartSizeMB:  8,
+		UploadConcurrency: 2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(8*1024*1024), storage.uploader.PartSize)
+	assert.Equal(t, 2, storage.uploader.Concurrency)
+	assert.False(t, storage.uploader.LeavePartsOnError)
+}
+
+// multipartServer fakes the ListMultipartUploads and AbortMultipartUpload
+// calls, returning uploads in two pages
+func multipartServer(t *testing.T, aborted *[]string) http.Handler {
+	t.Helper()
+
+	pages := []string{
+		`<ListMultipartUploadsResult><Bucket>test-bucket</Bucket><IsTruncated>true</IsTruncated>
+<NextKeyMarker>vault/notes/b.md</NextKeyMarker><NextUploadIdMarker>u2</NextUploadIdMarker>
+<Upload><Key>vault/notes/a.md</Key><UploadId>u1</UploadId><Initiated>2024-01-01T00:00:00.000Z</Initiated></Upload>
+<Upload><Key>vault/notes/b.md</Key><UploadId>u2</UploadId><Initiated>%s</Initiated></Upload>
+</ListMultipartUploadsResult>`,
+		`<ListMultipartUploadsResult><Bucket>test-bucket</Bucket><IsTruncated>false</IsTruncated>
+<Upload><Key>vault/big.bin</Key><UploadId>u3</UploadId><Initiated>2024-02-01T00:00:00.000Z</Initiated></Upload>
+</ListMultipartUploadsResult>`,
+	}
+	recent := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && query.Has("uploads"):
+			assert.Equal(t, "vault/", query.Get("prefix"))
+			page := pages[0]
+			if query.Get("key-marker") != "" {
+				assert.Equal(t, "u2", query.Get("upload-id-marker"))
+				page = pages[1]
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = fmt.Fprintf(w, page, recent)
+		case r.Method == http.MethodDelete && query.Has("uploadId"):
+			*aborted = append(*aborted, r.URL.Path+"?"+query.Get("uploadId"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+}
+
+func newMultipartTestStorage(t *testing.T, handler http.Handler) *Storage {
+	t.Helper()
+
+	config := s3test.NewFakeS3(t, handler)
+	config.Prefix = "vault"
+	storage, err := NewStorage(config)
+	require.NoError(t, err)
+	return storage
+}
+
+func TestListAndAbortMultipartUploads(t *testing.T) {
+	var aborted []string
+	storage := newMultipartTestStorage(t, multipartServer(t, &aborted))
+	ctx := context.Background()
+
+	uploads, err := storage.ListMultipartUploads(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, uploads, 2, "the recent upload is skipped")
+	assert.Equal(t, MultipartUpload{
+		Key:       "vault/notes/a.md",
+		UploadID:  "u1",
+		Initiated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, uploads[0])
+	assert.Equal(t, "vault/big.bin", uploads[1].Key)
+
+	require.NoError(t, storage.AbortMultipartUpload(ctx, uploads[0]))
+	assert.Equal(t, []string{"/test-bucket/vault/notes/a.md?u1"}, aborted)
+}
@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// newRetryer builds the SDK retryer for cfg. Unset fields keep the SDK
+// defaults: 3 attempts, a 20 second maximum backoff and the SDK's jittered
+// exponential backoff.
+func newRetryer(cfg types.S3StorageConfig) aws.Retryer {
+	standard := func(o *retry.StandardOptions) {
+		if cfg.RetryAttempts > 0 {
+			o.MaxAttempts = cfg.RetryAttempts
+		}
+		if cfg.RetryMaxBackoff > 0 {
+			o.MaxBackoff = time.Duration(cfg.RetryMaxBackoff) * time.Millisecond
+		}
+		if cfg.RetryDelay > 0 {
+			o.Backoff = &exponentialBackoff{
+				base:   time.Duration(cfg.RetryDelay) * time.Millisecond,
+				max:    o.MaxBackoff,
+				random: rand.Float64,
+			}
+		}
+	}
+
+	if strings.EqualFold(cfg.RetryMode, types.S3RetryModeStandard) {
+		return retry.NewStandard(standard)
+	}
+	return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+		o.StandardOptions = append(o.StandardOptions, standard)
+	})
+}
+
+// exponentialBackoff doubles the delay with each retry, starting from
+// base and capped at max. Delays are jittered between half and all of the
+// computed value, so retries never come sooner than half the base delay.
+type exponentialBackoff struct {
+	base   time.Duration
+	max    time.Duration
+	random func() float64
+}
+
+// BackoffDelay returns the delay before retrying after the given attempt,
+// counted from 1
+func (b *exponentialBackoff) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	delay := b.max
+	if shift := max(attempt-1, 0); shift < 32 {
+		if d := b.base << shift; d > 0 && d < b.max {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(b.random()*float64(delay-half)), nil
+}
@@ -0,0 +1,126 @@
+package s3
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestNewRetryer(t *testing.T) {
+	errThrottled := errors.New("slow down")
+
+	tests := []struct {
+		name         string
+		config       types.S3StorageConfig
+		wantAdaptive bool
+		wantAttempts int
+		// wantDelays bounds the delay before the first few retries
+		wantDelays [][2]time.Duration
+	}{
+		{
+			name:         "defaults",
+			config:       types.S3StorageConfig{},
+			wantAdaptive: true,
+			wantAttempts: retry.DefaultMaxAttempts,
+		},
+		{
+			name: "standard mode with backoff",
+			config: types.S3StorageConfig{
+				RetryMode:       "standard",
+				RetryAttempts:   5,
+				RetryDelay:      100,
+				RetryMaxBackoff: 300,
+			},
+			wantAttempts: 5,
+			wantDelays: [][2]time.Duration{
+				{50 * time.Millisecond, 100 * time.Millisecond},
+				{100 * time.Millisecond, 200 * time.Millisecond},
+				{150 * time.Millisecond, 300 * time.Millisecond},
+				{150 * time.Millisecond, 300 * time.Millisecond},
+			},
+		},
+		{
+			name: "adaptive mode with delay",
+			config: types.S3StorageConfig{
+				RetryMode:     "Adaptive",
+				RetryAttempts: 4,
+				RetryDelay:    2000,
+			},
+			wantAdaptive: true,
+			wantAttempts: 4,
+			wantDelays: [][2]time.Duration{
+				{time.Second, 2 * time.Second},
+				{2 * time.Second, 4 * time.Second},
+				{4 * time.Second, 8 * time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			awsConfig, err := createAWSConfig(types.S3StorageConfig{
+				Bucket:          "test-bucket",
+				Region:          "us-east-1",
+				RetryAttempts:   tt.config.RetryAttempts,
+				RetryDelay:      tt.config.RetryDelay,
+				RetryMaxBackoff: tt.config.RetryMaxBackoff,
+				RetryMode:       tt.config.RetryMode,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, awsConfig.Retryer)
+
+			retryer := awsConfig.Retryer()
+			if tt.wantAdaptive {
+				assert.IsType(t, &retry.AdaptiveMode{}, retryer)
+			} else {
+				assert.IsType(t, &retry.Standard{}, retryer)
+			}
+			assert.Equal(t, tt.wantAttempts, retryer.MaxAttempts())
+
+			for i, bounds := range tt.wantDelays {
+				for range 20 {
+					delay, err := retryer.RetryDelay(i+1, errThrottled)
+					require.NoError(t, err)
+					assert.GreaterOrEqual(t, delay, bounds[0], "retry %d", i+1)
+					assert.LessOrEqual(t, delay, bounds[1], "retry %d", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := &exponentialBackoff{
+		base:   100 * time.Millisecond,
+		max:    time.Second,
+		random: func() float64 { return 1 },
+	}
+
+	for _, tc := range []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	} {
+		delay, err := b.BackoffDelay(tc.attempt, nil)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, delay, "attempt %d", tc.attempt)
+	}
+
+	// The lowest jitter still waits half the delay
+	b.random = func() float64 { return 0 }
+	delay, err := b.BackoffDelay(2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, delay)
+}
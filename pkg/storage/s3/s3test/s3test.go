@@ -0,0 +1,36 @@
+// Package s3test provides a fake S3 endpoint for tests of code that talks
+// to S3 storage.
+package s3test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Bucket is the bucket configurations returned by NewFakeS3 point at
+const Bucket = "test-bucket"
+
+// NewFakeS3 serves handler as an S3 endpoint until the test ends and
+// returns a configuration for path-style access to Bucket on it with
+// static test credentials. The endpoint is plain HTTP, so a CA bundle set
+// in the environment is cleared for the test.
+func NewFakeS3(t testing.TB, handler http.Handler) types.S3StorageConfig {
+	t.Helper()
+
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return types.S3StorageConfig{
+		Bucket:          Bucket,
+		Region:          "us-east-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		PathStyle:       true,
+	}
+}
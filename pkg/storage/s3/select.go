@@ -0,0 +1,134 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// S3 Select reads each note as CSV with one record per line. Control
+// characters that don't appear in markdown stand in for the field
+// delimiter, quote and comment characters, so that every line is a single
+// field and lines starting with '#' or containing quotes are kept as they
+// are.
+const (
+	selectFieldDelimiter = "\x1f"
+	selectQuoteCharacter = "\x1e"
+	selectCommentChar    = "\x1d"
+)
+
+// likeEscaper escapes a literal for a LIKE pattern with ESCAPE '\' inside
+// a single-quoted SQL string
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `'`, `''`)
+
+// FilesContaining returns those of paths whose objects contain literal,
+// asking S3 Select about each object with up to batchReadConcurrency
+// requests in flight. Objects S3 Select fails on, such as ones it can't
+// parse, are returned so that the caller searches them itself. It returns
+// types.ErrSelectNotSupported unless select_enabled is set.
+func (s *Storage) FilesContaining(ctx context.Context, paths []string, literal string, ignoreCase bool) ([]string, error) {
+	if !s.config.SelectEnabled {
+		return nil, types.ErrSelectNotSupported
+	}
+	expression := selectExpression(literal, ignoreCase)
+
+	work := make(chan string)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		skipped = make(map[string]bool)
+	)
+	for range min(batchReadConcurrency, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				found, err := s.selectMatches(ctx, path, expression)
+				if err == nil && !found {
+					mu.Lock()
+					skipped[path] = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	unique := uniquePaths(paths)
+	for _, path := range unique {
+		work <- path
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matches := make([]string, 0, len(unique)-len(skipped))
+	for _, path := range unique {
+		if !skipped[path] {
+			matches = append(matches, path)
+		}
+	}
+	return matches, nil
+}
+
+// selectMatches reports whether the S3 Select expression returns any
+// record for the object at path
+func (s *Storage) selectMatches(ctx context.Context, path, expression string) (bool, error) {
+	output, err := s.client.SelectObjectContent(ctx, &s3.SelectObjectContentInput{
+		Bucket:         aws.String(s.config.Bucket),
+		Key:            aws.String(s.buildKey(path)),
+		Expression:     aws.String(expression),
+		ExpressionType: s3types.ExpressionTypeSql,
+		InputSerialization: &s3types.InputSerialization{
+			CSV: &s3types.CSVInput{
+				FileHeaderInfo:  s3types.FileHeaderInfoNone,
+				FieldDelimiter:  aws.String(selectFieldDelimiter),
+				QuoteCharacter:  aws.String(selectQuoteCharacter),
+				Comments:        aws.String(selectCommentChar),
+				RecordDelimiter: aws.String("\n"),
+			},
+			CompressionType: s3types.CompressionTypeNone,
+		},
+		OutputSerialization: &s3types.OutputSerialization{
+			CSV: &s3types.CSVOutput{},
+		},
+	})
+	if err != nil {
+		return false, s.handleError("select", path, err)
+	}
+
+	stream := output.GetStream()
+	defer func() { _ = stream.Close() }()
+
+	found := false
+	for event := range stream.Events() {
+		if records, ok := event.(*s3types.SelectObjectContentEventStreamMemberRecords); ok && len(records.Value.Payload) > 0 {
+			found = true
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return false, s.handleError("select", path, err)
+	}
+	return found, nil
+}
+
+// selectExpression returns the S3 Select query for the first line that
+// contains literal
+func selectExpression(literal string, ignoreCase bool) string {
+	column := "s._1"
+	if ignoreCase {
+		column = "LOWER(s._1)"
+		literal = strings.ToLower(literal)
+	}
+	return fmt.Sprintf(`SELECT s._1 FROM S3Object s WHERE %s LIKE '%%%s%%' ESCAPE '\' LIMIT 1`,
+		column, likeEscaper.Replace(literal))
+}
@@ -0,0 +1,115 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// selectServer fakes SelectObjectContent for the vault prefix, answering
+// with a record when the object contains the LIKE literal. Keys in broken
+// fail with an InvalidTextEncoding error.
+func selectServer(t *testing.T, objects map[string]string, broken map[string]bool, requests *atomic.Int32) http.Handler {
+	t.Helper()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/test-bucket/")
+		if r.Method != http.MethodPost || !r.URL.Query().Has("select") {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		requests.Add(1)
+
+		var req struct {
+			Expression string `xml:"Expression"`
+		}
+		require.NoError(t, xml.NewDecoder(r.Body).Decode(&req))
+
+		if broken[key] {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("<Error><Code>InvalidTextEncoding</Code><Message>Invalid encoding</Message></Error>"))
+			return
+		}
+
+		// Pull the literal back out of "... LIKE '%literal%' ESCAPE ..."
+		literal := req.Expression[strings.Index(req.Expression, "LIKE '%")+len("LIKE '%"):]
+		literal = literal[:strings.Index(literal, "%' ESCAPE")]
+		literal = strings.NewReplacer(`\%`, `%`, `\_`, `_`, `\\`, `\`, `''`, `'`).Replace(literal)
+		content := objects[key]
+		if strings.Contains(req.Expression, "LOWER(") {
+			content = strings.ToLower(content)
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		w.WriteHeader(http.StatusOK)
+		encoder := eventstream.NewEncoder()
+		if strings.Contains(content, literal) {
+			writeSelectEvent(t, w, encoder, "Records", []byte("a matching line\n"))
+		}
+		writeSelectEvent(t, w, encoder, "End", nil)
+	})
+}
+
+func writeSelectEvent(t *testing.T, w http.ResponseWriter, encoder *eventstream.Encoder, eventType string, payload []byte) {
+	t.Helper()
+	var headers eventstream.Headers
+	headers.Set(":message-type", eventstream.StringValue("event"))
+	headers.Set(":event-type", eventstream.StringValue(eventType))
+	if payload != nil {
+		headers.Set(":content-type", eventstream.StringValue("application/octet-stream"))
+	}
+	require.NoError(t, encoder.Encode(w, eventstream.Message{Headers: headers, Payload: payload}))
+}
+
+func TestFilesContaining(t *testing.T) {
+	objects := map[string]string{
+		"vault/notes/a.md": "# Alpha\n\nThe Deploy checklist.\n",
+		"vault/notes/b.md": "# Beta\n\nNothing here.\n",
+		"vault/notes/c.md": "# Gamma\n\n100% done_now, it's deploy day.\n",
+		"vault/notes/d.md": "# Delta\n",
+	}
+	broken := map[string]bool{"vault/notes/d.md": true}
+
+	var requests atomic.Int32
+	storage := newMultipartTestStorage(t, selectServer(t, objects, broken, &requests))
+	storage.config.SelectEnabled = true
+	paths := []string{"notes/a.md", "notes/b.md", "notes/c.md", "notes/d.md"}
+
+	// Objects S3 Select can't check are kept for the caller to search
+	files, err := storage.FilesContaining(context.Background(), paths, "deploy", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/c.md", "notes/d.md"}, files)
+	assert.Equal(t, int32(4), requests.Load())
+
+	files, err = storage.FilesContaining(context.Background(), paths, "deploy", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/a.md", "notes/c.md", "notes/d.md"}, files)
+
+	// LIKE wildcards and quotes in the literal are matched literally
+	files, err = storage.FilesContaining(context.Background(), paths[:3], "100% done_now, it's", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes/c.md"}, files)
+
+	storage.config.SelectEnabled = false
+	_, err = storage.FilesContaining(context.Background(), paths, "deploy", false)
+	assert.ErrorIs(t, err, types.ErrSelectNotSupported)
+}
+
+func TestSelectExpression(t *testing.T) {
+	assert.Equal(t,
+		`SELECT s._1 FROM S3Object s WHERE s._1 LIKE '%50\% off%' ESCAPE '\' LIMIT 1`,
+		selectExpression("50% off", false))
+	assert.Equal(t,
+		`SELECT s._1 FROM S3Object s WHERE LOWER(s._1) LIKE '%don''t \_x\\%' ESCAPE '\' LIMIT 1`,
+		selectExpression(`Don't _X\`, true))
+}
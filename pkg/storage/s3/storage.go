@@ -1,17 +1,18 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -20,6 +21,9 @@ import (
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
+// errChecksumMismatch indicates that S3 stored different bytes than were sent
+var errChecksumMismatch = errors.New("checksum mismatch")
+
 // Storage implements the StorageBackend interface for AWS S3
 type Storage struct {
 	client     *s3.Client
@@ -51,7 +55,7 @@ func NewStorage(cfg types.S3StorageConfig) (*Storage, error) {
 	})
 
 	// Create upload and download managers
-	uploader := manager.NewUploader(client)
+	uploader := manager.NewUploader(client, uploaderOptions(cfg))
 	downloader := manager.NewDownloader(client)
 
 	storage := &Storage{
@@ -92,14 +96,48 @@ func (s *Storage) Read(ctx context.Context, path string) ([]byte, error) {
 	return data, nil
 }
 
-// Write stores content at the given path
+// Write stores content at the given path. Notes carry their frontmatter
+// title, tags and type as object metadata; see NoteMetadata.
 func (s *Storage) Write(ctx context.Context, path string, data []byte) error {
-	key := s.buildKey(path)
+	return s.WriteWithMetadata(ctx, path, data, NoteMetadata(path, data))
+}
+
+// WriteWithMetadata stores content at the given path with meta as object
+// user metadata (x-amz-meta-*). Keys and values are sanitized to be valid
+// headers, and entries past S3's 2 KB metadata limit are dropped.
+func (s *Storage) WriteWithMetadata(ctx context.Context, path string, data []byte, meta map[string]string) error {
+	input, digest := s.putObjectInput(path, data, meta)
+
+	output, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		return s.handleError("write", path, err)
+	}
+
+	if s.config.VerifyChecksums && s.etagIsMD5() {
+		if err := verifyETag(aws.ToString(output.ETag), digest); err != nil {
+			return types.NewStorageError(types.StorageTypeS3, "write", path, err, false)
+		}
+	}
 
+	return nil
+}
+
+// putObjectInput builds the PutObject request for a write. The returned
+// digest is the content MD5 when checksums are verified.
+func (s *Storage) putObjectInput(path string, data []byte, meta map[string]string) (*s3.PutObjectInput, []byte) {
 	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.config.Bucket),
-		Key:    aws.String(key),
-		Body:   strings.NewReader(string(data)),
+		Bucket:   aws.String(s.config.Bucket),
+		Key:      aws.String(s.buildKey(path)),
+		Body:     bytes.NewReader(data),
+		Metadata: sanitizeMetadata(meta),
+	}
+
+	// Have S3 reject the upload if the body is corrupted in transit
+	var digest []byte
+	if s.config.VerifyChecksums {
+		var header string
+		header, digest = contentMD5(data)
+		input.ContentMD5 = aws.String(header)
 	}
 
 	// Add server-side encryption if configured
@@ -111,16 +149,11 @@ func (s *Storage) Write(ctx context.Context, path string, data []byte) error {
 	}
 
 	// Set storage class if configured
-	if s.config.StorageClass != "" {
-		input.StorageClass = s3types.StorageClass(s.config.StorageClass)
-	}
-
-	_, err := s.client.PutObject(ctx, input)
-	if err != nil {
-		return s.handleError("write", path, err)
+	if class := s.config.StorageClassFor(path); class != "" {
+		input.StorageClass = s3types.StorageClass(class)
 	}
 
-	return nil
+	return input, digest
 }
 
 // Delete removes a file at the given path
@@ -151,8 +184,11 @@ func (s *Storage) Exists(ctx context.Context, path string) (bool, error) {
 
 	_, err := s.client.HeadObject(ctx, input)
 	if err != nil {
+		// HEAD responses have no body, so a missing key is reported as
+		// NotFound rather than NoSuchKey
+		var nf *s3types.NotFound
 		var nsk *s3types.NoSuchKey
-		if errors.As(err, &nsk) {
+		if errors.As(err, &nf) || errors.As(err, &nsk) {
 			return false, nil
 		}
 		return false, s.handleError("exists", path, err)
@@ -163,6 +199,26 @@ func (s *Storage) Exists(ctx context.Context, path string) (bool, error) {
 
 // List returns all files matching the given prefix
 func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var files []string
+	err := s.walk(ctx, "list", prefix, func(path string, _ *types.FileInfo) error {
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Walk calls fn for each object matching prefix as the listing is paged
+// through, so only one page of keys is held at a time
+func (s *Storage) Walk(ctx context.Context, prefix string, fn types.WalkFunc) error {
+	return s.walk(ctx, "walk", prefix, fn)
+}
+
+// walk pages through the objects matching prefix, reporting listing
+// errors as failures of operation
+func (s *Storage) walk(ctx context.Context, operation, prefix string, fn types.WalkFunc) error {
 	key := s.buildKey(prefix)
 
 	input := &s3.ListObjectsV2Input{
@@ -170,27 +226,44 @@ func (s *Storage) List(ctx context.Context, prefix string) ([]string, error) {
 		Prefix: aws.String(key),
 	}
 
-	var files []string
+	// Keys are relative to the prefix as buildKey joins it
+	keyPrefix := s.buildKey("")
+
 	paginator := s3.NewListObjectsV2Paginator(s.client, input)
 
 	for paginator.HasMorePages() {
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, s.handleError("list", prefix, err)
+			return s.handleError(operation, prefix, err)
 		}
 
 		for _, obj := range output.Contents {
-			if obj.Key != nil {
-				// Remove the prefix to get relative path
-				relativePath := strings.TrimPrefix(*obj.Key, s.config.Prefix)
-				if relativePath != "" {
-					files = append(files, relativePath)
-				}
+			if obj.Key == nil {
+				continue
+			}
+			// Remove the prefix to get relative path
+			relativePath := strings.TrimPrefix(*obj.Key, keyPrefix)
+			if relativePath == "" {
+				continue
+			}
+
+			info := &types.FileInfo{
+				Path:         relativePath,
+				Size:         aws.ToInt64(obj.Size),
+				ETag:         aws.ToString(obj.ETag),
+				ContentType:  types.ContentTypeByExtension(relativePath),
+				StorageClass: string(obj.StorageClass),
+			}
+			if obj.LastModified != nil {
+				info.ModTime = obj.LastModified.Unix()
+			}
+			if err := fn(relativePath, info); err != nil {
+				return err
 			}
 		}
 	}
 
-	return files, nil
+	return nil
 }
 
 // Stat returns metadata about a file
@@ -224,11 +297,11 @@ func (s *Storage) Stat(ctx context.Context, path string) (*types.FileInfo, error
 		info.StorageClass = string(result.StorageClass)
 	}
 
-	// Add custom metadata
+	// Add custom metadata, decoding values that were encoded on write
 	if len(result.Metadata) > 0 {
 		info.Metadata = make(map[string]string)
 		for k, v := range result.Metadata {
-			info.Metadata[k] = v
+			info.Metadata[k] = decodeMetadataValue(v)
 		}
 	}
 
@@ -271,10 +344,13 @@ func (s *Storage) WriteStream(ctx context.Context, path string, reader io.Reader
 	}
 
 	// Set storage class if configured
-	if s.config.StorageClass != "" {
-		input.StorageClass = s3types.StorageClass(s.config.StorageClass)
+	if class := s.config.StorageClassFor(path); class != "" {
+		input.StorageClass = s3types.StorageClass(class)
 	}
 
+	// The upload manager may split the body into a multipart upload, whose
+	// ETag is a digest of the part digests rather than a plain MD5 of the
+	// content, so checksums are not compared for streamed writes
 	_, err := s.uploader.Upload(ctx, input)
 	if err != nil {
 		return s.handleError("write_stream", path, err)
@@ -296,6 +372,11 @@ func (s *Storage) Copy(ctx context.Context, src, dst string) error {
 		CopySource: aws.String(copySource),
 	}
 
+	// The copy takes the storage class of its destination path
+	if class := s.config.StorageClassFor(dst); class != "" {
+		input.StorageClass = s3types.StorageClass(class)
+	}
+
 	// Add server-side encryption if configured
 	if s.config.ServerSideEncryption != "" {
 		input.ServerSideEncryption = s3types.ServerSideEncryption(s.config.ServerSideEncryption)
@@ -351,6 +432,21 @@ func (s *Storage) Close() error {
 	return nil
 }
 
+// uploaderOptions applies the configured part size and concurrency to the
+// upload manager. Failed multipart uploads are always aborted so their
+// parts don't linger in the bucket and accrue storage charges.
+func uploaderOptions(cfg types.S3StorageConfig) func(*manager.Uploader) {
+	return func(u *manager.Uploader) {
+		if cfg.UploadPartSizeMB > 0 {
+			u.PartSize = int64(cfg.UploadPartSizeMB) * 1024 * 1024
+		}
+		if cfg.UploadConcurrency > 0 {
+			u.Concurrency = cfg.UploadConcurrency
+		}
+		u.LeavePartsOnError = false
+	}
+}
+
 // buildKey constructs the full S3 key with prefix
 func (s *Storage) buildKey(path string) string {
 	if s.config.Prefix == "" {
@@ -359,6 +455,38 @@ func (s *Storage) buildKey(path string) string {
 	return strings.TrimSuffix(s.config.Prefix, "/") + "/" + strings.TrimPrefix(path, "/")
 }
 
+// etagIsMD5 reports whether single-part uploads return the content MD5 as
+// their ETag. That only holds for unencrypted and SSE-S3 objects; SSE-KMS,
+// DSSE-KMS and SSE-C objects get an opaque ETag instead.
+func (s *Storage) etagIsMD5() bool {
+	switch s.config.ServerSideEncryption {
+	case "", string(s3types.ServerSideEncryptionAes256):
+		return true
+	}
+	return false
+}
+
+// contentMD5 returns the base64 Content-MD5 header value and raw digest for data
+func contentMD5(data []byte) (string, []byte) {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:]), sum[:]
+}
+
+// verifyETag compares a single-part upload ETag with the expected MD5 digest.
+// Multipart ETags (containing a '-') are not plain MD5s and are accepted.
+func verifyETag(etag string, digest []byte) error {
+	etag = strings.Trim(etag, `"`)
+	if etag == "" || strings.Contains(etag, "-") {
+		return nil
+	}
+
+	expected := hex.EncodeToString(digest)
+	if !strings.EqualFold(etag, expected) {
+		return fmt.Errorf("%w: ETag %s does not match MD5 %s", errChecksumMismatch, etag, expected)
+	}
+	return nil
+}
+
 // handleError converts AWS S3 errors to storage errors
 func (s *Storage) handleError(operation, path string, err error) error {
 	// Check for retryable errors
@@ -407,10 +535,36 @@ func validateConfig(cfg types.S3StorageConfig) error {
 		return fmt.Errorf("retry delay cannot be negative")
 	}
 
+	if cfg.RetryMaxBackoff < 0 {
+		return fmt.Errorf("retry max backoff cannot be negative")
+	}
+
+	if cfg.RetryMaxBackoff > 0 && cfg.RetryDelay > cfg.RetryMaxBackoff {
+		return fmt.Errorf("retry delay cannot exceed retry max backoff")
+	}
+
+	switch strings.ToLower(cfg.RetryMode) {
+	case "", types.S3RetryModeStandard, types.S3RetryModeAdaptive:
+	default:
+		return fmt.Errorf("retry mode must be %q or %q, got %q", types.S3RetryModeStandard, types.S3RetryModeAdaptive, cfg.RetryMode)
+	}
+
 	if cfg.RequestTimeout < 0 {
 		return fmt.Errorf("request timeout cannot be negative")
 	}
 
+	if cfg.MaxIdleConns < 0 || cfg.MaxIdleConnsPerHost < 0 || cfg.IdleConnTimeout < 0 {
+		return fmt.Errorf("connection pool settings cannot be negative")
+	}
+
+	if _, err := proxyFunc(cfg.Proxy); err != nil {
+		return err
+	}
+
+	if _, err := credentialSource(cfg); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -430,6 +584,11 @@ func createAWSConfig(cfg types.S3StorageConfig) (aws.Config, error) {
 		// nolint:staticcheck
 		opts = append(opts, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
 			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				// Other services, such as STS for assumed roles, keep
+				// their own endpoints
+				if service != s3.ServiceID {
+					return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+				}
 				// nolint:staticcheck
 				return aws.Endpoint{
 					URL:           cfg.Endpoint,
@@ -438,30 +597,31 @@ func createAWSConfig(cfg types.S3StorageConfig) (aws.Config, error) {
 			})))
 	}
 
-	// Set credentials if provided
-	if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
-		creds := credentials.NewStaticCredentialsProvider(
-			cfg.AccessKeyID,
-			cfg.SecretAccessKey,
-			cfg.SessionToken,
-		)
-		opts = append(opts, config.WithCredentialsProvider(creds))
+	// Set credentials from the configured source
+	source, err := credentialSource(cfg)
+	if err != nil {
+		return aws.Config{}, err
 	}
+	opts = append(opts, credentialOptions(cfg, source)...)
 
 	// Set retry configuration
-	if cfg.RetryAttempts > 0 {
-		retryMode := aws.RetryModeAdaptive
-		opts = append(opts, config.WithRetryMode(retryMode))
-		opts = append(opts, config.WithRetryMaxAttempts(cfg.RetryAttempts))
-	}
+	opts = append(opts, config.WithRetryer(func() aws.Retryer {
+		return newRetryer(cfg)
+	}))
 
 	// Set HTTP client configuration
-	if cfg.RequestTimeout > 0 {
-		httpClient := &http.Client{
-			Timeout: time.Duration(cfg.RequestTimeout) * time.Second,
-		}
-		opts = append(opts, config.WithHTTPClient(httpClient))
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return aws.Config{}, err
 	}
+	opts = append(opts, config.WithHTTPClient(httpClient))
 
-	return config.LoadDefaultConfig(ctx, opts...)
+	awsConfig, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if source.Kind == types.S3CredentialsRole {
+		awsConfig = assumeRole(awsConfig, source.Name)
+	}
+	return awsConfig, nil
 }
@@ -2,12 +2,19 @@ package s3
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/s3/s3test"
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
 )
 
@@ -122,6 +129,37 @@ func TestValidateConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "retry delay cannot be negative",
 		},
+		{
+			name: "negative retry max backoff",
+			config: types.S3StorageConfig{
+				Bucket:          "test-bucket",
+				Region:          "us-east-1",
+				RetryMaxBackoff: -1,
+			},
+			wantErr: true,
+			errMsg:  "retry max backoff cannot be negative",
+		},
+		{
+			name: "retry delay above max backoff",
+			config: types.S3StorageConfig{
+				Bucket:          "test-bucket",
+				Region:          "us-east-1",
+				RetryDelay:      5000,
+				RetryMaxBackoff: 1000,
+			},
+			wantErr: true,
+			errMsg:  "retry delay cannot exceed retry max backoff",
+		},
+		{
+			name: "unknown retry mode",
+			config: types.S3StorageConfig{
+				Bucket:    "test-bucket",
+				Region:    "us-east-1",
+				RetryMode: "legacy",
+			},
+			wantErr: true,
+			errMsg:  "retry mode must be",
+		},
 		{
 			name: "negative request timeout",
 			config: types.S3StorageConfig{
@@ -132,6 +170,26 @@ func TestValidateConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "request timeout cannot be negative",
 		},
+		{
+			name: "negative idle connections",
+			config: types.S3StorageConfig{
+				Bucket:              "test-bucket",
+				Region:              "us-east-1",
+				MaxIdleConnsPerHost: -1,
+			},
+			wantErr: true,
+			errMsg:  "connection pool settings cannot be negative",
+		},
+		{
+			name: "invalid proxy",
+			config: types.S3StorageConfig{
+				Bucket: "test-bucket",
+				Region: "us-east-1",
+				Proxy:  "proxy:3128",
+			},
+			wantErr: true,
+			errMsg:  "invalid proxy URL",
+		},
 	}
 
 	for _, tt := range tests {
@@ -252,6 +310,151 @@ func TestHandleError(t *testing.T) {
 	assert.Equal(t, testErr, sErr.Err)
 }
 
+func TestContentMD5(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		header string
+	}{
+		{"empty", []byte{}, "1B2M2Y8AsgTpgAmY7PhCfg=="},
+		{"hello", []byte("hello"), "XUFAKrxLKna5cZ2REBfFkg=="},
+		{"note", []byte("# Note\n\nContent"), "0dChQKsNCd6M03jP/QFdRQ=="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, digest := contentMD5(tt.data)
+			assert.Equal(t, tt.header, header)
+
+			sum := md5.Sum(tt.data)
+			assert.Equal(t, sum[:], digest)
+		})
+	}
+}
+
+func TestVerifyETag(t *testing.T) {
+	_, digest := contentMD5([]byte("hello"))
+
+	tests := []struct {
+		name    string
+		etag    string
+		wantErr bool
+	}{
+		{"matching quoted", `"5d41402abc4b2a76b9719d911017c592"`, false},
+		{"matching unquoted upper case", "5D41402ABC4B2A76B9719D911017C592", false},
+		{"mismatch", `"00000000000000000000000000000000"`, true},
+		{"multipart etag skipped", `"d41d8cd98f00b204e9800998ecf8427e-2"`, false},
+		{"missing etag skipped", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyETag(tt.etag, digest)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errChecksumMismatch)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWriteVerifyChecksums(t *testing.T) {
+	tests := []struct {
+		name           string
+		verify         bool
+		sse            string
+		corruptETag    bool
+		wantErr        bool
+		wantContentMD5 bool
+	}{
+		{"disabled", false, "", true, false, false},
+		{"matching etag", true, "", false, false, true},
+		{"mismatched etag", true, "", true, true, true},
+		{"sse-s3 mismatched etag", true, "AES256", true, true, true},
+		{"kms etag not compared", true, "aws:kms", true, false, true},
+		{"dsse-kms etag not compared", true, "aws:kms:dsse", true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotContentMD5 string
+			config := s3test.NewFakeS3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				gotContentMD5 = r.Header.Get("Content-MD5")
+
+				sum := md5.Sum(body)
+				etag := hex.EncodeToString(sum[:])
+				if tt.corruptETag {
+					etag = strings.Repeat("0", len(etag))
+				}
+				w.Header().Set("ETag", `"`+etag+`"`)
+				w.WriteHeader(http.StatusOK)
+			}))
+			config.ServerSideEncryption = tt.sse
+			config.VerifyChecksums = tt.verify
+
+			storage, err := NewStorage(config)
+			require.NoError(t, err)
+
+			err = storage.Write(context.Background(), "notes/test.md", []byte("hello"))
+			if tt.wantErr {
+				var storageErr *types.StorageError
+				require.True(t, errors.As(err, &storageErr))
+				assert.False(t, storageErr.Retryable)
+				assert.ErrorIs(t, err, errChecksumMismatch)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.wantContentMD5 {
+				assert.Equal(t, "XUFAKrxLKna5cZ2REBfFkg==", gotContentMD5)
+			} else {
+				assert.Empty(t, gotContentMD5)
+			}
+		})
+	}
+}
+
+func TestStorageClassRules(t *testing.T) {
+	classes := make(map[string]string)
+	config := s3test.NewFakeS3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		classes[r.URL.Path] = r.Header.Get("X-Amz-Storage-Class")
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = io.WriteString(w, `<CopyObjectResult><ETag>"x"</ETag></CopyObjectResult>`)
+			return
+		}
+		w.Header().Set("ETag", `"x"`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	config.StorageClass = "STANDARD"
+	config.StorageClassRules = []types.StorageClassRule{
+		{Prefix: "daily/", Class: "STANDARD_IA"},
+		{Prefix: "archive/", Class: "GLACIER"},
+	}
+
+	storage, err := NewStorage(config)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, storage.Write(ctx, "daily/2024-01-15.md", []byte("day")))
+	require.NoError(t, storage.Write(ctx, "notes/a.md", []byte("note")))
+	require.NoError(t, storage.WriteStream(ctx, "archive/big.bin", strings.NewReader("big")))
+	require.NoError(t, storage.Copy(ctx, "notes/a.md", "daily/copy.md"))
+
+	assert.Equal(t, map[string]string{
+		"/test-bucket/daily/2024-01-15.md": "STANDARD_IA",
+		"/test-bucket/notes/a.md":          "STANDARD",
+		"/test-bucket/archive/big.bin":     "GLACIER",
+		"/test-bucket/daily/copy.md":       "STANDARD_IA",
+	}, classes)
+
+	input, _ := storage.putObjectInput("notes/b.md", []byte("b"), nil)
+	assert.Equal(t, "STANDARD", string(input.StorageClass))
+}
+
 func TestCreateAWSConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -510,6 +713,109 @@ func TestStorageStreamOperations(t *testing.T) {
 	_ = storage.Delete(ctx, testPath)
 }
 
+// listServer fakes ListObjectsV2 over keys, sorted, returning pageSize
+// keys per page. It counts the pages requested.
+func listServer(t *testing.T, keys []string, pageSize int, pages *int) http.Handler {
+	t.Helper()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method != http.MethodGet || query.Get("list-type") != "2" {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		*pages++
+
+		var matching []string
+		for _, key := range keys {
+			if strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+				matching = append(matching, key)
+			}
+		}
+		truncated := len(matching) > pageSize
+		if truncated {
+			matching = matching[:pageSize]
+		}
+
+		var b strings.Builder
+		b.WriteString("<ListBucketResult><Name>test-bucket</Name>")
+		for _, key := range matching {
+			fmt.Fprintf(&b, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2024-01-02T03:04:05.000Z</LastModified><ETag>&quot;etag&quot;</ETag><StorageClass>STANDARD</StorageClass></Contents>", key, len(key))
+		}
+		fmt.Fprintf(&b, "<KeyCount>%d</KeyCount><IsTruncated>%t</IsTruncated>", len(matching), truncated)
+		if truncated {
+			fmt.Fprintf(&b, "<NextContinuationToken>%s</NextContinuationToken>", matching[len(matching)-1])
+		}
+		b.WriteString("</ListBucketResult>")
+
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(b.String()))
+	})
+}
+
+func TestWalk(t *testing.T) {
+	var keys []string
+	for i := range 25 {
+		keys = append(keys, fmt.Sprintf("vault/notes/%02d.md", i))
+	}
+	keys = append(keys, "vault/other.md")
+
+	var pages int
+	storage := newMultipartTestStorage(t, listServer(t, keys, 10, &pages))
+	ctx := context.Background()
+
+	var walked []string
+	err := storage.Walk(ctx, "notes/", func(path string, info *types.FileInfo) error {
+		walked = append(walked, path)
+		assert.Equal(t, path, info.Path)
+		assert.Equal(t, int64(len("vault/"+path)), info.Size)
+		assert.Equal(t, int64(1704164645), info.ModTime)
+		assert.Equal(t, `"etag"`, info.ETag)
+		assert.Equal(t, "STANDARD", info.StorageClass)
+		assert.Equal(t, types.MarkdownContentType, info.ContentType)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, pages)
+
+	listed, err := storage.List(ctx, "notes/")
+	require.NoError(t, err)
+	assert.Len(t, walked, 25)
+	assert.Equal(t, listed, walked)
+
+	// Returning an error stops the walk before the next page is fetched
+	errStop := errors.New("stop")
+	pages = 0
+	walked = nil
+	err = storage.Walk(ctx, "notes/", func(path string, _ *types.FileInfo) error {
+		walked = append(walked, path)
+		if len(walked) == 5 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Len(t, walked, 5)
+	assert.Equal(t, 1, pages)
+}
+
+func TestWalkListError(t *testing.T) {
+	storage := newMultipartTestStorage(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>"))
+	}))
+
+	err := storage.Walk(context.Background(), "notes/", func(string, *types.FileInfo) error {
+		t.Error("callback should not be called")
+		return nil
+	})
+	var storageErr *types.StorageError
+	require.ErrorAs(t, err, &storageErr)
+	assert.Equal(t, "walk", storageErr.Operation)
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
 // Helper function to get environment variable or skip test
 func getEnvOrSkip(t *testing.T, key string) string {
 	value := "" // In real tests, this would use os.Getenv(key)
@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Connection pool defaults for the S3 HTTP client, used when the
+// configuration leaves them at zero
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 100
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// newHTTPClient builds the HTTP client for S3 requests: the SDK's client,
+// which keeps support for AWS_CA_BUNDLE, with its transport tuned to keep
+// enough connections open for many small requests in parallel and to use
+// the configured proxy
+func newHTTPClient(cfg types.S3StorageConfig) (*awshttp.BuildableClient, error) {
+	proxy, err := proxyFunc(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	client := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		tr.MaxIdleConns = orDefault(cfg.MaxIdleConns, DefaultMaxIdleConns)
+		tr.MaxIdleConnsPerHost = orDefault(cfg.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost)
+		tr.IdleConnTimeout = DefaultIdleConnTimeout
+		if cfg.IdleConnTimeout > 0 {
+			tr.IdleConnTimeout = time.Duration(cfg.IdleConnTimeout) * time.Second
+		}
+		tr.Proxy = proxy
+	})
+	if cfg.RequestTimeout > 0 {
+		client = client.WithTimeout(time.Duration(cfg.RequestTimeout) * time.Second)
+	}
+	return client, nil
+}
+
+// proxyFunc returns the proxy selection for the transport: every request
+// goes through proxy when it is set, and otherwise the environment
+// decides. The environment is read now rather than through
+// http.ProxyFromEnvironment, which reads it once per process.
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == "" {
+		fromEnv := httpproxy.FromEnvironment().ProxyFunc()
+		return func(req *http.Request) (*url.URL, error) {
+			return fromEnv(req.URL)
+		}, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: must be like http://host:port", proxy)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// orDefault returns value, or def when value is zero
+func orDefault(value, def int) int {
+	if value == 0 {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// clearProxyEnv unsets the proxy variables for the test
+func clearProxyEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy", "REQUEST_METHOD"} {
+		t.Setenv(name, "")
+	}
+}
+
+// proxyFor returns the proxy the transport of client picks for rawURL, or
+// "" for a direct connection
+func proxyFor(t *testing.T, client *awshttp.BuildableClient, rawURL string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	require.NoError(t, err)
+	proxyURL, err := client.GetTransport().Proxy(req)
+	require.NoError(t, err)
+	if proxyURL == nil {
+		return ""
+	}
+	return proxyURL.String()
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	clearProxyEnv(t)
+
+	client, err := newHTTPClient(types.S3StorageConfig{
+		MaxIdleConns:        250,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     30,
+		RequestTimeout:      15,
+	})
+	require.NoError(t, err)
+
+	tr := client.GetTransport()
+	assert.Equal(t, 250, tr.MaxIdleConns)
+	assert.Equal(t, 64, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, 30*time.Second, tr.IdleConnTimeout)
+	assert.Equal(t, 15*time.Second, client.GetTimeout())
+
+	// Unset fields use the defaults rather than Go's two connections per host
+	client, err = newHTTPClient(types.S3StorageConfig{})
+	require.NoError(t, err)
+	tr = client.GetTransport()
+	assert.Equal(t, DefaultMaxIdleConns, tr.MaxIdleConns)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, tr.IdleConnTimeout)
+	assert.Zero(t, client.GetTimeout())
+}
+
+func TestNewHTTPClient_Proxy(t *testing.T) {
+	clearProxyEnv(t)
+
+	client, err := newHTTPClient(types.S3StorageConfig{})
+	require.NoError(t, err)
+	assert.Empty(t, proxyFor(t, client, "http://minio.example.com:9000/bucket"))
+
+	// Without an explicit proxy the environment decides
+	t.Setenv("HTTP_PROXY", "http://env-proxy.example.com:3128")
+	t.Setenv("HTTPS_PROXY", "http://secure-proxy.example.com:3128")
+	t.Setenv("NO_PROXY", "internal.example.com")
+	client, err = newHTTPClient(types.S3StorageConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://env-proxy.example.com:3128", proxyFor(t, client, "http://minio.example.com:9000/bucket"))
+	assert.Equal(t, "http://secure-proxy.example.com:3128", proxyFor(t, client, "https://s3.us-east-1.amazonaws.com/bucket"))
+	assert.Empty(t, proxyFor(t, client, "http://internal.example.com/bucket"))
+
+	// An explicit proxy takes every request
+	client, err = newHTTPClient(types.S3StorageConfig{Proxy: "http://config-proxy.example.com:8080"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://config-proxy.example.com:8080", proxyFor(t, client, "https://s3.us-east-1.amazonaws.com/bucket"))
+	assert.Equal(t, "http://config-proxy.example.com:8080", proxyFor(t, client, "http://internal.example.com/bucket"))
+
+	_, err = newHTTPClient(types.S3StorageConfig{Proxy: "proxy.example.com"})
+	assert.Error(t, err)
+}
+
+func TestCreateAWSConfig_HTTPClient(t *testing.T) {
+	clearProxyEnv(t)
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	awsConfig, err := createAWSConfig(types.S3StorageConfig{
+		Bucket:              "test-bucket",
+		Region:              "us-east-1",
+		MaxIdleConnsPerHost: 32,
+		Proxy:               "http://proxy.example.com:3128",
+	})
+	require.NoError(t, err)
+
+	client, ok := awsConfig.HTTPClient.(*awshttp.BuildableClient)
+	require.True(t, ok, "expected the SDK's buildable client, got %T", awsConfig.HTTPClient)
+	assert.Equal(t, 32, client.GetTransport().MaxIdleConnsPerHost)
+	assert.Equal(t, "http://proxy.example.com:3128", proxyFor(t, client, "https://s3.us-east-1.amazonaws.com/bucket"))
+}
@@ -0,0 +1,120 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// ListVersions returns the versions of the object at path, newest first,
+// or none if the key has never been written. Deleting an object in a
+// versioned bucket leaves a delete marker, which is listed as a Deleted
+// version. Buckets without versioning report the current object as a single
+// version with the ID "null".
+func (s *Storage) ListVersions(ctx context.Context, path string) ([]types.FileVersion, error) {
+	key := s.buildKey(path)
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(s.config.Bucket),
+		Prefix: aws.String(key),
+	}
+
+	var versions []types.FileVersion
+	for {
+		output, err := s.client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return nil, s.handleError("list_versions", path, err)
+		}
+
+		// The prefix also matches longer keys, such as "a.md.bak" for "a.md"
+		for _, v := range output.Versions {
+			if aws.ToString(v.Key) != key {
+				continue
+			}
+			versions = append(versions, types.FileVersion{
+				ID:      aws.ToString(v.VersionId),
+				ModTime: aws.ToTime(v.LastModified),
+				Size:    aws.ToInt64(v.Size),
+				Current: aws.ToBool(v.IsLatest),
+			})
+		}
+		for _, m := range output.DeleteMarkers {
+			if aws.ToString(m.Key) != key {
+				continue
+			}
+			versions = append(versions, types.FileVersion{
+				ID:      aws.ToString(m.VersionId),
+				ModTime: aws.ToTime(m.LastModified),
+				Current: aws.ToBool(m.IsLatest),
+				Deleted: true,
+			})
+		}
+
+		if !aws.ToBool(output.IsTruncated) {
+			break
+		}
+		input.KeyMarker = output.NextKeyMarker
+		input.VersionIdMarker = output.NextVersionIdMarker
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Current != versions[j].Current {
+			return versions[i].Current
+		}
+		return versions[i].ModTime.After(versions[j].ModTime)
+	})
+	return versions, nil
+}
+
+// ReadVersion retrieves the content of one version of the object at path
+func (s *Storage) ReadVersion(ctx context.Context, path, versionID string) ([]byte, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:    aws.String(s.config.Bucket),
+		Key:       aws.String(s.buildKey(path)),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return nil, s.handleError("read_version", path, err)
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, types.NewStorageError(types.StorageTypeS3, "read_version", path, err, false)
+	}
+	return data, nil
+}
+
+// RestoreVersion makes a past version of the object at path current again
+// by copying it over the key within the bucket
+func (s *Storage) RestoreVersion(ctx context.Context, path, versionID string) error {
+	key := s.buildKey(path)
+	copySource := fmt.Sprintf("%s/%s?versionId=%s", s.config.Bucket, key, url.QueryEscape(versionID))
+
+	input := &s3.CopyObjectInput{
+		Bucket:     aws.String(s.config.Bucket),
+		Key:        aws.String(key),
+		CopySource: aws.String(copySource),
+	}
+	if class := s.config.StorageClassFor(path); class != "" {
+		input.StorageClass = s3types.StorageClass(class)
+	}
+	if s.config.ServerSideEncryption != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryption(s.config.ServerSideEncryption)
+		if s.config.KMSKeyID != "" {
+			input.SSEKMSKeyId = aws.String(s.config.KMSKeyID)
+		}
+	}
+
+	if _, err := s.client.CopyObject(ctx, input); err != nil {
+		return s.handleError("restore_version", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []otlpRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req otlpRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	tracer, closeTracer := NewTracerFromConfig(types.TracingConfig{OTLPEndpoint: server.URL + "/", ServiceName: "kbvault-test"})
+	require.NotNil(t, tracer)
+
+	ctx := WithRequestID(context.Background(), "req-7")
+	ctx, parent := tracer.Start(ctx, "search")
+	_, child := tracer.Start(ctx, "storage.read")
+	child.SetAttribute(AttrStoragePath, "notes/a.md")
+	child.Finish(errors.New("not found"))
+	parent.Finish(nil)
+
+	// Closing sends the queued spans
+	require.NoError(t, closeTracer())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].ResourceSpans, 1)
+	resource := requests[0].ResourceSpans[0]
+	assert.Equal(t, []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: "kbvault-test"}}}, resource.Resource.Attributes)
+
+	spans := resource.ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	read, search := spans[0], spans[1]
+	assert.Equal(t, "storage.read", read.Name)
+	assert.Equal(t, search.TraceID, read.TraceID)
+	assert.Equal(t, search.SpanID, read.ParentSpanID)
+	assert.Equal(t, otlpStatus{Code: otlpStatusError, Message: "not found"}, read.Status)
+	assert.Equal(t, otlpStatus{Code: otlpStatusOK}, search.Status)
+	assert.Equal(t, []otlpAttribute{
+		{Key: AttrRequestID, Value: otlpValue{StringValue: "req-7"}},
+		{Key: AttrStoragePath, Value: otlpValue{StringValue: "notes/a.md"}},
+	}, read.Attributes)
+	assert.NotEmpty(t, read.StartTimeUnixNano)
+
+	// Spans ending after Close are dropped
+	_, late := tracer.Start(context.Background(), "late")
+	late.Finish(nil)
+}
+
+func TestOTLPExporter_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(types.TracingConfig{OTLPEndpoint: server.URL})
+	_, span := NewTracer(exporter).Start(context.Background(), "search")
+	span.Finish(nil)
+	assert.ErrorContains(t, exporter.Close(), "503")
+}
+
+func TestNewTracerFromConfig_Disabled(t *testing.T) {
+	assert.Nil(t, NewOTLPExporter(types.TracingConfig{}))
+
+	tracer, closeTracer := NewTracerFromConfig(types.TracingConfig{})
+	assert.Nil(t, tracer)
+	assert.NoError(t, closeTracer())
+}
@@ -0,0 +1,146 @@
+package trace
+
+import (
+	"context"
+	"io"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Span attributes set by StorageWrapper
+const (
+	AttrStorageBackend   = "storage.backend"
+	AttrStorageOperation = "storage.operation"
+	AttrStoragePath      = "storage.path"
+	AttrStorageDest      = "storage.destination"
+)
+
+// StorageWrapper wraps a storage backend and records a span named
+// "storage.<operation>" for every call, with the backend, operation, path,
+// duration and error. Like the metrics wrapper it is itself a backend, so
+// it can be stacked with the other wrappers.
+type StorageWrapper struct {
+	backend types.StorageBackend
+	tracer  *Tracer
+}
+
+// NewStorageWrapper creates a storage wrapper that records spans with
+// tracer
+func NewStorageWrapper(backend types.StorageBackend, tracer *Tracer) *StorageWrapper {
+	return &StorageWrapper{
+		backend: backend,
+		tracer:  tracer,
+	}
+}
+
+// start begins the span for operation on path
+func (w *StorageWrapper) start(ctx context.Context, operation, path string) (context.Context, *Span) {
+	ctx, span := w.tracer.Start(ctx, "storage."+operation)
+	span.SetAttribute(AttrStorageBackend, string(w.backend.Type()))
+	span.SetAttribute(AttrStorageOperation, operation)
+	if path != "" {
+		span.SetAttribute(AttrStoragePath, path)
+	}
+	return ctx, span
+}
+
+// Type returns the storage backend type
+func (w *StorageWrapper) Type() types.StorageType {
+	return w.backend.Type()
+}
+
+// Read with tracing
+func (w *StorageWrapper) Read(ctx context.Context, path string) ([]byte, error) {
+	ctx, span := w.start(ctx, "read", path)
+	data, err := w.backend.Read(ctx, path)
+	span.Finish(err)
+	return data, err
+}
+
+// Write with tracing
+func (w *StorageWrapper) Write(ctx context.Context, path string, data []byte) error {
+	ctx, span := w.start(ctx, "write", path)
+	err := w.backend.Write(ctx, path, data)
+	span.Finish(err)
+	return err
+}
+
+// Delete with tracing
+func (w *StorageWrapper) Delete(ctx context.Context, path string) error {
+	ctx, span := w.start(ctx, "delete", path)
+	err := w.backend.Delete(ctx, path)
+	span.Finish(err)
+	return err
+}
+
+// Exists with tracing
+func (w *StorageWrapper) Exists(ctx context.Context, path string) (bool, error) {
+	ctx, span := w.start(ctx, "exists", path)
+	exists, err := w.backend.Exists(ctx, path)
+	span.Finish(err)
+	return exists, err
+}
+
+// List with tracing; the prefix is recorded as the path
+func (w *StorageWrapper) List(ctx context.Context, prefix string) ([]string, error) {
+	ctx, span := w.start(ctx, "list", prefix)
+	files, err := w.backend.List(ctx, prefix)
+	span.Finish(err)
+	return files, err
+}
+
+// Stat with tracing
+func (w *StorageWrapper) Stat(ctx context.Context, path string) (*types.FileInfo, error) {
+	ctx, span := w.start(ctx, "stat", path)
+	info, err := w.backend.Stat(ctx, path)
+	span.Finish(err)
+	return info, err
+}
+
+// ReadStream with tracing. The span covers opening the stream, not
+// reading it.
+func (w *StorageWrapper) ReadStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	ctx, span := w.start(ctx, "read_stream", path)
+	reader, err := w.backend.ReadStream(ctx, path)
+	span.Finish(err)
+	return reader, err
+}
+
+// WriteStream with tracing
+func (w *StorageWrapper) WriteStream(ctx context.Context, path string, reader io.Reader) error {
+	ctx, span := w.start(ctx, "write_stream", path)
+	err := w.backend.WriteStream(ctx, path, reader)
+	span.Finish(err)
+	return err
+}
+
+// Copy with tracing
+func (w *StorageWrapper) Copy(ctx context.Context, src, dst string) error {
+	ctx, span := w.start(ctx, "copy", src)
+	span.SetAttribute(AttrStorageDest, dst)
+	err := w.backend.Copy(ctx, src, dst)
+	span.Finish(err)
+	return err
+}
+
+// Move with tracing
+func (w *StorageWrapper) Move(ctx context.Context, src, dst string) error {
+	ctx, span := w.start(ctx, "move", src)
+	span.SetAttribute(AttrStorageDest, dst)
+	err := w.backend.Move(ctx, src, dst)
+	span.Finish(err)
+	return err
+}
+
+// Health with tracing
+func (w *StorageWrapper) Health(ctx context.Context) error {
+	ctx, span := w.start(ctx, "health", "")
+	err := w.backend.Health(ctx)
+	span.Finish(err)
+	return err
+}
+
+// Close delegates to underlying backend
+func (w *StorageWrapper) Close() error {
+	return w.backend.Close()
+}
@@ -0,0 +1,69 @@
+package trace
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestStorageWrapper(t *testing.T) {
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true})
+	require.NoError(t, err)
+
+	recorder := &Recorder{}
+	w := NewStorageWrapper(backend, NewTracer(recorder))
+	defer func() { _ = w.Close() }()
+	assert.Equal(t, types.StorageTypeLocal, w.Type())
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	require.NoError(t, w.Write(ctx, "notes/a.md", []byte("hello")))
+	_, err = w.Read(ctx, "notes/a.md")
+	require.NoError(t, err)
+	require.NoError(t, w.Copy(ctx, "notes/a.md", "notes/b.md"))
+	_, err = w.Read(ctx, "notes/missing.md")
+	require.Error(t, err)
+	require.NoError(t, w.WriteStream(ctx, "notes/c.md", strings.NewReader("streamed")))
+	_, err = w.List(ctx, "notes/")
+	require.NoError(t, err)
+	require.NoError(t, w.Health(ctx))
+
+	spans := recorder.Spans()
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+		assert.Equal(t, "req-42", span.Attributes[AttrRequestID], span.Name)
+		assert.Equal(t, "local", span.Attributes[AttrStorageBackend], span.Name)
+		assert.Equal(t, strings.TrimPrefix(span.Name, "storage."), span.Attributes[AttrStorageOperation])
+		assert.False(t, span.End.Before(span.Start), span.Name)
+	}
+	assert.Equal(t, []string{
+		"storage.write", "storage.read", "storage.copy", "storage.read",
+		"storage.write_stream", "storage.list", "storage.health",
+	}, names)
+
+	assert.Equal(t, "notes/a.md", spans[0].Attributes[AttrStoragePath])
+	assert.Empty(t, spans[0].Err)
+	assert.Equal(t, "notes/b.md", spans[2].Attributes[AttrStorageDest])
+	assert.Equal(t, "notes/missing.md", spans[3].Attributes[AttrStoragePath])
+	assert.NotEmpty(t, spans[3].Err, "failed operations record their error")
+	assert.Equal(t, "notes/", spans[5].Attributes[AttrStoragePath])
+	assert.NotContains(t, spans[6].Attributes, AttrStoragePath)
+}
+
+func TestStorageWrapper_NilTracer(t *testing.T) {
+	backend, err := local.New(types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true})
+	require.NoError(t, err)
+
+	// Without a tracer the wrapper only delegates
+	w := NewStorageWrapper(backend, nil)
+	require.NoError(t, w.Write(context.Background(), "a.md", []byte("x")))
+	data, err := w.Read(context.Background(), "a.md")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("x"), data)
+}
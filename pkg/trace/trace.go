@@ -0,0 +1,183 @@
+// Package trace carries request IDs through contexts and records spans for
+// operations such as storage calls, optionally exporting them to an
+// OpenTelemetry collector over OTLP.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// RequestIDHeader is the HTTP header request IDs are accepted from and
+// returned in
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// AttrRequestID is the span attribute holding the request ID
+const AttrRequestID = "request.id"
+
+type requestIDKey struct{}
+
+type spanKey struct{}
+
+// NewRequestID returns a random request ID of 32 hex characters
+func NewRequestID() string {
+	return randomHex(16)
+}
+
+// WithRequestID returns a copy of ctx carrying id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" when there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ValidRequestID reports whether id is acceptable as a request ID from a
+// client: non-empty, at most 128 characters and printable ASCII
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Span is a finished or running operation. Exporters receive spans once
+// they have ended and must not modify them.
+type Span struct {
+	Name string
+
+	// TraceID and SpanID are 32 and 16 hex characters; ParentID is the
+	// SpanID of the enclosing span, or "" for a root span
+	TraceID  string
+	SpanID   string
+	ParentID string
+
+	Start time.Time
+	End   time.Time
+
+	// Attributes describe the operation, such as the storage path
+	Attributes map[string]string
+
+	// Err is the error the operation ended with, or "" on success
+	Err string
+
+	tracer *Tracer
+}
+
+// Duration returns how long the span ran
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SetAttribute records key with value on the span. Calls on a nil span do
+// nothing.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.Attributes[key] = value
+}
+
+// Finish ends the span with err, which may be nil, and hands it to the
+// tracer's exporter. Calls on a nil span do nothing.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.End = time.Now()
+	if err != nil {
+		s.Err = err.Error()
+	}
+	s.tracer.exporter.ExportSpan(s)
+}
+
+// Exporter receives spans as they end. Implementations must be safe for
+// concurrent use.
+type Exporter interface {
+	ExportSpan(span *Span)
+}
+
+// Tracer starts spans and sends them to an exporter when they end. A nil
+// Tracer records nothing, so callers can trace unconditionally.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer returns a tracer exporting to exporter, or nil when exporter
+// is nil
+func NewTracer(exporter Exporter) *Tracer {
+	if exporter == nil {
+		return nil
+	}
+	return &Tracer{exporter: exporter}
+}
+
+// Start begins a span named name. It is a child of the span in ctx, if
+// any, and carries the request ID of ctx. The returned context holds the
+// new span for nested operations. Spans must be ended with Finish.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		Name:       name,
+		SpanID:     randomHex(8),
+		Start:      time.Now(),
+		Attributes: make(map[string]string),
+		tracer:     t,
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = randomHex(16)
+	}
+	if id := RequestID(ctx); id != "" {
+		span.Attributes[AttrRequestID] = id
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Recorder is an Exporter that keeps spans in memory, for tests and
+// debugging
+type Recorder struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// ExportSpan records span
+func (r *Recorder) ExportSpan(span *Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+// Spans returns the spans recorded so far, in the order they ended
+func (r *Recorder) Spans() []*Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Span(nil), r.spans...)
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,74 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, RequestID(ctx))
+
+	id := NewRequestID()
+	assert.Len(t, id, 32)
+	assert.NotEqual(t, id, NewRequestID())
+	assert.Equal(t, id, RequestID(WithRequestID(ctx, id)))
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID("req-123"))
+	assert.True(t, ValidRequestID(NewRequestID()))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("has space"))
+	assert.False(t, ValidRequestID("line\nbreak"))
+	assert.False(t, ValidRequestID("naïve"))
+	assert.False(t, ValidRequestID(strings.Repeat("a", 129)))
+}
+
+func TestTracer_Start(t *testing.T) {
+	recorder := &Recorder{}
+	tracer := NewTracer(recorder)
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	ctx, parent := tracer.Start(ctx, "search")
+	_, child := tracer.Start(ctx, "storage.read")
+	child.SetAttribute("storage.path", "notes/a.md")
+	child.Finish(errors.New("not found"))
+	parent.Finish(nil)
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "storage.read", spans[0].Name)
+	assert.Equal(t, "search", spans[1].Name)
+
+	// The child belongs to the parent's trace
+	assert.Len(t, spans[1].TraceID, 32)
+	assert.Len(t, spans[1].SpanID, 16)
+	assert.Empty(t, spans[1].ParentID)
+	assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentID)
+
+	assert.Equal(t, "req-1", spans[0].Attributes[AttrRequestID])
+	assert.Equal(t, "notes/a.md", spans[0].Attributes["storage.path"])
+	assert.Equal(t, "not found", spans[0].Err)
+	assert.Empty(t, spans[1].Err)
+	assert.Equal(t, spans[0].End.Sub(spans[0].Start), spans[0].Duration())
+}
+
+func TestTracer_Nil(t *testing.T) {
+	assert.Nil(t, NewTracer(nil))
+
+	// A nil tracer hands out nil spans, which ignore every call
+	var tracer *Tracer
+	ctx := context.Background()
+	got, span := tracer.Start(ctx, "search")
+	assert.Equal(t, ctx, got)
+	assert.Nil(t, span)
+	span.SetAttribute("k", "v")
+	span.Finish(errors.New("ignored"))
+}
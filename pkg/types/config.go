@@ -1,7 +1,21 @@
 package types
 
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// CurrentSchemaVersion is the configuration schema version written by this
+// build. Profiles with an older version are migrated when loaded.
+const CurrentSchemaVersion = 1
+
 // Config represents the complete kbVault configuration
 type Config struct {
+	// SchemaVersion identifies the layout of the configuration file
+	SchemaVersion int `toml:"schema_version" json:"schema_version"`
+
 	// Vault configuration
 	Vault VaultConfig `toml:"vault" json:"vault"`
 
@@ -14,6 +28,9 @@ type Config struct {
 	// Logging configuration
 	Logging LoggingConfig `toml:"logging" json:"logging"`
 
+	// Tracing configuration
+	Tracing TracingConfig `toml:"tracing" json:"tracing"`
+
 	// TUI configuration
 	TUI TUIConfig `toml:"tui" json:"tui"`
 
@@ -35,6 +52,14 @@ type VaultConfig struct {
 	// DailyDir is the subdirectory for daily notes
 	DailyDir string `toml:"daily_dir" json:"daily_dir"`
 
+	// ExtraDirs are further subdirectories that hold notes, listed and
+	// indexed along with NotesDir and DailyDir
+	ExtraDirs []string `toml:"extra_dirs" json:"extra_dirs"`
+
+	// Ignore lists .gitignore-style patterns for files that are left out
+	// when notes are listed, indexed and counted; see IgnorePatterns
+	Ignore []string `toml:"ignore" json:"ignore"`
+
 	// TemplatesDir is the subdirectory for note templates
 	TemplatesDir string `toml:"templates_dir" json:"templates_dir"`
 
@@ -50,11 +75,153 @@ type VaultConfig struct {
 	// TimeFormat for timestamps
 	TimeFormat string `toml:"time_format" json:"time_format"`
 
+	// TitleSource is the comma-separated order in which a note's title is
+	// taken from its frontmatter, first heading and filename
+	TitleSource string `toml:"title_source" json:"title_source"`
+
+	// IDScheme is how IDs, and so file names, are generated for new notes
+	IDScheme string `toml:"id_scheme" json:"id_scheme"`
+
 	// AutoSave enables automatic saving of modified notes
 	AutoSave bool `toml:"auto_save" json:"auto_save"`
 
 	// AutoSync enables automatic synchronization with remote storage
 	AutoSync bool `toml:"auto_sync" json:"auto_sync"`
+
+	// InlineTags adds #hashtags written in a note's body to the tags from
+	// its frontmatter when notes are listed and indexed
+	InlineTags bool `toml:"inline_tags" json:"inline_tags"`
+
+	// TrackChecksums records a checksum of the body in the frontmatter of
+	// notes as they are written, so kbvault verify can detect changes made
+	// outside kbvault
+	TrackChecksums bool `toml:"track_checksums" json:"track_checksums"`
+
+	// FrontmatterSchema lists the frontmatter fields notes must have and
+	// the values fields may take; checked when notes are created or
+	// edited and by kbvault doctor
+	FrontmatterSchema FrontmatterSchema `toml:"frontmatter_schema" json:"frontmatter_schema"`
+}
+
+// FrontmatterSchema constrains the frontmatter of notes. Fields are named
+// by their frontmatter key, such as "type", "tags" or a custom "author".
+type FrontmatterSchema struct {
+	// Required fields must be present with a non-empty value
+	Required []string `toml:"required" json:"required"`
+
+	// Allowed restricts fields to a set of values. Every element of a list
+	// field, such as tags, must be allowed. Fields not listed, and absent
+	// fields, are not restricted.
+	Allowed map[string][]string `toml:"allowed" json:"allowed"`
+}
+
+// IsZero reports whether the schema places no constraints on notes
+func (s FrontmatterSchema) IsZero() bool {
+	return len(s.Required) == 0 && len(s.Allowed) == 0
+}
+
+// Validate checks that the schema names its fields and gives each
+// restricted field at least one allowed value
+func (s FrontmatterSchema) Validate() error {
+	for _, field := range s.Required {
+		if strings.TrimSpace(field) == "" {
+			return NewValidationError("vault.frontmatter_schema.required cannot contain an empty field name")
+		}
+	}
+	for field, values := range s.Allowed {
+		if strings.TrimSpace(field) == "" {
+			return NewValidationError("vault.frontmatter_schema.allowed cannot contain an empty field name")
+		}
+		if len(values) == 0 {
+			return NewValidationError(fmt.Sprintf("vault.frontmatter_schema.allowed.%s must list at least one value", field))
+		}
+	}
+	return nil
+}
+
+// NoteDirs returns the storage directories notes are kept in, as listing
+// prefixes: the vault root (""), then NotesDir, DailyDir and ExtraDirs with
+// a trailing slash. Duplicates and empty entries are dropped. Only the
+// directories themselves hold notes; their subdirectories are not included.
+func (v VaultConfig) NoteDirs() []string {
+	dirs := []string{""}
+	seen := map[string]bool{"": true}
+	for _, dir := range append([]string{v.NotesDir, v.DailyDir}, v.ExtraDirs...) {
+		dir = strings.Trim(path.Clean("/"+strings.ReplaceAll(dir, "\\", "/")), "/")
+		if dir != "" {
+			dir += "/"
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// TitleSource is a place a note's title can be read from
+type TitleSource string
+
+const (
+	TitleSourceFrontmatter TitleSource = "frontmatter" // title field in frontmatter
+	TitleSourceHeading     TitleSource = "heading"     // first "# " heading in the body
+	TitleSourceFilename    TitleSource = "filename"    // file name without extension
+)
+
+// DefaultTitleSource is the default title precedence
+const DefaultTitleSource = "frontmatter,heading,filename"
+
+// ParseTitleSources parses a comma-separated title precedence such as
+// "heading,frontmatter". An empty string yields the default order.
+func ParseTitleSources(value string) ([]TitleSource, error) {
+	if strings.TrimSpace(value) == "" {
+		value = DefaultTitleSource
+	}
+
+	var sources []TitleSource
+	seen := make(map[TitleSource]bool)
+	for _, part := range strings.Split(value, ",") {
+		source := TitleSource(strings.ToLower(strings.TrimSpace(part)))
+		switch source {
+		case TitleSourceFrontmatter, TitleSourceHeading, TitleSourceFilename:
+		default:
+			return nil, NewValidationError(fmt.Sprintf("invalid title source %q: must be frontmatter, heading or filename", part))
+		}
+		if seen[source] {
+			return nil, NewValidationError(fmt.Sprintf("title source %q is listed more than once", source))
+		}
+		seen[source] = true
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// IDScheme is a way of generating IDs for new notes
+type IDScheme string
+
+const (
+	IDSchemeULID          IDScheme = "ulid"           // sortable random ID; title only in frontmatter
+	IDSchemeTimestamp     IDScheme = "timestamp"      // creation time to the millisecond; title only in frontmatter
+	IDSchemeSlug          IDScheme = "slug"           // title in lowercase with hyphens; not unique
+	IDSchemeSlugTimestamp IDScheme = "slug-timestamp" // slug followed by the creation time
+)
+
+// DefaultIDScheme is the ID scheme used when none is configured
+const DefaultIDScheme = IDSchemeULID
+
+// ParseIDScheme parses an ID scheme name. An empty string yields the
+// default scheme.
+func ParseIDScheme(value string) (IDScheme, error) {
+	scheme := IDScheme(strings.ToLower(strings.TrimSpace(value)))
+	switch scheme {
+	case "":
+		return DefaultIDScheme, nil
+	case IDSchemeULID, IDSchemeTimestamp, IDSchemeSlug, IDSchemeSlugTimestamp:
+		return scheme, nil
+	default:
+		return "", NewValidationError(fmt.Sprintf("invalid id scheme %q: must be ulid, timestamp, slug or slug-timestamp", value))
+	}
 }
 
 // ServerConfig contains HTTP and gRPC server settings
@@ -83,9 +250,16 @@ type HTTPServerConfig struct {
 	// EnableCORS allows cross-origin requests
 	EnableCORS bool `toml:"enable_cors" json:"enable_cors"`
 
-	// CORSOrigins specifies allowed CORS origins
+	// CORSOrigins specifies allowed CORS origins: "*" for any origin, an
+	// exact origin such as "https://app.example.com", or a subdomain
+	// wildcard such as "https://*.example.com". Without a scheme, any
+	// scheme matches.
 	CORSOrigins []string `toml:"cors_origins" json:"cors_origins"`
 
+	// CORSRoutes replaces CORSOrigins for requests under a path prefix; the
+	// longest matching prefix wins
+	CORSRoutes []CORSRoute `toml:"cors_routes" json:"cors_routes,omitempty"`
+
 	// ReadTimeout for requests (seconds)
 	ReadTimeout int `toml:"read_timeout" json:"read_timeout"`
 
@@ -98,10 +272,30 @@ type HTTPServerConfig struct {
 	// MaxRequestSize limits request body size (bytes)
 	MaxRequestSize int64 `toml:"max_request_size" json:"max_request_size"`
 
+	// EnableMetrics serves Prometheus metrics on GET /metrics
+	EnableMetrics bool `toml:"enable_metrics" json:"enable_metrics"`
+
+	// MaxWebSocketConnections limits concurrent live search connections
+	// (0 = unlimited)
+	MaxWebSocketConnections int `toml:"max_websocket_connections" json:"max_websocket_connections"`
+
+	// SearchDebounceMS delays a live search until the client has stopped
+	// sending queries for this many milliseconds
+	SearchDebounceMS int `toml:"search_debounce_ms" json:"search_debounce_ms"`
+
 	// TLS configuration
 	TLS TLSConfig `toml:"tls" json:"tls"`
 }
 
+// CORSRoute sets the CORS origins allowed for requests under a path prefix
+type CORSRoute struct {
+	// Prefix is matched against the request path, such as "/api/notes"
+	Prefix string `toml:"prefix" json:"prefix"`
+
+	// Origins are the allowed origins, in the same forms as CORSOrigins
+	Origins []string `toml:"origins" json:"origins"`
+}
+
 // GRPCServerConfig configures the gRPC server
 type GRPCServerConfig struct {
 	// Enabled turns the gRPC server on/off
@@ -195,6 +389,25 @@ type RateLimitConfig struct {
 	BurstSize int `toml:"burst_size" json:"burst_size"`
 }
 
+// TracingConfig configures request tracing. Operations carry a request ID
+// and record spans, which are exported to an OpenTelemetry collector when
+// OTLPEndpoint is set.
+type TracingConfig struct {
+	// OTLPEndpoint is the base URL of a collector's OTLP/HTTP receiver,
+	// such as "http://localhost:4318"; spans are sent to
+	// <endpoint>/v1/traces. Empty disables export.
+	OTLPEndpoint string `toml:"otlp_endpoint" json:"otlp_endpoint"`
+
+	// ServiceName identifies kbvault in exported spans (default "kbvault")
+	ServiceName string `toml:"service_name" json:"service_name"`
+}
+
+// LogLevels are the logging.level values, from most to least verbose
+var LogLevels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// LogFormats are the logging.format values
+var LogFormats = []string{"text", "json"}
+
 // LoggingConfig configures application logging
 type LoggingConfig struct {
 	// Level sets the log level (DEBUG, INFO, WARN, ERROR)
@@ -244,6 +457,9 @@ type TUIConfig struct {
 
 	// EnableMouse allows mouse interaction
 	EnableMouse bool `toml:"enable_mouse" json:"enable_mouse"`
+
+	// EnableColors allows ANSI colors in terminal output
+	EnableColors bool `toml:"enable_colors" json:"enable_colors"`
 }
 
 // MCPConfig configures Model Context Protocol integration
@@ -273,6 +489,7 @@ type MCPConfig struct {
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
+		SchemaVersion: CurrentSchemaVersion,
 		Vault: VaultConfig{
 			Name:            "my-kb",
 			NotesDir:        "notes",
@@ -282,11 +499,16 @@ func DefaultConfig() *Config {
 			MaxFileSize:     10 * 1024 * 1024, // 10MB
 			DateFormat:      "2006-01-02",
 			TimeFormat:      "15:04:05",
+			TitleSource:     DefaultTitleSource,
+			IDScheme:        string(DefaultIDScheme),
+			InlineTags:      true,
 			AutoSave:        true,
 			AutoSync:        false,
 		},
 		Storage: StorageConfig{
-			Type: StorageTypeLocal,
+			Type:                StorageTypeLocal,
+			Compression:         CompressionNone,
+			CompressionStatSize: CompressionSizeStored,
 			Local: LocalStorageConfig{
 				Path:          "./vault",
 				CreateDirs:    true,
@@ -324,6 +546,9 @@ func DefaultConfig() *Config {
 				WriteTimeout:   30,
 				IdleTimeout:    60,
 				MaxRequestSize: 10 * 1024 * 1024, // 10MB
+
+				MaxWebSocketConnections: 100,
+				SearchDebounceMS:        150,
 			},
 			GRPC: GRPCServerConfig{
 				Enabled:              false,
@@ -362,6 +587,7 @@ func DefaultConfig() *Config {
 			RefreshInterval: 30,
 			PageSize:        20,
 			EnableMouse:     true,
+			EnableColors:    true,
 		},
 		MCP: MCPConfig{
 			Enabled:              true,
@@ -385,10 +611,27 @@ func (c *Config) Validate() error {
 	if c.Vault.MaxFileSize <= 0 {
 		return NewValidationError("vault max_file_size must be positive")
 	}
+	if _, err := ParseTitleSources(c.Vault.TitleSource); err != nil {
+		return err
+	}
+	if _, err := ParseIDScheme(c.Vault.IDScheme); err != nil {
+		return err
+	}
+	if err := c.Vault.FrontmatterSchema.Validate(); err != nil {
+		return err
+	}
+	if _, err := ParseIgnorePatterns(c.Vault.Ignore); err != nil {
+		return err
+	}
+	for _, dir := range c.Vault.ExtraDirs {
+		if dir == "" || path.IsAbs(dir) || slices.Contains(strings.Split(strings.ReplaceAll(dir, "\\", "/"), "/"), "..") {
+			return NewValidationError(fmt.Sprintf("vault extra_dirs entry %q must be a relative directory inside the vault", dir))
+		}
+	}
 
 	// Validate storage config
-	if c.Storage.Type != StorageTypeLocal && c.Storage.Type != StorageTypeS3 {
-		return NewValidationError("storage type must be 'local' or 's3'")
+	if err := c.Storage.validate(); err != nil {
+		return err
 	}
 
 	// Validate server config
@@ -396,6 +639,15 @@ func (c *Config) Validate() error {
 		if c.Server.HTTP.Port <= 0 || c.Server.HTTP.Port > 65535 {
 			return NewValidationError("HTTP port must be between 1 and 65535")
 		}
+		if c.Server.HTTP.MaxWebSocketConnections < 0 {
+			return NewValidationError("server.http.max_websocket_connections cannot be negative")
+		}
+		if c.Server.HTTP.SearchDebounceMS < 0 {
+			return NewValidationError("server.http.search_debounce_ms cannot be negative")
+		}
+		if err := c.Server.HTTP.validateCORS(); err != nil {
+			return err
+		}
 	}
 	if c.Server.GRPC.Enabled {
 		if c.Server.GRPC.Port <= 0 || c.Server.GRPC.Port > 65535 {
@@ -411,5 +663,146 @@ func (c *Config) Validate() error {
 		return NewValidationError("auth type must be 'none', 'apikey', or 'jwt'")
 	}
 
+	// Validate vector search config
+	if c.VectorSearch.Embedding.RateLimitRPM < 0 {
+		return NewValidationError("vector_search.embedding.rate_limit_rpm cannot be negative")
+	}
+	if w := c.VectorSearch.Search.HybridWeight; w < 0 || w > 1 {
+		return NewValidationError("vector_search.search.hybrid_weight must be between 0 and 1")
+	}
+	if search := c.VectorSearch.Search; search.EnableReranking {
+		model := strings.TrimSpace(search.RerankingModel)
+		if model != "" && !strings.EqualFold(model, "rrf") && search.RerankingEndpoint == "" {
+			return NewValidationError("vector_search.search.reranking_endpoint is required for reranking model " + model)
+		}
+	}
+
 	return nil
 }
+
+// validate checks the settings of the selected backend and the cache
+func (s *StorageConfig) validate() error {
+	switch s.Type {
+	case StorageTypeLocal:
+		if s.Local.Path == "" {
+			return NewValidationError("storage.local.path cannot be empty")
+		}
+		if s.Local.VersionRetention < 0 {
+			return NewValidationError("storage.local.version_retention cannot be negative")
+		}
+	case StorageTypeS3:
+		if s.S3.Bucket == "" {
+			return NewValidationError("storage.s3.bucket is required for s3 storage")
+		}
+		if s.S3.Region == "" {
+			return NewValidationError("storage.s3.region is required for s3 storage")
+		}
+		if _, err := ParseS3CredentialSource(s.S3.CredentialSource); err != nil {
+			return err
+		}
+		if s.S3.UploadPartSizeMB != 0 && s.S3.UploadPartSizeMB < 5 {
+			return NewValidationError("storage.s3.upload_part_size_mb must be at least 5")
+		}
+		if s.S3.UploadConcurrency < 0 {
+			return NewValidationError("storage.s3.upload_concurrency cannot be negative")
+		}
+		if s.S3.StorageClass != "" && !validS3StorageClass(s.S3.StorageClass) {
+			return NewValidationError(fmt.Sprintf("storage.s3.storage_class %q is not a valid S3 storage class (valid: %s)",
+				s.S3.StorageClass, strings.Join(S3StorageClasses, ", ")))
+		}
+		for i, rule := range s.S3.StorageClassRules {
+			if rule.Prefix == "" {
+				return NewValidationError(fmt.Sprintf("storage.s3.storage_class_rules[%d].prefix cannot be empty", i))
+			}
+			if !validS3StorageClass(rule.Class) {
+				return NewValidationError(fmt.Sprintf("storage.s3.storage_class_rules[%d].class %q is not a valid S3 storage class (valid: %s)",
+					i, rule.Class, strings.Join(S3StorageClasses, ", ")))
+			}
+		}
+	case StorageTypeAzure:
+		if s.Azure.ContainerName == "" {
+			return NewValidationError("storage.azure.container_name is required for azure storage")
+		}
+	default:
+		return NewValidationError("storage type must be 'local', 's3', or 'azure'")
+	}
+
+	cacheSizes := []struct {
+		key   string
+		value int
+	}{
+		{"storage.cache.memory.max_size_mb", s.Cache.Memory.MaxSizeMB},
+		{"storage.cache.memory.max_items", s.Cache.Memory.MaxItems},
+		{"storage.cache.memory.ttl_minutes", s.Cache.Memory.TTLMinutes},
+		{"storage.cache.disk.max_size_mb", s.Cache.Disk.MaxSizeMB},
+		{"storage.cache.disk.ttl_hours", s.Cache.Disk.TTLHours},
+		{"storage.cache.disk.cleanup_interval_hours", s.Cache.Disk.CleanupIntervalHours},
+	}
+	for _, size := range cacheSizes {
+		if size.value < 0 {
+			return NewValidationError(size.key + " cannot be negative")
+		}
+	}
+
+	switch strings.ToLower(s.Compression) {
+	case "", CompressionNone, CompressionGzip:
+	default:
+		return NewValidationError(fmt.Sprintf("storage.compression %q must be none or gzip", s.Compression))
+	}
+	switch strings.ToLower(s.CompressionStatSize) {
+	case "", CompressionSizeStored, CompressionSizeLogical:
+	default:
+		return NewValidationError(fmt.Sprintf("storage.compression_stat_size %q must be stored or logical", s.CompressionStatSize))
+	}
+
+	if s.Encryption.Enabled {
+		switch {
+		case s.Encryption.Passphrase == "" && s.Encryption.KeyFile == "":
+			return NewValidationError("storage.encryption requires a passphrase or key_file")
+		case s.Encryption.Passphrase != "" && s.Encryption.KeyFile != "":
+			return NewValidationError("storage.encryption.passphrase and key_file cannot both be set")
+		}
+	}
+
+	return nil
+}
+
+// validateCORS checks the CORS origin patterns and route prefixes
+func (c HTTPServerConfig) validateCORS() error {
+	for _, origin := range c.CORSOrigins {
+		if !validCORSOrigin(origin) {
+			return NewValidationError(fmt.Sprintf("server.http.cors_origins entry %q must be \"*\" or an origin such as https://app.example.com or *.example.com", origin))
+		}
+	}
+	for _, route := range c.CORSRoutes {
+		if !strings.HasPrefix(route.Prefix, "/") {
+			return NewValidationError(fmt.Sprintf("server.http.cors_routes prefix %q must start with /", route.Prefix))
+		}
+		for _, origin := range route.Origins {
+			if !validCORSOrigin(origin) {
+				return NewValidationError(fmt.Sprintf("server.http.cors_routes origin %q for %s must be \"*\" or an origin such as https://app.example.com or *.example.com", origin, route.Prefix))
+			}
+		}
+	}
+	return nil
+}
+
+// validCORSOrigin reports whether origin is "*" or a host with an optional
+// scheme, port and "*." subdomain wildcard, and no path
+func validCORSOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	host := origin
+	if scheme, rest, ok := strings.Cut(origin, "://"); ok {
+		if scheme == "" {
+			return false
+		}
+		host = rest
+	}
+	if strings.ContainsAny(host, "/?#") {
+		return false
+	}
+	host = strings.TrimPrefix(host, "*.")
+	return host != "" && !strings.Contains(host, "*")
+}
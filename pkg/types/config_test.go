@@ -1,6 +1,8 @@
 package types
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -83,6 +85,65 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "extra dirs",
+			modifyFunc: func(c *Config) {
+				c.Vault.ExtraDirs = []string{"archive", "projects/work"}
+			},
+			expectError: false,
+		},
+		{
+			name: "absolute extra dir",
+			modifyFunc: func(c *Config) {
+				c.Vault.ExtraDirs = []string{"/srv/notes"}
+			},
+			expectError: true,
+		},
+		{
+			name: "extra dir outside vault",
+			modifyFunc: func(c *Config) {
+				c.Vault.ExtraDirs = []string{"../shared"}
+			},
+			expectError: true,
+		},
+		{
+			name: "frontmatter schema",
+			modifyFunc: func(c *Config) {
+				c.Vault.FrontmatterSchema = FrontmatterSchema{
+					Required: []string{"author", "status"},
+					Allowed:  map[string][]string{"status": {"draft", "published"}},
+				}
+			},
+			expectError: false,
+		},
+		{
+			name: "frontmatter schema with empty required field",
+			modifyFunc: func(c *Config) {
+				c.Vault.FrontmatterSchema.Required = []string{"author", " "}
+			},
+			expectError: true,
+		},
+		{
+			name: "frontmatter schema without allowed values",
+			modifyFunc: func(c *Config) {
+				c.Vault.FrontmatterSchema.Allowed = map[string][]string{"status": {}}
+			},
+			expectError: true,
+		},
+		{
+			name: "ignore patterns",
+			modifyFunc: func(c *Config) {
+				c.Vault.Ignore = []string{".obsidian/", "*.draft.md"}
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid ignore pattern",
+			modifyFunc: func(c *Config) {
+				c.Vault.Ignore = []string{"notes/[a"}
+			},
+			expectError: true,
+		},
 		{
 			name: "invalid storage type",
 			modifyFunc: func(c *Config) {
@@ -129,6 +190,63 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "invalid title source",
+			modifyFunc: func(c *Config) {
+				c.Vault.TitleSource = "heading,path"
+			},
+			expectError: true,
+		},
+		{
+			name: "s3 upload part size below minimum",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "kb"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.UploadPartSizeMB = 4
+			},
+			expectError: true,
+		},
+		{
+			name: "cors origins and routes",
+			modifyFunc: func(c *Config) {
+				c.Server.HTTP.CORSOrigins = []string{"https://app.example.com", "https://*.example.com", "*.example.dev", "http://localhost:3000"}
+				c.Server.HTTP.CORSRoutes = []CORSRoute{{Prefix: "/api", Origins: []string{"*"}}}
+			},
+			expectError: false,
+		},
+		{
+			name: "cors origin with path",
+			modifyFunc: func(c *Config) {
+				c.Server.HTTP.CORSOrigins = []string{"https://app.example.com/notes"}
+			},
+			expectError: true,
+		},
+		{
+			name: "cors origin with inner wildcard",
+			modifyFunc: func(c *Config) {
+				c.Server.HTTP.CORSOrigins = []string{"https://app.*.com"}
+			},
+			expectError: true,
+		},
+		{
+			name: "cors route without leading slash",
+			modifyFunc: func(c *Config) {
+				c.Server.HTTP.CORSRoutes = []CORSRoute{{Prefix: "api", Origins: []string{"*"}}}
+			},
+			expectError: true,
+		},
+		{
+			name: "s3 upload tuning",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "kb"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.UploadPartSizeMB = 16
+				c.Storage.S3.UploadConcurrency = 10
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -148,6 +266,375 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_ValidateStorageAndVector(t *testing.T) {
+	testCases := []struct {
+		name        string
+		modifyFunc  func(*Config)
+		errContains string
+	}{
+		{
+			name:       "local with path",
+			modifyFunc: func(c *Config) {},
+		},
+		{
+			name: "local without path",
+			modifyFunc: func(c *Config) {
+				c.Storage.Local.Path = ""
+			},
+			errContains: "storage.local.path",
+		},
+		{
+			name: "gzip compression with logical sizes",
+			modifyFunc: func(c *Config) {
+				c.Storage.Compression = "gzip"
+				c.Storage.CompressionStatSize = "logical"
+			},
+		},
+		{
+			name: "unknown compression",
+			modifyFunc: func(c *Config) {
+				c.Storage.Compression = "zstd"
+			},
+			errContains: "storage.compression",
+		},
+		{
+			name: "unknown compression stat size",
+			modifyFunc: func(c *Config) {
+				c.Storage.CompressionStatSize = "compressed"
+			},
+			errContains: "storage.compression_stat_size",
+		},
+		{
+			name: "encryption with a key file",
+			modifyFunc: func(c *Config) {
+				c.Storage.Encryption = EncryptionConfig{Enabled: true, KeyFile: "/etc/kbvault/key"}
+			},
+		},
+		{
+			name: "encryption without a key",
+			modifyFunc: func(c *Config) {
+				c.Storage.Encryption.Enabled = true
+			},
+			errContains: "requires a passphrase or key_file",
+		},
+		{
+			name: "encryption with both keys",
+			modifyFunc: func(c *Config) {
+				c.Storage.Encryption = EncryptionConfig{Enabled: true, Passphrase: "secret", KeyFile: "/etc/kbvault/key"}
+			},
+			errContains: "cannot both be set",
+		},
+		{
+			name: "s3 with bucket and region",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+				c.Storage.S3.Region = "us-east-1"
+			},
+		},
+		{
+			name: "s3 without bucket",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Region = "us-east-1"
+			},
+			errContains: "storage.s3.bucket",
+		},
+		{
+			name: "s3 without region",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+			},
+			errContains: "storage.s3.region",
+		},
+		{
+			name: "s3 with storage class rules",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.StorageClass = "STANDARD"
+				c.Storage.S3.StorageClassRules = []StorageClassRule{{Prefix: "daily/", Class: "STANDARD_IA"}}
+			},
+		},
+		{
+			name: "s3 with unknown storage class",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.StorageClass = "CHEAP"
+			},
+			errContains: "storage.s3.storage_class",
+		},
+		{
+			name: "s3 rule with unknown storage class",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.StorageClassRules = []StorageClassRule{{Prefix: "daily/", Class: "standard_ia"}}
+			},
+			errContains: "storage.s3.storage_class_rules[0].class",
+		},
+		{
+			name: "s3 with role credentials",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.CredentialSource = "role:arn:aws:iam::123456789012:role/kb"
+			},
+		},
+		{
+			name: "s3 with unknown credential source",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.CredentialSource = "instance"
+			},
+			errContains: "storage.s3.credential_source",
+		},
+		{
+			name: "s3 rule without prefix",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeS3
+				c.Storage.S3.Bucket = "bucket"
+				c.Storage.S3.Region = "us-east-1"
+				c.Storage.S3.StorageClassRules = []StorageClassRule{{Class: "GLACIER"}}
+			},
+			errContains: "storage.s3.storage_class_rules[0].prefix",
+		},
+		{
+			name: "s3 settings ignored for local storage",
+			modifyFunc: func(c *Config) {
+				c.Storage.S3.Bucket = ""
+				c.Storage.S3.Region = ""
+			},
+		},
+		{
+			name: "azure with container",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeAzure
+				c.Storage.Azure.ContainerName = "vault"
+			},
+		},
+		{
+			name: "azure without container",
+			modifyFunc: func(c *Config) {
+				c.Storage.Type = StorageTypeAzure
+			},
+			errContains: "storage.azure.container_name",
+		},
+		{
+			name: "zero cache sizes",
+			modifyFunc: func(c *Config) {
+				c.Storage.Cache.Memory.MaxSizeMB = 0
+				c.Storage.Cache.Memory.MaxItems = 0
+				c.Storage.Cache.Disk.MaxSizeMB = 0
+			},
+		},
+		{
+			name: "negative memory cache size",
+			modifyFunc: func(c *Config) {
+				c.Storage.Cache.Memory.MaxSizeMB = -1
+			},
+			errContains: "storage.cache.memory.max_size_mb",
+		},
+		{
+			name: "negative memory cache items",
+			modifyFunc: func(c *Config) {
+				c.Storage.Cache.Memory.MaxItems = -5
+			},
+			errContains: "storage.cache.memory.max_items",
+		},
+		{
+			name: "negative disk cache size",
+			modifyFunc: func(c *Config) {
+				c.Storage.Cache.Disk.MaxSizeMB = -1
+			},
+			errContains: "storage.cache.disk.max_size_mb",
+		},
+		{
+			name: "hybrid weight lower bound",
+			modifyFunc: func(c *Config) {
+				c.VectorSearch.Search.HybridWeight = 0
+			},
+		},
+		{
+			name: "hybrid weight upper bound",
+			modifyFunc: func(c *Config) {
+				c.VectorSearch.Search.HybridWeight = 1
+			},
+		},
+		{
+			name: "negative hybrid weight",
+			modifyFunc: func(c *Config) {
+				c.VectorSearch.Search.HybridWeight = -0.1
+			},
+			errContains: "hybrid_weight",
+		},
+		{
+			name: "hybrid weight above one",
+			modifyFunc: func(c *Config) {
+				c.VectorSearch.Search.HybridWeight = 1.5
+			},
+			errContains: "hybrid_weight",
+		},
+		{
+			name: "negative embedding rate limit",
+			modifyFunc: func(c *Config) {
+				c.VectorSearch.Embedding.RateLimitRPM = -1
+			},
+			errContains: "rate_limit_rpm",
+		},
+		{
+			name: "rrf reranking",
+			modifyFunc: func(c *Config) {
+				c.VectorSearch.Search.EnableReranking = true
+			},
+		},
+		{
+			name: "reranking model without endpoint",
+			modifyFunc: func(c *Config) {
+				c.VectorSearch.Search.EnableReranking = true
+				c.VectorSearch.Search.RerankingModel = "bge-reranker-base"
+			},
+			errContains: "reranking_endpoint",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.modifyFunc(config)
+
+			err := config.Validate()
+
+			if tc.errContains == "" {
+				if err != nil {
+					t.Errorf("Expected no validation error, got: %v", err)
+				}
+				return
+			}
+
+			var kbErr *KBError
+			if !errors.As(err, &kbErr) || !kbErr.IsType(ErrorTypeValidation) {
+				t.Fatalf("Expected validation error, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.errContains) {
+				t.Errorf("Expected error containing %q, got: %v", tc.errContains, err)
+			}
+		})
+	}
+}
+
+func TestS3StorageConfig_StorageClassFor(t *testing.T) {
+	config := S3StorageConfig{
+		StorageClass: "STANDARD",
+		StorageClassRules: []StorageClassRule{
+			{Prefix: "daily/2023/", Class: "GLACIER_IR"},
+			{Prefix: "daily/", Class: "STANDARD_IA"},
+			{Prefix: "/archive/", Class: "DEEP_ARCHIVE"},
+		},
+	}
+
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{"daily/2024-01-15.md", "STANDARD_IA"},
+		{"daily/2023/2023-06-01.md", "GLACIER_IR"},
+		{"/daily/2024-01-15.md", "STANDARD_IA"},
+		{"archive/old.md", "DEEP_ARCHIVE"},
+		{"notes/01ABC.md", "STANDARD"},
+		{"dailyish.md", "STANDARD"},
+	}
+
+	for _, tc := range testCases {
+		if got := config.StorageClassFor(tc.path); got != tc.want {
+			t.Errorf("StorageClassFor(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+
+	if got := (S3StorageConfig{}).StorageClassFor("notes/a.md"); got != "" {
+		t.Errorf("StorageClassFor without classes = %q, want empty", got)
+	}
+}
+
+func TestParseTitleSources(t *testing.T) {
+	testCases := []struct {
+		value       string
+		want        []TitleSource
+		expectError bool
+	}{
+		{"", []TitleSource{TitleSourceFrontmatter, TitleSourceHeading, TitleSourceFilename}, false},
+		{"heading, frontmatter", []TitleSource{TitleSourceHeading, TitleSourceFrontmatter}, false},
+		{"filename", []TitleSource{TitleSourceFilename}, false},
+		{"heading,path", nil, true},
+		{"heading,heading", nil, true},
+		{"heading,,filename", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseTitleSources(tc.value)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tc.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("Expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseIDScheme(t *testing.T) {
+	testCases := []struct {
+		value       string
+		want        IDScheme
+		expectError bool
+	}{
+		{"", IDSchemeULID, false},
+		{"ulid", IDSchemeULID, false},
+		{" Timestamp ", IDSchemeTimestamp, false},
+		{"slug", IDSchemeSlug, false},
+		{"slug-timestamp", IDSchemeSlugTimestamp, false},
+		{"uuid", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseIDScheme(tc.value)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tc.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
 func TestStorageType_Constants(t *testing.T) {
 	if StorageTypeLocal != "local" {
 		t.Errorf("Expected StorageTypeLocal to be 'local', got %s", StorageTypeLocal)
@@ -179,6 +666,30 @@ func TestVaultConfig_Defaults(t *testing.T) {
 	}
 }
 
+func TestVaultConfig_NoteDirs(t *testing.T) {
+	testCases := []struct {
+		name  string
+		vault VaultConfig
+		want  []string
+	}{
+		{"defaults", DefaultConfig().Vault, []string{"", "notes/", "notes/dailies/"}},
+		{"extra dirs", VaultConfig{NotesDir: "kb", DailyDir: "journal", ExtraDirs: []string{"archive/", "projects/work"}},
+			[]string{"", "kb/", "journal/", "archive/", "projects/work/"}},
+		{"duplicates and slashes", VaultConfig{NotesDir: "./notes/", DailyDir: "notes", ExtraDirs: []string{`notes\`, "/"}},
+			[]string{"", "notes/"}},
+		{"empty", VaultConfig{}, []string{""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.vault.NoteDirs()
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("NoteDirs() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestHTTPServerConfig_Defaults(t *testing.T) {
 	config := DefaultConfig()
 	http := config.Server.HTTP
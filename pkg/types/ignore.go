@@ -0,0 +1,103 @@
+package types
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// IgnorePatterns matches storage paths against vault.ignore patterns,
+// which follow .gitignore conventions for the common cases:
+//
+//   - a pattern without a slash, such as ".DS_Store" or "*.draft.md",
+//     matches a file or directory name at any depth
+//   - a pattern with a slash, such as "notes/drafts" or "/templates", is
+//     matched against the path from the vault root
+//   - a trailing slash, as in "drafts/", matches directories only
+//   - everything below an ignored directory is ignored
+//
+// Patterns use path.Match syntax. "**" and negated patterns are not
+// supported.
+type IgnorePatterns struct {
+	patterns []ignorePattern
+}
+
+// ignorePattern is one parsed vault.ignore pattern
+type ignorePattern struct {
+	glob     string
+	anchored bool
+	dirOnly  bool
+}
+
+// ParseIgnorePatterns parses vault.ignore patterns. No patterns yield nil,
+// which ignores nothing.
+func ParseIgnorePatterns(patterns []string) (*IgnorePatterns, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	m := &IgnorePatterns{}
+	for _, raw := range patterns {
+		glob := strings.TrimSpace(raw)
+		if strings.HasPrefix(glob, "!") {
+			return nil, NewValidationError(fmt.Sprintf("invalid vault.ignore pattern %q: negated patterns are not supported", raw))
+		}
+
+		p := ignorePattern{dirOnly: strings.HasSuffix(glob, "/")}
+		glob = strings.TrimRight(glob, "/")
+		p.anchored = strings.Contains(glob, "/")
+		p.glob = strings.TrimLeft(glob, "/")
+		if p.glob == "" {
+			return nil, NewValidationError(fmt.Sprintf("invalid vault.ignore pattern %q: pattern is empty", raw))
+		}
+		if _, err := path.Match(p.glob, ""); err != nil {
+			return nil, NewValidationError(fmt.Sprintf("invalid vault.ignore pattern %q: %v", raw, err))
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// Match reports whether p, a path from the vault root, is ignored. A nil
+// *IgnorePatterns ignores nothing.
+func (m *IgnorePatterns) Match(p string) bool {
+	if m == nil {
+		return false
+	}
+
+	parts := strings.Split(strings.Trim(strings.ReplaceAll(p, "\\", "/"), "/"), "/")
+	for _, pattern := range m.patterns {
+		if pattern.match(parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the path made of parts, or one of the directories
+// it is in, matches the pattern
+func (p ignorePattern) match(parts []string) bool {
+	// Directory-only patterns can't match the file itself
+	candidates := len(parts)
+	if p.dirOnly {
+		candidates--
+	}
+
+	if p.anchored {
+		// A "*" doesn't match "/", so the pattern can only match the
+		// leading path with as many elements as it has
+		n := strings.Count(p.glob, "/") + 1
+		if n > candidates {
+			return false
+		}
+		ok, _ := path.Match(p.glob, strings.Join(parts[:n], "/"))
+		return ok
+	}
+
+	for _, part := range parts[:candidates] {
+		if ok, _ := path.Match(p.glob, part); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package types
+
+import "testing"
+
+func TestIgnorePatterns_Match(t *testing.T) {
+	ignore, err := ParseIgnorePatterns([]string{".obsidian/", "*.draft.md", "notes/private", "/templates/", " scratch.md "})
+	if err != nil {
+		t.Fatalf("ParseIgnorePatterns: %v", err)
+	}
+
+	testCases := []struct {
+		path string
+		want bool
+	}{
+		{".obsidian/workspace.md", true},
+		{"notes/.obsidian/plugins/x.md", true},
+		{".obsidian", false}, // a file of that name, not the directory
+		{"notes/ideas.draft.md", true},
+		{"ideas.md", false},
+		{"notes/private/secret.md", true},
+		{"notes/private", true},
+		{"notes/privateer.md", false},
+		{"archive/notes/private/secret.md", false},
+		{"templates/daily.md", true},
+		{"notes/templates/daily.md", false},
+		{"scratch.md", true},
+		{`notes\private\secret.md`, true},
+	}
+	for _, tc := range testCases {
+		if got := ignore.Match(tc.path); got != tc.want {
+			t.Errorf("Match(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+
+	var none *IgnorePatterns
+	if none.Match("notes/a.md") {
+		t.Error("nil IgnorePatterns should match nothing")
+	}
+}
+
+func TestParseIgnorePatterns(t *testing.T) {
+	ignore, err := ParseIgnorePatterns(nil)
+	if err != nil || ignore != nil {
+		t.Errorf("ParseIgnorePatterns(nil) = %v, %v, want nil, nil", ignore, err)
+	}
+
+	for _, pattern := range []string{"!keep.md", "", "/", "notes/[a"} {
+		_, err := ParseIgnorePatterns([]string{"drafts/", pattern})
+		if !IsValidationError(err) {
+			t.Errorf("ParseIgnorePatterns(%q) error = %v, want a validation error", pattern, err)
+		}
+	}
+}
@@ -60,6 +60,9 @@ type Frontmatter struct {
 	// Template used to create this note
 	Template string `json:"template,omitempty" yaml:"template,omitempty" toml:"template,omitempty"`
 
+	// Checksum of the note body, see note.BodyChecksum
+	Checksum string `json:"checksum,omitempty" yaml:"checksum,omitempty" toml:"checksum,omitempty"`
+
 	// Custom metadata fields
 	Custom map[string]interface{} `json:"custom,omitempty" yaml:"custom,omitempty" toml:"custom,omitempty"`
 }
@@ -2,8 +2,15 @@ package types
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"time"
 )
 
 // StorageType represents the type of storage backend
@@ -12,6 +19,7 @@ type StorageType string
 const (
 	StorageTypeLocal StorageType = "local"
 	StorageTypeS3    StorageType = "s3"
+	StorageTypeAzure StorageType = "azure"
 )
 
 // StorageBackend defines the interface for all storage implementations
@@ -56,6 +64,249 @@ type StorageBackend interface {
 	Close() error
 }
 
+// VersionedBackend is implemented by storage backends that keep past
+// versions of files. Commands check for it with a type assertion; layers
+// wrapping a backend implement it by delegating and return
+// ErrVersioningNotSupported when the backend they wrap doesn't keep versions.
+type VersionedBackend interface {
+	// ListVersions returns the versions of the file at path, newest first.
+	// A file that has never been written has no versions.
+	ListVersions(ctx context.Context, path string) ([]FileVersion, error)
+
+	// ReadVersion retrieves the content of one version of a file
+	ReadVersion(ctx context.Context, path, versionID string) ([]byte, error)
+
+	// RestoreVersion makes a past version of a file its current content.
+	// The current content becomes a past version in turn.
+	RestoreVersion(ctx context.Context, path, versionID string) error
+}
+
+// ErrVersioningNotSupported is returned by version operations on storage
+// that doesn't keep versions
+var ErrVersioningNotSupported = errors.New("storage does not keep file versions")
+
+// FileVersion describes one version of a stored file
+type FileVersion struct {
+	// ID identifies the version to ReadVersion and RestoreVersion
+	ID string `json:"id"`
+
+	// ModTime is when the version was written
+	ModTime time.Time `json:"mod_time"`
+
+	// Size is the stored size of the version in bytes
+	Size int64 `json:"size"`
+
+	// Current is set for the version the file currently has
+	Current bool `json:"current"`
+
+	// Deleted is set for the marker a versioned bucket leaves when the file
+	// is deleted; it has no content
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// SelectBackend is implemented by storage backends that can search file
+// contents on the server, such as S3 with S3 Select, so that files without
+// a match aren't downloaded. The disk cache implements it by delegating and
+// returns ErrSelectNotSupported when the backend it wraps can't; other
+// layers don't implement it and their files are searched by the caller.
+type SelectBackend interface {
+	// FilesContaining returns those of paths whose content contains
+	// literal, in the order given. Files the server can't check are
+	// returned as well, so that the caller searches them itself.
+	FilesContaining(ctx context.Context, paths []string, literal string, ignoreCase bool) ([]string, error)
+}
+
+// ErrSelectNotSupported is returned by FilesContaining on storage that
+// can't search file contents on the server
+var ErrSelectNotSupported = errors.New("storage does not support server-side search")
+
+// CreateBackend is implemented by storage backends that can create a file
+// only when none exists at its path, in one atomic step, such as local
+// storage. Callers check for it with a type assertion; layers wrapping a
+// backend don't implement it, and callers fall back to Exists and Write.
+type CreateBackend interface {
+	// Create stores data at path unless a file is already there, in which
+	// case it returns an error matching fs.ErrExist
+	Create(ctx context.Context, path string, data []byte) error
+}
+
+// BatchBackend is implemented by storage backends that can read or delete
+// many files faster than one call per file, such as S3 with concurrent
+// requests and DeleteObjects. Callers check for it with a type assertion,
+// or use BatchRead and BatchDelete, which fall back to one call per file.
+type BatchBackend interface {
+	// BatchRead retrieves the content of each of paths. Files that can't be
+	// read are left out of the result and reported in a *BatchError; the
+	// others are still returned.
+	BatchRead(ctx context.Context, paths []string) (map[string][]byte, error)
+
+	// BatchDelete removes each of paths. Files that can't be removed are
+	// reported in a *BatchError; the others are still removed.
+	BatchDelete(ctx context.Context, paths []string) error
+}
+
+// BatchError reports the paths a batch operation failed for, with the
+// error for each. errors.Is and errors.As match any of them.
+type BatchError struct {
+	Operation string
+	Errors    map[string]error
+}
+
+func (e *BatchError) Error() string {
+	paths := e.Paths()
+	noun := "paths"
+	if len(paths) == 1 {
+		noun = "path"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "batch %s failed for %d %s", e.Operation, len(paths), noun)
+	for i, p := range paths {
+		// Name the first few; a large batch could fail for every path
+		if i == 3 {
+			fmt.Fprintf(&b, "; and %d more", len(paths)-i)
+			break
+		}
+		fmt.Fprintf(&b, "; %s: %v", p, e.Errors[p])
+	}
+	return b.String()
+}
+
+func (e *BatchError) Unwrap() []error {
+	paths := e.Paths()
+	errs := make([]error, len(paths))
+	for i, p := range paths {
+		errs[i] = e.Errors[p]
+	}
+	return errs
+}
+
+// Paths returns the failed paths in order
+func (e *BatchError) Paths() []string {
+	paths := make([]string, 0, len(e.Errors))
+	for p := range e.Errors {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// BatchErrorFrom returns a *BatchError for the failed paths in errs, or
+// nil when there are none
+func BatchErrorFrom(operation string, errs map[string]error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &BatchError{Operation: operation, Errors: errs}
+}
+
+// PathErrors returns the error for each path a batch operation failed for.
+// An error that isn't a *BatchError applies to every one of paths.
+func PathErrors(err error, paths []string) map[string]error {
+	if err == nil {
+		return nil
+	}
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.Errors
+	}
+	errs := make(map[string]error, len(paths))
+	for _, p := range paths {
+		errs[p] = err
+	}
+	return errs
+}
+
+// BatchRead reads paths from backend, in one batch when it implements
+// BatchBackend and one file at a time otherwise. It reports failures like
+// BatchBackend.BatchRead.
+func BatchRead(ctx context.Context, backend StorageBackend, paths []string) (map[string][]byte, error) {
+	if batch, ok := backend.(BatchBackend); ok {
+		return batch.BatchRead(ctx, paths)
+	}
+
+	files := make(map[string][]byte, len(paths))
+	errs := make(map[string]error)
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return files, err
+		}
+		data, err := backend.Read(ctx, p)
+		if err != nil {
+			errs[p] = err
+			continue
+		}
+		files[p] = data
+	}
+	return files, BatchErrorFrom("read", errs)
+}
+
+// BatchDelete removes paths from backend, in one batch when it implements
+// BatchBackend and one file at a time otherwise. It reports failures like
+// BatchBackend.BatchDelete.
+func BatchDelete(ctx context.Context, backend StorageBackend, paths []string) error {
+	if batch, ok := backend.(BatchBackend); ok {
+		return batch.BatchDelete(ctx, paths)
+	}
+
+	errs := make(map[string]error)
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := backend.Delete(ctx, p); err != nil {
+			errs[p] = err
+		}
+	}
+	return BatchErrorFrom("delete", errs)
+}
+
+// WalkFunc is called by Walk for each file with its path and metadata.
+// Returning an error stops the walk, and Walk returns that error.
+type WalkFunc func(path string, info *FileInfo) error
+
+// WalkBackend is implemented by storage backends that can visit files as
+// they are listed instead of collecting every path first, such as S3 a page
+// of keys at a time. Callers check for it with a type assertion, or use
+// Walk, which falls back to List and Stat.
+type WalkBackend interface {
+	// Walk calls fn for each file List(ctx, prefix) would return, in
+	// lexical order. info holds what the listing provides without a request
+	// per file: at least the path, size and modification time.
+	Walk(ctx context.Context, prefix string, fn WalkFunc) error
+}
+
+// Walk calls fn for each file under prefix in backend, as it is listed when
+// backend implements WalkBackend. Otherwise the files are listed first and
+// stat'ed one at a time; files removed in between are skipped.
+func Walk(ctx context.Context, backend StorageBackend, prefix string, fn WalkFunc) error {
+	if walker, ok := backend.(WalkBackend); ok {
+		return walker.Walk(ctx, prefix, fn)
+	}
+
+	paths, err := backend.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := backend.Stat(ctx, p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(p, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // FileInfo contains metadata about a stored file
 type FileInfo struct {
 	// Path is the full path to the file
@@ -80,6 +331,23 @@ type FileInfo struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// MarkdownContentType is the content type of notes
+const MarkdownContentType = "text/markdown"
+
+// ContentTypeByExtension returns the MIME type for a file path's extension:
+// MarkdownContentType for .md and .markdown files, the registered type for
+// other known extensions, and "" when the extension is unknown
+func ContentTypeByExtension(p string) string {
+	ext := strings.ToLower(path.Ext(p))
+	switch ext {
+	case "":
+		return ""
+	case ".md", ".markdown":
+		return MarkdownContentType
+	}
+	return mime.TypeByExtension(ext)
+}
+
 // StorageConfig contains configuration for storage backends
 type StorageConfig struct {
 	// Type specifies which storage backend to use
@@ -91,10 +359,55 @@ type StorageConfig struct {
 	// S3 storage configuration
 	S3 S3StorageConfig `toml:"s3" json:"s3"`
 
+	// Azure Blob Storage configuration
+	Azure AzureBlobConfig `toml:"azure" json:"azure"`
+
 	// Cache configuration
 	Cache CacheConfig `toml:"cache" json:"cache"`
+
+	// Compression compresses files at rest: "none" (default) or "gzip".
+	// Compressed files are stored with a ".gz" suffix and read back
+	// transparently; List still returns the logical paths.
+	Compression string `toml:"compression" json:"compression"`
+
+	// CompressionStatSize is the size Stat reports for compressed files:
+	// "stored" (default) for the compressed size, or "logical" for the
+	// uncompressed size, which costs a read of the file
+	CompressionStatSize string `toml:"compression_stat_size" json:"compression_stat_size"`
+
+	// Encryption encrypts files on the client before they reach the backend
+	Encryption EncryptionConfig `toml:"encryption" json:"encryption"`
 }
 
+// EncryptionConfig configures client-side encryption of files at rest with
+// AES-256-GCM. The key comes from exactly one of Passphrase or KeyFile.
+type EncryptionConfig struct {
+	// Enabled encrypts files as they are written. Files written before it
+	// was enabled are still read as they are.
+	Enabled bool `toml:"enabled" json:"enabled"`
+
+	// Passphrase derives the key with scrypt, using a salt kept in the
+	// vault at .kbvault/encryption.json. Use a ${VAR} reference rather than
+	// storing the passphrase in the configuration file.
+	Passphrase string `toml:"passphrase" json:"passphrase"`
+
+	// KeyFile is a file holding the 32-byte key, raw or hex or base64
+	// encoded
+	KeyFile string `toml:"key_file" json:"key_file"`
+}
+
+// Storage compression algorithms
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// Sizes reported by Stat for compressed files
+const (
+	CompressionSizeStored  = "stored"
+	CompressionSizeLogical = "logical"
+)
+
 // LocalStorageConfig configures local filesystem storage
 type LocalStorageConfig struct {
 	// Path is the root directory for the vault
@@ -114,6 +427,12 @@ type LocalStorageConfig struct {
 
 	// LockTimeout is the maximum time to wait for a file lock (seconds)
 	LockTimeout int `toml:"lock_timeout" json:"lock_timeout"`
+
+	// VersionRetention is how many past versions are kept per file. When it
+	// is set, the previous content of a file is kept as a snapshot under
+	// .kbvault/versions each time the file is overwritten or deleted, and
+	// the oldest snapshots beyond the limit are removed. 0 disables versions.
+	VersionRetention int `toml:"version_retention" json:"version_retention"`
 }
 
 // S3StorageConfig configures S3-compatible storage
@@ -136,6 +455,14 @@ type S3StorageConfig struct {
 	// SessionToken for temporary credentials (optional)
 	SessionToken string `toml:"session_token" json:"session_token"`
 
+	// CredentialSource picks where credentials come from: "static" for
+	// the keys above, "profile:<name>" for a shared config profile such
+	// as an SSO profile, "role:<arn>" to assume a role through STS, or
+	// "default" for the SDK's default chain (environment, shared config,
+	// instance and container roles). Empty uses the keys when they are
+	// set and the default chain otherwise.
+	CredentialSource string `toml:"credential_source" json:"credential_source"`
+
 	// UseSSL enables HTTPS for API calls
 	UseSSL bool `toml:"use_ssl" json:"use_ssl"`
 
@@ -148,6 +475,10 @@ type S3StorageConfig struct {
 	// StorageClass for uploaded objects
 	StorageClass string `toml:"storage_class" json:"storage_class"`
 
+	// StorageClassRules override StorageClass for paths under a prefix;
+	// the first matching rule wins
+	StorageClassRules []StorageClassRule `toml:"storage_class_rules" json:"storage_class_rules,omitempty"`
+
 	// ServerSideEncryption enables SSE
 	ServerSideEncryption string `toml:"server_side_encryption" json:"server_side_encryption"`
 
@@ -157,14 +488,180 @@ type S3StorageConfig struct {
 	// RetryAttempts for failed operations
 	RetryAttempts int `toml:"retry_attempts" json:"retry_attempts"`
 
-	// RetryDelay base delay between retries (milliseconds)
+	// RetryDelay base delay between retries (milliseconds); each retry
+	// waits up to twice as long as the one before
 	RetryDelay int `toml:"retry_delay" json:"retry_delay"`
 
+	// RetryMaxBackoff caps the delay between retries (milliseconds, 0 uses
+	// the SDK default of 20 seconds)
+	RetryMaxBackoff int `toml:"retry_max_backoff" json:"retry_max_backoff"`
+
+	// RetryMode is the AWS SDK retry mode: "adaptive" (default), which also
+	// rate-limits requests while S3 is throttling, or "standard"
+	RetryMode string `toml:"retry_mode" json:"retry_mode"`
+
 	// RequestTimeout for individual requests (seconds)
 	RequestTimeout int `toml:"request_timeout" json:"request_timeout"`
 
+	// MaxIdleConns caps the idle connections kept open for reuse across
+	// all hosts (0 uses the default of 100)
+	MaxIdleConns int `toml:"max_idle_conns" json:"max_idle_conns"`
+
+	// MaxIdleConnsPerHost caps the idle connections kept open to one host
+	// (0 uses the default of 100, rather than Go's 2, so that many small
+	// requests in parallel reuse connections)
+	MaxIdleConnsPerHost int `toml:"max_idle_conns_per_host" json:"max_idle_conns_per_host"`
+
+	// IdleConnTimeout closes connections left idle for longer (seconds, 0
+	// uses the default of 90)
+	IdleConnTimeout int `toml:"idle_conn_timeout" json:"idle_conn_timeout"`
+
+	// Proxy is the URL of a proxy for all S3 requests. Empty uses
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment.
+	Proxy string `toml:"proxy" json:"proxy"`
+
 	// EnableVersioning enables S3 bucket versioning
 	EnableVersioning bool `toml:"enable_versioning" json:"enable_versioning"`
+
+	// VerifyChecksums sends Content-MD5 with writes and checks the returned ETag
+	VerifyChecksums bool `toml:"verify_checksums" json:"verify_checksums"`
+
+	// UploadPartSizeMB is the part size for multipart stream uploads
+	// (minimum 5, 0 uses the SDK default of 5)
+	UploadPartSizeMB int `toml:"upload_part_size_mb" json:"upload_part_size_mb"`
+
+	// UploadConcurrency is how many parts of a stream upload are sent in
+	// parallel (0 uses the SDK default of 5)
+	UploadConcurrency int `toml:"upload_concurrency" json:"upload_concurrency"`
+
+	// SelectEnabled lets kbvault grep use S3 Select to skip objects that
+	// can't match instead of downloading every note
+	SelectEnabled bool `toml:"select_enabled" json:"select_enabled"`
+}
+
+// S3 retry modes
+const (
+	S3RetryModeStandard = "standard"
+	S3RetryModeAdaptive = "adaptive"
+)
+
+// S3 credential kinds; see S3StorageConfig.CredentialSource
+const (
+	S3CredentialsDefault = "default"
+	S3CredentialsStatic  = "static"
+	S3CredentialsProfile = "profile"
+	S3CredentialsRole    = "role"
+)
+
+// S3CredentialSource is a parsed S3StorageConfig.CredentialSource
+type S3CredentialSource struct {
+	// Kind is one of the S3Credentials kinds
+	Kind string
+
+	// Name is the profile name or the role ARN
+	Name string
+}
+
+// ParseS3CredentialSource parses a credential source such as "static" or
+// "role:arn:aws:iam::123456789012:role/kbvault". An empty source yields
+// the zero S3CredentialSource.
+func ParseS3CredentialSource(value string) (S3CredentialSource, error) {
+	value = strings.TrimSpace(value)
+	kind, name, hasName := strings.Cut(value, ":")
+	kind = strings.ToLower(kind)
+
+	switch {
+	case value == "":
+		return S3CredentialSource{}, nil
+	case !hasName && (kind == S3CredentialsDefault || kind == S3CredentialsStatic):
+		return S3CredentialSource{Kind: kind}, nil
+	case hasName && (kind == S3CredentialsProfile || kind == S3CredentialsRole):
+		if name = strings.TrimSpace(name); name == "" {
+			what := "profile name"
+			if kind == S3CredentialsRole {
+				what = "role ARN"
+			}
+			return S3CredentialSource{}, NewValidationError(fmt.Sprintf("invalid storage.s3.credential_source %q: %s cannot be empty", value, what))
+		}
+		return S3CredentialSource{Kind: kind, Name: name}, nil
+	default:
+		return S3CredentialSource{}, NewValidationError(fmt.Sprintf(
+			"invalid storage.s3.credential_source %q: must be static, default, profile:<name> or role:<arn>", value))
+	}
+}
+
+// StorageClassRule picks the S3 storage class for vault paths under a
+// prefix, such as "daily/"
+type StorageClassRule struct {
+	// Prefix is matched against the vault path, not the bucket key
+	Prefix string `toml:"prefix" json:"prefix"`
+
+	// Class is the storage class for matching paths
+	Class string `toml:"class" json:"class"`
+}
+
+// S3StorageClasses are the storage class names S3 accepts
+var S3StorageClasses = []string{
+	"STANDARD",
+	"REDUCED_REDUNDANCY",
+	"STANDARD_IA",
+	"ONEZONE_IA",
+	"INTELLIGENT_TIERING",
+	"GLACIER",
+	"GLACIER_IR",
+	"DEEP_ARCHIVE",
+	"OUTPOSTS",
+	"SNOW",
+	"EXPRESS_ONEZONE",
+}
+
+// StorageClassFor returns the storage class for a vault path: the class of
+// the first rule whose prefix matches, or StorageClass
+func (c S3StorageConfig) StorageClassFor(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	for _, rule := range c.StorageClassRules {
+		if strings.HasPrefix(path, strings.TrimPrefix(rule.Prefix, "/")) {
+			return rule.Class
+		}
+	}
+	return c.StorageClass
+}
+
+// validS3StorageClass reports whether class is a known S3 storage class
+func validS3StorageClass(class string) bool {
+	for _, known := range S3StorageClasses {
+		if class == known {
+			return true
+		}
+	}
+	return false
+}
+
+// AzureBlobConfig configures Azure Blob Storage
+type AzureBlobConfig struct {
+	// AccountName is the storage account name
+	AccountName string `toml:"account_name" json:"account_name"`
+
+	// AccountKey for shared key authentication
+	AccountKey string `toml:"account_key" json:"account_key"`
+
+	// ConnectionString for authentication (takes precedence over AccountKey)
+	ConnectionString string `toml:"connection_string" json:"connection_string"`
+
+	// ContainerName is the blob container holding the vault
+	ContainerName string `toml:"container_name" json:"container_name"`
+
+	// Endpoint overrides the service URL (e.g. Azurite for testing)
+	Endpoint string `toml:"endpoint" json:"endpoint"`
+
+	// Prefix for all blobs in the container
+	Prefix string `toml:"prefix" json:"prefix"`
+
+	// RetryAttempts for failed operations
+	RetryAttempts int `toml:"retry_attempts" json:"retry_attempts"`
+
+	// RequestTimeout for individual requests (seconds)
+	RequestTimeout int `toml:"request_timeout" json:"request_timeout"`
 }
 
 // CacheConfig configures the caching layer
@@ -0,0 +1,208 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// mapBackend is a StorageBackend over a map that doesn't batch
+type mapBackend struct {
+	StorageBackend
+	files   map[string][]byte
+	failing map[string]bool
+}
+
+func (m *mapBackend) Read(ctx context.Context, path string) ([]byte, error) {
+	if m.failing[path] {
+		return nil, NewStorageError(StorageTypeLocal, "read", path, io.ErrUnexpectedEOF, true)
+	}
+	data, ok := m.files[path]
+	if !ok {
+		return nil, NewStorageError(StorageTypeLocal, "read", path, fs.ErrNotExist, false)
+	}
+	return data, nil
+}
+
+func (m *mapBackend) Delete(ctx context.Context, path string) error {
+	if _, ok := m.files[path]; !ok {
+		return NewStorageError(StorageTypeLocal, "delete", path, fs.ErrNotExist, false)
+	}
+	delete(m.files, path)
+	return nil
+}
+
+func TestBatchRead_Fallback(t *testing.T) {
+	backend := &mapBackend{
+		files:   map[string][]byte{"a.md": []byte("a"), "b.md": []byte("b"), "c.md": []byte("c")},
+		failing: map[string]bool{"c.md": true},
+	}
+
+	files, err := BatchRead(context.Background(), backend, []string{"a.md", "b.md", "c.md", "missing.md"})
+	if len(files) != 2 || string(files["a.md"]) != "a" || string(files["b.md"]) != "b" {
+		t.Errorf("BatchRead() files = %v, want a.md and b.md", files)
+	}
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("BatchRead() error = %v, want a *BatchError", err)
+	}
+	if got := strings.Join(batchErr.Paths(), ","); got != "c.md,missing.md" {
+		t.Errorf("failed paths = %s, want c.md,missing.md", got)
+	}
+	if !IsRetryable(batchErr.Errors["c.md"]) || !errors.Is(batchErr.Errors["missing.md"], fs.ErrNotExist) {
+		t.Errorf("per-path errors = %v", batchErr.Errors)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected errors.Is to match a per-path error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := BatchRead(ctx, backend, []string{"a.md"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("BatchRead() with a canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestBatchDelete_Fallback(t *testing.T) {
+	backend := &mapBackend{files: map[string][]byte{"a.md": nil, "b.md": nil}}
+
+	err := BatchDelete(context.Background(), backend, []string{"a.md", "missing.md", "b.md"})
+	if len(backend.files) != 0 {
+		t.Errorf("files left after BatchDelete: %v", backend.files)
+	}
+
+	errs := PathErrors(err, []string{"a.md", "missing.md", "b.md"})
+	if len(errs) != 1 || errs["missing.md"] == nil {
+		t.Errorf("PathErrors() = %v, want only missing.md", errs)
+	}
+
+	if err := BatchDelete(context.Background(), backend, nil); err != nil {
+		t.Errorf("BatchDelete() of nothing = %v", err)
+	}
+}
+
+// listBackend is a StorageBackend over a map that lists but doesn't walk
+type listBackend struct {
+	mapBackend
+}
+
+func (l *listBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	var paths []string
+	for path := range l.files {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func (l *listBackend) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	data, ok := l.files[path]
+	if !ok || l.failing[path] {
+		return nil, NewStorageError(StorageTypeLocal, "stat", path, fs.ErrNotExist, false)
+	}
+	return &FileInfo{Path: path, Size: int64(len(data))}, nil
+}
+
+func TestWalk_Fallback(t *testing.T) {
+	backend := &listBackend{mapBackend{
+		files:   map[string][]byte{"notes/b.md": []byte("bb"), "notes/a.md": []byte("a"), "notes/c.md": nil, "other.md": nil},
+		failing: map[string]bool{"notes/c.md": true},
+	}}
+
+	var walked []string
+	err := Walk(context.Background(), backend, "notes/", func(path string, info *FileInfo) error {
+		walked = append(walked, path)
+		if info.Path != path || info.Size != int64(len(backend.files[path])) {
+			t.Errorf("info for %s = %+v", path, info)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	// Sorted, and without the file that disappeared before it was stat'ed
+	if got := strings.Join(walked, ","); got != "notes/a.md,notes/b.md" {
+		t.Errorf("Walk() visited %s, want notes/a.md,notes/b.md", got)
+	}
+
+	errStop := errors.New("stop")
+	walked = nil
+	err = Walk(context.Background(), backend, "", func(path string, _ *FileInfo) error {
+		walked = append(walked, path)
+		return errStop
+	})
+	if !errors.Is(err, errStop) || len(walked) != 1 {
+		t.Errorf("Walk() = %v after visiting %v, want the callback's error after one file", err, walked)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	if err := BatchErrorFrom("read", nil); err != nil {
+		t.Errorf("BatchErrorFrom() without failures = %v, want nil", err)
+	}
+
+	err := BatchErrorFrom("delete", map[string]error{"a.md": errors.New("denied")})
+	if got := err.Error(); got != "batch delete failed for 1 path; a.md: denied" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	errs := map[string]error{}
+	for _, p := range []string{"e.md", "d.md", "c.md", "b.md", "a.md"} {
+		errs[p] = errors.New("gone")
+	}
+	want := "batch read failed for 5 paths; a.md: gone; b.md: gone; c.md: gone; and 2 more"
+	if got := BatchErrorFrom("read", errs).Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	// An error for the whole operation applies to every path
+	whole := errors.New("storage is closed")
+	failed := PathErrors(whole, []string{"a.md", "b.md"})
+	if len(failed) != 2 || failed["a.md"] != whole || failed["b.md"] != whole {
+		t.Errorf("PathErrors() = %v, want the error for both paths", failed)
+	}
+	if PathErrors(nil, []string{"a.md"}) != nil {
+		t.Error("PathErrors(nil) should be nil")
+	}
+}
+
+func TestParseS3CredentialSource(t *testing.T) {
+	testCases := []struct {
+		value       string
+		want        S3CredentialSource
+		expectError bool
+	}{
+		{"", S3CredentialSource{}, false},
+		{"static", S3CredentialSource{Kind: S3CredentialsStatic}, false},
+		{" Default ", S3CredentialSource{Kind: S3CredentialsDefault}, false},
+		{"profile:work-sso", S3CredentialSource{Kind: S3CredentialsProfile, Name: "work-sso"}, false},
+		{"role:arn:aws:iam::123456789012:role/kb", S3CredentialSource{Kind: S3CredentialsRole, Name: "arn:aws:iam::123456789012:role/kb"}, false},
+		{"profile:", S3CredentialSource{}, true},
+		{"role", S3CredentialSource{}, true},
+		{"static:keys", S3CredentialSource{}, true},
+		{"instance", S3CredentialSource{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseS3CredentialSource(tc.value)
+			if tc.expectError {
+				if !IsValidationError(err) {
+					t.Errorf("Expected validation error for %q, got %v, %v", tc.value, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
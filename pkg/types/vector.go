@@ -57,7 +57,8 @@ const (
 	EmbeddingProviderAzure   EmbeddingProvider = "azure"
 	EmbeddingProviderHugging EmbeddingProvider = "huggingface"
 	EmbeddingProviderCohere  EmbeddingProvider = "cohere"
-	EmbeddingProviderLocal   EmbeddingProvider = "local" // Local embedding model
+	EmbeddingProviderOllama  EmbeddingProvider = "ollama" // Models served by Ollama
+	EmbeddingProviderLocal   EmbeddingProvider = "local"  // Local embedding model
 )
 
 // Document represents a document for vector indexing
@@ -200,6 +201,10 @@ type EmbeddingConfig struct {
 	// Dimensions is the embedding vector dimensions
 	Dimensions int `toml:"dimensions" json:"dimensions"`
 
+	// RateLimitRPM caps requests to the provider per minute (0 = no limit).
+	// Each request embeds up to Indexing.BatchSize texts.
+	RateLimitRPM int `toml:"rate_limit_rpm" json:"rate_limit_rpm"`
+
 	// OpenAI configuration
 	OpenAI OpenAIEmbeddingConfig `toml:"openai" json:"openai"`
 
@@ -212,6 +217,9 @@ type EmbeddingConfig struct {
 	// Cohere configuration
 	Cohere CohereEmbeddingConfig `toml:"cohere" json:"cohere"`
 
+	// Ollama configuration
+	Ollama OllamaEmbeddingConfig `toml:"ollama" json:"ollama"`
+
 	// Local embedding configuration
 	Local LocalEmbeddingConfig `toml:"local" json:"local"`
 }
@@ -250,6 +258,12 @@ type AzureEmbeddingConfig struct {
 
 	// APIVersion for Azure OpenAI API
 	APIVersion string `toml:"api_version" json:"api_version"`
+
+	// RequestTimeout for API calls (seconds)
+	RequestTimeout int `toml:"request_timeout" json:"request_timeout"`
+
+	// MaxRetries for failed requests
+	MaxRetries int `toml:"max_retries" json:"max_retries"`
 }
 
 // HuggingFaceEmbeddingConfig configures Hugging Face embeddings
@@ -262,6 +276,12 @@ type HuggingFaceEmbeddingConfig struct {
 
 	// EndpointURL for custom endpoints
 	EndpointURL string `toml:"endpoint_url" json:"endpoint_url"`
+
+	// RequestTimeout for API calls (seconds)
+	RequestTimeout int `toml:"request_timeout" json:"request_timeout"`
+
+	// MaxRetries for failed requests
+	MaxRetries int `toml:"max_retries" json:"max_retries"`
 }
 
 // CohereEmbeddingConfig configures Cohere embeddings
@@ -269,11 +289,37 @@ type CohereEmbeddingConfig struct {
 	// APIKey for Cohere API
 	APIKey string `toml:"api_key" json:"api_key"`
 
-	// Model name
+	// Model name (e.g., "embed-english-v3.0")
 	Model string `toml:"model" json:"model"`
 
-	// InputType for embeddings
+	// InputType for embedded documents (default "search_document").
+	// Search queries are embedded as "search_query" when documents are
+	// embedded as "search_document", and with InputType otherwise.
 	InputType string `toml:"input_type" json:"input_type"`
+
+	// BaseURL for proxies and compatible endpoints
+	BaseURL string `toml:"base_url" json:"base_url"`
+
+	// RequestTimeout for API calls (seconds)
+	RequestTimeout int `toml:"request_timeout" json:"request_timeout"`
+
+	// MaxRetries for failed requests
+	MaxRetries int `toml:"max_retries" json:"max_retries"`
+}
+
+// OllamaEmbeddingConfig configures embeddings from an Ollama server
+type OllamaEmbeddingConfig struct {
+	// BaseURL of the Ollama server (default "http://localhost:11434")
+	BaseURL string `toml:"base_url" json:"base_url"`
+
+	// Model name (e.g., "nomic-embed-text")
+	Model string `toml:"model" json:"model"`
+
+	// RequestTimeout for API calls (seconds)
+	RequestTimeout int `toml:"request_timeout" json:"request_timeout"`
+
+	// MaxRetries for failed requests
+	MaxRetries int `toml:"max_retries" json:"max_retries"`
 }
 
 // LocalEmbeddingConfig configures local embedding models
@@ -379,6 +425,10 @@ type QdrantConfig struct {
 
 	// CollectionName in Qdrant
 	CollectionName string `toml:"collection_name" json:"collection_name"`
+
+	// DistanceMetric used when creating the collection ("cosine",
+	// "euclidean", "dot"); empty means cosine
+	DistanceMetric string `toml:"distance_metric" json:"distance_metric"`
 }
 
 // IndexingConfig configures document indexing behavior
@@ -425,8 +475,15 @@ type SearchConfig struct {
 	// EnableReranking whether to rerank results
 	EnableReranking bool `toml:"enable_reranking" json:"enable_reranking"`
 
-	// RerankingModel for reranking results
+	// RerankingModel for reranking results: "rrf" for reciprocal rank
+	// fusion, or a model served by RerankingEndpoint
 	RerankingModel string `toml:"reranking_model" json:"reranking_model"`
+
+	// RerankingEndpoint is the URL of an external rerank API
+	RerankingEndpoint string `toml:"reranking_endpoint" json:"reranking_endpoint"`
+
+	// RerankingAPIKey for the reranking endpoint (optional)
+	RerankingAPIKey string `toml:"reranking_api_key" json:"reranking_api_key"`
 }
 
 // DefaultVectorSearchConfig returns a configuration with sensible defaults
@@ -443,6 +500,23 @@ func DefaultVectorSearchConfig() *VectorSearchConfig {
 				RequestTimeout: 30,
 				MaxRetries:     3,
 			},
+			Azure: AzureEmbeddingConfig{
+				RequestTimeout: 30,
+				MaxRetries:     3,
+			},
+			HuggingFace: HuggingFaceEmbeddingConfig{
+				RequestTimeout: 30,
+				MaxRetries:     3,
+			},
+			Cohere: CohereEmbeddingConfig{
+				InputType:      "search_document",
+				RequestTimeout: 30,
+				MaxRetries:     3,
+			},
+			Ollama: OllamaEmbeddingConfig{
+				RequestTimeout: 60,
+				MaxRetries:     3,
+			},
 		},
 		Local: LocalVectorConfig{
 			DatabasePath:   "./vector.db",
@@ -466,6 +540,7 @@ func DefaultVectorSearchConfig() *VectorSearchConfig {
 			MaxLimit:        100,
 			MinScore:        0.7,
 			EnableReranking: false,
+			RerankingModel:  "rrf",
 		},
 	}
 }
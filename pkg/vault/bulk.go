@@ -0,0 +1,88 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// BulkResult reports the outcome of one item in a bulk operation
+type BulkResult struct {
+	// ID is the note ID; empty for a create that failed before an ID was
+	// assigned
+	ID string `json:"id,omitempty"`
+
+	// Note is the created note, for successful creates
+	Note *types.Note `json:"note,omitempty"`
+
+	// Err is the error for this item, nil on success
+	Err error `json:"-"`
+}
+
+// CreateNotes creates several notes. Each input is handled independently:
+// a failure is recorded in its result and doesn't stop the remaining
+// inputs. An error is returned only when the request as a whole is
+// rejected or the context is cancelled, in which case unprocessed items
+// carry the context error.
+func (v *Vault) CreateNotes(ctx context.Context, inputs []NoteInput) ([]BulkResult, error) {
+	if err := v.checkBulkSize(len(inputs)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(inputs))
+	for i, input := range inputs {
+		if err := ctx.Err(); err != nil {
+			failRemaining(results[i:], err)
+			return results, err
+		}
+
+		n, err := v.CreateNote(ctx, input)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i] = BulkResult{ID: n.ID, Note: n}
+	}
+
+	return results, nil
+}
+
+// DeleteNotes deletes several notes by ID, with the same per-item error
+// handling as CreateNotes
+func (v *Vault) DeleteNotes(ctx context.Context, ids []string) ([]BulkResult, error) {
+	if err := v.checkBulkSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(ids))
+	for i, id := range ids {
+		results[i].ID = id
+	}
+
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			failRemaining(results[i:], err)
+			return results, err
+		}
+
+		results[i].Err = v.DeleteNote(ctx, id)
+	}
+
+	return results, nil
+}
+
+// checkBulkSize rejects bulk requests larger than MaxBulkSize
+func (v *Vault) checkBulkSize(n int) error {
+	if v.options.MaxBulkSize > 0 && n > v.options.MaxBulkSize {
+		return types.NewValidationError(fmt.Sprintf("bulk operation has %d items, the maximum is %d", n, v.options.MaxBulkSize)).
+			WithContext("max_bulk_size", v.options.MaxBulkSize)
+	}
+	return nil
+}
+
+func failRemaining(results []BulkResult, err error) {
+	for i := range results {
+		results[i].Err = err
+	}
+}
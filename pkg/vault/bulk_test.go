@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestVault_CreateNotes(t *testing.T) {
+	v, _ := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	results, err := v.CreateNotes(ctx, []NoteInput{
+		{Title: "One", Tags: []string{"bulk"}},
+		{Title: ""},
+		{Title: "Three"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.NoError(t, results[0].Err)
+	assert.NotEmpty(t, results[0].ID)
+	assert.Equal(t, "One", results[0].Note.Title)
+
+	assert.True(t, types.IsValidationError(results[1].Err))
+	assert.Empty(t, results[1].ID)
+	assert.Nil(t, results[1].Note)
+
+	assert.NoError(t, results[2].Err)
+
+	notes, err := v.ListNotes(ctx)
+	require.NoError(t, err)
+	assert.Len(t, notes, 2)
+}
+
+func TestVault_DeleteNotes(t *testing.T) {
+	v, _ := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	created, err := v.CreateNotes(ctx, []NoteInput{{Title: "A"}, {Title: "B"}, {Title: "C"}})
+	require.NoError(t, err)
+
+	results, err := v.DeleteNotes(ctx, []string{created[0].ID, "missing", created[2].ID})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, types.IsNotFoundError(results[1].Err))
+	assert.Equal(t, "missing", results[1].ID)
+	assert.NoError(t, results[2].Err)
+
+	notes, err := v.ListNotes(ctx)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, created[1].ID, notes[0].ID)
+}
+
+func TestVault_BulkSizeLimit(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxBulkSize = 2
+	v, _ := newTestVault(t, opts)
+	ctx := context.Background()
+
+	_, err := v.CreateNotes(ctx, []NoteInput{{Title: "A"}, {Title: "B"}, {Title: "C"}})
+	assert.True(t, types.IsValidationError(err))
+
+	_, err = v.DeleteNotes(ctx, []string{"a", "b", "c"})
+	assert.True(t, types.IsValidationError(err))
+
+	notes, err := v.ListNotes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, notes, "an oversized request should not create anything")
+
+	results, err := v.CreateNotes(ctx, []NoteInput{{Title: "A"}, {Title: "B"}})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	// Zero disables the limit
+	opts.MaxBulkSize = 0
+	unlimited, _ := newTestVault(t, opts)
+	results, err = unlimited.CreateNotes(ctx, make([]NoteInput, 5))
+	require.NoError(t, err)
+	assert.Len(t, results, 5)
+}
+
+func TestVault_BulkCancelled(t *testing.T) {
+	v, _ := newTestVault(t, DefaultOptions())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := v.CreateNotes(ctx, []NoteInput{{Title: "A"}, {Title: "B"}})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+
+	results, err = v.DeleteNotes(ctx, []string{"a"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "a", results[0].ID)
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+}
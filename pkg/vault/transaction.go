@@ -0,0 +1,163 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/ulid"
+)
+
+// StagingDir holds the files of transactional writes until they are
+// promoted to their final paths. Each batch stages under its own
+// subdirectory.
+const StagingDir = ".kbvault/staging"
+
+// WriteBatchTransactional creates several notes so that either all of them
+// are written or none are. Unlike CreateNotes, any invalid input or failed
+// write fails the whole batch.
+//
+// Notes are first written to staging paths under StagingDir and, once all
+// of them are staged, moved to their final paths: a rename on local
+// storage, a copy and delete on S3. A failure removes the staged files and
+// the notes already moved. Moves are not atomic across several S3 objects,
+// so other readers can briefly see part of the batch, and a failed rollback
+// leaves files behind; the error then reports what could not be removed.
+func (v *Vault) WriteBatchTransactional(ctx context.Context, inputs []NoteInput) error {
+	if err := v.checkBulkSize(len(inputs)); err != nil {
+		return err
+	}
+
+	notes, err := v.newNotes(ctx, inputs)
+	if err != nil {
+		return err
+	}
+
+	tx := &transaction{
+		vault:   v,
+		staging: path.Join(StagingDir, ulid.New()),
+	}
+	if err := tx.stage(ctx, notes); err != nil {
+		return tx.rollback(ctx, err)
+	}
+	if err := tx.promote(ctx, notes); err != nil {
+		return tx.rollback(ctx, err)
+	}
+
+	for _, n := range notes {
+		if err := v.index(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newNotes builds the notes for inputs, failing on the first invalid
+// input and on notes that would share a file
+func (v *Vault) newNotes(ctx context.Context, inputs []NoteInput) ([]*types.Note, error) {
+	notes := make([]*types.Note, len(inputs))
+	paths := make(map[string]bool, len(inputs))
+	for i, input := range inputs {
+		n, err := v.newNote(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("note %d: %w", i, err)
+		}
+		if paths[n.FilePath] {
+			return nil, fmt.Errorf("note %d: %w", i, types.NewNoteExistsError(n.ID))
+		}
+		paths[n.FilePath] = true
+		notes[i] = n
+	}
+	return notes, nil
+}
+
+// transaction tracks the files a transactional write has created so that
+// they can be removed on failure
+type transaction struct {
+	vault   *Vault
+	staging string
+
+	// staged and promoted are the staging and final paths written so far
+	staged   []string
+	promoted []string
+}
+
+// stagingPath returns the path n is staged at
+func (tx *transaction) stagingPath(n *types.Note) string {
+	return path.Join(tx.staging, n.FilePath)
+}
+
+// stage writes every note to its staging path
+func (tx *transaction) stage(ctx context.Context, notes []*types.Note) error {
+	for _, n := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		stagingPath := tx.stagingPath(n)
+		if err := tx.vault.storage.Write(ctx, stagingPath, tx.vault.serialize(n)); err != nil {
+			return fmt.Errorf("failed to stage note %s: %w", n.ID, err)
+		}
+		tx.staged = append(tx.staged, stagingPath)
+	}
+	return nil
+}
+
+// promote moves every staged note to its final path
+func (tx *transaction) promote(ctx context.Context, notes []*types.Note) error {
+	for i, n := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// A note created at the same path since the batch was validated
+		// is neither overwritten nor removed on rollback
+		exists, err := tx.vault.storage.Exists(ctx, n.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to check note %s: %w", n.ID, err)
+		}
+		if exists {
+			return types.NewNoteExistsError(n.ID)
+		}
+
+		// A move that copies before deleting can fail after creating the
+		// final file, so the path is removed on rollback either way
+		err = tx.vault.storage.Move(ctx, tx.stagingPath(n), n.FilePath)
+		tx.promoted = append(tx.promoted, n.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to write note %s: %w", n.ID, err)
+		}
+		tx.staged[i] = ""
+	}
+	return nil
+}
+
+// rollback removes the files written so far and returns err, joined with
+// any failure to remove them. It runs even when ctx is cancelled.
+func (tx *transaction) rollback(ctx context.Context, err error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	var failed []error
+	remove := func(filePath string) {
+		if filePath == "" {
+			return
+		}
+		if err := tx.vault.storage.Delete(ctx, filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			failed = append(failed, err)
+		}
+	}
+	for _, filePath := range tx.promoted {
+		remove(filePath)
+	}
+	for _, filePath := range tx.staged {
+		remove(filePath)
+	}
+
+	if len(failed) > 0 {
+		return errors.Join(err, fmt.Errorf("rollback incomplete: %w", errors.Join(failed...)))
+	}
+	return err
+}
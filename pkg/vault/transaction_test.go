@@ -0,0 +1,223 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// failingStorage fails the failAt'th call (1-based) to the operation op,
+// and optionally every Delete
+type failingStorage struct {
+	types.StorageBackend
+	op          string
+	failAt      int
+	calls       int
+	failDeletes bool
+}
+
+var errInjected = errors.New("injected failure")
+
+func (s *failingStorage) fail(op string) error {
+	if op != s.op {
+		return nil
+	}
+	s.calls++
+	if s.calls == s.failAt {
+		return errInjected
+	}
+	return nil
+}
+
+func (s *failingStorage) Write(ctx context.Context, path string, data []byte) error {
+	if err := s.fail("write"); err != nil {
+		return err
+	}
+	return s.StorageBackend.Write(ctx, path, data)
+}
+
+func (s *failingStorage) Move(ctx context.Context, src, dst string) error {
+	if err := s.fail("move"); err != nil {
+		return err
+	}
+	return s.StorageBackend.Move(ctx, src, dst)
+}
+
+func (s *failingStorage) Delete(ctx context.Context, path string) error {
+	if s.failDeletes {
+		return errInjected
+	}
+	return s.StorageBackend.Delete(ctx, path)
+}
+
+// newFailingVault returns a vault over local storage that fails as
+// configured by failing
+func newFailingVault(t *testing.T, failing *failingStorage) (*Vault, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	backend, err := storage.CreateStorage(types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: dir, CreateDirs: true},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	failing.StorageBackend = backend
+	v := New(failing, DefaultOptions())
+	v.now = func() time.Time { return testTime }
+	return v, dir
+}
+
+// filesIn returns the files below dir, relative to it
+func filesIn(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestVault_WriteBatchTransactional(t *testing.T) {
+	v, dir := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	// Searching first loads the index, which the batch then updates
+	_, err := v.Search(ctx, search.SearchQuery{Query: "anything"})
+	require.NoError(t, err)
+
+	require.NoError(t, v.WriteBatchTransactional(ctx, []NoteInput{
+		{Title: "First", Content: "Goroutines.\n"},
+		{Title: "Second", Tags: []string{"batch"}},
+		{Title: "Third"},
+	}))
+
+	notes, err := v.ListNotes(ctx)
+	require.NoError(t, err)
+	assert.Len(t, notes, 3)
+
+	results, err := v.Search(ctx, search.SearchQuery{Query: "goroutines"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "First", results[0].Note.Title)
+
+	for _, file := range filesIn(t, dir) {
+		assert.NotContains(t, file, StagingDir)
+	}
+}
+
+func TestVault_WriteBatchTransactionalRollback(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing *failingStorage
+	}{
+		{"staging fails", &failingStorage{op: "write", failAt: 3}},
+		{"promotion fails", &failingStorage{op: "move", failAt: 3}},
+		{"first promotion fails", &failingStorage{op: "move", failAt: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, dir := newFailingVault(t, tt.failing)
+			ctx := context.Background()
+
+			err := v.WriteBatchTransactional(ctx, []NoteInput{
+				{Title: "One"}, {Title: "Two"}, {Title: "Three"}, {Title: "Four"},
+			})
+			require.ErrorIs(t, err, errInjected)
+
+			notes, err := v.ListNotes(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, notes, "a failed batch should leave no notes")
+			assert.Empty(t, filesIn(t, dir), "a failed batch should leave no staged files")
+		})
+	}
+}
+
+func TestVault_WriteBatchTransactionalKeepsConcurrentNote(t *testing.T) {
+	v, _ := newFailingVault(t, &failingStorage{op: "move", failAt: 1})
+	ctx := context.Background()
+
+	notes, err := v.newNotes(ctx, []NoteInput{{Title: "One"}, {Title: "Two"}})
+	require.NoError(t, err)
+	tx := &transaction{vault: v, staging: path.Join(StagingDir, "test")}
+	require.NoError(t, tx.stage(ctx, notes))
+
+	// Another writer creates a note at the first path after validation
+	other := []byte("written by someone else")
+	require.NoError(t, v.storage.Write(ctx, notes[0].FilePath, other))
+
+	err = tx.rollback(ctx, tx.promote(ctx, notes))
+	require.Error(t, err)
+	assert.True(t, types.IsConflictError(err), "got %v", err)
+
+	data, err := v.storage.Read(ctx, notes[0].FilePath)
+	require.NoError(t, err, "rollback must not remove a note it did not write")
+	assert.Equal(t, other, data)
+	exists, err := v.storage.Exists(ctx, tx.stagingPath(notes[0]))
+	require.NoError(t, err)
+	assert.False(t, exists, "staged files are removed")
+}
+
+func TestVault_WriteBatchTransactionalRollbackFails(t *testing.T) {
+	v, _ := newFailingVault(t, &failingStorage{op: "move", failAt: 2, failDeletes: true})
+
+	err := v.WriteBatchTransactional(context.Background(), []NoteInput{{Title: "One"}, {Title: "Two"}})
+	require.ErrorIs(t, err, errInjected)
+	assert.Contains(t, err.Error(), "rollback incomplete")
+}
+
+func TestVault_WriteBatchTransactionalValidation(t *testing.T) {
+	opts := DefaultOptions()
+	opts.IDScheme = types.IDSchemeSlug
+	opts.MaxBulkSize = 3
+	v, dir := newTestVault(t, opts)
+	ctx := context.Background()
+
+	_, err := v.CreateNote(ctx, NoteInput{Title: "Existing"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		inputs []NoteInput
+		check  func(error) bool
+	}{
+		{"invalid input", []NoteInput{{Title: "Valid"}, {Title: ""}}, types.IsValidationError},
+		{"existing note", []NoteInput{{Title: "New"}, {Title: "Existing"}}, types.IsConflictError},
+		{"duplicate in batch", []NoteInput{{Title: "Same"}, {Title: "Same"}}, types.IsConflictError},
+		{"too many notes", []NoteInput{{Title: "A"}, {Title: "B"}, {Title: "C"}, {Title: "D"}}, types.IsValidationError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.WriteBatchTransactional(ctx, tt.inputs)
+			require.Error(t, err)
+			var kbErr *types.KBError
+			require.True(t, errors.As(err, &kbErr), "got %v", err)
+			assert.True(t, tt.check(kbErr), "got %v", err)
+
+			assert.Equal(t, []string{"notes/existing.md"}, filesIn(t, dir))
+		})
+	}
+}
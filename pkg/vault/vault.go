@@ -0,0 +1,578 @@
+// Package vault provides a programmatic API for the notes in a knowledge
+// base vault. It wraps a storage backend with note-level operations so that
+// the CLI and servers share a single implementation of note parsing,
+// serialization and indexing.
+package vault
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/metrics"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/ulid"
+)
+
+// Limits applied to note input, matching the validation tags on
+// types.CreateNoteRequest
+const (
+	maxTitleLength   = 200
+	maxContentLength = 10 * 1024 * 1024
+)
+
+// NoteInput describes a note to create
+type NoteInput = types.CreateNoteRequest
+
+// Options configures a Vault
+type Options struct {
+	// NotesDir is the directory new notes are written to
+	NotesDir string
+
+	// DailyDir is the directory holding daily notes
+	DailyDir string
+
+	// ExtraDirs are further directories holding notes
+	ExtraDirs []string
+
+	// Ignore excludes matching files from listing and indexing; nil
+	// ignores nothing
+	Ignore *types.IgnorePatterns
+
+	// MaxBulkSize limits the number of items in a bulk operation; zero
+	// means no limit
+	MaxBulkSize int
+
+	// TitleSources is the order in which note titles are resolved; empty
+	// uses the default precedence
+	TitleSources []types.TitleSource
+
+	// IDScheme generates the IDs of new notes; empty uses ULIDs
+	IDScheme types.IDScheme
+
+	// TrackChecksums records a body checksum in the frontmatter of every
+	// note written; notes that already have one are always kept current
+	TrackChecksums bool
+
+	// FrontmatterSchema is checked when notes are created or updated
+	FrontmatterSchema note.Schema
+
+	// Search configures the full-text search engine
+	Search search.Options
+
+	// Metrics, if set, records search latency
+	Metrics *metrics.Registry
+
+	// Tracer, if set, records a span for every search
+	Tracer *trace.Tracer
+}
+
+// DefaultOptions returns the options used for a default vault configuration
+func DefaultOptions() Options {
+	return Options{
+		NotesDir:    "notes",
+		DailyDir:    "notes/dailies",
+		MaxBulkSize: 100,
+		Search:      search.DefaultOptions(),
+	}
+}
+
+// OptionsFromConfig derives vault options from a kbvault configuration
+func OptionsFromConfig(cfg *types.Config) (Options, error) {
+	opts := DefaultOptions()
+	if cfg.Vault.NotesDir != "" {
+		opts.NotesDir = cfg.Vault.NotesDir
+	}
+	if cfg.Vault.DailyDir != "" {
+		opts.DailyDir = cfg.Vault.DailyDir
+	}
+	opts.ExtraDirs = cfg.Vault.ExtraDirs
+	opts.MaxBulkSize = cfg.MCP.MaxBulkSize
+	opts.TrackChecksums = cfg.Vault.TrackChecksums
+	opts.FrontmatterSchema = cfg.Vault.FrontmatterSchema
+	opts.Search.InlineTags = cfg.Vault.InlineTags
+	opts.Search.MaxLimit = cfg.VectorSearch.Search.MaxLimit
+
+	titleSources, err := types.ParseTitleSources(cfg.Vault.TitleSource)
+	if err != nil {
+		return opts, err
+	}
+	opts.TitleSources = titleSources
+
+	ignore, err := types.ParseIgnorePatterns(cfg.Vault.Ignore)
+	if err != nil {
+		return opts, err
+	}
+	opts.Ignore = ignore
+
+	idScheme, err := types.ParseIDScheme(cfg.Vault.IDScheme)
+	if err != nil {
+		return opts, err
+	}
+	opts.IDScheme = idScheme
+
+	return opts, nil
+}
+
+// Vault provides note operations on top of a storage backend. It is safe
+// for concurrent use.
+type Vault struct {
+	storage types.StorageBackend
+	options Options
+	search  *search.Engine
+
+	// indexMu guards indexed, which records whether the search index has
+	// been loaded from storage
+	indexMu sync.Mutex
+	indexed bool
+
+	// indexedAt is when the search index was built from the notes in
+	// storage, in Unix nanoseconds
+	indexedAt atomic.Int64
+
+	// now returns the current time; replaced in tests
+	now func() time.Time
+}
+
+// New creates a vault backed by storage. The caller keeps ownership of
+// storage and is responsible for closing it.
+func New(storage types.StorageBackend, opts Options) *Vault {
+	if opts.NotesDir == "" {
+		opts.NotesDir = DefaultOptions().NotesDir
+	}
+	if opts.Search.TitleSources == nil {
+		opts.Search.TitleSources = opts.TitleSources
+	}
+	if opts.Search.Dirs == nil {
+		opts.Search.Dirs = noteDirs(opts)
+	}
+	if opts.Search.Ignore == nil {
+		opts.Search.Ignore = opts.Ignore
+	}
+
+	return &Vault{
+		storage: storage,
+		options: opts,
+		search:  search.New(storage, opts.Search),
+		now:     time.Now,
+	}
+}
+
+// Storage returns the backend the vault reads and writes
+func (v *Vault) Storage() types.StorageBackend {
+	return v.storage
+}
+
+// CreateNote writes a new note with a freshly generated ID
+func (v *Vault) CreateNote(ctx context.Context, input NoteInput) (*types.Note, error) {
+	n, err := v.newNote(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.write(ctx, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// newNote validates input and builds the note it describes, with a fresh
+// ID whose file doesn't exist yet
+func (v *Vault) newNote(ctx context.Context, input NoteInput) (*types.Note, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+
+	id := note.GenerateID(input.Title, string(v.options.IDScheme))
+	filePath := path.Join(v.options.NotesDir, ulid.ToFilename(id))
+
+	exists, err := v.storage.Exists(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check note %s: %w", id, err)
+	}
+	if exists {
+		return nil, types.NewNoteExistsError(id)
+	}
+
+	noteType := input.Type
+	if noteType == "" {
+		noteType = note.DefaultType
+	}
+	tags := input.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	now := v.now().UTC().Truncate(time.Second)
+	n := &types.Note{
+		ID:             id,
+		Title:          input.Title,
+		Content:        input.Content,
+		FilePath:       filePath,
+		StorageBackend: v.storage.Type(),
+		Frontmatter: types.Frontmatter{
+			ID:       id,
+			Title:    input.Title,
+			Tags:     tags,
+			Type:     noteType,
+			Storage:  string(v.storage.Type()),
+			Created:  note.FormatTimestamp(now),
+			Updated:  note.FormatTimestamp(now),
+			Template: input.Template,
+			Custom:   input.Custom,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := v.checkSchema(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// GetNote reads the note with the given ID
+func (v *Vault) GetNote(ctx context.Context, id string) (*types.Note, error) {
+	filePath, err := v.findNote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return v.readNote(ctx, filePath)
+}
+
+// UpdateNote applies the non-nil fields of req to an existing note and
+// refreshes its updated timestamp. Tags replace the existing tags when
+// non-nil; custom fields are merged, and a nil value removes a field.
+func (v *Vault) UpdateNote(ctx context.Context, req types.UpdateNoteRequest) (*types.Note, error) {
+	n, err := v.GetNote(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		if err := validateTitle(*req.Title); err != nil {
+			return nil, err
+		}
+		n.Title = *req.Title
+		n.Frontmatter.Title = *req.Title
+	}
+	if req.Content != nil {
+		if len(*req.Content) > maxContentLength {
+			return nil, types.NewInvalidContentError("content exceeds 10MB limit")
+		}
+		n.Content = *req.Content
+	}
+	if req.Tags != nil {
+		n.Frontmatter.Tags = req.Tags
+	}
+	if req.Type != nil {
+		n.Frontmatter.Type = *req.Type
+	}
+	for key, value := range req.Custom {
+		if value == nil {
+			delete(n.Frontmatter.Custom, key)
+			continue
+		}
+		if n.Frontmatter.Custom == nil {
+			n.Frontmatter.Custom = make(map[string]interface{})
+		}
+		n.Frontmatter.Custom[key] = value
+	}
+
+	if err := v.checkSchema(n); err != nil {
+		return nil, err
+	}
+
+	now := v.now().UTC().Truncate(time.Second)
+	n.Frontmatter.Updated = note.FormatTimestamp(now)
+	n.UpdatedAt = now
+
+	if err := v.write(ctx, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// DeleteNote removes the note with the given ID
+func (v *Vault) DeleteNote(ctx context.Context, id string) error {
+	filePath, err := v.findNote(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := v.storage.Delete(ctx, filePath); err != nil {
+		return fmt.Errorf("failed to delete note %s: %w", id, err)
+	}
+
+	return v.unindex(ctx, id)
+}
+
+// ListNotes returns every note in the vault sorted by ID. Files that can't
+// be read are skipped.
+func (v *Vault) ListNotes(ctx context.Context) ([]*types.Note, error) {
+	files, err := v.noteFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make([]*types.Note, 0, len(files))
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		n, err := v.readNote(ctx, file)
+		if err != nil {
+			continue
+		}
+		notes = append(notes, n)
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].ID < notes[j].ID
+	})
+	return notes, nil
+}
+
+// Search runs a full-text query. The index is loaded from storage on first
+// use and kept up to date by the vault's write operations.
+func (v *Vault) Search(ctx context.Context, query search.SearchQuery) ([]search.SearchResult, error) {
+	ctx, span := v.options.Tracer.Start(ctx, "search")
+	span.SetAttribute("search.query", query.Query)
+
+	start := time.Now()
+	results, err := v.runSearch(ctx, query)
+	if v.options.Metrics != nil {
+		v.options.Metrics.ObserveSearch(time.Since(start), err)
+	}
+	span.SetAttribute("search.results", strconv.Itoa(len(results)))
+	span.Finish(err)
+	return results, err
+}
+
+// EffectiveLimit returns the number of results Search returns at most for
+// query and whether the configured maximum lowered it. See
+// search.Engine.EffectiveLimit.
+func (v *Vault) EffectiveLimit(query search.SearchQuery) (int, bool) {
+	return v.search.EffectiveLimit(query)
+}
+
+// IndexStatus reports the size and freshness of the search index. The
+// index is synced when it is built by the first search.
+func (v *Vault) IndexStatus() search.IndexStatus {
+	status := v.search.IndexStatus()
+	if indexedAt := v.indexedAt.Load(); indexedAt != 0 {
+		if t := time.Unix(0, indexedAt); t.After(status.SyncedAt) {
+			status.SyncedAt = t
+		}
+	}
+	return status
+}
+
+func (v *Vault) runSearch(ctx context.Context, query search.SearchQuery) ([]search.SearchResult, error) {
+	if err := v.ensureIndex(ctx); err != nil {
+		return nil, err
+	}
+	return v.search.Search(ctx, query)
+}
+
+// validateInput checks a note to be created
+func validateInput(input NoteInput) error {
+	if err := validateTitle(input.Title); err != nil {
+		return err
+	}
+	if len(input.Content) > maxContentLength {
+		return types.NewInvalidContentError("content exceeds 10MB limit")
+	}
+	return nil
+}
+
+func validateTitle(title string) error {
+	if strings.TrimSpace(title) == "" {
+		return types.NewValidationError("note title cannot be empty")
+	}
+	if len(title) > maxTitleLength {
+		return types.NewValidationError(fmt.Sprintf("note title exceeds %d characters", maxTitleLength))
+	}
+	return nil
+}
+
+// checkSchema rejects notes whose frontmatter doesn't match the
+// configured schema with a validation error
+func (v *Vault) checkSchema(n *types.Note) error {
+	if err := note.ValidateFrontmatter(n.Frontmatter, v.options.FrontmatterSchema); err != nil {
+		return types.NewValidationError(err.Error()).WithCause(err)
+	}
+	return nil
+}
+
+// validateID rejects IDs that could address a file outside the note
+// directories
+func validateID(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return types.NewInvalidIDError(id)
+	}
+	return nil
+}
+
+// findNote returns the path of the note with the given ID, checking the
+// usual locations before scanning the vault
+func (v *Vault) findNote(ctx context.Context, id string) (string, error) {
+	if err := validateID(id); err != nil {
+		return "", err
+	}
+
+	filename := ulid.ToFilename(id)
+	for _, dir := range noteDirs(v.options) {
+		filePath := path.Join(dir, filename)
+		exists, err := v.storage.Exists(ctx, filePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to check note %s: %w", id, err)
+		}
+		if exists {
+			return filePath, nil
+		}
+	}
+
+	files, err := v.noteFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if path.Base(file) == filename {
+			return file, nil
+		}
+	}
+
+	return "", types.NewNoteNotFoundError(id)
+}
+
+// noteDirs returns the directories notes are stored in, as listing
+// prefixes
+func noteDirs(opts Options) []string {
+	return types.VaultConfig{
+		NotesDir:  opts.NotesDir,
+		DailyDir:  opts.DailyDir,
+		ExtraDirs: opts.ExtraDirs,
+	}.NoteDirs()
+}
+
+// noteFiles lists the markdown files in the note directories, leaving out
+// ignored ones
+func (v *Vault) noteFiles(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+
+	for _, dir := range noteDirs(v.options) {
+		listed, err := v.storage.List(ctx, dir)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			// Directories that don't exist yet hold no notes
+			continue
+		}
+
+		for _, file := range listed {
+			if !strings.HasSuffix(file, ".md") || seen[file] || v.options.Ignore.Match(file) {
+				continue
+			}
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// readNote reads and parses a note file, filling in storage metadata
+func (v *Vault) readNote(ctx context.Context, filePath string) (*types.Note, error) {
+	data, err := v.storage.Read(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note %s: %w", filePath, err)
+	}
+
+	n := note.ParseWithOptions(filePath, data, note.ParseOptions{TitleSources: v.options.TitleSources})
+	n.StorageBackend = v.storage.Type()
+
+	if info, err := v.storage.Stat(ctx, filePath); err == nil && info.ModTime > 0 {
+		modTime := time.Unix(info.ModTime, 0)
+		if n.UpdatedAt.IsZero() {
+			n.UpdatedAt = modTime
+		}
+		if n.CreatedAt.IsZero() {
+			n.CreatedAt = modTime
+		}
+	}
+
+	return n, nil
+}
+
+// write serializes a note to storage and updates the search index
+func (v *Vault) write(ctx context.Context, n *types.Note) error {
+	if err := v.storage.Write(ctx, n.FilePath, v.serialize(n)); err != nil {
+		return fmt.Errorf("failed to write note %s: %w", n.ID, err)
+	}
+	return v.index(ctx, n)
+}
+
+// serialize returns the file content of a note, refreshing its checksum
+// and size
+func (v *Vault) serialize(n *types.Note) []byte {
+	note.UpdateChecksum(&n.Frontmatter, n.Content, v.options.TrackChecksums)
+	data := note.SerializeNote(n.Frontmatter, n.Content)
+	n.Size = int64(len(data))
+	return data
+}
+
+// index adds a written note to the search index once it has been loaded
+func (v *Vault) index(ctx context.Context, n *types.Note) error {
+	v.indexMu.Lock()
+	defer v.indexMu.Unlock()
+	if !v.indexed {
+		return nil
+	}
+	return v.search.IndexNote(ctx, n)
+}
+
+// unindex removes a deleted note from the search index
+func (v *Vault) unindex(ctx context.Context, id string) error {
+	v.indexMu.Lock()
+	defer v.indexMu.Unlock()
+	if !v.indexed {
+		return nil
+	}
+	return v.search.RemoveFromIndex(ctx, id)
+}
+
+// ensureIndex loads every note into the search index on first use
+func (v *Vault) ensureIndex(ctx context.Context) error {
+	v.indexMu.Lock()
+	defer v.indexMu.Unlock()
+	if v.indexed {
+		return nil
+	}
+
+	notes, err := v.ListNotes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+	for _, n := range notes {
+		if err := v.search.IndexNote(ctx, n); err != nil {
+			return fmt.Errorf("failed to index note %s: %w", n.ID, err)
+		}
+	}
+
+	v.indexed = true
+	v.indexedAt.Store(v.now().UnixNano())
+	return nil
+}
@@ -0,0 +1,460 @@
+package vault
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/internal/search"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/metrics"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/note"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/storage"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/trace"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+var testTime = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// newTestVault returns a vault over local storage in a temporary directory
+func newTestVault(t *testing.T, opts Options) (*Vault, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	backend, err := storage.CreateStorage(types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: dir, CreateDirs: true},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	v := New(backend, opts)
+	v.now = func() time.Time { return testTime }
+	return v, dir
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	cfg := types.DefaultConfig()
+	cfg.Vault.NotesDir = "kb"
+	cfg.MCP.MaxBulkSize = 7
+	cfg.VectorSearch.Search.MaxLimit = 50
+	cfg.Vault.FrontmatterSchema.Required = []string{"author"}
+
+	cfg.Vault.TitleSource = "heading,filename"
+
+	opts, err := OptionsFromConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "kb", opts.NotesDir)
+	assert.Equal(t, cfg.Vault.DailyDir, opts.DailyDir)
+	assert.Equal(t, 7, opts.MaxBulkSize)
+	assert.Equal(t, 50, opts.Search.MaxLimit)
+	assert.Equal(t, []string{"author"}, opts.FrontmatterSchema.Required)
+	assert.Equal(t, []types.TitleSource{types.TitleSourceHeading, types.TitleSourceFilename}, opts.TitleSources)
+
+	assert.Equal(t, types.IDSchemeULID, opts.IDScheme)
+
+	cfg.Vault.TitleSource = "nope"
+	_, err = OptionsFromConfig(cfg)
+	assert.True(t, types.IsValidationError(err))
+
+	cfg.Vault.TitleSource = ""
+	cfg.Vault.IDScheme = "uuid"
+	_, err = OptionsFromConfig(cfg)
+	assert.True(t, types.IsValidationError(err))
+
+	cfg.Vault.IDScheme = ""
+	cfg.Vault.Ignore = []string{"!drafts"}
+	_, err = OptionsFromConfig(cfg)
+	assert.True(t, types.IsValidationError(err))
+}
+
+func TestVault_Ignore(t *testing.T) {
+	cfg := types.DefaultConfig()
+	cfg.Vault.Ignore = []string{"drafts/", "*.draft.md"}
+	opts, err := OptionsFromConfig(cfg)
+	require.NoError(t, err)
+	v, dir := newTestVault(t, opts)
+	ctx := context.Background()
+
+	for _, file := range []string{"notes/kept.md", "notes/drafts/wip.md", "notes/idea.draft.md"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("# Ignored or not\n\nPangolin.\n"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "drafts.md"), []byte("# Drafts\n\nPangolin.\n"), 0644))
+
+	notes, err := v.ListNotes(ctx)
+	require.NoError(t, err)
+	var paths []string
+	for _, n := range notes {
+		paths = append(paths, n.FilePath)
+	}
+	assert.ElementsMatch(t, []string{"notes/drafts.md", "notes/kept.md"}, paths)
+
+	results, err := v.Search(ctx, search.SearchQuery{Query: "pangolin"})
+	require.NoError(t, err)
+	paths = nil
+	for _, r := range results {
+		paths = append(paths, r.Note.FilePath)
+	}
+	assert.ElementsMatch(t, []string{"notes/drafts.md", "notes/kept.md"}, paths)
+}
+
+func TestVault_FrontmatterSchema(t *testing.T) {
+	opts := DefaultOptions()
+	opts.FrontmatterSchema = note.Schema{
+		Required: []string{"author"},
+		Allowed:  map[string][]string{"status": {"draft", "published"}},
+	}
+	v, _ := newTestVault(t, opts)
+	ctx := context.Background()
+
+	_, err := v.CreateNote(ctx, NoteInput{Title: "No author"})
+	require.True(t, types.IsValidationError(err), "got %v", err)
+	assert.Contains(t, err.Error(), `missing required field "author"`)
+	var schemaErr *note.SchemaError
+	assert.ErrorAs(t, err, &schemaErr)
+
+	_, err = v.CreateNote(ctx, NoteInput{Title: "Bad status", Custom: map[string]interface{}{"author": "alice", "status": "wip"}})
+	require.True(t, types.IsValidationError(err), "got %v", err)
+	assert.Contains(t, err.Error(), `field "status" has value "wip"`)
+
+	n, err := v.CreateNote(ctx, NoteInput{Title: "Good", Custom: map[string]interface{}{"author": "alice", "status": "draft"}})
+	require.NoError(t, err)
+
+	// Updates are checked too, and a rejected update changes nothing
+	_, err = v.UpdateNote(ctx, types.UpdateNoteRequest{ID: n.ID, Custom: map[string]interface{}{"author": nil}})
+	require.True(t, types.IsValidationError(err), "got %v", err)
+	stored, err := v.GetNote(ctx, n.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", stored.Frontmatter.Custom["author"])
+
+	_, err = v.UpdateNote(ctx, types.UpdateNoteRequest{ID: n.ID, Custom: map[string]interface{}{"status": "published"}})
+	require.NoError(t, err)
+}
+
+func TestVault_IndexStatus(t *testing.T) {
+	v, _ := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	_, err := v.CreateNote(ctx, NoteInput{Title: "Indexed"})
+	require.NoError(t, err)
+	assert.True(t, v.IndexStatus().SyncedAt.IsZero(), "the index is built by the first search")
+
+	_, err = v.Search(ctx, search.SearchQuery{Query: "indexed"})
+	require.NoError(t, err)
+	status := v.IndexStatus()
+	assert.Equal(t, 1, status.Documents)
+	assert.Equal(t, testTime, status.SyncedAt.UTC())
+}
+
+func TestVault_TitleSources(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TitleSources = []types.TitleSource{types.TitleSourceHeading, types.TitleSourceFrontmatter}
+	v, dir := newTestVault(t, opts)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "n.md"),
+		[]byte("---\ntitle: From Frontmatter\n---\n\n# From Heading\n"), 0644))
+
+	n, err := v.GetNote(context.Background(), "n")
+	require.NoError(t, err)
+	assert.Equal(t, "From Heading", n.Title)
+}
+
+func TestVault_CreateAndGetNote(t *testing.T) {
+	v, dir := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	created, err := v.CreateNote(ctx, NoteInput{
+		Title:   "Go: Concurrency",
+		Content: "# Go: Concurrency\n\nChannels and goroutines.\n",
+		Tags:    []string{"go", "concurrency"},
+		Custom:  map[string]interface{}{"status": "draft"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, created.ID, 26)
+	assert.Equal(t, "notes/"+created.ID+".md", created.FilePath)
+	assert.Equal(t, "note", created.Frontmatter.Type)
+	assert.Equal(t, "2024-03-01T10:00:00Z", created.Frontmatter.Created)
+
+	_, err = os.Stat(filepath.Join(dir, "notes", created.ID+".md"))
+	require.NoError(t, err)
+
+	got, err := v.GetNote(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+	assert.Equal(t, "Go: Concurrency", got.Title)
+	assert.Equal(t, []string{"go", "concurrency"}, got.Frontmatter.Tags)
+	assert.Equal(t, "draft", got.Frontmatter.Custom["status"])
+	assert.Equal(t, created.Content, got.Content)
+	assert.Equal(t, testTime, got.CreatedAt)
+	assert.Equal(t, types.StorageTypeLocal, got.StorageBackend)
+}
+
+func TestVault_CreateNoteValidation(t *testing.T) {
+	v, _ := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	_, err := v.CreateNote(ctx, NoteInput{Title: "  "})
+	assert.True(t, types.IsValidationError(err))
+
+	_, err = v.CreateNote(ctx, NoteInput{Title: string(make([]byte, 201))})
+	assert.True(t, types.IsValidationError(err))
+
+	_, err = v.CreateNote(ctx, NoteInput{Title: "Big", Content: string(make([]byte, maxContentLength+1))})
+	assert.True(t, types.IsValidationError(err))
+}
+
+func TestVault_GetNote(t *testing.T) {
+	v, dir := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	// Notes written by hand outside the notes directory are still found
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes", "dailies"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "dailies", "2024-03-01.md"),
+		[]byte("# Friday\n\nStandup notes.\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "loose.md"),
+		[]byte("---\ntitle: Loose: unquoted\ntags: [a, b]\n---\n\nBody\n"), 0644))
+
+	daily, err := v.GetNote(ctx, "2024-03-01")
+	require.NoError(t, err)
+	assert.Equal(t, "Friday", daily.Title)
+	assert.Equal(t, "notes/dailies/2024-03-01.md", daily.FilePath)
+	assert.False(t, daily.UpdatedAt.IsZero())
+
+	loose, err := v.GetNote(ctx, "loose")
+	require.NoError(t, err)
+	assert.Equal(t, "Loose: unquoted", loose.Title)
+	assert.Equal(t, []string{"a", "b"}, loose.Frontmatter.Tags)
+
+	_, err = v.GetNote(ctx, "missing")
+	assert.True(t, types.IsNotFoundError(err))
+
+	_, err = v.GetNote(ctx, "../secret")
+	assert.True(t, types.IsValidationError(err))
+}
+
+func TestVault_UpdateNote(t *testing.T) {
+	v, _ := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	created, err := v.CreateNote(ctx, NoteInput{
+		Title:   "Original",
+		Content: "Original body\n",
+		Tags:    []string{"keep"},
+		Custom:  map[string]interface{}{"status": "draft", "owner": "sam"},
+	})
+	require.NoError(t, err)
+
+	later := testTime.Add(time.Hour)
+	v.now = func() time.Time { return later }
+
+	title := "Renamed"
+	updated, err := v.UpdateNote(ctx, types.UpdateNoteRequest{
+		ID:     created.ID,
+		Title:  &title,
+		Custom: map[string]interface{}{"status": "final", "owner": nil},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.Title)
+	assert.Equal(t, "2024-03-01T11:00:00Z", updated.Frontmatter.Updated)
+
+	got, err := v.GetNote(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", got.Title)
+	assert.Equal(t, "Original body\n", got.Content, "content should be unchanged")
+	assert.Equal(t, []string{"keep"}, got.Frontmatter.Tags, "tags should be unchanged")
+	assert.Equal(t, map[string]interface{}{"status": "final"}, got.Frontmatter.Custom)
+	assert.Equal(t, "2024-03-01T10:00:00Z", got.Frontmatter.Created)
+	assert.Equal(t, later, got.UpdatedAt)
+
+	content := "New body\n"
+	_, err = v.UpdateNote(ctx, types.UpdateNoteRequest{ID: created.ID, Content: &content, Tags: []string{}})
+	require.NoError(t, err)
+
+	got, err = v.GetNote(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "New body\n", got.Content)
+	assert.Empty(t, got.Frontmatter.Tags)
+
+	empty := ""
+	_, err = v.UpdateNote(ctx, types.UpdateNoteRequest{ID: created.ID, Title: &empty})
+	assert.True(t, types.IsValidationError(err))
+
+	_, err = v.UpdateNote(ctx, types.UpdateNoteRequest{ID: "missing", Title: &title})
+	assert.True(t, types.IsNotFoundError(err))
+}
+
+func TestVault_IDScheme(t *testing.T) {
+	opts := DefaultOptions()
+	opts.IDScheme = types.IDSchemeSlug
+	v, _ := newTestVault(t, opts)
+	ctx := context.Background()
+
+	created, err := v.CreateNote(ctx, NoteInput{Title: "HTTP Routing"})
+	require.NoError(t, err)
+	assert.Equal(t, "http-routing", created.ID)
+	assert.Equal(t, "notes/http-routing.md", created.FilePath)
+
+	_, err = v.CreateNote(ctx, NoteInput{Title: "HTTP Routing"})
+	assert.True(t, types.IsConflictError(err))
+
+	v.options.IDScheme = types.IDSchemeTimestamp
+	first, err := v.CreateNote(ctx, NoteInput{Title: "Same"})
+	require.NoError(t, err)
+	second, err := v.CreateNote(ctx, NoteInput{Title: "Same"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, "Same", second.Frontmatter.Title)
+}
+
+func TestVault_TrackChecksums(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TrackChecksums = true
+	v, _ := newTestVault(t, opts)
+	ctx := context.Background()
+
+	created, err := v.CreateNote(ctx, NoteInput{Title: "Tracked", Content: "First body\n"})
+	require.NoError(t, err)
+	assert.Equal(t, note.BodyChecksum("First body\n"), created.Frontmatter.Checksum)
+
+	content := "Second body\n"
+	_, err = v.UpdateNote(ctx, types.UpdateNoteRequest{ID: created.ID, Content: &content})
+	require.NoError(t, err)
+
+	got, err := v.GetNote(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, note.BodyChecksum(got.Content), got.Frontmatter.Checksum)
+
+	untracked, _ := newTestVault(t, DefaultOptions())
+	plain, err := untracked.CreateNote(ctx, NoteInput{Title: "Plain", Content: "Body\n"})
+	require.NoError(t, err)
+	assert.Empty(t, plain.Frontmatter.Checksum)
+}
+
+func TestVault_DeleteNote(t *testing.T) {
+	v, dir := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	created, err := v.CreateNote(ctx, NoteInput{Title: "Temporary"})
+	require.NoError(t, err)
+
+	require.NoError(t, v.DeleteNote(ctx, created.ID))
+	_, err = os.Stat(filepath.Join(dir, created.FilePath))
+	assert.True(t, os.IsNotExist(err))
+
+	err = v.DeleteNote(ctx, created.ID)
+	assert.True(t, types.IsNotFoundError(err))
+}
+
+func TestVault_ListNotes(t *testing.T) {
+	v, dir := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	notes, err := v.ListNotes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+
+	first, err := v.CreateNote(ctx, NoteInput{Title: "First"})
+	require.NoError(t, err)
+	second, err := v.CreateNote(ctx, NoteInput{Title: "Second"})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "readme.txt"), []byte("not a note"), 0644))
+
+	notes, err = v.ListNotes(ctx)
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	assert.Equal(t, first.ID, notes[0].ID)
+	assert.Equal(t, second.ID, notes[1].ID)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = v.ListNotes(cancelled)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestVault_Search(t *testing.T) {
+	v, dir := newTestVault(t, DefaultOptions())
+	ctx := context.Background()
+
+	// A note that exists before the index is built
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "existing.md"),
+		[]byte("---\ntitle: Existing\ntags: [kubernetes]\n---\n\nPods and deployments.\n"), 0644))
+
+	results, err := v.Search(ctx, search.SearchQuery{Query: "pods"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "existing", results[0].Note.ID)
+
+	// Writes after the first search update the index
+	created, err := v.CreateNote(ctx, NoteInput{Title: "Goroutines", Content: "Lightweight threads.\n", Tags: []string{"go"}})
+	require.NoError(t, err)
+
+	results, err = v.Search(ctx, search.SearchQuery{Query: "goroutines"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, created.ID, results[0].Note.ID)
+
+	results, err = v.Search(ctx, search.SearchQuery{Tags: []string{"kubernetes"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	require.NoError(t, v.DeleteNote(ctx, created.ID))
+	results, err = v.Search(ctx, search.SearchQuery{Query: "goroutines"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestVault_SearchMetrics(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Metrics = metrics.NewRegistry()
+	v, _ := newTestVault(t, opts)
+
+	_, err := v.Search(context.Background(), search.SearchQuery{Query: "anything"})
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, opts.Metrics.WritePrometheus(&out))
+	assert.Contains(t, out.String(), `kbvault_search_requests_total{status="ok"} 1`)
+}
+
+func TestVault_SearchTrace(t *testing.T) {
+	backend, err := storage.CreateStorage(types.StorageConfig{
+		Type:  types.StorageTypeLocal,
+		Local: types.LocalStorageConfig{Path: t.TempDir(), CreateDirs: true},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	recorder := &trace.Recorder{}
+	opts := DefaultOptions()
+	opts.Tracer = trace.NewTracer(recorder)
+	v := New(trace.NewStorageWrapper(backend, opts.Tracer), opts)
+
+	ctx := trace.WithRequestID(context.Background(), "req-1")
+	_, err = v.Search(ctx, search.SearchQuery{Query: "anything"})
+	require.NoError(t, err)
+
+	spans := recorder.Spans()
+	require.NotEmpty(t, spans)
+	searchSpan := spans[len(spans)-1]
+	assert.Equal(t, "search", searchSpan.Name)
+	assert.Equal(t, "anything", searchSpan.Attributes["search.query"])
+	assert.Equal(t, "0", searchSpan.Attributes["search.results"])
+
+	// Storage calls made by the search are its children and carry the
+	// same request ID
+	for _, span := range spans[:len(spans)-1] {
+		assert.Contains(t, span.Name, "storage.")
+		assert.Equal(t, searchSpan.TraceID, span.TraceID)
+		assert.Equal(t, searchSpan.SpanID, span.ParentID)
+		assert.Equal(t, "req-1", span.Attributes[trace.AttrRequestID])
+	}
+	assert.Equal(t, "req-1", searchSpan.Attributes[trace.AttrRequestID])
+}
@@ -0,0 +1,88 @@
+package vector
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// ChunkID returns the vector index ID of chunk index of a note
+func ChunkID(noteID string, index int) string {
+	return fmt.Sprintf("%s#%d", noteID, index)
+}
+
+// ChunkDocument splits doc into chunks of at most config.ChunkSize
+// characters, each overlapping the previous one by config.ChunkOverlap
+// characters. Chunks end at whitespace where possible, and a final chunk
+// shorter than config.MinChunkSize is merged into the one before it.
+//
+// A document that fits in one chunk, or any document when ChunkSize is 0,
+// is returned unchanged as the only chunk. Otherwise each chunk copies the
+// document's fields, with ID set by ChunkID, ParentID set to the document
+// ID, ChunkIndex to its position and ChunkSize to its length in characters.
+func ChunkDocument(doc *types.Document, config types.IndexingConfig) []*types.Document {
+	runes := []rune(doc.Content)
+	size := config.ChunkSize
+	if size <= 0 || len(runes) <= size {
+		return []*types.Document{doc}
+	}
+
+	overlap := config.ChunkOverlap
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var bounds [][2]int
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			end = len(runes)
+		} else {
+			end = chunkBreak(runes, start, end)
+		}
+
+		if end-start < config.MinChunkSize && len(bounds) > 0 {
+			bounds[len(bounds)-1][1] = end
+		} else {
+			bounds = append(bounds, [2]int{start, end})
+		}
+		if end == len(runes) {
+			break
+		}
+
+		// Always move forward, even when the overlap would cover the
+		// whole chunk
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+
+	chunks := make([]*types.Document, len(bounds))
+	for i, b := range bounds {
+		chunk := *doc
+		chunk.ID = ChunkID(doc.ID, i)
+		chunk.Content = strings.TrimSpace(string(runes[b[0]:b[1]]))
+		chunk.Embedding = nil
+		chunk.ParentID = doc.ID
+		chunk.ChunkIndex = i
+		chunk.ChunkSize = b[1] - b[0]
+		chunks[i] = &chunk
+	}
+	return chunks
+}
+
+// chunkBreak returns where a chunk from start should end, at or before end:
+// just after the last whitespace in the second half of the chunk, or end
+// when there is none
+func chunkBreak(runes []rune, start, end int) int {
+	for i := end; i > start+(end-start)/2; i-- {
+		if unicode.IsSpace(runes[i-1]) {
+			return i
+		}
+	}
+	return end
+}
@@ -0,0 +1,80 @@
+package vector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+func TestChunkDocument(t *testing.T) {
+	doc := &types.Document{
+		ID:      "note",
+		Title:   "Chunked",
+		Path:    "notes/note.md",
+		Tags:    []string{"go"},
+		Content: strings.Repeat("word ", 50), // 250 characters
+	}
+
+	t.Run("short documents are not chunked", func(t *testing.T) {
+		chunks := ChunkDocument(doc, types.IndexingConfig{ChunkSize: 1000})
+		require.Len(t, chunks, 1)
+		assert.Same(t, doc, chunks[0])
+
+		chunks = ChunkDocument(doc, types.IndexingConfig{})
+		require.Len(t, chunks, 1)
+		assert.Same(t, doc, chunks[0])
+	})
+
+	t.Run("chunks break at whitespace and overlap", func(t *testing.T) {
+		chunks := ChunkDocument(doc, types.IndexingConfig{ChunkSize: 100, ChunkOverlap: 20})
+		require.Greater(t, len(chunks), 2)
+
+		for i, chunk := range chunks {
+			assert.Equal(t, ChunkID("note", i), chunk.ID)
+			assert.Equal(t, "note", chunk.ParentID)
+			assert.Equal(t, i, chunk.ChunkIndex)
+			assert.LessOrEqual(t, chunk.ChunkSize, 100)
+			assert.Equal(t, "Chunked", chunk.Title)
+			assert.Equal(t, "notes/note.md", chunk.Path)
+			assert.Equal(t, []string{"go"}, chunk.Tags)
+
+			// No word is split
+			for _, word := range strings.Fields(chunk.Content) {
+				assert.Equal(t, "word", word)
+			}
+		}
+
+		// Together the chunks cover the whole document, with overlap
+		total := 0
+		for _, chunk := range chunks {
+			total += chunk.ChunkSize
+		}
+		assert.Greater(t, total, len(doc.Content))
+	})
+
+	t.Run("short final chunk is merged", func(t *testing.T) {
+		doc := &types.Document{ID: "note", Content: strings.Repeat("a", 210)}
+
+		chunks := ChunkDocument(doc, types.IndexingConfig{ChunkSize: 100, MinChunkSize: 50})
+		require.Len(t, chunks, 2)
+		assert.Equal(t, 100, chunks[0].ChunkSize)
+		assert.Equal(t, 110, chunks[1].ChunkSize)
+
+		chunks = ChunkDocument(doc, types.IndexingConfig{ChunkSize: 100})
+		require.Len(t, chunks, 3)
+		assert.Equal(t, 10, chunks[2].ChunkSize)
+	})
+
+	t.Run("chunk size counts characters", func(t *testing.T) {
+		doc := &types.Document{ID: "note", Content: strings.Repeat("é", 150)}
+
+		chunks := ChunkDocument(doc, types.IndexingConfig{ChunkSize: 100})
+		require.Len(t, chunks, 2)
+		assert.Equal(t, strings.Repeat("é", 100), chunks[0].Content)
+		assert.Equal(t, strings.Repeat("é", 50), chunks[1].Content)
+	})
+}
@@ -0,0 +1,212 @@
+// Package cohere implements an embedding client for the Cohere embed API.
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// DefaultBaseURL is the Cohere API root used when no base URL is configured
+const DefaultBaseURL = "https://api.cohere.com/v2"
+
+// Input types tell Cohere what an embedding is for. Documents and the
+// queries searching them are embedded differently, which matters for
+// retrieval quality.
+const (
+	InputTypeSearchDocument = "search_document"
+	InputTypeSearchQuery    = "search_query"
+	InputTypeClassification = "classification"
+	InputTypeClustering     = "clustering"
+)
+
+// MaxBatchSize is the most texts the embed API accepts in one request
+const MaxBatchSize = 96
+
+// backend identifies this provider in VectorSearchError values
+const backend = types.VectorSearchType(types.EmbeddingProviderCohere)
+
+// modelDimensions are the sizes of the embeddings Cohere's models generate
+var modelDimensions = map[string]int{
+	"embed-english-v3.0":            1024,
+	"embed-multilingual-v3.0":       1024,
+	"embed-english-light-v3.0":      384,
+	"embed-multilingual-light-v3.0": 384,
+	"embed-english-v2.0":            4096,
+	"embed-english-light-v2.0":      1024,
+	"embed-multilingual-v2.0":       768,
+}
+
+func init() {
+	embedding.Register(types.EmbeddingProviderCohere, newProvider)
+}
+
+// newProvider creates a client from the embedding configuration, using
+// embedding.model when the Cohere section names no model
+func newProvider(config types.EmbeddingConfig, limits embedding.Limits) (embedding.Provider, error) {
+	cohereConfig := config.Cohere
+	if cohereConfig.Model == "" {
+		cohereConfig.Model = config.Model
+	}
+	return New(cohereConfig, limits)
+}
+
+// Client generates embeddings with the Cohere embed API. GetEmbedding and
+// GetEmbeddings embed documents; GetQueryEmbedding embeds search queries.
+type Client struct {
+	url           string
+	model         string
+	documentInput string
+	queryInput    string
+	api           *embedding.APIClient
+}
+
+// embedRequest is the body of a POST /embed request
+type embedRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"`
+	EmbeddingTypes []string `json:"embedding_types"`
+}
+
+// embedResponse is the body of a successful embed response
+type embedResponse struct {
+	Embeddings struct {
+		Float [][]float64 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// errorResponse is the body of a failed API request
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// New creates a Cohere embedding client. BaseURL may point at a proxy and
+// should include the API version (e.g. "https://api.cohere.com/v2").
+// Requests, retries included, are sent within limits, and never embed more
+// than MaxBatchSize texts.
+func New(config types.CohereEmbeddingConfig, limits embedding.Limits) (*Client, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("cohere API key cannot be empty")
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("cohere embedding model cannot be empty")
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("cohere max retries cannot be negative")
+	}
+	if limits.BatchSize < 0 {
+		return nil, fmt.Errorf("cohere batch size cannot be negative")
+	}
+
+	documentInput := config.InputType
+	if documentInput == "" {
+		documentInput = InputTypeSearchDocument
+	}
+	queryInput := documentInput
+	switch documentInput {
+	case InputTypeSearchDocument:
+		queryInput = InputTypeSearchQuery
+	case InputTypeSearchQuery, InputTypeClassification, InputTypeClustering:
+	default:
+		return nil, fmt.Errorf("unsupported cohere input type: %s", documentInput)
+	}
+
+	if limits.BatchSize == 0 || limits.BatchSize > MaxBatchSize {
+		limits.BatchSize = MaxBatchSize
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	api := embedding.NewAPIClient(backend, config.Model, config.RequestTimeout, config.MaxRetries, limits)
+	api.Header.Set("Authorization", "Bearer "+config.APIKey)
+	api.ErrorMessage = errorMessage
+
+	return &Client{
+		url:           baseURL + "/embed",
+		model:         config.Model,
+		documentInput: documentInput,
+		queryInput:    queryInput,
+		api:           api,
+	}, nil
+}
+
+// Model returns the embedding model used by the client
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Dimensions returns the size of the model's embeddings, or 0 for models
+// it doesn't know
+func (c *Client) Dimensions() int {
+	return modelDimensions[c.model]
+}
+
+// GetEmbedding generates an embedding for a single document
+func (c *Client) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.embedTexts(ctx, []string{text}, c.documentInput)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetEmbeddings generates embeddings for several documents, in as few
+// requests as the batch size allows. The result is in the same order as
+// texts.
+func (c *Client) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return c.embedTexts(ctx, texts, c.documentInput)
+}
+
+// GetQueryEmbedding generates an embedding for a search query, which is
+// embedded as search_query when documents are embedded as search_document
+func (c *Client) GetQueryEmbedding(ctx context.Context, query string) ([]float64, error) {
+	embeddings, err := c.embedTexts(ctx, []string{query}, c.queryInput)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// embedTexts embeds texts with inputType, one batch per request
+func (c *Client) embedTexts(ctx context.Context, texts []string, inputType string) ([][]float64, error) {
+	return embedding.EmbedInBatches(ctx, texts, c.api.Limits.BatchSize, func(ctx context.Context, batch []string) ([][]float64, error) {
+		return c.embedBatch(ctx, batch, inputType)
+	})
+}
+
+// embedBatch embeds texts in one request, retrying failures the API
+// reports as temporary
+func (c *Client) embedBatch(ctx context.Context, texts []string, inputType string) ([][]float64, error) {
+	request := embedRequest{
+		Model:          c.model,
+		Texts:          texts,
+		InputType:      inputType,
+		EmbeddingTypes: []string{"float"},
+	}
+	var parsed embedResponse
+	if err := c.api.PostJSON(ctx, c.url, request, &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed.Embeddings.Float) != len(texts) {
+		return nil, c.api.NewError(fmt.Errorf("expected %d embeddings, got %d", len(texts), len(parsed.Embeddings.Float)), false)
+	}
+
+	return parsed.Embeddings.Float, nil
+}
+
+// errorMessage returns the message of an API error body
+func errorMessage(body []byte) string {
+	var apiErr errorResponse
+	if json.Unmarshal(body, &apiErr) != nil {
+		return ""
+	}
+	return apiErr.Message
+}
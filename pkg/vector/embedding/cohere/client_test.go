@@ -0,0 +1,285 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// noDelay is a backoff that retries immediately
+type noDelay struct{}
+
+func (noDelay) Duration(int) time.Duration { return 0 }
+func (noDelay) Reset()                     {}
+
+func newTestClient(t *testing.T, url string, maxRetries int) *Client {
+	t.Helper()
+
+	client, err := New(types.CohereEmbeddingConfig{
+		APIKey:         "test-key",
+		Model:          "embed-english-v3.0",
+		BaseURL:        url + "/v2/",
+		RequestTimeout: 5,
+		MaxRetries:     maxRetries,
+	}, embedding.Limits{})
+	require.NoError(t, err)
+	client.api.Retry.Backoff = noDelay{}
+
+	return client
+}
+
+// recordingServer answers embed requests with one embedding per text, the
+// text's length, and records each request
+type recordingServer struct {
+	mu       sync.Mutex
+	requests []embedRequest
+}
+
+func (s *recordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req embedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	var resp embedResponse
+	for _, text := range req.Texts {
+		resp.Embeddings.Float = append(resp.Embeddings.Float, []float64{float64(len(text))})
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *recordingServer) inputTypes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inputTypes := make([]string, len(s.requests))
+	for i, req := range s.requests {
+		inputTypes[i] = req.InputType
+	}
+	return inputTypes
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(types.CohereEmbeddingConfig{Model: "m"}, embedding.Limits{})
+	assert.Error(t, err)
+
+	_, err = New(types.CohereEmbeddingConfig{APIKey: "k"}, embedding.Limits{})
+	assert.Error(t, err)
+
+	_, err = New(types.CohereEmbeddingConfig{APIKey: "k", Model: "m"}, embedding.Limits{BatchSize: -1})
+	assert.Error(t, err)
+
+	_, err = New(types.CohereEmbeddingConfig{APIKey: "k", Model: "m", InputType: "image"}, embedding.Limits{})
+	assert.ErrorContains(t, err, "unsupported cohere input type")
+
+	client, err := New(types.CohereEmbeddingConfig{APIKey: "k", Model: "m", MaxRetries: 2}, embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBaseURL+"/embed", client.url)
+	assert.Equal(t, 30*time.Second, client.api.HTTPClient.Timeout)
+	assert.Equal(t, 3, client.api.Retry.MaxAttempts)
+	assert.Equal(t, MaxBatchSize, client.api.Limits.BatchSize)
+
+	// Smaller batches are kept, larger ones lowered to what the API accepts
+	client, err = New(types.CohereEmbeddingConfig{APIKey: "k", Model: "m"}, embedding.Limits{BatchSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 10, client.api.Limits.BatchSize)
+	client, err = New(types.CohereEmbeddingConfig{APIKey: "k", Model: "m"}, embedding.Limits{BatchSize: 500})
+	require.NoError(t, err)
+	assert.Equal(t, MaxBatchSize, client.api.Limits.BatchSize)
+}
+
+func TestClient_GetEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v2/embed", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var req embedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"first", "second"}, req.Texts)
+		assert.Equal(t, "embed-english-v3.0", req.Model)
+		assert.Equal(t, []string{"float"}, req.EmbeddingTypes)
+
+		_, _ = w.Write([]byte(`{"id":"1","embeddings":{"float":[[0.1,0.2],[0.3,0.4]]},"texts":["first","second"]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, 0)
+
+	embeddings, err := client.GetEmbeddings(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+
+	empty, err := client.GetEmbeddings(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestClient_InputType(t *testing.T) {
+	server := &recordingServer{}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client := newTestClient(t, httpServer.URL, 0)
+	ctx := context.Background()
+
+	// Indexing embeds documents
+	_, err := client.GetEmbeddings(ctx, []string{"a note", "another note"})
+	require.NoError(t, err)
+	_, err = client.GetEmbedding(ctx, "a third note")
+	require.NoError(t, err)
+
+	// Searching embeds a query, directly or through the provider interface
+	_, err = client.GetQueryEmbedding(ctx, "notes about go")
+	require.NoError(t, err)
+	_, err = embedding.EmbedQuery(ctx, client, "notes about go")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		InputTypeSearchDocument,
+		InputTypeSearchDocument,
+		InputTypeSearchQuery,
+		InputTypeSearchQuery,
+	}, server.inputTypes())
+}
+
+func TestClient_InputTypeConfigured(t *testing.T) {
+	server := &recordingServer{}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// Input types other than search_document apply to queries as well
+	client, err := New(types.CohereEmbeddingConfig{
+		APIKey:    "k",
+		Model:     "m",
+		BaseURL:   httpServer.URL,
+		InputType: InputTypeClustering,
+	}, embedding.Limits{})
+	require.NoError(t, err)
+
+	_, err = client.GetEmbedding(context.Background(), "a note")
+	require.NoError(t, err)
+	_, err = client.GetQueryEmbedding(context.Background(), "a query")
+	require.NoError(t, err)
+	assert.Equal(t, []string{InputTypeClustering, InputTypeClustering}, server.inputTypes())
+}
+
+func TestClient_Batches(t *testing.T) {
+	server := &recordingServer{}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client := newTestClient(t, httpServer.URL, 0)
+
+	texts := make([]string, MaxBatchSize*2+1)
+	want := make([][]float64, len(texts))
+	for i := range texts {
+		texts[i] = string(make([]byte, i))
+		want[i] = []float64{float64(i)}
+	}
+
+	embeddings, err := client.GetEmbeddings(context.Background(), texts)
+	require.NoError(t, err)
+	assert.Equal(t, want, embeddings)
+
+	require.Len(t, server.requests, 3)
+	assert.Len(t, server.requests[0].Texts, MaxBatchSize)
+	assert.Len(t, server.requests[1].Texts, MaxBatchSize)
+	assert.Len(t, server.requests[2].Texts, 1)
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		maxRetries   int
+		wantRequests int32
+		retryable    bool
+	}{
+		{"rate limited", http.StatusTooManyRequests, 2, 3, true},
+		{"server error", http.StatusServiceUnavailable, 1, 2, true},
+		{"bad request", http.StatusBadRequest, 2, 1, false},
+		{"unauthorized", http.StatusUnauthorized, 2, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"message":"something went wrong"}`))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(t, server.URL, tt.maxRetries).GetEmbedding(context.Background(), "text")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "something went wrong")
+			assert.Equal(t, tt.wantRequests, requests.Load())
+
+			var vectorErr *types.VectorSearchError
+			require.True(t, errors.As(err, &vectorErr))
+			assert.Equal(t, tt.retryable, vectorErr.IsRetryable())
+			assert.Equal(t, "embed", vectorErr.Operation)
+		})
+	}
+}
+
+func TestClient_RecoversAfterRetry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"embeddings":{"float":[[0.5]]}}`))
+	}))
+	defer server.Close()
+
+	embedding, err := newTestClient(t, server.URL, 3).GetQueryEmbedding(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.5}, embedding)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestClient_InvalidResponses(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed json", `{"embeddings":`},
+		{"too few embeddings", `{"embeddings":{"float":[]}}`},
+		{"no float embeddings", `{"embeddings":{"int8":[[1]]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(t, server.URL, 2).GetEmbedding(context.Background(), "a")
+			require.Error(t, err)
+			assert.Equal(t, int32(1), requests.Load(), "invalid responses aren't retried")
+		})
+	}
+}
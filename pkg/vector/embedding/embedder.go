@@ -0,0 +1,82 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// errNoProvider is returned when a backend has to embed text but was
+// created without a provider
+var errNoProvider = errors.New("no embedding provider configured")
+
+// Embedder gives a vector backend its embedding methods. Backends embed it
+// so that GetEmbedding and GetEmbeddings satisfy VectorSearchBackend, and
+// errors are reported as VectorSearchErrors from Backend. Provider may be
+// nil when callers always supply their own vectors.
+type Embedder struct {
+	Backend  types.VectorSearchType
+	Provider Provider
+}
+
+// GetEmbedding generates an embedding with the configured provider
+func (e Embedder) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	if e.Provider == nil {
+		return nil, e.newError(text, errNoProvider)
+	}
+	return e.Provider.GetEmbedding(ctx, text)
+}
+
+// GetEmbeddings generates embeddings with the configured provider
+func (e Embedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if e.Provider == nil {
+		return nil, e.newError("", errNoProvider)
+	}
+	return e.Provider.GetEmbeddings(ctx, texts)
+}
+
+// EmbedQuery generates an embedding for a search query with the configured
+// provider
+func (e Embedder) EmbedQuery(ctx context.Context, query string) ([]float64, error) {
+	if e.Provider == nil {
+		return nil, e.newError(query, errNoProvider)
+	}
+	return EmbedQuery(ctx, e.Provider, query)
+}
+
+// DocumentVectors returns the embedding of each document, generating the
+// missing ones in a single batch
+func (e Embedder) DocumentVectors(ctx context.Context, docs []*types.Document) ([][]float64, error) {
+	vectors := make([][]float64, len(docs))
+	var missing []int
+	var texts []string
+	for i, doc := range docs {
+		if len(doc.Embedding) > 0 {
+			vectors[i] = doc.Embedding
+			continue
+		}
+		missing = append(missing, i)
+		texts = append(texts, doc.Content)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embeddings, err := e.GetEmbeddings(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(missing) {
+		return nil, types.NewVectorSearchError(e.Backend, "index", "", fmt.Errorf("expected %d embeddings, got %d", len(missing), len(embeddings)), false)
+	}
+	for j, i := range missing {
+		vectors[i] = embeddings[j]
+	}
+	return vectors, nil
+}
+
+func (e Embedder) newError(query string, err error) error {
+	return types.NewVectorSearchError(e.Backend, "embed", query, err, false)
+}
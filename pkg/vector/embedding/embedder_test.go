@@ -0,0 +1,68 @@
+package embedding_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// lengthProvider embeds each text as its length and counts batches
+type lengthProvider struct {
+	batches int
+}
+
+func (p *lengthProvider) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return []float64{float64(len(text))}, nil
+}
+
+func (p *lengthProvider) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	p.batches++
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(len(text))}
+	}
+	return vectors, nil
+}
+
+func (p *lengthProvider) Dimensions() int { return 1 }
+
+func TestEmbedder_DocumentVectors(t *testing.T) {
+	provider := &lengthProvider{}
+	e := embedding.Embedder{Backend: types.VectorSearchTypeLocal, Provider: provider}
+
+	vectors, err := e.DocumentVectors(context.Background(), []*types.Document{
+		{ID: "a", Content: "abc"},
+		{ID: "b", Content: "ignored", Embedding: []float64{9}},
+		{ID: "c", Content: "abcde"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{3}, {9}, {5}}, vectors)
+	assert.Equal(t, 1, provider.batches, "missing embeddings are generated in one batch")
+
+	_, err = e.DocumentVectors(context.Background(), []*types.Document{{ID: "d", Embedding: []float64{1}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.batches, "documents with embeddings need no batch")
+}
+
+func TestEmbedder_NoProvider(t *testing.T) {
+	e := embedding.Embedder{Backend: types.VectorSearchTypeLocal}
+	ctx := context.Background()
+
+	_, err := e.GetEmbedding(ctx, "text")
+	assert.Error(t, err)
+	_, err = e.EmbedQuery(ctx, "query")
+	assert.Error(t, err)
+	_, err = e.DocumentVectors(ctx, []*types.Document{{ID: "a", Content: "text"}})
+	assert.Error(t, err)
+
+	var vsErr *types.VectorSearchError
+	_, err = e.GetEmbeddings(ctx, []string{"text"})
+	require.ErrorAs(t, err, &vsErr)
+	assert.Equal(t, types.VectorSearchTypeLocal, vsErr.Backend)
+	assert.Equal(t, "embed", vsErr.Operation)
+}
@@ -0,0 +1,42 @@
+// Package embedding defines the interface embedding providers implement
+// and creates providers from configuration. Providers live in subpackages,
+// such as embedding/openai, which register themselves with NewProvider
+// when imported.
+package embedding
+
+import "context"
+
+// Provider generates embeddings for text
+type Provider interface {
+	// GetEmbedding generates an embedding for a single text
+	GetEmbedding(ctx context.Context, text string) ([]float64, error)
+
+	// GetEmbeddings generates embeddings for several texts, in the same
+	// order as texts
+	GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error)
+
+	// Dimensions returns the size of the embeddings the provider
+	// generates, or 0 when it isn't known before embedding, as with
+	// models the provider doesn't recognize
+	Dimensions() int
+}
+
+// QueryProvider is implemented by providers that embed search queries
+// differently from the documents they are matched against, such as
+// Cohere's search_query and search_document input types. Their
+// GetEmbedding and GetEmbeddings embed documents.
+type QueryProvider interface {
+	Provider
+
+	// GetQueryEmbedding generates an embedding for a search query
+	GetQueryEmbedding(ctx context.Context, query string) ([]float64, error)
+}
+
+// EmbedQuery generates an embedding for a search query with provider,
+// as a query when the provider tells queries and documents apart
+func EmbedQuery(ctx context.Context, provider Provider, query string) ([]float64, error) {
+	if qp, ok := provider.(QueryProvider); ok {
+		return qp.GetQueryEmbedding(ctx, query)
+	}
+	return provider.GetEmbedding(ctx, query)
+}
@@ -0,0 +1,252 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/retry"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// maxErrorBody limits how much of an error response is read
+const maxErrorBody = 64 * 1024
+
+// APIClient sends embedding requests to an HTTP API, within limits and
+// retrying failures the API reports as temporary. Provider clients
+// configure one per API and build their request and response bodies.
+type APIClient struct {
+	// Backend and Model identify the provider in VectorSearchError values
+	Backend types.VectorSearchType
+	Model   string
+
+	HTTPClient *http.Client
+	Retry      *retry.Config
+	Limits     Limits
+
+	// Header is sent with every request, usually for authentication
+	Header http.Header
+
+	// ErrorMessage extracts the API's message from the body of a failed
+	// request, returning "" when there is none. The body is used as is
+	// when ErrorMessage is nil.
+	ErrorMessage func(body []byte) string
+
+	// RetryAfter returns how long a failed response asks to wait before
+	// retrying. The Retry-After header is used when it is nil.
+	RetryAfter func(header http.Header) time.Duration
+}
+
+// NewAPIClient creates an API client whose requests time out after
+// timeoutSeconds, 30 when zero or less, and are retried up to maxRetries
+// times
+func NewAPIClient(backend types.VectorSearchType, model string, timeoutSeconds, maxRetries int, limits Limits) *APIClient {
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &APIClient{
+		Backend:    backend,
+		Model:      model,
+		HTTPClient: &http.Client{Timeout: timeout},
+		Retry: &retry.Config{
+			MaxAttempts: maxRetries + 1,
+			Backoff:     retry.NewExponentialBackoff(500*time.Millisecond, 10*time.Second),
+			ShouldRetry: retry.VectorSearchErrorShouldRetry,
+		},
+		Limits: limits,
+		Header: make(http.Header),
+	}
+}
+
+// PostJSON posts request as JSON to url and decodes a 200 response into
+// response, waiting for the rate limit before every attempt
+func (a *APIClient) PostJSON(ctx context.Context, url string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return a.NewError(fmt.Errorf("failed to encode request: %w", err), false)
+	}
+
+	return retry.Retry(ctx, a.Retry, func() error {
+		return a.post(ctx, url, body, response)
+	})
+}
+
+// post performs a single request once the rate limit allows
+func (a *APIClient) post(ctx context.Context, url string, body []byte, response any) error {
+	if err := a.Limits.Limiter.Wait(ctx); err != nil {
+		return a.NewError(err, false)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return a.NewError(fmt.Errorf("failed to create request: %w", err), false)
+	}
+	for key, values := range a.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		// Network failures and timeouts are worth retrying unless the
+		// caller gave up
+		retryable := ctx.Err() == nil
+		return a.NewError(fmt.Errorf("request failed: %w", err), retryable)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		after := retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		if a.RetryAfter != nil {
+			after = a.RetryAfter(resp.Header)
+		}
+		err := retry.WithRetryAfter(a.statusError(resp), after)
+		return a.NewError(err, IsRetryableStatus(resp.StatusCode))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return a.NewError(fmt.Errorf("failed to decode response: %w", err), false)
+	}
+	return nil
+}
+
+// NewError wraps err as a VectorSearchError for the embed operation
+func (a *APIClient) NewError(err error, retryable bool) error {
+	return types.NewVectorSearchError(a.Backend, "embed", a.Model, err, retryable)
+}
+
+// statusError builds an error from a non-200 response, using the API's
+// error message when one is present
+func (a *APIClient) statusError(resp *http.Response) error {
+	data := ReadErrorBody(resp)
+
+	msg := strings.TrimSpace(string(data))
+	if a.ErrorMessage != nil {
+		if apiMsg := a.ErrorMessage(data); apiMsg != "" {
+			msg = apiMsg
+		}
+	}
+	if msg != "" {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, msg)
+	}
+	return errors.New(resp.Status)
+}
+
+// JSONClient sends requests with JSON bodies to the HTTP API of a vector
+// database and reports failures as VectorSearchErrors from Backend
+type JSONClient struct {
+	Backend    types.VectorSearchType
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// Header is sent with every request, usually for authentication
+	Header http.Header
+
+	// StatusError builds the error for a response outside the 2xx range,
+	// usually from the API's error message in the body
+	StatusError func(resp *http.Response) error
+}
+
+// NewJSONClient creates a client for the API at baseURL whose requests
+// time out after 30 seconds
+func NewJSONClient(backend types.VectorSearchType, baseURL string, statusError func(*http.Response) error) *JSONClient {
+	return &JSONClient{
+		Backend:     backend,
+		BaseURL:     baseURL,
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+		Header:      make(http.Header),
+		StatusError: statusError,
+	}
+}
+
+// Do sends a request to path below BaseURL, with body encoded as JSON when
+// it is not nil, and decodes the response into out when out is not nil.
+// operation and query describe the request in errors.
+func (c *JSONClient) Do(ctx context.Context, operation, query, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return c.NewError(operation, query, fmt.Errorf("failed to encode request: %w", err), false)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return c.NewError(operation, query, fmt.Errorf("failed to create request: %w", err), false)
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		// Network failures and timeouts are worth retrying unless the
+		// caller gave up
+		retryable := ctx.Err() == nil
+		return c.NewError(operation, query, fmt.Errorf("request failed: %w", err), retryable)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return c.NewError(operation, query, c.StatusError(resp), IsRetryableStatus(resp.StatusCode))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return c.NewError(operation, query, fmt.Errorf("failed to decode response: %w", err), false)
+		}
+	}
+	return nil
+}
+
+// NewError wraps err as a VectorSearchError for operation
+func (c *JSONClient) NewError(operation, query string, err error, retryable bool) error {
+	return types.NewVectorSearchError(c.Backend, operation, query, err, retryable)
+}
+
+// EmbedInBatches embeds texts with embed, batchSize texts at a time, or all
+// at once when batchSize is zero. The result is in the same order as texts.
+func EmbedInBatches(ctx context.Context, texts []string, batchSize int, embed func(ctx context.Context, batch []string) ([][]float64, error)) ([][]float64, error) {
+	if batchSize <= 0 {
+		batchSize = len(texts)
+	}
+
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch := texts[start:min(start+batchSize, len(texts))]
+		batchEmbeddings, err := embed(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batchEmbeddings...)
+	}
+	return embeddings, nil
+}
+
+// ReadErrorBody reads the body of a failed API response for its error
+// message, up to a limit so a misbehaving server can't exhaust memory
+func ReadErrorBody(resp *http.Response) []byte {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return data
+}
+
+// IsRetryableStatus reports whether an API response status is worth
+// retrying
+func IsRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
@@ -0,0 +1,160 @@
+// Package huggingface implements an embedding client for Hugging Face
+// feature-extraction endpoints: the serverless Inference API and dedicated
+// Inference Endpoints.
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// DefaultBaseURL is the serverless Inference API root used when no endpoint
+// URL is configured
+const DefaultBaseURL = "https://router.huggingface.co/hf-inference/models"
+
+// backend identifies this provider in VectorSearchError values
+const backend = types.VectorSearchType(types.EmbeddingProviderHugging)
+
+// modelDimensions are the sizes of the embeddings of common sentence
+// embedding models on the Hub
+var modelDimensions = map[string]int{
+	"sentence-transformers/all-MiniLM-L6-v2":                      384,
+	"sentence-transformers/all-MiniLM-L12-v2":                     384,
+	"sentence-transformers/all-mpnet-base-v2":                     768,
+	"sentence-transformers/multi-qa-mpnet-base-dot-v1":            768,
+	"sentence-transformers/paraphrase-multilingual-mpnet-base-v2": 768,
+	"BAAI/bge-small-en-v1.5":                                      384,
+	"BAAI/bge-base-en-v1.5":                                       768,
+	"BAAI/bge-large-en-v1.5":                                      1024,
+}
+
+func init() {
+	embedding.Register(types.EmbeddingProviderHugging, newProvider)
+}
+
+// newProvider creates a client from the embedding configuration, using
+// embedding.model when the Hugging Face section names no model
+func newProvider(config types.EmbeddingConfig, limits embedding.Limits) (embedding.Provider, error) {
+	hfConfig := config.HuggingFace
+	if hfConfig.Model == "" {
+		hfConfig.Model = config.Model
+	}
+	return New(hfConfig, limits)
+}
+
+// Client generates embeddings with a Hugging Face feature-extraction
+// endpoint. The model must pool its output into one vector per text, as
+// sentence-transformers models do.
+type Client struct {
+	url   string
+	model string
+	api   *embedding.APIClient
+}
+
+// embedRequest is the body of a feature-extraction request
+type embedRequest struct {
+	Inputs []string `json:"inputs"`
+}
+
+// errorResponse is the body of a failed API request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates a Hugging Face embedding client. Requests go to the model on
+// the serverless Inference API, or to EndpointURL when it is set, in which
+// case the model only names the embeddings and the API key is optional.
+// Requests, retries included, are sent within limits.
+func New(config types.HuggingFaceEmbeddingConfig, limits embedding.Limits) (*Client, error) {
+	endpoint := strings.TrimRight(config.EndpointURL, "/")
+	if endpoint == "" {
+		if config.Model == "" {
+			return nil, fmt.Errorf("huggingface embedding model cannot be empty")
+		}
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("huggingface API key cannot be empty")
+		}
+		endpoint = fmt.Sprintf("%s/%s/pipeline/feature-extraction", DefaultBaseURL, escapeModel(config.Model))
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("huggingface max retries cannot be negative")
+	}
+	if limits.BatchSize < 0 {
+		return nil, fmt.Errorf("huggingface batch size cannot be negative")
+	}
+
+	api := embedding.NewAPIClient(backend, config.Model, config.RequestTimeout, config.MaxRetries, limits)
+	if config.APIKey != "" {
+		api.Header.Set("Authorization", "Bearer "+config.APIKey)
+	}
+	// Wait for a cold model to load instead of failing with 503
+	api.Header.Set("X-Wait-For-Model", "true")
+	api.ErrorMessage = errorMessage
+
+	return &Client{url: endpoint, model: config.Model, api: api}, nil
+}
+
+// Model returns the embedding model used by the client
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Dimensions returns the size of the model's embeddings, or 0 for models
+// it doesn't know
+func (c *Client) Dimensions() int {
+	return modelDimensions[c.model]
+}
+
+// GetEmbedding generates an embedding for a single text
+func (c *Client) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetEmbeddings generates embeddings for several texts, in a single request
+// unless there are more texts than the batch size. The result is in the
+// same order as texts.
+func (c *Client) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return embedding.EmbedInBatches(ctx, texts, c.api.Limits.BatchSize, c.embedBatch)
+}
+
+// embedBatch embeds texts in one request, retrying failures the API
+// reports as temporary
+func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	var embeddings [][]float64
+	if err := c.api.PostJSON(ctx, c.url, embedRequest{Inputs: texts}, &embeddings); err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(texts) {
+		return nil, c.api.NewError(fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embeddings)), false)
+	}
+	return embeddings, nil
+}
+
+// errorMessage returns the message of an API error body
+func errorMessage(body []byte) string {
+	var apiErr errorResponse
+	if json.Unmarshal(body, &apiErr) != nil {
+		return ""
+	}
+	return apiErr.Error
+}
+
+// escapeModel escapes each part of a model ID such as "BAAI/bge-small-en"
+// for use in a URL path, keeping the slash between owner and name
+func escapeModel(model string) string {
+	parts := strings.Split(model, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,136 @@
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// noDelay is a backoff that retries immediately
+type noDelay struct{}
+
+func (noDelay) Duration(int) time.Duration { return 0 }
+func (noDelay) Reset()                     {}
+
+func newTestClient(t *testing.T, url string, maxRetries int) *Client {
+	t.Helper()
+
+	client, err := New(types.HuggingFaceEmbeddingConfig{
+		APIKey:         "test-key",
+		Model:          "sentence-transformers/all-MiniLM-L6-v2",
+		EndpointURL:    url + "/embed/",
+		RequestTimeout: 5,
+		MaxRetries:     maxRetries,
+	}, embedding.Limits{})
+	require.NoError(t, err)
+	client.api.Retry.Backoff = noDelay{}
+
+	return client
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(types.HuggingFaceEmbeddingConfig{APIKey: "k"}, embedding.Limits{})
+	assert.ErrorContains(t, err, "model cannot be empty")
+
+	_, err = New(types.HuggingFaceEmbeddingConfig{Model: "m"}, embedding.Limits{})
+	assert.ErrorContains(t, err, "API key cannot be empty")
+
+	_, err = New(types.HuggingFaceEmbeddingConfig{APIKey: "k", Model: "m"}, embedding.Limits{BatchSize: -1})
+	assert.Error(t, err)
+
+	client, err := New(types.HuggingFaceEmbeddingConfig{APIKey: "k", Model: "BAAI/bge-base-en-v1.5", MaxRetries: 2}, embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBaseURL+"/BAAI/bge-base-en-v1.5/pipeline/feature-extraction", client.url)
+	assert.Equal(t, 30*time.Second, client.api.HTTPClient.Timeout)
+	assert.Equal(t, 3, client.api.Retry.MaxAttempts)
+	assert.Equal(t, 768, client.Dimensions())
+
+	// A dedicated endpoint needs neither a model nor a key
+	client, err = New(types.HuggingFaceEmbeddingConfig{EndpointURL: "https://endpoint.example/"}, embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://endpoint.example", client.url)
+	assert.Empty(t, client.api.Header.Get("Authorization"))
+	assert.Equal(t, 0, client.Dimensions())
+}
+
+func TestClient_GetEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/embed", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "true", r.Header.Get("X-Wait-For-Model"))
+
+		var req embedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"first", "second"}, req.Inputs)
+
+		_, _ = w.Write([]byte(`[[0.1,0.2],[0.3,0.4]]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, 0)
+
+	embeddings, err := client.GetEmbeddings(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+}
+
+func TestClient_TokenEmbeddings(t *testing.T) {
+	// Models without pooling return one vector per token
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[[[0.1,0.2],[0.3,0.4]]]`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server.URL, 2).GetEmbedding(context.Background(), "text")
+	require.Error(t, err)
+
+	var vectorErr *types.VectorSearchError
+	require.True(t, errors.As(err, &vectorErr))
+	assert.False(t, vectorErr.IsRetryable())
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantRequests int32
+		retryable    bool
+	}{
+		{"model loading", http.StatusServiceUnavailable, 3, true},
+		{"bad request", http.StatusBadRequest, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"error":"something went wrong"}`))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(t, server.URL, 2).GetEmbedding(context.Background(), "text")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "something went wrong")
+			assert.Equal(t, tt.wantRequests, requests.Load())
+
+			var vectorErr *types.VectorSearchError
+			require.True(t, errors.As(err, &vectorErr))
+			assert.Equal(t, types.VectorSearchType(types.EmbeddingProviderHugging), vectorErr.Backend)
+			assert.Equal(t, tt.retryable, vectorErr.IsRetryable())
+		})
+	}
+}
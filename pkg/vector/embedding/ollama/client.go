@@ -0,0 +1,141 @@
+// Package ollama implements an embedding client for models served by a
+// local or remote Ollama server.
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// DefaultBaseURL is the address Ollama listens on by default
+const DefaultBaseURL = "http://localhost:11434"
+
+// backend identifies this provider in VectorSearchError values
+const backend = types.VectorSearchType(types.EmbeddingProviderOllama)
+
+// modelDimensions are the sizes of the embeddings of Ollama's embedding
+// models, by name without a tag
+var modelDimensions = map[string]int{
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"all-minilm":             384,
+	"snowflake-arctic-embed": 1024,
+	"bge-m3":                 1024,
+	"bge-large":              1024,
+}
+
+func init() {
+	embedding.Register(types.EmbeddingProviderOllama, newProvider)
+}
+
+// newProvider creates a client from the embedding configuration, using
+// embedding.model when the Ollama section names no model
+func newProvider(config types.EmbeddingConfig, limits embedding.Limits) (embedding.Provider, error) {
+	ollamaConfig := config.Ollama
+	if ollamaConfig.Model == "" {
+		ollamaConfig.Model = config.Model
+	}
+	return New(ollamaConfig, limits)
+}
+
+// Client generates embeddings with the Ollama embed API
+type Client struct {
+	url   string
+	model string
+	api   *embedding.APIClient
+}
+
+// embedRequest is the body of a POST /api/embed request
+type embedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// embedResponse is the body of a successful embed response
+type embedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// errorResponse is the body of a failed API request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates an Ollama embedding client. The model must already be pulled
+// on the server. Requests, retries included, are sent within limits.
+func New(config types.OllamaEmbeddingConfig, limits embedding.Limits) (*Client, error) {
+	if config.Model == "" {
+		return nil, fmt.Errorf("ollama embedding model cannot be empty")
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("ollama max retries cannot be negative")
+	}
+	if limits.BatchSize < 0 {
+		return nil, fmt.Errorf("ollama batch size cannot be negative")
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	api := embedding.NewAPIClient(backend, config.Model, config.RequestTimeout, config.MaxRetries, limits)
+	api.ErrorMessage = errorMessage
+
+	return &Client{url: baseURL + "/api/embed", model: config.Model, api: api}, nil
+}
+
+// Model returns the embedding model used by the client
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Dimensions returns the size of the model's embeddings, or 0 for models
+// it doesn't know
+func (c *Client) Dimensions() int {
+	name, _, _ := strings.Cut(c.model, ":")
+	return modelDimensions[name]
+}
+
+// GetEmbedding generates an embedding for a single text
+func (c *Client) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetEmbeddings generates embeddings for several texts, in a single request
+// unless there are more texts than the batch size. The result is in the
+// same order as texts.
+func (c *Client) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return embedding.EmbedInBatches(ctx, texts, c.api.Limits.BatchSize, c.embedBatch)
+}
+
+// embedBatch embeds texts in one request, retrying failures the server
+// reports as temporary
+func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	var parsed embedResponse
+	if err := c.api.PostJSON(ctx, c.url, embedRequest{Model: c.model, Input: texts}, &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed.Embeddings) != len(texts) {
+		return nil, c.api.NewError(fmt.Errorf("expected %d embeddings, got %d", len(texts), len(parsed.Embeddings)), false)
+	}
+	return parsed.Embeddings, nil
+}
+
+// errorMessage returns the message of an API error body
+func errorMessage(body []byte) string {
+	var apiErr errorResponse
+	if json.Unmarshal(body, &apiErr) != nil {
+		return ""
+	}
+	return apiErr.Error
+}
@@ -0,0 +1,90 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(types.OllamaEmbeddingConfig{}, embedding.Limits{})
+	assert.ErrorContains(t, err, "model cannot be empty")
+
+	_, err = New(types.OllamaEmbeddingConfig{Model: "m", MaxRetries: -1}, embedding.Limits{})
+	assert.Error(t, err)
+
+	client, err := New(types.OllamaEmbeddingConfig{Model: "mxbai-embed-large"}, embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBaseURL+"/api/embed", client.url)
+	assert.Equal(t, 30*time.Second, client.api.HTTPClient.Timeout)
+	assert.Equal(t, 1024, client.Dimensions())
+
+	// Tags don't change the embedding size
+	client, err = New(types.OllamaEmbeddingConfig{Model: "all-minilm:l6-v2"}, embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, 384, client.Dimensions())
+}
+
+func TestClient_GetEmbeddings(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embed", r.URL.Path)
+
+		var req embedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "nomic-embed-text", req.Model)
+
+		mu.Lock()
+		batches = append(batches, req.Input)
+		mu.Unlock()
+
+		var resp embedResponse
+		for _, text := range req.Input {
+			resp.Embeddings = append(resp.Embeddings, []float64{float64(len(text))})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := New(types.OllamaEmbeddingConfig{BaseURL: server.URL + "/", Model: "nomic-embed-text"}, embedding.Limits{BatchSize: 2})
+	require.NoError(t, err)
+
+	embeddings, err := client.GetEmbeddings(context.Background(), []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1}, {2}, {3}}, embeddings)
+	assert.Equal(t, [][]string{{"a", "bb"}, {"ccc"}}, batches)
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model \"nomic-embed-text\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(types.OllamaEmbeddingConfig{BaseURL: server.URL, Model: "nomic-embed-text", MaxRetries: 2}, embedding.Limits{})
+	require.NoError(t, err)
+
+	_, err = client.GetEmbedding(context.Background(), "text")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "try pulling it first")
+
+	var vectorErr *types.VectorSearchError
+	require.True(t, errors.As(err, &vectorErr))
+	assert.Equal(t, types.VectorSearchType(types.EmbeddingProviderOllama), vectorErr.Backend)
+	assert.False(t, vectorErr.IsRetryable())
+}
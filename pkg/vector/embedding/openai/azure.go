@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is
+// configured
+const DefaultAzureAPIVersion = "2024-02-01"
+
+// azureBackend identifies Azure OpenAI in VectorSearchError values
+const azureBackend = types.VectorSearchType(types.EmbeddingProviderAzure)
+
+func init() {
+	embedding.Register(types.EmbeddingProviderAzure, newAzureProvider)
+}
+
+// newAzureProvider creates an Azure OpenAI client from the embedding
+// configuration
+func newAzureProvider(config types.EmbeddingConfig, limits embedding.Limits) (embedding.Provider, error) {
+	return NewAzure(config.Azure, config.Model, limits)
+}
+
+// NewAzure creates a client for an Azure OpenAI embedding deployment. The
+// deployment decides the model; model names it so that Dimensions knows
+// the embedding size, and may be empty when the deployment is named after
+// its model. Requests, retries included, are sent within limits.
+func NewAzure(config types.AzureEmbeddingConfig, model string, limits embedding.Limits) (*Client, error) {
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("azure endpoint cannot be empty")
+	}
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("azure API key cannot be empty")
+	}
+	if config.DeploymentName == "" {
+		return nil, fmt.Errorf("azure deployment name cannot be empty")
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("azure max retries cannot be negative")
+	}
+	if limits.BatchSize < 0 {
+		return nil, fmt.Errorf("azure batch size cannot be negative")
+	}
+
+	apiVersion := config.APIVersion
+	if apiVersion == "" {
+		apiVersion = DefaultAzureAPIVersion
+	}
+	if model == "" {
+		model = config.DeploymentName
+	}
+
+	endpoint := fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s",
+		strings.TrimRight(config.Endpoint, "/"), url.PathEscape(config.DeploymentName), url.QueryEscape(apiVersion))
+
+	api := newAPIClient(azureBackend, model, config.RequestTimeout, config.MaxRetries, limits)
+	api.Header.Set("api-key", config.APIKey)
+
+	return &Client{url: endpoint, model: model, api: api}, nil
+}
@@ -0,0 +1,83 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+func TestNewAzure(t *testing.T) {
+	valid := types.AzureEmbeddingConfig{Endpoint: "https://example.openai.azure.com/", APIKey: "k", DeploymentName: "embed"}
+
+	for _, clear := range []func(*types.AzureEmbeddingConfig){
+		func(c *types.AzureEmbeddingConfig) { c.Endpoint = "" },
+		func(c *types.AzureEmbeddingConfig) { c.APIKey = "" },
+		func(c *types.AzureEmbeddingConfig) { c.DeploymentName = "" },
+	} {
+		config := valid
+		clear(&config)
+		_, err := NewAzure(config, "", embedding.Limits{})
+		assert.Error(t, err)
+	}
+
+	client, err := NewAzure(valid, "", embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.openai.azure.com/openai/deployments/embed/embeddings?api-version="+DefaultAzureAPIVersion, client.url)
+	assert.Equal(t, "embed", client.Model(), "the deployment names the model when none is given")
+	assert.Equal(t, 0, client.Dimensions())
+
+	client, err = NewAzure(valid, "text-embedding-3-small", embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, 1536, client.Dimensions())
+}
+
+func TestAzureClient_GetEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/embed/embeddings", r.URL.Path)
+		assert.Equal(t, "2023-05-15", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "azure-key", r.Header.Get("api-key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[0.5,0.25]}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAzure(types.AzureEmbeddingConfig{
+		Endpoint:       server.URL,
+		APIKey:         "azure-key",
+		DeploymentName: "embed",
+		APIVersion:     "2023-05-15",
+	}, "text-embedding-3-small", embedding.Limits{})
+	require.NoError(t, err)
+
+	got, err := client.GetEmbedding(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 0.25}, got)
+
+}
+
+func TestAzureClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"code":"401","message":"Access denied due to invalid subscription key"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAzure(types.AzureEmbeddingConfig{Endpoint: server.URL, APIKey: "k", DeploymentName: "embed"}, "", embedding.Limits{})
+	require.NoError(t, err)
+
+	_, err = client.GetEmbedding(context.Background(), "text")
+	assert.ErrorContains(t, err, "invalid subscription key")
+
+	var vectorErr *types.VectorSearchError
+	require.ErrorAs(t, err, &vectorErr)
+	assert.Equal(t, types.VectorSearchType(types.EmbeddingProviderAzure), vectorErr.Backend)
+	assert.False(t, vectorErr.IsRetryable())
+}
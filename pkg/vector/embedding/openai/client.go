@@ -0,0 +1,196 @@
+// Package openai implements an embedding client for the OpenAI embeddings
+// API and compatible endpoints.
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/retry"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// DefaultBaseURL is the OpenAI API root used when no base URL is configured
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// backend identifies this provider in VectorSearchError values
+const backend = types.VectorSearchType(types.EmbeddingProviderOpenAI)
+
+// modelDimensions are the sizes of the embeddings OpenAI's models generate
+var modelDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+func init() {
+	embedding.Register(types.EmbeddingProviderOpenAI, newProvider)
+}
+
+// newProvider creates a client from the embedding configuration, using
+// embedding.model when the OpenAI section names no model
+func newProvider(config types.EmbeddingConfig, limits embedding.Limits) (embedding.Provider, error) {
+	openaiConfig := config.OpenAI
+	if openaiConfig.Model == "" {
+		openaiConfig.Model = config.Model
+	}
+	return New(openaiConfig, limits)
+}
+
+// Client generates embeddings with the OpenAI embeddings API or an Azure
+// OpenAI deployment, which accepts the same requests
+type Client struct {
+	url   string
+	model string
+	api   *embedding.APIClient
+}
+
+// embeddingRequest is the body of a POST /embeddings request
+type embeddingRequest struct {
+	Input          []string `json:"input"`
+	Model          string   `json:"model"`
+	EncodingFormat string   `json:"encoding_format"`
+}
+
+// embeddingResponse is the body of a successful embeddings response
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Model string `json:"model"`
+}
+
+// errorResponse is the body of a failed API request
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+// New creates an OpenAI embedding client. BaseURL may point at a proxy or an
+// OpenAI-compatible service and should include the API version (e.g.
+// "https://api.openai.com/v1"). Requests, retries included, are sent within
+// limits.
+func New(config types.OpenAIEmbeddingConfig, limits embedding.Limits) (*Client, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("openai API key cannot be empty")
+	}
+	if config.Model == "" {
+		return nil, fmt.Errorf("openai embedding model cannot be empty")
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("openai max retries cannot be negative")
+	}
+	if limits.BatchSize < 0 {
+		return nil, fmt.Errorf("openai batch size cannot be negative")
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	api := newAPIClient(backend, config.Model, config.RequestTimeout, config.MaxRetries, limits)
+	api.Header.Set("Authorization", "Bearer "+config.APIKey)
+	if config.Organization != "" {
+		api.Header.Set("OpenAI-Organization", config.Organization)
+	}
+
+	return &Client{url: baseURL + "/embeddings", model: config.Model, api: api}, nil
+}
+
+// newAPIClient creates an API client that understands OpenAI's error
+// bodies and retry headers
+func newAPIClient(backend types.VectorSearchType, model string, timeout, maxRetries int, limits embedding.Limits) *embedding.APIClient {
+	api := embedding.NewAPIClient(backend, model, timeout, maxRetries, limits)
+	api.ErrorMessage = errorMessage
+	api.RetryAfter = retryAfter
+	return api
+}
+
+// Model returns the embedding model used by the client
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Dimensions returns the size of the model's embeddings, or 0 for models
+// it doesn't know, such as those of compatible services
+func (c *Client) Dimensions() int {
+	return modelDimensions[c.model]
+}
+
+// GetEmbedding generates an embedding for a single text
+func (c *Client) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetEmbeddings generates embeddings for several texts, in a single request
+// unless there are more texts than the batch size. The result is in the
+// same order as texts.
+func (c *Client) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return embedding.EmbedInBatches(ctx, texts, c.api.Limits.BatchSize, c.embedBatch)
+}
+
+// embedBatch embeds texts in one request, retrying failures the API
+// reports as temporary
+func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	request := embeddingRequest{
+		Input:          texts,
+		Model:          c.model,
+		EncodingFormat: "float",
+	}
+	var parsed embeddingResponse
+	if err := c.api.PostJSON(ctx, c.url, request, &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed.Data) != len(texts) {
+		return nil, c.api.NewError(fmt.Errorf("expected %d embeddings, got %d", len(texts), len(parsed.Data)), false)
+	}
+
+	// The API reports each embedding's input index; don't rely on ordering
+	sort.Slice(parsed.Data, func(i, j int) bool {
+		return parsed.Data[i].Index < parsed.Data[j].Index
+	})
+
+	embeddings := make([][]float64, len(texts))
+	for i, item := range parsed.Data {
+		if item.Index != i {
+			return nil, c.api.NewError(fmt.Errorf("missing embedding for input %d", i), false)
+		}
+		embeddings[i] = item.Embedding
+	}
+
+	return embeddings, nil
+}
+
+// errorMessage returns the message of an API error body
+func errorMessage(body []byte) string {
+	var apiErr errorResponse
+	if json.Unmarshal(body, &apiErr) != nil {
+		return ""
+	}
+	return apiErr.Error.Message
+}
+
+// retryAfter returns how long a response asks to wait before retrying,
+// preferring the millisecond precision of OpenAI's retry-after-ms header
+func retryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.Atoi(header.Get("retry-after-ms")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return retry.ParseRetryAfter(header.Get("Retry-After"))
+}
@@ -0,0 +1,289 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/retry"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// noDelay is a backoff that retries immediately
+type noDelay struct{}
+
+func (noDelay) Duration(int) time.Duration { return 0 }
+func (noDelay) Reset()                     {}
+
+func newTestClient(t *testing.T, url string, maxRetries int) *Client {
+	t.Helper()
+
+	client, err := New(types.OpenAIEmbeddingConfig{
+		APIKey:         "test-key",
+		Organization:   "org-123",
+		BaseURL:        url + "/v1/",
+		Model:          "text-embedding-3-small",
+		RequestTimeout: 5,
+		MaxRetries:     maxRetries,
+	}, embedding.Limits{})
+	require.NoError(t, err)
+	client.api.Retry.Backoff = noDelay{}
+
+	return client
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(types.OpenAIEmbeddingConfig{Model: "m"}, embedding.Limits{})
+	assert.Error(t, err)
+
+	_, err = New(types.OpenAIEmbeddingConfig{APIKey: "k"}, embedding.Limits{})
+	assert.Error(t, err)
+
+	_, err = New(types.OpenAIEmbeddingConfig{APIKey: "k", Model: "m"}, embedding.Limits{BatchSize: -1})
+	assert.Error(t, err)
+
+	client, err := New(types.OpenAIEmbeddingConfig{APIKey: "k", Model: "m", MaxRetries: 2}, embedding.Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBaseURL+"/embeddings", client.url)
+	assert.Equal(t, 30*time.Second, client.api.HTTPClient.Timeout)
+	assert.Equal(t, 3, client.api.Retry.MaxAttempts)
+}
+
+func TestClient_GetEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "org-123", r.Header.Get("OpenAI-Organization"))
+
+		var req embeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"first", "second"}, req.Input)
+		assert.Equal(t, "text-embedding-3-small", req.Model)
+
+		// Return the embeddings out of order
+		_, _ = w.Write([]byte(`{"data":[
+			{"index":1,"embedding":[0.3,0.4]},
+			{"index":0,"embedding":[0.1,0.2]}
+		],"model":"text-embedding-3-small"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, 0)
+
+	embeddings, err := client.GetEmbeddings(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+
+	empty, err := client.GetEmbeddings(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestClient_GetEmbedding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[1,2,3]}]}`))
+	}))
+	defer server.Close()
+
+	embedding, err := newTestClient(t, server.URL, 0).GetEmbedding(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, embedding)
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		maxRetries   int
+		wantRequests int32
+		retryable    bool
+	}{
+		{"rate limited", http.StatusTooManyRequests, 2, 3, true},
+		{"server error", http.StatusBadGateway, 1, 2, true},
+		{"bad request", http.StatusBadRequest, 2, 1, false},
+		{"unauthorized", http.StatusUnauthorized, 2, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"error":{"message":"something went wrong","type":"test"}}`))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(t, server.URL, tt.maxRetries).GetEmbedding(context.Background(), "text")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "something went wrong")
+			assert.Equal(t, tt.wantRequests, requests.Load())
+
+			var vectorErr *types.VectorSearchError
+			require.True(t, errors.As(err, &vectorErr))
+			assert.Equal(t, tt.retryable, vectorErr.IsRetryable())
+			assert.Equal(t, "embed", vectorErr.Operation)
+		})
+	}
+}
+
+func TestClient_RecoversAfterRetry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[0.5]}]}`))
+	}))
+	defer server.Close()
+
+	embedding, err := newTestClient(t, server.URL, 3).GetEmbedding(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.5}, embedding)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestClient_InvalidResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []string
+		body   string
+	}{
+		{"malformed json", []string{"a"}, `{"data":`},
+		{"too few embeddings", []string{"a"}, `{"data":[]}`},
+		{"duplicate index", []string{"a", "b"}, `{"data":[{"index":0,"embedding":[1]},{"index":0,"embedding":[2]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(t, server.URL, 2).GetEmbeddings(context.Background(), tt.inputs)
+			require.Error(t, err)
+			assert.False(t, retry.VectorSearchErrorShouldRetry(err))
+		})
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, 0)
+	client.api.HTTPClient.Timeout = 50 * time.Millisecond
+
+	_, err := client.GetEmbedding(context.Background(), "text")
+	require.Error(t, err)
+
+	var vectorErr *types.VectorSearchError
+	require.True(t, errors.As(err, &vectorErr))
+	assert.True(t, vectorErr.IsRetryable())
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		wait   time.Duration
+	}{
+		{"milliseconds", "retry-after-ms", "150", 150 * time.Millisecond},
+		{"seconds", "Retry-After", "1", time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				requests atomic.Int32
+				limited  time.Time
+				retried  time.Time
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) == 1 {
+					limited = time.Now()
+					w.Header().Set(tt.header, tt.value)
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write([]byte(`{"error":{"message":"rate limit reached","type":"requests"}}`))
+					return
+				}
+				retried = time.Now()
+				_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[0.5]}]}`))
+			}))
+			defer server.Close()
+
+			// The backoff alone would retry at once
+			got, err := newTestClient(t, server.URL, 1).GetEmbedding(context.Background(), "text")
+			require.NoError(t, err)
+			assert.Equal(t, []float64{0.5}, got)
+			assert.Equal(t, int32(2), requests.Load())
+			assert.GreaterOrEqual(t, retried.Sub(limited), tt.wait)
+		})
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []time.Time
+		batches  [][]string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		mu.Lock()
+		requests = append(requests, time.Now())
+		batches = append(batches, req.Input)
+		rejected := len(requests) == 2
+		mu.Unlock()
+
+		// Retries wait their turn too
+		if rejected {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var resp embeddingResponse
+		for i := range req.Input {
+			resp.Data = append(resp.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			}{Index: i, Embedding: []float64{float64(len(req.Input[i]))}})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, 1)
+	// 1200 requests a minute is one every 50ms
+	client.api.Limits = embedding.Limits{Limiter: embedding.NewRateLimiter(1200), BatchSize: 2}
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	embeddings, err := client.GetEmbeddings(context.Background(), texts)
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1}, {2}, {3}, {4}, {5}}, embeddings)
+
+	// Three batches, one of them retried
+	assert.Equal(t, [][]string{{"a", "bb"}, {"ccc", "dddd"}, {"ccc", "dddd"}, {"eeeee"}}, batches)
+	require.Len(t, requests, 4)
+	for i := 1; i < len(requests); i++ {
+		assert.GreaterOrEqual(t, requests[i].Sub(requests[i-1]), 45*time.Millisecond, "request %d", i)
+	}
+}
@@ -0,0 +1,101 @@
+package embedding
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// Constructor creates a provider from the embedding configuration. Its
+// requests are sent within limits.
+type Constructor func(config types.EmbeddingConfig, limits Limits) (Provider, error)
+
+var (
+	constructorsMu sync.RWMutex
+	constructors   = make(map[types.EmbeddingProvider]Constructor)
+)
+
+// Register makes a provider available to NewProvider under name. Provider
+// packages register themselves when they are imported, so a program
+// imports the providers it supports, usually for their side effect only.
+// Registering a name twice panics.
+func Register(name types.EmbeddingProvider, constructor Constructor) {
+	constructorsMu.Lock()
+	defer constructorsMu.Unlock()
+
+	if constructor == nil {
+		panic("embedding: Register constructor is nil")
+	}
+	if _, dup := constructors[name]; dup {
+		panic("embedding: Register called twice for provider " + string(name))
+	}
+	constructors[name] = constructor
+}
+
+// Registered returns the names of the registered providers, sorted
+func Registered() []types.EmbeddingProvider {
+	constructorsMu.RLock()
+	defer constructorsMu.RUnlock()
+
+	names := make([]types.EmbeddingProvider, 0, len(constructors))
+	for name := range constructors {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// NewProvider creates the provider selected by config.Provider, sending at
+// most config.RateLimitRPM requests a minute. The none provider is an
+// error, as is a provider whose embeddings don't have config.Dimensions
+// dimensions.
+func NewProvider(config types.EmbeddingConfig) (Provider, error) {
+	return NewProviderWithLimits(config, Limits{})
+}
+
+// NewProviderWithLimits is like NewProvider, but batches texts as limits
+// ask. A limits.Limiter, if set, replaces the one built from
+// config.RateLimitRPM.
+func NewProviderWithLimits(config types.EmbeddingConfig, limits Limits) (Provider, error) {
+	if config.Provider == "" || config.Provider == types.EmbeddingProviderNone {
+		return nil, fmt.Errorf("no embedding provider configured")
+	}
+	if config.RateLimitRPM < 0 {
+		return nil, fmt.Errorf("embedding rate limit cannot be negative")
+	}
+	if config.Dimensions < 0 {
+		return nil, fmt.Errorf("embedding dimensions cannot be negative")
+	}
+	if limits.Limiter == nil {
+		limits.Limiter = NewRateLimiter(config.RateLimitRPM)
+	}
+
+	constructorsMu.RLock()
+	constructor, ok := constructors[config.Provider]
+	constructorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("embedding provider %s not yet implemented", config.Provider)
+	}
+
+	provider, err := constructor(config, limits)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckDimensions(provider, config.Dimensions); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// CheckDimensions returns an error when provider is known to generate
+// embeddings of another size than the dimensions a backend is configured
+// for. Zero dimensions on either side aren't checked.
+func CheckDimensions(provider Provider, dimensions int) error {
+	size := provider.Dimensions()
+	if size == 0 || dimensions == 0 || size == dimensions {
+		return nil
+	}
+	return fmt.Errorf("embedding dimensions is %d, but the provider generates %d-dimensional embeddings", dimensions, size)
+}
@@ -0,0 +1,223 @@
+package embedding_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/cohere"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/huggingface"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/ollama"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/openai"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     types.EmbeddingConfig
+		want       embedding.Provider
+		model      string
+		dimensions int
+	}{
+		{
+			name: "openai",
+			config: types.EmbeddingConfig{
+				Provider: types.EmbeddingProviderOpenAI,
+				OpenAI:   types.OpenAIEmbeddingConfig{APIKey: "key", Model: "text-embedding-3-large"},
+			},
+			want:       &openai.Client{},
+			model:      "text-embedding-3-large",
+			dimensions: 3072,
+		},
+		{
+			name: "openai with shared model",
+			config: types.EmbeddingConfig{
+				Provider:   types.EmbeddingProviderOpenAI,
+				Model:      "text-embedding-3-small",
+				Dimensions: 1536,
+				OpenAI:     types.OpenAIEmbeddingConfig{APIKey: "key"},
+			},
+			want:       &openai.Client{},
+			model:      "text-embedding-3-small",
+			dimensions: 1536,
+		},
+		{
+			name: "openai-compatible model",
+			config: types.EmbeddingConfig{
+				Provider:   types.EmbeddingProviderOpenAI,
+				Model:      "nomic-embed-text",
+				Dimensions: 768,
+				OpenAI:     types.OpenAIEmbeddingConfig{APIKey: "key", BaseURL: "http://localhost:11434/v1"},
+			},
+			want:  &openai.Client{},
+			model: "nomic-embed-text",
+		},
+		{
+			name: "cohere",
+			config: types.EmbeddingConfig{
+				Provider:     types.EmbeddingProviderCohere,
+				Model:        "embed-english-light-v3.0",
+				RateLimitRPM: 100,
+				Cohere:       types.CohereEmbeddingConfig{APIKey: "key"},
+			},
+			want:       &cohere.Client{},
+			model:      "embed-english-light-v3.0",
+			dimensions: 384,
+		},
+		{
+			name: "azure",
+			config: types.EmbeddingConfig{
+				Provider: types.EmbeddingProviderAzure,
+				Model:    "text-embedding-3-large",
+				Azure: types.AzureEmbeddingConfig{
+					Endpoint:       "https://example.openai.azure.com",
+					APIKey:         "key",
+					DeploymentName: "embeddings",
+				},
+			},
+			want:       &openai.Client{},
+			model:      "text-embedding-3-large",
+			dimensions: 3072,
+		},
+		{
+			name: "huggingface",
+			config: types.EmbeddingConfig{
+				Provider:    types.EmbeddingProviderHugging,
+				Model:       "sentence-transformers/all-MiniLM-L6-v2",
+				HuggingFace: types.HuggingFaceEmbeddingConfig{APIKey: "key"},
+			},
+			want:       &huggingface.Client{},
+			model:      "sentence-transformers/all-MiniLM-L6-v2",
+			dimensions: 384,
+		},
+		{
+			name: "ollama",
+			config: types.EmbeddingConfig{
+				Provider: types.EmbeddingProviderOllama,
+				Ollama:   types.OllamaEmbeddingConfig{Model: "nomic-embed-text:latest"},
+			},
+			want:       &ollama.Client{},
+			model:      "nomic-embed-text:latest",
+			dimensions: 768,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := embedding.NewProvider(tt.config)
+			require.NoError(t, err)
+			require.IsType(t, tt.want, provider)
+			assert.Equal(t, tt.dimensions, provider.Dimensions())
+
+			model := provider.(interface{ Model() string }).Model()
+			assert.Equal(t, tt.model, model)
+		})
+	}
+}
+
+func TestNewProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config types.EmbeddingConfig
+		errMsg string
+	}{
+		{"unset", types.EmbeddingConfig{}, "no embedding provider configured"},
+		{"none", types.EmbeddingConfig{Provider: types.EmbeddingProviderNone}, "no embedding provider configured"},
+		{"unimplemented", types.EmbeddingConfig{Provider: types.EmbeddingProviderLocal}, "embedding provider local not yet implemented"},
+		{"unknown", types.EmbeddingConfig{Provider: "word2vec"}, "embedding provider word2vec not yet implemented"},
+		{
+			name:   "invalid provider config",
+			config: types.EmbeddingConfig{Provider: types.EmbeddingProviderOpenAI, Model: "text-embedding-3-small"},
+			errMsg: "openai API key cannot be empty",
+		},
+		{
+			name: "negative rate limit",
+			config: types.EmbeddingConfig{
+				Provider:     types.EmbeddingProviderCohere,
+				RateLimitRPM: -1,
+				Cohere:       types.CohereEmbeddingConfig{APIKey: "key", Model: "embed-english-v3.0"},
+			},
+			errMsg: "rate limit cannot be negative",
+		},
+		{
+			name: "dimension mismatch",
+			config: types.EmbeddingConfig{
+				Provider:   types.EmbeddingProviderCohere,
+				Dimensions: 1536,
+				Cohere:     types.CohereEmbeddingConfig{APIKey: "key", Model: "embed-english-v3.0"},
+			},
+			errMsg: "embedding dimensions is 1536, but the provider generates 1024-dimensional embeddings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := embedding.NewProvider(tt.config)
+			require.Error(t, err)
+			assert.Nil(t, provider)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+// staticProvider generates embeddings of a fixed size
+type staticProvider struct {
+	dimensions int
+}
+
+func (p staticProvider) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	return make([]float64, p.dimensions), nil
+}
+
+func (p staticProvider) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i := range texts {
+		embeddings[i] = make([]float64, p.dimensions)
+	}
+	return embeddings, nil
+}
+
+func (p staticProvider) Dimensions() int {
+	return p.dimensions
+}
+
+func TestRegister(t *testing.T) {
+	assert.Contains(t, embedding.Registered(), types.EmbeddingProviderOpenAI)
+	assert.Contains(t, embedding.Registered(), types.EmbeddingProviderCohere)
+	assert.Contains(t, embedding.Registered(), types.EmbeddingProviderAzure)
+	assert.Contains(t, embedding.Registered(), types.EmbeddingProviderHugging)
+	assert.Contains(t, embedding.Registered(), types.EmbeddingProviderOllama)
+
+	var got embedding.Limits
+	embedding.Register("static", func(config types.EmbeddingConfig, limits embedding.Limits) (embedding.Provider, error) {
+		got = limits
+		return staticProvider{dimensions: 8}, nil
+	})
+
+	provider, err := embedding.NewProviderWithLimits(types.EmbeddingConfig{Provider: "static", RateLimitRPM: 60}, embedding.Limits{BatchSize: 16})
+	require.NoError(t, err)
+	assert.Equal(t, staticProvider{dimensions: 8}, provider)
+	assert.Equal(t, 16, got.BatchSize)
+	assert.NotNil(t, got.Limiter, "the limiter is built from rate_limit_rpm")
+
+	_, err = embedding.NewProvider(types.EmbeddingConfig{Provider: "static", Dimensions: 4})
+	assert.ErrorContains(t, err, "provider generates 8-dimensional embeddings")
+
+	assert.Panics(t, func() {
+		embedding.Register(types.EmbeddingProviderOpenAI, func(types.EmbeddingConfig, embedding.Limits) (embedding.Provider, error) {
+			return nil, nil
+		})
+	})
+	assert.Panics(t, func() { embedding.Register("nil", nil) })
+}
+
+func TestCheckDimensions(t *testing.T) {
+	assert.NoError(t, embedding.CheckDimensions(staticProvider{dimensions: 3}, 3))
+	assert.NoError(t, embedding.CheckDimensions(staticProvider{dimensions: 3}, 0))
+	assert.NoError(t, embedding.CheckDimensions(staticProvider{}, 1536))
+	assert.Error(t, embedding.CheckDimensions(staticProvider{dimensions: 3}, 4))
+}
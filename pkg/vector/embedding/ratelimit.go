@@ -0,0 +1,68 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limits bounds the requests a provider sends to its API
+type Limits struct {
+	// Limiter, if set, is waited on before every request, retries included
+	Limiter *RateLimiter
+
+	// BatchSize is the most texts embedded in one request; larger calls
+	// are split into several requests. Zero means no limit.
+	BatchSize int
+}
+
+// RateLimiter spaces requests evenly to stay under a number of requests
+// per minute. It is safe for concurrent use, and a nil RateLimiter never
+// waits.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimiter returns a limiter allowing rpm requests per minute, or nil
+// when rpm is zero or less, meaning no limit
+func NewRateLimiter(rpm int) *RateLimiter {
+	if rpm <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Minute / time.Duration(rpm)}
+}
+
+// Wait blocks until the next request may be sent or ctx is done. The first
+// request is not delayed.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	// Reserve the next free slot
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0))
+	assert.Nil(t, NewRateLimiter(-5))
+	assert.Equal(t, time.Second, NewRateLimiter(60).interval)
+
+	// A nil limiter never waits
+	var limiter *RateLimiter
+	start := time.Now()
+	for range 100 {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestRateLimiter_Throttles(t *testing.T) {
+	// 1200 requests a minute is one every 50ms
+	limiter := NewRateLimiter(1200)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		sent []time.Time
+	)
+	start := time.Now()
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, limiter.Wait(context.Background()))
+			mu.Lock()
+			sent = append(sent, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// The first request goes at once and each later one waits its turn
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+	first := sent[0]
+	for _, at := range sent[1:] {
+		if at.Before(first) {
+			first = at
+		}
+	}
+	assert.Less(t, first.Sub(start), 30*time.Millisecond)
+}
+
+func TestRateLimiter_Cancel(t *testing.T) {
+	limiter := NewRateLimiter(1) // one request a minute
+	require.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
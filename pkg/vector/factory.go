@@ -4,6 +4,14 @@ import (
 	"fmt"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+	_ "github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/cohere"      // registers the cohere provider
+	_ "github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/huggingface" // registers the huggingface provider
+	_ "github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/ollama"      // registers the ollama provider
+	_ "github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/openai"      // registers the openai and azure providers
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/pinecone"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/qdrant"
 )
 
 // Factory creates vector search backends based on configuration
@@ -14,27 +22,56 @@ func NewFactory() *Factory {
 	return &Factory{}
 }
 
-// CreateVectorSearch creates a vector search backend based on the provided configuration
+// CreateVectorSearch creates a vector search backend based on the provided
+// configuration, or the no-op backend when vector search is disabled. When
+// config.Search.EnableReranking is set, the backend's results are reranked
+// before they're returned.
 func (f *Factory) CreateVectorSearch(config types.VectorSearchConfig) (types.VectorSearchBackend, error) {
-	if !config.Enabled {
+	if !config.Enabled || config.Type == types.VectorSearchTypeNone {
 		return NewNoneBackend(), nil
 	}
 
-	switch config.Type {
-	case types.VectorSearchTypeNone:
-		return NewNoneBackend(), nil
+	backend, err := NewBackend(&config)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Search.EnableReranking {
+		return backend, nil
+	}
+
+	reranker, err := NewReranker(config.Search)
+	if err != nil {
+		_ = backend.Close()
+		return nil, err
+	}
+	return NewRerankingBackend(backend, reranker, config.Search), nil
+}
+
+// NewBackend creates the vector search backend selected by cfg.Type,
+// together with the embedding provider it uses. Unlike CreateVectorSearch it
+// never falls back to the no-op backend: a missing configuration or the
+// none type is an error, so callers that need real vector search fail
+// clearly. Adding a backend means adding a case here.
+func NewBackend(cfg *types.VectorSearchConfig) (types.VectorSearchBackend, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("vector search is not configured")
+	}
+
+	switch cfg.Type {
+	case "", types.VectorSearchTypeNone:
+		return nil, fmt.Errorf("no vector search backend configured: set vector_search.type to one of local, pinecone, weaviate, chroma or qdrant")
 	case types.VectorSearchTypeLocal:
-		return NewLocalBackend(config)
+		return NewLocalBackend(*cfg)
 	case types.VectorSearchTypePinecone:
-		return NewPineconeBackend(config)
+		return NewPineconeBackend(*cfg)
 	case types.VectorSearchTypeWeaviate:
-		return NewWeaviateBackend(config)
+		return NewWeaviateBackend(*cfg)
 	case types.VectorSearchTypeChroma:
-		return NewChromaBackend(config)
+		return NewChromaBackend(*cfg)
 	case types.VectorSearchTypeQdrant:
-		return NewQdrantBackend(config)
+		return NewQdrantBackend(*cfg)
 	default:
-		return nil, fmt.Errorf("unsupported vector search type: %s", config.Type)
+		return nil, fmt.Errorf("unsupported vector search type: %s", cfg.Type)
 	}
 }
 
@@ -82,6 +119,7 @@ func (f *Factory) GetSupportedEmbeddingProviders() []types.EmbeddingProvider {
 		types.EmbeddingProviderAzure,
 		types.EmbeddingProviderHugging,
 		types.EmbeddingProviderCohere,
+		types.EmbeddingProviderOllama,
 		types.EmbeddingProviderLocal,
 	}
 }
@@ -121,6 +159,9 @@ func validatePineconeConfig(config types.PineconeConfig) error {
 	if config.IndexName == "" {
 		return fmt.Errorf("pinecone index name cannot be empty")
 	}
+	if config.ProjectID == "" {
+		return fmt.Errorf("pinecone project ID cannot be empty")
+	}
 	return nil
 }
 
@@ -160,6 +201,9 @@ func validateQdrantConfig(config types.QdrantConfig) error {
 	if config.CollectionName == "" {
 		return fmt.Errorf("qdrant collection name cannot be empty")
 	}
+	if config.DistanceMetric != "" && !contains([]string{"cosine", "euclidean", "dot"}, config.DistanceMetric) {
+		return fmt.Errorf("unsupported distance metric: %s (supported: [cosine euclidean dot])", config.DistanceMetric)
+	}
 	return nil
 }
 
@@ -180,16 +224,29 @@ func NewNoneBackend() types.VectorSearchBackend {
 	return &NoneBackend{}
 }
 
-// NewLocalBackend creates a local vector search backend
+// NewLocalBackend creates a local vector search backend that keeps vectors
+// in the file at config.Local.DatabasePath and embeds documents and queries
+// with the configured embedding provider
 func NewLocalBackend(config types.VectorSearchConfig) (types.VectorSearchBackend, error) {
-	// TODO: Implement in Session 6
-	return nil, fmt.Errorf("local vector search backend not yet implemented")
+	embedder, err := NewEmbedder(config)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := local.New(config, embedder)
+	if err != nil {
+		return nil, err
+	}
+	return backend, nil
 }
 
-// NewPineconeBackend creates a Pinecone vector search backend
+// NewPineconeBackend creates a Pinecone vector search backend that embeds
+// documents and queries with the configured embedding provider
 func NewPineconeBackend(config types.VectorSearchConfig) (types.VectorSearchBackend, error) {
-	// TODO: Implement in Session 6
-	return nil, fmt.Errorf("pinecone vector search backend not yet implemented")
+	embedder, err := NewEmbedder(config)
+	if err != nil {
+		return nil, err
+	}
+	return pinecone.New(config, embedder)
 }
 
 // NewWeaviateBackend creates a Weaviate vector search backend
@@ -204,10 +261,30 @@ func NewChromaBackend(config types.VectorSearchConfig) (types.VectorSearchBacken
 	return nil, fmt.Errorf("chroma vector search backend not yet implemented")
 }
 
-// NewQdrantBackend creates a Qdrant vector search backend
+// NewQdrantBackend creates a Qdrant vector search backend that embeds
+// documents and queries with the configured embedding provider
 func NewQdrantBackend(config types.VectorSearchConfig) (types.VectorSearchBackend, error) {
-	// TODO: Implement in Session 6
-	return nil, fmt.Errorf("qdrant vector search backend not yet implemented")
+	embedder, err := NewEmbedder(config)
+	if err != nil {
+		return nil, err
+	}
+	return qdrant.New(config, embedder)
+}
+
+// NewEmbedder creates a client for the configured embedding provider, or
+// returns nil when no provider is configured, in which case backends only
+// accept documents and queries that carry their own embeddings. The client
+// sends at most embedding.rate_limit_rpm requests a minute, each embedding
+// up to indexing.batch_size texts, and must generate embeddings of
+// embedding.dimensions dimensions when both are known.
+func NewEmbedder(vectorConfig types.VectorSearchConfig) (embedding.Provider, error) {
+	config := vectorConfig.Embedding
+	if config.Provider == "" || config.Provider == types.EmbeddingProviderNone {
+		return nil, nil
+	}
+	return embedding.NewProviderWithLimits(config, embedding.Limits{
+		BatchSize: max(vectorConfig.Indexing.BatchSize, 0),
+	})
 }
 
 // DefaultFactory is the default vector search factory instance
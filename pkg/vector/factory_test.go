@@ -1,11 +1,18 @@
 package vector
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/cohere"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding/openai"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/local"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/pinecone"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/qdrant"
 )
 
 func TestNewFactory(t *testing.T) {
@@ -42,22 +49,52 @@ func TestFactory_CreateVectorSearch(t *testing.T) {
 			wantErr:  false,
 		},
 		{
-			name: "local type - not implemented",
+			name: "local type - missing database path",
 			config: types.VectorSearchConfig{
 				Enabled: true,
 				Type:    types.VectorSearchTypeLocal,
 			},
 			wantErr: true,
-			errMsg:  "not yet implemented",
+			errMsg:  "database path cannot be empty",
 		},
 		{
-			name: "pinecone type - not implemented",
+			name: "pinecone type - missing API key",
 			config: types.VectorSearchConfig{
 				Enabled: true,
 				Type:    types.VectorSearchTypePinecone,
 			},
 			wantErr: true,
-			errMsg:  "not yet implemented",
+			errMsg:  "pinecone API key cannot be empty",
+		},
+		{
+			name: "pinecone type",
+			config: types.VectorSearchConfig{
+				Enabled:  true,
+				Type:     types.VectorSearchTypePinecone,
+				Pinecone: types.PineconeConfig{APIKey: "test-key", Environment: "us-west1-gcp", IndexName: "notes", ProjectID: "abc1234"},
+			},
+			wantType: types.VectorSearchTypePinecone,
+		},
+		{
+			name: "qdrant type",
+			config: types.VectorSearchConfig{
+				Enabled:   true,
+				Type:      types.VectorSearchTypeQdrant,
+				Embedding: types.EmbeddingConfig{Provider: types.EmbeddingProviderNone, Dimensions: 384},
+				Qdrant:    types.QdrantConfig{Host: "localhost", Port: 6333, CollectionName: "notes"},
+			},
+			wantType: types.VectorSearchTypeQdrant,
+		},
+		{
+			name: "qdrant type - unsupported embedding provider",
+			config: types.VectorSearchConfig{
+				Enabled:   true,
+				Type:      types.VectorSearchTypeQdrant,
+				Embedding: types.EmbeddingConfig{Provider: types.EmbeddingProviderLocal, Dimensions: 384},
+				Qdrant:    types.QdrantConfig{Host: "localhost", Port: 6333, CollectionName: "notes"},
+			},
+			wantErr: true,
+			errMsg:  "embedding provider local not yet implemented",
 		},
 		{
 			name: "invalid type",
@@ -155,6 +192,21 @@ func TestFactory_ValidateConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "unsupported local vector engine",
 		},
+		{
+			name: "invalid qdrant config - unsupported distance metric",
+			config: types.VectorSearchConfig{
+				Enabled: true,
+				Type:    types.VectorSearchTypeQdrant,
+				Qdrant: types.QdrantConfig{
+					Host:           "localhost",
+					Port:           6333,
+					CollectionName: "notes",
+					DistanceMetric: "manhattan",
+				},
+			},
+			wantErr: true,
+			errMsg:  "unsupported distance metric",
+		},
 	}
 
 	for _, tt := range tests {
@@ -193,6 +245,7 @@ func TestFactory_GetSupportedEmbeddingProviders(t *testing.T) {
 	assert.Contains(t, providers, types.EmbeddingProviderAzure)
 	assert.Contains(t, providers, types.EmbeddingProviderHugging)
 	assert.Contains(t, providers, types.EmbeddingProviderCohere)
+	assert.Contains(t, providers, types.EmbeddingProviderOllama)
 	assert.Contains(t, providers, types.EmbeddingProviderLocal)
 }
 
@@ -284,6 +337,7 @@ func TestValidatePineconeConfig(t *testing.T) {
 				APIKey:      "test-key",
 				Environment: "us-west1-gcp",
 				IndexName:   "test-index",
+				ProjectID:   "abc1234",
 			},
 			wantErr: false,
 		},
@@ -314,6 +368,16 @@ func TestValidatePineconeConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "index name cannot be empty",
 		},
+		{
+			name: "empty project ID",
+			config: types.PineconeConfig{
+				APIKey:      "test-key",
+				Environment: "us-west1-gcp",
+				IndexName:   "test-index",
+			},
+			wantErr: true,
+			errMsg:  "project ID cannot be empty",
+		},
 	}
 
 	for _, tt := range tests {
@@ -329,6 +393,125 @@ func TestValidatePineconeConfig(t *testing.T) {
 	}
 }
 
+func TestNewBackend(t *testing.T) {
+	qdrantConfig := types.QdrantConfig{Host: "localhost", Port: 6333, CollectionName: "notes"}
+
+	tests := []struct {
+		name   string
+		config *types.VectorSearchConfig
+		want   any
+		errMsg string
+	}{
+		{name: "nil config", config: nil, errMsg: "vector search is not configured"},
+		{name: "empty type", config: &types.VectorSearchConfig{}, errMsg: "no vector search backend configured"},
+		{name: "none", config: &types.VectorSearchConfig{Type: types.VectorSearchTypeNone}, errMsg: "no vector search backend configured"},
+		{name: "unknown", config: &types.VectorSearchConfig{Type: "milvus"}, errMsg: "unsupported vector search type: milvus"},
+		{name: "local without database path", config: &types.VectorSearchConfig{Type: types.VectorSearchTypeLocal}, errMsg: "local vector database path cannot be empty"},
+		{
+			name: "local",
+			config: &types.VectorSearchConfig{
+				Type:  types.VectorSearchTypeLocal,
+				Local: types.LocalVectorConfig{DatabasePath: filepath.Join(t.TempDir(), "vectors.json")},
+			},
+			want: &local.Backend{},
+		},
+		{name: "pinecone without API key", config: &types.VectorSearchConfig{Type: types.VectorSearchTypePinecone}, errMsg: "pinecone API key cannot be empty"},
+		{
+			name: "pinecone",
+			config: &types.VectorSearchConfig{
+				Type:     types.VectorSearchTypePinecone,
+				Pinecone: types.PineconeConfig{APIKey: "test-key", Environment: "us-west1-gcp", IndexName: "notes", ProjectID: "abc1234"},
+			},
+			want: &pinecone.Backend{},
+		},
+		{name: "weaviate", config: &types.VectorSearchConfig{Type: types.VectorSearchTypeWeaviate}, errMsg: "weaviate vector search backend not yet implemented"},
+		{name: "chroma", config: &types.VectorSearchConfig{Type: types.VectorSearchTypeChroma}, errMsg: "chroma vector search backend not yet implemented"},
+		{
+			name: "qdrant",
+			config: &types.VectorSearchConfig{
+				Type:      types.VectorSearchTypeQdrant,
+				Embedding: types.EmbeddingConfig{Dimensions: 384},
+				Qdrant:    qdrantConfig,
+			},
+			want: &qdrant.Backend{},
+		},
+		{
+			name: "qdrant with invalid config",
+			config: &types.VectorSearchConfig{
+				Type:      types.VectorSearchTypeQdrant,
+				Embedding: types.EmbeddingConfig{Dimensions: 384},
+			},
+			errMsg: "qdrant host cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend, err := NewBackend(tt.config)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				assert.Nil(t, backend)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, backend)
+			assert.NoError(t, backend.Close())
+		})
+	}
+}
+
+func TestNewEmbedder(t *testing.T) {
+	provider, err := NewEmbedder(types.VectorSearchConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, provider)
+
+	provider, err = NewEmbedder(types.VectorSearchConfig{Embedding: types.EmbeddingConfig{Provider: types.EmbeddingProviderNone}})
+	require.NoError(t, err)
+	assert.Nil(t, provider)
+
+	// The provider's own model wins, falling back to embedding.model
+	provider, err = NewEmbedder(types.VectorSearchConfig{Embedding: types.EmbeddingConfig{
+		Provider: types.EmbeddingProviderOpenAI,
+		Model:    "text-embedding-3-large",
+		OpenAI:   types.OpenAIEmbeddingConfig{APIKey: "key"},
+	}})
+	require.NoError(t, err)
+	require.IsType(t, &openai.Client{}, provider)
+	assert.Equal(t, "text-embedding-3-large", provider.(*openai.Client).Model())
+
+	_, err = NewEmbedder(types.VectorSearchConfig{Embedding: types.EmbeddingConfig{Provider: types.EmbeddingProviderOpenAI, Model: "m"}})
+	assert.ErrorContains(t, err, "API key cannot be empty")
+
+	provider, err = NewEmbedder(types.VectorSearchConfig{Embedding: types.EmbeddingConfig{
+		Provider: types.EmbeddingProviderCohere,
+		Model:    "embed-english-v3.0",
+		Cohere:   types.CohereEmbeddingConfig{APIKey: "key"},
+	}})
+	require.NoError(t, err)
+	require.IsType(t, &cohere.Client{}, provider)
+	assert.Equal(t, "embed-english-v3.0", provider.(*cohere.Client).Model())
+
+	// A model known to generate other dimensions is refused
+	_, err = NewEmbedder(types.VectorSearchConfig{Embedding: types.EmbeddingConfig{
+		Provider:   types.EmbeddingProviderOpenAI,
+		Model:      "text-embedding-3-small",
+		Dimensions: 384,
+		OpenAI:     types.OpenAIEmbeddingConfig{APIKey: "key"},
+	}})
+	assert.ErrorContains(t, err, "embedding dimensions is 384, but the provider generates 1536-dimensional embeddings")
+
+	_, err = NewEmbedder(types.VectorSearchConfig{Embedding: types.EmbeddingConfig{Provider: types.EmbeddingProviderLocal}})
+	assert.ErrorContains(t, err, "embedding provider local not yet implemented")
+
+	_, err = NewEmbedder(types.VectorSearchConfig{Embedding: types.EmbeddingConfig{
+		Provider:     types.EmbeddingProviderOpenAI,
+		RateLimitRPM: -1,
+		OpenAI:       types.OpenAIEmbeddingConfig{APIKey: "key", Model: "m"},
+	}})
+	assert.ErrorContains(t, err, "rate limit cannot be negative")
+}
+
 func TestDefaultFactory(t *testing.T) {
 	assert.NotNil(t, DefaultFactory)
 }
@@ -0,0 +1,389 @@
+// Package local implements a vector search backend that keeps documents and
+// their embeddings in a single file and searches them exhaustively. It
+// needs no server and suits vaults of up to a few tens of thousands of
+// chunks.
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// backend identifies the local backend in VectorSearchError values
+const backend = types.VectorSearchTypeLocal
+
+// defaultLimit is the number of results returned when a query sets none
+const defaultLimit = 10
+
+// fileVersion is bumped whenever the database file layout changes
+const fileVersion = 1
+
+// database is the on-disk form of the index
+type database struct {
+	Version    int               `json:"version"`
+	Dimensions int               `json:"dimensions"`
+	Documents  []*types.Document `json:"documents"`
+}
+
+// Backend stores documents with their embeddings in memory and writes them
+// to the database file after every change
+type Backend struct {
+	embedding.Embedder
+
+	path   string
+	metric string
+
+	mu         sync.RWMutex
+	dimensions int
+	documents  map[string]*types.Document
+}
+
+// New creates a local backend from the vector search configuration,
+// loading the database file at config.Local.DatabasePath if it exists.
+// Documents and queries without an embedding are embedded with embedder,
+// which may be nil when callers always supply their own vectors.
+func New(config types.VectorSearchConfig, embedder embedding.Provider) (*Backend, error) {
+	lc := config.Local
+	if lc.DatabasePath == "" {
+		return nil, fmt.Errorf("local vector database path cannot be empty")
+	}
+
+	metric := strings.ToLower(lc.DistanceMetric)
+	if metric == "" {
+		metric = "cosine"
+	}
+	if metric != "cosine" && metric != "euclidean" && metric != "dot" {
+		return nil, fmt.Errorf("unsupported distance metric: %s (supported: cosine, euclidean, dot)", lc.DistanceMetric)
+	}
+
+	b := &Backend{
+		path:       lc.DatabasePath,
+		metric:     metric,
+		Embedder:   embedding.Embedder{Backend: backend, Provider: embedder},
+		dimensions: config.Embedding.Dimensions,
+		documents:  make(map[string]*types.Document),
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Type returns the vector search backend type
+func (b *Backend) Type() types.VectorSearchType {
+	return backend
+}
+
+// Dimensions returns the size of the stored embeddings, or 0 when nothing
+// has been indexed and no size was configured
+func (b *Backend) Dimensions() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.dimensions
+}
+
+// Count returns the number of stored documents
+func (b *Backend) Count() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.documents)
+}
+
+// IndexDocument adds or updates a document
+func (b *Backend) IndexDocument(ctx context.Context, doc *types.Document) error {
+	return b.IndexDocuments(ctx, []*types.Document{doc})
+}
+
+// IndexDocuments adds or updates documents, embedding any that don't have
+// an embedding yet. Every embedding must have the same size as those
+// already stored.
+func (b *Backend) IndexDocuments(ctx context.Context, docs []*types.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	vectors, err := b.DocumentVectors(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dimensions := b.dimensions
+	for i, doc := range docs {
+		if dimensions == 0 {
+			dimensions = len(vectors[i])
+		}
+		if len(vectors[i]) != dimensions {
+			return b.newError("index", doc.ID, fmt.Errorf("embedding has %d dimensions, expected %d", len(vectors[i]), dimensions), false)
+		}
+	}
+
+	b.dimensions = dimensions
+	for i, doc := range docs {
+		stored := *doc
+		stored.Embedding = vectors[i]
+		b.documents[doc.ID] = &stored
+	}
+	return b.save("index")
+}
+
+// DeleteDocument removes a document. Deleting a document that isn't indexed
+// is not an error.
+func (b *Backend) DeleteDocument(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.documents[id]; !ok {
+		return nil
+	}
+	delete(b.documents, id)
+	return b.save("delete")
+}
+
+// Search returns the documents nearest to the query. query.Tags must all be
+// present on a document, ignoring case. query.Filters must each equal a
+// metadata field; the keys "title", "path" and "parent_id" compare those
+// document fields instead.
+func (b *Backend) Search(ctx context.Context, query *types.VectorQuery) (*types.VectorSearchResults, error) {
+	start := time.Now()
+
+	vector := query.QueryEmbedding
+	if len(vector) == 0 {
+		var err error
+		if vector, err = b.EmbedQuery(ctx, query.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.dimensions != 0 && len(vector) != b.dimensions {
+		return nil, b.newError("search", query.Query, fmt.Errorf("query embedding has %d dimensions, expected %d", len(vector), b.dimensions), false)
+	}
+
+	var results []*types.VectorSearchResult
+	for _, doc := range b.documents {
+		if !matchesQuery(doc, query) {
+			continue
+		}
+		result := b.resultOf(doc, vector, query)
+		if result.Score < query.MinScore {
+			continue
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Document.ID < results[j].Document.ID
+	})
+
+	total := len(results)
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return &types.VectorSearchResults{
+		Results:   results,
+		Total:     total,
+		QueryTime: time.Since(start),
+		Query:     query.Query,
+	}, nil
+}
+
+// Health checks that the database directory exists
+func (b *Backend) Health(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Dir(b.path)); err != nil {
+		return b.newError("health", "", err, false)
+	}
+	return nil
+}
+
+// Close releases nothing; every change is already saved
+func (b *Backend) Close() error {
+	return nil
+}
+
+// resultOf scores doc against vector. Cosine and dot scores are
+// similarities; Euclidean distances are converted so that a higher Score
+// is always more similar.
+func (b *Backend) resultOf(doc *types.Document, vector []float64, query *types.VectorQuery) *types.VectorSearchResult {
+	result := &types.VectorSearchResult{}
+	switch b.metric {
+	case "euclidean":
+		result.Distance = euclidean(doc.Embedding, vector)
+		result.Score = 1 / (1 + result.Distance)
+	case "dot":
+		result.Score = dot(doc.Embedding, vector)
+		result.Distance = 1 - result.Score
+	default:
+		result.Score = cosine(doc.Embedding, vector)
+		result.Distance = 1 - result.Score
+	}
+
+	copied := *doc
+	if !query.IncludeContent {
+		copied.Content = ""
+	}
+	if !query.IncludeEmbeddings {
+		copied.Embedding = nil
+	}
+	result.Document = &copied
+	return result
+}
+
+// load reads the database file, if there is one
+func (b *Backend) load() error {
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return b.newError("load", "", fmt.Errorf("failed to read vector database: %w", err), false)
+	}
+
+	var db database
+	if err := json.Unmarshal(data, &db); err != nil {
+		return b.newError("load", "", fmt.Errorf("failed to decode vector database %s: %w", b.path, err), false)
+	}
+	if db.Version != fileVersion {
+		return b.newError("load", "", fmt.Errorf("vector database %s has unsupported version %d", b.path, db.Version), false)
+	}
+	if b.dimensions != 0 && db.Dimensions != 0 && db.Dimensions != b.dimensions {
+		return b.newError("load", "", fmt.Errorf("vector database %s has %d dimensions, configured for %d", b.path, db.Dimensions, b.dimensions), false)
+	}
+
+	if db.Dimensions != 0 {
+		b.dimensions = db.Dimensions
+	}
+	for _, doc := range db.Documents {
+		b.documents[doc.ID] = doc
+	}
+	return nil
+}
+
+// save writes the database file through a temporary file, so a failed
+// write never leaves a truncated database. Callers hold b.mu.
+func (b *Backend) save(operation string) error {
+	docs := make([]*types.Document, 0, len(b.documents))
+	for _, doc := range b.documents {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+
+	data, err := json.Marshal(database{Version: fileVersion, Dimensions: b.dimensions, Documents: docs})
+	if err != nil {
+		return b.newError(operation, "", fmt.Errorf("failed to encode vector database: %w", err), false)
+	}
+
+	dir := filepath.Dir(b.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return b.newError(operation, "", fmt.Errorf("failed to create %s: %w", dir, err), false)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(b.path)+".tmp-*")
+	if err != nil {
+		return b.newError(operation, "", fmt.Errorf("failed to save vector database: %w", err), false)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return b.newError(operation, "", fmt.Errorf("failed to save vector database: %w", err), false)
+	}
+	if err := tmp.Close(); err != nil {
+		return b.newError(operation, "", fmt.Errorf("failed to save vector database: %w", err), false)
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return b.newError(operation, "", fmt.Errorf("failed to save vector database: %w", err), false)
+	}
+	return nil
+}
+
+// newError wraps err as a VectorSearchError
+func (b *Backend) newError(operation, query string, err error, retryable bool) error {
+	return types.NewVectorSearchError(backend, operation, query, err, retryable)
+}
+
+// matchesQuery reports whether doc has every tag and filter value of query
+func matchesQuery(doc *types.Document, query *types.VectorQuery) bool {
+	for _, tag := range query.Tags {
+		found := false
+		for _, t := range doc.Tags {
+			if strings.EqualFold(t, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for key, want := range query.Filters {
+		var got any
+		switch key {
+		case "title":
+			got = doc.Title
+		case "path":
+			got = doc.Path
+		case "parent_id":
+			got = doc.ParentID
+		default:
+			got = doc.Metadata[key]
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+func cosine(a, b []float64) float64 {
+	var dotProduct, normA, normB float64
+	for i := range a {
+		dotProduct += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func euclidean(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,209 @@
+package local
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// fakeEmbedder embeds each text as a fixed vector chosen by the test
+type fakeEmbedder struct {
+	vectors map[string][]float64
+	calls   int
+}
+
+func (f *fakeEmbedder) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := f.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (f *fakeEmbedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	f.calls++
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embeddings[i] = f.vectors[text]
+	}
+	return embeddings, nil
+}
+
+func (f *fakeEmbedder) Dimensions() int {
+	return 0
+}
+
+func newTestBackend(t *testing.T, path, metric string, embedder *fakeEmbedder) *Backend {
+	t.Helper()
+
+	config := types.VectorSearchConfig{
+		Local: types.LocalVectorConfig{DatabasePath: path, DistanceMetric: metric},
+	}
+	b, err := New(config, embedder)
+	require.NoError(t, err)
+	return b
+}
+
+func testEmbedder() *fakeEmbedder {
+	return &fakeEmbedder{vectors: map[string][]float64{
+		"cats":   {1, 0, 0},
+		"dogs":   {0, 1, 0},
+		"kitten": {0.9, 0.1, 0},
+		"birds":  {0, 0, 1},
+	}}
+}
+
+func resultIDs(results *types.VectorSearchResults) []string {
+	ids := make([]string, len(results.Results))
+	for i, r := range results.Results {
+		ids[i] = r.Document.ID
+	}
+	return ids
+}
+
+func TestBackend_IndexAndSearch(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vectors.json")
+	embedder := testEmbedder()
+	b := newTestBackend(t, path, "", embedder)
+
+	require.NoError(t, b.IndexDocuments(ctx, []*types.Document{
+		{ID: "cats", Content: "cats", Tags: []string{"Pets"}},
+		{ID: "dogs", Content: "dogs", Tags: []string{"pets"}},
+		{ID: "birds", Content: "birds", Metadata: map[string]any{"type": "wild"}},
+	}))
+	assert.Equal(t, 1, embedder.calls, "documents are embedded in one batch")
+	assert.Equal(t, 3, b.Count())
+	assert.Equal(t, 3, b.Dimensions())
+
+	results, err := b.Search(ctx, &types.VectorQuery{Query: "kitten", Limit: 2, IncludeContent: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cats", "dogs"}, resultIDs(results))
+	assert.Equal(t, 3, results.Total)
+	assert.Equal(t, "cats", results.Results[0].Document.Content)
+	assert.Nil(t, results.Results[0].Document.Embedding)
+	assert.InDelta(t, 1-results.Results[0].Score, results.Results[0].Distance, 1e-9)
+
+	results, err = b.Search(ctx, &types.VectorQuery{Query: "kitten", MinScore: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cats"}, resultIDs(results))
+	assert.Empty(t, results.Results[0].Document.Content)
+
+	results, err = b.Search(ctx, &types.VectorQuery{Query: "kitten", Tags: []string{"PETS"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cats", "dogs"}, resultIDs(results))
+
+	results, err = b.Search(ctx, &types.VectorQuery{QueryEmbedding: []float64{1, 0, 0}, Filters: map[string]any{"type": "wild"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"birds"}, resultIDs(results))
+
+	require.NoError(t, b.DeleteDocument(ctx, "cats"))
+	require.NoError(t, b.DeleteDocument(ctx, "missing"))
+	results, err = b.Search(ctx, &types.VectorQuery{Query: "kitten", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dogs"}, resultIDs(results))
+}
+
+func TestBackend_Persists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vault", "vectors.json")
+
+	b := newTestBackend(t, path, "", testEmbedder())
+	require.NoError(t, b.IndexDocument(ctx, &types.Document{ID: "cats", Title: "Cats", Content: "cats"}))
+	require.NoError(t, b.Close())
+
+	reopened := newTestBackend(t, path, "", testEmbedder())
+	assert.Equal(t, 1, reopened.Count())
+	assert.Equal(t, 3, reopened.Dimensions())
+
+	results, err := reopened.Search(ctx, &types.VectorQuery{Query: "cats", IncludeEmbeddings: true})
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, "Cats", results.Results[0].Document.Title)
+	assert.Equal(t, []float64{1, 0, 0}, results.Results[0].Document.Embedding)
+
+	_, err = New(types.VectorSearchConfig{
+		Local:     types.LocalVectorConfig{DatabasePath: path},
+		Embedding: types.EmbeddingConfig{Dimensions: 384},
+	}, nil)
+	assert.ErrorContains(t, err, "has 3 dimensions, configured for 384")
+}
+
+func TestBackend_Euclidean(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBackend(t, filepath.Join(t.TempDir(), "vectors.json"), "euclidean", testEmbedder())
+
+	require.NoError(t, b.IndexDocuments(ctx, []*types.Document{
+		{ID: "cats", Content: "cats"},
+		{ID: "birds", Content: "birds"},
+	}))
+
+	results, err := b.Search(ctx, &types.VectorQuery{Query: "cats"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cats", "birds"}, resultIDs(results))
+	assert.Equal(t, 1.0, results.Results[0].Score)
+	assert.Equal(t, 0.0, results.Results[0].Distance)
+	assert.InDelta(t, 1/(1+1.4142135), results.Results[1].Score, 1e-6)
+}
+
+func TestBackend_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := New(types.VectorSearchConfig{}, nil)
+	assert.ErrorContains(t, err, "database path cannot be empty")
+
+	_, err = New(types.VectorSearchConfig{Local: types.LocalVectorConfig{DatabasePath: "x", DistanceMetric: "manhattan"}}, nil)
+	assert.ErrorContains(t, err, "unsupported distance metric")
+
+	b := newTestBackend(t, filepath.Join(t.TempDir(), "vectors.json"), "", &fakeEmbedder{vectors: map[string][]float64{
+		"short": {1, 0},
+		"long":  {1, 0, 0},
+	}})
+	err = b.IndexDocuments(ctx, []*types.Document{{ID: "a", Content: "short"}, {ID: "b", Content: "long"}})
+	var vsErr *types.VectorSearchError
+	require.ErrorAs(t, err, &vsErr)
+	assert.Contains(t, err.Error(), "expected 2")
+	assert.Equal(t, 0, b.Count())
+
+	noEmbedder, err := New(types.VectorSearchConfig{Local: types.LocalVectorConfig{DatabasePath: filepath.Join(t.TempDir(), "vectors.json")}}, nil)
+	require.NoError(t, err)
+	_, err = noEmbedder.Search(ctx, &types.VectorQuery{Query: "cats"})
+	assert.ErrorContains(t, err, "no embedding provider configured")
+}
+
+// queryEmbedder embeds search queries apart from documents
+type queryEmbedder struct {
+	*fakeEmbedder
+	queries map[string][]float64
+}
+
+func (q *queryEmbedder) GetQueryEmbedding(ctx context.Context, query string) ([]float64, error) {
+	return q.queries[query], nil
+}
+
+func TestBackend_QueryEmbedding(t *testing.T) {
+	ctx := context.Background()
+	embedder := &queryEmbedder{
+		fakeEmbedder: testEmbedder(),
+		queries:      map[string][]float64{"cats": {0, 0, 1}},
+	}
+	b, err := New(types.VectorSearchConfig{
+		Local: types.LocalVectorConfig{DatabasePath: filepath.Join(t.TempDir(), "vectors.json")},
+	}, embedder)
+	require.NoError(t, err)
+
+	require.NoError(t, b.IndexDocuments(ctx, []*types.Document{
+		{ID: "cats", Content: "cats"},
+		{ID: "birds", Content: "birds"},
+	}))
+
+	// The query is embedded as a query, not as the document "cats"
+	results, err := b.Search(ctx, &types.VectorQuery{Query: "cats", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"birds"}, resultIDs(results))
+}
@@ -0,0 +1,170 @@
+package pinecone
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// metadataPrefix marks the vector metadata fields that hold document
+// metadata rather than document fields
+const metadataPrefix = "metadata."
+
+// vector is a record as upserted
+type vector struct {
+	ID       string         `json:"id"`
+	Values   []float64      `json:"values"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// upsertRequest is the body of a POST /vectors/upsert
+type upsertRequest struct {
+	Vectors   []vector `json:"vectors"`
+	Namespace string   `json:"namespace,omitempty"`
+}
+
+// deleteRequest is the body of a POST /vectors/delete
+type deleteRequest struct {
+	IDs       []string `json:"ids"`
+	Namespace string   `json:"namespace,omitempty"`
+}
+
+// queryRequest is the body of a POST /query
+type queryRequest struct {
+	Vector          []float64      `json:"vector"`
+	TopK            int            `json:"topK"`
+	Filter          map[string]any `json:"filter,omitempty"`
+	Namespace       string         `json:"namespace,omitempty"`
+	IncludeMetadata bool           `json:"includeMetadata"`
+	IncludeValues   bool           `json:"includeValues"`
+}
+
+// match is a query hit
+type match struct {
+	ID       string         `json:"id"`
+	Score    float64        `json:"score"`
+	Values   []float64      `json:"values,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// metadataOf returns the metadata stored with doc's vector. Pinecone
+// metadata is flat and holds only strings, numbers, booleans and lists of
+// strings, so timestamps are stored as RFC 3339 strings, document metadata
+// under metadataPrefix, and metadata values of other types as JSON strings.
+func metadataOf(doc *types.Document) map[string]any {
+	m := map[string]any{"id": doc.ID}
+	setString := func(key, value string) {
+		if value != "" {
+			m[key] = value
+		}
+	}
+	setString("title", doc.Title)
+	setString("path", doc.Path)
+	setString("content", doc.Content)
+	setString("parent_id", doc.ParentID)
+	if len(doc.Tags) > 0 {
+		m["tags"] = doc.Tags
+	}
+	if !doc.CreatedAt.IsZero() {
+		m["created_at"] = doc.CreatedAt.Format(time.RFC3339Nano)
+	}
+	if !doc.UpdatedAt.IsZero() {
+		m["updated_at"] = doc.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	if doc.ChunkIndex != 0 {
+		m["chunk_index"] = doc.ChunkIndex
+	}
+	if doc.ChunkSize != 0 {
+		m["chunk_size"] = doc.ChunkSize
+	}
+
+	for key, value := range doc.Metadata {
+		if value = metadataValue(value); value != nil {
+			m[metadataPrefix+key] = value
+		}
+	}
+	return m
+}
+
+// metadataValue converts a document metadata value to one Pinecone
+// accepts, or nil when it should be left out
+func metadataValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string, bool, int, int32, int64, float32, float64:
+		return v
+	case []string:
+		return v
+	case []any:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return jsonString(v)
+			}
+			strs = append(strs, s)
+		}
+		return strs
+	default:
+		return jsonString(v)
+	}
+}
+
+// jsonString encodes value as a JSON string, or returns nil when it can't
+// be encoded
+func jsonString(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// document rebuilds the document a vector's metadata was stored for
+func document(m map[string]any) *types.Document {
+	doc := &types.Document{
+		ID:         stringField(m, "id"),
+		Title:      stringField(m, "title"),
+		Path:       stringField(m, "path"),
+		Content:    stringField(m, "content"),
+		ParentID:   stringField(m, "parent_id"),
+		ChunkIndex: intField(m, "chunk_index"),
+		ChunkSize:  intField(m, "chunk_size"),
+		CreatedAt:  timeField(m, "created_at"),
+		UpdatedAt:  timeField(m, "updated_at"),
+	}
+	if tags, ok := m["tags"].([]any); ok {
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok {
+				doc.Tags = append(doc.Tags, s)
+			}
+		}
+	}
+	for key, value := range m {
+		if name, ok := strings.CutPrefix(key, metadataPrefix); ok {
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]any)
+			}
+			doc.Metadata[name] = value
+		}
+	}
+	return doc
+}
+
+func stringField(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func intField(m map[string]any, key string) int {
+	n, _ := m[key].(float64)
+	return int(n)
+}
+
+func timeField(m map[string]any, key string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, stringField(m, key))
+	return t
+}
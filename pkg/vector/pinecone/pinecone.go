@@ -0,0 +1,331 @@
+// Package pinecone implements a vector search backend on the Pinecone data
+// plane API.
+package pinecone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// backend identifies Pinecone in VectorSearchError values
+const backend = types.VectorSearchTypePinecone
+
+// defaultLimit is the number of results returned when a query sets none
+const defaultLimit = 10
+
+// upsertBatchSize is the most vectors sent in one upsert request, keeping
+// requests well under Pinecone's 2 MB limit
+const upsertBatchSize = 100
+
+// Backend stores documents as vectors in a Pinecone index, in the
+// configured namespace. Pinecone reports similarities as scores, so the
+// index should use the cosine or dotproduct metric.
+type Backend struct {
+	embedding.Embedder
+
+	api        *embedding.JSONClient
+	namespace  string
+	dimensions int
+}
+
+// New creates a Pinecone backend from the vector search configuration.
+// Requests go to the index host derived from the index name, project ID and
+// environment, which serves both serverless and pod-based indexes.
+// Documents and queries without an embedding are embedded with embedder,
+// which may be nil when callers always supply their own vectors.
+func New(config types.VectorSearchConfig, embedder embedding.Provider) (*Backend, error) {
+	pc := config.Pinecone
+	if pc.APIKey == "" {
+		return nil, fmt.Errorf("pinecone API key cannot be empty")
+	}
+	if pc.Environment == "" {
+		return nil, fmt.Errorf("pinecone environment cannot be empty")
+	}
+	if pc.IndexName == "" {
+		return nil, fmt.Errorf("pinecone index name cannot be empty")
+	}
+	if pc.ProjectID == "" {
+		return nil, fmt.Errorf("pinecone project ID cannot be empty")
+	}
+	if embedder != nil {
+		if err := embedding.CheckDimensions(embedder, config.Embedding.Dimensions); err != nil {
+			return nil, err
+		}
+	}
+
+	api := embedding.NewJSONClient(backend, IndexURL(pc), statusError)
+	api.Header.Set("Api-Key", pc.APIKey)
+
+	return &Backend{
+		api:        api,
+		namespace:  pc.Namespace,
+		dimensions: config.Embedding.Dimensions,
+		Embedder:   embedding.Embedder{Backend: backend, Provider: embedder},
+	}, nil
+}
+
+// IndexURL returns the base URL of the index's data plane API
+func IndexURL(config types.PineconeConfig) string {
+	return fmt.Sprintf("https://%s-%s.svc.%s.pinecone.io", config.IndexName, config.ProjectID, config.Environment)
+}
+
+// Type returns the vector search backend type
+func (b *Backend) Type() types.VectorSearchType {
+	return backend
+}
+
+// IndexDocument adds or updates a document
+func (b *Backend) IndexDocument(ctx context.Context, doc *types.Document) error {
+	return b.IndexDocuments(ctx, []*types.Document{doc})
+}
+
+// IndexDocuments upserts documents as vectors, embedding any that don't
+// have an embedding yet
+func (b *Backend) IndexDocuments(ctx context.Context, docs []*types.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	values, err := b.DocumentVectors(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	vectors := make([]vector, len(docs))
+	for i, doc := range docs {
+		if b.dimensions > 0 && len(values[i]) != b.dimensions {
+			return b.newError("index", doc.ID, fmt.Errorf("embedding has %d dimensions, expected %d", len(values[i]), b.dimensions), false)
+		}
+		vectors[i] = vector{ID: doc.ID, Values: values[i], Metadata: metadataOf(doc)}
+	}
+
+	for start := 0; start < len(vectors); start += upsertBatchSize {
+		batch := vectors[start:min(start+upsertBatchSize, len(vectors))]
+		request := upsertRequest{Vectors: batch, Namespace: b.namespace}
+		if err := b.api.Do(ctx, "index", "", http.MethodPost, "/vectors/upsert", request, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteDocument removes a document. Deleting a document that isn't indexed
+// is not an error.
+func (b *Backend) DeleteDocument(ctx context.Context, id string) error {
+	request := deleteRequest{IDs: []string{id}, Namespace: b.namespace}
+	return b.api.Do(ctx, "delete", id, http.MethodPost, "/vectors/delete", request, nil)
+}
+
+// Search returns the documents nearest to the query. query.Tags must all be
+// present on a document. query.Filters match document metadata: a scalar
+// must equal the field, a slice matches any of its values, and a map with
+// "gt", "gte", "lt" or "lte" keys is a numeric range. The keys "title",
+// "path" and "parent_id" filter those document fields instead. Results
+// scoring below query.MinScore are dropped.
+func (b *Backend) Search(ctx context.Context, query *types.VectorQuery) (*types.VectorSearchResults, error) {
+	start := time.Now()
+
+	vector := query.QueryEmbedding
+	if len(vector) == 0 {
+		var err error
+		if vector, err = b.EmbedQuery(ctx, query.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	filter, err := buildFilter(query)
+	if err != nil {
+		return nil, b.newError("search", query.Query, err, false)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	request := queryRequest{
+		Vector:          vector,
+		TopK:            limit,
+		Filter:          filter,
+		Namespace:       b.namespace,
+		IncludeMetadata: true,
+		IncludeValues:   query.IncludeEmbeddings,
+	}
+	var response struct {
+		Matches []match `json:"matches"`
+	}
+	if err := b.api.Do(ctx, "search", query.Query, http.MethodPost, "/query", request, &response); err != nil {
+		return nil, err
+	}
+
+	results := &types.VectorSearchResults{
+		Results: make([]*types.VectorSearchResult, 0, len(response.Matches)),
+		Query:   query.Query,
+	}
+	for _, m := range response.Matches {
+		if m.Score < query.MinScore {
+			continue
+		}
+		results.Results = append(results.Results, resultOf(m, query))
+	}
+	results.Total = len(results.Results)
+	results.QueryTime = time.Since(start)
+
+	return results, nil
+}
+
+// Health checks that the index is reachable with the configured API key
+func (b *Backend) Health(ctx context.Context) error {
+	return b.api.Do(ctx, "health", "", http.MethodPost, "/describe_index_stats", map[string]any{}, nil)
+}
+
+// Close releases idle connections
+func (b *Backend) Close() error {
+	b.api.HTTPClient.CloseIdleConnections()
+	return nil
+}
+
+// resultOf converts a query match to a search result
+func resultOf(m match, query *types.VectorQuery) *types.VectorSearchResult {
+	doc := document(m.Metadata)
+	if doc.ID == "" {
+		doc.ID = m.ID
+	}
+	if !query.IncludeContent {
+		doc.Content = ""
+	}
+	if query.IncludeEmbeddings {
+		doc.Embedding = m.Values
+	}
+	return &types.VectorSearchResult{Document: doc, Score: m.Score, Distance: 1 - m.Score}
+}
+
+// newError wraps err as a VectorSearchError
+func (b *Backend) newError(operation, query string, err error, retryable bool) error {
+	return types.NewVectorSearchError(backend, operation, query, err, retryable)
+}
+
+// statusError builds an error from a failed response, using the API's
+// error message when one is present. Pinecone reports errors either as
+// {"code": 3, "message": "..."} or as {"error": {"message": "..."}}.
+func statusError(resp *http.Response) error {
+	data := embedding.ReadErrorBody(resp)
+
+	var apiErr struct {
+		Message string `json:"message"`
+		Error   struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := strings.TrimSpace(string(data))
+	if err := json.Unmarshal(data, &apiErr); err == nil {
+		switch {
+		case apiErr.Error.Message != "":
+			message = apiErr.Error.Message
+		case apiErr.Message != "":
+			message = apiErr.Message
+		}
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return fmt.Errorf("status %d: %s", resp.StatusCode, message)
+}
+
+// documentFields are filter keys that refer to document fields rather than
+// metadata
+var documentFields = map[string]bool{"title": true, "path": true, "parent_id": true}
+
+// buildFilter translates the tag and metadata filters of query into a
+// Pinecone metadata filter, or nil when there are none
+func buildFilter(query *types.VectorQuery) (map[string]any, error) {
+	type condition struct {
+		field string
+		match map[string]any
+	}
+
+	var conditions []condition
+	for _, tag := range query.Tags {
+		conditions = append(conditions, condition{"tags", map[string]any{"$in": []string{tag}}})
+	}
+
+	for key, value := range query.Filters {
+		field := metadataPrefix + key
+		if documentFields[key] {
+			field = key
+		}
+
+		m, err := filterMatch(value)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", key, err)
+		}
+		conditions = append(conditions, condition{field, m})
+	}
+
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	// Map iteration order is random; keep requests deterministic
+	sort.SliceStable(conditions, func(i, j int) bool {
+		return conditions[i].field < conditions[j].field
+	})
+
+	and := make([]map[string]any, len(conditions))
+	for i, c := range conditions {
+		and[i] = map[string]any{c.field: c.match}
+	}
+	return map[string]any{"$and": and}, nil
+}
+
+// filterMatch builds the operators matching a field against value
+func filterMatch(value any) (map[string]any, error) {
+	switch v := value.(type) {
+	case string, bool, int, int64, float64:
+		return map[string]any{"$eq": v}, nil
+	case []string:
+		return map[string]any{"$in": v}, nil
+	case []any:
+		return map[string]any{"$in": v}, nil
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for op, bound := range v {
+			switch op {
+			case "gt", "gte", "lt", "lte":
+			default:
+				return nil, fmt.Errorf("unsupported range operator %q", op)
+			}
+			n, err := toFloat(bound)
+			if err != nil {
+				return nil, err
+			}
+			m["$"+op] = n
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// toFloat converts a numeric range bound to float64
+func toFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("range bound %v is not a number", value)
+	}
+}
@@ -0,0 +1,331 @@
+package pinecone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/vectortest"
+)
+
+// stubServer is a stand-in for the parts of the Pinecone data plane API the
+// backend uses. It records request bodies as raw JSON.
+type stubServer struct {
+	*vectortest.Server
+	t *testing.T
+
+	bodies  map[string][]string
+	matches []match
+}
+
+func newStubServer(t *testing.T) (*stubServer, *vectortest.Server) {
+	stub := &stubServer{t: t, bodies: make(map[string][]string)}
+	stub.Server = vectortest.NewServer(t, "Api-Key", "secret", stub.handle)
+	stub.FailBody = `{"code":8,"message":"stub failure","details":[]}`
+	return stub, stub.Server
+}
+
+func (s *stubServer) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(s.t, err)
+	s.bodies[r.URL.Path] = append(s.bodies[r.URL.Path], string(body))
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/vectors/upsert":
+		var request upsertRequest
+		require.NoError(s.t, json.Unmarshal(body, &request))
+		_ = json.NewEncoder(w).Encode(map[string]any{"upsertedCount": len(request.Vectors)})
+	case r.Method == http.MethodPost && r.URL.Path == "/vectors/delete":
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/query":
+		_ = json.NewEncoder(w).Encode(map[string]any{"matches": s.matches, "namespace": "kb"})
+	case r.Method == http.MethodPost && r.URL.Path == "/describe_index_stats":
+		_, _ = w.Write([]byte(`{"dimension":3,"totalVectorCount":0}`))
+	default:
+		s.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testConfig() types.VectorSearchConfig {
+	return types.VectorSearchConfig{
+		Enabled:   true,
+		Type:      types.VectorSearchTypePinecone,
+		Embedding: types.EmbeddingConfig{Dimensions: 3},
+		Pinecone: types.PineconeConfig{
+			APIKey:      "secret",
+			Environment: "us-east1-gcp",
+			IndexName:   "notes",
+			ProjectID:   "abc1234",
+			Namespace:   "kb",
+		},
+	}
+}
+
+// newTestBackend returns a backend sending its requests to serverURL
+func newTestBackend(t *testing.T, serverURL string, embedder embedding.Provider) *Backend {
+	t.Helper()
+
+	b, err := New(testConfig(), embedder)
+	require.NoError(t, err)
+	b.api.BaseURL = serverURL
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func TestNew(t *testing.T) {
+	b, err := New(testConfig(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://notes-abc1234.svc.us-east1-gcp.pinecone.io", b.api.BaseURL)
+	assert.Equal(t, "kb", b.namespace)
+	assert.Equal(t, types.VectorSearchTypePinecone, b.Type())
+
+	tests := []struct {
+		name   string
+		modify func(*types.VectorSearchConfig)
+		errMsg string
+	}{
+		{"missing API key", func(c *types.VectorSearchConfig) { c.Pinecone.APIKey = "" }, "API key cannot be empty"},
+		{"missing environment", func(c *types.VectorSearchConfig) { c.Pinecone.Environment = "" }, "environment cannot be empty"},
+		{"missing index", func(c *types.VectorSearchConfig) { c.Pinecone.IndexName = "" }, "index name cannot be empty"},
+		{"missing project", func(c *types.VectorSearchConfig) { c.Pinecone.ProjectID = "" }, "project ID cannot be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			tt.modify(&config)
+			_, err := New(config, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestBackend_IndexAndDelete(t *testing.T) {
+	stub, server := newStubServer(t)
+	embedder := &vectortest.Embedder{}
+	b := newTestBackend(t, server.URL, embedder)
+	ctx := context.Background()
+
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	docs := []*types.Document{
+		{
+			ID:        "01ABC",
+			Title:     "Go Basics",
+			Path:      "notes/01ABC.md",
+			Content:   "Variables and types",
+			Tags:      []string{"go"},
+			Metadata:  map[string]any{"type": "note", "links": map[string]any{"count": 2}},
+			CreatedAt: created,
+			Embedding: []float64{0.1, 0.2, 0.3},
+		},
+		{ID: "01DEF", Title: "Channels", Content: "Channels and goroutines", ParentID: "01XYZ", ChunkIndex: 2},
+	}
+	require.NoError(t, b.IndexDocuments(ctx, docs))
+
+	// Only the document without an embedding was embedded
+	assert.Equal(t, [][]string{{"Channels and goroutines"}}, embedder.Calls)
+
+	require.Len(t, stub.bodies["/vectors/upsert"], 1)
+	assert.JSONEq(t, `{
+		"namespace": "kb",
+		"vectors": [
+			{
+				"id": "01ABC",
+				"values": [0.1, 0.2, 0.3],
+				"metadata": {
+					"id": "01ABC",
+					"title": "Go Basics",
+					"path": "notes/01ABC.md",
+					"content": "Variables and types",
+					"tags": ["go"],
+					"created_at": "2024-03-01T10:00:00Z",
+					"metadata.type": "note",
+					"metadata.links": "{\"count\":2}"
+				}
+			},
+			{
+				"id": "01DEF",
+				"values": [0.5, 0.5, 0.5],
+				"metadata": {
+					"id": "01DEF",
+					"title": "Channels",
+					"content": "Channels and goroutines",
+					"parent_id": "01XYZ",
+					"chunk_index": 2
+				}
+			}
+		]
+	}`, stub.bodies["/vectors/upsert"][0])
+
+	require.NoError(t, b.DeleteDocument(ctx, "01ABC"))
+	require.Len(t, stub.bodies["/vectors/delete"], 1)
+	assert.JSONEq(t, `{"ids": ["01ABC"], "namespace": "kb"}`, stub.bodies["/vectors/delete"][0])
+
+	// Embeddings of the wrong size are rejected before any request
+	err := b.IndexDocument(ctx, &types.Document{ID: "bad", Embedding: []float64{1}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected 3")
+	assert.Len(t, stub.bodies["/vectors/upsert"], 1)
+}
+
+func TestBackend_IndexBatches(t *testing.T) {
+	stub, server := newStubServer(t)
+	b := newTestBackend(t, server.URL, &vectortest.Embedder{})
+
+	docs := make([]*types.Document, upsertBatchSize+1)
+	for i := range docs {
+		docs[i] = &types.Document{ID: "doc", Content: "text"}
+	}
+	require.NoError(t, b.IndexDocuments(context.Background(), docs))
+	assert.Len(t, stub.bodies["/vectors/upsert"], 2)
+}
+
+func TestBackend_IndexWithoutEmbedder(t *testing.T) {
+	_, server := newStubServer(t)
+	b := newTestBackend(t, server.URL, nil)
+
+	err := b.IndexDocument(context.Background(), &types.Document{ID: "01ABC", Content: "text"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no embedding provider configured")
+}
+
+func TestBackend_Search(t *testing.T) {
+	stub, server := newStubServer(t)
+	embedder := &vectortest.Embedder{}
+	b := newTestBackend(t, server.URL, embedder)
+	ctx := context.Background()
+
+	stub.matches = []match{
+		{ID: "01ABC", Score: 0.92, Metadata: map[string]any{
+			"id": "01ABC", "title": "Go Basics", "content": "Variables", "tags": []string{"go"},
+			"created_at": "2024-03-01T10:00:00Z", "metadata.type": "note",
+		}},
+		{ID: "01DEF", Score: 0.41, Metadata: map[string]any{"id": "01DEF", "title": "Channels"}},
+	}
+
+	results, err := b.Search(ctx, &types.VectorQuery{
+		Query:    "golang variables",
+		Limit:    5,
+		MinScore: 0.5,
+		Tags:     []string{"go"},
+		Filters:  map[string]interface{}{"type": "note", "priority": map[string]any{"gte": 2}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, stub.bodies["/query"], 1)
+	assert.JSONEq(t, `{
+		"vector": [0.5, 0.5, 0.5],
+		"topK": 5,
+		"namespace": "kb",
+		"includeMetadata": true,
+		"includeValues": false,
+		"filter": {"$and": [
+			{"metadata.priority": {"$gte": 2}},
+			{"metadata.type": {"$eq": "note"}},
+			{"tags": {"$in": ["go"]}}
+		]}
+	}`, stub.bodies["/query"][0])
+
+	// The low-scoring match is dropped and content is left out by default
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, 1, results.Total)
+	assert.Equal(t, "golang variables", results.Query)
+	hit := results.Results[0]
+	assert.Equal(t, "01ABC", hit.Document.ID)
+	assert.Equal(t, "Go Basics", hit.Document.Title)
+	assert.Equal(t, []string{"go"}, hit.Document.Tags)
+	assert.Equal(t, map[string]any{"type": "note"}, hit.Document.Metadata)
+	assert.True(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC).Equal(hit.Document.CreatedAt))
+	assert.Empty(t, hit.Document.Content)
+	assert.InDelta(t, 0.92, hit.Score, 1e-9)
+	assert.InDelta(t, 0.08, hit.Distance, 1e-9)
+
+	// A precomputed query embedding skips the embedder
+	embedder.Calls = nil
+	results, err = b.Search(ctx, &types.VectorQuery{QueryEmbedding: []float64{1, 0, 0}, IncludeContent: true, IncludeEmbeddings: true})
+	require.NoError(t, err)
+	assert.Empty(t, embedder.Calls)
+	assert.JSONEq(t, `{
+		"vector": [1, 0, 0],
+		"topK": 10,
+		"namespace": "kb",
+		"includeMetadata": true,
+		"includeValues": true
+	}`, stub.bodies["/query"][1])
+	require.Len(t, results.Results, 2)
+	assert.Equal(t, "Variables", results.Results[0].Document.Content)
+}
+
+func TestBuildFilter(t *testing.T) {
+	f, err := buildFilter(&types.VectorQuery{
+		Filters: map[string]interface{}{
+			"path":     "notes/01ABC.md",
+			"status":   []string{"draft", "review"},
+			"priority": map[string]any{"gte": 2, "lt": 5.5},
+			"pinned":   true,
+		},
+	})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"$and":[
+		{"metadata.pinned":{"$eq":true}},
+		{"metadata.priority":{"$gte":2,"$lt":5.5}},
+		{"metadata.status":{"$in":["draft","review"]}},
+		{"path":{"$eq":"notes/01ABC.md"}}
+	]}`, string(data))
+
+	f, err = buildFilter(&types.VectorQuery{})
+	require.NoError(t, err)
+	assert.Nil(t, f)
+
+	_, err = buildFilter(&types.VectorQuery{Filters: map[string]interface{}{"priority": map[string]any{"near": 1}}})
+	assert.Error(t, err)
+	_, err = buildFilter(&types.VectorQuery{Filters: map[string]interface{}{"created": time.Now()}})
+	assert.Error(t, err)
+}
+
+func TestBackend_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retryable bool
+	}{
+		{"server error", http.StatusServiceUnavailable, true},
+		{"rate limited", http.StatusTooManyRequests, true},
+		{"bad request", http.StatusBadRequest, false},
+		{"unauthorized", http.StatusUnauthorized, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, server := newStubServer(t)
+			stub.FailWith = tt.status
+			b := newTestBackend(t, server.URL, nil)
+
+			err := b.Health(context.Background())
+			var vectorErr *types.VectorSearchError
+			require.True(t, errors.As(err, &vectorErr), "err = %v", err)
+			assert.Equal(t, types.VectorSearchTypePinecone, vectorErr.Backend)
+			assert.Equal(t, "health", vectorErr.Operation)
+			assert.Equal(t, tt.retryable, vectorErr.IsRetryable())
+			assert.Contains(t, err.Error(), "stub failure")
+
+			_, err = b.Search(context.Background(), &types.VectorQuery{QueryEmbedding: []float64{1, 0, 0}})
+			require.True(t, errors.As(err, &vectorErr), "err = %v", err)
+			assert.Equal(t, "search", vectorErr.Operation)
+			assert.Equal(t, tt.retryable, vectorErr.IsRetryable())
+		})
+	}
+}
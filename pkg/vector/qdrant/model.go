@@ -0,0 +1,129 @@
+package qdrant
+
+import (
+	"sort"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// collectionInfo is the part of a GET /collections/{name} response that
+// describes the collection's vectors
+type collectionInfo struct {
+	Result struct {
+		Config struct {
+			Params struct {
+				Vectors struct {
+					Size     int    `json:"size"`
+					Distance string `json:"distance"`
+				} `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	} `json:"result"`
+}
+
+// point is a vector with its payload, as upserted
+type point struct {
+	ID      string    `json:"id"`
+	Vector  []float64 `json:"vector"`
+	Payload payload   `json:"payload"`
+}
+
+// scoredPoint is a search hit
+type scoredPoint struct {
+	ID      any       `json:"id"`
+	Score   float64   `json:"score"`
+	Payload payload   `json:"payload"`
+	Vector  []float64 `json:"vector,omitempty"`
+}
+
+// payload holds the document fields stored with each point
+type payload struct {
+	ID         string         `json:"id"`
+	Title      string         `json:"title,omitempty"`
+	Path       string         `json:"path,omitempty"`
+	Content    string         `json:"content,omitempty"`
+	Tags       []string       `json:"tags,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	ChunkIndex int            `json:"chunk_index,omitempty"`
+	ChunkSize  int            `json:"chunk_size,omitempty"`
+}
+
+// payloadOf returns the payload stored for doc
+func payloadOf(doc *types.Document) payload {
+	return payload{
+		ID:         doc.ID,
+		Title:      doc.Title,
+		Path:       doc.Path,
+		Content:    doc.Content,
+		Tags:       doc.Tags,
+		Metadata:   doc.Metadata,
+		CreatedAt:  doc.CreatedAt,
+		UpdatedAt:  doc.UpdatedAt,
+		ParentID:   doc.ParentID,
+		ChunkIndex: doc.ChunkIndex,
+		ChunkSize:  doc.ChunkSize,
+	}
+}
+
+// document rebuilds the document a payload was stored for
+func (p payload) document() *types.Document {
+	return &types.Document{
+		ID:         p.ID,
+		Title:      p.Title,
+		Path:       p.Path,
+		Content:    p.Content,
+		Tags:       p.Tags,
+		Metadata:   p.Metadata,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+		ParentID:   p.ParentID,
+		ChunkIndex: p.ChunkIndex,
+		ChunkSize:  p.ChunkSize,
+	}
+}
+
+// searchRequest is the body of a POST /collections/{name}/points/search
+type searchRequest struct {
+	Vector      []float64 `json:"vector"`
+	Limit       int       `json:"limit"`
+	Filter      *filter   `json:"filter,omitempty"`
+	WithPayload bool      `json:"with_payload"`
+	WithVector  bool      `json:"with_vector"`
+}
+
+// filter is a Qdrant filter whose conditions must all hold
+type filter struct {
+	Must []condition `json:"must"`
+}
+
+// condition matches a payload field against a value, a set of values or a
+// numeric range
+type condition struct {
+	Key   string      `json:"key"`
+	Match *match      `json:"match,omitempty"`
+	Range *valueRange `json:"range,omitempty"`
+}
+
+type match struct {
+	Value any   `json:"value,omitempty"`
+	Any   []any `json:"any,omitempty"`
+}
+
+type valueRange struct {
+	GT  *float64 `json:"gt,omitempty"`
+	GTE *float64 `json:"gte,omitempty"`
+	LT  *float64 `json:"lt,omitempty"`
+	LTE *float64 `json:"lte,omitempty"`
+}
+
+// sortConditions orders conditions by key, keeping the relative order of
+// conditions on the same key
+func sortConditions(conditions []condition) {
+	sort.SliceStable(conditions, func(i, j int) bool {
+		return conditions[i].Key < conditions[j].Key
+	})
+}
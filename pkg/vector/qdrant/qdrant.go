@@ -0,0 +1,423 @@
+// Package qdrant implements a vector search backend on the Qdrant REST API.
+package qdrant
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+)
+
+// backend identifies Qdrant in VectorSearchError values
+const backend = types.VectorSearchTypeQdrant
+
+// defaultLimit is the number of results returned when a query sets none
+const defaultLimit = 10
+
+// distances maps configured distance metrics to Qdrant distance names
+var distances = map[string]string{
+	"cosine":    "Cosine",
+	"euclidean": "Euclid",
+	"dot":       "Dot",
+}
+
+// Backend stores documents as points in a Qdrant collection. Qdrant point
+// IDs must be UUIDs or integers, so each document ID is mapped to a
+// name-based UUID and kept in the point's "id" payload field.
+type Backend struct {
+	embedding.Embedder
+
+	api        *embedding.JSONClient
+	collection string
+	dimensions int
+	distance   string
+
+	// ensureMu guards ensured, which records that the collection is known
+	// to exist
+	ensureMu sync.Mutex
+	ensured  bool
+}
+
+// New creates a Qdrant backend from the vector search configuration.
+// Documents and queries without an embedding are embedded with embedder,
+// which may be nil when callers always supply their own vectors.
+func New(config types.VectorSearchConfig, embedder embedding.Provider) (*Backend, error) {
+	qc := config.Qdrant
+	if qc.Host == "" {
+		return nil, fmt.Errorf("qdrant host cannot be empty")
+	}
+	if qc.Port <= 0 || qc.Port > 65535 {
+		return nil, fmt.Errorf("qdrant port must be between 1 and 65535")
+	}
+	if qc.CollectionName == "" {
+		return nil, fmt.Errorf("qdrant collection name cannot be empty")
+	}
+	if config.Embedding.Dimensions <= 0 {
+		return nil, fmt.Errorf("qdrant requires embedding dimensions")
+	}
+	if embedder != nil {
+		if err := embedding.CheckDimensions(embedder, config.Embedding.Dimensions); err != nil {
+			return nil, err
+		}
+	}
+
+	metric := strings.ToLower(qc.DistanceMetric)
+	if metric == "" {
+		metric = "cosine"
+	}
+	distance, ok := distances[metric]
+	if !ok {
+		return nil, fmt.Errorf("unsupported qdrant distance metric: %s (supported: cosine, euclidean, dot)", qc.DistanceMetric)
+	}
+
+	scheme := "http"
+	if qc.UseSSL {
+		scheme = "https"
+	}
+
+	api := embedding.NewJSONClient(backend, fmt.Sprintf("%s://%s:%d", scheme, qc.Host, qc.Port), statusError)
+	if qc.APIKey != "" {
+		api.Header.Set("api-key", qc.APIKey)
+	}
+
+	return &Backend{
+		api:        api,
+		collection: qc.CollectionName,
+		dimensions: config.Embedding.Dimensions,
+		distance:   distance,
+		Embedder:   embedding.Embedder{Backend: backend, Provider: embedder},
+	}, nil
+}
+
+// Type returns the vector search backend type
+func (b *Backend) Type() types.VectorSearchType {
+	return backend
+}
+
+// EnsureCollection creates the collection with the configured dimensions
+// and distance metric if it doesn't exist. An existing collection with a
+// different vector size is an error. Other methods call it on first use.
+func (b *Backend) EnsureCollection(ctx context.Context) error {
+	b.ensureMu.Lock()
+	defer b.ensureMu.Unlock()
+
+	if b.ensured {
+		return nil
+	}
+
+	var info collectionInfo
+	err := b.api.Do(ctx, "ensure_collection", "", http.MethodGet, b.collectionPath(""), nil, &info)
+	switch {
+	case err == nil:
+		if size := info.Result.Config.Params.Vectors.Size; size != 0 && size != b.dimensions {
+			return b.newError("ensure_collection", "", fmt.Errorf("collection %s has %d dimensions, configured for %d", b.collection, size, b.dimensions), false)
+		}
+	case isNotFound(err):
+		body := map[string]any{
+			"vectors": map[string]any{"size": b.dimensions, "distance": b.distance},
+		}
+		if err := b.api.Do(ctx, "ensure_collection", "", http.MethodPut, b.collectionPath(""), body, nil); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	b.ensured = true
+	return nil
+}
+
+// IndexDocument adds or updates a document
+func (b *Backend) IndexDocument(ctx context.Context, doc *types.Document) error {
+	return b.IndexDocuments(ctx, []*types.Document{doc})
+}
+
+// IndexDocuments upserts documents as points, embedding any that don't have
+// an embedding yet
+func (b *Backend) IndexDocuments(ctx context.Context, docs []*types.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := b.EnsureCollection(ctx); err != nil {
+		return err
+	}
+
+	vectors, err := b.DocumentVectors(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	points := make([]point, len(docs))
+	for i, doc := range docs {
+		if len(vectors[i]) != b.dimensions {
+			return b.newError("index", doc.ID, fmt.Errorf("embedding has %d dimensions, expected %d", len(vectors[i]), b.dimensions), false)
+		}
+		points[i] = point{ID: pointID(doc.ID), Vector: vectors[i], Payload: payloadOf(doc)}
+	}
+
+	return b.api.Do(ctx, "index", "", http.MethodPut, b.collectionPath("/points?wait=true"), map[string]any{"points": points}, nil)
+}
+
+// DeleteDocument removes a document. Deleting a document that isn't indexed
+// is not an error.
+func (b *Backend) DeleteDocument(ctx context.Context, id string) error {
+	if err := b.EnsureCollection(ctx); err != nil {
+		return err
+	}
+
+	body := map[string]any{"points": []string{pointID(id)}}
+	return b.api.Do(ctx, "delete", id, http.MethodPost, b.collectionPath("/points/delete?wait=true"), body, nil)
+}
+
+// Search returns the documents nearest to the query. query.Tags must all be
+// present on a document. query.Filters match document metadata: a scalar
+// must equal the field, a slice matches any of its values, and a map with
+// "gt", "gte", "lt" or "lte" keys is a numeric range. The keys "title",
+// "path" and "parent_id" filter those document fields instead.
+func (b *Backend) Search(ctx context.Context, query *types.VectorQuery) (*types.VectorSearchResults, error) {
+	start := time.Now()
+	if err := b.EnsureCollection(ctx); err != nil {
+		return nil, err
+	}
+
+	vector := query.QueryEmbedding
+	if len(vector) == 0 {
+		var err error
+		if vector, err = b.EmbedQuery(ctx, query.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	filter, err := buildFilter(query)
+	if err != nil {
+		return nil, b.newError("search", query.Query, err, false)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	request := searchRequest{
+		Vector:      vector,
+		Limit:       limit,
+		Filter:      filter,
+		WithPayload: true,
+		WithVector:  query.IncludeEmbeddings,
+	}
+	// Qdrant's score_threshold is a similarity for cosine and dot but a
+	// maximum distance for Euclid, so MinScore is applied to the converted
+	// scores instead
+	var response struct {
+		Result []scoredPoint `json:"result"`
+	}
+	if err := b.api.Do(ctx, "search", query.Query, http.MethodPost, b.collectionPath("/points/search"), request, &response); err != nil {
+		return nil, err
+	}
+
+	results := &types.VectorSearchResults{
+		Results: make([]*types.VectorSearchResult, 0, len(response.Result)),
+		Query:   query.Query,
+	}
+	for _, p := range response.Result {
+		result := b.resultOf(p, query)
+		if result.Score < query.MinScore {
+			continue
+		}
+		results.Results = append(results.Results, result)
+	}
+	results.Total = len(results.Results)
+	results.QueryTime = time.Since(start)
+
+	return results, nil
+}
+
+// Health checks that the Qdrant server is reachable
+func (b *Backend) Health(ctx context.Context) error {
+	return b.api.Do(ctx, "health", "", http.MethodGet, "/healthz", nil, nil)
+}
+
+// Close releases idle connections
+func (b *Backend) Close() error {
+	b.api.HTTPClient.CloseIdleConnections()
+	return nil
+}
+
+// resultOf converts a scored point to a search result. Cosine and dot
+// scores are similarities; Euclid scores are distances and are converted
+// so that a higher Score is always more similar.
+func (b *Backend) resultOf(p scoredPoint, query *types.VectorQuery) *types.VectorSearchResult {
+	doc := p.Payload.document()
+	if !query.IncludeContent {
+		doc.Content = ""
+	}
+	if query.IncludeEmbeddings {
+		doc.Embedding = p.Vector
+	}
+
+	result := &types.VectorSearchResult{Document: doc}
+	if b.distance == "Euclid" {
+		result.Distance = p.Score
+		result.Score = 1 / (1 + p.Score)
+	} else {
+		result.Score = p.Score
+		result.Distance = 1 - p.Score
+	}
+	return result
+}
+
+// collectionPath returns the API path of the collection followed by suffix
+func (b *Backend) collectionPath(suffix string) string {
+	return "/collections/" + url.PathEscape(b.collection) + suffix
+}
+
+// newError wraps err as a VectorSearchError
+func (b *Backend) newError(operation, query string, err error, retryable bool) error {
+	return types.NewVectorSearchError(backend, operation, query, err, retryable)
+}
+
+// httpStatusError is a non-2xx response from the API
+type httpStatusError struct {
+	code    int
+	message string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.code, e.message)
+}
+
+// statusError builds an error from a failed response, using the API's
+// error message when one is present
+func statusError(resp *http.Response) error {
+	data := embedding.ReadErrorBody(resp)
+
+	var apiErr struct {
+		Status struct {
+			Error string `json:"error"`
+		} `json:"status"`
+	}
+	message := strings.TrimSpace(string(data))
+	if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Status.Error != "" {
+		message = apiErr.Status.Error
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return &httpStatusError{code: resp.StatusCode, message: message}
+}
+
+// isNotFound reports whether err is a 404 response
+func isNotFound(err error) bool {
+	var statusErr *httpStatusError
+	return errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound
+}
+
+// pointID maps a document ID to a stable name-based (version 5) UUID
+func pointID(id string) string {
+	sum := sha1.Sum([]byte("kbvault:" + id))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// documentFields are filter keys that refer to document fields rather than
+// metadata
+var documentFields = map[string]bool{"title": true, "path": true, "parent_id": true}
+
+// buildFilter translates the tag and metadata filters of query into a
+// Qdrant filter, or nil when there are none
+func buildFilter(query *types.VectorQuery) (*filter, error) {
+	var must []condition
+	for _, tag := range query.Tags {
+		must = append(must, condition{Key: "tags", Match: &match{Value: tag}})
+	}
+
+	for key, value := range query.Filters {
+		field := "metadata." + key
+		if documentFields[key] {
+			field = key
+		}
+
+		c, err := filterCondition(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", key, err)
+		}
+		must = append(must, c)
+	}
+
+	if len(must) == 0 {
+		return nil, nil
+	}
+	// Map iteration order is random; keep requests deterministic
+	sortConditions(must)
+	return &filter{Must: must}, nil
+}
+
+// filterCondition builds the condition matching field against value
+func filterCondition(field string, value any) (condition, error) {
+	switch v := value.(type) {
+	case string, bool, int, int64:
+		return condition{Key: field, Match: &match{Value: v}}, nil
+	case float64:
+		if v == float64(int64(v)) {
+			return condition{Key: field, Match: &match{Value: int64(v)}}, nil
+		}
+		return condition{Key: field, Range: &valueRange{GTE: &v, LTE: &v}}, nil
+	case []string:
+		values := make([]any, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		return condition{Key: field, Match: &match{Any: values}}, nil
+	case []any:
+		return condition{Key: field, Match: &match{Any: v}}, nil
+	case map[string]any:
+		r := &valueRange{}
+		for op, bound := range v {
+			n, err := toFloat(bound)
+			if err != nil {
+				return condition{}, err
+			}
+			switch op {
+			case "gt":
+				r.GT = &n
+			case "gte":
+				r.GTE = &n
+			case "lt":
+				r.LT = &n
+			case "lte":
+				r.LTE = &n
+			default:
+				return condition{}, fmt.Errorf("unsupported range operator %q", op)
+			}
+		}
+		return condition{Key: field, Range: r}, nil
+	default:
+		return condition{}, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// toFloat converts a numeric range bound to float64
+func toFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("range bound %v is not a number", value)
+	}
+}
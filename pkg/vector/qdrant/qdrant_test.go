@@ -0,0 +1,396 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/embedding"
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/vector/vectortest"
+)
+
+// stubServer is an in-memory stand-in for the parts of the Qdrant REST API
+// the backend uses
+type stubServer struct {
+	*vectortest.Server
+	t *testing.T
+
+	collection map[string]any // nil until created
+	points     map[string]point
+	searches   []searchRequest
+	hits       []scoredPoint
+}
+
+func newStubServer(t *testing.T) (*stubServer, *vectortest.Server) {
+	stub := &stubServer{t: t, points: make(map[string]point)}
+	stub.Server = vectortest.NewServer(t, "api-key", "secret", stub.handle)
+	stub.FailBody = `{"status":{"error":"stub failure"}}`
+	return stub, stub.Server
+}
+
+func (s *stubServer) handle(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/healthz":
+		_, _ = w.Write([]byte("healthz check passed"))
+	case r.Method == http.MethodGet && r.URL.Path == "/collections/notes":
+		if s.collection == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":{"error":"Not found: Collection notes doesn't exist!"}}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"result": map[string]any{"config": map[string]any{"params": s.collection}},
+		})
+	case r.Method == http.MethodPut && r.URL.Path == "/collections/notes":
+		var body map[string]any
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&body))
+		s.collection = body
+		_, _ = w.Write([]byte(`{"result":true}`))
+	case r.Method == http.MethodPut && r.URL.Path == "/collections/notes/points":
+		assert.Equal(s.t, "true", r.URL.Query().Get("wait"))
+		var body struct {
+			Points []point `json:"points"`
+		}
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&body))
+		for _, p := range body.Points {
+			s.points[p.ID] = p
+		}
+		_, _ = w.Write([]byte(`{"result":{"status":"completed"}}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/collections/notes/points/delete":
+		var body struct {
+			Points []string `json:"points"`
+		}
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&body))
+		for _, id := range body.Points {
+			delete(s.points, id)
+		}
+		_, _ = w.Write([]byte(`{"result":{"status":"completed"}}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/collections/notes/points/search":
+		var body searchRequest
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&body))
+		s.searches = append(s.searches, body)
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": s.hits})
+	default:
+		s.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testConfig(t *testing.T, serverURL string) types.VectorSearchConfig {
+	t.Helper()
+
+	u, err := url.Parse(serverURL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	return types.VectorSearchConfig{
+		Enabled:   true,
+		Type:      types.VectorSearchTypeQdrant,
+		Embedding: types.EmbeddingConfig{Dimensions: 3},
+		Qdrant: types.QdrantConfig{
+			Host:           u.Hostname(),
+			Port:           port,
+			APIKey:         "secret",
+			CollectionName: "notes",
+		},
+	}
+}
+
+func newTestBackend(t *testing.T, config types.VectorSearchConfig, embedder embedding.Provider) *Backend {
+	t.Helper()
+
+	b, err := New(config, embedder)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func TestNew(t *testing.T) {
+	valid := types.VectorSearchConfig{
+		Embedding: types.EmbeddingConfig{Dimensions: 3},
+		Qdrant:    types.QdrantConfig{Host: "localhost", Port: 6333, CollectionName: "notes", UseSSL: true},
+	}
+
+	b, err := New(valid, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://localhost:6333", b.api.BaseURL)
+	assert.Equal(t, "Cosine", b.distance)
+	assert.Equal(t, types.VectorSearchTypeQdrant, b.Type())
+
+	tests := []struct {
+		name   string
+		modify func(*types.VectorSearchConfig)
+		errMsg string
+	}{
+		{"missing host", func(c *types.VectorSearchConfig) { c.Qdrant.Host = "" }, "host cannot be empty"},
+		{"bad port", func(c *types.VectorSearchConfig) { c.Qdrant.Port = 0 }, "port must be between"},
+		{"missing collection", func(c *types.VectorSearchConfig) { c.Qdrant.CollectionName = "" }, "collection name cannot be empty"},
+		{"missing dimensions", func(c *types.VectorSearchConfig) { c.Embedding.Dimensions = 0 }, "requires embedding dimensions"},
+		{"bad metric", func(c *types.VectorSearchConfig) { c.Qdrant.DistanceMetric = "manhattan" }, "unsupported qdrant distance metric"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+			_, err := New(config, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	// The embedder must produce vectors of the configured size
+	_, err = New(valid, &vectortest.Embedder{})
+	require.NoError(t, err)
+	valid.Embedding.Dimensions = 1536
+	_, err = New(valid, &vectortest.Embedder{})
+	assert.ErrorContains(t, err, "embedding dimensions is 1536, but the provider generates 3-dimensional embeddings")
+}
+
+func TestBackend_EnsureCollection(t *testing.T) {
+	stub, server := newStubServer(t)
+	config := testConfig(t, server.URL)
+	config.Qdrant.DistanceMetric = "dot"
+	b := newTestBackend(t, config, nil)
+	ctx := context.Background()
+
+	require.NoError(t, b.EnsureCollection(ctx))
+	require.NoError(t, b.EnsureCollection(ctx))
+
+	assert.Equal(t, []string{"GET /collections/notes", "PUT /collections/notes"}, stub.Requests())
+	assert.Equal(t, map[string]any{"vectors": map[string]any{"size": float64(3), "distance": "Dot"}}, stub.collection)
+
+	// An existing collection sized for other embeddings is refused
+	config.Embedding.Dimensions = 1536
+	err := newTestBackend(t, config, nil).EnsureCollection(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has 3 dimensions, configured for 1536")
+}
+
+func TestBackend_IndexAndDelete(t *testing.T) {
+	stub, server := newStubServer(t)
+	embedder := &vectortest.Embedder{}
+	b := newTestBackend(t, testConfig(t, server.URL), embedder)
+	ctx := context.Background()
+
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	docs := []*types.Document{
+		{
+			ID:        "01ABC",
+			Title:     "Go Basics",
+			Path:      "notes/01ABC.md",
+			Content:   "Variables and types",
+			Tags:      []string{"go"},
+			Metadata:  map[string]any{"type": "note"},
+			CreatedAt: created,
+			Embedding: []float64{0.1, 0.2, 0.3},
+		},
+		{ID: "01DEF", Title: "Channels", Content: "Channels and goroutines"},
+	}
+	require.NoError(t, b.IndexDocuments(ctx, docs))
+
+	// Only the document without an embedding was embedded
+	assert.Equal(t, [][]string{{"Channels and goroutines"}}, embedder.Calls)
+
+	require.Len(t, stub.points, 2)
+	stored := stub.points[pointID("01ABC")]
+	assert.Equal(t, []float64{0.1, 0.2, 0.3}, stored.Vector)
+	assert.Equal(t, "01ABC", stored.Payload.ID)
+	assert.Equal(t, "Go Basics", stored.Payload.Title)
+	assert.Equal(t, "notes/01ABC.md", stored.Payload.Path)
+	assert.Equal(t, []string{"go"}, stored.Payload.Tags)
+	assert.Equal(t, map[string]any{"type": "note"}, stored.Payload.Metadata)
+	assert.True(t, created.Equal(stored.Payload.CreatedAt))
+	assert.Equal(t, []float64{0.5, 0.5, 0.5}, stub.points[pointID("01DEF")].Vector)
+
+	require.NoError(t, b.DeleteDocument(ctx, "01ABC"))
+	assert.NotContains(t, stub.points, pointID("01ABC"))
+	assert.Contains(t, stub.points, pointID("01DEF"))
+
+	// Embeddings of the wrong size are rejected before any request
+	err := b.IndexDocument(ctx, &types.Document{ID: "bad", Embedding: []float64{1}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected 3")
+}
+
+func TestBackend_IndexWithoutEmbedder(t *testing.T) {
+	_, server := newStubServer(t)
+	b := newTestBackend(t, testConfig(t, server.URL), nil)
+
+	err := b.IndexDocument(context.Background(), &types.Document{ID: "01ABC", Content: "text"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no embedding provider configured")
+}
+
+func TestBackend_Search(t *testing.T) {
+	stub, server := newStubServer(t)
+	embedder := &vectortest.Embedder{}
+	b := newTestBackend(t, testConfig(t, server.URL), embedder)
+	ctx := context.Background()
+
+	stub.hits = []scoredPoint{
+		{ID: pointID("01ABC"), Score: 0.92, Payload: payload{ID: "01ABC", Title: "Go Basics", Content: "Variables", Tags: []string{"go"}}},
+		{ID: pointID("01DEF"), Score: 0.41, Payload: payload{ID: "01DEF", Title: "Channels"}},
+	}
+
+	results, err := b.Search(ctx, &types.VectorQuery{
+		Query:    "golang variables",
+		Limit:    5,
+		MinScore: 0.5,
+		Tags:     []string{"go"},
+		Filters:  map[string]interface{}{"type": "note"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, stub.searches, 1)
+	request := stub.searches[0]
+	assert.Equal(t, []float64{0.5, 0.5, 0.5}, request.Vector)
+	assert.Equal(t, 5, request.Limit)
+	assert.True(t, request.WithPayload)
+	assert.False(t, request.WithVector)
+	filterJSON, err := json.Marshal(request.Filter)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"must":[
+		{"key":"metadata.type","match":{"value":"note"}},
+		{"key":"tags","match":{"value":"go"}}
+	]}`, string(filterJSON))
+
+	// The low-scoring hit is dropped and content is left out by default
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, 1, results.Total)
+	assert.Equal(t, "golang variables", results.Query)
+	hit := results.Results[0]
+	assert.Equal(t, "01ABC", hit.Document.ID)
+	assert.Equal(t, "Go Basics", hit.Document.Title)
+	assert.Empty(t, hit.Document.Content)
+	assert.InDelta(t, 0.92, hit.Score, 1e-9)
+	assert.InDelta(t, 0.08, hit.Distance, 1e-9)
+
+	// A precomputed query embedding skips the embedder
+	embedder.Calls = nil
+	_, err = b.Search(ctx, &types.VectorQuery{QueryEmbedding: []float64{1, 0, 0}, IncludeContent: true})
+	require.NoError(t, err)
+	assert.Empty(t, embedder.Calls)
+	assert.Equal(t, defaultLimit, stub.searches[1].Limit)
+	assert.Nil(t, stub.searches[1].Filter)
+}
+
+func TestBackend_SearchEuclid(t *testing.T) {
+	stub, server := newStubServer(t)
+	config := testConfig(t, server.URL)
+	config.Qdrant.DistanceMetric = "euclidean"
+	b := newTestBackend(t, config, nil)
+
+	stub.hits = []scoredPoint{{ID: pointID("01ABC"), Score: 1, Payload: payload{ID: "01ABC"}}}
+	results, err := b.Search(context.Background(), &types.VectorQuery{QueryEmbedding: []float64{1, 0, 0}})
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.InDelta(t, 1.0, results.Results[0].Distance, 1e-9)
+	assert.InDelta(t, 0.5, results.Results[0].Score, 1e-9)
+}
+
+func TestBuildFilter(t *testing.T) {
+	f, err := buildFilter(&types.VectorQuery{
+		Filters: map[string]interface{}{
+			"path":     "notes/01ABC.md",
+			"status":   []string{"draft", "review"},
+			"priority": map[string]any{"gte": 2, "lt": 5.5},
+			"pinned":   true,
+			"version":  float64(3),
+		},
+	})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"must":[
+		{"key":"metadata.pinned","match":{"value":true}},
+		{"key":"metadata.priority","range":{"gte":2,"lt":5.5}},
+		{"key":"metadata.status","match":{"any":["draft","review"]}},
+		{"key":"metadata.version","match":{"value":3}},
+		{"key":"path","match":{"value":"notes/01ABC.md"}}
+	]}`, string(data))
+
+	f, err = buildFilter(&types.VectorQuery{})
+	require.NoError(t, err)
+	assert.Nil(t, f)
+
+	_, err = buildFilter(&types.VectorQuery{Filters: map[string]interface{}{"priority": map[string]any{"near": 1}}})
+	assert.Error(t, err)
+	_, err = buildFilter(&types.VectorQuery{Filters: map[string]interface{}{"created": time.Now()}})
+	assert.Error(t, err)
+}
+
+func TestBackend_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retryable bool
+	}{
+		{"server error", http.StatusServiceUnavailable, true},
+		{"rate limited", http.StatusTooManyRequests, true},
+		{"bad request", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, server := newStubServer(t)
+			stub.FailWith = tt.status
+			b := newTestBackend(t, testConfig(t, server.URL), nil)
+
+			err := b.Health(context.Background())
+			var vectorErr *types.VectorSearchError
+			require.True(t, errors.As(err, &vectorErr), "err = %v", err)
+			assert.Equal(t, types.VectorSearchTypeQdrant, vectorErr.Backend)
+			assert.Equal(t, "health", vectorErr.Operation)
+			assert.Equal(t, tt.retryable, vectorErr.IsRetryable())
+			assert.Contains(t, err.Error(), "stub failure")
+		})
+	}
+}
+
+func TestBackend_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	b := newTestBackend(t, testConfig(t, server.URL), nil)
+	b.api.HTTPClient.Timeout = 50 * time.Millisecond
+
+	err := b.Health(context.Background())
+	var vectorErr *types.VectorSearchError
+	require.True(t, errors.As(err, &vectorErr), "err = %v", err)
+	assert.True(t, vectorErr.IsRetryable())
+
+	// A cancelled caller isn't retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = b.Health(ctx)
+	require.True(t, errors.As(err, &vectorErr), "err = %v", err)
+	assert.False(t, vectorErr.IsRetryable())
+}
+
+func TestPointID(t *testing.T) {
+	id := pointID("01ABC")
+	assert.Equal(t, id, pointID("01ABC"))
+	assert.NotEqual(t, id, pointID("01ABD"))
+
+	parts := strings.Split(id, "-")
+	require.Len(t, parts, 5)
+	assert.Equal(t, []int{8, 4, 4, 4, 12}, []int{len(parts[0]), len(parts[1]), len(parts[2]), len(parts[3]), len(parts[4])})
+	assert.Equal(t, byte('5'), parts[2][0], "version 5 UUID")
+}
@@ -0,0 +1,344 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// RerankingModelRRF selects the built-in reciprocal rank fusion reranker
+const RerankingModelRRF = "rrf"
+
+// DefaultRRFK is the rank constant used by reciprocal rank fusion. Larger
+// values flatten the difference between neighbouring ranks.
+const DefaultRRFK = 60
+
+// rerankerBackend identifies rerankers in VectorSearchError values
+const rerankerBackend = types.VectorSearchType("reranker")
+
+// Reranker reorders the candidates returned by a vector search. Candidates
+// arrive in the backend's order, most similar first; the returned slice
+// replaces that order and carries the reranker's scores.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, candidates []*types.VectorSearchResult) ([]*types.VectorSearchResult, error)
+}
+
+// NewReranker creates the reranker selected by config.RerankingModel. An
+// empty model or "rrf" uses reciprocal rank fusion; any other model is
+// sent to config.RerankingEndpoint.
+func NewReranker(config types.SearchConfig) (Reranker, error) {
+	model := strings.TrimSpace(config.RerankingModel)
+	if model == "" || strings.EqualFold(model, RerankingModelRRF) {
+		return NewRRFReranker(DefaultRRFK, config.HybridWeight), nil
+	}
+	return NewEndpointReranker(config.RerankingEndpoint, config.RerankingAPIKey, model, 30*time.Second)
+}
+
+// RRFReranker fuses the vector ranking with a keyword ranking of the same
+// candidates using reciprocal rank fusion: each candidate scores
+// w/(k+rank) for its vector rank plus (1-w)/(k+rank) for its keyword rank.
+// Candidates that don't contain any query term get no keyword score.
+type RRFReranker struct {
+	k            int
+	vectorWeight float64
+}
+
+// NewRRFReranker creates a reciprocal rank fusion reranker. vectorWeight
+// balances the two rankings like SearchConfig.HybridWeight: 0.0 uses only
+// keyword ranks, 1.0 only vector ranks.
+func NewRRFReranker(k int, vectorWeight float64) *RRFReranker {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	vectorWeight = min(max(vectorWeight, 0), 1)
+	return &RRFReranker{k: k, vectorWeight: vectorWeight}
+}
+
+// Rerank orders candidates by their fused score. Scores are scaled so a
+// candidate ranked first by both rankings scores 1.0.
+func (r *RRFReranker) Rerank(ctx context.Context, query string, candidates []*types.VectorSearchResult) ([]*types.VectorSearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	terms := rerankTerms(query)
+	keyword := make([]int, len(candidates))
+	for i, c := range candidates {
+		keyword[i] = keywordScore(terms, c.Document)
+	}
+
+	// Keyword ranks, best first; candidates without a match stay unranked
+	order := make([]int, 0, len(candidates))
+	for i := range candidates {
+		if keyword[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return keyword[order[a]] > keyword[order[b]]
+	})
+	keywordRank := make(map[int]int, len(order))
+	for rank, i := range order {
+		keywordRank[i] = rank + 1
+	}
+
+	k := float64(r.k)
+	best := 1 / (k + 1)
+	reranked := make([]*types.VectorSearchResult, len(candidates))
+	for i, c := range candidates {
+		score := r.vectorWeight / (k + float64(i+1))
+		if rank, ok := keywordRank[i]; ok {
+			score += (1 - r.vectorWeight) / (k + float64(rank))
+		}
+
+		result := *c
+		result.Score = score / best
+		reranked[i] = &result
+	}
+
+	// Stable, so ties keep the vector order
+	sort.SliceStable(reranked, func(a, b int) bool {
+		return reranked[a].Score > reranked[b].Score
+	})
+	return reranked, nil
+}
+
+// rerankTerms splits text into lowercase words
+func rerankTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// keywordScore counts occurrences of terms in a document. Title matches
+// count twice.
+func keywordScore(terms []string, doc *types.Document) int {
+	if doc == nil || len(terms) == 0 {
+		return 0
+	}
+
+	count := func(text string) int {
+		n := 0
+		for _, word := range rerankTerms(text) {
+			for _, term := range terms {
+				if word == term {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	return 2*count(doc.Title) + count(doc.Content)
+}
+
+// EndpointReranker scores candidates with an external reranking service,
+// typically a cross-encoder. It POSTs
+//
+//	{"model": ..., "query": ..., "documents": [...], "top_n": n}
+//
+// and expects {"results": [{"index": i, "relevance_score": s}, ...]}, the
+// format used by Cohere, Jina and compatible rerank APIs.
+type EndpointReranker struct {
+	endpoint   string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// rerankRequest is the body sent to a reranking endpoint
+type rerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// rerankResponse is the body returned by a reranking endpoint
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// NewEndpointReranker creates a reranker that calls endpoint. apiKey is
+// sent as a bearer token when set.
+func NewEndpointReranker(endpoint, apiKey, model string, timeout time.Duration) (*EndpointReranker, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("reranking endpoint cannot be empty for model %q", model)
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &EndpointReranker{
+		endpoint:   endpoint,
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Rerank orders candidates by the relevance scores returned by the
+// endpoint. Candidates the endpoint leaves out follow in their original
+// order with a score of zero.
+func (r *EndpointReranker) Rerank(ctx context.Context, query string, candidates []*types.VectorSearchResult) ([]*types.VectorSearchResult, error) {
+	if len(candidates) == 0 {
+		return []*types.VectorSearchResult{}, nil
+	}
+
+	documents := make([]string, len(candidates))
+	for i, c := range candidates {
+		if c.Document != nil {
+			documents[i] = strings.TrimSpace(c.Document.Title + "\n\n" + c.Document.Content)
+		}
+	}
+
+	body, err := json.Marshal(rerankRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: documents,
+		TopN:      len(documents),
+	})
+	if err != nil {
+		return nil, r.newError(query, fmt.Errorf("failed to encode request: %w", err), false)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, r.newError(query, fmt.Errorf("failed to create request: %w", err), false)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, r.newError(query, fmt.Errorf("request failed: %w", err), ctx.Err() == nil)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		err := fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, r.newError(query, err, retryable)
+	}
+
+	var parsed rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, r.newError(query, fmt.Errorf("failed to decode response: %w", err), false)
+	}
+
+	reranked := make([]*types.VectorSearchResult, 0, len(candidates))
+	seen := make([]bool, len(candidates))
+	for _, item := range parsed.Results {
+		if item.Index < 0 || item.Index >= len(candidates) || seen[item.Index] {
+			return nil, r.newError(query, fmt.Errorf("invalid result index %d", item.Index), false)
+		}
+		seen[item.Index] = true
+
+		result := *candidates[item.Index]
+		result.Score = item.RelevanceScore
+		reranked = append(reranked, &result)
+	}
+	sort.SliceStable(reranked, func(a, b int) bool {
+		return reranked[a].Score > reranked[b].Score
+	})
+
+	for i, c := range candidates {
+		if !seen[i] {
+			result := *c
+			result.Score = 0
+			reranked = append(reranked, &result)
+		}
+	}
+	return reranked, nil
+}
+
+// newError wraps err as a VectorSearchError for the rerank operation
+func (r *EndpointReranker) newError(query string, err error, retryable bool) error {
+	return types.NewVectorSearchError(rerankerBackend, "rerank", query, err, retryable)
+}
+
+// RerankingBackend wraps a vector search backend and reranks its results.
+// Each search fetches up to SearchConfig.MaxLimit candidates, reranks them
+// and returns the top query.Limit.
+type RerankingBackend struct {
+	types.VectorSearchBackend
+	reranker Reranker
+	config   types.SearchConfig
+}
+
+// NewRerankingBackend wraps backend so its search results are reordered by
+// reranker
+func NewRerankingBackend(backend types.VectorSearchBackend, reranker Reranker, config types.SearchConfig) *RerankingBackend {
+	return &RerankingBackend{
+		VectorSearchBackend: backend,
+		reranker:            reranker,
+		config:              config,
+	}
+}
+
+// Search retrieves candidates from the wrapped backend and reranks them.
+// The limit defaults to SearchConfig.DefaultLimit and is capped at
+// SearchConfig.MaxLimit.
+func (b *RerankingBackend) Search(ctx context.Context, query *types.VectorQuery) (*types.VectorSearchResults, error) {
+	start := time.Now()
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = b.config.DefaultLimit
+	}
+	if b.config.MaxLimit > 0 && limit > b.config.MaxLimit {
+		limit = b.config.MaxLimit
+	}
+
+	// Rerankers need the text of each candidate and benefit from a pool
+	// larger than the final result
+	candidateQuery := *query
+	candidateQuery.Limit = max(b.config.MaxLimit, limit)
+	candidateQuery.IncludeContent = true
+
+	results, err := b.VectorSearchBackend.Search(ctx, &candidateQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	reranked, err := b.reranker.Rerank(ctx, query.Query, results.Results)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(reranked) > limit {
+		reranked = reranked[:limit]
+	}
+
+	if !query.IncludeContent {
+		for i, r := range reranked {
+			if r.Document != nil {
+				doc := *r.Document
+				doc.Content = ""
+				result := *r
+				result.Document = &doc
+				reranked[i] = &result
+			}
+		}
+	}
+
+	return &types.VectorSearchResults{
+		Results:   reranked,
+		Total:     results.Total,
+		QueryTime: time.Since(start),
+		Query:     query.Query,
+	}, nil
+}
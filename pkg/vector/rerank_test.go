@@ -0,0 +1,220 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/madstone-tech/mdstn-kb-mcp/pkg/types"
+)
+
+// candidates builds search results in the given order, most similar first
+func candidates(docs ...*types.Document) []*types.VectorSearchResult {
+	results := make([]*types.VectorSearchResult, len(docs))
+	for i, doc := range docs {
+		results[i] = &types.VectorSearchResult{Document: doc, Score: 0.9 - float64(i)*0.1}
+	}
+	return results
+}
+
+func resultIDs(results []*types.VectorSearchResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Document.ID
+	}
+	return ids
+}
+
+// stubBackend returns fixed results and records the last query
+type stubBackend struct {
+	NoneBackend
+	results   []*types.VectorSearchResult
+	lastQuery *types.VectorQuery
+}
+
+func (s *stubBackend) Search(ctx context.Context, query *types.VectorQuery) (*types.VectorSearchResults, error) {
+	s.lastQuery = query
+	results := s.results
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return &types.VectorSearchResults{Results: results, Total: len(s.results), Query: query.Query}, nil
+}
+
+func TestRRFReranker_Rerank(t *testing.T) {
+	docs := candidates(
+		&types.Document{ID: "a", Title: "Gardening", Content: "Soil and seeds"},
+		&types.Document{ID: "b", Title: "Cooking", Content: "Pasta recipes"},
+		&types.Document{ID: "c", Title: "Kubernetes", Content: "Deploying kubernetes clusters"},
+	)
+
+	t.Run("keyword matches move up", func(t *testing.T) {
+		reranked, err := NewRRFReranker(DefaultRRFK, 0.7).Rerank(context.Background(), "kubernetes", docs)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "a", "b"}, resultIDs(reranked))
+		assert.Greater(t, reranked[0].Score, reranked[1].Score)
+		assert.LessOrEqual(t, reranked[0].Score, 1.0)
+
+		// The candidates themselves are left alone
+		assert.Equal(t, []string{"a", "b", "c"}, resultIDs(docs))
+		assert.InDelta(t, 0.9, docs[0].Score, 1e-9)
+	})
+
+	t.Run("vector only keeps order", func(t *testing.T) {
+		reranked, err := NewRRFReranker(DefaultRRFK, 1).Rerank(context.Background(), "kubernetes", docs)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, resultIDs(reranked))
+		assert.InDelta(t, 1.0, reranked[0].Score, 1e-9)
+	})
+
+	t.Run("keyword only ranks by term count", func(t *testing.T) {
+		reranked, err := NewRRFReranker(DefaultRRFK, 0).Rerank(context.Background(), "pasta seeds soil", docs)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, resultIDs(reranked))
+		assert.Zero(t, reranked[2].Score)
+	})
+
+	t.Run("no candidates", func(t *testing.T) {
+		reranked, err := NewRRFReranker(0, 0.5).Rerank(context.Background(), "anything", nil)
+		require.NoError(t, err)
+		assert.Empty(t, reranked)
+	})
+}
+
+func TestEndpointReranker_Rerank(t *testing.T) {
+	var received rerankRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		// Return b, then c; a is left out
+		_, _ = w.Write([]byte(`{"results":[{"index":2,"relevance_score":0.4},{"index":1,"relevance_score":0.95}]}`))
+	}))
+	defer server.Close()
+
+	reranker, err := NewEndpointReranker(server.URL, "secret", "rerank-v1", 0)
+	require.NoError(t, err)
+
+	docs := candidates(
+		&types.Document{ID: "a", Title: "A", Content: "first"},
+		&types.Document{ID: "b", Title: "B", Content: "second"},
+		&types.Document{ID: "c", Title: "C", Content: "third"},
+	)
+	reranked, err := reranker.Rerank(context.Background(), "query", docs)
+	require.NoError(t, err)
+
+	assert.Equal(t, "rerank-v1", received.Model)
+	assert.Equal(t, "query", received.Query)
+	assert.Equal(t, []string{"A\n\nfirst", "B\n\nsecond", "C\n\nthird"}, received.Documents)
+	assert.Equal(t, 3, received.TopN)
+
+	assert.Equal(t, []string{"b", "c", "a"}, resultIDs(reranked))
+	assert.InDelta(t, 0.95, reranked[0].Score, 1e-9)
+	assert.Zero(t, reranked[2].Score)
+}
+
+func TestEndpointReranker_Errors(t *testing.T) {
+	_, err := NewEndpointReranker("", "", "rerank-v1", 0)
+	assert.Error(t, err)
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		retryable bool
+	}{
+		{"server error", http.StatusServiceUnavailable, "unavailable", true},
+		{"bad request", http.StatusBadRequest, "bad model", false},
+		{"invalid index", http.StatusOK, `{"results":[{"index":5,"relevance_score":1}]}`, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			reranker, err := NewEndpointReranker(server.URL, "", "rerank-v1", 0)
+			require.NoError(t, err)
+
+			_, err = reranker.Rerank(context.Background(), "query", candidates(&types.Document{ID: "a"}))
+			var vsErr *types.VectorSearchError
+			require.ErrorAs(t, err, &vsErr)
+			assert.Equal(t, "rerank", vsErr.Operation)
+			assert.Equal(t, tc.retryable, vsErr.IsRetryable())
+		})
+	}
+}
+
+func TestNewReranker(t *testing.T) {
+	r, err := NewReranker(types.SearchConfig{RerankingModel: "RRF", HybridWeight: 0.3})
+	require.NoError(t, err)
+	require.IsType(t, &RRFReranker{}, r)
+	assert.InDelta(t, 0.3, r.(*RRFReranker).vectorWeight, 1e-9)
+
+	r, err = NewReranker(types.SearchConfig{})
+	require.NoError(t, err)
+	assert.IsType(t, &RRFReranker{}, r)
+
+	r, err = NewReranker(types.SearchConfig{RerankingModel: "bge-reranker", RerankingEndpoint: "http://localhost:8080/rerank"})
+	require.NoError(t, err)
+	assert.IsType(t, &EndpointReranker{}, r)
+
+	_, err = NewReranker(types.SearchConfig{RerankingModel: "bge-reranker"})
+	assert.Error(t, err)
+}
+
+func TestRerankingBackend_Search(t *testing.T) {
+	docs := make([]*types.Document, 0, 6)
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		docs = append(docs, &types.Document{ID: id, Content: "about " + id})
+	}
+	backend := &stubBackend{results: candidates(docs...)}
+
+	config := types.SearchConfig{DefaultLimit: 2, MaxLimit: 5}
+	rerank := NewRerankingBackend(backend, NewRRFReranker(DefaultRRFK, 0.5), config)
+
+	t.Run("reranks the candidate pool", func(t *testing.T) {
+		results, err := rerank.Search(context.Background(), &types.VectorQuery{Query: "e", Limit: 3})
+		require.NoError(t, err)
+
+		// Candidates are fetched up to MaxLimit, with content
+		assert.Equal(t, 5, backend.lastQuery.Limit)
+		assert.True(t, backend.lastQuery.IncludeContent)
+
+		assert.Equal(t, []string{"e", "a", "b"}, resultIDs(results.Results))
+		assert.Equal(t, 6, results.Total)
+		for _, r := range results.Results {
+			assert.Empty(t, r.Document.Content, "content was not requested")
+		}
+		assert.Equal(t, "about e", docs[4].Content)
+	})
+
+	t.Run("default limit", func(t *testing.T) {
+		results, err := rerank.Search(context.Background(), &types.VectorQuery{Query: "c", IncludeContent: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "a"}, resultIDs(results.Results))
+		assert.Equal(t, "about c", results.Results[0].Document.Content)
+	})
+
+	t.Run("limit capped at max", func(t *testing.T) {
+		results, err := rerank.Search(context.Background(), &types.VectorQuery{Query: "f", Limit: 50})
+		require.NoError(t, err)
+		assert.Equal(t, 5, backend.lastQuery.Limit)
+		assert.Len(t, results.Results, 5)
+		assert.NotContains(t, resultIDs(results.Results), "f", "f is outside the candidate pool")
+	})
+
+	t.Run("backend errors", func(t *testing.T) {
+		failing := NewRerankingBackend(NewNoneBackend(), NewRRFReranker(DefaultRRFK, 0.5), config)
+		_, err := failing.Search(context.Background(), &types.VectorQuery{Query: "a"})
+		assert.Error(t, err)
+	})
+}
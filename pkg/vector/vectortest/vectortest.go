@@ -0,0 +1,95 @@
+// Package vectortest provides a stub HTTP API and a fake embedding
+// provider for tests of vector search backends.
+package vectortest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Server is a stub vector database API. It records each request, checks
+// that it carries the expected API key, and answers with FailWith and
+// FailBody when FailWith is set, or with Handle otherwise. Handle is called
+// with the server locked, so it may update state shared with the test.
+type Server struct {
+	URL string
+
+	t      testing.TB
+	header string
+	apiKey string
+	handle http.HandlerFunc
+
+	mu       sync.Mutex
+	requests []string
+
+	// FailWith, when not zero, is the status of every response, with
+	// FailBody as its body
+	FailWith int
+	FailBody string
+}
+
+// NewServer serves handle until the test ends, expecting apiKey in the
+// header named header on every request
+func NewServer(t testing.TB, header, apiKey string, handle http.HandlerFunc) *Server {
+	t.Helper()
+
+	s := &Server{t: t, header: header, apiKey: apiKey, handle: handle}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	s.URL = server.URL
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	assert.Equal(s.t, s.apiKey, r.Header.Get(s.header))
+
+	if s.FailWith != 0 {
+		w.WriteHeader(s.FailWith)
+		_, _ = w.Write([]byte(s.FailBody))
+		return
+	}
+	s.handle(w, r)
+}
+
+// Requests returns "METHOD path" for each request received so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Embedder is an embedding provider that returns the same 3-dimensional
+// vector for every text and records the batches it embeds
+type Embedder struct {
+	Calls [][]string
+}
+
+func (e *Embedder) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := e.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *Embedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	e.Calls = append(e.Calls, texts)
+	embeddings := make([][]float64, len(texts))
+	for i := range texts {
+		embeddings[i] = []float64{0.5, 0.5, 0.5}
+	}
+	return embeddings, nil
+}
+
+func (e *Embedder) Dimensions() int {
+	return 3
+}